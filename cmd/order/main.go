@@ -6,24 +6,38 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/grafana/pyroscope-go"
 	"github.com/opentracing/opentracing-go"
+	"github.com/redis/go-redis/v9"
 	"go.uber.org/fx"
 	"go.uber.org/fx/fxevent"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"gorm.io/gorm"
+	"io"
 	"net"
 	"net/http"
 	"time"
 
 	orderv1 "go-bootiful-ordering/gen/order/v1"
+	productv1 "go-bootiful-ordering/gen/product/v1"
 	orderHandler "go-bootiful-ordering/internal/order/handler"
 	orderRepository "go-bootiful-ordering/internal/order/repository"
 	orderService "go-bootiful-ordering/internal/order/service"
+	"go-bootiful-ordering/internal/pkg/auth"
+	"go-bootiful-ordering/internal/pkg/cache"
 	"go-bootiful-ordering/internal/pkg/config"
+	"go-bootiful-ordering/internal/pkg/grpcclient"
+	"go-bootiful-ordering/internal/pkg/grpcserver"
 	"go-bootiful-ordering/internal/pkg/health"
+	"go-bootiful-ordering/internal/pkg/idgen"
+	"go-bootiful-ordering/internal/pkg/limit"
 	"go-bootiful-ordering/internal/pkg/metrics"
 	"go-bootiful-ordering/internal/pkg/migrate"
+	"go-bootiful-ordering/internal/pkg/paging"
 	"go-bootiful-ordering/internal/pkg/profiling"
+	"go-bootiful-ordering/internal/pkg/requestid"
+	pkgRoutes "go-bootiful-ordering/internal/pkg/routes"
+	"go-bootiful-ordering/internal/pkg/tenant"
+	"go-bootiful-ordering/internal/pkg/timefmt"
 	"go-bootiful-ordering/internal/pkg/tracing"
 )
 
@@ -35,20 +49,40 @@ type Route interface {
 }
 
 // NewGinEngine creates a new gin.Engine with the given routes
-func NewGinEngine(routes []Route, tracer opentracing.Tracer) *gin.Engine {
+func NewGinEngine(routes []Route, tracer opentracing.Tracer, log *zap.Logger, cfg *config.Config, filter *pkgRoutes.Filter, limiter *limit.Limiter, tenants *tenant.Resolver, checker *health.Checker, m *metrics.Metrics) *gin.Engine {
 	r := gin.Default()
 
+	// Trailing slashes are stripped before routing (see NewHTTPServer) instead of redirected
+	r.RedirectTrailingSlash = false
+
+	// Tag the request with its request ID first, so every later middleware can log it
+	r.Use(requestid.GinMiddleware())
+
 	// Add OpenTracing middleware
 	r.Use(tracing.GinMiddleware(tracer))
 
+	// Tag the request with its tenant
+	r.Use(tenants.GinMiddleware())
+
 	// Add Prometheus middleware
-	r.Use(metrics.GinMiddleware())
+	r.Use(m.GinMiddleware(log, cfg.Server.HTTP.SlowThreshold()))
 
 	// Register metrics endpoint
 	metrics.RegisterMetricsEndpoint(r)
 
-	// Register health check endpoint
-	health.RegisterHealthEndpoint(r)
+	// Register the slow request triage endpoint when debugging is enabled
+	if cfg.Debug.Enabled {
+		metrics.RegisterSlowRequestsEndpoint(r, m.SlowRequests)
+	}
+
+	// Register liveness and readiness endpoints
+	health.RegisterHealthEndpoint(r, checker)
+
+	// Reject routes disabled by configuration
+	r.Use(filter.GinMiddleware())
+
+	// Cap concurrent requests
+	r.Use(limiter.GinMiddleware())
 
 	// Create a router group for API routes
 	apiGroup := r.Group("")
@@ -63,28 +97,344 @@ func NewGinEngine(routes []Route, tracer opentracing.Tracer) *gin.Engine {
 
 func NewHTTPServer(engine *gin.Engine, cfg *config.Config) *http.Server {
 	return &http.Server{
-		Addr:    ":" + cfg.Server.HTTP.Port,
-		Handler: engine,
+		Addr:    cfg.Server.HTTP.Addr(),
+		Handler: pkgRoutes.StripTrailingSlash(health.Healthz(engine)),
 	}
 }
 
 // NewGRPCServer creates a new gRPC server
-func NewGRPCServer(orderServer *orderHandler.GRPCOrderServer, tracer opentracing.Tracer) *grpc.Server {
+func NewGRPCServer(orderServer *orderHandler.GRPCOrderServer, tracer opentracing.Tracer, log *zap.Logger, cfg *config.Config, filter *pkgRoutes.Filter, limiter *limit.Limiter, tenants *tenant.Resolver, checker *health.Checker, m *metrics.Metrics, guard *auth.AdminGuard) *grpc.Server {
 	// Chain the tracing and metrics interceptors
 	chainedInterceptor := grpc.ChainUnaryInterceptor(
+		requestid.UnaryServerInterceptor(),
 		tracing.UnaryServerInterceptor(tracer),
-		metrics.UnaryServerInterceptor(),
+		tenants.UnaryServerInterceptor(),
+		m.UnaryServerInterceptor(log, cfg.Server.GRPC.SlowThreshold()),
+		filter.UnaryServerInterceptor(),
+		limiter.UnaryServerInterceptor(),
+		guard.UnaryServerInterceptor(),
 	)
 
 	server := grpc.NewServer(chainedInterceptor)
 	orderv1.RegisterOrderServiceServer(server, orderServer)
 
 	// Register health check service
-	health.RegisterHealthServer(server)
+	health.RegisterHealthServer(server, checker)
 
 	return server
 }
 
+// NewHealthChecker creates the readiness checker from the dependency checks the service mode provides
+func NewHealthChecker(checks []health.Check) *health.Checker {
+	return health.NewChecker(health.DefaultCheckTimeout, checks...)
+}
+
+// NewRouteFilter creates the filter rejecting routes disabled by configuration
+func NewRouteFilter(cfg *config.Config) *pkgRoutes.Filter {
+	return pkgRoutes.NewFilter(cfg.Routes.Disabled)
+}
+
+// NewConcurrencyLimiter creates the limiter shared by the HTTP and gRPC servers
+func NewConcurrencyLimiter(cfg *config.Config, m *metrics.Metrics) *limit.Limiter {
+	return limit.NewLimiter(cfg.Server.MaxConcurrentRequests, cfg.Server.ConcurrencyQueueTimeout, m)
+}
+
+// NewPageLimits creates the page size limits applied to HTTP and gRPC list requests
+func NewPageLimits(cfg *config.Config) paging.Limits {
+	return paging.NewLimits(cfg.Paging.DefaultPageSize, cfg.Paging.MaxPageSize)
+}
+
+// NewTenantResolver creates the resolver tagging requests with their tenant
+func NewTenantResolver(cfg *config.Config) *tenant.Resolver {
+	return tenant.NewResolver(cfg.Tenant.Header, cfg.Tenant.MetricLabels, cfg.Tenant.MaxMetricTenants)
+}
+
+// ConfigureTimeFormat applies the configured response timestamp layout
+func ConfigureTimeFormat(cfg *config.Config) {
+	timefmt.SetLayout(cfg.Server.TimeFormat)
+}
+
+// ValidateRouteFilter fails startup when a disabled route does not match any registered route
+func ValidateRouteFilter(filter *pkgRoutes.Filter, engine *gin.Engine, server *grpc.Server, log *zap.Logger) error {
+	if err := filter.Validate(engine, server); err != nil {
+		log.Error("Invalid route configuration", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// ValidateAdminMethods fails startup when a guarded admin gRPC method is not registered
+func ValidateAdminMethods(guard *auth.AdminGuard, server *grpc.Server, log *zap.Logger) error {
+	if err := guard.Validate(server); err != nil {
+		log.Error("Invalid admin method configuration", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// NewAdminGuard creates the guard protecting admin endpoints
+func NewAdminGuard(cfg *config.Config) (*auth.AdminGuard, error) {
+	return auth.NewAdminGuard(cfg.Security.AdminAPIKey, cfg.Security.TrustedNetworks, cfg.Security.AdminMethods)
+}
+
+// NewReplayConfig creates the outbox replay configuration
+func NewReplayConfig(cfg *config.Config) orderService.ReplayConfig {
+	return orderService.ReplayConfig{Topic: cfg.Outbox.ReplayTopic}
+}
+
+// NewAmountConfig creates the order amount limits
+func NewAmountConfig(cfg *config.Config) orderService.AmountConfig {
+	return orderService.AmountConfig{MaxTotalAmount: cfg.Order.MaxTotalAmount}
+}
+
+// NewProductIDConfig creates the product ID format check for order items
+func NewProductIDConfig(cfg *config.Config) (orderService.ProductIDConfig, error) {
+	productIDConfig := orderService.ProductIDConfig{Format: cfg.Order.ProductIDFormat}
+	if err := productIDConfig.Validate(); err != nil {
+		return orderService.ProductIDConfig{}, fmt.Errorf("invalid order configuration: %w", err)
+	}
+	return productIDConfig, nil
+}
+
+// NewShipmentConfig creates the tracking requirement for shipped orders
+func NewShipmentConfig(cfg *config.Config) orderService.ShipmentConfig {
+	return orderService.ShipmentConfig{RequireTracking: cfg.Order.RequireTracking}
+}
+
+// NewItemConfig creates the cap on the items embedded in an order fetched by ID
+func NewItemConfig(cfg *config.Config) (orderService.ItemConfig, error) {
+	itemConfig := orderService.ItemConfig{MaxEmbeddedItems: cfg.Order.MaxEmbeddedItems}
+	if err := itemConfig.Validate(); err != nil {
+		return orderService.ItemConfig{}, fmt.Errorf("invalid order configuration: %w", err)
+	}
+	return itemConfig, nil
+}
+
+// NewItemStorage selects where the database mode stores order items
+func NewItemStorage(cfg *config.Config) (orderRepository.ItemStorage, error) {
+	storage := orderRepository.ItemStorage(cfg.Order.ItemStorage)
+	if storage == "" {
+		storage = orderRepository.ItemStorageTable
+	}
+	if err := storage.Validate(); err != nil {
+		return "", fmt.Errorf("invalid order configuration: %w", err)
+	}
+	return storage, nil
+}
+
+// NewIdempotencyConfig creates the order creation idempotency configuration
+func NewIdempotencyConfig(cfg *config.Config) (orderService.IdempotencyConfig, error) {
+	idempotencyConfig := orderService.IdempotencyConfig{
+		TTL:      cfg.Idempotency.TTL,
+		CacheTTL: cfg.Idempotency.CacheTTL,
+	}
+	if err := idempotencyConfig.Validate(); err != nil {
+		return orderService.IdempotencyConfig{}, fmt.Errorf("invalid idempotency configuration: %w", err)
+	}
+	return idempotencyConfig, nil
+}
+
+// NewIdempotencyCache creates the cache answering CreateOrder retries before the database.
+// Caching is disabled unless a cache TTL is configured, in which case it connects to Redis.
+func NewIdempotencyCache(lc fx.Lifecycle, log *zap.Logger, cfg *config.Config, idempotency orderService.IdempotencyConfig) orderService.IdempotencyCache {
+	if idempotency.CacheTTL == 0 {
+		log.Info("Idempotency cache disabled")
+		return orderService.NoopIdempotencyCache{}
+	}
+
+	// The client connects lazily; while Redis is unreachable retries are answered from the database
+	client := redis.NewClient(&redis.Options{
+		Addr:         cfg.Redis.Addr(),
+		Password:     cfg.Redis.Password,
+		DB:           cfg.Redis.DB,
+		DialTimeout:  cfg.Redis.DialTimeout,
+		ReadTimeout:  cfg.Redis.ReadTimeout,
+		WriteTimeout: cfg.Redis.WriteTimeout,
+	})
+
+	// Register lifecycle hooks for the connection
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			log.Info("Closing idempotency cache client")
+			return client.Close()
+		},
+	})
+
+	return orderService.NewRedisIdempotencyCache(cache.NewRedisCache(client), idempotency.CacheTTL)
+}
+
+// NewIDGenerator creates the generator of entity IDs selected by configuration.
+// Orders default to ULIDs so that id-based pagination follows creation order.
+func NewIDGenerator(cfg *config.Config) (idgen.IDGenerator, error) {
+	if cfg.ID.Generator == "" {
+		return idgen.New(idgen.KindULID)
+	}
+	return idgen.New(cfg.ID.Generator)
+}
+
+// NewShippingEstimator creates the flat-rate shipping estimator from the configured rates
+func NewShippingEstimator(cfg *config.Config) orderService.ShippingEstimator {
+	rates := make(map[string]orderService.ShippingRate, len(cfg.Shipping.Rates))
+	for country, rate := range cfg.Shipping.Rates {
+		rates[country] = orderService.ShippingRate{
+			BaseCost:    rate.BaseCost,
+			PerItemCost: rate.PerItemCost,
+			TransitDays: rate.TransitDays,
+		}
+	}
+	return orderService.NewFlatRateShippingEstimator(rates)
+}
+
+// NewOrderServiceClient connects to the order service that remote mode proxies to
+func NewOrderServiceClient(lc fx.Lifecycle, log *zap.Logger, cfg *config.Config, tracer opentracing.Tracer, m *metrics.Metrics) (orderv1.OrderServiceClient, error) {
+	if cfg.Service.RemoteAddr == "" {
+		return nil, fmt.Errorf("service.remoteAddr is required in %s mode", orderService.ModeRemote)
+	}
+
+	client, conn, err := grpcclient.NewOrderServiceClient(cfg.Service.RemoteAddr, tracer, m)
+	if err != nil {
+		log.Error("Failed to create order service client", zap.Error(err))
+		return nil, err
+	}
+
+	// Register lifecycle hooks for the connection
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			log.Info("Closing order service client")
+			return conn.Close()
+		},
+	})
+
+	return client, nil
+}
+
+// NewProductServiceClient connects to the product service that stock reservation and
+// product checks share. No connection is made, and the client is nil, when neither is enabled.
+func NewProductServiceClient(lc fx.Lifecycle, log *zap.Logger, cfg *config.Config, tracer opentracing.Tracer, m *metrics.Metrics) (productv1.ProductServiceClient, error) {
+	if !cfg.Stock.Reserve && !cfg.Order.ValidateProducts {
+		return nil, nil
+	}
+
+	if cfg.Stock.ProductServiceAddr == "" {
+		return nil, fmt.Errorf("stock.productServiceAddr is required when stock.reserve or order.validateProducts is enabled")
+	}
+
+	client, conn, err := grpcclient.NewProductServiceClient(cfg.Stock.ProductServiceAddr, tracer, m)
+	if err != nil {
+		log.Error("Failed to create product service client", zap.Error(err))
+		return nil, err
+	}
+
+	// Register lifecycle hooks for the connection
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			log.Info("Closing product service client")
+			return conn.Close()
+		},
+	})
+
+	return client, nil
+}
+
+// NewStockReserver creates the reserver taking ordered quantities from product stock.
+// Reservation is disabled unless configured, in which case orders do not check stock.
+func NewStockReserver(log *zap.Logger, cfg *config.Config, client productv1.ProductServiceClient) orderService.StockReserver {
+	if !cfg.Stock.Reserve {
+		log.Info("Stock reservation disabled")
+		return orderService.NoopStockReserver{}
+	}
+	return orderService.NewGRPCStockReserver(client)
+}
+
+// NewProductCatalog creates the catalog order items are checked against before pricing.
+// Checking is disabled unless configured, in which case items are trusted as sent.
+func NewProductCatalog(log *zap.Logger, cfg *config.Config, client productv1.ProductServiceClient) (orderService.ProductCatalog, error) {
+	if !cfg.Order.ValidateProducts {
+		log.Info("Product catalog checks disabled")
+		return orderService.NoopProductCatalog{}, nil
+	}
+
+	policy := cfg.Order.PricePolicy
+	if policy == "" {
+		policy = orderService.PricePolicyEnforce
+	}
+	if err := orderService.ValidatePricePolicy(policy); err != nil {
+		return nil, fmt.Errorf("invalid order.pricePolicy: %w", err)
+	}
+
+	return orderService.NewGRPCProductCatalog(client, policy)
+}
+
+// NewCustomerValidator creates the check that orders are placed for existing customers.
+// Checking is disabled unless a customer service is configured.
+func NewCustomerValidator(log *zap.Logger, cfg *config.Config) (orderService.CustomerValidator, error) {
+	if cfg.Customer.ServiceURL == "" {
+		log.Info("Customer checks disabled")
+		return orderService.NoopCustomerValidator{}, nil
+	}
+
+	validator, err := orderService.NewHTTPCustomerValidator(cfg.Customer.ServiceURL, cfg.Customer.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("invalid customer configuration: %w", err)
+	}
+	return validator, nil
+}
+
+// OrderServiceOptions returns the providers backing the OrderService in the given mode.
+// Only db mode connects to the database and runs migrations.
+func OrderServiceOptions(mode string) (fx.Option, error) {
+	mode, err := orderService.ParseMode(mode)
+	if err != nil {
+		return nil, err
+	}
+
+	switch mode {
+	case orderService.ModeMemory:
+		return fx.Options(
+			fx.Provide(NewIDGenerator),
+			fx.Provide(fx.Annotate(orderService.NewMemoryOrderService, fx.As(new(orderService.OrderService)))),
+		), nil
+	case orderService.ModeRemote:
+		return fx.Options(
+			fx.Provide(NewOrderServiceClient),
+			fx.Provide(fx.Annotate(orderService.NewRemoteOrderService, fx.As(new(orderService.OrderService)))),
+		), nil
+	default:
+		return fx.Options(
+			// Database configuration and connection
+			fx.Provide(GetDBConfig),
+			fx.Provide(config.NewGormDB),
+			fx.Provide(fx.Annotate(health.NewDatabaseCheck, fx.ResultTags(`group:"healthChecks"`))),
+
+			// Entity ID generator
+			fx.Provide(NewIDGenerator),
+
+			// Order repository
+			fx.Provide(NewItemStorage),
+			fx.Provide(fx.Annotate(orderRepository.NewGormOrderRepository, fx.As(new(orderRepository.OrderRepository)))),
+
+			// Outbox repository
+			fx.Provide(fx.Annotate(orderRepository.NewGormOutboxRepository, fx.As(new(orderRepository.OutboxRepository)))),
+
+			// Idempotency key repository
+			fx.Provide(fx.Annotate(orderRepository.NewGormIdempotencyRepository, fx.As(new(orderRepository.IdempotencyRepository)))),
+
+			// Order service
+			fx.Provide(NewReplayConfig),
+			fx.Provide(NewIdempotencyConfig),
+			fx.Provide(NewIdempotencyCache),
+			fx.Provide(NewProductServiceClient),
+			fx.Provide(NewStockReserver),
+			fx.Provide(NewProductCatalog),
+			fx.Provide(NewCustomerValidator),
+			fx.Provide(fx.Annotate(orderService.NewDBOrderService, fx.As(new(orderService.OrderService)))),
+
+			fx.Invoke(func(*gorm.DB) {}), // Add DB to invoke to ensure it's initialized
+			fx.Invoke(RunMigrations),     // Run database migrations
+		), nil
+	}
+}
+
 // StartHTTPServer starts the HTTP server with graceful shutdown
 func StartHTTPServer(lc fx.Lifecycle, server *http.Server, log *zap.Logger) {
 	lc.Append(fx.Hook{
@@ -118,7 +468,7 @@ func StartHTTPServer(lc fx.Lifecycle, server *http.Server, log *zap.Logger) {
 func StartGRPCServer(lc fx.Lifecycle, server *grpc.Server, log *zap.Logger, cfg *config.Config) {
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
-			grpcAddr := ":" + cfg.Server.GRPC.Port
+			grpcAddr := cfg.Server.GRPC.Addr()
 			listener, err := net.Listen("tcp", grpcAddr)
 			if err != nil {
 				log.Error("Failed to listen for gRPC", zap.Error(err))
@@ -135,35 +485,39 @@ func StartGRPCServer(lc fx.Lifecycle, server *grpc.Server, log *zap.Logger, cfg
 		},
 		OnStop: func(ctx context.Context) error {
 			log.Info("Stopping gRPC server")
-			server.GracefulStop()
+			grpcserver.Stop(ctx, log, server, cfg.Server.GRPC.StopTimeout())
 			return nil
 		},
 	})
 }
 
-// LoadConfig loads the application configuration
-func LoadConfig(log *zap.Logger) (*config.Config, error) {
-	cfg, err := config.LoadServiceConfig("order")
-	if err != nil {
-		log.Error("Failed to load configuration", zap.Error(err))
-		return nil, err
+// newTracer creates the tracer reporting to the configured exporters, or to the Tempo agent
+// at hostPort when none are configured
+func newTracer(log *zap.Logger, cfg *config.Config, hostPort string) (opentracing.Tracer, io.Closer, error) {
+	if len(cfg.Tracing.Exporters) == 0 {
+		return tracing.InitTracer(cfg.Service.Name, hostPort, tracing.Propagation(cfg.Tracing.Propagation))
 	}
-	return cfg, nil
+
+	exporters := make([]tracing.Exporter, len(cfg.Tracing.Exporters))
+	for i, exporter := range cfg.Tracing.Exporters {
+		exporters[i] = tracing.Exporter{Type: exporter.Type, Endpoint: exporter.Endpoint}
+		log.Info("Exporting spans", zap.String("type", exporter.Type), zap.String("endpoint", exporter.Endpoint))
+	}
+	return tracing.InitTracerWithExporters(cfg.Service.Name, exporters, tracing.Propagation(cfg.Tracing.Propagation))
 }
 
 // InitTracer initializes the OpenTracing tracer
 func InitTracer(lc fx.Lifecycle, log *zap.Logger, cfg *config.Config) opentracing.Tracer {
 	// Initialize tracer with configuration from YAML
 	// Try to use Tempo config first, fall back to Jaeger config for backward compatibility
-	hostPort := cfg.Tempo.HostPort()
-	if hostPort == ":" { // If Tempo config is not set
-		hostPort = cfg.Jaeger.HostPort() // Use Jaeger config (which is actually TempoConfig now)
-		log.Info("Using Jaeger configuration for tracing (pointing to Tempo)")
+	backend, legacy := cfg.TracingBackend()
+	if legacy {
+		log.Warn("The jaeger configuration block is deprecated, rename it to tempo")
 	} else {
 		log.Info("Using Tempo configuration for tracing")
 	}
 
-	tracer, closer, err := tracing.InitTracer(cfg.Service.Name, hostPort)
+	tracer, closer, err := newTracer(log, cfg, backend.HostPort())
 	if err != nil {
 		log.Fatal("Failed to initialize tracer", zap.Error(err))
 	}
@@ -179,14 +533,11 @@ func InitTracer(lc fx.Lifecycle, log *zap.Logger, cfg *config.Config) opentracin
 	return tracer
 }
 
-// MetricsService represents the metrics service
-type MetricsService struct{}
-
-// InitMetrics initializes the Prometheus metrics
-func InitMetrics(log *zap.Logger, cfg *config.Config) *MetricsService {
+// InitMetrics initializes the Prometheus metrics with the configured histogram buckets,
+// keeping recent slow requests when the debug endpoint is enabled
+func InitMetrics(log *zap.Logger, cfg *config.Config) (*metrics.Metrics, error) {
 	log.Info("Initializing metrics")
-	metrics.InitMetrics(cfg.Service.Name)
-	return &MetricsService{}
+	return metrics.InitMetrics(cfg.Service.Name, cfg.MetricsOptions())
 }
 
 // ProfilingService represents the profiling service
@@ -229,9 +580,24 @@ func GetDBConfig(cfg *config.Config) *config.DBConfig {
 	return &cfg.DB
 }
 
-// RunMigrations runs database migrations
-func RunMigrations(log *zap.Logger, dbConfig *config.DBConfig) error {
-	log.Info("Running database migrations for order service")
+// RunMigrations brings the database schema up to date. The migration files are the
+// source of truth; GORM AutoMigrate replaces them only when db.useAutoMigrate is set
+// for a local database.
+func RunMigrations(log *zap.Logger, dbConfig *config.DBConfig, db *gorm.DB) error {
+	if dbConfig.UseAutoMigrate {
+		if err := migrate.CheckAutoMigrate(dbConfig.Host); err != nil {
+			return err
+		}
+
+		log.Warn("Creating the order schema with GORM AutoMigrate; migration files are not applied (development only)")
+		if err := orderRepository.AutoMigrate(db); err != nil {
+			log.Error("Failed to auto-migrate database", zap.Error(err))
+			return err
+		}
+		return nil
+	}
+
+	log.Info("Running database migrations for order service from the migration files")
 
 	// Build DSN
 	dsn := fmt.Sprintf(
@@ -253,23 +619,61 @@ func RunMigrations(log *zap.Logger, dbConfig *config.DBConfig) error {
 }
 
 func main() {
+	// The service mode decides which dependencies are provided, so the
+	// configuration is loaded before the dependency graph is built
+	cfg, err := config.LoadServiceConfig("order")
+	if err != nil {
+		zap.NewExample().Fatal("Failed to load configuration", zap.Error(err))
+	}
+
+	serviceOptions, err := OrderServiceOptions(cfg.Service.Mode)
+	if err != nil {
+		zap.NewExample().Fatal("Invalid service mode", zap.Error(err))
+	}
+
 	fx.New(
+		fx.Supply(cfg),
 		fx.Provide(fx.Annotate(
 			NewHTTPServer,
 			fx.ParamTags(``, ``))),
-		fx.Provide(LoadConfig),    // Provide the configuration
-		fx.Provide(InitTracer),    // Provide the tracer
-		fx.Provide(InitMetrics),   // Provide metrics initialization
-		fx.Provide(InitProfiling), // Provide profiling initialization
+		fx.Provide(InitTracer),            // Provide the tracer
+		fx.Provide(InitMetrics),           // Provide metrics initialization
+		fx.Provide(InitProfiling),         // Provide profiling initialization
+		fx.Provide(NewRouteFilter),        // Provide the disabled route filter
+		fx.Provide(NewConcurrencyLimiter), // Provide the concurrency limiter
+		fx.Provide(NewTenantResolver),     // Provide the tenant resolver
+		fx.Provide(NewPageLimits),         // Provide the page size limits
+		fx.Provide(NewAmountConfig),       // Provide the order amount limits
+		fx.Provide(NewProductIDConfig),    // Provide the order item product ID format
+		fx.Provide(NewShipmentConfig),     // Provide the shipped order tracking requirement
+		fx.Provide(NewItemConfig),         // Provide the embedded order item cap
+
+		// Readiness checker over the registered dependency checks
+		fx.Provide(fx.Annotate(
+			NewHealthChecker,
+			fx.ParamTags(`group:"healthChecks"`))),
+
 		fx.Provide(fx.Annotate(
 			NewGinEngine,
-			fx.ParamTags(`group:"routes"`, ``))),
+			fx.ParamTags(`group:"routes"`, ``, ``, ``, ``, ``, ``))),
 
 		// Order handlers
 		fx.Provide(AsRoute(orderHandler.NewCreateOrderHandler)),
+		fx.Provide(AsRoute(orderHandler.NewPreviewOrderHandler)),
+		fx.Provide(AsRoute(orderHandler.NewShippingEstimateHandler)),
 		fx.Provide(AsRoute(orderHandler.NewGetOrderHandler)),
+		fx.Provide(AsRoute(orderHandler.NewListOrderItemsHandler)),
 		fx.Provide(AsRoute(orderHandler.NewListOrdersHandler)),
 		fx.Provide(AsRoute(orderHandler.NewUpdateOrderStatusHandler)),
+		fx.Provide(AsRoute(orderHandler.NewCancelOrderHandler)),
+		fx.Provide(AsRoute(orderHandler.NewReorderHandler)),
+
+		// Admin handlers
+		fx.Provide(NewAdminGuard),
+		fx.Provide(AsRoute(orderHandler.NewOrderTimeSeriesHandler)),
+		fx.Provide(AsRoute(orderHandler.NewOrderEventsHandler)),
+		fx.Provide(AsRoute(orderHandler.NewReplayEventsHandler)),
+		fx.Provide(AsRoute(orderHandler.NewBulkShipOrdersHandler)),
 
 		// gRPC server
 		fx.Provide(orderHandler.NewGRPCOrderServer),
@@ -285,27 +689,21 @@ func main() {
 			return log.Sugar()
 		}),
 
-		// Database configuration and connection
-		fx.Provide(GetDBConfig),
-		fx.Provide(config.NewGormDB),
-
-		// Order repository
-		fx.Provide(fx.Annotate(orderRepository.NewGormOrderRepository, fx.As(new(orderRepository.OrderRepository)))),
-
-		// Outbox repository
-		fx.Provide(fx.Annotate(orderRepository.NewGormOutboxRepository, fx.As(new(orderRepository.OutboxRepository)))),
+		// Shipping estimator
+		fx.Provide(NewShippingEstimator),
 
-		// Order service
-		fx.Provide(fx.Annotate(orderService.NewDBOrderService, fx.As(new(orderService.OrderService)))),
+		// Order service for the configured mode
+		serviceOptions,
 
 		fx.WithLogger(func(log *zap.Logger) fxevent.Logger {
 			return &fxevent.ZapLogger{Logger: log}
 		}),
-		fx.Invoke(func(*gorm.DB) {}),                  // Add DB to invoke to ensure it's initialized
 		fx.Invoke(func(tracer opentracing.Tracer) {}), // Add Tracer to invoke to ensure it's initialized
-		fx.Invoke(func(*MetricsService) {}),           // Add MetricsService to invoke to ensure it's initialized
+		fx.Invoke(func(*metrics.Metrics) {}),          // Add Metrics to invoke to ensure it's initialized
 		fx.Invoke(func(*ProfilingService) {}),         // Add ProfilingService to invoke to ensure it's initialized
-		fx.Invoke(RunMigrations),                      // Run database migrations
+		fx.Invoke(ConfigureTimeFormat),                // Apply the response timestamp layout
+		fx.Invoke(ValidateRouteFilter),                // Validate disabled routes
+		fx.Invoke(ValidateAdminMethods),               // Validate guarded admin gRPC methods
 		fx.Invoke(StartHTTPServer),                    // Start the HTTP server with a graceful shutdown
 		fx.Invoke(StartGRPCServer),                    // Start the gRPC server
 	).Run()
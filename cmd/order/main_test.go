@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	orderService "go-bootiful-ordering/internal/order/service"
+	"go-bootiful-ordering/internal/pkg/config"
+	"go-bootiful-ordering/internal/pkg/metrics"
+	"go-bootiful-ordering/internal/pkg/metrics/metricstest"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// serviceApp returns the options of an app holding only the given mode's OrderService and
+// what its providers share with the rest of main
+func serviceApp(t *testing.T, cfg *config.Config, extra ...fx.Option) []fx.Option {
+	t.Helper()
+	serviceOptions, err := OrderServiceOptions(cfg.Service.Mode)
+	if err != nil {
+		t.Fatalf("OrderServiceOptions(%q) error = %v", cfg.Service.Mode, err)
+	}
+	return append([]fx.Option{
+		fx.NopLogger,
+		fx.Supply(cfg),
+		fx.Provide(zap.NewNop),
+		fx.Provide(func(log *zap.Logger) *zap.SugaredLogger { return log.Sugar() }),
+		fx.Provide(func() opentracing.Tracer { return opentracing.NoopTracer{} }),
+		fx.Provide(func() *metrics.Metrics { return metricstest.NewRecorder().Metrics }),
+		fx.Provide(NewAmountConfig, NewProductIDConfig, NewShipmentConfig, NewItemConfig, NewShippingEstimator),
+		serviceOptions,
+	}, extra...)
+}
+
+func TestOrderServiceOptionsSelectsTheImplementation(t *testing.T) {
+	tests := []struct {
+		mode string
+		want string
+	}{
+		{mode: orderService.ModeMemory, want: "*service.MemoryOrderService"},
+		{mode: orderService.ModeRemote, want: "*service.RemoteOrderService"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.Service.Mode = tt.mode
+			cfg.Service.RemoteAddr = "localhost:9090" // The client connects lazily, so nothing needs to listen
+
+			var svc orderService.OrderService
+			app := fx.New(serviceApp(t, cfg, fx.Populate(&svc))...)
+			if err := app.Err(); err != nil {
+				t.Fatalf("fx.New() error = %v", err)
+			}
+			if got := fmt.Sprintf("%T", svc); got != tt.want {
+				t.Errorf("OrderService = %s, want %s", got, tt.want)
+			}
+
+			// Neither mode connects to the database
+			if err := fx.ValidateApp(serviceApp(t, cfg, fx.Invoke(func(*gorm.DB) {}))...); err == nil {
+				t.Errorf("%s mode provides a database connection, want none", tt.mode)
+			}
+		})
+	}
+}
+
+func TestOrderServiceOptionsDBMode(t *testing.T) {
+	for _, mode := range []string{"", orderService.ModeDB} {
+		t.Run("mode "+mode, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.Service.Mode = mode
+
+			// Validating builds the graph without running the constructors, which would connect
+			// to the database
+			err := fx.ValidateApp(serviceApp(t, cfg, fx.Invoke(func(orderService.OrderService, *gorm.DB) {}))...)
+			if err != nil {
+				t.Errorf("fx.ValidateApp() error = %v, want the database-backed service graph", err)
+			}
+		})
+	}
+}
+
+func TestOrderServiceOptionsRejectsBadConfig(t *testing.T) {
+	if _, err := OrderServiceOptions("sqlite"); err == nil || !strings.Contains(err.Error(), `"sqlite"`) {
+		t.Errorf("OrderServiceOptions(sqlite) error = %v, want one naming the unknown mode", err)
+	}
+
+	cfg := &config.Config{}
+	cfg.Service.Mode = orderService.ModeRemote
+	app := fx.New(serviceApp(t, cfg, fx.Invoke(func(orderService.OrderService) {}))...)
+	if err := app.Err(); err == nil || !strings.Contains(err.Error(), "service.remoteAddr") {
+		t.Errorf("remote mode without an address error = %v, want one naming service.remoteAddr", err)
+	}
+}
+
+func TestRunMigrationsAutoMigratesOnlyWhenSet(t *testing.T) {
+	tests := []struct {
+		name           string
+		useAutoMigrate bool
+		host           string
+		wantAuto       bool
+		wantErr        string
+	}{
+		{name: "flag set for a local database", useAutoMigrate: true, host: "localhost", wantAuto: true},
+		{name: "flag set for a shared database", useAutoMigrate: true, host: "postgres", wantErr: "local development only"},
+		// The migration files are not found from the test's directory, so applying them fails
+		{name: "flag unset", host: "127.0.0.1", wantErr: "migrate"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// A dry run builds the DDL of AutoMigrate without a database
+			db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost dbname=orders"}), &gorm.Config{
+				DryRun:               true,
+				DisableAutomaticPing: true,
+				Logger:               logger.Discard,
+			})
+			if err != nil {
+				t.Fatalf("gorm.Open() error = %v", err)
+			}
+			var ddl []string
+			if err := db.Callback().Raw().After("gorm:raw").Register("test:record", func(db *gorm.DB) {
+				ddl = append(ddl, db.Statement.SQL.String())
+			}); err != nil {
+				t.Fatalf("registering the DDL recorder: %v", err)
+			}
+
+			core, logs := observer.New(zap.InfoLevel)
+			dbConfig := &config.DBConfig{Host: tt.host, Port: "1", User: "orders", Name: "orders", SSLMode: "disable", UseAutoMigrate: tt.useAutoMigrate}
+			err = RunMigrations(zap.New(core), dbConfig, db)
+
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("RunMigrations() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("RunMigrations() error = %v, want one containing %q", err, tt.wantErr)
+			}
+			autoMigrated := false
+			for _, statement := range ddl {
+				if strings.HasPrefix(statement, "CREATE TABLE") {
+					autoMigrated = true
+				}
+			}
+			if autoMigrated != tt.wantAuto {
+				t.Errorf("AutoMigrate ran = %v, want %v: %q", autoMigrated, tt.wantAuto, ddl)
+			}
+			if !tt.useAutoMigrate && logs.FilterMessageSnippet("from the migration files").Len() != 1 {
+				t.Errorf("startup log = %v, want it to name the migration files", logs.All())
+			}
+		})
+	}
+}
@@ -12,18 +12,30 @@ import (
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"gorm.io/gorm"
+	"io"
 	"net"
 	"net/http"
 	"time"
 
 	productv1 "go-bootiful-ordering/gen/product/v1"
+	"go-bootiful-ordering/internal/pkg/auth"
+	"go-bootiful-ordering/internal/pkg/cache"
 	"go-bootiful-ordering/internal/pkg/config"
+	"go-bootiful-ordering/internal/pkg/grpcserver"
 	"go-bootiful-ordering/internal/pkg/health"
+	"go-bootiful-ordering/internal/pkg/idgen"
+	"go-bootiful-ordering/internal/pkg/limit"
 	"go-bootiful-ordering/internal/pkg/metrics"
 	"go-bootiful-ordering/internal/pkg/migrate"
+	"go-bootiful-ordering/internal/pkg/paging"
 	"go-bootiful-ordering/internal/pkg/profiling"
+	"go-bootiful-ordering/internal/pkg/requestid"
+	pkgRoutes "go-bootiful-ordering/internal/pkg/routes"
+	"go-bootiful-ordering/internal/pkg/tenant"
+	"go-bootiful-ordering/internal/pkg/timefmt"
 	"go-bootiful-ordering/internal/pkg/tracing"
 	productConfig "go-bootiful-ordering/internal/product/config" // Still needed for RedisConfig
+	productDomain "go-bootiful-ordering/internal/product/domain"
 	productHandler "go-bootiful-ordering/internal/product/handler"
 	productRepository "go-bootiful-ordering/internal/product/repository"
 	productService "go-bootiful-ordering/internal/product/service"
@@ -37,20 +49,40 @@ type Route interface {
 }
 
 // NewGinEngine creates a new gin.Engine with the given routes
-func NewGinEngine(routes []Route, tracer opentracing.Tracer) *gin.Engine {
+func NewGinEngine(routes []Route, tracer opentracing.Tracer, log *zap.Logger, cfg *config.Config, filter *pkgRoutes.Filter, limiter *limit.Limiter, tenants *tenant.Resolver, checker *health.Checker, m *metrics.Metrics) *gin.Engine {
 	r := gin.Default()
 
+	// Trailing slashes are stripped before routing (see NewHTTPServer) instead of redirected
+	r.RedirectTrailingSlash = false
+
+	// Tag the request with its request ID first, so every later middleware can log it
+	r.Use(requestid.GinMiddleware())
+
 	// Add OpenTracing middleware
 	r.Use(tracing.GinMiddleware(tracer))
 
+	// Tag the request with its tenant
+	r.Use(tenants.GinMiddleware())
+
 	// Add Prometheus middleware
-	r.Use(metrics.GinMiddleware())
+	r.Use(m.GinMiddleware(log, cfg.Server.HTTP.SlowThreshold()))
 
 	// Register metrics endpoint
 	metrics.RegisterMetricsEndpoint(r)
 
-	// Register health check endpoint
-	health.RegisterHealthEndpoint(r)
+	// Register the slow request triage endpoint when debugging is enabled
+	if cfg.Debug.Enabled {
+		metrics.RegisterSlowRequestsEndpoint(r, m.SlowRequests)
+	}
+
+	// Register liveness and readiness endpoints
+	health.RegisterHealthEndpoint(r, checker)
+
+	// Reject routes disabled by configuration
+	r.Use(filter.GinMiddleware())
+
+	// Cap concurrent requests
+	r.Use(limiter.GinMiddleware())
 
 	// Create a router group for API routes
 	apiGroup := r.Group("")
@@ -65,28 +97,91 @@ func NewGinEngine(routes []Route, tracer opentracing.Tracer) *gin.Engine {
 
 func NewHTTPServer(engine *gin.Engine, cfg *config.Config) *http.Server {
 	return &http.Server{
-		Addr:    ":" + cfg.Server.HTTP.Port,
-		Handler: engine,
+		Addr:    cfg.Server.HTTP.Addr(),
+		Handler: pkgRoutes.StripTrailingSlash(health.Healthz(engine)),
 	}
 }
 
 // NewGRPCServer creates a new gRPC server
-func NewGRPCServer(productServer *productHandler.GRPCProductServer, tracer opentracing.Tracer) *grpc.Server {
+func NewGRPCServer(productServer *productHandler.GRPCProductServer, tracer opentracing.Tracer, log *zap.Logger, cfg *config.Config, filter *pkgRoutes.Filter, limiter *limit.Limiter, tenants *tenant.Resolver, checker *health.Checker, m *metrics.Metrics, guard *auth.AdminGuard) *grpc.Server {
 	// Chain the tracing and metrics interceptors
 	chainedInterceptor := grpc.ChainUnaryInterceptor(
+		requestid.UnaryServerInterceptor(),
 		tracing.UnaryServerInterceptor(tracer),
-		metrics.UnaryServerInterceptor(),
+		tenants.UnaryServerInterceptor(),
+		m.UnaryServerInterceptor(log, cfg.Server.GRPC.SlowThreshold()),
+		filter.UnaryServerInterceptor(),
+		limiter.UnaryServerInterceptor(),
+		guard.UnaryServerInterceptor(),
 	)
 
 	server := grpc.NewServer(chainedInterceptor)
 	productv1.RegisterProductServiceServer(server, productServer)
 
 	// Register health check service
-	health.RegisterHealthServer(server)
+	health.RegisterHealthServer(server, checker)
 
 	return server
 }
 
+// NewHealthChecker creates the readiness checker from the dependency checks the service mode provides
+func NewHealthChecker(checks []health.Check) *health.Checker {
+	return health.NewChecker(health.DefaultCheckTimeout, checks...)
+}
+
+// NewRouteFilter creates the filter rejecting routes disabled by configuration
+func NewRouteFilter(cfg *config.Config) *pkgRoutes.Filter {
+	return pkgRoutes.NewFilter(cfg.Routes.Disabled)
+}
+
+// NewConcurrencyLimiter creates the limiter shared by the HTTP and gRPC servers
+func NewConcurrencyLimiter(cfg *config.Config, m *metrics.Metrics) *limit.Limiter {
+	return limit.NewLimiter(cfg.Server.MaxConcurrentRequests, cfg.Server.ConcurrencyQueueTimeout, m)
+}
+
+// NewPageLimits creates the page size limits applied to HTTP and gRPC list requests
+func NewPageLimits(cfg *config.Config) paging.Limits {
+	return paging.NewLimits(cfg.Paging.DefaultPageSize, cfg.Paging.MaxPageSize)
+}
+
+// NewTenantResolver creates the resolver tagging requests with their tenant
+func NewTenantResolver(cfg *config.Config) *tenant.Resolver {
+	return tenant.NewResolver(cfg.Tenant.Header, cfg.Tenant.MetricLabels, cfg.Tenant.MaxMetricTenants)
+}
+
+// ConfigureTimeFormat applies the configured response timestamp layout
+func ConfigureTimeFormat(cfg *config.Config) {
+	timefmt.SetLayout(cfg.Server.TimeFormat)
+}
+
+// ValidateRouteFilter fails startup when a disabled route does not match any registered route
+func ValidateRouteFilter(filter *pkgRoutes.Filter, engine *gin.Engine, server *grpc.Server, log *zap.Logger) error {
+	if err := filter.Validate(engine, server); err != nil {
+		log.Error("Invalid route configuration", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// ValidateAdminMethods fails startup when a guarded admin gRPC method is not registered
+func ValidateAdminMethods(guard *auth.AdminGuard, server *grpc.Server, log *zap.Logger) error {
+	if err := guard.Validate(server); err != nil {
+		log.Error("Invalid admin method configuration", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// NewAdminGuard creates the guard protecting admin endpoints
+func NewAdminGuard(cfg *config.Config) (*auth.AdminGuard, error) {
+	return auth.NewAdminGuard(cfg.Security.AdminAPIKey, cfg.Security.TrustedNetworks, cfg.Security.AdminMethods)
+}
+
+// NewIDGenerator creates the generator of entity IDs selected by configuration
+func NewIDGenerator(cfg *config.Config) (idgen.IDGenerator, error) {
+	return idgen.New(cfg.ID.Generator)
+}
+
 // StartHTTPServer starts the HTTP server with graceful shutdown
 func StartHTTPServer(lc fx.Lifecycle, server *http.Server, log *zap.Logger) {
 	lc.Append(fx.Hook{
@@ -120,7 +215,7 @@ func StartHTTPServer(lc fx.Lifecycle, server *http.Server, log *zap.Logger) {
 func StartGRPCServer(lc fx.Lifecycle, server *grpc.Server, log *zap.Logger, cfg *config.Config) {
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
-			grpcAddr := ":" + cfg.Server.GRPC.Port
+			grpcAddr := cfg.Server.GRPC.Addr()
 			listener, err := net.Listen("tcp", grpcAddr)
 			if err != nil {
 				log.Error("Failed to listen for gRPC", zap.Error(err))
@@ -137,7 +232,7 @@ func StartGRPCServer(lc fx.Lifecycle, server *grpc.Server, log *zap.Logger, cfg
 		},
 		OnStop: func(ctx context.Context) error {
 			log.Info("Stopping gRPC server")
-			server.GracefulStop()
+			grpcserver.Stop(ctx, log, server, cfg.Server.GRPC.StopTimeout())
 			return nil
 		},
 	})
@@ -153,19 +248,33 @@ func LoadConfig(log *zap.Logger) (*config.Config, error) {
 	return cfg, nil
 }
 
+// newTracer creates the tracer reporting to the configured exporters, or to the Tempo agent
+// at hostPort when none are configured
+func newTracer(log *zap.Logger, cfg *config.Config, hostPort string) (opentracing.Tracer, io.Closer, error) {
+	if len(cfg.Tracing.Exporters) == 0 {
+		return tracing.InitTracer(cfg.Service.Name, hostPort, tracing.Propagation(cfg.Tracing.Propagation))
+	}
+
+	exporters := make([]tracing.Exporter, len(cfg.Tracing.Exporters))
+	for i, exporter := range cfg.Tracing.Exporters {
+		exporters[i] = tracing.Exporter{Type: exporter.Type, Endpoint: exporter.Endpoint}
+		log.Info("Exporting spans", zap.String("type", exporter.Type), zap.String("endpoint", exporter.Endpoint))
+	}
+	return tracing.InitTracerWithExporters(cfg.Service.Name, exporters, tracing.Propagation(cfg.Tracing.Propagation))
+}
+
 // InitTracer initializes the OpenTracing tracer
 func InitTracer(lc fx.Lifecycle, log *zap.Logger, cfg *config.Config) opentracing.Tracer {
 	// Initialize tracer with configuration from YAML
 	// Try to use Tempo config first, fall back to Jaeger config for backward compatibility
-	hostPort := cfg.Tempo.HostPort()
-	if hostPort == ":" { // If Tempo config is not set
-		hostPort = cfg.Jaeger.HostPort() // Use Jaeger config (which is actually TempoConfig now)
-		log.Info("Using Jaeger configuration for tracing (pointing to Tempo)")
+	backend, legacy := cfg.TracingBackend()
+	if legacy {
+		log.Warn("The jaeger configuration block is deprecated, rename it to tempo")
 	} else {
 		log.Info("Using Tempo configuration for tracing")
 	}
 
-	tracer, closer, err := tracing.InitTracer(cfg.Service.Name, hostPort)
+	tracer, closer, err := newTracer(log, cfg, backend.HostPort())
 	if err != nil {
 		log.Fatal("Failed to initialize tracer", zap.Error(err))
 	}
@@ -181,14 +290,11 @@ func InitTracer(lc fx.Lifecycle, log *zap.Logger, cfg *config.Config) opentracin
 	return tracer
 }
 
-// MetricsService represents the metrics service
-type MetricsService struct{}
-
-// InitMetrics initializes the Prometheus metrics
-func InitMetrics(log *zap.Logger, cfg *config.Config) *MetricsService {
+// InitMetrics initializes the Prometheus metrics with the configured histogram buckets,
+// keeping recent slow requests when the debug endpoint is enabled
+func InitMetrics(log *zap.Logger, cfg *config.Config) (*metrics.Metrics, error) {
 	log.Info("Initializing metrics")
-	metrics.InitMetrics(cfg.Service.Name)
-	return &MetricsService{}
+	return metrics.InitMetrics(cfg.Service.Name, cfg.MetricsOptions())
 }
 
 // ProfilingService represents the profiling service
@@ -231,6 +337,23 @@ func GetDBConfig(cfg *config.Config) *config.DBConfig {
 	return &cfg.DB
 }
 
+// NewCacheConfig creates the product cache configuration
+func NewCacheConfig(cfg *config.Config) (productRepository.CacheConfig, error) {
+	cacheConfig := productRepository.CacheConfig{
+		NegativeTTL:  cfg.Product.NegativeCacheTTL,
+		CategoryTTLs: cfg.Redis.CategoryTTLOverrides,
+		StaleWindow:  cfg.Product.CacheStaleWindow,
+
+		ProductTTL:       cfg.Redis.ProductTTL,
+		ListTTL:          cfg.Redis.ListTTL,
+		TTLJitterPercent: cfg.Redis.TTLJitterPercent,
+	}
+	if err := cacheConfig.Validate(); err != nil {
+		return productRepository.CacheConfig{}, err
+	}
+	return cacheConfig, nil
+}
+
 // NewRedisConfig creates a Redis configuration from the YAML configuration
 func NewRedisConfig(cfg *config.Config) *productConfig.RedisConfig {
 	return &productConfig.RedisConfig{
@@ -238,12 +361,66 @@ func NewRedisConfig(cfg *config.Config) *productConfig.RedisConfig {
 		Port:     cfg.Redis.Port,
 		Password: cfg.Redis.Password,
 		DB:       cfg.Redis.DB,
+
+		DialTimeout:  cfg.Redis.DialTimeout,
+		ReadTimeout:  cfg.Redis.ReadTimeout,
+		WriteTimeout: cfg.Redis.WriteTimeout,
+	}
+}
+
+// NewProductCache creates the Redis cache of the product repository, retrying failed operations
+func NewProductCache(cfg *config.Config, client *redis.Client) (cache.Cache, error) {
+	retryConfig := cache.RetryConfig{
+		Attempts:  cfg.Redis.RetryAttempts,
+		BaseDelay: cfg.Redis.RetryBaseDelay,
 	}
+	if err := retryConfig.Validate(); err != nil {
+		return nil, err
+	}
+	return cache.NewRetryingCache(cache.NewRedisCache(client), retryConfig), nil
+}
+
+// NewGetProductConfig creates the product read handler configuration
+func NewGetProductConfig(cfg *config.Config) productHandler.GetProductConfig {
+	return productHandler.GetProductConfig{GoneForDeleted: cfg.Product.GoneForDeleted}
+}
+
+// NewFieldLimits creates the product field limits from the YAML configuration
+func NewFieldLimits(cfg *config.Config) productDomain.FieldLimits {
+	return productDomain.NewFieldLimits(
+		cfg.Product.MaxNameLength,
+		cfg.Product.MaxDescriptionLength,
+		cfg.Product.MaxCategoryLength,
+	)
+}
+
+// NewProductSort returns the configured default product ordering
+func NewProductSort(cfg *config.Config) (productDomain.ProductSort, error) {
+	sort := productDomain.ProductSort(cfg.Product.DefaultSort)
+	if !sort.IsValid() {
+		return "", fmt.Errorf("unknown product default sort %q", cfg.Product.DefaultSort)
+	}
+	return sort, nil
 }
 
-// RunMigrations runs database migrations
-func RunMigrations(log *zap.Logger, dbConfig *config.DBConfig) error {
-	log.Info("Running database migrations for product service")
+// RunMigrations brings the database schema up to date. The migration files are the
+// source of truth; GORM AutoMigrate replaces them only when db.useAutoMigrate is set
+// for a local database.
+func RunMigrations(log *zap.Logger, dbConfig *config.DBConfig, db *gorm.DB) error {
+	if dbConfig.UseAutoMigrate {
+		if err := migrate.CheckAutoMigrate(dbConfig.Host); err != nil {
+			return err
+		}
+
+		log.Warn("Creating the product schema with GORM AutoMigrate; migration files are not applied (development only)")
+		if err := productRepository.AutoMigrate(db); err != nil {
+			log.Error("Failed to auto-migrate database", zap.Error(err))
+			return err
+		}
+		return nil
+	}
+
+	log.Info("Running database migrations for product service from the migration files")
 
 	// Build DSN
 	dsn := fmt.Sprintf(
@@ -269,26 +446,42 @@ func main() {
 		fx.Provide(fx.Annotate(
 			NewHTTPServer,
 			fx.ParamTags(``, ``))),
-		fx.Provide(LoadConfig),    // Provide the configuration
-		fx.Provide(InitTracer),    // Provide the tracer
-		fx.Provide(InitMetrics),   // Provide metrics initialization
-		fx.Provide(InitProfiling), // Provide profiling initialization
+		fx.Provide(LoadConfig),            // Provide the configuration
+		fx.Provide(InitTracer),            // Provide the tracer
+		fx.Provide(InitMetrics),           // Provide metrics initialization
+		fx.Provide(InitProfiling),         // Provide profiling initialization
+		fx.Provide(NewRouteFilter),        // Provide the disabled route filter
+		fx.Provide(NewConcurrencyLimiter), // Provide the concurrency limiter
+		fx.Provide(NewTenantResolver),     // Provide the tenant resolver
+		fx.Provide(NewPageLimits),         // Provide the page size limits
+
+		// Readiness checker over the registered dependency checks
+		fx.Provide(fx.Annotate(
+			NewHealthChecker,
+			fx.ParamTags(`group:"healthChecks"`))),
+
 		fx.Provide(fx.Annotate(
 			NewGinEngine,
-			fx.ParamTags(`group:"routes"`, ``))),
+			fx.ParamTags(`group:"routes"`, ``, ``, ``, ``, ``, ``))),
 
 		// Product handlers
 		fx.Provide(fx.Annotate(
 			productHandler.NewCreateProductHandler,
 			fx.As(new(Route)),
 			fx.ResultTags(`group:"routes"`),
+			fx.ParamTags(``, `name:"dbProductService"`, ``),
+		)),
+		fx.Provide(fx.Annotate(
+			productHandler.NewBatchCreateProductsHandler,
+			fx.As(new(Route)),
+			fx.ResultTags(`group:"routes"`),
 			fx.ParamTags(``, `name:"dbProductService"`),
 		)),
 		fx.Provide(fx.Annotate(
 			productHandler.NewGetProductHandler,
 			fx.As(new(Route)),
 			fx.ResultTags(`group:"routes"`),
-			fx.ParamTags(``, `name:"dbProductService"`),
+			fx.ParamTags(``, `name:"dbProductService"`, ``),
 		)),
 		fx.Provide(fx.Annotate(
 			productHandler.NewListProductsHandler,
@@ -300,6 +493,12 @@ func main() {
 			productHandler.NewUpdateProductHandler,
 			fx.As(new(Route)),
 			fx.ResultTags(`group:"routes"`),
+			fx.ParamTags(``, `name:"dbProductService"`, ``),
+		)),
+		fx.Provide(fx.Annotate(
+			productHandler.NewUpdateStockHandler,
+			fx.As(new(Route)),
+			fx.ResultTags(`group:"routes"`),
 			fx.ParamTags(``, `name:"dbProductService"`),
 		)),
 		fx.Provide(fx.Annotate(
@@ -308,11 +507,44 @@ func main() {
 			fx.ResultTags(`group:"routes"`),
 			fx.ParamTags(``, `name:"dbProductService"`),
 		)),
+		fx.Provide(fx.Annotate(
+			productHandler.NewCheckAvailabilityHandler,
+			fx.As(new(Route)),
+			fx.ResultTags(`group:"routes"`),
+			fx.ParamTags(``, `name:"dbProductService"`),
+		)),
+		fx.Provide(fx.Annotate(
+			productHandler.NewSearchProductsHandler,
+			fx.As(new(Route)),
+			fx.ResultTags(`group:"routes"`),
+			fx.ParamTags(``, `name:"dbProductService"`),
+		)),
+
+		// Admin handlers
+		fx.Provide(NewAdminGuard),
+		fx.Provide(fx.Annotate(
+			productHandler.NewInventoryExportHandler,
+			fx.As(new(Route)),
+			fx.ResultTags(`group:"routes"`),
+			fx.ParamTags(``, `name:"dbProductService"`, ``),
+		)),
+		fx.Provide(fx.Annotate(
+			productHandler.NewBulkDeleteProductsHandler,
+			fx.As(new(Route)),
+			fx.ResultTags(`group:"routes"`),
+			fx.ParamTags(``, `name:"dbProductService"`, ``),
+		)),
+		fx.Provide(fx.Annotate(
+			productHandler.NewCategoryStatusHandler,
+			fx.As(new(Route)),
+			fx.ResultTags(`group:"routes"`),
+			fx.ParamTags(``, `name:"dbProductService"`, ``),
+		)),
 
 		// gRPC server
 		fx.Provide(fx.Annotate(
 			productHandler.NewGRPCProductServer,
-			fx.ParamTags(``, `name:"dbProductService"`))),
+			fx.ParamTags(``, `name:"dbProductService"`, ``, ``))),
 
 		fx.Provide(fx.Annotate(
 			NewGRPCServer,
@@ -329,16 +561,28 @@ func main() {
 		// Database configuration and connection
 		fx.Provide(GetDBConfig),
 		fx.Provide(config.NewGormDB),
+		fx.Provide(fx.Annotate(health.NewDatabaseCheck, fx.ResultTags(`group:"healthChecks"`))),
+
+		// Product field limits and default ordering
+		fx.Provide(NewFieldLimits),
+		fx.Provide(NewGetProductConfig),
+		fx.Provide(NewProductSort),
 
 		// Redis configuration and connection
 		fx.Provide(NewRedisConfig),
 		fx.Provide(productConfig.NewRedisClient),
+		fx.Provide(fx.Annotate(health.NewRedisCheck, fx.ResultTags(`group:"healthChecks"`))),
+
+		// Entity ID generator
+		fx.Provide(NewIDGenerator),
 
 		// Product repository
+		fx.Provide(NewCacheConfig),
+		fx.Provide(NewProductCache),
 		fx.Provide(productRepository.NewGormProductRepository),
 		fx.Provide(fx.Annotate(
-			func(redis *redis.Client, gormRepo *productRepository.GormProductRepository) productRepository.ProductRepository {
-				return productRepository.NewRedisProductRepository(redis, gormRepo)
+			func(log *zap.Logger, productCache cache.Cache, gormRepo *productRepository.GormProductRepository, cacheConfig productRepository.CacheConfig) productRepository.ProductRepository {
+				return productRepository.NewRedisProductRepository(log, productCache, gormRepo, cacheConfig)
 			},
 			fx.As(new(productRepository.ProductRepository)),
 		)),
@@ -355,9 +599,12 @@ func main() {
 		}),
 		fx.Invoke(func(*gorm.DB) {}),                  // Add DB to invoke to ensure it's initialized
 		fx.Invoke(func(tracer opentracing.Tracer) {}), // Add Tracer to invoke to ensure it's initialized
-		fx.Invoke(func(*MetricsService) {}),           // Add MetricsService to invoke to ensure it's initialized
+		fx.Invoke(func(*metrics.Metrics) {}),          // Add Metrics to invoke to ensure it's initialized
 		fx.Invoke(func(*ProfilingService) {}),         // Add ProfilingService to invoke to ensure it's initialized
 		fx.Invoke(RunMigrations),                      // Run database migrations
+		fx.Invoke(ConfigureTimeFormat),                // Apply the response timestamp layout
+		fx.Invoke(ValidateRouteFilter),                // Validate disabled routes
+		fx.Invoke(ValidateAdminMethods),               // Validate guarded admin gRPC methods
 		fx.Invoke(StartHTTPServer),                    // Start the HTTP server with a graceful shutdown
 		fx.Invoke(StartGRPCServer),                    // Start the gRPC server
 	).Run()
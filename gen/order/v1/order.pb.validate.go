@@ -102,6 +102,12 @@ func (m *Order) validate(all bool) error {
 
 	// no validation rules for UpdatedAt
 
+	// no validation rules for TrackingNumber
+
+	// no validation rules for Carrier
+
+	// no validation rules for ItemsTruncated
+
 	if len(errors) > 0 {
 		return OrderMultiError(errors)
 	}
@@ -784,6 +790,252 @@ var _ interface {
 	ErrorName() string
 } = GetOrderResponseValidationError{}
 
+// Validate checks the field values on ListOrderItemsRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *ListOrderItemsRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on ListOrderItemsRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// ListOrderItemsRequestMultiError, or nil if none found.
+func (m *ListOrderItemsRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *ListOrderItemsRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for OrderId
+
+	// no validation rules for PageSize
+
+	// no validation rules for PageToken
+
+	if len(errors) > 0 {
+		return ListOrderItemsRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// ListOrderItemsRequestMultiError is an error wrapping multiple validation
+// errors returned by ListOrderItemsRequest.ValidateAll() if the designated
+// constraints aren't met.
+type ListOrderItemsRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m ListOrderItemsRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m ListOrderItemsRequestMultiError) AllErrors() []error { return m }
+
+// ListOrderItemsRequestValidationError is the validation error returned by
+// ListOrderItemsRequest.Validate if the designated constraints aren't met.
+type ListOrderItemsRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e ListOrderItemsRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e ListOrderItemsRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e ListOrderItemsRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e ListOrderItemsRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e ListOrderItemsRequestValidationError) ErrorName() string {
+	return "ListOrderItemsRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e ListOrderItemsRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sListOrderItemsRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = ListOrderItemsRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = ListOrderItemsRequestValidationError{}
+
+// Validate checks the field values on ListOrderItemsResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *ListOrderItemsResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on ListOrderItemsResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// ListOrderItemsResponseMultiError, or nil if none found.
+func (m *ListOrderItemsResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *ListOrderItemsResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	for idx, item := range m.GetItems() {
+		_, _ = idx, item
+
+		if all {
+			switch v := interface{}(item).(type) {
+			case interface{ ValidateAll() error }:
+				if err := v.ValidateAll(); err != nil {
+					errors = append(errors, ListOrderItemsResponseValidationError{
+						field:  fmt.Sprintf("Items[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			case interface{ Validate() error }:
+				if err := v.Validate(); err != nil {
+					errors = append(errors, ListOrderItemsResponseValidationError{
+						field:  fmt.Sprintf("Items[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			}
+		} else if v, ok := interface{}(item).(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return ListOrderItemsResponseValidationError{
+					field:  fmt.Sprintf("Items[%v]", idx),
+					reason: "embedded message failed validation",
+					cause:  err,
+				}
+			}
+		}
+
+	}
+
+	// no validation rules for NextPageToken
+
+	if len(errors) > 0 {
+		return ListOrderItemsResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// ListOrderItemsResponseMultiError is an error wrapping multiple validation
+// errors returned by ListOrderItemsResponse.ValidateAll() if the designated
+// constraints aren't met.
+type ListOrderItemsResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m ListOrderItemsResponseMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m ListOrderItemsResponseMultiError) AllErrors() []error { return m }
+
+// ListOrderItemsResponseValidationError is the validation error returned by
+// ListOrderItemsResponse.Validate if the designated constraints aren't met.
+type ListOrderItemsResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e ListOrderItemsResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e ListOrderItemsResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e ListOrderItemsResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e ListOrderItemsResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e ListOrderItemsResponseValidationError) ErrorName() string {
+	return "ListOrderItemsResponseValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e ListOrderItemsResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sListOrderItemsResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = ListOrderItemsResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = ListOrderItemsResponseValidationError{}
+
 // Validate checks the field values on ListOrdersRequest with the rules defined
 // in the proto definition for this message. If any rules are violated, the
 // first error encountered is returned, or nil if there are no violations.
@@ -812,6 +1064,8 @@ func (m *ListOrdersRequest) validate(all bool) error {
 
 	// no validation rules for PageToken
 
+	// no validation rules for IncludeTotal
+
 	if len(errors) > 0 {
 		return ListOrdersRequestMultiError(errors)
 	}
@@ -950,6 +1204,10 @@ func (m *ListOrdersResponse) validate(all bool) error {
 
 	// no validation rules for NextPageToken
 
+	if m.TotalSize != nil {
+		// no validation rules for TotalSize
+	}
+
 	if len(errors) > 0 {
 		return ListOrdersResponseMultiError(errors)
 	}
@@ -1056,6 +1314,10 @@ func (m *UpdateOrderStatusRequest) validate(all bool) error {
 
 	// no validation rules for Status
 
+	// no validation rules for TrackingNumber
+
+	// no validation rules for Carrier
+
 	if len(errors) > 0 {
 		return UpdateOrderStatusRequestMultiError(errors)
 	}
@@ -1266,3 +1528,238 @@ var _ interface {
 	Cause() error
 	ErrorName() string
 } = UpdateOrderStatusResponseValidationError{}
+
+// Validate checks the field values on CancelOrderRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *CancelOrderRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on CancelOrderRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// CancelOrderRequestMultiError, or nil if none found.
+func (m *CancelOrderRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *CancelOrderRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for OrderId
+
+	if len(errors) > 0 {
+		return CancelOrderRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// CancelOrderRequestMultiError is an error wrapping multiple validation errors
+// returned by CancelOrderRequest.ValidateAll() if the designated constraints
+// aren't met.
+type CancelOrderRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m CancelOrderRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m CancelOrderRequestMultiError) AllErrors() []error { return m }
+
+// CancelOrderRequestValidationError is the validation error returned by
+// CancelOrderRequest.Validate if the designated constraints aren't met.
+type CancelOrderRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e CancelOrderRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e CancelOrderRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e CancelOrderRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e CancelOrderRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e CancelOrderRequestValidationError) ErrorName() string {
+	return "CancelOrderRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e CancelOrderRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sCancelOrderRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = CancelOrderRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = CancelOrderRequestValidationError{}
+
+// Validate checks the field values on CancelOrderResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *CancelOrderResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on CancelOrderResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// CancelOrderResponseMultiError, or nil if none found.
+func (m *CancelOrderResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *CancelOrderResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if all {
+		switch v := interface{}(m.GetOrder()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, CancelOrderResponseValidationError{
+					field:  "Order",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, CancelOrderResponseValidationError{
+					field:  "Order",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetOrder()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return CancelOrderResponseValidationError{
+				field:  "Order",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	if len(errors) > 0 {
+		return CancelOrderResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// CancelOrderResponseMultiError is an error wrapping multiple validation
+// errors returned by CancelOrderResponse.ValidateAll() if the designated
+// constraints aren't met.
+type CancelOrderResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m CancelOrderResponseMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m CancelOrderResponseMultiError) AllErrors() []error { return m }
+
+// CancelOrderResponseValidationError is the validation error returned by
+// CancelOrderResponse.Validate if the designated constraints aren't met.
+type CancelOrderResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e CancelOrderResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e CancelOrderResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e CancelOrderResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e CancelOrderResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e CancelOrderResponseValidationError) ErrorName() string {
+	return "CancelOrderResponseValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e CancelOrderResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sCancelOrderResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = CancelOrderResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = CancelOrderResponseValidationError{}
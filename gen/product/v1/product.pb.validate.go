@@ -72,6 +72,22 @@ func (m *Product) validate(all bool) error {
 
 	// no validation rules for UpdatedAt
 
+	// no validation rules for WeightGrams
+
+	// no validation rules for LengthMm
+
+	// no validation rules for WidthMm
+
+	// no validation rules for HeightMm
+
+	// no validation rules for AverageRating
+
+	// no validation rules for ReviewCount
+
+	// no validation rules for Status
+
+	// no validation rules for Version
+
 	if len(errors) > 0 {
 		return ProductMultiError(errors)
 	}
@@ -181,6 +197,14 @@ func (m *CreateProductRequest) validate(all bool) error {
 
 	// no validation rules for Category
 
+	// no validation rules for WeightGrams
+
+	// no validation rules for LengthMm
+
+	// no validation rules for WidthMm
+
+	// no validation rules for HeightMm
+
 	if len(errors) > 0 {
 		return CreateProductRequestMultiError(errors)
 	}
@@ -392,6 +416,279 @@ var _ interface {
 	ErrorName() string
 } = CreateProductResponseValidationError{}
 
+// Validate checks the field values on BatchCreateProductsRequest with the
+// rules defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *BatchCreateProductsRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on BatchCreateProductsRequest with the
+// rules defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// BatchCreateProductsRequestMultiError, or nil if none found.
+func (m *BatchCreateProductsRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *BatchCreateProductsRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	for idx, item := range m.GetProducts() {
+		_, _ = idx, item
+
+		if all {
+			switch v := interface{}(item).(type) {
+			case interface{ ValidateAll() error }:
+				if err := v.ValidateAll(); err != nil {
+					errors = append(errors, BatchCreateProductsRequestValidationError{
+						field:  fmt.Sprintf("Products[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			case interface{ Validate() error }:
+				if err := v.Validate(); err != nil {
+					errors = append(errors, BatchCreateProductsRequestValidationError{
+						field:  fmt.Sprintf("Products[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			}
+		} else if v, ok := interface{}(item).(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return BatchCreateProductsRequestValidationError{
+					field:  fmt.Sprintf("Products[%v]", idx),
+					reason: "embedded message failed validation",
+					cause:  err,
+				}
+			}
+		}
+
+	}
+
+	if len(errors) > 0 {
+		return BatchCreateProductsRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// BatchCreateProductsRequestMultiError is an error wrapping multiple
+// validation errors returned by BatchCreateProductsRequest.ValidateAll() if
+// the designated constraints aren't met.
+type BatchCreateProductsRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m BatchCreateProductsRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m BatchCreateProductsRequestMultiError) AllErrors() []error { return m }
+
+// BatchCreateProductsRequestValidationError is the validation error returned
+// by BatchCreateProductsRequest.Validate if the designated constraints aren't met.
+type BatchCreateProductsRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e BatchCreateProductsRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e BatchCreateProductsRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e BatchCreateProductsRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e BatchCreateProductsRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e BatchCreateProductsRequestValidationError) ErrorName() string {
+	return "BatchCreateProductsRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e BatchCreateProductsRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sBatchCreateProductsRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = BatchCreateProductsRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = BatchCreateProductsRequestValidationError{}
+
+// Validate checks the field values on BatchCreateProductsResponse with the
+// rules defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *BatchCreateProductsResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on BatchCreateProductsResponse with the
+// rules defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// BatchCreateProductsResponseMultiError, or nil if none found.
+func (m *BatchCreateProductsResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *BatchCreateProductsResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	for idx, item := range m.GetProducts() {
+		_, _ = idx, item
+
+		if all {
+			switch v := interface{}(item).(type) {
+			case interface{ ValidateAll() error }:
+				if err := v.ValidateAll(); err != nil {
+					errors = append(errors, BatchCreateProductsResponseValidationError{
+						field:  fmt.Sprintf("Products[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			case interface{ Validate() error }:
+				if err := v.Validate(); err != nil {
+					errors = append(errors, BatchCreateProductsResponseValidationError{
+						field:  fmt.Sprintf("Products[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			}
+		} else if v, ok := interface{}(item).(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return BatchCreateProductsResponseValidationError{
+					field:  fmt.Sprintf("Products[%v]", idx),
+					reason: "embedded message failed validation",
+					cause:  err,
+				}
+			}
+		}
+
+	}
+
+	if len(errors) > 0 {
+		return BatchCreateProductsResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// BatchCreateProductsResponseMultiError is an error wrapping multiple
+// validation errors returned by BatchCreateProductsResponse.ValidateAll() if
+// the designated constraints aren't met.
+type BatchCreateProductsResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m BatchCreateProductsResponseMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m BatchCreateProductsResponseMultiError) AllErrors() []error { return m }
+
+// BatchCreateProductsResponseValidationError is the validation error returned
+// by BatchCreateProductsResponse.Validate if the designated constraints
+// aren't met.
+type BatchCreateProductsResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e BatchCreateProductsResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e BatchCreateProductsResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e BatchCreateProductsResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e BatchCreateProductsResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e BatchCreateProductsResponseValidationError) ErrorName() string {
+	return "BatchCreateProductsResponseValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e BatchCreateProductsResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sBatchCreateProductsResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = BatchCreateProductsResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = BatchCreateProductsResponseValidationError{}
+
 // Validate checks the field values on GetProductRequest with the rules defined
 // in the proto definition for this message. If any rules are violated, the
 // first error encountered is returned, or nil if there are no violations.
@@ -627,48 +924,42 @@ var _ interface {
 	ErrorName() string
 } = GetProductResponseValidationError{}
 
-// Validate checks the field values on ListProductsRequest with the rules
+// Validate checks the field values on BatchGetProductsRequest with the rules
 // defined in the proto definition for this message. If any rules are
 // violated, the first error encountered is returned, or nil if there are no violations.
-func (m *ListProductsRequest) Validate() error {
+func (m *BatchGetProductsRequest) Validate() error {
 	return m.validate(false)
 }
 
-// ValidateAll checks the field values on ListProductsRequest with the rules
-// defined in the proto definition for this message. If any rules are
+// ValidateAll checks the field values on BatchGetProductsRequest with the
+// rules defined in the proto definition for this message. If any rules are
 // violated, the result is a list of violation errors wrapped in
-// ListProductsRequestMultiError, or nil if none found.
-func (m *ListProductsRequest) ValidateAll() error {
+// BatchGetProductsRequestMultiError, or nil if none found.
+func (m *BatchGetProductsRequest) ValidateAll() error {
 	return m.validate(true)
 }
 
-func (m *ListProductsRequest) validate(all bool) error {
+func (m *BatchGetProductsRequest) validate(all bool) error {
 	if m == nil {
 		return nil
 	}
 
 	var errors []error
 
-	// no validation rules for Category
-
-	// no validation rules for PageSize
-
-	// no validation rules for PageToken
-
 	if len(errors) > 0 {
-		return ListProductsRequestMultiError(errors)
+		return BatchGetProductsRequestMultiError(errors)
 	}
 
 	return nil
 }
 
-// ListProductsRequestMultiError is an error wrapping multiple validation
-// errors returned by ListProductsRequest.ValidateAll() if the designated
+// BatchGetProductsRequestMultiError is an error wrapping multiple validation
+// errors returned by BatchGetProductsRequest.ValidateAll() if the designated
 // constraints aren't met.
-type ListProductsRequestMultiError []error
+type BatchGetProductsRequestMultiError []error
 
 // Error returns a concatenation of all the error messages it wraps.
-func (m ListProductsRequestMultiError) Error() string {
+func (m BatchGetProductsRequestMultiError) Error() string {
 	msgs := make([]string, 0, len(m))
 	for _, err := range m {
 		msgs = append(msgs, err.Error())
@@ -677,11 +968,11 @@ func (m ListProductsRequestMultiError) Error() string {
 }
 
 // AllErrors returns a list of validation violation errors.
-func (m ListProductsRequestMultiError) AllErrors() []error { return m }
+func (m BatchGetProductsRequestMultiError) AllErrors() []error { return m }
 
-// ListProductsRequestValidationError is the validation error returned by
-// ListProductsRequest.Validate if the designated constraints aren't met.
-type ListProductsRequestValidationError struct {
+// BatchGetProductsRequestValidationError is the validation error returned by
+// BatchGetProductsRequest.Validate if the designated constraints aren't met.
+type BatchGetProductsRequestValidationError struct {
 	field  string
 	reason string
 	cause  error
@@ -689,24 +980,24 @@ type ListProductsRequestValidationError struct {
 }
 
 // Field function returns field value.
-func (e ListProductsRequestValidationError) Field() string { return e.field }
+func (e BatchGetProductsRequestValidationError) Field() string { return e.field }
 
 // Reason function returns reason value.
-func (e ListProductsRequestValidationError) Reason() string { return e.reason }
+func (e BatchGetProductsRequestValidationError) Reason() string { return e.reason }
 
 // Cause function returns cause value.
-func (e ListProductsRequestValidationError) Cause() error { return e.cause }
+func (e BatchGetProductsRequestValidationError) Cause() error { return e.cause }
 
 // Key function returns key value.
-func (e ListProductsRequestValidationError) Key() bool { return e.key }
+func (e BatchGetProductsRequestValidationError) Key() bool { return e.key }
 
 // ErrorName returns error name.
-func (e ListProductsRequestValidationError) ErrorName() string {
-	return "ListProductsRequestValidationError"
+func (e BatchGetProductsRequestValidationError) ErrorName() string {
+	return "BatchGetProductsRequestValidationError"
 }
 
 // Error satisfies the builtin error interface
-func (e ListProductsRequestValidationError) Error() string {
+func (e BatchGetProductsRequestValidationError) Error() string {
 	cause := ""
 	if e.cause != nil {
 		cause = fmt.Sprintf(" | caused by: %v", e.cause)
@@ -718,14 +1009,14 @@ func (e ListProductsRequestValidationError) Error() string {
 	}
 
 	return fmt.Sprintf(
-		"invalid %sListProductsRequest.%s: %s%s",
+		"invalid %sBatchGetProductsRequest.%s: %s%s",
 		key,
 		e.field,
 		e.reason,
 		cause)
 }
 
-var _ error = ListProductsRequestValidationError{}
+var _ error = BatchGetProductsRequestValidationError{}
 
 var _ interface {
 	Field() string
@@ -733,80 +1024,1392 @@ var _ interface {
 	Key() bool
 	Cause() error
 	ErrorName() string
-} = ListProductsRequestValidationError{}
+} = BatchGetProductsRequestValidationError{}
 
-// Validate checks the field values on ListProductsResponse with the rules
+// Validate checks the field values on BatchGetProductsResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *BatchGetProductsResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on BatchGetProductsResponse with the
+// rules defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// BatchGetProductsResponseMultiError, or nil if none found.
+func (m *BatchGetProductsResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *BatchGetProductsResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	for idx, item := range m.GetProducts() {
+		_, _ = idx, item
+
+		if all {
+			switch v := interface{}(item).(type) {
+			case interface{ ValidateAll() error }:
+				if err := v.ValidateAll(); err != nil {
+					errors = append(errors, BatchGetProductsResponseValidationError{
+						field:  fmt.Sprintf("Products[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			case interface{ Validate() error }:
+				if err := v.Validate(); err != nil {
+					errors = append(errors, BatchGetProductsResponseValidationError{
+						field:  fmt.Sprintf("Products[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			}
+		} else if v, ok := interface{}(item).(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return BatchGetProductsResponseValidationError{
+					field:  fmt.Sprintf("Products[%v]", idx),
+					reason: "embedded message failed validation",
+					cause:  err,
+				}
+			}
+		}
+
+	}
+
+	if len(errors) > 0 {
+		return BatchGetProductsResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// BatchGetProductsResponseMultiError is an error wrapping multiple validation
+// errors returned by BatchGetProductsResponse.ValidateAll() if the designated
+// constraints aren't met.
+type BatchGetProductsResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m BatchGetProductsResponseMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m BatchGetProductsResponseMultiError) AllErrors() []error { return m }
+
+// BatchGetProductsResponseValidationError is the validation error returned by
+// BatchGetProductsResponse.Validate if the designated constraints aren't met.
+type BatchGetProductsResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e BatchGetProductsResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e BatchGetProductsResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e BatchGetProductsResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e BatchGetProductsResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e BatchGetProductsResponseValidationError) ErrorName() string {
+	return "BatchGetProductsResponseValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e BatchGetProductsResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sBatchGetProductsResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = BatchGetProductsResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = BatchGetProductsResponseValidationError{}
+
+// Validate checks the field values on ListProductsRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *ListProductsRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on ListProductsRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// ListProductsRequestMultiError, or nil if none found.
+func (m *ListProductsRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *ListProductsRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for Category
+
+	// no validation rules for PageSize
+
+	// no validation rules for PageToken
+
+	// no validation rules for SortBy
+
+	// no validation rules for SortDir
+
+	// no validation rules for IncludeTotal
+
+	if m.MinPrice != nil {
+		// no validation rules for MinPrice
+	}
+
+	if m.MaxPrice != nil {
+		// no validation rules for MaxPrice
+	}
+
+	if len(errors) > 0 {
+		return ListProductsRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// ListProductsRequestMultiError is an error wrapping multiple validation
+// errors returned by ListProductsRequest.ValidateAll() if the designated
+// constraints aren't met.
+type ListProductsRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m ListProductsRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m ListProductsRequestMultiError) AllErrors() []error { return m }
+
+// ListProductsRequestValidationError is the validation error returned by
+// ListProductsRequest.Validate if the designated constraints aren't met.
+type ListProductsRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e ListProductsRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e ListProductsRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e ListProductsRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e ListProductsRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e ListProductsRequestValidationError) ErrorName() string {
+	return "ListProductsRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e ListProductsRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sListProductsRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = ListProductsRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = ListProductsRequestValidationError{}
+
+// Validate checks the field values on ListProductsResponse with the rules
 // defined in the proto definition for this message. If any rules are
 // violated, the first error encountered is returned, or nil if there are no violations.
 func (m *ListProductsResponse) Validate() error {
 	return m.validate(false)
 }
 
-// ValidateAll checks the field values on ListProductsResponse with the rules
+// ValidateAll checks the field values on ListProductsResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// ListProductsResponseMultiError, or nil if none found.
+func (m *ListProductsResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *ListProductsResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	for idx, item := range m.GetProducts() {
+		_, _ = idx, item
+
+		if all {
+			switch v := interface{}(item).(type) {
+			case interface{ ValidateAll() error }:
+				if err := v.ValidateAll(); err != nil {
+					errors = append(errors, ListProductsResponseValidationError{
+						field:  fmt.Sprintf("Products[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			case interface{ Validate() error }:
+				if err := v.Validate(); err != nil {
+					errors = append(errors, ListProductsResponseValidationError{
+						field:  fmt.Sprintf("Products[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			}
+		} else if v, ok := interface{}(item).(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return ListProductsResponseValidationError{
+					field:  fmt.Sprintf("Products[%v]", idx),
+					reason: "embedded message failed validation",
+					cause:  err,
+				}
+			}
+		}
+
+	}
+
+	// no validation rules for NextPageToken
+
+	if m.TotalSize != nil {
+		// no validation rules for TotalSize
+	}
+
+	if len(errors) > 0 {
+		return ListProductsResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// ListProductsResponseMultiError is an error wrapping multiple validation
+// errors returned by ListProductsResponse.ValidateAll() if the designated
+// constraints aren't met.
+type ListProductsResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m ListProductsResponseMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m ListProductsResponseMultiError) AllErrors() []error { return m }
+
+// ListProductsResponseValidationError is the validation error returned by
+// ListProductsResponse.Validate if the designated constraints aren't met.
+type ListProductsResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e ListProductsResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e ListProductsResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e ListProductsResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e ListProductsResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e ListProductsResponseValidationError) ErrorName() string {
+	return "ListProductsResponseValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e ListProductsResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sListProductsResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = ListProductsResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = ListProductsResponseValidationError{}
+
+// Validate checks the field values on SearchProductsRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *SearchProductsRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on SearchProductsRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// SearchProductsRequestMultiError, or nil if none found.
+func (m *SearchProductsRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *SearchProductsRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for Query
+
+	// no validation rules for PageSize
+
+	// no validation rules for PageToken
+
+	if len(errors) > 0 {
+		return SearchProductsRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// SearchProductsRequestMultiError is an error wrapping multiple validation
+// errors returned by SearchProductsRequest.ValidateAll() if the designated
+// constraints aren't met.
+type SearchProductsRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m SearchProductsRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m SearchProductsRequestMultiError) AllErrors() []error { return m }
+
+// SearchProductsRequestValidationError is the validation error returned by
+// SearchProductsRequest.Validate if the designated constraints aren't met.
+type SearchProductsRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e SearchProductsRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e SearchProductsRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e SearchProductsRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e SearchProductsRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e SearchProductsRequestValidationError) ErrorName() string {
+	return "SearchProductsRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e SearchProductsRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sSearchProductsRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = SearchProductsRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = SearchProductsRequestValidationError{}
+
+// Validate checks the field values on SearchProductsResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *SearchProductsResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on SearchProductsResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// SearchProductsResponseMultiError, or nil if none found.
+func (m *SearchProductsResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *SearchProductsResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	for idx, item := range m.GetProducts() {
+		_, _ = idx, item
+
+		if all {
+			switch v := interface{}(item).(type) {
+			case interface{ ValidateAll() error }:
+				if err := v.ValidateAll(); err != nil {
+					errors = append(errors, SearchProductsResponseValidationError{
+						field:  fmt.Sprintf("Products[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			case interface{ Validate() error }:
+				if err := v.Validate(); err != nil {
+					errors = append(errors, SearchProductsResponseValidationError{
+						field:  fmt.Sprintf("Products[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			}
+		} else if v, ok := interface{}(item).(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return SearchProductsResponseValidationError{
+					field:  fmt.Sprintf("Products[%v]", idx),
+					reason: "embedded message failed validation",
+					cause:  err,
+				}
+			}
+		}
+
+	}
+
+	// no validation rules for NextPageToken
+
+	if len(errors) > 0 {
+		return SearchProductsResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// SearchProductsResponseMultiError is an error wrapping multiple validation
+// errors returned by SearchProductsResponse.ValidateAll() if the designated
+// constraints aren't met.
+type SearchProductsResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m SearchProductsResponseMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m SearchProductsResponseMultiError) AllErrors() []error { return m }
+
+// SearchProductsResponseValidationError is the validation error returned by
+// SearchProductsResponse.Validate if the designated constraints aren't met.
+type SearchProductsResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e SearchProductsResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e SearchProductsResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e SearchProductsResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e SearchProductsResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e SearchProductsResponseValidationError) ErrorName() string {
+	return "SearchProductsResponseValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e SearchProductsResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sSearchProductsResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = SearchProductsResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = SearchProductsResponseValidationError{}
+
+// Validate checks the field values on UpdateProductRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *UpdateProductRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on UpdateProductRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// UpdateProductRequestMultiError, or nil if none found.
+func (m *UpdateProductRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *UpdateProductRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for ProductId
+
+	// no validation rules for Name
+
+	// no validation rules for Description
+
+	// no validation rules for Price
+
+	// no validation rules for Stock
+
+	// no validation rules for Category
+
+	// no validation rules for WeightGrams
+
+	// no validation rules for LengthMm
+
+	// no validation rules for WidthMm
+
+	// no validation rules for HeightMm
+
+	// no validation rules for Version
+
+	if len(errors) > 0 {
+		return UpdateProductRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// UpdateProductRequestMultiError is an error wrapping multiple validation
+// errors returned by UpdateProductRequest.ValidateAll() if the designated
+// constraints aren't met.
+type UpdateProductRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m UpdateProductRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m UpdateProductRequestMultiError) AllErrors() []error { return m }
+
+// UpdateProductRequestValidationError is the validation error returned by
+// UpdateProductRequest.Validate if the designated constraints aren't met.
+type UpdateProductRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e UpdateProductRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e UpdateProductRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e UpdateProductRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e UpdateProductRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e UpdateProductRequestValidationError) ErrorName() string {
+	return "UpdateProductRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e UpdateProductRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sUpdateProductRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = UpdateProductRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = UpdateProductRequestValidationError{}
+
+// Validate checks the field values on UpdateProductResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *UpdateProductResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on UpdateProductResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// UpdateProductResponseMultiError, or nil if none found.
+func (m *UpdateProductResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *UpdateProductResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if all {
+		switch v := interface{}(m.GetProduct()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, UpdateProductResponseValidationError{
+					field:  "Product",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, UpdateProductResponseValidationError{
+					field:  "Product",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetProduct()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return UpdateProductResponseValidationError{
+				field:  "Product",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	if len(errors) > 0 {
+		return UpdateProductResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// UpdateProductResponseMultiError is an error wrapping multiple validation
+// errors returned by UpdateProductResponse.ValidateAll() if the designated
+// constraints aren't met.
+type UpdateProductResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m UpdateProductResponseMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m UpdateProductResponseMultiError) AllErrors() []error { return m }
+
+// UpdateProductResponseValidationError is the validation error returned by
+// UpdateProductResponse.Validate if the designated constraints aren't met.
+type UpdateProductResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e UpdateProductResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e UpdateProductResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e UpdateProductResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e UpdateProductResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e UpdateProductResponseValidationError) ErrorName() string {
+	return "UpdateProductResponseValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e UpdateProductResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sUpdateProductResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = UpdateProductResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = UpdateProductResponseValidationError{}
+
+// Validate checks the field values on DeleteProductRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *DeleteProductRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on DeleteProductRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// DeleteProductRequestMultiError, or nil if none found.
+func (m *DeleteProductRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *DeleteProductRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for ProductId
+
+	if len(errors) > 0 {
+		return DeleteProductRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// DeleteProductRequestMultiError is an error wrapping multiple validation
+// errors returned by DeleteProductRequest.ValidateAll() if the designated
+// constraints aren't met.
+type DeleteProductRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m DeleteProductRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m DeleteProductRequestMultiError) AllErrors() []error { return m }
+
+// DeleteProductRequestValidationError is the validation error returned by
+// DeleteProductRequest.Validate if the designated constraints aren't met.
+type DeleteProductRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e DeleteProductRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e DeleteProductRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e DeleteProductRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e DeleteProductRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e DeleteProductRequestValidationError) ErrorName() string {
+	return "DeleteProductRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e DeleteProductRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sDeleteProductRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = DeleteProductRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = DeleteProductRequestValidationError{}
+
+// Validate checks the field values on DeleteProductResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *DeleteProductResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on DeleteProductResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// DeleteProductResponseMultiError, or nil if none found.
+func (m *DeleteProductResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *DeleteProductResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for Success
+
+	if len(errors) > 0 {
+		return DeleteProductResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// DeleteProductResponseMultiError is an error wrapping multiple validation
+// errors returned by DeleteProductResponse.ValidateAll() if the designated
+// constraints aren't met.
+type DeleteProductResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m DeleteProductResponseMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m DeleteProductResponseMultiError) AllErrors() []error { return m }
+
+// DeleteProductResponseValidationError is the validation error returned by
+// DeleteProductResponse.Validate if the designated constraints aren't met.
+type DeleteProductResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e DeleteProductResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e DeleteProductResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e DeleteProductResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e DeleteProductResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e DeleteProductResponseValidationError) ErrorName() string {
+	return "DeleteProductResponseValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e DeleteProductResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sDeleteProductResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = DeleteProductResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = DeleteProductResponseValidationError{}
+
+// Validate checks the field values on StockItem with the rules defined in the
+// proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *StockItem) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on StockItem with the rules defined in
+// the proto definition for this message. If any rules are violated, the
+// result is a list of violation errors wrapped in StockItemMultiError, or nil
+// if none found.
+func (m *StockItem) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *StockItem) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for ProductId
+
+	// no validation rules for Quantity
+
+	if len(errors) > 0 {
+		return StockItemMultiError(errors)
+	}
+
+	return nil
+}
+
+// StockItemMultiError is an error wrapping multiple validation errors returned
+// by StockItem.ValidateAll() if the designated constraints aren't met.
+type StockItemMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m StockItemMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m StockItemMultiError) AllErrors() []error { return m }
+
+// StockItemValidationError is the validation error returned by
+// StockItem.Validate if the designated constraints aren't met.
+type StockItemValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e StockItemValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e StockItemValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e StockItemValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e StockItemValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e StockItemValidationError) ErrorName() string { return "StockItemValidationError" }
+
+// Error satisfies the builtin error interface
+func (e StockItemValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sStockItem.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = StockItemValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = StockItemValidationError{}
+
+// Validate checks the field values on ReserveStockRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *ReserveStockRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on ReserveStockRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// ReserveStockRequestMultiError, or nil if none found.
+func (m *ReserveStockRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *ReserveStockRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	for idx, item := range m.GetItems() {
+		_, _ = idx, item
+
+		if all {
+			switch v := interface{}(item).(type) {
+			case interface{ ValidateAll() error }:
+				if err := v.ValidateAll(); err != nil {
+					errors = append(errors, ReserveStockRequestValidationError{
+						field:  fmt.Sprintf("Items[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			case interface{ Validate() error }:
+				if err := v.Validate(); err != nil {
+					errors = append(errors, ReserveStockRequestValidationError{
+						field:  fmt.Sprintf("Items[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			}
+		} else if v, ok := interface{}(item).(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return ReserveStockRequestValidationError{
+					field:  fmt.Sprintf("Items[%v]", idx),
+					reason: "embedded message failed validation",
+					cause:  err,
+				}
+			}
+		}
+
+	}
+
+	if len(errors) > 0 {
+		return ReserveStockRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// ReserveStockRequestMultiError is an error wrapping multiple validation
+// errors returned by ReserveStockRequest.ValidateAll() if the designated
+// constraints aren't met.
+type ReserveStockRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m ReserveStockRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m ReserveStockRequestMultiError) AllErrors() []error { return m }
+
+// ReserveStockRequestValidationError is the validation error returned by
+// ReserveStockRequest.Validate if the designated constraints aren't met.
+type ReserveStockRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e ReserveStockRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e ReserveStockRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e ReserveStockRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e ReserveStockRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e ReserveStockRequestValidationError) ErrorName() string {
+	return "ReserveStockRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e ReserveStockRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sReserveStockRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = ReserveStockRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = ReserveStockRequestValidationError{}
+
+// Validate checks the field values on ReserveStockResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *ReserveStockResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on ReserveStockResponse with the rules
 // defined in the proto definition for this message. If any rules are
 // violated, the result is a list of violation errors wrapped in
-// ListProductsResponseMultiError, or nil if none found.
-func (m *ListProductsResponse) ValidateAll() error {
+// ReserveStockResponseMultiError, or nil if none found.
+func (m *ReserveStockResponse) ValidateAll() error {
 	return m.validate(true)
 }
 
-func (m *ListProductsResponse) validate(all bool) error {
+func (m *ReserveStockResponse) validate(all bool) error {
 	if m == nil {
 		return nil
 	}
 
 	var errors []error
 
-	for idx, item := range m.GetProducts() {
-		_, _ = idx, item
-
-		if all {
-			switch v := interface{}(item).(type) {
-			case interface{ ValidateAll() error }:
-				if err := v.ValidateAll(); err != nil {
-					errors = append(errors, ListProductsResponseValidationError{
-						field:  fmt.Sprintf("Products[%v]", idx),
-						reason: "embedded message failed validation",
-						cause:  err,
-					})
-				}
-			case interface{ Validate() error }:
-				if err := v.Validate(); err != nil {
-					errors = append(errors, ListProductsResponseValidationError{
-						field:  fmt.Sprintf("Products[%v]", idx),
-						reason: "embedded message failed validation",
-						cause:  err,
-					})
-				}
-			}
-		} else if v, ok := interface{}(item).(interface{ Validate() error }); ok {
-			if err := v.Validate(); err != nil {
-				return ListProductsResponseValidationError{
-					field:  fmt.Sprintf("Products[%v]", idx),
-					reason: "embedded message failed validation",
-					cause:  err,
-				}
-			}
-		}
-
-	}
-
-	// no validation rules for NextPageToken
-
 	if len(errors) > 0 {
-		return ListProductsResponseMultiError(errors)
+		return ReserveStockResponseMultiError(errors)
 	}
 
 	return nil
 }
 
-// ListProductsResponseMultiError is an error wrapping multiple validation
-// errors returned by ListProductsResponse.ValidateAll() if the designated
+// ReserveStockResponseMultiError is an error wrapping multiple validation
+// errors returned by ReserveStockResponse.ValidateAll() if the designated
 // constraints aren't met.
-type ListProductsResponseMultiError []error
+type ReserveStockResponseMultiError []error
 
 // Error returns a concatenation of all the error messages it wraps.
-func (m ListProductsResponseMultiError) Error() string {
+func (m ReserveStockResponseMultiError) Error() string {
 	msgs := make([]string, 0, len(m))
 	for _, err := range m {
 		msgs = append(msgs, err.Error())
@@ -815,11 +2418,11 @@ func (m ListProductsResponseMultiError) Error() string {
 }
 
 // AllErrors returns a list of validation violation errors.
-func (m ListProductsResponseMultiError) AllErrors() []error { return m }
+func (m ReserveStockResponseMultiError) AllErrors() []error { return m }
 
-// ListProductsResponseValidationError is the validation error returned by
-// ListProductsResponse.Validate if the designated constraints aren't met.
-type ListProductsResponseValidationError struct {
+// ReserveStockResponseValidationError is the validation error returned by
+// ReserveStockResponse.Validate if the designated constraints aren't met.
+type ReserveStockResponseValidationError struct {
 	field  string
 	reason string
 	cause  error
@@ -827,24 +2430,24 @@ type ListProductsResponseValidationError struct {
 }
 
 // Field function returns field value.
-func (e ListProductsResponseValidationError) Field() string { return e.field }
+func (e ReserveStockResponseValidationError) Field() string { return e.field }
 
 // Reason function returns reason value.
-func (e ListProductsResponseValidationError) Reason() string { return e.reason }
+func (e ReserveStockResponseValidationError) Reason() string { return e.reason }
 
 // Cause function returns cause value.
-func (e ListProductsResponseValidationError) Cause() error { return e.cause }
+func (e ReserveStockResponseValidationError) Cause() error { return e.cause }
 
 // Key function returns key value.
-func (e ListProductsResponseValidationError) Key() bool { return e.key }
+func (e ReserveStockResponseValidationError) Key() bool { return e.key }
 
 // ErrorName returns error name.
-func (e ListProductsResponseValidationError) ErrorName() string {
-	return "ListProductsResponseValidationError"
+func (e ReserveStockResponseValidationError) ErrorName() string {
+	return "ReserveStockResponseValidationError"
 }
 
 // Error satisfies the builtin error interface
-func (e ListProductsResponseValidationError) Error() string {
+func (e ReserveStockResponseValidationError) Error() string {
 	cause := ""
 	if e.cause != nil {
 		cause = fmt.Sprintf(" | caused by: %v", e.cause)
@@ -856,14 +2459,14 @@ func (e ListProductsResponseValidationError) Error() string {
 	}
 
 	return fmt.Sprintf(
-		"invalid %sListProductsResponse.%s: %s%s",
+		"invalid %sReserveStockResponse.%s: %s%s",
 		key,
 		e.field,
 		e.reason,
 		cause)
 }
 
-var _ error = ListProductsResponseValidationError{}
+var _ error = ReserveStockResponseValidationError{}
 
 var _ interface {
 	Field() string
@@ -871,56 +2474,78 @@ var _ interface {
 	Key() bool
 	Cause() error
 	ErrorName() string
-} = ListProductsResponseValidationError{}
+} = ReserveStockResponseValidationError{}
 
-// Validate checks the field values on UpdateProductRequest with the rules
+// Validate checks the field values on ReleaseStockRequest with the rules
 // defined in the proto definition for this message. If any rules are
 // violated, the first error encountered is returned, or nil if there are no violations.
-func (m *UpdateProductRequest) Validate() error {
+func (m *ReleaseStockRequest) Validate() error {
 	return m.validate(false)
 }
 
-// ValidateAll checks the field values on UpdateProductRequest with the rules
+// ValidateAll checks the field values on ReleaseStockRequest with the rules
 // defined in the proto definition for this message. If any rules are
 // violated, the result is a list of violation errors wrapped in
-// UpdateProductRequestMultiError, or nil if none found.
-func (m *UpdateProductRequest) ValidateAll() error {
+// ReleaseStockRequestMultiError, or nil if none found.
+func (m *ReleaseStockRequest) ValidateAll() error {
 	return m.validate(true)
 }
 
-func (m *UpdateProductRequest) validate(all bool) error {
+func (m *ReleaseStockRequest) validate(all bool) error {
 	if m == nil {
 		return nil
 	}
 
 	var errors []error
 
-	// no validation rules for ProductId
-
-	// no validation rules for Name
-
-	// no validation rules for Description
-
-	// no validation rules for Price
+	for idx, item := range m.GetItems() {
+		_, _ = idx, item
 
-	// no validation rules for Stock
+		if all {
+			switch v := interface{}(item).(type) {
+			case interface{ ValidateAll() error }:
+				if err := v.ValidateAll(); err != nil {
+					errors = append(errors, ReleaseStockRequestValidationError{
+						field:  fmt.Sprintf("Items[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			case interface{ Validate() error }:
+				if err := v.Validate(); err != nil {
+					errors = append(errors, ReleaseStockRequestValidationError{
+						field:  fmt.Sprintf("Items[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			}
+		} else if v, ok := interface{}(item).(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return ReleaseStockRequestValidationError{
+					field:  fmt.Sprintf("Items[%v]", idx),
+					reason: "embedded message failed validation",
+					cause:  err,
+				}
+			}
+		}
 
-	// no validation rules for Category
+	}
 
 	if len(errors) > 0 {
-		return UpdateProductRequestMultiError(errors)
+		return ReleaseStockRequestMultiError(errors)
 	}
 
 	return nil
 }
 
-// UpdateProductRequestMultiError is an error wrapping multiple validation
-// errors returned by UpdateProductRequest.ValidateAll() if the designated
+// ReleaseStockRequestMultiError is an error wrapping multiple validation
+// errors returned by ReleaseStockRequest.ValidateAll() if the designated
 // constraints aren't met.
-type UpdateProductRequestMultiError []error
+type ReleaseStockRequestMultiError []error
 
 // Error returns a concatenation of all the error messages it wraps.
-func (m UpdateProductRequestMultiError) Error() string {
+func (m ReleaseStockRequestMultiError) Error() string {
 	msgs := make([]string, 0, len(m))
 	for _, err := range m {
 		msgs = append(msgs, err.Error())
@@ -929,11 +2554,11 @@ func (m UpdateProductRequestMultiError) Error() string {
 }
 
 // AllErrors returns a list of validation violation errors.
-func (m UpdateProductRequestMultiError) AllErrors() []error { return m }
+func (m ReleaseStockRequestMultiError) AllErrors() []error { return m }
 
-// UpdateProductRequestValidationError is the validation error returned by
-// UpdateProductRequest.Validate if the designated constraints aren't met.
-type UpdateProductRequestValidationError struct {
+// ReleaseStockRequestValidationError is the validation error returned by
+// ReleaseStockRequest.Validate if the designated constraints aren't met.
+type ReleaseStockRequestValidationError struct {
 	field  string
 	reason string
 	cause  error
@@ -941,24 +2566,24 @@ type UpdateProductRequestValidationError struct {
 }
 
 // Field function returns field value.
-func (e UpdateProductRequestValidationError) Field() string { return e.field }
+func (e ReleaseStockRequestValidationError) Field() string { return e.field }
 
 // Reason function returns reason value.
-func (e UpdateProductRequestValidationError) Reason() string { return e.reason }
+func (e ReleaseStockRequestValidationError) Reason() string { return e.reason }
 
 // Cause function returns cause value.
-func (e UpdateProductRequestValidationError) Cause() error { return e.cause }
+func (e ReleaseStockRequestValidationError) Cause() error { return e.cause }
 
 // Key function returns key value.
-func (e UpdateProductRequestValidationError) Key() bool { return e.key }
+func (e ReleaseStockRequestValidationError) Key() bool { return e.key }
 
 // ErrorName returns error name.
-func (e UpdateProductRequestValidationError) ErrorName() string {
-	return "UpdateProductRequestValidationError"
+func (e ReleaseStockRequestValidationError) ErrorName() string {
+	return "ReleaseStockRequestValidationError"
 }
 
 // Error satisfies the builtin error interface
-func (e UpdateProductRequestValidationError) Error() string {
+func (e ReleaseStockRequestValidationError) Error() string {
 	cause := ""
 	if e.cause != nil {
 		cause = fmt.Sprintf(" | caused by: %v", e.cause)
@@ -970,14 +2595,14 @@ func (e UpdateProductRequestValidationError) Error() string {
 	}
 
 	return fmt.Sprintf(
-		"invalid %sUpdateProductRequest.%s: %s%s",
+		"invalid %sReleaseStockRequest.%s: %s%s",
 		key,
 		e.field,
 		e.reason,
 		cause)
 }
 
-var _ error = UpdateProductRequestValidationError{}
+var _ error = ReleaseStockRequestValidationError{}
 
 var _ interface {
 	Field() string
@@ -985,73 +2610,44 @@ var _ interface {
 	Key() bool
 	Cause() error
 	ErrorName() string
-} = UpdateProductRequestValidationError{}
+} = ReleaseStockRequestValidationError{}
 
-// Validate checks the field values on UpdateProductResponse with the rules
+// Validate checks the field values on ReleaseStockResponse with the rules
 // defined in the proto definition for this message. If any rules are
 // violated, the first error encountered is returned, or nil if there are no violations.
-func (m *UpdateProductResponse) Validate() error {
+func (m *ReleaseStockResponse) Validate() error {
 	return m.validate(false)
 }
 
-// ValidateAll checks the field values on UpdateProductResponse with the rules
+// ValidateAll checks the field values on ReleaseStockResponse with the rules
 // defined in the proto definition for this message. If any rules are
 // violated, the result is a list of violation errors wrapped in
-// UpdateProductResponseMultiError, or nil if none found.
-func (m *UpdateProductResponse) ValidateAll() error {
+// ReleaseStockResponseMultiError, or nil if none found.
+func (m *ReleaseStockResponse) ValidateAll() error {
 	return m.validate(true)
 }
 
-func (m *UpdateProductResponse) validate(all bool) error {
+func (m *ReleaseStockResponse) validate(all bool) error {
 	if m == nil {
 		return nil
 	}
 
 	var errors []error
 
-	if all {
-		switch v := interface{}(m.GetProduct()).(type) {
-		case interface{ ValidateAll() error }:
-			if err := v.ValidateAll(); err != nil {
-				errors = append(errors, UpdateProductResponseValidationError{
-					field:  "Product",
-					reason: "embedded message failed validation",
-					cause:  err,
-				})
-			}
-		case interface{ Validate() error }:
-			if err := v.Validate(); err != nil {
-				errors = append(errors, UpdateProductResponseValidationError{
-					field:  "Product",
-					reason: "embedded message failed validation",
-					cause:  err,
-				})
-			}
-		}
-	} else if v, ok := interface{}(m.GetProduct()).(interface{ Validate() error }); ok {
-		if err := v.Validate(); err != nil {
-			return UpdateProductResponseValidationError{
-				field:  "Product",
-				reason: "embedded message failed validation",
-				cause:  err,
-			}
-		}
-	}
-
 	if len(errors) > 0 {
-		return UpdateProductResponseMultiError(errors)
+		return ReleaseStockResponseMultiError(errors)
 	}
 
 	return nil
 }
 
-// UpdateProductResponseMultiError is an error wrapping multiple validation
-// errors returned by UpdateProductResponse.ValidateAll() if the designated
+// ReleaseStockResponseMultiError is an error wrapping multiple validation
+// errors returned by ReleaseStockResponse.ValidateAll() if the designated
 // constraints aren't met.
-type UpdateProductResponseMultiError []error
+type ReleaseStockResponseMultiError []error
 
 // Error returns a concatenation of all the error messages it wraps.
-func (m UpdateProductResponseMultiError) Error() string {
+func (m ReleaseStockResponseMultiError) Error() string {
 	msgs := make([]string, 0, len(m))
 	for _, err := range m {
 		msgs = append(msgs, err.Error())
@@ -1060,11 +2656,11 @@ func (m UpdateProductResponseMultiError) Error() string {
 }
 
 // AllErrors returns a list of validation violation errors.
-func (m UpdateProductResponseMultiError) AllErrors() []error { return m }
+func (m ReleaseStockResponseMultiError) AllErrors() []error { return m }
 
-// UpdateProductResponseValidationError is the validation error returned by
-// UpdateProductResponse.Validate if the designated constraints aren't met.
-type UpdateProductResponseValidationError struct {
+// ReleaseStockResponseValidationError is the validation error returned by
+// ReleaseStockResponse.Validate if the designated constraints aren't met.
+type ReleaseStockResponseValidationError struct {
 	field  string
 	reason string
 	cause  error
@@ -1072,24 +2668,24 @@ type UpdateProductResponseValidationError struct {
 }
 
 // Field function returns field value.
-func (e UpdateProductResponseValidationError) Field() string { return e.field }
+func (e ReleaseStockResponseValidationError) Field() string { return e.field }
 
 // Reason function returns reason value.
-func (e UpdateProductResponseValidationError) Reason() string { return e.reason }
+func (e ReleaseStockResponseValidationError) Reason() string { return e.reason }
 
 // Cause function returns cause value.
-func (e UpdateProductResponseValidationError) Cause() error { return e.cause }
+func (e ReleaseStockResponseValidationError) Cause() error { return e.cause }
 
 // Key function returns key value.
-func (e UpdateProductResponseValidationError) Key() bool { return e.key }
+func (e ReleaseStockResponseValidationError) Key() bool { return e.key }
 
 // ErrorName returns error name.
-func (e UpdateProductResponseValidationError) ErrorName() string {
-	return "UpdateProductResponseValidationError"
+func (e ReleaseStockResponseValidationError) ErrorName() string {
+	return "ReleaseStockResponseValidationError"
 }
 
 // Error satisfies the builtin error interface
-func (e UpdateProductResponseValidationError) Error() string {
+func (e ReleaseStockResponseValidationError) Error() string {
 	cause := ""
 	if e.cause != nil {
 		cause = fmt.Sprintf(" | caused by: %v", e.cause)
@@ -1101,14 +2697,14 @@ func (e UpdateProductResponseValidationError) Error() string {
 	}
 
 	return fmt.Sprintf(
-		"invalid %sUpdateProductResponse.%s: %s%s",
+		"invalid %sReleaseStockResponse.%s: %s%s",
 		key,
 		e.field,
 		e.reason,
 		cause)
 }
 
-var _ error = UpdateProductResponseValidationError{}
+var _ error = ReleaseStockResponseValidationError{}
 
 var _ interface {
 	Field() string
@@ -1116,24 +2712,24 @@ var _ interface {
 	Key() bool
 	Cause() error
 	ErrorName() string
-} = UpdateProductResponseValidationError{}
+} = ReleaseStockResponseValidationError{}
 
-// Validate checks the field values on DeleteProductRequest with the rules
+// Validate checks the field values on UpdateStockRequest with the rules
 // defined in the proto definition for this message. If any rules are
 // violated, the first error encountered is returned, or nil if there are no violations.
-func (m *DeleteProductRequest) Validate() error {
+func (m *UpdateStockRequest) Validate() error {
 	return m.validate(false)
 }
 
-// ValidateAll checks the field values on DeleteProductRequest with the rules
+// ValidateAll checks the field values on UpdateStockRequest with the rules
 // defined in the proto definition for this message. If any rules are
 // violated, the result is a list of violation errors wrapped in
-// DeleteProductRequestMultiError, or nil if none found.
-func (m *DeleteProductRequest) ValidateAll() error {
+// UpdateStockRequestMultiError, or nil if none found.
+func (m *UpdateStockRequest) ValidateAll() error {
 	return m.validate(true)
 }
 
-func (m *DeleteProductRequest) validate(all bool) error {
+func (m *UpdateStockRequest) validate(all bool) error {
 	if m == nil {
 		return nil
 	}
@@ -1142,20 +2738,22 @@ func (m *DeleteProductRequest) validate(all bool) error {
 
 	// no validation rules for ProductId
 
+	// no validation rules for Stock
+
 	if len(errors) > 0 {
-		return DeleteProductRequestMultiError(errors)
+		return UpdateStockRequestMultiError(errors)
 	}
 
 	return nil
 }
 
-// DeleteProductRequestMultiError is an error wrapping multiple validation
-// errors returned by DeleteProductRequest.ValidateAll() if the designated
-// constraints aren't met.
-type DeleteProductRequestMultiError []error
+// UpdateStockRequestMultiError is an error wrapping multiple validation errors
+// returned by UpdateStockRequest.ValidateAll() if the designated constraints
+// aren't met.
+type UpdateStockRequestMultiError []error
 
 // Error returns a concatenation of all the error messages it wraps.
-func (m DeleteProductRequestMultiError) Error() string {
+func (m UpdateStockRequestMultiError) Error() string {
 	msgs := make([]string, 0, len(m))
 	for _, err := range m {
 		msgs = append(msgs, err.Error())
@@ -1164,11 +2762,11 @@ func (m DeleteProductRequestMultiError) Error() string {
 }
 
 // AllErrors returns a list of validation violation errors.
-func (m DeleteProductRequestMultiError) AllErrors() []error { return m }
+func (m UpdateStockRequestMultiError) AllErrors() []error { return m }
 
-// DeleteProductRequestValidationError is the validation error returned by
-// DeleteProductRequest.Validate if the designated constraints aren't met.
-type DeleteProductRequestValidationError struct {
+// UpdateStockRequestValidationError is the validation error returned by
+// UpdateStockRequest.Validate if the designated constraints aren't met.
+type UpdateStockRequestValidationError struct {
 	field  string
 	reason string
 	cause  error
@@ -1176,24 +2774,24 @@ type DeleteProductRequestValidationError struct {
 }
 
 // Field function returns field value.
-func (e DeleteProductRequestValidationError) Field() string { return e.field }
+func (e UpdateStockRequestValidationError) Field() string { return e.field }
 
 // Reason function returns reason value.
-func (e DeleteProductRequestValidationError) Reason() string { return e.reason }
+func (e UpdateStockRequestValidationError) Reason() string { return e.reason }
 
 // Cause function returns cause value.
-func (e DeleteProductRequestValidationError) Cause() error { return e.cause }
+func (e UpdateStockRequestValidationError) Cause() error { return e.cause }
 
 // Key function returns key value.
-func (e DeleteProductRequestValidationError) Key() bool { return e.key }
+func (e UpdateStockRequestValidationError) Key() bool { return e.key }
 
 // ErrorName returns error name.
-func (e DeleteProductRequestValidationError) ErrorName() string {
-	return "DeleteProductRequestValidationError"
+func (e UpdateStockRequestValidationError) ErrorName() string {
+	return "UpdateStockRequestValidationError"
 }
 
 // Error satisfies the builtin error interface
-func (e DeleteProductRequestValidationError) Error() string {
+func (e UpdateStockRequestValidationError) Error() string {
 	cause := ""
 	if e.cause != nil {
 		cause = fmt.Sprintf(" | caused by: %v", e.cause)
@@ -1205,14 +2803,14 @@ func (e DeleteProductRequestValidationError) Error() string {
 	}
 
 	return fmt.Sprintf(
-		"invalid %sDeleteProductRequest.%s: %s%s",
+		"invalid %sUpdateStockRequest.%s: %s%s",
 		key,
 		e.field,
 		e.reason,
 		cause)
 }
 
-var _ error = DeleteProductRequestValidationError{}
+var _ error = UpdateStockRequestValidationError{}
 
 var _ interface {
 	Field() string
@@ -1220,46 +2818,73 @@ var _ interface {
 	Key() bool
 	Cause() error
 	ErrorName() string
-} = DeleteProductRequestValidationError{}
+} = UpdateStockRequestValidationError{}
 
-// Validate checks the field values on DeleteProductResponse with the rules
+// Validate checks the field values on UpdateStockResponse with the rules
 // defined in the proto definition for this message. If any rules are
 // violated, the first error encountered is returned, or nil if there are no violations.
-func (m *DeleteProductResponse) Validate() error {
+func (m *UpdateStockResponse) Validate() error {
 	return m.validate(false)
 }
 
-// ValidateAll checks the field values on DeleteProductResponse with the rules
+// ValidateAll checks the field values on UpdateStockResponse with the rules
 // defined in the proto definition for this message. If any rules are
 // violated, the result is a list of violation errors wrapped in
-// DeleteProductResponseMultiError, or nil if none found.
-func (m *DeleteProductResponse) ValidateAll() error {
+// UpdateStockResponseMultiError, or nil if none found.
+func (m *UpdateStockResponse) ValidateAll() error {
 	return m.validate(true)
 }
 
-func (m *DeleteProductResponse) validate(all bool) error {
+func (m *UpdateStockResponse) validate(all bool) error {
 	if m == nil {
 		return nil
 	}
 
 	var errors []error
 
-	// no validation rules for Success
+	if all {
+		switch v := interface{}(m.GetProduct()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, UpdateStockResponseValidationError{
+					field:  "Product",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, UpdateStockResponseValidationError{
+					field:  "Product",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetProduct()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return UpdateStockResponseValidationError{
+				field:  "Product",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
 
 	if len(errors) > 0 {
-		return DeleteProductResponseMultiError(errors)
+		return UpdateStockResponseMultiError(errors)
 	}
 
 	return nil
 }
 
-// DeleteProductResponseMultiError is an error wrapping multiple validation
-// errors returned by DeleteProductResponse.ValidateAll() if the designated
+// UpdateStockResponseMultiError is an error wrapping multiple validation
+// errors returned by UpdateStockResponse.ValidateAll() if the designated
 // constraints aren't met.
-type DeleteProductResponseMultiError []error
+type UpdateStockResponseMultiError []error
 
 // Error returns a concatenation of all the error messages it wraps.
-func (m DeleteProductResponseMultiError) Error() string {
+func (m UpdateStockResponseMultiError) Error() string {
 	msgs := make([]string, 0, len(m))
 	for _, err := range m {
 		msgs = append(msgs, err.Error())
@@ -1268,11 +2893,11 @@ func (m DeleteProductResponseMultiError) Error() string {
 }
 
 // AllErrors returns a list of validation violation errors.
-func (m DeleteProductResponseMultiError) AllErrors() []error { return m }
+func (m UpdateStockResponseMultiError) AllErrors() []error { return m }
 
-// DeleteProductResponseValidationError is the validation error returned by
-// DeleteProductResponse.Validate if the designated constraints aren't met.
-type DeleteProductResponseValidationError struct {
+// UpdateStockResponseValidationError is the validation error returned by
+// UpdateStockResponse.Validate if the designated constraints aren't met.
+type UpdateStockResponseValidationError struct {
 	field  string
 	reason string
 	cause  error
@@ -1280,24 +2905,24 @@ type DeleteProductResponseValidationError struct {
 }
 
 // Field function returns field value.
-func (e DeleteProductResponseValidationError) Field() string { return e.field }
+func (e UpdateStockResponseValidationError) Field() string { return e.field }
 
 // Reason function returns reason value.
-func (e DeleteProductResponseValidationError) Reason() string { return e.reason }
+func (e UpdateStockResponseValidationError) Reason() string { return e.reason }
 
 // Cause function returns cause value.
-func (e DeleteProductResponseValidationError) Cause() error { return e.cause }
+func (e UpdateStockResponseValidationError) Cause() error { return e.cause }
 
 // Key function returns key value.
-func (e DeleteProductResponseValidationError) Key() bool { return e.key }
+func (e UpdateStockResponseValidationError) Key() bool { return e.key }
 
 // ErrorName returns error name.
-func (e DeleteProductResponseValidationError) ErrorName() string {
-	return "DeleteProductResponseValidationError"
+func (e UpdateStockResponseValidationError) ErrorName() string {
+	return "UpdateStockResponseValidationError"
 }
 
 // Error satisfies the builtin error interface
-func (e DeleteProductResponseValidationError) Error() string {
+func (e UpdateStockResponseValidationError) Error() string {
 	cause := ""
 	if e.cause != nil {
 		cause = fmt.Sprintf(" | caused by: %v", e.cause)
@@ -1309,14 +2934,14 @@ func (e DeleteProductResponseValidationError) Error() string {
 	}
 
 	return fmt.Sprintf(
-		"invalid %sDeleteProductResponse.%s: %s%s",
+		"invalid %sUpdateStockResponse.%s: %s%s",
 		key,
 		e.field,
 		e.reason,
 		cause)
 }
 
-var _ error = DeleteProductResponseValidationError{}
+var _ error = UpdateStockResponseValidationError{}
 
 var _ interface {
 	Field() string
@@ -1324,4 +2949,4 @@ var _ interface {
 	Key() bool
 	Cause() error
 	ErrorName() string
-} = DeleteProductResponseValidationError{}
+} = UpdateStockResponseValidationError{}
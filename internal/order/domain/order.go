@@ -1,9 +1,24 @@
 package domain
 
 import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
 	"time"
 )
 
+var (
+	// ErrInvalidArgument is returned when order input fails validation
+	ErrInvalidArgument = errors.New("invalid argument")
+	// ErrInsufficientStock is returned when an order asks for more units than a product has in stock
+	ErrInsufficientStock = errors.New("insufficient stock")
+	// ErrOrderNotFound is returned when no order has the requested ID
+	ErrOrderNotFound = errors.New("order not found")
+	// ErrInvalidStatusTransition is returned when an order cannot move from its current status to the requested one
+	ErrInvalidStatusTransition = errors.New("invalid status transition")
+)
+
 // OrderStatus represents the possible states of an order
 type OrderStatus int
 
@@ -16,20 +31,318 @@ const (
 	OrderStatusCancelled
 )
 
-// OrderItem represents an item within an order
+// IsValid reports whether the status is one of the known order states
+func (s OrderStatus) IsValid() bool {
+	return s >= OrderStatusPending && s <= OrderStatusCancelled
+}
+
+// String returns the lower-case name of the status
+func (s OrderStatus) String() string {
+	switch s {
+	case OrderStatusPending:
+		return "pending"
+	case OrderStatusProcessing:
+		return "processing"
+	case OrderStatusShipped:
+		return "shipped"
+	case OrderStatusDelivered:
+		return "delivered"
+	case OrderStatusCancelled:
+		return "cancelled"
+	default:
+		return "unspecified"
+	}
+}
+
+// allowedTransitions lists the statuses each status may move to. Orders move forward
+// one step at a time and can only be cancelled before they have shipped.
+var allowedTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusPending:    {OrderStatusProcessing, OrderStatusCancelled},
+	OrderStatusProcessing: {OrderStatusShipped, OrderStatusCancelled},
+	OrderStatusShipped:    {OrderStatusDelivered},
+}
+
+// CanTransitionTo reports whether an order in this status may move to next
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	for _, allowed := range allowedTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckTransition returns an ErrInvalidStatusTransition error unless an order in this status may move to next
+func (s OrderStatus) CheckTransition(next OrderStatus) error {
+	if !s.CanTransitionTo(next) {
+		return fmt.Errorf("%w: order cannot move from %s to %s", ErrInvalidStatusTransition, s, next)
+	}
+	return nil
+}
+
+// ValidationError aggregates the rule violations found while validating an order
+type ValidationError struct {
+	Problems []string
+}
+
+// Error implements the error interface
+func (e *ValidationError) Error() string {
+	return "invalid order: " + strings.Join(e.Problems, "; ")
+}
+
+// Unwrap makes errors.Is(err, ErrInvalidArgument) match validation errors
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidArgument
+}
+
+// OrderItem represents an item within an order.
+// Price is a snapshot of the unit price at the time the order was placed; it
+// is stored with the item and never re-read from the product catalog, so
+// historical orders always show what was paid.
 type OrderItem struct {
 	ProductID string `json:"product_id"`
 	Quantity  int32  `json:"quantity"`
 	Price     int64  `json:"price"`
 }
 
-// Order represents an order in the system
+// Order represents an order in the system. TrackingNumber and Carrier are set
+// when the order ships, if the shipping integration provided them. ItemsTruncated
+// is set when Items holds only the first of the order's items; the rest are read
+// page by page.
 type Order struct {
-	ID          string      `json:"id"`
-	CustomerID  string      `json:"customer_id"`
-	Items       []OrderItem `json:"items"`
-	Status      OrderStatus `json:"status"`
-	TotalAmount int64       `json:"total_amount"`
-	CreatedAt   time.Time   `json:"created_at"`
-	UpdatedAt   time.Time   `json:"updated_at"`
+	ID             string      `json:"id"`
+	CustomerID     string      `json:"customer_id"`
+	Items          []OrderItem `json:"items"`
+	ItemsTruncated bool        `json:"items_truncated,omitempty"`
+	Status         OrderStatus `json:"status"`
+	TotalAmount    int64       `json:"total_amount"`
+	TrackingNumber string      `json:"tracking_number,omitempty"`
+	Carrier        string      `json:"carrier,omitempty"`
+	CreatedAt      time.Time   `json:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at"`
+}
+
+const (
+	// MaxTrackingNumberLength is the longest tracking number an order can store
+	MaxTrackingNumberLength = 100
+	// MaxCarrierLength is the longest carrier name an order can store
+	MaxCarrierLength = 50
+)
+
+// Shipment holds the tracking information recorded when an order ships
+type Shipment struct {
+	TrackingNumber string
+	Carrier        string
+}
+
+// IsZero reports whether the shipment carries no tracking information
+func (s Shipment) IsZero() bool {
+	return s.TrackingNumber == "" && s.Carrier == ""
+}
+
+// Validate checks that a tracking number comes with its carrier and that both fit their columns
+func (s Shipment) Validate() error {
+	if (s.TrackingNumber == "") != (s.Carrier == "") {
+		return fmt.Errorf("%w: tracking_number and carrier must be given together", ErrInvalidArgument)
+	}
+	if len(s.TrackingNumber) > MaxTrackingNumberLength {
+		return fmt.Errorf("%w: tracking_number must not exceed %d characters", ErrInvalidArgument, MaxTrackingNumberLength)
+	}
+	if len(s.Carrier) > MaxCarrierLength {
+		return fmt.Errorf("%w: carrier must not exceed %d characters", ErrInvalidArgument, MaxCarrierLength)
+	}
+	return nil
+}
+
+// Validate checks the item's product, quantity and price
+func (i OrderItem) Validate() error {
+	var problems []string
+
+	if i.ProductID == "" {
+		problems = append(problems, "product_id is required")
+	}
+
+	if i.Quantity <= 0 {
+		problems = append(problems, "quantity must be greater than 0")
+	}
+
+	if i.Price < 0 {
+		problems = append(problems, "price cannot be negative")
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// Validate checks the order's customer, items and status, reporting every violation found
+func (o *Order) Validate() error {
+	var problems []string
+
+	if o.CustomerID == "" {
+		problems = append(problems, "customer_id is required")
+	}
+
+	if len(o.Items) == 0 {
+		problems = append(problems, "at least one item is required")
+	}
+
+	for idx, item := range o.Items {
+		var itemErr *ValidationError
+		if err := item.Validate(); errors.As(err, &itemErr) {
+			for _, problem := range itemErr.Problems {
+				problems = append(problems, fmt.Sprintf("items[%d]: %s", idx, problem))
+			}
+		}
+	}
+
+	if !o.Status.IsValid() {
+		problems = append(problems, fmt.Sprintf("unknown status %d", o.Status))
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// NormalizeAndValidate validates the order as sent, so problems name the items at the
+// positions the client used, and then merges the items that repeat a product
+func (o *Order) NormalizeAndValidate() error {
+	if err := o.Validate(); err != nil {
+		return err
+	}
+	return o.MergeItems()
+}
+
+// MergeItems combines the items of the same product into one item whose quantity is the
+// sum of theirs, keeping the position of the product's first item. Items of one product
+// with different prices, or whose quantities add up past an int32, are rejected with
+// ErrInvalidArgument naming the later item. Items are expected to have passed Validate
+// and, when a catalog prices them, to carry the catalog prices already.
+func (o *Order) MergeItems() error {
+	merged := make([]OrderItem, 0, len(o.Items))
+	first := make(map[string]int, len(o.Items))
+	for idx, item := range o.Items {
+		pos, seen := first[item.ProductID]
+		if !seen {
+			first[item.ProductID] = len(merged)
+			merged = append(merged, item)
+			continue
+		}
+
+		if merged[pos].Price != item.Price {
+			return fmt.Errorf("%w: items[%d]: product_id %q is repeated with a different price", ErrInvalidArgument, idx, item.ProductID)
+		}
+		if merged[pos].Quantity > math.MaxInt32-item.Quantity {
+			return fmt.Errorf("%w: items[%d]: total quantity of product_id %q overflows", ErrInvalidArgument, idx, item.ProductID)
+		}
+		merged[pos].Quantity += item.Quantity
+	}
+	o.Items = merged
+	return nil
+}
+
+// CalculateTotal returns the sum of price times quantity over the order's items, or an
+// ErrInvalidArgument error when the sum does not fit in an int64
+func (o *Order) CalculateTotal() (int64, error) {
+	var total int64
+	for idx, item := range o.Items {
+		subtotal, ok := mulInt64(item.Price, int64(item.Quantity))
+		if !ok {
+			return 0, fmt.Errorf("%w: items[%d]: price times quantity overflows the order total", ErrInvalidArgument, idx)
+		}
+		if total, ok = addInt64(total, subtotal); !ok {
+			return 0, fmt.Errorf("%w: order total overflows", ErrInvalidArgument)
+		}
+	}
+	return total, nil
+}
+
+// ComputeTotal calculates the order's total and stores it in TotalAmount. Totals that
+// overflow or exceed maxTotal are rejected with ErrInvalidArgument; a non-positive
+// maxTotal disables the cap.
+func (o *Order) ComputeTotal(maxTotal int64) error {
+	total, err := o.CalculateTotal()
+	if err != nil {
+		return err
+	}
+	if maxTotal > 0 && total > maxTotal {
+		return fmt.Errorf("%w: order total %d exceeds the maximum of %d", ErrInvalidArgument, total, maxTotal)
+	}
+	o.TotalAmount = total
+	return nil
+}
+
+// mulInt64 returns a*b and whether the product fits in an int64
+func mulInt64(a, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	product := a * b
+	// The division undoes the multiplication only if it did not wrap, except for
+	// MinInt64 * -1, which wraps back to MinInt64
+	if product/b != a || (b == -1 && a == math.MinInt64) {
+		return 0, false
+	}
+	return product, true
+}
+
+// addInt64 returns a+b and whether the sum fits in an int64
+func addInt64(a, b int64) (int64, bool) {
+	sum := a + b
+	// Adding operands of the same sign must not flip the sign
+	if (a > 0 && b > 0 && sum < 0) || (a < 0 && b < 0 && sum >= 0) {
+		return 0, false
+	}
+	return sum, true
+}
+
+// TimeBucket represents the granularity of an order time series
+type TimeBucket string
+
+const (
+	TimeBucketDay   TimeBucket = "day"
+	TimeBucketWeek  TimeBucket = "week"
+	TimeBucketMonth TimeBucket = "month"
+)
+
+// IsValid reports whether the bucket is a known granularity
+func (b TimeBucket) IsValid() bool {
+	switch b {
+	case TimeBucketDay, TimeBucketWeek, TimeBucketMonth:
+		return true
+	default:
+		return false
+	}
+}
+
+// PriceSource selects where the prices of a would-be order's items come from. Stored
+// order items are always snapshots of what was paid; the source only applies to orders
+// being priced, such as previews and reorders.
+type PriceSource string
+
+const (
+	// PriceSourceSnapshot keeps the prices the items carry: those sent by the client, or
+	// those stored with the order being repeated
+	PriceSourceSnapshot PriceSource = "snapshot"
+	// PriceSourceLive prices the items at the product catalog's current prices
+	PriceSourceLive PriceSource = "live"
+)
+
+// IsValid reports whether the source is a known price source
+func (s PriceSource) IsValid() bool {
+	switch s {
+	case PriceSourceSnapshot, PriceSourceLive:
+		return true
+	default:
+		return false
+	}
+}
+
+// PeriodCount represents the number of orders created within a time bucket
+type PeriodCount struct {
+	Date  time.Time `json:"date"`
+	Count int64     `json:"count"`
 }
@@ -0,0 +1,315 @@
+package domain
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOrderItemValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		item     OrderItem
+		problems []string
+	}{
+		{name: "valid", item: OrderItem{ProductID: "product-1", Quantity: 1, Price: 100}},
+		{name: "free item", item: OrderItem{ProductID: "product-1", Quantity: 1, Price: 0}},
+		{name: "missing product", item: OrderItem{Quantity: 1, Price: 100}, problems: []string{"product_id is required"}},
+		{name: "zero quantity", item: OrderItem{ProductID: "product-1", Quantity: 0, Price: 100}, problems: []string{"quantity must be greater than 0"}},
+		{name: "negative quantity", item: OrderItem{ProductID: "product-1", Quantity: -1, Price: 100}, problems: []string{"quantity must be greater than 0"}},
+		{name: "negative price", item: OrderItem{ProductID: "product-1", Quantity: 1, Price: -1}, problems: []string{"price cannot be negative"}},
+		{
+			name:     "every violation",
+			item:     OrderItem{Quantity: -1, Price: -1},
+			problems: []string{"product_id is required", "quantity must be greater than 0", "price cannot be negative"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertProblems(t, tt.item.Validate(), tt.problems)
+		})
+	}
+}
+
+func TestOrderValidate(t *testing.T) {
+	valid := OrderItem{ProductID: "product-1", Quantity: 1, Price: 100}
+
+	tests := []struct {
+		name     string
+		order    Order
+		problems []string
+	}{
+		{name: "valid", order: Order{CustomerID: "customer-1", Items: []OrderItem{valid}, Status: OrderStatusPending}},
+		{name: "missing customer", order: Order{Items: []OrderItem{valid}, Status: OrderStatusPending}, problems: []string{"customer_id is required"}},
+		{name: "no items", order: Order{CustomerID: "customer-1", Status: OrderStatusPending}, problems: []string{"at least one item is required"}},
+		{
+			name:     "bad items are named by index",
+			order:    Order{CustomerID: "customer-1", Items: []OrderItem{valid, {ProductID: "product-2", Quantity: -2, Price: 100}, {Quantity: 1}}, Status: OrderStatusPending},
+			problems: []string{"items[1]: quantity must be greater than 0", "items[2]: product_id is required"},
+		},
+		{
+			name:     "zero-quantity items are rejected where they were sent",
+			order:    Order{CustomerID: "customer-1", Items: []OrderItem{{ProductID: "product-2", Quantity: 0, Price: 100}, valid, {ProductID: "product-3", Quantity: 0, Price: 100}}, Status: OrderStatusPending},
+			problems: []string{"items[0]: quantity must be greater than 0", "items[2]: quantity must be greater than 0"},
+		},
+		{name: "unspecified status", order: Order{CustomerID: "customer-1", Items: []OrderItem{valid}}, problems: []string{"unknown status 0"}},
+		{name: "unknown status", order: Order{CustomerID: "customer-1", Items: []OrderItem{valid}, Status: OrderStatusCancelled + 1}, problems: []string{"unknown status 6"}},
+		{
+			name:     "every violation",
+			order:    Order{Status: OrderStatusUnspecified},
+			problems: []string{"customer_id is required", "at least one item is required", "unknown status 0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertProblems(t, tt.order.Validate(), tt.problems)
+		})
+	}
+}
+
+func TestOrderNormalizeAndValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		items    []OrderItem
+		want     []OrderItem
+		problems []string
+	}{
+		{
+			name: "repeats are merged after validation",
+			items: []OrderItem{
+				{ProductID: "product-1", Quantity: 1, Price: 100},
+				{ProductID: "product-2", Quantity: 2, Price: 200},
+				{ProductID: "product-1", Quantity: 3, Price: 100},
+			},
+			want: []OrderItem{{ProductID: "product-1", Quantity: 4, Price: 100}, {ProductID: "product-2", Quantity: 2, Price: 200}},
+		},
+		{
+			name:     "zero quantity is named at the position it was sent",
+			items:    []OrderItem{{ProductID: "product-1", Quantity: 1, Price: 100}, {ProductID: "product-1", Quantity: 1, Price: 100}, {ProductID: "product-2", Quantity: 0, Price: 200}},
+			problems: []string{"items[2]: quantity must be greater than 0"},
+		},
+		{
+			name:     "only zero-quantity items",
+			items:    []OrderItem{{ProductID: "product-1", Quantity: 0, Price: 100}, {ProductID: "product-2", Quantity: 0, Price: 200}},
+			problems: []string{"items[0]: quantity must be greater than 0", "items[1]: quantity must be greater than 0"},
+		},
+		{name: "no items", problems: []string{"at least one item is required"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := Order{CustomerID: "customer-1", Items: tt.items, Status: OrderStatusPending}
+			err := order.NormalizeAndValidate()
+			if tt.problems != nil {
+				assertProblems(t, err, tt.problems)
+				return
+			}
+			if err != nil {
+				t.Fatalf("NormalizeAndValidate() error = %v", err)
+			}
+			if !reflect.DeepEqual(order.Items, tt.want) {
+				t.Errorf("NormalizeAndValidate() items = %+v, want %+v", order.Items, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrderMergeItems(t *testing.T) {
+	tests := []struct {
+		name    string
+		items   []OrderItem
+		want    []OrderItem
+		wantErr string
+	}{
+		{
+			name:  "distinct products are kept in order",
+			items: []OrderItem{{ProductID: "product-1", Quantity: 1, Price: 100}, {ProductID: "product-2", Quantity: 2, Price: 200}},
+			want:  []OrderItem{{ProductID: "product-1", Quantity: 1, Price: 100}, {ProductID: "product-2", Quantity: 2, Price: 200}},
+		},
+		{
+			name: "repeats are summed at the first position",
+			items: []OrderItem{
+				{ProductID: "product-1", Quantity: 2, Price: 100},
+				{ProductID: "product-2", Quantity: 1, Price: 200},
+				{ProductID: "product-1", Quantity: 3, Price: 100},
+			},
+			want: []OrderItem{{ProductID: "product-1", Quantity: 5, Price: 100}, {ProductID: "product-2", Quantity: 1, Price: 200}},
+		},
+		{
+			name:    "repeat with a different price",
+			items:   []OrderItem{{ProductID: "product-1", Quantity: 1, Price: 100}, {ProductID: "product-1", Quantity: 1, Price: 90}},
+			wantErr: "items[1]",
+		},
+		{
+			name:    "summed quantity past int32",
+			items:   []OrderItem{{ProductID: "product-1", Quantity: math.MaxInt32, Price: 100}, {ProductID: "product-1", Quantity: 1, Price: 100}},
+			wantErr: "items[1]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := Order{Items: tt.items}
+			err := order.MergeItems()
+			if tt.wantErr != "" {
+				if !errors.Is(err, ErrInvalidArgument) || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("MergeItems() error = %v, want ErrInvalidArgument naming %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("MergeItems() error = %v", err)
+			}
+			if !reflect.DeepEqual(order.Items, tt.want) {
+				t.Errorf("MergeItems() items = %+v, want %+v", order.Items, tt.want)
+			}
+		})
+	}
+}
+
+func TestMulInt64(t *testing.T) {
+	tests := []struct {
+		a, b   int64
+		want   int64
+		wantOK bool
+	}{
+		{a: 1000, b: 3, want: 3000, wantOK: true},
+		{a: math.MaxInt64, b: 1, want: math.MaxInt64, wantOK: true},
+		{a: math.MaxInt64, b: 0, want: 0, wantOK: true},
+		{a: 0, b: math.MaxInt64, want: 0, wantOK: true},
+		{a: math.MaxInt64 / 2, b: 2, want: math.MaxInt64 - 1, wantOK: true},
+		{a: math.MaxInt64/2 + 1, b: 2, wantOK: false},
+		{a: math.MaxInt64, b: math.MaxInt32, wantOK: false},
+		{a: 1 << 32, b: 1 << 31, wantOK: false},
+		{a: math.MaxInt64, b: -1, want: -math.MaxInt64, wantOK: true},
+		{a: math.MinInt64, b: -1, wantOK: false},
+		{a: -1, b: math.MinInt64, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		got, ok := mulInt64(tt.a, tt.b)
+		if ok != tt.wantOK || (ok && got != tt.want) {
+			t.Errorf("mulInt64(%d, %d) = %d, %v, want %d, %v", tt.a, tt.b, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestAddInt64(t *testing.T) {
+	tests := []struct {
+		a, b   int64
+		want   int64
+		wantOK bool
+	}{
+		{a: 1000, b: 2000, want: 3000, wantOK: true},
+		{a: math.MaxInt64 - 1, b: 1, want: math.MaxInt64, wantOK: true},
+		{a: math.MaxInt64, b: 1, wantOK: false},
+		{a: math.MaxInt64, b: math.MaxInt64, wantOK: false},
+		{a: math.MaxInt64, b: -1, want: math.MaxInt64 - 1, wantOK: true},
+		{a: math.MinInt64, b: math.MaxInt64, want: -1, wantOK: true},
+		{a: math.MinInt64, b: -1, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		got, ok := addInt64(tt.a, tt.b)
+		if ok != tt.wantOK || (ok && got != tt.want) {
+			t.Errorf("addInt64(%d, %d) = %d, %v, want %d, %v", tt.a, tt.b, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestOrderComputeTotal(t *testing.T) {
+	tests := []struct {
+		name     string
+		items    []OrderItem
+		maxTotal int64
+		want     int64
+		wantErr  bool
+	}{
+		{
+			name:  "sum of price times quantity",
+			items: []OrderItem{{ProductID: "product-1", Quantity: 2, Price: 1000}, {ProductID: "product-2", Quantity: 1, Price: 500}},
+			want:  2500,
+		},
+		{
+			name:  "total of exactly MaxInt64",
+			items: []OrderItem{{ProductID: "product-1", Quantity: 1, Price: math.MaxInt64 - 1}, {ProductID: "product-2", Quantity: 1, Price: 1}},
+			want:  math.MaxInt64,
+		},
+		{
+			name:    "line that overflows",
+			items:   []OrderItem{{ProductID: "product-1", Quantity: 2, Price: math.MaxInt64/2 + 1}},
+			wantErr: true,
+		},
+		{
+			name:    "line at the maximum quantity that overflows",
+			items:   []OrderItem{{ProductID: "product-1", Quantity: math.MaxInt32, Price: math.MaxInt64 / 1000}},
+			wantErr: true,
+		},
+		{
+			name:    "lines that fit but whose sum overflows",
+			items:   []OrderItem{{ProductID: "product-1", Quantity: 1, Price: math.MaxInt64}, {ProductID: "product-2", Quantity: 1, Price: 1}},
+			wantErr: true,
+		},
+		{
+			name:     "total at the cap",
+			items:    []OrderItem{{ProductID: "product-1", Quantity: 2, Price: 500}},
+			maxTotal: 1000,
+			want:     1000,
+		},
+		{
+			name:     "total over the cap",
+			items:    []OrderItem{{ProductID: "product-1", Quantity: 2, Price: 501}},
+			maxTotal: 1000,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := Order{Items: tt.items, TotalAmount: -1}
+			err := order.ComputeTotal(tt.maxTotal)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidArgument) {
+					t.Fatalf("ComputeTotal() error = %v, want ErrInvalidArgument", err)
+				}
+				if order.TotalAmount != -1 {
+					t.Errorf("ComputeTotal() stored TotalAmount %d on error", order.TotalAmount)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ComputeTotal() error = %v", err)
+			}
+			if order.TotalAmount != tt.want {
+				t.Errorf("TotalAmount = %d, want %d", order.TotalAmount, tt.want)
+			}
+		})
+	}
+}
+
+// assertProblems checks that err is nil when no problems are expected, and otherwise a
+// ValidationError matching ErrInvalidArgument with exactly the expected problems
+func assertProblems(t *testing.T, err error, problems []string) {
+	t.Helper()
+	if len(problems) == 0 {
+		if err != nil {
+			t.Fatalf("Validate() error = %v, want nil", err)
+		}
+		return
+	}
+
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Validate() error = %v, want a *ValidationError", err)
+	}
+	if !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("Validate() error does not match ErrInvalidArgument")
+	}
+	if !reflect.DeepEqual(validationErr.Problems, problems) {
+		t.Errorf("Validate() problems = %q, want %q", validationErr.Problems, problems)
+	}
+}
@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrUnknownDestination is returned when no shipping rate covers the destination
+var ErrUnknownDestination = errors.New("unknown destination")
+
+// Address represents a shipping destination
+type Address struct {
+	Street     string `json:"street"`
+	City       string `json:"city"`
+	PostalCode string `json:"postal_code"`
+	// Country is an ISO 3166-1 alpha-2 code, e.g. "US"
+	Country string `json:"country"`
+}
+
+// Validate checks that the address names a country
+func (a Address) Validate() error {
+	if strings.TrimSpace(a.Country) == "" {
+		return &ValidationError{Problems: []string{"address.country is required"}}
+	}
+	return nil
+}
+
+// ShippingEstimate represents the estimated cost and delivery date of shipping an order
+type ShippingEstimate struct {
+	Cost              int64     `json:"cost"`
+	TransitDays       int       `json:"transit_days"`
+	EstimatedDelivery time.Time `json:"estimated_delivery"`
+}
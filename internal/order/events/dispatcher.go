@@ -0,0 +1,175 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"go-bootiful-ordering/internal/order/domain"
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrMalformedEvent is returned for a message whose payload does not match its payload type
+	ErrMalformedEvent = errors.New("malformed order event")
+	// ErrUnknownPayloadType is returned for a message whose payload type this version cannot decode
+	ErrUnknownPayloadType = errors.New("unknown order event payload type")
+)
+
+// Message is an order event as read from the outbox, with the fields of an order_outbox row.
+// A consumer of the outbox topic fills it from the Kafka message: ID, AggregateID and
+// TraceID from the id, key and trace_id headers, PayloadType from the payload_type header.
+type Message struct {
+	ID          string
+	EventType   EventType
+	PayloadType PayloadType
+	AggregateID string
+	Payload     []byte
+	CreatedAt   time.Time
+	// TraceID is the trace of the request that wrote the entry, or empty when it was not traced
+	TraceID string
+}
+
+// OrderEvent is an outbox message with its payload decoded. Payload is one of the
+// *OrderCreatedEventV1, *OrderStatusUpdatedEventV1, *OrderCancelledEventV1 and
+// *OrderShippedEventV1 payloads, as named by PayloadType.
+type OrderEvent struct {
+	ID          string
+	EventType   EventType
+	PayloadType PayloadType
+	OrderID     string
+	Payload     interface{}
+	CreatedAt   time.Time
+	// TraceID is the trace of the request that wrote the entry, or empty when it was not traced
+	TraceID string
+}
+
+// DecodeEvent decodes the payload of a message by its payload type. Messages without a
+// payload type were written before payloads were versioned and carry the whole order; they
+// are decoded into the V1 payload of their event type, so consumers only handle V1.
+func DecodeEvent(msg Message) (*OrderEvent, error) {
+	event := &OrderEvent{
+		ID:          msg.ID,
+		EventType:   msg.EventType,
+		PayloadType: msg.PayloadType,
+		OrderID:     msg.AggregateID,
+		CreatedAt:   msg.CreatedAt,
+		TraceID:     msg.TraceID,
+	}
+
+	if msg.PayloadType == "" {
+		return decodeLegacyEvent(event, msg.Payload)
+	}
+
+	switch msg.PayloadType {
+	case PayloadTypeOrderCreatedV1:
+		event.Payload = &OrderCreatedEventV1{}
+	case PayloadTypeOrderStatusUpdatedV1:
+		event.Payload = &OrderStatusUpdatedEventV1{}
+	case PayloadTypeOrderCancelledV1:
+		event.Payload = &OrderCancelledEventV1{}
+	case PayloadTypeOrderShippedV1:
+		event.Payload = &OrderShippedEventV1{}
+	default:
+		return nil, fmt.Errorf("%w: %q on message %s", ErrUnknownPayloadType, msg.PayloadType, msg.ID)
+	}
+	if err := json.Unmarshal(msg.Payload, event.Payload); err != nil {
+		return nil, fmt.Errorf("%w: message %s: %v", ErrMalformedEvent, msg.ID, err)
+	}
+	return event, nil
+}
+
+// decodeLegacyEvent decodes a whole-order payload into the V1 payload of the event type
+func decodeLegacyEvent(event *OrderEvent, payload []byte) (*OrderEvent, error) {
+	var order domain.Order
+	if err := json.Unmarshal(payload, &order); err != nil {
+		return nil, fmt.Errorf("%w: message %s: %v", ErrMalformedEvent, event.ID, err)
+	}
+
+	switch event.EventType {
+	case EventTypeOrderCreated:
+		event.PayloadType, event.Payload = PayloadTypeOrderCreatedV1, NewOrderCreatedEventV1(&order)
+	case EventTypeOrderStatusUpdated:
+		event.PayloadType, event.Payload = PayloadTypeOrderStatusUpdatedV1, NewOrderStatusUpdatedEventV1(&order)
+	case EventTypeOrderCancelled:
+		event.PayloadType, event.Payload = PayloadTypeOrderCancelledV1, NewOrderCancelledEventV1(&order)
+	case EventTypeOrderShipped:
+		event.PayloadType, event.Payload = PayloadTypeOrderShippedV1, NewOrderShippedEventV1(&order)
+	default:
+		return nil, fmt.Errorf("%w: message %s of event type %q has no payload type", ErrUnknownPayloadType, event.ID, event.EventType)
+	}
+	return event, nil
+}
+
+// EventHandler processes the order events it is subscribed to. Events are delivered at
+// least once, so handlers must tolerate seeing the same event ID again.
+type EventHandler interface {
+	// Handle processes one event; an error asks the consumer to deliver it again
+	Handle(ctx context.Context, event *OrderEvent) error
+}
+
+// EventHandlerFunc adapts a function to an EventHandler
+type EventHandlerFunc func(ctx context.Context, event *OrderEvent) error
+
+// Handle calls f
+func (f EventHandlerFunc) Handle(ctx context.Context, event *OrderEvent) error {
+	return f(ctx, event)
+}
+
+// Dispatcher routes outbox messages to the handlers subscribed to their event type. It is
+// not tied to a transport: whatever reads the outbox, such as a consumer of the Debezium
+// topic, passes each message to Dispatch.
+type Dispatcher struct {
+	log      *zap.SugaredLogger
+	handlers map[EventType][]EventHandler
+}
+
+// NewDispatcher creates a new Dispatcher without subscriptions
+func NewDispatcher(log *zap.SugaredLogger) *Dispatcher {
+	return &Dispatcher{
+		log:      log,
+		handlers: make(map[EventType][]EventHandler),
+	}
+}
+
+// Subscribe adds a handler for the given event types. Handlers of a type are called in
+// the order they subscribed.
+func (d *Dispatcher) Subscribe(handler EventHandler, eventTypes ...EventType) {
+	for _, eventType := range eventTypes {
+		d.handlers[eventType] = append(d.handlers[eventType], handler)
+	}
+}
+
+// Dispatch decodes an outbox message and passes it to every handler of its event type.
+// Messages of a type nobody subscribed to, or with a payload type this version does not
+// know, e.g. a V2 payload from a newer producer, are logged and skipped so they cannot
+// stall the consumer. Every handler is called even if an earlier one failed; their errors
+// are returned joined.
+func (d *Dispatcher) Dispatch(ctx context.Context, msg Message) error {
+	handlers := d.handlers[msg.EventType]
+	if len(handlers) == 0 {
+		d.log.Warnf("Skipping order event without handlers: id=%s eventType=%s aggregateID=%s",
+			msg.ID, msg.EventType, msg.AggregateID)
+		return nil
+	}
+
+	event, err := DecodeEvent(msg)
+	if errors.Is(err, ErrUnknownPayloadType) {
+		d.log.Warnf("Skipping order event: %v", err)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, handler := range handlers {
+		if err := handler.Handle(ctx, event); err != nil {
+			d.log.Errorf("Order event handler failed: %v, id=%s eventType=%s", err, event.ID, event.EventType)
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
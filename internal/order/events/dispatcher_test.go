@@ -0,0 +1,243 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"go-bootiful-ordering/internal/order/domain"
+	"go.uber.org/zap"
+)
+
+func testOrder() *domain.Order {
+	created := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+	return &domain.Order{
+		ID:             "order-1",
+		CustomerID:     "customer-1",
+		Items:          []domain.OrderItem{{ProductID: "product-1", Quantity: 2, Price: 1000}},
+		Status:         domain.OrderStatusShipped,
+		TotalAmount:    2000,
+		TrackingNumber: "1Z999",
+		Carrier:        "UPS",
+		CreatedAt:      created,
+		UpdatedAt:      created.Add(time.Hour),
+	}
+}
+
+func mustMarshal(t *testing.T, v interface{}) []byte {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	return data
+}
+
+func TestDecodeEvent(t *testing.T) {
+	order := testOrder()
+	legacy := mustMarshal(t, order)
+
+	tests := []struct {
+		name        string
+		eventType   EventType
+		payloadType PayloadType
+		payload     []byte
+		wantType    PayloadType
+		want        interface{}
+	}{
+		{
+			name:        "order created",
+			eventType:   EventTypeOrderCreated,
+			payloadType: PayloadTypeOrderCreatedV1,
+			payload:     mustMarshal(t, NewOrderCreatedEventV1(order)),
+			wantType:    PayloadTypeOrderCreatedV1,
+			want:        NewOrderCreatedEventV1(order),
+		},
+		{
+			name:        "order status updated",
+			eventType:   EventTypeOrderStatusUpdated,
+			payloadType: PayloadTypeOrderStatusUpdatedV1,
+			payload:     mustMarshal(t, NewOrderStatusUpdatedEventV1(order)),
+			wantType:    PayloadTypeOrderStatusUpdatedV1,
+			want:        NewOrderStatusUpdatedEventV1(order),
+		},
+		{
+			name:        "order cancelled",
+			eventType:   EventTypeOrderCancelled,
+			payloadType: PayloadTypeOrderCancelledV1,
+			payload:     mustMarshal(t, NewOrderCancelledEventV1(order)),
+			wantType:    PayloadTypeOrderCancelledV1,
+			want:        NewOrderCancelledEventV1(order),
+		},
+		{
+			name:        "order shipped",
+			eventType:   EventTypeOrderShipped,
+			payloadType: PayloadTypeOrderShippedV1,
+			payload:     mustMarshal(t, NewOrderShippedEventV1(order)),
+			wantType:    PayloadTypeOrderShippedV1,
+			want:        NewOrderShippedEventV1(order),
+		},
+		{
+			name:      "legacy order created",
+			eventType: EventTypeOrderCreated,
+			payload:   legacy,
+			wantType:  PayloadTypeOrderCreatedV1,
+			want:      NewOrderCreatedEventV1(order),
+		},
+		{
+			name:      "legacy order status updated",
+			eventType: EventTypeOrderStatusUpdated,
+			payload:   legacy,
+			wantType:  PayloadTypeOrderStatusUpdatedV1,
+			want:      NewOrderStatusUpdatedEventV1(order),
+		},
+		{
+			name:      "legacy order cancelled",
+			eventType: EventTypeOrderCancelled,
+			payload:   legacy,
+			wantType:  PayloadTypeOrderCancelledV1,
+			want:      NewOrderCancelledEventV1(order),
+		},
+		{
+			name:      "legacy order shipped",
+			eventType: EventTypeOrderShipped,
+			payload:   legacy,
+			wantType:  PayloadTypeOrderShippedV1,
+			want:      NewOrderShippedEventV1(order),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := DecodeEvent(Message{
+				ID:          "event-1",
+				EventType:   tt.eventType,
+				PayloadType: tt.payloadType,
+				AggregateID: order.ID,
+				Payload:     tt.payload,
+				TraceID:     "trace-1",
+			})
+			if err != nil {
+				t.Fatalf("DecodeEvent() error = %v", err)
+			}
+			if event.ID != "event-1" || event.OrderID != order.ID || event.EventType != tt.eventType || event.TraceID != "trace-1" {
+				t.Errorf("DecodeEvent() = %+v, want the message's ID, order, event type and trace", event)
+			}
+			if event.PayloadType != tt.wantType {
+				t.Errorf("DecodeEvent() payload type = %q, want %q", event.PayloadType, tt.wantType)
+			}
+			if !reflect.DeepEqual(event.Payload, tt.want) {
+				t.Errorf("DecodeEvent() payload = %+v, want %+v", event.Payload, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeEventErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		eventType   EventType
+		payloadType PayloadType
+		payload     string
+		want        error
+	}{
+		{name: "unknown payload type", eventType: EventTypeOrderCreated, payloadType: "order_created.v2", payload: `{}`, want: ErrUnknownPayloadType},
+		{name: "malformed payload", eventType: EventTypeOrderCreated, payloadType: PayloadTypeOrderCreatedV1, payload: `{"order_id":`, want: ErrMalformedEvent},
+		{name: "payload of the wrong shape", eventType: EventTypeOrderCreated, payloadType: PayloadTypeOrderCreatedV1, payload: `{"items": "none"}`, want: ErrMalformedEvent},
+		{name: "malformed legacy payload", eventType: EventTypeOrderCreated, payload: `not json`, want: ErrMalformedEvent},
+		{name: "legacy payload of an unknown event type", eventType: "order_refunded", payload: `{"id": "order-1"}`, want: ErrUnknownPayloadType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := DecodeEvent(Message{ID: "event-1", EventType: tt.eventType, PayloadType: tt.payloadType, Payload: []byte(tt.payload)})
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("DecodeEvent() error = %v, want %v", err, tt.want)
+			}
+			if event != nil {
+				t.Errorf("DecodeEvent() event = %+v, want nil", event)
+			}
+		})
+	}
+}
+
+// recordingHandler records the events it is given and returns err for each of them
+type recordingHandler struct {
+	events []*OrderEvent
+	err    error
+}
+
+func (h *recordingHandler) Handle(ctx context.Context, event *OrderEvent) error {
+	h.events = append(h.events, event)
+	return h.err
+}
+
+func TestDispatchRoutesByEventType(t *testing.T) {
+	order := testOrder()
+	created := &recordingHandler{}
+	shipped := &recordingHandler{}
+	all := &recordingHandler{}
+
+	dispatcher := NewDispatcher(zap.NewNop().Sugar())
+	dispatcher.Subscribe(created, EventTypeOrderCreated)
+	dispatcher.Subscribe(shipped, EventTypeOrderShipped)
+	dispatcher.Subscribe(all, EventTypeOrderCreated, EventTypeOrderShipped)
+
+	messages := []Message{
+		{ID: "event-1", EventType: EventTypeOrderCreated, PayloadType: PayloadTypeOrderCreatedV1, Payload: mustMarshal(t, NewOrderCreatedEventV1(order))},
+		{ID: "event-2", EventType: EventTypeOrderShipped, PayloadType: PayloadTypeOrderShippedV1, Payload: mustMarshal(t, NewOrderShippedEventV1(order))},
+		{ID: "event-3", EventType: EventTypeOrderCancelled, PayloadType: PayloadTypeOrderCancelledV1, Payload: mustMarshal(t, NewOrderCancelledEventV1(order))},
+		{ID: "event-4", EventType: EventTypeOrderCreated, PayloadType: "order_created.v2", Payload: []byte(`{}`)},
+	}
+	for _, msg := range messages {
+		if err := dispatcher.Dispatch(context.Background(), msg); err != nil {
+			t.Fatalf("Dispatch(%s) error = %v", msg.ID, err)
+		}
+	}
+
+	ids := func(h *recordingHandler) []string {
+		var got []string
+		for _, event := range h.events {
+			got = append(got, event.ID)
+		}
+		return got
+	}
+	if got := ids(created); !reflect.DeepEqual(got, []string{"event-1"}) {
+		t.Errorf("created handler got %v, want [event-1]", got)
+	}
+	if got := ids(shipped); !reflect.DeepEqual(got, []string{"event-2"}) {
+		t.Errorf("shipped handler got %v, want [event-2]", got)
+	}
+	if got := ids(all); !reflect.DeepEqual(got, []string{"event-1", "event-2"}) {
+		t.Errorf("handler of both types got %v, want [event-1 event-2]", got)
+	}
+}
+
+func TestDispatchErrors(t *testing.T) {
+	failure := errors.New("handler failed")
+	failing := &recordingHandler{err: failure}
+	next := &recordingHandler{}
+
+	dispatcher := NewDispatcher(zap.NewNop().Sugar())
+	dispatcher.Subscribe(failing, EventTypeOrderCreated)
+	dispatcher.Subscribe(next, EventTypeOrderCreated)
+
+	malformed := Message{ID: "event-1", EventType: EventTypeOrderCreated, PayloadType: PayloadTypeOrderCreatedV1, Payload: []byte(`{`)}
+	if err := dispatcher.Dispatch(context.Background(), malformed); !errors.Is(err, ErrMalformedEvent) {
+		t.Errorf("Dispatch(malformed) error = %v, want ErrMalformedEvent", err)
+	}
+	if len(failing.events) != 0 || len(next.events) != 0 {
+		t.Errorf("Dispatch(malformed) called the handlers")
+	}
+
+	valid := Message{ID: "event-2", EventType: EventTypeOrderCreated, PayloadType: PayloadTypeOrderCreatedV1, Payload: mustMarshal(t, NewOrderCreatedEventV1(testOrder()))}
+	if err := dispatcher.Dispatch(context.Background(), valid); !errors.Is(err, failure) {
+		t.Errorf("Dispatch() error = %v, want the handler's error", err)
+	}
+	if len(next.events) != 1 {
+		t.Errorf("Dispatch() called the handler after the failing one %d times, want 1", len(next.events))
+	}
+}
@@ -0,0 +1,32 @@
+package events
+
+import (
+	"context"
+
+	"go-bootiful-ordering/internal/pkg/metrics"
+	"go.uber.org/zap"
+)
+
+// MetricsHandler logs every order event it receives and counts it in
+// order_events_consumed_total by event type
+type MetricsHandler struct {
+	log     *zap.SugaredLogger
+	metrics *metrics.Metrics
+}
+
+// NewMetricsHandler creates a new MetricsHandler
+func NewMetricsHandler(log *zap.SugaredLogger, m *metrics.Metrics) *MetricsHandler {
+	return &MetricsHandler{
+		log:     log,
+		metrics: m,
+	}
+}
+
+// Handle logs and counts the event
+func (h *MetricsHandler) Handle(ctx context.Context, event *OrderEvent) error {
+	h.log.Infof("Order event consumed: id=%s eventType=%s payloadType=%s orderID=%s",
+		event.ID, event.EventType, event.PayloadType, event.OrderID)
+
+	h.metrics.OrderEventsConsumedCounter.WithLabelValues(string(event.EventType)).Inc()
+	return nil
+}
@@ -0,0 +1,145 @@
+package events
+
+import (
+	"time"
+
+	"go-bootiful-ordering/internal/order/domain"
+)
+
+// EventType represents the type of event
+type EventType string
+
+const (
+	// EventTypeOrderCreated represents an order created event
+	EventTypeOrderCreated EventType = "order_created"
+	// EventTypeOrderStatusUpdated represents an order status updated event
+	EventTypeOrderStatusUpdated EventType = "order_status_updated"
+	// EventTypeOrderCancelled represents an order cancelled event
+	EventTypeOrderCancelled EventType = "order_cancelled"
+	// EventTypeOrderShipped represents an order shipped event, carrying the tracking number and carrier
+	EventTypeOrderShipped EventType = "order_shipped"
+)
+
+// PayloadType names the schema of an event payload, so consumers can decode it without
+// inspecting it first. Fields may be added to a schema version; renaming or removing one
+// means a new version with a new payload type.
+type PayloadType string
+
+const (
+	// PayloadTypeOrderCreatedV1 is the payload type of OrderCreatedEventV1
+	PayloadTypeOrderCreatedV1 PayloadType = "order_created.v1"
+	// PayloadTypeOrderStatusUpdatedV1 is the payload type of OrderStatusUpdatedEventV1
+	PayloadTypeOrderStatusUpdatedV1 PayloadType = "order_status_updated.v1"
+	// PayloadTypeOrderCancelledV1 is the payload type of OrderCancelledEventV1
+	PayloadTypeOrderCancelledV1 PayloadType = "order_cancelled.v1"
+	// PayloadTypeOrderShippedV1 is the payload type of OrderShippedEventV1
+	PayloadTypeOrderShippedV1 PayloadType = "order_shipped.v1"
+)
+
+// SchemaVersionV1 is the schema_version of the V1 payloads
+const SchemaVersionV1 = 1
+
+// OrderItemV1 is an item of an order in the V1 payloads
+type OrderItemV1 struct {
+	ProductID string `json:"product_id"`
+	Quantity  int32  `json:"quantity"`
+	Price     int64  `json:"price"`
+}
+
+// OrderCreatedEventV1 is the payload of an order_created event
+type OrderCreatedEventV1 struct {
+	SchemaVersion int           `json:"schema_version"`
+	OrderID       string        `json:"order_id"`
+	CustomerID    string        `json:"customer_id"`
+	Status        string        `json:"status"`
+	Items         []OrderItemV1 `json:"items"`
+	TotalAmount   int64         `json:"total_amount"`
+	CreatedAt     time.Time     `json:"created_at"`
+}
+
+// OrderStatusUpdatedEventV1 is the payload of an order_status_updated event
+type OrderStatusUpdatedEventV1 struct {
+	SchemaVersion int       `json:"schema_version"`
+	OrderID       string    `json:"order_id"`
+	CustomerID    string    `json:"customer_id"`
+	Status        string    `json:"status"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// OrderCancelledEventV1 is the payload of an order_cancelled event. It lists the items so
+// inventory projections can return their quantities.
+type OrderCancelledEventV1 struct {
+	SchemaVersion int           `json:"schema_version"`
+	OrderID       string        `json:"order_id"`
+	CustomerID    string        `json:"customer_id"`
+	Items         []OrderItemV1 `json:"items"`
+	CancelledAt   time.Time     `json:"cancelled_at"`
+}
+
+// OrderShippedEventV1 is the payload of an order_shipped event. TrackingNumber and Carrier
+// are empty when the order shipped without tracking information.
+type OrderShippedEventV1 struct {
+	SchemaVersion  int       `json:"schema_version"`
+	OrderID        string    `json:"order_id"`
+	CustomerID     string    `json:"customer_id"`
+	TrackingNumber string    `json:"tracking_number"`
+	Carrier        string    `json:"carrier"`
+	ShippedAt      time.Time `json:"shipped_at"`
+}
+
+// NewOrderCreatedEventV1 creates the order_created payload of an order
+func NewOrderCreatedEventV1(order *domain.Order) *OrderCreatedEventV1 {
+	return &OrderCreatedEventV1{
+		SchemaVersion: SchemaVersionV1,
+		OrderID:       order.ID,
+		CustomerID:    order.CustomerID,
+		Status:        order.Status.String(),
+		Items:         itemsV1(order.Items),
+		TotalAmount:   order.TotalAmount,
+		CreatedAt:     order.CreatedAt,
+	}
+}
+
+// NewOrderStatusUpdatedEventV1 creates the order_status_updated payload of an order
+func NewOrderStatusUpdatedEventV1(order *domain.Order) *OrderStatusUpdatedEventV1 {
+	return &OrderStatusUpdatedEventV1{
+		SchemaVersion: SchemaVersionV1,
+		OrderID:       order.ID,
+		CustomerID:    order.CustomerID,
+		Status:        order.Status.String(),
+		UpdatedAt:     order.UpdatedAt,
+	}
+}
+
+// NewOrderCancelledEventV1 creates the order_cancelled payload of a cancelled order
+func NewOrderCancelledEventV1(order *domain.Order) *OrderCancelledEventV1 {
+	return &OrderCancelledEventV1{
+		SchemaVersion: SchemaVersionV1,
+		OrderID:       order.ID,
+		CustomerID:    order.CustomerID,
+		Items:         itemsV1(order.Items),
+		CancelledAt:   order.UpdatedAt,
+	}
+}
+
+// NewOrderShippedEventV1 creates the order_shipped payload of a shipped order
+func NewOrderShippedEventV1(order *domain.Order) *OrderShippedEventV1 {
+	return &OrderShippedEventV1{
+		SchemaVersion:  SchemaVersionV1,
+		OrderID:        order.ID,
+		CustomerID:     order.CustomerID,
+		TrackingNumber: order.TrackingNumber,
+		Carrier:        order.Carrier,
+		ShippedAt:      order.UpdatedAt,
+	}
+}
+
+// itemsV1 converts order items to their V1 payload form, never returning nil so the
+// items are serialized as an empty list rather than null
+func itemsV1(items []domain.OrderItem) []OrderItemV1 {
+	converted := make([]OrderItemV1, len(items))
+	for i, item := range items {
+		converted[i] = OrderItemV1{ProductID: item.ProductID, Quantity: item.Quantity, Price: item.Price}
+	}
+	return converted
+}
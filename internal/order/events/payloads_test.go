@@ -0,0 +1,108 @@
+package events
+
+import (
+	"reflect"
+	"testing"
+
+	"go-bootiful-ordering/internal/order/domain"
+)
+
+// The JSON of each V1 payload is part of the contract with consumers: changing a field
+// name or type here needs a new schema version, not an update of the expected JSON
+func TestPayloadJSONShape(t *testing.T) {
+	order := testOrder()
+
+	tests := []struct {
+		name    string
+		payload interface{}
+		want    string
+	}{
+		{
+			name:    "order created",
+			payload: NewOrderCreatedEventV1(order),
+			want: `{"schema_version":1,"order_id":"order-1","customer_id":"customer-1","status":"shipped",` +
+				`"items":[{"product_id":"product-1","quantity":2,"price":1000}],"total_amount":2000,"created_at":"2024-03-01T09:30:00Z"}`,
+		},
+		{
+			name:    "order status updated",
+			payload: NewOrderStatusUpdatedEventV1(order),
+			want:    `{"schema_version":1,"order_id":"order-1","customer_id":"customer-1","status":"shipped","updated_at":"2024-03-01T10:30:00Z"}`,
+		},
+		{
+			name:    "order cancelled",
+			payload: NewOrderCancelledEventV1(order),
+			want: `{"schema_version":1,"order_id":"order-1","customer_id":"customer-1",` +
+				`"items":[{"product_id":"product-1","quantity":2,"price":1000}],"cancelled_at":"2024-03-01T10:30:00Z"}`,
+		},
+		{
+			name:    "order shipped",
+			payload: NewOrderShippedEventV1(order),
+			want: `{"schema_version":1,"order_id":"order-1","customer_id":"customer-1","tracking_number":"1Z999","carrier":"UPS",` +
+				`"shipped_at":"2024-03-01T10:30:00Z"}`,
+		},
+		// Consumers iterate the items without a null check
+		{
+			name:    "order created without items",
+			payload: NewOrderCreatedEventV1(&domain.Order{ID: "order-2", CustomerID: "customer-1", Status: domain.OrderStatusPending}),
+			want:    `{"schema_version":1,"order_id":"order-2","customer_id":"customer-1","status":"pending","items":[],"total_amount":0,"created_at":"0001-01-01T00:00:00Z"}`,
+		},
+		{
+			name:    "order cancelled without items",
+			payload: NewOrderCancelledEventV1(&domain.Order{ID: "order-2", CustomerID: "customer-1"}),
+			want:    `{"schema_version":1,"order_id":"order-2","customer_id":"customer-1","items":[],"cancelled_at":"0001-01-01T00:00:00Z"}`,
+		},
+		{
+			name:    "order shipped without tracking",
+			payload: NewOrderShippedEventV1(&domain.Order{ID: "order-2", CustomerID: "customer-1"}),
+			want:    `{"schema_version":1,"order_id":"order-2","customer_id":"customer-1","tracking_number":"","carrier":"","shipped_at":"0001-01-01T00:00:00Z"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(mustMarshal(t, tt.payload)); got != tt.want {
+				t.Errorf("payload JSON = %s\nwant           %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// A producer may add fields to a V1 payload; consumers of this version decode the fields
+// they know and ignore the rest
+func TestDecodeEventIgnoresAddedFields(t *testing.T) {
+	tests := []struct {
+		name        string
+		payloadType PayloadType
+		payload     string
+		want        interface{}
+	}{
+		{
+			name:        "order created",
+			payloadType: PayloadTypeOrderCreatedV1,
+			payload: `{"schema_version":1,"order_id":"order-1","customer_id":"customer-1","status":"pending","currency":"VND",` +
+				`"items":[{"product_id":"product-1","quantity":2,"price":1000,"sku":"LAMP-1"}],"total_amount":2000}`,
+			want: &OrderCreatedEventV1{
+				SchemaVersion: 1, OrderID: "order-1", CustomerID: "customer-1", Status: "pending",
+				Items: []OrderItemV1{{ProductID: "product-1", Quantity: 2, Price: 1000}}, TotalAmount: 2000,
+			},
+		},
+		{
+			name:        "order shipped",
+			payloadType: PayloadTypeOrderShippedV1,
+			payload:     `{"schema_version":1,"order_id":"order-1","tracking_number":"1Z999","carrier":"UPS","tracking_url":"https://ups.example/1Z999"}`,
+			want:        &OrderShippedEventV1{SchemaVersion: 1, OrderID: "order-1", TrackingNumber: "1Z999", Carrier: "UPS"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := DecodeEvent(Message{ID: "event-1", PayloadType: tt.payloadType, Payload: []byte(tt.payload)})
+			if err != nil {
+				t.Fatalf("DecodeEvent() error = %v", err)
+			}
+			if !reflect.DeepEqual(event.Payload, tt.want) {
+				t.Errorf("DecodeEvent() payload = %+v, want %+v", event.Payload, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,334 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"go-bootiful-ordering/internal/order/domain"
+	"go-bootiful-ordering/internal/order/service"
+	"go-bootiful-ordering/internal/pkg/auth"
+	"go-bootiful-ordering/internal/pkg/requestid"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+)
+
+// defaultTimeSeriesRange is the range used when the time series request omits from
+const defaultTimeSeriesRange = 30 * 24 * time.Hour
+
+// OrderTimeSeriesHandler handles admin requests for order counts over time
+type OrderTimeSeriesHandler struct {
+	log     *zap.SugaredLogger
+	service service.OrderService
+	guard   *auth.AdminGuard
+}
+
+// NewOrderTimeSeriesHandler creates a new OrderTimeSeriesHandler
+func NewOrderTimeSeriesHandler(log *zap.SugaredLogger, service service.OrderService, guard *auth.AdminGuard) *OrderTimeSeriesHandler {
+	return &OrderTimeSeriesHandler{
+		log:     log,
+		service: service,
+		guard:   guard,
+	}
+}
+
+// Pattern returns the URL pattern for this handler
+func (h *OrderTimeSeriesHandler) Pattern() string {
+	return "/admin/orders/timeseries"
+}
+
+// Register registers the handler with the router group
+func (h *OrderTimeSeriesHandler) Register(rg *gin.RouterGroup) {
+	rg.GET("/admin/orders/timeseries", h.guard.GinMiddleware(), h.TimeSeries)
+}
+
+// TimeSeries handles HTTP requests for order counts grouped by day, week or month
+func (h *OrderTimeSeriesHandler) TimeSeries(c *gin.Context) {
+	log := requestid.SugaredLogger(c.Request.Context(), h.log)
+
+	to := time.Now()
+	if toStr := c.Query("to"); toStr != "" {
+		parsed, err := parseTime(toStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to parameter"})
+			return
+		}
+		to = parsed
+	}
+
+	from := to.Add(-defaultTimeSeriesRange)
+	if fromStr := c.Query("from"); fromStr != "" {
+		parsed, err := parseTime(fromStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from parameter"})
+			return
+		}
+		from = parsed
+	}
+
+	bucket := domain.TimeBucket(c.DefaultQuery("bucket", string(domain.TimeBucketDay)))
+	customerID := c.Query("customer_id")
+
+	counts, err := h.service.CountOrdersByPeriod(c.Request.Context(), customerID, bucket, from, to)
+	if err != nil {
+		log.Errorf("Failed to count orders: %v", err)
+		if errors.Is(err, domain.ErrInvalidArgument) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, service.ErrUnsupported) {
+			c.JSON(http.StatusNotImplemented, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count orders"})
+		return
+	}
+
+	c.JSON(http.StatusOK, newPeriodCountResponses(counts))
+}
+
+// parseTime parses an RFC3339 timestamp or a YYYY-MM-DD date
+func parseTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
+
+// OrderEventsHandler handles admin requests for the outbox events of an order
+type OrderEventsHandler struct {
+	log     *zap.SugaredLogger
+	service service.OrderService
+	guard   *auth.AdminGuard
+}
+
+// NewOrderEventsHandler creates a new OrderEventsHandler
+func NewOrderEventsHandler(log *zap.SugaredLogger, service service.OrderService, guard *auth.AdminGuard) *OrderEventsHandler {
+	return &OrderEventsHandler{
+		log:     log,
+		service: service,
+		guard:   guard,
+	}
+}
+
+// Pattern returns the URL pattern for this handler
+func (h *OrderEventsHandler) Pattern() string {
+	return "/admin/orders/:id/events"
+}
+
+// Register registers the handler with the router group
+func (h *OrderEventsHandler) Register(rg *gin.RouterGroup) {
+	rg.GET("/admin/orders/:id/events", h.guard.GinMiddleware(), h.ListEvents)
+}
+
+// OrderEventResponse represents an outbox entry in admin responses
+type OrderEventResponse struct {
+	ID            string          `json:"id"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   string          `json:"aggregate_id"`
+	EventType     string          `json:"event_type"`
+	Payload       json.RawMessage `json:"payload"`
+	CreatedAt     time.Time       `json:"created_at"`
+	TraceID       string          `json:"trace_id,omitempty"`
+	PayloadType   string          `json:"payload_type,omitempty"`
+}
+
+// ListEvents handles HTTP requests to list the outbox events of an order
+func (h *OrderEventsHandler) ListEvents(c *gin.Context) {
+	log := requestid.SugaredLogger(c.Request.Context(), h.log)
+
+	orderID := c.Param("id")
+	if orderID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Order ID is required"})
+		return
+	}
+
+	orderEvents, err := h.service.GetOrderEvents(c.Request.Context(), orderID)
+	if err != nil {
+		log.Errorf("Failed to get order events: %v, orderID=%s", err, orderID)
+		if errors.Is(err, service.ErrUnsupported) {
+			c.JSON(http.StatusNotImplemented, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get order events"})
+		return
+	}
+
+	events := make([]OrderEventResponse, len(orderEvents))
+	for i, event := range orderEvents {
+		events[i] = OrderEventResponse{
+			ID:            event.ID,
+			AggregateType: event.AggregateType,
+			AggregateID:   event.AggregateID,
+			EventType:     string(event.EventType),
+			Payload:       event.Payload,
+			CreatedAt:     event.CreatedAt,
+			TraceID:       event.TraceID,
+			PayloadType:   string(event.PayloadType),
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"events": events})
+}
+
+// ReplayEventsHandler handles admin requests to re-publish past order events
+type ReplayEventsHandler struct {
+	log     *zap.SugaredLogger
+	service service.OrderService
+	guard   *auth.AdminGuard
+}
+
+// NewReplayEventsHandler creates a new ReplayEventsHandler
+func NewReplayEventsHandler(log *zap.SugaredLogger, service service.OrderService, guard *auth.AdminGuard) *ReplayEventsHandler {
+	return &ReplayEventsHandler{
+		log:     log,
+		service: service,
+		guard:   guard,
+	}
+}
+
+// Pattern returns the URL pattern for this handler
+func (h *ReplayEventsHandler) Pattern() string {
+	return "/admin/events/replay"
+}
+
+// Register registers the handler with the router group
+func (h *ReplayEventsHandler) Register(rg *gin.RouterGroup) {
+	rg.POST("/admin/events/replay", h.guard.GinMiddleware(), h.ReplayEvents)
+}
+
+// ReplayEvents handles HTTP requests to replay the order events created between from and to
+func (h *ReplayEventsHandler) ReplayEvents(c *gin.Context) {
+	log := requestid.SugaredLogger(c.Request.Context(), h.log)
+
+	// Both ends of the range are required so a replay is never unbounded by accident
+	from, err := parseTime(c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from parameter"})
+		return
+	}
+
+	to, err := parseTime(c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to parameter"})
+		return
+	}
+
+	aggregateID := c.Query("aggregate_id")
+
+	replayed, err := h.service.ReplayEvents(c.Request.Context(), aggregateID, from, to)
+	if err != nil {
+		log.Errorf("Failed to replay events: %v, aggregateID=%s", err, aggregateID)
+		if errors.Is(err, domain.ErrInvalidArgument) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, service.ErrUnsupported) {
+			c.JSON(http.StatusNotImplemented, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to replay events"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"replayed": replayed})
+}
+
+// BulkShipOrdersHandler handles admin requests to ship many orders with their tracking numbers
+type BulkShipOrdersHandler struct {
+	log     *zap.SugaredLogger
+	service service.OrderService
+	guard   *auth.AdminGuard
+}
+
+// NewBulkShipOrdersHandler creates a new BulkShipOrdersHandler
+func NewBulkShipOrdersHandler(log *zap.SugaredLogger, service service.OrderService, guard *auth.AdminGuard) *BulkShipOrdersHandler {
+	return &BulkShipOrdersHandler{
+		log:     log,
+		service: service,
+		guard:   guard,
+	}
+}
+
+// Pattern returns the URL pattern for this handler
+func (h *BulkShipOrdersHandler) Pattern() string {
+	return "/admin/orders/ship"
+}
+
+// Register registers the handler with the router group
+func (h *BulkShipOrdersHandler) Register(rg *gin.RouterGroup) {
+	rg.POST("/admin/orders/ship", h.guard.GinMiddleware(), h.ShipOrders)
+}
+
+// shipResultResponse is the outcome of shipping one order in a bulk shipment. Status is
+// the code a single PATCH /orders/{id} shipping it would have answered.
+type shipResultResponse struct {
+	OrderID string         `json:"order_id"`
+	Status  int            `json:"status"`
+	Error   string         `json:"error,omitempty"`
+	Order   *orderResponse `json:"order,omitempty"`
+}
+
+// ShipOrders handles HTTP requests to ship the orders listed in the JSON body
+// ([{"order_id": "...", "tracking_number": "...", "carrier": "..."}, ...]), reporting the
+// outcome of each order in request order
+func (h *BulkShipOrdersHandler) ShipOrders(c *gin.Context) {
+	log := requestid.SugaredLogger(c.Request.Context(), h.log)
+
+	var request []struct {
+		OrderID        string `json:"order_id"`
+		TrackingNumber string `json:"tracking_number"`
+		Carrier        string `json:"carrier"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Errorf("Failed to decode request: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	shipments := make([]service.OrderShipment, len(request))
+	for i, item := range request {
+		shipments[i] = service.OrderShipment{
+			OrderID:  item.OrderID,
+			Shipment: domain.Shipment{TrackingNumber: item.TrackingNumber, Carrier: item.Carrier},
+		}
+	}
+
+	results, err := service.ShipOrders(c.Request.Context(), h.service, shipments)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	shipped := 0
+	responses := make([]shipResultResponse, len(results))
+	for i, result := range results {
+		responses[i] = shipResultResponse{OrderID: result.OrderID, Status: http.StatusOK}
+		if result.Err != nil {
+			responses[i].Status, responses[i].Error = shipErrorResponse(result.Err)
+			if responses[i].Status == http.StatusInternalServerError {
+				log.Errorf("Failed to ship order: %v, orderID=%s", result.Err, result.OrderID)
+			}
+			continue
+		}
+		order := newOrderResponse(result.Order)
+		responses[i].Order = &order
+		shipped++
+	}
+
+	c.JSON(http.StatusOK, gin.H{"shipped": shipped, "failed": len(results) - shipped, "results": responses})
+}
+
+// shipErrorResponse returns the status code and message reporting an order that failed to ship
+func shipErrorResponse(err error) (int, string) {
+	switch {
+	case errors.Is(err, domain.ErrOrderNotFound):
+		return http.StatusNotFound, "Order not found"
+	case errors.Is(err, domain.ErrInvalidStatusTransition):
+		return http.StatusConflict, err.Error()
+	case errors.Is(err, domain.ErrInvalidArgument):
+		return http.StatusBadRequest, err.Error()
+	default:
+		return http.StatusInternalServerError, "Failed to ship order"
+	}
+}
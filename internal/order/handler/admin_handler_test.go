@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go-bootiful-ordering/internal/order/domain"
+	"go-bootiful-ordering/internal/order/events"
+	"go-bootiful-ordering/internal/order/service"
+	"go-bootiful-ordering/internal/pkg/auth"
+	"go-bootiful-ordering/internal/pkg/idgen"
+	"go.uber.org/zap"
+)
+
+const testAdminAPIKey = "admin-key"
+
+// newAdminEngine serves handler on a gin engine guarded by testAdminAPIKey
+func newAdminEngine(t *testing.T, register func(guard *auth.AdminGuard, rg *gin.RouterGroup)) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	guard, err := auth.NewAdminGuard(testAdminAPIKey, nil, nil)
+	if err != nil {
+		t.Fatalf("NewAdminGuard() error = %v", err)
+	}
+	engine := gin.New()
+	register(guard, engine.Group(""))
+	return engine
+}
+
+func TestListOrderEvents(t *testing.T) {
+	ctx := context.Background()
+	svc := service.NewMemoryOrderService(zap.NewNop().Sugar(), idgen.NewSequenceGenerator("id-"),
+		service.AmountConfig{}, service.ProductIDConfig{}, service.ShipmentConfig{}, service.ItemConfig{})
+	order, err := svc.CreateOrder(ctx, "customer-1", []domain.OrderItem{{ProductID: "product-1", Quantity: 2, Price: 1000}})
+	if err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+	if _, err := svc.UpdateOrderStatus(ctx, order.ID, domain.OrderStatusProcessing); err != nil {
+		t.Fatalf("UpdateOrderStatus() error = %v", err)
+	}
+	other, err := svc.CreateOrder(ctx, "customer-2", []domain.OrderItem{{ProductID: "product-2", Quantity: 1, Price: 500}})
+	if err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+
+	engine := newAdminEngine(t, func(guard *auth.AdminGuard, rg *gin.RouterGroup) {
+		NewOrderEventsHandler(zap.NewNop().Sugar(), svc, guard).Register(rg)
+	})
+	list := func(orderID, apiKey string) (int, []OrderEventResponse) {
+		req := httptest.NewRequest(http.MethodGet, "/admin/orders/"+orderID+"/events", nil)
+		if apiKey != "" {
+			req.Header.Set(auth.APIKeyHeader, apiKey)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+
+		var body struct {
+			Events []OrderEventResponse `json:"events"`
+		}
+		if w.Code == http.StatusOK {
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decoding %s: %v", w.Body.String(), err)
+			}
+		}
+		return w.Code, body.Events
+	}
+
+	code, got := list(order.ID, testAdminAPIKey)
+	if code != http.StatusOK || len(got) != 2 {
+		t.Fatalf("GET events = %d with %d events, want 200 with 2", code, len(got))
+	}
+	want := []struct {
+		eventType   events.EventType
+		payloadType events.PayloadType
+	}{
+		{events.EventTypeOrderCreated, events.PayloadTypeOrderCreatedV1},
+		{events.EventTypeOrderStatusUpdated, events.PayloadTypeOrderStatusUpdatedV1},
+	}
+	for i, event := range got {
+		if event.EventType != string(want[i].eventType) || event.PayloadType != string(want[i].payloadType) || event.AggregateID != order.ID {
+			t.Errorf("events[%d] = %s %s of %s, want %s %s of %s", i, event.EventType, event.PayloadType, event.AggregateID, want[i].eventType, want[i].payloadType, order.ID)
+		}
+	}
+
+	var updated events.OrderStatusUpdatedEventV1
+	if err := json.Unmarshal(got[1].Payload, &updated); err != nil || updated.OrderID != order.ID || updated.Status != domain.OrderStatusProcessing.String() {
+		t.Errorf("status updated payload = %s, want order %s moved to processing", got[1].Payload, order.ID)
+	}
+
+	if code, got := list(other.ID, testAdminAPIKey); code != http.StatusOK || len(got) != 1 || got[0].EventType != string(events.EventTypeOrderCreated) {
+		t.Errorf("GET events of another order = %d with %+v, want only its created event", code, got)
+	}
+	if code, got := list("order-missing", testAdminAPIKey); code != http.StatusOK || len(got) != 0 {
+		t.Errorf("GET events of an unknown order = %d with %d events, want 200 with none", code, len(got))
+	}
+	if code, _ := list(order.ID, ""); code != http.StatusUnauthorized {
+		t.Errorf("GET events without an API key = %d, want 401", code)
+	}
+}
@@ -2,13 +2,17 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"go-bootiful-ordering/gen/order/v1"
 	"go-bootiful-ordering/internal/order/domain"
+	"go-bootiful-ordering/internal/order/protoconv"
 	"go-bootiful-ordering/internal/order/service"
+	"go-bootiful-ordering/internal/pkg/paging"
+	"go-bootiful-ordering/internal/pkg/requestid"
 	"go.uber.org/zap"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
-	"time"
 )
 
 // GRPCOrderServer implements the OrderService gRPC server
@@ -16,54 +20,64 @@ type GRPCOrderServer struct {
 	orderv1.UnimplementedOrderServiceServer
 	log     *zap.SugaredLogger
 	service service.OrderService
+	paging  paging.Limits
 }
 
 // NewGRPCOrderServer creates a new GRPCOrderServer
-func NewGRPCOrderServer(log *zap.SugaredLogger, service service.OrderService) *GRPCOrderServer {
+func NewGRPCOrderServer(log *zap.SugaredLogger, service service.OrderService, paging paging.Limits) *GRPCOrderServer {
 	return &GRPCOrderServer{
 		log:     log,
 		service: service,
+		paging:  paging,
 	}
 }
 
 // CreateOrder implements the CreateOrder RPC method
 func (s *GRPCOrderServer) CreateOrder(ctx context.Context, req *orderv1.CreateOrderRequest) (*orderv1.CreateOrderResponse, error) {
-	s.log.Infof("GRPCOrderServer_CreateOrder customerID=%s", req.CustomerId)
+	log := requestid.SugaredLogger(ctx, s.log)
 
-	if req.CustomerId == "" {
-		return nil, status.Error(codes.InvalidArgument, "customer_id is required")
-	}
+	log.Infof("GRPCOrderServer_CreateOrder customerID=%s", req.CustomerId)
 
-	if len(req.Items) == 0 {
-		return nil, status.Error(codes.InvalidArgument, "at least one item is required")
+	// Convert protobuf items to domain items
+	items := protoconv.ItemsFromProto(req.Items)
+
+	// Validate request
+	candidate := &domain.Order{CustomerID: req.CustomerId, Items: items, Status: domain.OrderStatusPending}
+	if err := candidate.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
 
-	// Convert protobuf items to domain items
-	items := make([]domain.OrderItem, len(req.Items))
-	for i, item := range req.Items {
-		items[i] = domain.OrderItem{
-			ProductID: item.ProductId,
-			Quantity:  item.Quantity,
-			Price:     item.Price,
+	// Pass the idempotency key from the request metadata on to the service
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if keys := md.Get(service.IdempotencyMetadataKey); len(keys) > 0 {
+			ctx = service.WithIdempotencyKey(ctx, keys[0])
 		}
 	}
 
 	// Create order using the service
-	order, err := s.service.CreateOrder(ctx, req.CustomerId, items)
+	order, err := s.service.CreateOrder(ctx, req.CustomerId, candidate.Items)
 	if err != nil {
-		s.log.Errorf("Failed to create order: %v", err)
+		log.Errorf("Failed to create order: %v", err)
+		if errors.Is(err, domain.ErrInvalidArgument) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if errors.Is(err, domain.ErrInsufficientStock) {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
 		return nil, status.Error(codes.Internal, "failed to create order")
 	}
 
 	// Convert domain order to protobuf order
 	return &orderv1.CreateOrderResponse{
-		Order: domainToProtoOrder(order),
+		Order: protoconv.OrderToProto(order),
 	}, nil
 }
 
 // GetOrder implements the GetOrder RPC method
 func (s *GRPCOrderServer) GetOrder(ctx context.Context, req *orderv1.GetOrderRequest) (*orderv1.GetOrderResponse, error) {
-	s.log.Infof("GRPCOrderServer_GetOrder orderID=%s", req.OrderId)
+	log := requestid.SugaredLogger(ctx, s.log)
+
+	log.Infof("GRPCOrderServer_GetOrder orderID=%s", req.OrderId)
 
 	if req.OrderId == "" {
 		return nil, status.Error(codes.InvalidArgument, "order_id is required")
@@ -72,119 +86,182 @@ func (s *GRPCOrderServer) GetOrder(ctx context.Context, req *orderv1.GetOrderReq
 	// Get order using the service
 	order, err := s.service.GetOrder(ctx, req.OrderId)
 	if err != nil {
-		s.log.Errorf("Failed to get order: %v, orderID=%s", err, req.OrderId)
-		return nil, status.Error(codes.NotFound, "order not found")
+		log.Errorf("Failed to get order: %v, orderID=%s", err, req.OrderId)
+		if errors.Is(err, domain.ErrOrderNotFound) {
+			return nil, status.Error(codes.NotFound, "order not found")
+		}
+		return nil, status.Error(codes.Internal, "failed to get order")
 	}
 
 	// Convert domain order to protobuf order
 	return &orderv1.GetOrderResponse{
-		Order: domainToProtoOrder(order),
+		Order: protoconv.OrderToProto(order),
+	}, nil
+}
+
+// ListOrderItems implements the ListOrderItems RPC method
+func (s *GRPCOrderServer) ListOrderItems(ctx context.Context, req *orderv1.ListOrderItemsRequest) (*orderv1.ListOrderItemsResponse, error) {
+	log := requestid.SugaredLogger(ctx, s.log)
+
+	log.Infof("GRPCOrderServer_ListOrderItems orderID=%s pageSize=%d pageToken=%s",
+		req.OrderId, req.PageSize, req.PageToken)
+
+	if req.OrderId == "" {
+		return nil, status.Error(codes.InvalidArgument, "order_id is required")
+	}
+
+	// Apply the default and maximum page size
+	pageSize, err := s.paging.PageSize(req.PageSize)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	items, nextPageToken, err := s.service.ListOrderItems(ctx, req.OrderId, pageSize, req.PageToken)
+	if err != nil {
+		log.Errorf("Failed to list order items: %v, orderID=%s", err, req.OrderId)
+		if errors.Is(err, domain.ErrInvalidArgument) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if errors.Is(err, domain.ErrOrderNotFound) {
+			return nil, status.Error(codes.NotFound, "order not found")
+		}
+		return nil, status.Error(codes.Internal, "failed to list order items")
+	}
+
+	return &orderv1.ListOrderItemsResponse{
+		Items:         protoconv.ItemsToProto(items),
+		NextPageToken: nextPageToken,
 	}, nil
 }
 
 // ListOrders implements the ListOrders RPC method
 func (s *GRPCOrderServer) ListOrders(ctx context.Context, req *orderv1.ListOrdersRequest) (*orderv1.ListOrdersResponse, error) {
-	s.log.Infof("GRPCOrderServer_ListOrders customerID=%s pageSize=%d pageToken=%s",
+	log := requestid.SugaredLogger(ctx, s.log)
+
+	log.Infof("GRPCOrderServer_ListOrders customerID=%s pageSize=%d pageToken=%s",
 		req.CustomerId, req.PageSize, req.PageToken)
 
 	if req.CustomerId == "" {
 		return nil, status.Error(codes.InvalidArgument, "customer_id is required")
 	}
 
+	// Apply the default and maximum page size
+	pageSize, err := s.paging.PageSize(req.PageSize)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
 	// List orders using the service
-	orders, nextPageToken, err := s.service.ListOrders(ctx, req.CustomerId, req.PageSize, req.PageToken)
+	orders, nextPageToken, err := s.service.ListOrders(ctx, req.CustomerId, pageSize, req.PageToken)
 	if err != nil {
-		s.log.Errorf("Failed to list orders: %v, customerID=%s", err, req.CustomerId)
+		log.Errorf("Failed to list orders: %v, customerID=%s", err, req.CustomerId)
+		if errors.Is(err, domain.ErrInvalidArgument) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
 		return nil, status.Error(codes.Internal, "failed to list orders")
 	}
 
+	// Count the customer's orders only when asked, as it costs a second query
+	var totalSize *int64
+	if req.IncludeTotal {
+		total, err := s.service.CountOrders(ctx, req.CustomerId)
+		if err != nil {
+			log.Errorf("Failed to count orders: %v, customerID=%s", err, req.CustomerId)
+			return nil, status.Error(codes.Internal, "failed to list orders")
+		}
+		totalSize = &total
+	}
+
 	// Convert domain orders to protobuf orders
 	protoOrders := make([]*orderv1.Order, len(orders))
 	for i, order := range orders {
-		protoOrders[i] = domainToProtoOrder(order)
+		protoOrders[i] = protoconv.OrderToProto(order)
 	}
 
 	return &orderv1.ListOrdersResponse{
 		Orders:        protoOrders,
 		NextPageToken: nextPageToken,
+		TotalSize:     totalSize,
 	}, nil
 }
 
 // UpdateOrderStatus implements the UpdateOrderStatus RPC method
 func (s *GRPCOrderServer) UpdateOrderStatus(ctx context.Context, req *orderv1.UpdateOrderStatusRequest) (*orderv1.UpdateOrderStatusResponse, error) {
-	s.log.Infof("GRPCOrderServer_UpdateOrderStatus orderID=%s status=%d",
-		req.OrderId, int32(req.Status))
+	log := requestid.SugaredLogger(ctx, s.log)
+
+	log.Infof("GRPCOrderServer_UpdateOrderStatus orderID=%s status=%d carrier=%s",
+		req.OrderId, int32(req.Status), req.Carrier)
 
 	if req.OrderId == "" {
 		return nil, status.Error(codes.InvalidArgument, "order_id is required")
 	}
 
 	// Convert protobuf status to domain status
-	var orderStatus domain.OrderStatus
-	switch req.Status {
-	case orderv1.OrderStatus_ORDER_STATUS_PENDING:
-		orderStatus = domain.OrderStatusPending
-	case orderv1.OrderStatus_ORDER_STATUS_PROCESSING:
-		orderStatus = domain.OrderStatusProcessing
-	case orderv1.OrderStatus_ORDER_STATUS_SHIPPED:
-		orderStatus = domain.OrderStatusShipped
-	case orderv1.OrderStatus_ORDER_STATUS_DELIVERED:
-		orderStatus = domain.OrderStatusDelivered
-	case orderv1.OrderStatus_ORDER_STATUS_CANCELLED:
-		orderStatus = domain.OrderStatusCancelled
-	default:
+	orderStatus := protoconv.StatusFromProto(req.Status)
+	if orderStatus == domain.OrderStatusUnspecified {
 		return nil, status.Error(codes.InvalidArgument, "invalid order status")
 	}
 
+	// Tracking information is only recorded when the order ships
+	shipment := domain.Shipment{TrackingNumber: req.TrackingNumber, Carrier: req.Carrier}
+	if orderStatus != domain.OrderStatusShipped && !shipment.IsZero() {
+		return nil, status.Error(codes.InvalidArgument, "tracking_number and carrier can only be set when shipping an order")
+	}
+
 	// Update order status using the service
-	order, err := s.service.UpdateOrderStatus(ctx, req.OrderId, orderStatus)
+	var order *domain.Order
+	var err error
+	if orderStatus == domain.OrderStatusShipped {
+		order, err = s.service.ShipOrder(ctx, req.OrderId, shipment)
+	} else {
+		order, err = s.service.UpdateOrderStatus(ctx, req.OrderId, orderStatus)
+	}
 	if err != nil {
-		s.log.Errorf("Failed to update order status: %v, orderID=%s", err, req.OrderId)
+		log.Errorf("Failed to update order status: %v, orderID=%s", err, req.OrderId)
+		switch {
+		case errors.Is(err, domain.ErrOrderNotFound):
+			return nil, status.Error(codes.NotFound, "order not found")
+		case errors.Is(err, domain.ErrInvalidStatusTransition):
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		case errors.Is(err, domain.ErrInvalidArgument):
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
 		return nil, status.Error(codes.Internal, "failed to update order status")
 	}
 
 	// Convert domain order to protobuf order
 	return &orderv1.UpdateOrderStatusResponse{
-		Order: domainToProtoOrder(order),
+		Order: protoconv.OrderToProto(order),
 	}, nil
 }
 
-// domainToProtoOrder converts a domain order to a protobuf order
-func domainToProtoOrder(order *domain.Order) *orderv1.Order {
-	// Convert domain items to protobuf items
-	items := make([]*orderv1.OrderItem, len(order.Items))
-	for i, item := range order.Items {
-		items[i] = &orderv1.OrderItem{
-			ProductId: item.ProductID,
-			Quantity:  item.Quantity,
-			Price:     item.Price,
-		}
+// CancelOrder implements the CancelOrder RPC method
+func (s *GRPCOrderServer) CancelOrder(ctx context.Context, req *orderv1.CancelOrderRequest) (*orderv1.CancelOrderResponse, error) {
+	log := requestid.SugaredLogger(ctx, s.log)
+
+	log.Infof("GRPCOrderServer_CancelOrder orderID=%s", req.OrderId)
+
+	if req.OrderId == "" {
+		return nil, status.Error(codes.InvalidArgument, "order_id is required")
 	}
 
-	// Convert domain status to protobuf status
-	var protoStatus orderv1.OrderStatus
-	switch order.Status {
-	case domain.OrderStatusPending:
-		protoStatus = orderv1.OrderStatus_ORDER_STATUS_PENDING
-	case domain.OrderStatusProcessing:
-		protoStatus = orderv1.OrderStatus_ORDER_STATUS_PROCESSING
-	case domain.OrderStatusShipped:
-		protoStatus = orderv1.OrderStatus_ORDER_STATUS_SHIPPED
-	case domain.OrderStatusDelivered:
-		protoStatus = orderv1.OrderStatus_ORDER_STATUS_DELIVERED
-	case domain.OrderStatusCancelled:
-		protoStatus = orderv1.OrderStatus_ORDER_STATUS_CANCELLED
-	default:
-		protoStatus = orderv1.OrderStatus_ORDER_STATUS_UNSPECIFIED
-	}
-
-	return &orderv1.Order{
-		Id:          order.ID,
-		CustomerId:  order.CustomerID,
-		Items:       items,
-		Status:      protoStatus,
-		TotalAmount: order.TotalAmount,
-		CreatedAt:   order.CreatedAt.Format(time.RFC3339),
-		UpdatedAt:   order.UpdatedAt.Format(time.RFC3339),
+	// Cancel the order using the service
+	order, err := s.service.CancelOrder(ctx, req.OrderId)
+	if err != nil {
+		log.Errorf("Failed to cancel order: %v, orderID=%s", err, req.OrderId)
+		switch {
+		case errors.Is(err, domain.ErrOrderNotFound):
+			return nil, status.Error(codes.NotFound, "order not found")
+		case errors.Is(err, domain.ErrInvalidStatusTransition):
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		case errors.Is(err, domain.ErrInvalidArgument):
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "failed to cancel order")
 	}
+
+	// Convert domain order to protobuf order
+	return &orderv1.CancelOrderResponse{
+		Order: protoconv.OrderToProto(order),
+	}, nil
 }
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"context"
+	"testing"
+
+	"go-bootiful-ordering/gen/order/v1"
+	"go-bootiful-ordering/internal/order/domain"
+	"go-bootiful-ordering/internal/order/service"
+	"go-bootiful-ordering/internal/pkg/paging"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// pageSizeRecordingService records the page sizes of the listings reaching it; the methods
+// it does not implement are not expected to be called
+type pageSizeRecordingService struct {
+	service.OrderService
+
+	pageSizes []int32
+}
+
+func (s *pageSizeRecordingService) ListOrders(ctx context.Context, customerID string, pageSize int32, pageToken string) ([]*domain.Order, string, error) {
+	s.pageSizes = append(s.pageSizes, pageSize)
+	return nil, "", nil
+}
+
+func TestGRPCListOrdersPageSize(t *testing.T) {
+	tests := []struct {
+		name      string
+		requested int32
+		want      int32
+		wantCode  codes.Code
+	}{
+		{name: "zero selects the default", requested: 0, want: 10},
+		{name: "within the limit", requested: 25, want: 25},
+		{name: "at the limit", requested: 50, want: 50},
+		{name: "oversized is clamped", requested: 1000, want: 50},
+		{name: "negative is rejected", requested: -1, wantCode: codes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &pageSizeRecordingService{}
+			server := NewGRPCOrderServer(zap.NewNop().Sugar(), svc, paging.NewLimits(10, 50))
+
+			_, err := server.ListOrders(context.Background(), &orderv1.ListOrdersRequest{CustomerId: "customer-1", PageSize: tt.requested})
+			if tt.wantCode != codes.OK {
+				if status.Code(err) != tt.wantCode {
+					t.Fatalf("ListOrders() error = %v, want %s", err, tt.wantCode)
+				}
+				if len(svc.pageSizes) != 0 {
+					t.Errorf("rejected listing reached the service with page sizes %v", svc.pageSizes)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ListOrders() error = %v", err)
+			}
+			if len(svc.pageSizes) != 1 || svc.pageSizes[0] != tt.want {
+				t.Errorf("service listed with page sizes %v, want [%d]", svc.pageSizes, tt.want)
+			}
+		})
+	}
+}
@@ -1,15 +1,17 @@
 package handler
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	"go-bootiful-ordering/internal/order/domain"
 	"go-bootiful-ordering/internal/order/service"
+	"go-bootiful-ordering/internal/pkg/paging"
+	"go-bootiful-ordering/internal/pkg/requestid"
 	"go.uber.org/zap"
 	"net/http"
-	"strconv"
 )
 
-// Route interface defines a HTTP route handler
+// Route interface defines a HTTP route handler; Pattern returns the gin path it registers
 type Route interface {
 	Register(*gin.RouterGroup)
 	Pattern() string
@@ -41,25 +43,159 @@ func (h *CreateOrderHandler) Register(rg *gin.RouterGroup) {
 
 // CreateOrder handles HTTP requests to create orders
 func (h *CreateOrderHandler) CreateOrder(c *gin.Context) {
+	log := requestid.SugaredLogger(c.Request.Context(), h.log)
+
 	var request struct {
 		CustomerID string             `json:"customer_id"`
 		Items      []domain.OrderItem `json:"items"`
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
-		h.log.Errorf("Failed to decode request: %v", err)
+		log.Errorf("Failed to decode request: %v", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
 		return
 	}
 
-	order, err := h.service.CreateOrder(c.Request.Context(), request.CustomerID, request.Items)
+	// Validate request
+	candidate := &domain.Order{CustomerID: request.CustomerID, Items: request.Items, Status: domain.OrderStatusPending}
+	if err := candidate.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := service.WithIdempotencyKey(c.Request.Context(), c.GetHeader(service.IdempotencyHeader))
+	order, err := h.service.CreateOrder(ctx, request.CustomerID, candidate.Items)
 	if err != nil {
-		h.log.Errorf("Failed to create order: %v", err)
+		log.Errorf("Failed to create order: %v", err)
+		if errors.Is(err, domain.ErrInvalidArgument) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, domain.ErrInsufficientStock) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create order"})
 		return
 	}
 
-	c.JSON(http.StatusCreated, order)
+	c.JSON(http.StatusCreated, newOrderResponse(order))
+}
+
+// PreviewOrderHandler handles requests to preview an order without creating it
+type PreviewOrderHandler struct {
+	log     *zap.SugaredLogger
+	service service.OrderService
+}
+
+// NewPreviewOrderHandler creates a new PreviewOrderHandler
+func NewPreviewOrderHandler(log *zap.SugaredLogger, service service.OrderService) *PreviewOrderHandler {
+	return &PreviewOrderHandler{
+		log:     log,
+		service: service,
+	}
+}
+
+// Pattern returns the URL pattern for this handler
+func (h *PreviewOrderHandler) Pattern() string {
+	return "/orders/preview"
+}
+
+// Register registers the handler with the router group
+func (h *PreviewOrderHandler) Register(rg *gin.RouterGroup) {
+	rg.POST("/orders/preview", h.PreviewOrder)
+}
+
+// PreviewOrder handles HTTP requests to preview orders. The price_source query parameter
+// selects the prices used: snapshot, the default, keeps those sent; live takes the current ones.
+func (h *PreviewOrderHandler) PreviewOrder(c *gin.Context) {
+	log := requestid.SugaredLogger(c.Request.Context(), h.log)
+
+	var request struct {
+		CustomerID string             `json:"customer_id"`
+		Items      []domain.OrderItem `json:"items"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Errorf("Failed to decode request: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	source := domain.PriceSource(c.DefaultQuery("price_source", string(domain.PriceSourceSnapshot)))
+	order, err := h.service.PreviewOrder(c.Request.Context(), request.CustomerID, request.Items, source)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidArgument) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, service.ErrUnsupported) {
+			c.JSON(http.StatusNotImplemented, gin.H{"error": err.Error()})
+			return
+		}
+		log.Errorf("Failed to preview order: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to preview order"})
+		return
+	}
+
+	c.JSON(http.StatusOK, newOrderResponse(order))
+}
+
+// ShippingEstimateHandler handles requests to estimate the shipping of an order
+type ShippingEstimateHandler struct {
+	log       *zap.SugaredLogger
+	estimator service.ShippingEstimator
+}
+
+// NewShippingEstimateHandler creates a new ShippingEstimateHandler
+func NewShippingEstimateHandler(log *zap.SugaredLogger, estimator service.ShippingEstimator) *ShippingEstimateHandler {
+	return &ShippingEstimateHandler{
+		log:       log,
+		estimator: estimator,
+	}
+}
+
+// Pattern returns the URL pattern for this handler
+func (h *ShippingEstimateHandler) Pattern() string {
+	return "/orders/shipping-estimate"
+}
+
+// Register registers the handler with the router group
+func (h *ShippingEstimateHandler) Register(rg *gin.RouterGroup) {
+	rg.POST("/orders/shipping-estimate", h.EstimateShipping)
+}
+
+// EstimateShipping handles HTTP requests to estimate the shipping cost and delivery date of items
+func (h *ShippingEstimateHandler) EstimateShipping(c *gin.Context) {
+	log := requestid.SugaredLogger(c.Request.Context(), h.log)
+
+	var request struct {
+		Address domain.Address     `json:"address"`
+		Items   []domain.OrderItem `json:"items"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Errorf("Failed to decode request: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	estimate, err := h.estimator.EstimateShipping(c.Request.Context(), request.Address, request.Items)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidArgument) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, domain.ErrUnknownDestination) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
+		log.Errorf("Failed to estimate shipping: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to estimate shipping"})
+		return
+	}
+
+	c.JSON(http.StatusOK, newShippingEstimateResponse(estimate))
 }
 
 // GetOrderHandler handles requests to get an order by ID
@@ -78,7 +214,7 @@ func NewGetOrderHandler(log *zap.SugaredLogger, service service.OrderService) *G
 
 // Pattern returns the URL pattern for this handler
 func (h *GetOrderHandler) Pattern() string {
-	return "/orders/"
+	return "/orders/:id"
 }
 
 // Register registers the handler with the router group
@@ -88,6 +224,8 @@ func (h *GetOrderHandler) Register(rg *gin.RouterGroup) {
 
 // GetOrder handles HTTP requests to get orders
 func (h *GetOrderHandler) GetOrder(c *gin.Context) {
+	log := requestid.SugaredLogger(c.Request.Context(), h.log)
+
 	orderID := c.Param("id")
 	if orderID == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Order ID is required"})
@@ -96,25 +234,87 @@ func (h *GetOrderHandler) GetOrder(c *gin.Context) {
 
 	order, err := h.service.GetOrder(c.Request.Context(), orderID)
 	if err != nil {
-		h.log.Errorf("Failed to get order: %v, orderID=%s", err, orderID)
+		log.Errorf("Failed to get order: %v, orderID=%s", err, orderID)
 		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
 		return
 	}
 
-	c.JSON(http.StatusOK, order)
+	c.JSON(http.StatusOK, newOrderResponse(order))
+}
+
+// ListOrderItemsHandler handles requests to page through the items of an order
+type ListOrderItemsHandler struct {
+	log     *zap.SugaredLogger
+	service service.OrderService
+	paging  paging.Limits
+}
+
+// NewListOrderItemsHandler creates a new ListOrderItemsHandler
+func NewListOrderItemsHandler(log *zap.SugaredLogger, service service.OrderService, paging paging.Limits) *ListOrderItemsHandler {
+	return &ListOrderItemsHandler{
+		log:     log,
+		service: service,
+		paging:  paging,
+	}
+}
+
+// Pattern returns the URL pattern for this handler
+func (h *ListOrderItemsHandler) Pattern() string {
+	return "/orders/:id/items"
+}
+
+// Register registers the handler with the router group
+func (h *ListOrderItemsHandler) Register(rg *gin.RouterGroup) {
+	rg.GET("/orders/:id/items", h.ListOrderItems)
+}
+
+// ListOrderItems handles HTTP requests to list the items of an order with bounded page sizes,
+// as an order may have more items than fit in memory
+func (h *ListOrderItemsHandler) ListOrderItems(c *gin.Context) {
+	log := requestid.SugaredLogger(c.Request.Context(), h.log)
+
+	orderID := c.Param("id")
+
+	pageSize, err := h.paging.ParsePageSize(c.Query("page_size"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	items, nextPageToken, err := h.service.ListOrderItems(c.Request.Context(), orderID, pageSize, c.Query("page_token"))
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidArgument) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, domain.ErrOrderNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+			return
+		}
+		log.Errorf("Failed to list order items: %v, orderID=%s", err, orderID)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list order items"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items":           items,
+		"next_page_token": nextPageToken,
+	})
 }
 
 // ListOrdersHandler handles requests to list orders
 type ListOrdersHandler struct {
 	log     *zap.SugaredLogger
 	service service.OrderService
+	paging  paging.Limits
 }
 
 // NewListOrdersHandler creates a new ListOrdersHandler
-func NewListOrdersHandler(log *zap.SugaredLogger, service service.OrderService) *ListOrdersHandler {
+func NewListOrdersHandler(log *zap.SugaredLogger, service service.OrderService, paging paging.Limits) *ListOrdersHandler {
 	return &ListOrdersHandler{
 		log:     log,
 		service: service,
+		paging:  paging,
 	}
 }
 
@@ -130,35 +330,60 @@ func (h *ListOrdersHandler) Register(rg *gin.RouterGroup) {
 
 // ListOrders handles HTTP requests to list orders
 func (h *ListOrdersHandler) ListOrders(c *gin.Context) {
+	log := requestid.SugaredLogger(c.Request.Context(), h.log)
+
 	customerID := c.Query("customer_id")
 	if customerID == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Customer ID is required"})
 		return
 	}
 
-	pageSizeStr := c.Query("page_size")
-	pageSize := int32(10) // Default page size
-	if pageSizeStr != "" {
-		if size, err := strconv.ParseInt(pageSizeStr, 10, 32); err == nil {
-			pageSize = int32(size)
-		}
+	// Apply the default and maximum page size
+	pageSize, err := h.paging.ParsePageSize(c.Query("page_size"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
 	pageToken := c.Query("page_token")
 
+	includeTotal, err := paging.ParseIncludeTotal(c.Query("include_total"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	orders, nextPageToken, err := h.service.ListOrders(c.Request.Context(), customerID, pageSize, pageToken)
 	if err != nil {
-		h.log.Errorf("Failed to list orders: %v, customerID=%s", err, customerID)
+		if errors.Is(err, domain.ErrInvalidArgument) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		log.Errorf("Failed to list orders: %v, customerID=%s", err, customerID)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list orders"})
 		return
 	}
 
+	// Count the customer's orders only when asked, as it costs a second query
+	var totalSize *int64
+	if includeTotal {
+		total, err := h.service.CountOrders(c.Request.Context(), customerID)
+		if err != nil {
+			log.Errorf("Failed to count orders: %v, customerID=%s", err, customerID)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list orders"})
+			return
+		}
+		totalSize = &total
+	}
+
 	response := struct {
-		Orders        []*domain.Order `json:"orders"`
+		Orders        []orderResponse `json:"orders"`
 		NextPageToken string          `json:"next_page_token,omitempty"`
+		TotalSize     *int64          `json:"total_size,omitempty"`
 	}{
-		Orders:        orders,
+		Orders:        newOrderResponses(orders),
 		NextPageToken: nextPageToken,
+		TotalSize:     totalSize,
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -180,7 +405,7 @@ func NewUpdateOrderStatusHandler(log *zap.SugaredLogger, service service.OrderSe
 
 // Pattern returns the URL pattern for this handler
 func (h *UpdateOrderStatusHandler) Pattern() string {
-	return "/orders/"
+	return "/orders/:id"
 }
 
 // Register registers the handler with the router group
@@ -188,8 +413,11 @@ func (h *UpdateOrderStatusHandler) Register(rg *gin.RouterGroup) {
 	rg.PATCH("/orders/:id", h.UpdateOrderStatus)
 }
 
-// UpdateOrderStatus handles HTTP requests to update order status
+// UpdateOrderStatus handles HTTP requests to update order status. Shipping an order
+// may carry its tracking_number and carrier; other statuses take neither.
 func (h *UpdateOrderStatusHandler) UpdateOrderStatus(c *gin.Context) {
+	log := requestid.SugaredLogger(c.Request.Context(), h.log)
+
 	orderID := c.Param("id")
 	if orderID == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Order ID is required"})
@@ -197,21 +425,156 @@ func (h *UpdateOrderStatusHandler) UpdateOrderStatus(c *gin.Context) {
 	}
 
 	var request struct {
-		Status domain.OrderStatus `json:"status"`
+		Status         domain.OrderStatus `json:"status"`
+		TrackingNumber string             `json:"tracking_number"`
+		Carrier        string             `json:"carrier"`
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
-		h.log.Errorf("Failed to decode request: %v", err)
+		log.Errorf("Failed to decode request: %v", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
 		return
 	}
 
-	order, err := h.service.UpdateOrderStatus(c.Request.Context(), orderID, request.Status)
+	shipment := domain.Shipment{TrackingNumber: request.TrackingNumber, Carrier: request.Carrier}
+	if request.Status != domain.OrderStatusShipped && !shipment.IsZero() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "tracking_number and carrier can only be set when shipping an order"})
+		return
+	}
+
+	var order *domain.Order
+	var err error
+	if request.Status == domain.OrderStatusShipped {
+		order, err = h.service.ShipOrder(c.Request.Context(), orderID, shipment)
+	} else {
+		order, err = h.service.UpdateOrderStatus(c.Request.Context(), orderID, request.Status)
+	}
 	if err != nil {
-		h.log.Errorf("Failed to update order status: %v, orderID=%s", err, orderID)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update order status"})
+		log.Errorf("Failed to update order status: %v, orderID=%s", err, orderID)
+		switch {
+		case errors.Is(err, domain.ErrOrderNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		case errors.Is(err, domain.ErrInvalidStatusTransition):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, domain.ErrInvalidArgument):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update order status"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, newOrderResponse(order))
+}
+
+// CancelOrderHandler handles requests to cancel an order
+type CancelOrderHandler struct {
+	log     *zap.SugaredLogger
+	service service.OrderService
+}
+
+// NewCancelOrderHandler creates a new CancelOrderHandler
+func NewCancelOrderHandler(log *zap.SugaredLogger, service service.OrderService) *CancelOrderHandler {
+	return &CancelOrderHandler{
+		log:     log,
+		service: service,
+	}
+}
+
+// Pattern returns the URL pattern for this handler
+func (h *CancelOrderHandler) Pattern() string {
+	return "/orders/:id/cancel"
+}
+
+// Register registers the handler with the router group
+func (h *CancelOrderHandler) Register(rg *gin.RouterGroup) {
+	rg.POST("/orders/:id/cancel", h.CancelOrder)
+}
+
+// CancelOrder handles HTTP requests to cancel an order and return its stock
+func (h *CancelOrderHandler) CancelOrder(c *gin.Context) {
+	log := requestid.SugaredLogger(c.Request.Context(), h.log)
+
+	orderID := c.Param("id")
+	if orderID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Order ID is required"})
+		return
+	}
+
+	order, err := h.service.CancelOrder(c.Request.Context(), orderID)
+	if err != nil {
+		log.Errorf("Failed to cancel order: %v, orderID=%s", err, orderID)
+		switch {
+		case errors.Is(err, domain.ErrOrderNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		case errors.Is(err, domain.ErrInvalidStatusTransition):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, domain.ErrInvalidArgument):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel order"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, newOrderResponse(order))
+}
+
+// ReorderHandler handles requests to place an existing order again
+type ReorderHandler struct {
+	log     *zap.SugaredLogger
+	service service.OrderService
+}
+
+// NewReorderHandler creates a new ReorderHandler
+func NewReorderHandler(log *zap.SugaredLogger, service service.OrderService) *ReorderHandler {
+	return &ReorderHandler{
+		log:     log,
+		service: service,
+	}
+}
+
+// Pattern returns the URL pattern for this handler
+func (h *ReorderHandler) Pattern() string {
+	return "/orders/:id/reorder"
+}
+
+// Register registers the handler with the router group
+func (h *ReorderHandler) Register(rg *gin.RouterGroup) {
+	rg.POST("/orders/:id/reorder", h.Reorder)
+}
+
+// Reorder handles HTTP requests to create a new order with the items of an existing one.
+// The price_source query parameter selects the prices used: live, the default, takes the
+// current ones; snapshot keeps those the items were bought at.
+func (h *ReorderHandler) Reorder(c *gin.Context) {
+	log := requestid.SugaredLogger(c.Request.Context(), h.log)
+
+	orderID := c.Param("id")
+	if orderID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Order ID is required"})
+		return
+	}
+
+	source := domain.PriceSource(c.DefaultQuery("price_source", string(domain.PriceSourceLive)))
+	ctx := service.WithIdempotencyKey(c.Request.Context(), c.GetHeader(service.IdempotencyHeader))
+	order, err := h.service.Reorder(ctx, orderID, source)
+	if err != nil {
+		log.Errorf("Failed to reorder: %v, orderID=%s", err, orderID)
+		switch {
+		case errors.Is(err, domain.ErrOrderNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		case errors.Is(err, domain.ErrInvalidArgument):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, domain.ErrInsufficientStock):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrUnsupported):
+			c.JSON(http.StatusNotImplemented, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reorder"})
+		}
 		return
 	}
 
-	c.JSON(http.StatusOK, order)
+	c.JSON(http.StatusCreated, newOrderResponse(order))
 }
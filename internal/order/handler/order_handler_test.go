@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go-bootiful-ordering/internal/order/domain"
+	"go-bootiful-ordering/internal/order/service"
+	"go.uber.org/zap"
+)
+
+// priceSourceRecordingService records the price sources and idempotency keys of the
+// previews and reorders reaching it and fails them with err; the methods it does not
+// implement are not expected to be called
+type priceSourceRecordingService struct {
+	service.OrderService
+
+	err     error
+	sources []domain.PriceSource
+	keys    []string
+}
+
+func (s *priceSourceRecordingService) PreviewOrder(ctx context.Context, customerID string, items []domain.OrderItem, source domain.PriceSource) (*domain.Order, error) {
+	s.sources = append(s.sources, source)
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &domain.Order{CustomerID: customerID, Items: items, Status: domain.OrderStatusPending}, nil
+}
+
+func (s *priceSourceRecordingService) Reorder(ctx context.Context, orderID string, source domain.PriceSource) (*domain.Order, error) {
+	s.sources = append(s.sources, source)
+	s.keys = append(s.keys, service.IdempotencyKeyFromContext(ctx))
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &domain.Order{ID: "order-2", CustomerID: "customer-1", Status: domain.OrderStatusPending}, nil
+}
+
+func TestPriceSourceParameter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	preview := `{"customer_id":"customer-1","items":[{"product_id":"product-1","quantity":1,"price":1000}]}`
+
+	tests := []struct {
+		name       string
+		path       string
+		err        error
+		wantStatus int
+		wantSource domain.PriceSource
+	}{
+		// Previews price what was sent unless asked otherwise, reorders what is current
+		{name: "preview by default", path: "/orders/preview", wantStatus: http.StatusOK, wantSource: domain.PriceSourceSnapshot},
+		{name: "live preview", path: "/orders/preview?price_source=live", wantStatus: http.StatusOK, wantSource: domain.PriceSourceLive},
+		{name: "reorder by default", path: "/orders/order-1/reorder", wantStatus: http.StatusCreated, wantSource: domain.PriceSourceLive},
+		{name: "snapshot reorder", path: "/orders/order-1/reorder?price_source=snapshot", wantStatus: http.StatusCreated, wantSource: domain.PriceSourceSnapshot},
+		{
+			name: "unknown source", path: "/orders/order-1/reorder?price_source=cached", wantSource: "cached",
+			err: fmt.Errorf("%w: unknown price source", domain.ErrInvalidArgument), wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "live preview without a catalog", path: "/orders/preview?price_source=live", wantSource: domain.PriceSourceLive,
+			err: fmt.Errorf("%w: live prices", service.ErrUnsupported), wantStatus: http.StatusNotImplemented,
+		},
+		{
+			name: "live reorder without a catalog", path: "/orders/order-1/reorder", wantSource: domain.PriceSourceLive,
+			err: fmt.Errorf("%w: live prices", service.ErrUnsupported), wantStatus: http.StatusNotImplemented,
+		},
+		{name: "reorder of an unknown order", path: "/orders/order-1/reorder", wantSource: domain.PriceSourceLive, err: domain.ErrOrderNotFound, wantStatus: http.StatusNotFound},
+		{name: "reorder out of stock", path: "/orders/order-1/reorder", wantSource: domain.PriceSourceLive, err: domain.ErrInsufficientStock, wantStatus: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &priceSourceRecordingService{err: tt.err}
+			router := gin.New()
+			NewPreviewOrderHandler(zap.NewNop().Sugar(), svc).Register(&router.RouterGroup)
+			NewReorderHandler(zap.NewNop().Sugar(), svc).Register(&router.RouterGroup)
+
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(preview))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set(service.IdempotencyHeader, "key-1")
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if len(svc.sources) != 1 || svc.sources[0] != tt.wantSource {
+				t.Errorf("service received price sources %v, want [%s]", svc.sources, tt.wantSource)
+			}
+			// A retried reorder must not place the order twice
+			if strings.Contains(tt.path, "/reorder") && (len(svc.keys) != 1 || svc.keys[0] != "key-1") {
+				t.Errorf("Reorder() idempotency keys = %v, want [key-1]", svc.keys)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"go-bootiful-ordering/internal/order/domain"
+	"go-bootiful-ordering/internal/pkg/timefmt"
+	"net/url"
+)
+
+// orderResponse is the JSON representation of an order. Timestamps are formatted with
+// the configured layout, the same one the gRPC handler uses, instead of time.Time's RFC3339Nano.
+// ItemsURL points to the paginated items of an order whose items were truncated.
+type orderResponse struct {
+	*domain.Order
+	ItemsURL  string `json:"items_url,omitempty"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// newOrderResponse converts a domain order into its JSON representation
+func newOrderResponse(order *domain.Order) orderResponse {
+	response := orderResponse{
+		Order:     order,
+		CreatedAt: timefmt.Format(order.CreatedAt),
+		UpdatedAt: timefmt.Format(order.UpdatedAt),
+	}
+	if order.ItemsTruncated {
+		response.ItemsURL = "/orders/" + url.PathEscape(order.ID) + "/items"
+	}
+	return response
+}
+
+// newOrderResponses converts a page of domain orders into their JSON representation
+func newOrderResponses(orders []*domain.Order) []orderResponse {
+	responses := make([]orderResponse, len(orders))
+	for i, order := range orders {
+		responses[i] = newOrderResponse(order)
+	}
+	return responses
+}
+
+// periodCountResponse is the JSON representation of a time series bucket
+type periodCountResponse struct {
+	Date  string `json:"date"`
+	Count int64  `json:"count"`
+}
+
+// newPeriodCountResponses converts time series buckets into their JSON representation
+func newPeriodCountResponses(counts []domain.PeriodCount) []periodCountResponse {
+	responses := make([]periodCountResponse, len(counts))
+	for i, count := range counts {
+		responses[i] = periodCountResponse{Date: timefmt.Format(count.Date), Count: count.Count}
+	}
+	return responses
+}
+
+// shippingEstimateResponse is the JSON representation of a shipping estimate
+type shippingEstimateResponse struct {
+	domain.ShippingEstimate
+	EstimatedDelivery string `json:"estimated_delivery"`
+}
+
+// newShippingEstimateResponse converts a shipping estimate into its JSON representation
+func newShippingEstimateResponse(estimate domain.ShippingEstimate) shippingEstimateResponse {
+	return shippingEstimateResponse{
+		ShippingEstimate:  estimate,
+		EstimatedDelivery: timefmt.Format(estimate.EstimatedDelivery),
+	}
+}
@@ -0,0 +1,106 @@
+package protoconv
+
+import (
+	"go-bootiful-ordering/gen/order/v1"
+	"go-bootiful-ordering/internal/order/domain"
+	"go-bootiful-ordering/internal/pkg/timefmt"
+)
+
+// StatusToProto converts a domain order status to a protobuf order status
+func StatusToProto(status domain.OrderStatus) orderv1.OrderStatus {
+	switch status {
+	case domain.OrderStatusPending:
+		return orderv1.OrderStatus_ORDER_STATUS_PENDING
+	case domain.OrderStatusProcessing:
+		return orderv1.OrderStatus_ORDER_STATUS_PROCESSING
+	case domain.OrderStatusShipped:
+		return orderv1.OrderStatus_ORDER_STATUS_SHIPPED
+	case domain.OrderStatusDelivered:
+		return orderv1.OrderStatus_ORDER_STATUS_DELIVERED
+	case domain.OrderStatusCancelled:
+		return orderv1.OrderStatus_ORDER_STATUS_CANCELLED
+	default:
+		return orderv1.OrderStatus_ORDER_STATUS_UNSPECIFIED
+	}
+}
+
+// StatusFromProto converts a protobuf order status to a domain order status.
+// Unknown statuses convert to domain.OrderStatusUnspecified.
+func StatusFromProto(status orderv1.OrderStatus) domain.OrderStatus {
+	switch status {
+	case orderv1.OrderStatus_ORDER_STATUS_PENDING:
+		return domain.OrderStatusPending
+	case orderv1.OrderStatus_ORDER_STATUS_PROCESSING:
+		return domain.OrderStatusProcessing
+	case orderv1.OrderStatus_ORDER_STATUS_SHIPPED:
+		return domain.OrderStatusShipped
+	case orderv1.OrderStatus_ORDER_STATUS_DELIVERED:
+		return domain.OrderStatusDelivered
+	case orderv1.OrderStatus_ORDER_STATUS_CANCELLED:
+		return domain.OrderStatusCancelled
+	default:
+		return domain.OrderStatusUnspecified
+	}
+}
+
+// ItemsToProto converts domain order items to protobuf order items
+func ItemsToProto(items []domain.OrderItem) []*orderv1.OrderItem {
+	protoItems := make([]*orderv1.OrderItem, len(items))
+	for i, item := range items {
+		protoItems[i] = &orderv1.OrderItem{
+			ProductId: item.ProductID,
+			Quantity:  item.Quantity,
+			Price:     item.Price,
+		}
+	}
+	return protoItems
+}
+
+// ItemsFromProto converts protobuf order items to domain order items
+func ItemsFromProto(protoItems []*orderv1.OrderItem) []domain.OrderItem {
+	items := make([]domain.OrderItem, len(protoItems))
+	for i, item := range protoItems {
+		items[i] = domain.OrderItem{
+			ProductID: item.ProductId,
+			Quantity:  item.Quantity,
+			Price:     item.Price,
+		}
+	}
+	return items
+}
+
+// OrderToProto converts a domain order to a protobuf order
+func OrderToProto(order *domain.Order) *orderv1.Order {
+	return &orderv1.Order{
+		Id:             order.ID,
+		CustomerId:     order.CustomerID,
+		Items:          ItemsToProto(order.Items),
+		ItemsTruncated: order.ItemsTruncated,
+		Status:         StatusToProto(order.Status),
+		TotalAmount:    order.TotalAmount,
+		TrackingNumber: order.TrackingNumber,
+		Carrier:        order.Carrier,
+		CreatedAt:      timefmt.Format(order.CreatedAt),
+		UpdatedAt:      timefmt.Format(order.UpdatedAt),
+	}
+}
+
+// OrderFromProto converts a protobuf order to a domain order
+func OrderFromProto(order *orderv1.Order) *domain.Order {
+	// Timestamps are sent in the configured layout; unparsable values are left zero
+	createdAt, _ := timefmt.Parse(order.CreatedAt)
+	updatedAt, _ := timefmt.Parse(order.UpdatedAt)
+
+	return &domain.Order{
+		ID:             order.Id,
+		CustomerID:     order.CustomerId,
+		Items:          ItemsFromProto(order.Items),
+		ItemsTruncated: order.ItemsTruncated,
+		Status:         StatusFromProto(order.Status),
+		TotalAmount:    order.TotalAmount,
+		TrackingNumber: order.TrackingNumber,
+		Carrier:        order.Carrier,
+		CreatedAt:      createdAt,
+		UpdatedAt:      updatedAt,
+	}
+}
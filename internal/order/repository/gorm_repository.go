@@ -3,22 +3,105 @@ package repository
 import (
 	"context"
 	"errors"
-	"github.com/google/uuid"
+	"fmt"
 	"go-bootiful-ordering/internal/order/domain"
+	"go-bootiful-ordering/internal/pkg/idgen"
+	"go-bootiful-ordering/internal/pkg/paging"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strconv"
 	"time"
 )
 
+// orderListColumn and orderListDirection are the column and direction orders are listed by
+const (
+	orderListColumn    = "id"
+	orderListDirection = "ASC"
+)
+
 // GormOrderRepository implements OrderRepository using GORM
 type GormOrderRepository struct {
-	db *gorm.DB
+	db          *gorm.DB
+	ids         idgen.IDGenerator
+	itemStorage ItemStorage
 }
 
-// NewGormOrderRepository creates a new GormOrderRepository
-func NewGormOrderRepository(db *gorm.DB) *GormOrderRepository {
+// NewGormOrderRepository creates a new GormOrderRepository storing order items in itemStorage
+func NewGormOrderRepository(db *gorm.DB, ids idgen.IDGenerator, itemStorage ItemStorage) *GormOrderRepository {
 	return &GormOrderRepository{
-		db: db,
+		db:          db,
+		ids:         ids,
+		itemStorage: itemStorage,
+	}
+}
+
+// loadItems loads the order_items rows of the orders whose items column is NULL, which
+// are the orders stored in the table, in either mode. Orders stored as jsonb carry their
+// items in the row and need no second query. With a positive maxItems at most maxItems+1
+// rows are read per order, so a huge order cannot exhaust memory and truncateItems can
+// tell whether its list was cut.
+func (r *GormOrderRepository) loadItems(db *gorm.DB, maxItems int, orderModels ...*OrderModel) error {
+	var orderIDs []string
+	for _, model := range orderModels {
+		if model.ItemsJSON == nil {
+			orderIDs = append(orderIDs, model.ID)
+		}
+	}
+	if len(orderIDs) == 0 {
+		return nil
+	}
+
+	query := db.Where("order_id IN ?", orderIDs)
+	if maxItems > 0 {
+		// Rank the items of every order by ID, so the bound applies per order rather than to the page
+		ranked := db.Model(&OrderItemModel{}).
+			Select("*, ROW_NUMBER() OVER (PARTITION BY order_id ORDER BY id) AS item_rank").
+			Where("order_id IN ?", orderIDs)
+		query = db.Table("(?) AS ranked_items", ranked).Where("item_rank <= ?", maxItems+1)
+	}
+
+	var itemModels []OrderItemModel
+	if err := query.Order("id").Find(&itemModels).Error; err != nil {
+		return err
+	}
+
+	byOrder := make(map[string][]OrderItemModel, len(orderIDs))
+	for _, item := range itemModels {
+		byOrder[item.OrderID] = append(byOrder[item.OrderID], item)
+	}
+	for _, model := range orderModels {
+		if model.ItemsJSON == nil {
+			model.Items = byOrder[model.ID]
+		}
+	}
+	return nil
+}
+
+// truncateItems cuts an order to its first maxItems items and reports whether it had more.
+// The items column is read whole, so jsonb orders are cut here rather than in the query.
+func truncateItems(model *OrderModel, maxItems int) bool {
+	if maxItems <= 0 {
+		return false
 	}
+	var truncated bool
+	if len(model.ItemsJSON) > maxItems {
+		model.ItemsJSON = model.ItemsJSON[:maxItems]
+		truncated = true
+	}
+	if len(model.Items) > maxItems {
+		model.Items = model.Items[:maxItems]
+		truncated = true
+	}
+	return truncated
+}
+
+// toBoundedDomain converts an order read with loadItems to a domain.Order with at most
+// maxItems items, setting ItemsTruncated when it has more
+func toBoundedDomain(model *OrderModel, maxItems int) *domain.Order {
+	truncated := truncateItems(model, maxItems)
+	order := model.ToOrderDomain()
+	order.ItemsTruncated = truncated
+	return order
 }
 
 // BeginTransaction starts a new transaction
@@ -27,38 +110,43 @@ func (r *GormOrderRepository) BeginTransaction(ctx context.Context) (*gorm.DB, e
 	return tx, tx.Error
 }
 
-// prepareOrder prepares an order for creation
-func prepareOrder(order *domain.Order) {
-	// Generate a new UUID if not provided
+// prepareOrder prepares an order for creation, rejecting a total that overflows
+func (r *GormOrderRepository) prepareOrder(order *domain.Order) error {
+	// Generate a new ID if not provided
 	if order.ID == "" {
-		order.ID = uuid.New().String()
+		order.ID = r.ids.NewID()
 	}
 
-	// Set timestamps
-	now := time.Now()
+	// Set timestamps; the columns have no time zone, so they hold UTC
+	now := time.Now().UTC()
 	order.CreatedAt = now
 	order.UpdatedAt = now
 
 	// Calculate total amount if not set
 	if order.TotalAmount == 0 {
-		for _, item := range order.Items {
-			order.TotalAmount += item.Price * int64(item.Quantity)
+		total, err := order.CalculateTotal()
+		if err != nil {
+			return err
 		}
+		order.TotalAmount = total
 	}
 
 	// Set default status if not set
 	if order.Status == domain.OrderStatusUnspecified {
 		order.Status = domain.OrderStatusPending
 	}
+	return nil
 }
 
 // CreateOrderWithTx persists a new order within an existing transaction and returns the created order
 func (r *GormOrderRepository) CreateOrderWithTx(ctx context.Context, tx *gorm.DB, order *domain.Order) (*domain.Order, error) {
 	// Prepare the order
-	prepareOrder(order)
+	if err := r.prepareOrder(order); err != nil {
+		return nil, err
+	}
 
 	// Convert domain model to database model
-	orderModel := FromOrderDomain(order)
+	orderModel := FromOrderDomain(order, r.itemStorage)
 
 	// Create order
 	if err := tx.Create(orderModel).Error; err != nil {
@@ -69,56 +157,142 @@ func (r *GormOrderRepository) CreateOrderWithTx(ctx context.Context, tx *gorm.DB
 	return orderModel.ToOrderDomain(), nil
 }
 
-// CreateOrder persists a new order and returns the created order
-func (r *GormOrderRepository) CreateOrder(ctx context.Context, order *domain.Order) (*domain.Order, error) {
-	// Begin transaction
-	tx, err := r.BeginTransaction(ctx)
-	if err != nil {
+// GetOrder retrieves an order by ID with at most maxItems of its items
+func (r *GormOrderRepository) GetOrder(ctx context.Context, orderID string, maxItems int) (*domain.Order, error) {
+	var orderModel OrderModel
+
+	// Query order with items
+	if err := r.db.WithContext(ctx).First(&orderModel, "id = ?", orderID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrOrderNotFound
+		}
 		return nil, err
 	}
-
-	// Create order within transaction
-	createdOrder, err := r.CreateOrderWithTx(ctx, tx, order)
-	if err != nil {
-		tx.Rollback()
+	if err := r.loadItems(r.db.WithContext(ctx), maxItems, &orderModel); err != nil {
 		return nil, err
 	}
 
-	// Commit transaction
-	if err := tx.Commit().Error; err != nil {
-		return nil, err
+	// Convert to domain model
+	return toBoundedDomain(&orderModel, maxItems), nil
+}
+
+// ListOrderItems retrieves the items of an order ordered by item ID; the page token is
+// the ID of the last item returned. Items stored as jsonb are listed in stored order
+// and their page token is the offset of the next item.
+func (r *GormOrderRepository) ListOrderItems(ctx context.Context, orderID string, pageSize int32, pageToken string) ([]domain.OrderItem, string, error) {
+	// A past-the-end page is empty too, so look the order up to report a missing one. Its
+	// items column is read in either mode, so orders written by the other mode list too.
+	var orderModel OrderModel
+	if err := r.db.WithContext(ctx).Select("id", "items").First(&orderModel, "id = ?", orderID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", domain.ErrOrderNotFound
+		}
+		return nil, "", err
+	}
+	if orderModel.ItemsJSON != nil {
+		return listJSONItems(orderModel.ItemsJSON, pageSize, pageToken)
 	}
 
-	// Return the created order
-	return createdOrder, nil
+	query := r.db.WithContext(ctx).Where("order_id = ?", orderID)
+	if pageToken != "" {
+		afterID, err := strconv.ParseUint(pageToken, 10, 64)
+		if err != nil {
+			return nil, "", fmt.Errorf("%w: invalid page_token %q", domain.ErrInvalidArgument, pageToken)
+		}
+		query = query.Where("id > ?", afterID)
+	}
+	if pageSize > 0 {
+		query = query.Limit(int(pageSize + 1)) // Fetch one extra to determine if there are more results
+	}
+
+	var itemModels []OrderItemModel
+	if err := query.Order("id").Find(&itemModels).Error; err != nil {
+		return nil, "", err
+	}
+
+	var nextPageToken string
+	if pageSize > 0 && len(itemModels) > int(pageSize) {
+		itemModels = itemModels[:pageSize]
+		nextPageToken = strconv.FormatUint(uint64(itemModels[len(itemModels)-1].ID), 10)
+	}
+
+	items := make([]domain.OrderItem, len(itemModels))
+	for i, item := range itemModels {
+		items[i] = domain.OrderItem{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+			Price:     item.Price,
+		}
+	}
+
+	return items, nextPageToken, nil
+}
+
+// listJSONItems returns a page of the items stored in an order's items column
+func listJSONItems(stored itemsJSON, pageSize int32, pageToken string) ([]domain.OrderItem, string, error) {
+	offset := 0
+	if pageToken != "" {
+		var err error
+		offset, err = strconv.Atoi(pageToken)
+		if err != nil || offset < 0 {
+			return nil, "", fmt.Errorf("%w: invalid page_token %q", domain.ErrInvalidArgument, pageToken)
+		}
+	}
+	if offset > len(stored) {
+		offset = len(stored)
+	}
+
+	end := len(stored)
+	var nextPageToken string
+	if pageSize > 0 && end-offset > int(pageSize) {
+		end = offset + int(pageSize)
+		nextPageToken = strconv.Itoa(end)
+	}
+
+	items := make([]domain.OrderItem, end-offset)
+	for i, item := range stored[offset:end] {
+		items[i] = domain.OrderItem(item)
+	}
+
+	return items, nextPageToken, nil
 }
 
-// GetOrder retrieves an order by ID
-func (r *GormOrderRepository) GetOrder(ctx context.Context, orderID string) (*domain.Order, error) {
+// GetOrderForUpdateWithTx retrieves an order by ID with at most maxItems of its items
+// within an existing transaction, locking its row until the transaction ends
+func (r *GormOrderRepository) GetOrderForUpdateWithTx(ctx context.Context, tx *gorm.DB, orderID string, maxItems int) (*domain.Order, error) {
 	var orderModel OrderModel
 
-	// Query order with items
-	if err := r.db.WithContext(ctx).Preload("Items").First(&orderModel, "id = ?", orderID).Error; err != nil {
+	// Lock the order row; its items are only read
+	query := tx.Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: clause.CurrentTable}})
+	if err := query.First(&orderModel, "id = ?", orderID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("order not found")
+			return nil, domain.ErrOrderNotFound
 		}
 		return nil, err
 	}
+	if err := r.loadItems(tx, maxItems, &orderModel); err != nil {
+		return nil, err
+	}
 
-	// Convert to domain model
-	return orderModel.ToOrderDomain(), nil
+	return toBoundedDomain(&orderModel, maxItems), nil
 }
 
-// ListOrders retrieves a list of orders for a customer with pagination
-func (r *GormOrderRepository) ListOrders(ctx context.Context, customerID string, pageSize int32, pageToken string) ([]*domain.Order, string, error) {
-	var orderModels []OrderModel
+// ListOrders retrieves a list of orders for a customer with pagination, each with at most
+// maxItems of its items. Orders are listed by ID; the page token is an opaque cursor
+// holding the ID of the last order of the page.
+func (r *GormOrderRepository) ListOrders(ctx context.Context, customerID string, pageSize int32, pageToken string, maxItems int) ([]*domain.Order, string, error) {
+	var orderModels []*OrderModel
 
 	// Build query
-	query := r.db.WithContext(ctx).Preload("Items").Where("customer_id = ?", customerID)
+	query := r.db.WithContext(ctx).Where("customer_id = ?", customerID)
 
 	// Apply pagination
 	if pageToken != "" {
-		query = query.Where("id > ?", pageToken)
+		cursor, err := paging.DecodeCursor(pageToken, orderListColumn, orderListDirection)
+		if err != nil {
+			return nil, "", fmt.Errorf("%w: %v", domain.ErrInvalidArgument, err)
+		}
+		query = query.Where(paging.KeysetPredicate(orderListColumn, orderListDirection), cursor.ID)
 	}
 
 	// Apply limit
@@ -127,74 +301,118 @@ func (r *GormOrderRepository) ListOrders(ctx context.Context, customerID string,
 	}
 
 	// Execute query
-	if err := query.Order("id").Find(&orderModels).Error; err != nil {
+	if err := query.Order(orderListColumn + " " + orderListDirection).Find(&orderModels).Error; err != nil {
 		return nil, "", err
 	}
 
-	// Determine if there are more results
+	// Determine if there are more results; the next page starts after the last returned order
 	var nextPageToken string
-	if len(orderModels) > int(pageSize) {
-		nextPageToken = orderModels[len(orderModels)-1].ID
-		orderModels = orderModels[:len(orderModels)-1]
+	if pageSize > 0 && len(orderModels) > int(pageSize) {
+		orderModels = orderModels[:pageSize]
+		nextPageToken = paging.EncodeCursor(paging.Cursor{Column: orderListColumn, Direction: orderListDirection, ID: orderModels[len(orderModels)-1].ID})
+	}
+	if err := r.loadItems(r.db.WithContext(ctx), maxItems, orderModels...); err != nil {
+		return nil, "", err
 	}
 
 	// Convert to domain models
 	orders := make([]*domain.Order, len(orderModels))
 	for i, model := range orderModels {
-		orders[i] = model.ToOrderDomain()
+		orders[i] = toBoundedDomain(model, maxItems)
 	}
 
 	return orders, nextPageToken, nil
 }
 
-// UpdateOrderStatusWithTx updates the status of an order within an existing transaction
-func (r *GormOrderRepository) UpdateOrderStatusWithTx(ctx context.Context, tx *gorm.DB, orderID string, status domain.OrderStatus) (*domain.Order, error) {
-	// Update order status
-	if err := tx.Model(&OrderModel{}).Where("id = ?", orderID).Updates(map[string]interface{}{
-		"status":     int(status),
-		"updated_at": time.Now(),
-	}).Error; err != nil {
-		return nil, err
+// CountOrders counts a customer's orders
+func (r *GormOrderRepository) CountOrders(ctx context.Context, customerID string) (int64, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&OrderModel{}).Where("customer_id = ?", customerID).Count(&count).Error; err != nil {
+		return 0, err
 	}
+	return count, nil
+}
 
-	// Check if order exists
-	var count int64
-	if err := tx.Model(&OrderModel{}).Where("id = ?", orderID).Count(&count).Error; err != nil {
-		return nil, err
+// CountOrdersByPeriod counts orders created in [from, to) grouped by time bucket. Buckets
+// are UTC days, weeks starting on Monday and months, as created_at holds UTC; the bounds
+// are converted to UTC, since a timestamp without time zone would ignore their offset.
+func (r *GormOrderRepository) CountOrdersByPeriod(ctx context.Context, customerID string, bucket domain.TimeBucket, from, to time.Time) ([]domain.PeriodCount, error) {
+	var rows []struct {
+		Bucket time.Time
+		Count  int64
 	}
 
-	if count == 0 {
-		return nil, errors.New("order not found")
+	// Build query
+	query := r.db.WithContext(ctx).Model(&OrderModel{}).
+		Select("date_trunc(?, created_at) AS bucket, COUNT(*) AS count", string(bucket)).
+		Where("created_at >= ? AND created_at < ?", from.UTC(), to.UTC())
+
+	// Filter by customer if provided
+	if customerID != "" {
+		query = query.Where("customer_id = ?", customerID)
 	}
 
-	// Get order with items
-	var orderModel OrderModel
-	if err := tx.Preload("Items").First(&orderModel, "id = ?", orderID).Error; err != nil {
+	// Execute query
+	if err := query.Group("bucket").Order("bucket").Scan(&rows).Error; err != nil {
 		return nil, err
 	}
 
-	return orderModel.ToOrderDomain(), nil
+	// Convert to domain models
+	counts := make([]domain.PeriodCount, len(rows))
+	for i, row := range rows {
+		counts[i] = domain.PeriodCount{
+			Date:  row.Bucket,
+			Count: row.Count,
+		}
+	}
+
+	return counts, nil
 }
 
-// UpdateOrderStatus updates the status of an order
-func (r *GormOrderRepository) UpdateOrderStatus(ctx context.Context, orderID string, status domain.OrderStatus) (*domain.Order, error) {
-	// Begin transaction
-	tx, err := r.BeginTransaction(ctx)
-	if err != nil {
+// UpdateOrderStatusWithTx updates the status of an order within an existing transaction
+func (r *GormOrderRepository) UpdateOrderStatusWithTx(ctx context.Context, tx *gorm.DB, orderID string, status domain.OrderStatus, maxItems int) (*domain.Order, error) {
+	return r.updateOrderWithTx(tx, orderID, map[string]interface{}{
+		"status": int(status),
+	}, maxItems)
+}
+
+// ShipOrderWithTx marks an order as shipped and stores its tracking information within an existing transaction
+func (r *GormOrderRepository) ShipOrderWithTx(ctx context.Context, tx *gorm.DB, orderID string, shipment domain.Shipment, maxItems int) (*domain.Order, error) {
+	return r.updateOrderWithTx(tx, orderID, map[string]interface{}{
+		"status":          int(domain.OrderStatusShipped),
+		"tracking_number": shipment.TrackingNumber,
+		"carrier":         shipment.Carrier,
+	}, maxItems)
+}
+
+// updateOrderWithTx applies updates to an order and returns it with at most maxItems of its items
+func (r *GormOrderRepository) updateOrderWithTx(tx *gorm.DB, orderID string, updates map[string]interface{}, maxItems int) (*domain.Order, error) {
+	// Update the order. The UPDATE holds the order's row lock until the
+	// transaction ends, so concurrent changes to one order commit their outbox
+	// entries one after another, in the order the changes were applied.
+	updates["updated_at"] = time.Now().UTC()
+	if err := tx.Model(&OrderModel{}).Where("id = ?", orderID).Updates(updates).Error; err != nil {
 		return nil, err
 	}
 
-	// Update order status within transaction
-	updatedOrder, err := r.UpdateOrderStatusWithTx(ctx, tx, orderID, status)
-	if err != nil {
-		tx.Rollback()
+	// Check if order exists
+	var count int64
+	if err := tx.Model(&OrderModel{}).Where("id = ?", orderID).Count(&count).Error; err != nil {
 		return nil, err
 	}
 
-	// Commit transaction
-	if err := tx.Commit().Error; err != nil {
+	if count == 0 {
+		return nil, domain.ErrOrderNotFound
+	}
+
+	// Get order with items
+	var orderModel OrderModel
+	if err := tx.First(&orderModel, "id = ?", orderID).Error; err != nil {
+		return nil, err
+	}
+	if err := r.loadItems(tx, maxItems, &orderModel); err != nil {
 		return nil, err
 	}
 
-	return updatedOrder, nil
+	return toBoundedDomain(&orderModel, maxItems), nil
 }
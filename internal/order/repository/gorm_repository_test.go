@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go-bootiful-ordering/internal/order/domain"
+	"go-bootiful-ordering/internal/pkg/idgen"
+	"gorm.io/gorm"
+)
+
+func TestToBoundedDomain(t *testing.T) {
+	tableItems := func(n int) []OrderItemModel {
+		items := make([]OrderItemModel, n)
+		for i := range items {
+			items[i] = OrderItemModel{ID: uint(i + 1), ProductID: "product", Quantity: 1, Price: 100}
+		}
+		return items
+	}
+	jsonItems := func(n int) itemsJSON {
+		items := make(itemsJSON, n)
+		for i := range items {
+			items[i] = itemJSON{ProductID: "product", Quantity: 1, Price: 100}
+		}
+		return items
+	}
+
+	tests := []struct {
+		name          string
+		model         OrderModel
+		maxItems      int
+		wantItems     int
+		wantTruncated bool
+	}{
+		{name: "table order within the cap", model: OrderModel{Items: tableItems(3)}, maxItems: 3, wantItems: 3},
+		{name: "table order past the cap", model: OrderModel{Items: tableItems(4)}, maxItems: 3, wantItems: 3, wantTruncated: true},
+		{name: "jsonb order within the cap", model: OrderModel{ItemsJSON: jsonItems(3)}, maxItems: 3, wantItems: 3},
+		{name: "jsonb order past the cap", model: OrderModel{ItemsJSON: jsonItems(10)}, maxItems: 3, wantItems: 3, wantTruncated: true},
+		{name: "no cap", model: OrderModel{Items: tableItems(10)}, maxItems: 0, wantItems: 10},
+		{name: "empty jsonb order", model: OrderModel{ItemsJSON: itemsJSON{}}, maxItems: 3, wantItems: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := toBoundedDomain(&tt.model, tt.maxItems)
+			if len(order.Items) != tt.wantItems || order.ItemsTruncated != tt.wantTruncated {
+				t.Errorf("toBoundedDomain() = %d items, truncated %v, want %d items, truncated %v",
+					len(order.Items), order.ItemsTruncated, tt.wantItems, tt.wantTruncated)
+			}
+		})
+	}
+}
+
+// recordedQuery is a statement built by GORM with its bind values
+type recordedQuery struct {
+	sql  string
+	vars []interface{}
+}
+
+// recordQueries records the SELECT statements built on db, which a dry-run connection
+// builds without sending
+func recordQueries(t *testing.T, db *gorm.DB) *[]recordedQuery {
+	t.Helper()
+	queries := &[]recordedQuery{}
+	record := func(db *gorm.DB) {
+		*queries = append(*queries, recordedQuery{sql: db.Statement.SQL.String(), vars: db.Statement.Vars})
+	}
+	if err := db.Callback().Query().After("gorm:query").Register("test:record", record); err != nil {
+		t.Fatalf("registering the query recorder: %v", err)
+	}
+	if err := db.Callback().Row().After("gorm:row").Register("test:record", record); err != nil {
+		t.Fatalf("registering the row recorder: %v", err)
+	}
+	return queries
+}
+
+func TestCountOrdersByPeriodQuery(t *testing.T) {
+	hanoi := time.FixedZone("UTC+7", 7*60*60)
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, hanoi)
+	to := time.Date(2024, 4, 1, 0, 0, 0, 0, hanoi)
+
+	tests := []struct {
+		name       string
+		customerID string
+		bucket     domain.TimeBucket
+		wantSQL    string
+		wantVars   []interface{}
+	}{
+		{
+			name:     "all customers by day",
+			bucket:   domain.TimeBucketDay,
+			wantSQL:  `SELECT date_trunc($1, created_at) AS bucket, COUNT(*) AS count FROM "orders" WHERE created_at >= $2 AND created_at < $3 GROUP BY "bucket" ORDER BY bucket`,
+			wantVars: []interface{}{"day", from.UTC(), to.UTC()},
+		},
+		{
+			name:       "one customer by week",
+			customerID: "customer-1",
+			bucket:     domain.TimeBucketWeek,
+			wantSQL:    `SELECT date_trunc($1, created_at) AS bucket, COUNT(*) AS count FROM "orders" WHERE (created_at >= $2 AND created_at < $3) AND customer_id = $4 GROUP BY "bucket" ORDER BY bucket`,
+			wantVars:   []interface{}{"week", from.UTC(), to.UTC(), "customer-1"},
+		},
+		{
+			name:     "by month",
+			bucket:   domain.TimeBucketMonth,
+			wantSQL:  `SELECT date_trunc($1, created_at) AS bucket, COUNT(*) AS count FROM "orders" WHERE created_at >= $2 AND created_at < $3 GROUP BY "bucket" ORDER BY bucket`,
+			wantVars: []interface{}{"month", from.UTC(), to.UTC()},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newDryRunDB(t)
+			queries := recordQueries(t, db)
+			repo := NewGormOrderRepository(db, idgen.NewSequenceGenerator("order-"), ItemStorageTable)
+
+			// A dry run builds the query but cannot scan rows from it
+			if _, err := repo.CountOrdersByPeriod(context.Background(), tt.customerID, tt.bucket, from, to); err != nil && !errors.Is(err, gorm.ErrDryRunModeUnsupported) {
+				t.Fatalf("CountOrdersByPeriod() error = %v", err)
+			}
+			if len(*queries) != 1 {
+				t.Fatalf("CountOrdersByPeriod() ran %d queries, want 1", len(*queries))
+			}
+			query := (*queries)[0]
+			if strings.Join(strings.Fields(query.sql), " ") != tt.wantSQL {
+				t.Errorf("query = %s\nwant    %s", query.sql, tt.wantSQL)
+			}
+			// The bounds are sent in UTC, the zone created_at is stored in
+			if !reflect.DeepEqual(query.vars, tt.wantVars) {
+				t.Errorf("bind values = %v, want %v", query.vars, tt.wantVars)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// IdempotencyModel records the order created for a customer's idempotency key
+type IdempotencyModel struct {
+	CustomerID     string `gorm:"primaryKey"`
+	IdempotencyKey string `gorm:"primaryKey"`
+	OrderID        string
+	CreatedAt      time.Time
+}
+
+// TableName specifies the table name for IdempotencyModel
+func (IdempotencyModel) TableName() string {
+	return "order_idempotency"
+}
+
+// IdempotencyRepository defines the interface for idempotency key persistence operations
+type IdempotencyRepository interface {
+	// FindOrderID returns the ID of the order created under a customer's key since the
+	// given time, or an empty string when there is none
+	FindOrderID(ctx context.Context, customerID, key string, since time.Time) (string, error)
+
+	// SaveKeyWithTx records the order created under a customer's key within an existing
+	// transaction, replacing an entry created before expiredBefore. It reports false when a
+	// live entry already exists; a concurrent transaction saving the same key blocks on the
+	// primary key until the other one commits or rolls back.
+	SaveKeyWithTx(ctx context.Context, tx *gorm.DB, customerID, key, orderID string, expiredBefore time.Time) (bool, error)
+}
+
+// GormIdempotencyRepository implements IdempotencyRepository using GORM
+type GormIdempotencyRepository struct {
+	db *gorm.DB
+}
+
+// NewGormIdempotencyRepository creates a new GormIdempotencyRepository
+func NewGormIdempotencyRepository(db *gorm.DB) *GormIdempotencyRepository {
+	return &GormIdempotencyRepository{
+		db: db,
+	}
+}
+
+// FindOrderID returns the ID of the order created under a customer's key since the given time
+func (r *GormIdempotencyRepository) FindOrderID(ctx context.Context, customerID, key string, since time.Time) (string, error) {
+	var entry IdempotencyModel
+	err := r.db.WithContext(ctx).
+		Where("customer_id = ? AND idempotency_key = ? AND created_at >= ?", customerID, key, since).
+		First(&entry).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return entry.OrderID, nil
+}
+
+// SaveKeyWithTx inserts the key, or takes over an expired entry, with a single upsert
+func (r *GormIdempotencyRepository) SaveKeyWithTx(ctx context.Context, tx *gorm.DB, customerID, key, orderID string, expiredBefore time.Time) (bool, error) {
+	entry := &IdempotencyModel{
+		CustomerID:     customerID,
+		IdempotencyKey: key,
+		OrderID:        orderID,
+		CreatedAt:      time.Now(),
+	}
+
+	result := tx.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "customer_id"}, {Name: "idempotency_key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"order_id", "created_at"}),
+		Where: clause.Where{Exprs: []clause.Expression{
+			clause.Expr{SQL: "order_idempotency.created_at < ?", Vars: []interface{}{expiredBefore}},
+		}},
+	}).Create(entry)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
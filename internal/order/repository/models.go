@@ -1,20 +1,95 @@
 package repository
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
 	"go-bootiful-ordering/internal/order/domain"
 	"gorm.io/gorm"
 	"time"
 )
 
-// OrderModel represents the database model for an order
+// ItemStorage is where the items of an order are stored
+type ItemStorage string
+
+const (
+	// ItemStorageTable stores each item as a row of order_items, read with a second query
+	ItemStorageTable ItemStorage = "table"
+	// ItemStorageJSONB stores the items in the orders.items jsonb column, so reading an
+	// order needs no second query
+	ItemStorageJSONB ItemStorage = "jsonb"
+)
+
+// Validate checks that the storage is a known one
+func (s ItemStorage) Validate() error {
+	switch s {
+	case ItemStorageTable, ItemStorageJSONB:
+		return nil
+	default:
+		return fmt.Errorf("unknown item storage %q, expected %q or %q", s, ItemStorageTable, ItemStorageJSONB)
+	}
+}
+
+// OrderModel represents the database model for an order. Depending on the item storage
+// its items are either order_items rows in Items or the items column in ItemsJSON, which
+// is NULL for orders stored in the table.
 type OrderModel struct {
-	ID          string `gorm:"primaryKey"`
-	CustomerID  string
-	Status      int
-	TotalAmount int64
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	Items       []OrderItemModel `gorm:"foreignKey:OrderID"`
+	ID             string `gorm:"primaryKey"`
+	CustomerID     string
+	Status         int
+	TotalAmount    int64
+	TrackingNumber string
+	Carrier        string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	Items          []OrderItemModel `gorm:"foreignKey:OrderID"`
+	ItemsJSON      itemsJSON        `gorm:"column:items;type:jsonb"`
+}
+
+// itemJSON is an order item as stored in the items column
+type itemJSON struct {
+	ProductID string `json:"product_id"`
+	Quantity  int32  `json:"quantity"`
+	Price     int64  `json:"price"`
+}
+
+// itemsJSON is the value of the items column. A nil slice is stored as NULL and an
+// empty one as [], so an order without items still reads back from the column.
+type itemsJSON []itemJSON
+
+// Value implements driver.Valuer
+func (j itemsJSON) Value() (driver.Value, error) {
+	if j == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal([]itemJSON(j))
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan implements sql.Scanner
+func (j *itemsJSON) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*j = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into order items", src)
+	}
+
+	items := itemsJSON{}
+	if err := json.Unmarshal(data, &items); err != nil {
+		return fmt.Errorf("malformed order items: %w", err)
+	}
+	*j = items
+	return nil
 }
 
 // OrderItemModel represents the database model for an order item
@@ -38,52 +113,73 @@ func (OrderItemModel) TableName() string {
 	return "order_items"
 }
 
-// ToOrderDomain converts an OrderModel to a domain.Order
+// ToOrderDomain converts an OrderModel to a domain.Order, taking the items from the items
+// column when it is set and from the order_items rows otherwise
 func (m *OrderModel) ToOrderDomain() *domain.Order {
-	items := make([]domain.OrderItem, len(m.Items))
-	for i, item := range m.Items {
-		items[i] = domain.OrderItem{
-			ProductID: item.ProductID,
-			Quantity:  item.Quantity,
-			Price:     item.Price,
+	var items []domain.OrderItem
+	if m.ItemsJSON != nil {
+		items = make([]domain.OrderItem, len(m.ItemsJSON))
+		for i, item := range m.ItemsJSON {
+			items[i] = domain.OrderItem(item)
+		}
+	} else {
+		items = make([]domain.OrderItem, len(m.Items))
+		for i, item := range m.Items {
+			items[i] = domain.OrderItem{
+				ProductID: item.ProductID,
+				Quantity:  item.Quantity,
+				Price:     item.Price,
+			}
 		}
 	}
 
 	return &domain.Order{
-		ID:          m.ID,
-		CustomerID:  m.CustomerID,
-		Items:       items,
-		Status:      domain.OrderStatus(m.Status),
-		TotalAmount: m.TotalAmount,
-		CreatedAt:   m.CreatedAt,
-		UpdatedAt:   m.UpdatedAt,
+		ID:             m.ID,
+		CustomerID:     m.CustomerID,
+		Items:          items,
+		Status:         domain.OrderStatus(m.Status),
+		TotalAmount:    m.TotalAmount,
+		TrackingNumber: m.TrackingNumber,
+		Carrier:        m.Carrier,
+		CreatedAt:      m.CreatedAt,
+		UpdatedAt:      m.UpdatedAt,
 	}
 }
 
-// FromOrderDomain creates an OrderModel from a domain.Order
-func FromOrderDomain(order *domain.Order) *OrderModel {
-	items := make([]OrderItemModel, len(order.Items))
+// FromOrderDomain creates an OrderModel from a domain.Order, with its items in the given storage
+func FromOrderDomain(order *domain.Order, storage ItemStorage) *OrderModel {
+	model := &OrderModel{
+		ID:             order.ID,
+		CustomerID:     order.CustomerID,
+		Status:         int(order.Status),
+		TotalAmount:    order.TotalAmount,
+		TrackingNumber: order.TrackingNumber,
+		Carrier:        order.Carrier,
+		CreatedAt:      order.CreatedAt,
+		UpdatedAt:      order.UpdatedAt,
+	}
+
+	if storage == ItemStorageJSONB {
+		model.ItemsJSON = make(itemsJSON, len(order.Items))
+		for i, item := range order.Items {
+			model.ItemsJSON[i] = itemJSON(item)
+		}
+		return model
+	}
+
+	model.Items = make([]OrderItemModel, len(order.Items))
 	for i, item := range order.Items {
-		items[i] = OrderItemModel{
+		model.Items[i] = OrderItemModel{
 			OrderID:   order.ID,
 			ProductID: item.ProductID,
 			Quantity:  item.Quantity,
 			Price:     item.Price,
 		}
 	}
-
-	return &OrderModel{
-		ID:          order.ID,
-		CustomerID:  order.CustomerID,
-		Status:      int(order.Status),
-		TotalAmount: order.TotalAmount,
-		Items:       items,
-		CreatedAt:   order.CreatedAt,
-		UpdatedAt:   order.UpdatedAt,
-	}
+	return model
 }
 
 // AutoMigrate creates or updates the database schema for order models
 func AutoMigrate(db *gorm.DB) error {
-	return db.AutoMigrate(&OrderModel{}, &OrderItemModel{}, &OutboxModel{})
+	return db.AutoMigrate(&OrderModel{}, &OrderItemModel{}, &OutboxModel{}, &IdempotencyModel{})
 }
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"go-bootiful-ordering/internal/order/domain"
+)
+
+func TestOrderModelRoundTrip(t *testing.T) {
+	createdAt := time.Date(2025, 10, 19, 12, 0, 0, 0, time.UTC)
+	order := func(items []domain.OrderItem) *domain.Order {
+		return &domain.Order{
+			ID:             "order-1",
+			CustomerID:     "customer-1",
+			Items:          items,
+			Status:         domain.OrderStatusShipped,
+			TotalAmount:    3500,
+			TrackingNumber: "1Z999AA10123456784",
+			Carrier:        "UPS",
+			CreatedAt:      createdAt,
+			UpdatedAt:      createdAt.Add(time.Hour),
+		}
+	}
+	items := []domain.OrderItem{
+		{ProductID: "product-1", Quantity: 2, Price: 1000},
+		{ProductID: "product-2", Quantity: 1, Price: 1500},
+	}
+
+	for _, storage := range []ItemStorage{ItemStorageTable, ItemStorageJSONB} {
+		for _, tt := range []struct {
+			name  string
+			items []domain.OrderItem
+		}{
+			{name: "with items", items: items},
+			{name: "without items", items: []domain.OrderItem{}},
+		} {
+			t.Run(string(storage)+" "+tt.name, func(t *testing.T) {
+				want := order(tt.items)
+				model := FromOrderDomain(want, storage)
+
+				if storage == ItemStorageJSONB && (model.ItemsJSON == nil || model.Items != nil) {
+					t.Fatalf("FromOrderDomain() in jsonb mode stored items in the table: %+v", model)
+				}
+				if storage == ItemStorageTable && model.ItemsJSON != nil {
+					t.Fatalf("FromOrderDomain() in table mode stored items in the column: %+v", model)
+				}
+
+				if got := model.ToOrderDomain(); !reflect.DeepEqual(got, want) {
+					t.Errorf("ToOrderDomain(FromOrderDomain()) = %+v, want %+v", got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestItemsJSONRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		items itemsJSON
+		value interface{}
+	}{
+		{name: "items", items: itemsJSON{{ProductID: "product-1", Quantity: 2, Price: 1000}}, value: `[{"product_id":"product-1","quantity":2,"price":1000}]`},
+		{name: "empty list is stored as []", items: itemsJSON{}, value: `[]`},
+		{name: "nil is stored as NULL", items: nil, value: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := tt.items.Value()
+			if err != nil {
+				t.Fatalf("Value() error = %v", err)
+			}
+			if value != tt.value {
+				t.Fatalf("Value() = %v, want %v", value, tt.value)
+			}
+
+			// Postgres returns jsonb as text, and NULL as nil
+			var scanned itemsJSON
+			var src interface{}
+			if value != nil {
+				src = []byte(value.(string))
+			}
+			if err := scanned.Scan(src); err != nil {
+				t.Fatalf("Scan() error = %v", err)
+			}
+			if !reflect.DeepEqual(scanned, tt.items) || (scanned == nil) != (tt.items == nil) {
+				t.Errorf("Scan(Value()) = %#v, want %#v", scanned, tt.items)
+			}
+		})
+	}
+}
+
+func TestItemsJSONScanRejectsMalformedItems(t *testing.T) {
+	var scanned itemsJSON
+	if err := scanned.Scan([]byte(`{"product_id":`)); err == nil {
+		t.Error("Scan() error = nil, want an error for malformed items")
+	}
+	if err := scanned.Scan(42); err == nil {
+		t.Error("Scan() error = nil, want an error for a non-text value")
+	}
+}
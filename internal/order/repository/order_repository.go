@@ -4,27 +4,47 @@ import (
 	"context"
 	"go-bootiful-ordering/internal/order/domain"
 	"gorm.io/gorm"
+	"time"
 )
 
-// OrderRepository defines the interface for order persistence operations
+// OrderRepository defines the interface for order persistence operations.
+// Writes run inside a transaction opened by the caller with BeginTransaction,
+// so that the order change and its outbox entry commit together; the
+// repository never starts a transaction of its own.
 type OrderRepository interface {
-	// CreateOrder persists a new order and returns the created order
-	CreateOrder(ctx context.Context, order *domain.Order) (*domain.Order, error)
-
 	// CreateOrderWithTx persists a new order within an existing transaction and returns the created order
 	CreateOrderWithTx(ctx context.Context, tx *gorm.DB, order *domain.Order) (*domain.Order, error)
 
-	// GetOrder retrieves an order by ID
-	GetOrder(ctx context.Context, orderID string) (*domain.Order, error)
+	// GetOrder retrieves an order by ID with at most maxItems of its items, setting
+	// ItemsTruncated when it has more; zero embeds every item
+	GetOrder(ctx context.Context, orderID string, maxItems int) (*domain.Order, error)
+
+	// ListOrderItems retrieves the items of an order with pagination, in the order they were added
+	ListOrderItems(ctx context.Context, orderID string, pageSize int32, pageToken string) ([]domain.OrderItem, string, error)
+
+	// GetOrderForUpdateWithTx retrieves an order by ID with at most maxItems of its items within
+	// an existing transaction and locks it until the transaction ends, so its status can be
+	// checked before it is changed
+	GetOrderForUpdateWithTx(ctx context.Context, tx *gorm.DB, orderID string, maxItems int) (*domain.Order, error)
 
-	// ListOrders retrieves a list of orders for a customer with pagination
-	ListOrders(ctx context.Context, customerID string, pageSize int32, pageToken string) ([]*domain.Order, string, error)
+	// ListOrders retrieves a list of orders for a customer with pagination, each with at most
+	// maxItems of its items like GetOrder
+	ListOrders(ctx context.Context, customerID string, pageSize int32, pageToken string, maxItems int) ([]*domain.Order, string, error)
 
-	// UpdateOrderStatus updates the status of an order
-	UpdateOrderStatus(ctx context.Context, orderID string, status domain.OrderStatus) (*domain.Order, error)
+	// CountOrders counts a customer's orders, i.e. every order ListOrders returns across all pages
+	CountOrders(ctx context.Context, customerID string) (int64, error)
+
+	// CountOrdersByPeriod counts orders created in [from, to) grouped by time bucket.
+	// An empty customerID counts orders of all customers.
+	CountOrdersByPeriod(ctx context.Context, customerID string, bucket domain.TimeBucket, from, to time.Time) ([]domain.PeriodCount, error)
 
 	// UpdateOrderStatusWithTx updates the status of an order within an existing transaction
-	UpdateOrderStatusWithTx(ctx context.Context, tx *gorm.DB, orderID string, status domain.OrderStatus) (*domain.Order, error)
+	// and returns it with at most maxItems of its items like GetOrder
+	UpdateOrderStatusWithTx(ctx context.Context, tx *gorm.DB, orderID string, status domain.OrderStatus, maxItems int) (*domain.Order, error)
+
+	// ShipOrderWithTx marks an order as shipped and stores its tracking information within an
+	// existing transaction, and returns it with at most maxItems of its items like GetOrder
+	ShipOrderWithTx(ctx context.Context, tx *gorm.DB, orderID string, shipment domain.Shipment, maxItems int) (*domain.Order, error)
 
 	// BeginTransaction starts a new transaction
 	BeginTransaction(ctx context.Context) (*gorm.DB, error)
@@ -2,21 +2,11 @@ package repository
 
 import (
 	"encoding/json"
-	"github.com/google/uuid"
 	"go-bootiful-ordering/internal/order/domain"
+	"go-bootiful-ordering/internal/order/events"
 	"time"
 )
 
-// EventType represents the type of event
-type EventType string
-
-const (
-	// EventTypeOrderCreated represents an order created event
-	EventTypeOrderCreated EventType = "order_created"
-	// EventTypeOrderStatusUpdated represents an order status updated event
-	EventTypeOrderStatusUpdated EventType = "order_status_updated"
-)
-
 // AggregateType represents the type of aggregate
 type AggregateType string
 
@@ -27,12 +17,17 @@ const (
 
 // OutboxModel represents the database model for an outbox entry
 type OutboxModel struct {
-	ID            string    `gorm:"primaryKey;type:uuid"`
+	ID            string    `gorm:"primaryKey;type:varchar(36)"`
 	AggregateType string    `gorm:"not null"`
 	AggregateID   string    `gorm:"not null;index"`
 	EventType     string    `gorm:"not null"`
 	Payload       []byte    `gorm:"type:jsonb;not null"`
 	CreatedAt     time.Time `gorm:"not null;index;default:CURRENT_TIMESTAMP"`
+	// TraceID is the trace of the request that wrote the entry, or empty when it was not traced
+	TraceID string
+	// PayloadType names the schema of Payload, e.g. order_created.v1; it is empty on entries
+	// written before payloads were versioned, whose payload is the whole order
+	PayloadType string
 }
 
 // TableName specifies the table name for OutboxModel
@@ -40,36 +35,56 @@ func (OutboxModel) TableName() string {
 	return "order_outbox"
 }
 
-// NewOrderCreatedOutboxEntry creates a new outbox entry for an order created event
-func NewOrderCreatedOutboxEntry(order *domain.Order) (*OutboxModel, error) {
-	payload, err := json.Marshal(order)
+// newOrderOutboxEntry creates a new outbox entry for an event of an order with its typed
+// payload. The entry gets its ID when it is saved.
+func newOrderOutboxEntry(order *domain.Order, eventType events.EventType, payloadType events.PayloadType, payload interface{}) (*OutboxModel, error) {
+	data, err := json.Marshal(payload)
 	if err != nil {
 		return nil, err
 	}
 
 	return &OutboxModel{
-		ID:            uuid.New().String(),
 		AggregateType: string(AggregateTypeOrder),
 		AggregateID:   order.ID,
-		EventType:     string(EventTypeOrderCreated),
-		Payload:       payload,
-		CreatedAt:     time.Now(),
+		EventType:     string(eventType),
+		Payload:       data,
+		CreatedAt:     time.Now().UTC(),
+		PayloadType:   string(payloadType),
 	}, nil
 }
 
+// NewOrderCreatedOutboxEntry creates a new outbox entry for an order created event
+func NewOrderCreatedOutboxEntry(order *domain.Order) (*OutboxModel, error) {
+	return newOrderOutboxEntry(order, events.EventTypeOrderCreated, events.PayloadTypeOrderCreatedV1, events.NewOrderCreatedEventV1(order))
+}
+
 // NewOrderStatusUpdatedOutboxEntry creates a new outbox entry for an order status updated event
 func NewOrderStatusUpdatedOutboxEntry(order *domain.Order) (*OutboxModel, error) {
-	payload, err := json.Marshal(order)
-	if err != nil {
-		return nil, err
-	}
+	return newOrderOutboxEntry(order, events.EventTypeOrderStatusUpdated, events.PayloadTypeOrderStatusUpdatedV1, events.NewOrderStatusUpdatedEventV1(order))
+}
 
+// NewOrderCancelledOutboxEntry creates a new outbox entry for an order cancelled event
+func NewOrderCancelledOutboxEntry(order *domain.Order) (*OutboxModel, error) {
+	return newOrderOutboxEntry(order, events.EventTypeOrderCancelled, events.PayloadTypeOrderCancelledV1, events.NewOrderCancelledEventV1(order))
+}
+
+// NewOrderShippedOutboxEntry creates a new outbox entry for an order shipped event
+func NewOrderShippedOutboxEntry(order *domain.Order) (*OutboxModel, error) {
+	return newOrderOutboxEntry(order, events.EventTypeOrderShipped, events.PayloadTypeOrderShippedV1, events.NewOrderShippedEventV1(order))
+}
+
+// NewReplayOutboxEntry copies an outbox entry so that it is published again.
+// The copy gets a new ID when it is saved and is routed by the given aggregate type, which
+// Debezium uses as the topic name. It keeps the payload and its type as well as the trace
+// of the original request.
+func NewReplayOutboxEntry(entry *OutboxModel, aggregateType string) *OutboxModel {
 	return &OutboxModel{
-		ID:            uuid.New().String(),
-		AggregateType: string(AggregateTypeOrder),
-		AggregateID:   order.ID,
-		EventType:     string(EventTypeOrderStatusUpdated),
-		Payload:       payload,
-		CreatedAt:     time.Now(),
-	}, nil
+		AggregateType: aggregateType,
+		AggregateID:   entry.AggregateID,
+		EventType:     entry.EventType,
+		Payload:       entry.Payload,
+		CreatedAt:     time.Now().UTC(),
+		TraceID:       entry.TraceID,
+		PayloadType:   entry.PayloadType,
+	}
 }
@@ -2,36 +2,78 @@ package repository
 
 import (
 	"context"
+	"go-bootiful-ordering/internal/pkg/idgen"
+	"go-bootiful-ordering/internal/pkg/tracing"
 	"gorm.io/gorm"
+	"time"
 )
 
 // OutboxRepository defines the interface for outbox persistence operations
 type OutboxRepository interface {
-	// SaveOutboxEntry persists a new outbox entry
-	SaveOutboxEntry(ctx context.Context, entry *OutboxModel) error
-
 	// SaveOutboxEntryWithTx persists a new outbox entry within an existing transaction
 	SaveOutboxEntryWithTx(ctx context.Context, tx *gorm.DB, entry *OutboxModel) error
+
+	// GetOutboxEntries retrieves the outbox entries of an aggregate in creation order
+	GetOutboxEntries(ctx context.Context, aggregateID string) ([]*OutboxModel, error)
+
+	// FindOutboxEntries retrieves up to limit entries of an aggregate type created in [from, to),
+	// optionally restricted to one aggregate, in creation order
+	FindOutboxEntries(ctx context.Context, aggregateType, aggregateID string, from, to time.Time, limit int) ([]*OutboxModel, error)
 }
 
 // GormOutboxRepository implements OutboxRepository using GORM
 type GormOutboxRepository struct {
-	db *gorm.DB
+	db  *gorm.DB
+	ids idgen.IDGenerator
 }
 
 // NewGormOutboxRepository creates a new GormOutboxRepository
-func NewGormOutboxRepository(db *gorm.DB) *GormOutboxRepository {
+func NewGormOutboxRepository(db *gorm.DB, ids idgen.IDGenerator) *GormOutboxRepository {
 	return &GormOutboxRepository{
-		db: db,
+		db:  db,
+		ids: ids,
 	}
 }
 
-// SaveOutboxEntry persists a new outbox entry
-func (r *GormOutboxRepository) SaveOutboxEntry(ctx context.Context, entry *OutboxModel) error {
-	return r.db.WithContext(ctx).Create(entry).Error
-}
-
-// SaveOutboxEntryWithTx persists a new outbox entry within an existing transaction
+// SaveOutboxEntryWithTx persists a new outbox entry within an existing transaction.
+// An entry without an ID gets one from the repository's generator. An entry without a
+// trace ID is stamped with the trace of the request in ctx, which Debezium publishes as
+// the trace_id header so consumers can join the trace.
 func (r *GormOutboxRepository) SaveOutboxEntryWithTx(ctx context.Context, tx *gorm.DB, entry *OutboxModel) error {
+	if entry.ID == "" {
+		entry.ID = r.ids.NewID()
+	}
+	if entry.TraceID == "" {
+		entry.TraceID = tracing.TraceID(ctx)
+	}
 	return tx.WithContext(ctx).Create(entry).Error
 }
+
+// GetOutboxEntries retrieves the outbox entries of an aggregate in creation order
+func (r *GormOutboxRepository) GetOutboxEntries(ctx context.Context, aggregateID string) ([]*OutboxModel, error) {
+	var entries []*OutboxModel
+	if err := r.db.WithContext(ctx).Where("aggregate_id = ?", aggregateID).Order("created_at, id").Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// FindOutboxEntries retrieves up to limit entries of an aggregate type created in [from, to),
+// optionally restricted to one aggregate, in creation order. created_at has no time zone
+// and holds UTC, so the bounds are converted to UTC.
+func (r *GormOutboxRepository) FindOutboxEntries(ctx context.Context, aggregateType, aggregateID string, from, to time.Time, limit int) ([]*OutboxModel, error) {
+	query := r.db.WithContext(ctx).
+		Where("aggregate_type = ?", aggregateType).
+		Where("created_at >= ? AND created_at < ?", from.UTC(), to.UTC())
+
+	// Filter by aggregate if provided
+	if aggregateID != "" {
+		query = query.Where("aggregate_id = ?", aggregateID)
+	}
+
+	var entries []*OutboxModel
+	if err := query.Order("created_at, id").Limit(limit).Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
@@ -0,0 +1,296 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/uber/jaeger-client-go"
+	"go-bootiful-ordering/internal/order/domain"
+	"go-bootiful-ordering/internal/order/events"
+	"go-bootiful-ordering/internal/pkg/idgen"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newDryRunDB opens a GORM connection that builds statements without sending them, and
+// so without connecting; it stands in for a transaction
+func newDryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost dbname=orders"}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+		Logger:                 logger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+	return db
+}
+
+func TestSaveOutboxEntryUsesInjectedIDs(t *testing.T) {
+	db := newDryRunDB(t)
+	repo := NewGormOutboxRepository(db, idgen.NewSequenceGenerator("event-"))
+	order := &domain.Order{ID: "order-1", CustomerID: "customer-1", Status: domain.OrderStatusPending}
+
+	created, err := NewOrderCreatedOutboxEntry(order)
+	if err != nil {
+		t.Fatalf("NewOrderCreatedOutboxEntry() error = %v", err)
+	}
+	cancelled, err := NewOrderCancelledOutboxEntry(order)
+	if err != nil {
+		t.Fatalf("NewOrderCancelledOutboxEntry() error = %v", err)
+	}
+	replayed := NewReplayOutboxEntry(created, "order-replay")
+	kept := &OutboxModel{ID: "event-kept", AggregateType: string(AggregateTypeOrder), AggregateID: order.ID}
+
+	entries := []*OutboxModel{created, cancelled, replayed, kept}
+	for _, entry := range entries {
+		if err := repo.SaveOutboxEntryWithTx(context.Background(), db, entry); err != nil {
+			t.Fatalf("SaveOutboxEntryWithTx() error = %v", err)
+		}
+	}
+
+	want := []string{"event-1", "event-2", "event-3", "event-kept"}
+	for i, entry := range entries {
+		if entry.ID != want[i] {
+			t.Errorf("entries[%d].ID = %q, want %q", i, entry.ID, want[i])
+		}
+	}
+	if replayed.AggregateType != "order-replay" || replayed.AggregateID != order.ID || string(replayed.Payload) != string(created.Payload) {
+		t.Errorf("replayed entry = %+v, want a copy of the created entry routed to order-replay", replayed)
+	}
+}
+
+func TestSaveOutboxEntryRecordsTheRequestTrace(t *testing.T) {
+	tracer, closer := jaeger.NewTracer("test", jaeger.NewConstSampler(true), jaeger.NewNullReporter())
+	defer closer.Close()
+	span := tracer.StartSpan("POST /orders")
+	defer span.Finish()
+	traceID := span.Context().(jaeger.SpanContext).TraceID().String()
+	traced := opentracing.ContextWithSpan(context.Background(), span)
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		// entryTraceID is the trace the entry already carries, as a replayed entry does
+		entryTraceID string
+		want         string
+	}{
+		{name: "request in a trace", ctx: traced, want: traceID},
+		{name: "request outside a trace", ctx: context.Background(), want: ""},
+		{name: "entry with a trace", ctx: traced, entryTraceID: "trace-original", want: "trace-original"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newDryRunDB(t)
+			var inserts [][]interface{}
+			if err := db.Callback().Create().After("gorm:create").Register("test:record", func(db *gorm.DB) {
+				inserts = append(inserts, db.Statement.Vars)
+			}); err != nil {
+				t.Fatalf("registering the insert recorder: %v", err)
+			}
+			repo := NewGormOutboxRepository(db, idgen.NewSequenceGenerator("event-"))
+			entry, err := NewOrderCreatedOutboxEntry(&domain.Order{ID: "order-1", CustomerID: "customer-1", Status: domain.OrderStatusPending})
+			if err != nil {
+				t.Fatalf("NewOrderCreatedOutboxEntry() error = %v", err)
+			}
+			entry.TraceID = tt.entryTraceID
+
+			if err := repo.SaveOutboxEntryWithTx(tt.ctx, db, entry); err != nil {
+				t.Fatalf("SaveOutboxEntryWithTx() error = %v", err)
+			}
+			if entry.TraceID != tt.want {
+				t.Errorf("entry.TraceID = %q, want %q", entry.TraceID, tt.want)
+			}
+			if len(inserts) != 1 || !containsVar(inserts[0], tt.want) {
+				t.Errorf("inserts = %v, want one writing trace_id %q", inserts, tt.want)
+			}
+		})
+	}
+}
+
+// containsVar reports whether want is one of the bind values of a statement
+func containsVar(vars []interface{}, want interface{}) bool {
+	for _, v := range vars {
+		if v == want {
+			return true
+		}
+	}
+	return false
+}
+
+func TestOrderOutboxEntriesCarryVersionedPayloads(t *testing.T) {
+	order := &domain.Order{
+		ID: "order-1", CustomerID: "customer-1", Status: domain.OrderStatusShipped, TotalAmount: 2000,
+		Items:          []domain.OrderItem{{ProductID: "product-1", Quantity: 2, Price: 1000}},
+		TrackingNumber: "1Z999", Carrier: "UPS", ItemsTruncated: true,
+	}
+
+	tests := []struct {
+		name            string
+		newEntry        func(*domain.Order) (*OutboxModel, error)
+		wantEventType   events.EventType
+		wantPayloadType events.PayloadType
+		wantPayload     interface{}
+		// wantFields are the payload's top-level fields, and only those
+		wantFields []string
+	}{
+		{
+			name: "order created", newEntry: NewOrderCreatedOutboxEntry,
+			wantEventType: events.EventTypeOrderCreated, wantPayloadType: events.PayloadTypeOrderCreatedV1,
+			wantPayload: events.NewOrderCreatedEventV1(order),
+			wantFields:  []string{"created_at", "customer_id", "items", "order_id", "schema_version", "status", "total_amount"},
+		},
+		{
+			name: "order status updated", newEntry: NewOrderStatusUpdatedOutboxEntry,
+			wantEventType: events.EventTypeOrderStatusUpdated, wantPayloadType: events.PayloadTypeOrderStatusUpdatedV1,
+			wantPayload: events.NewOrderStatusUpdatedEventV1(order),
+			wantFields:  []string{"customer_id", "order_id", "schema_version", "status", "updated_at"},
+		},
+		{
+			name: "order cancelled", newEntry: NewOrderCancelledOutboxEntry,
+			wantEventType: events.EventTypeOrderCancelled, wantPayloadType: events.PayloadTypeOrderCancelledV1,
+			wantPayload: events.NewOrderCancelledEventV1(order),
+			wantFields:  []string{"cancelled_at", "customer_id", "items", "order_id", "schema_version"},
+		},
+		{
+			name: "order shipped", newEntry: NewOrderShippedOutboxEntry,
+			wantEventType: events.EventTypeOrderShipped, wantPayloadType: events.PayloadTypeOrderShippedV1,
+			wantPayload: events.NewOrderShippedEventV1(order),
+			wantFields:  []string{"carrier", "customer_id", "order_id", "schema_version", "shipped_at", "tracking_number"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, err := tt.newEntry(order)
+			if err != nil {
+				t.Fatalf("creating the entry: %v", err)
+			}
+			if entry.EventType != string(tt.wantEventType) || entry.PayloadType != string(tt.wantPayloadType) || entry.AggregateID != order.ID {
+				t.Errorf("entry = %+v, want event type %s and payload type %s of %s", entry, tt.wantEventType, tt.wantPayloadType, order.ID)
+			}
+
+			var fields map[string]json.RawMessage
+			if err := json.Unmarshal(entry.Payload, &fields); err != nil {
+				t.Fatalf("decoding the payload: %v", err)
+			}
+			var names []string
+			for name := range fields {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			if !reflect.DeepEqual(names, tt.wantFields) {
+				t.Errorf("payload fields = %v, want %v", names, tt.wantFields)
+			}
+			if string(fields["schema_version"]) != "1" {
+				t.Errorf("schema_version = %s, want 1", fields["schema_version"])
+			}
+
+			event, err := events.DecodeEvent(events.Message{ID: "event-1", EventType: events.EventType(entry.EventType), PayloadType: events.PayloadType(entry.PayloadType), Payload: entry.Payload})
+			if err != nil {
+				t.Fatalf("DecodeEvent() error = %v", err)
+			}
+			if !reflect.DeepEqual(event.Payload, tt.wantPayload) {
+				t.Errorf("decoded payload = %+v, want %+v", event.Payload, tt.wantPayload)
+			}
+		})
+	}
+}
+
+func TestGetOutboxEntriesQuery(t *testing.T) {
+	db := newDryRunDB(t)
+	queries := recordQueries(t, db)
+	repo := NewGormOutboxRepository(db, idgen.NewSequenceGenerator("event-"))
+
+	if _, err := repo.GetOutboxEntries(context.Background(), "order-1"); err != nil {
+		t.Fatalf("GetOutboxEntries() error = %v", err)
+	}
+	if len(*queries) != 1 {
+		t.Fatalf("GetOutboxEntries() ran %d queries, want 1", len(*queries))
+	}
+	query := (*queries)[0]
+	want := `SELECT * FROM "order_outbox" WHERE aggregate_id = $1 ORDER BY created_at, id`
+	if query.sql != want || !reflect.DeepEqual(query.vars, []interface{}{"order-1"}) {
+		t.Errorf("query = %s %v, want %s [order-1]", query.sql, query.vars, want)
+	}
+}
+
+func TestFindOutboxEntriesQuery(t *testing.T) {
+	hanoi := time.FixedZone("UTC+7", 7*60*60)
+	from := time.Date(2024, 3, 1, 7, 0, 0, 0, hanoi)
+	to := time.Date(2024, 3, 2, 7, 0, 0, 0, hanoi)
+	fromUTC, toUTC := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		aggregateID string
+		wantSQL     string
+		wantVars    []interface{}
+	}{
+		{
+			name:     "every order",
+			wantSQL:  `SELECT * FROM "order_outbox" WHERE aggregate_type = $1 AND (created_at >= $2 AND created_at < $3) ORDER BY created_at, id LIMIT $4`,
+			wantVars: []interface{}{"order", fromUTC, toUTC, 11},
+		},
+		{
+			name:        "one order",
+			aggregateID: "order-1",
+			wantSQL:     `SELECT * FROM "order_outbox" WHERE aggregate_type = $1 AND (created_at >= $2 AND created_at < $3) AND aggregate_id = $4 ORDER BY created_at, id LIMIT $5`,
+			wantVars:    []interface{}{"order", fromUTC, toUTC, "order-1", 11},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newDryRunDB(t)
+			queries := recordQueries(t, db)
+			repo := NewGormOutboxRepository(db, idgen.NewSequenceGenerator("event-"))
+
+			if _, err := repo.FindOutboxEntries(context.Background(), string(AggregateTypeOrder), tt.aggregateID, from, to, 11); err != nil {
+				t.Fatalf("FindOutboxEntries() error = %v", err)
+			}
+			if len(*queries) != 1 {
+				t.Fatalf("FindOutboxEntries() ran %d queries, want 1", len(*queries))
+			}
+			query := (*queries)[0]
+			if query.sql != tt.wantSQL {
+				t.Errorf("query = %s\nwant    %s", query.sql, tt.wantSQL)
+			}
+			// The bounds are sent in UTC, the zone created_at is stored in
+			if !reflect.DeepEqual(query.vars, tt.wantVars) {
+				t.Errorf("bind values = %v, want %v", query.vars, tt.wantVars)
+			}
+		})
+	}
+}
+
+func TestNewReplayOutboxEntry(t *testing.T) {
+	order := &domain.Order{ID: "order-1", CustomerID: "customer-1", Status: domain.OrderStatusPending}
+	original, err := NewOrderCreatedOutboxEntry(order)
+	if err != nil {
+		t.Fatalf("NewOrderCreatedOutboxEntry() error = %v", err)
+	}
+	original.ID, original.TraceID = "event-1", "trace-1"
+	original.CreatedAt = time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	replayed := NewReplayOutboxEntry(original, "order-replay")
+	if replayed.ID != "" || replayed.AggregateType != "order-replay" || !replayed.CreatedAt.After(original.CreatedAt) {
+		t.Errorf("replayed entry = %+v, want a new entry on order-replay", replayed)
+	}
+	if replayed.AggregateID != original.AggregateID || replayed.EventType != original.EventType || replayed.PayloadType != original.PayloadType ||
+		string(replayed.Payload) != string(original.Payload) || replayed.TraceID != original.TraceID {
+		t.Errorf("replayed entry = %+v, want the event, payload and trace of %+v", replayed, original)
+	}
+	if original.ID != "event-1" || original.AggregateType != string(AggregateTypeOrder) {
+		t.Errorf("original entry = %+v, want it untouched", original)
+	}
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"go-bootiful-ordering/internal/order/domain"
+)
+
+// MaxBulkShipOrders bounds the number of orders a single bulk shipment may ship
+const MaxBulkShipOrders = 500
+
+// OrderShipment is the tracking information to ship one order with
+type OrderShipment struct {
+	OrderID  string
+	Shipment domain.Shipment
+}
+
+// ShipResult is the outcome of shipping one order of a bulk shipment: the shipped order,
+// or the error that kept it from shipping
+type ShipResult struct {
+	OrderID string
+	Order   *domain.Order
+	Err     error
+}
+
+// ShipOrders ships each order through ShipOrder, in request order. Every order is shipped
+// on its own, in db mode in its own transaction with its own order_shipped event, so an
+// order that cannot ship, e.g. one already delivered, does not hold back the others; its
+// error is returned in its result. The error return is for batches rejected as a whole:
+// an empty or oversized batch, or one naming an order twice.
+func ShipOrders(ctx context.Context, svc OrderService, shipments []OrderShipment) ([]ShipResult, error) {
+	if len(shipments) == 0 {
+		return nil, fmt.Errorf("%w: at least one order is required", domain.ErrInvalidArgument)
+	}
+	if len(shipments) > MaxBulkShipOrders {
+		return nil, fmt.Errorf("%w: at most %d orders can be shipped at once, got %d", domain.ErrInvalidArgument, MaxBulkShipOrders, len(shipments))
+	}
+
+	seen := make(map[string]int, len(shipments))
+	for i, shipment := range shipments {
+		if first, ok := seen[shipment.OrderID]; ok {
+			return nil, fmt.Errorf("%w: [%d]: order %q is already listed at [%d]", domain.ErrInvalidArgument, i, shipment.OrderID, first)
+		}
+		seen[shipment.OrderID] = i
+	}
+
+	results := make([]ShipResult, len(shipments))
+	for i, shipment := range shipments {
+		results[i].OrderID = shipment.OrderID
+		if shipment.OrderID == "" {
+			results[i].Err = fmt.Errorf("%w: order_id is required", domain.ErrInvalidArgument)
+			continue
+		}
+		results[i].Order, results[i].Err = svc.ShipOrder(ctx, shipment.OrderID, shipment.Shipment)
+	}
+	return results, nil
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultCustomerLookupTimeout bounds a customer lookup when no timeout is configured
+const DefaultCustomerLookupTimeout = 2 * time.Second
+
+// CustomerValidator checks that the customer an order is placed for exists. Orders hold
+// a customer ID only, so the customers themselves live in another system.
+type CustomerValidator interface {
+	// Exists reports whether the customer exists; an error means the check itself failed
+	Exists(ctx context.Context, customerID string) (bool, error)
+}
+
+// NoopCustomerValidator provides an implementation of CustomerValidator that accepts
+// every customer, for deployments without a customer service
+type NoopCustomerValidator struct{}
+
+// Exists always reports the customer as existing
+func (NoopCustomerValidator) Exists(ctx context.Context, customerID string) (bool, error) {
+	return true, nil
+}
+
+// HTTPCustomerValidator provides an implementation of CustomerValidator backed by a
+// customer service answering GET {baseURL}/customers/{id} with 200 for a known customer
+// and 404 for an unknown one
+type HTTPCustomerValidator struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewHTTPCustomerValidator creates a new HTTPCustomerValidator whose lookups give up after
+// timeout, or after DefaultCustomerLookupTimeout when it is zero
+func NewHTTPCustomerValidator(baseURL string, timeout time.Duration) (*HTTPCustomerValidator, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("customer service URL %q must be an http(s) URL", baseURL)
+	}
+	if timeout < 0 {
+		return nil, fmt.Errorf("customer lookup timeout cannot be negative, got %s", timeout)
+	}
+	if timeout == 0 {
+		timeout = DefaultCustomerLookupTimeout
+	}
+	return &HTTPCustomerValidator{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// Exists looks the customer up on the customer service
+func (v *HTTPCustomerValidator) Exists(ctx context.Context, customerID string) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.baseURL+"/customers/"+url.PathEscape(customerID), nil)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("customer service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return false, nil
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return true, nil
+	default:
+		return false, fmt.Errorf("customer service: unexpected status %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-bootiful-ordering/internal/order/domain"
+)
+
+// knownCustomers accepts the customers it holds and fails every check with err when set
+type knownCustomers struct {
+	ids    map[string]bool
+	err    error
+	checks []string
+}
+
+func (v *knownCustomers) Exists(ctx context.Context, customerID string) (bool, error) {
+	v.checks = append(v.checks, customerID)
+	if v.err != nil {
+		return false, v.err
+	}
+	return v.ids[customerID], nil
+}
+
+func TestOrdersRequireAnExistingCustomer(t *testing.T) {
+	lookupFailure := errors.New("customer service: unexpected status 503")
+	items := []domain.OrderItem{{ProductID: "product-1", Quantity: 2, Price: 100}}
+
+	tests := []struct {
+		name       string
+		customerID string
+		err        error
+		// wantErr is nil for an accepted order
+		wantErr error
+	}{
+		{name: "known customer", customerID: "customer-1"},
+		{name: "unknown customer", customerID: "customer-2", wantErr: domain.ErrInvalidArgument},
+		{name: "failed lookup", customerID: "customer-1", err: lookupFailure, wantErr: lookupFailure},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			customers := &knownCustomers{ids: map[string]bool{"customer-1": true}, err: tt.err}
+			repo := &recordingOrderRepository{}
+			stock := &recordingStockReserver{}
+			svc := newTestService(repo, stock, NoopProductCatalog{})
+			svc.customers = customers
+
+			preview, err := svc.PreviewOrder(context.Background(), tt.customerID, items, domain.PriceSourceSnapshot)
+			if tt.wantErr == nil {
+				if err != nil || preview.TotalAmount != 200 {
+					t.Fatalf("PreviewOrder() = %+v, %v, want the order priced at 200", preview, err)
+				}
+			} else if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("PreviewOrder() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == domain.ErrInvalidArgument && !strings.Contains(err.Error(), tt.customerID) {
+				t.Errorf("PreviewOrder() error = %v, want it to name %s", err, tt.customerID)
+			}
+
+			// The order reaches the database only for a known customer; the tests have none
+			_, err = svc.CreateOrder(context.Background(), tt.customerID, items)
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CreateOrder() error = %v, want %v", err, tt.wantErr)
+			}
+			wantTransactions := 0
+			if tt.wantErr == nil {
+				wantTransactions = 1
+			}
+			if repo.transactions != wantTransactions || stock.reserved != 0 {
+				t.Errorf("CreateOrder() opened %d transactions and reserved stock %d times, want %d and none", repo.transactions, stock.reserved, wantTransactions)
+			}
+			if len(customers.checks) != 2 || customers.checks[0] != tt.customerID || customers.checks[1] != tt.customerID {
+				t.Errorf("customer checks = %v, want %s checked by each call", customers.checks, tt.customerID)
+			}
+		})
+	}
+}
+
+func TestNoopCustomerValidatorAcceptsEveryCustomer(t *testing.T) {
+	for _, id := range []string{"customer-1", ""} {
+		if exists, err := (NoopCustomerValidator{}).Exists(context.Background(), id); !exists || err != nil {
+			t.Errorf("Exists(%q) = %v, %v, want true, nil", id, exists, err)
+		}
+	}
+}
+
+func TestHTTPCustomerValidatorExists(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.EscapedPath())
+		switch r.URL.Path {
+		case "/customers/customer-1", "/customers/customer 3":
+			w.WriteHeader(http.StatusOK)
+		case "/customers/customer-2":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	// A trailing slash on the configured URL is not doubled
+	validator, err := NewHTTPCustomerValidator(server.URL+"/", time.Second)
+	if err != nil {
+		t.Fatalf("NewHTTPCustomerValidator() error = %v", err)
+	}
+
+	tests := []struct {
+		customerID string
+		want       bool
+		wantPath   string
+		wantErr    bool
+	}{
+		{customerID: "customer-1", want: true, wantPath: "/customers/customer-1"},
+		{customerID: "customer-2", want: false, wantPath: "/customers/customer-2"},
+		{customerID: "customer 3", want: true, wantPath: "/customers/customer%203"},
+		{customerID: "broken", wantPath: "/customers/broken", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.customerID, func(t *testing.T) {
+			paths = nil
+			exists, err := validator.Exists(context.Background(), tt.customerID)
+			if (err != nil) != tt.wantErr || exists != tt.want {
+				t.Errorf("Exists() = %v, %v, want %v with error %v", exists, err, tt.want, tt.wantErr)
+			}
+			if len(paths) != 1 || paths[0] != tt.wantPath {
+				t.Errorf("requested paths = %v, want [%s]", paths, tt.wantPath)
+			}
+		})
+	}
+}
+
+func TestHTTPCustomerValidatorTimesOut(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	validator, err := NewHTTPCustomerValidator(server.URL, 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("NewHTTPCustomerValidator() error = %v", err)
+	}
+	if exists, err := validator.Exists(context.Background(), "customer-1"); exists || err == nil {
+		t.Errorf("Exists() = %v, %v, want the lookup to fail once the timeout passes", exists, err)
+	}
+}
+
+func TestNewHTTPCustomerValidatorRejectsBadConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		timeout time.Duration
+		wantErr string
+	}{
+		{name: "no scheme", url: "customers:8080", wantErr: "must be an http(s) URL"},
+		{name: "other scheme", url: "grpc://customers:8080", wantErr: "must be an http(s) URL"},
+		{name: "no host", url: "http://", wantErr: "must be an http(s) URL"},
+		{name: "negative timeout", url: "http://customers:8080", timeout: -time.Second, wantErr: "cannot be negative"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewHTTPCustomerValidator(tt.url, tt.timeout); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("NewHTTPCustomerValidator() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
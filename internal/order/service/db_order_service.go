@@ -2,31 +2,221 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"go-bootiful-ordering/internal/order/domain"
 	"go-bootiful-ordering/internal/order/repository"
+	"go-bootiful-ordering/internal/pkg/idgen"
+	"go-bootiful-ordering/internal/pkg/metrics"
+	"go-bootiful-ordering/internal/pkg/tenant"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"time"
 )
 
+const (
+	// MaxTimeSeriesDays bounds the time range of an order time series query
+	MaxTimeSeriesDays = 366
+	// MaxReplayDays bounds the time range of an event replay
+	MaxReplayDays = 31
+	// MaxReplayEvents bounds the number of events a single replay may re-publish
+	MaxReplayEvents = 1000
+)
+
+// ReplayConfig controls where replayed outbox events are published
+type ReplayConfig struct {
+	// Topic receives the replayed events; empty replays onto the live topic
+	Topic string
+}
+
+// AmountConfig bounds the amounts an order may carry
+type AmountConfig struct {
+	// MaxTotalAmount caps an order's total; zero disables the cap
+	MaxTotalAmount int64
+}
+
+// ProductIDConfig controls which product IDs an order may reference
+type ProductIDConfig struct {
+	// Format is the ID kind the product service generates (idgen.KindUUID or idgen.KindULID);
+	// empty accepts any non-empty product ID
+	Format string
+}
+
+// Validate checks that the format is one the product service can generate
+func (c ProductIDConfig) Validate() error {
+	switch c.Format {
+	case "", idgen.KindUUID, idgen.KindULID:
+		return nil
+	default:
+		return fmt.Errorf("unknown product ID format %q, expected %q or %q", c.Format, idgen.KindUUID, idgen.KindULID)
+	}
+}
+
+// Check rejects the first item whose product ID is not in the configured format, so a
+// malformed cart fails before any stock is reserved or the database is queried
+func (c ProductIDConfig) Check(items []domain.OrderItem) error {
+	if c.Format == "" {
+		return nil
+	}
+	for idx, item := range items {
+		if item.ProductID != "" && !idgen.Valid(c.Format, item.ProductID) {
+			return fmt.Errorf("%w: items[%d]: product_id %q is not a valid %s", domain.ErrInvalidArgument, idx, item.ProductID, c.Format)
+		}
+	}
+	return nil
+}
+
+// ShipmentConfig controls the tracking information an order must carry when it ships
+type ShipmentConfig struct {
+	// RequireTracking rejects shipping an order without a tracking number and carrier
+	RequireTracking bool
+}
+
+// Check validates the shipment and, when tracking is required, that it has a tracking number
+func (c ShipmentConfig) Check(shipment domain.Shipment) error {
+	if err := shipment.Validate(); err != nil {
+		return err
+	}
+	if c.RequireTracking && shipment.IsZero() {
+		return fmt.Errorf("%w: tracking_number and carrier are required to ship an order", domain.ErrInvalidArgument)
+	}
+	return nil
+}
+
+// DefaultMaxEmbeddedItems is the number of items embedded in an order when no cap is configured
+const DefaultMaxEmbeddedItems = 100
+
+// ItemConfig bounds the items embedded in the orders the service returns
+type ItemConfig struct {
+	// MaxEmbeddedItems caps the items embedded in every returned order, except a cancelled
+	// one; zero uses DefaultMaxEmbeddedItems. The remaining items are read with ListOrderItems.
+	MaxEmbeddedItems int
+}
+
+// Validate checks that the cap is not negative
+func (c ItemConfig) Validate() error {
+	if c.MaxEmbeddedItems < 0 {
+		return fmt.Errorf("maxEmbeddedItems must not be negative, got %d", c.MaxEmbeddedItems)
+	}
+	return nil
+}
+
+// embedLimit returns the number of items embedded in a returned order
+func (c ItemConfig) embedLimit() int {
+	if c.MaxEmbeddedItems == 0 {
+		return DefaultMaxEmbeddedItems
+	}
+	return c.MaxEmbeddedItems
+}
+
+// errIdempotencyKeyTaken rolls back an order whose idempotency key was saved by a concurrent request
+var errIdempotencyKeyTaken = errors.New("idempotency key already used")
+
 // DBOrderService provides an implementation of OrderService that uses a database repository
 type DBOrderService struct {
-	log        *zap.SugaredLogger
-	repo       repository.OrderRepository
-	outboxRepo repository.OutboxRepository
+	log             *zap.SugaredLogger
+	repo            repository.OrderRepository
+	outboxRepo      repository.OutboxRepository
+	idempotencyRepo repository.IdempotencyRepository
+	replay          ReplayConfig
+	stock           StockReserver
+	catalog         ProductCatalog
+	customers       CustomerValidator
+	idempotency     IdempotencyConfig
+	results         IdempotencyCache
+	amounts         AmountConfig
+	productIDs      ProductIDConfig
+	shipments       ShipmentConfig
+	items           ItemConfig
+	metrics         *metrics.Metrics
 }
 
 // NewDBOrderService creates a new DBOrderService
-func NewDBOrderService(log *zap.SugaredLogger, repo repository.OrderRepository, outboxRepo repository.OutboxRepository) *DBOrderService {
+func NewDBOrderService(log *zap.SugaredLogger, repo repository.OrderRepository, outboxRepo repository.OutboxRepository, idempotencyRepo repository.IdempotencyRepository, replay ReplayConfig, stock StockReserver, catalog ProductCatalog, customers CustomerValidator, idempotency IdempotencyConfig, results IdempotencyCache, amounts AmountConfig, productIDs ProductIDConfig, shipments ShipmentConfig, items ItemConfig, m *metrics.Metrics) *DBOrderService {
 	return &DBOrderService{
-		log:        log,
-		repo:       repo,
-		outboxRepo: outboxRepo,
+		log:             log,
+		repo:            repo,
+		outboxRepo:      outboxRepo,
+		idempotencyRepo: idempotencyRepo,
+		replay:          replay,
+		stock:           stock,
+		catalog:         catalog,
+		customers:       customers,
+		idempotency:     idempotency,
+		results:         results,
+		amounts:         amounts,
+		productIDs:      productIDs,
+		shipments:       shipments,
+		items:           items,
+		metrics:         m,
 	}
 }
 
-// CreateOrder creates a new order using the repository
+// withTx runs fn in a transaction, committing when it succeeds and rolling back
+// when it fails. Commits and rollbacks are counted per operation.
+func (s *DBOrderService) withTx(ctx context.Context, operation string, fn func(tx *gorm.DB) error) error {
+	// Begin transaction
+	tx, err := s.repo.BeginTransaction(ctx)
+	if err != nil {
+		s.log.Errorf("Failed to begin transaction: %v", err)
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		s.metrics.DatabaseRollbackCounter.WithLabelValues(operation).Inc()
+		return err
+	}
+
+	// Commit transaction; Postgres rolls back a transaction whose commit fails
+	if err := tx.Commit().Error; err != nil {
+		s.log.Errorf("Failed to commit transaction: %v", err)
+		s.metrics.DatabaseRollbackCounter.WithLabelValues(operation).Inc()
+		return err
+	}
+
+	s.metrics.DatabaseTransactionCounter.WithLabelValues(operation).Inc()
+	return nil
+}
+
+// CreateOrder creates a new order using the repository. When the context carries an
+// idempotency key, a repeated request from the same customer within the configured TTL
+// returns the order created by the first request instead of creating another one.
 func (s *DBOrderService) CreateOrder(ctx context.Context, customerID string, items []domain.OrderItem) (*domain.Order, error) {
 	s.log.Infof("DBOrderService_CreateOrder customerID=%s", customerID)
 
+	key := IdempotencyKeyFromContext(ctx)
+	if len(key) > MaxIdempotencyKeyLength {
+		return nil, fmt.Errorf("%w: idempotency key must not exceed %d characters", domain.ErrInvalidArgument, MaxIdempotencyKeyLength)
+	}
+	if err := s.productIDs.Check(items); err != nil {
+		return nil, err
+	}
+	expiredBefore := time.Now().Add(-s.idempotency.ttl())
+
+	// Answer a repeated request with the order it already created, from the cache when it has it
+	if key != "" {
+		cached, err := s.results.Get(ctx, customerID, key)
+		if err != nil {
+			// The database still holds the key, so a failing cache only costs the lookup below
+			s.log.Warnf("Failed to read idempotency cache: %v", err)
+		}
+		if cached != nil {
+			s.log.Infof("Replaying cached order orderID=%s for idempotency key", cached.ID)
+			return cached, nil
+		}
+
+		orderID, err := s.idempotencyRepo.FindOrderID(ctx, customerID, key, expiredBefore)
+		if err != nil {
+			s.log.Errorf("Failed to look up idempotency key: %v", err)
+			return nil, err
+		}
+		if orderID != "" {
+			s.log.Infof("Replaying order orderID=%s for idempotency key", orderID)
+			return s.repo.GetOrder(ctx, orderID, s.items.embedLimit())
+		}
+	}
+
 	// Create a new order domain object
 	order := &domain.Order{
 		CustomerID: customerID,
@@ -34,43 +224,198 @@ func (s *DBOrderService) CreateOrder(ctx context.Context, customerID string, ite
 		Status:     domain.OrderStatusPending,
 	}
 
-	// Begin transaction
-	tx, err := s.repo.BeginTransaction(ctx)
-	if err != nil {
-		s.log.Errorf("Failed to begin transaction: %v", err)
+	// Validate the order, check its customer and items and compute its total before
+	// opening a transaction
+	if err := s.prepareOrder(ctx, order, domain.PriceSourceSnapshot); err != nil {
 		return nil, err
 	}
 
-	// Create order within transaction
-	createdOrder, err := s.repo.CreateOrderWithTx(ctx, tx, order)
+	// Create the order, claim its idempotency key, reserve its stock and write its outbox
+	// entry in one transaction
+	var createdOrder *domain.Order
+	reserved := false
+	err := s.withTx(ctx, "create_order", func(tx *gorm.DB) error {
+		var err error
+		createdOrder, err = s.repo.CreateOrderWithTx(ctx, tx, order)
+		if err != nil {
+			s.log.Errorf("Failed to create order: %v", err)
+			return err
+		}
+
+		// Claim the key before reserving stock; a concurrent request with the same key waits
+		// here until the first one commits, then finds the key taken and rolls back its own
+		// order without having reserved anything
+		if key != "" {
+			saved, err := s.idempotencyRepo.SaveKeyWithTx(ctx, tx, customerID, key, createdOrder.ID, expiredBefore)
+			if err != nil {
+				s.log.Errorf("Failed to save idempotency key: %v", err)
+				return err
+			}
+			if !saved {
+				return errIdempotencyKeyTaken
+			}
+		}
+
+		// The order and its stock live in different databases, so a failed order returns
+		// the stock instead of sharing one transaction with it
+		if err := s.stock.Reserve(ctx, order.Items); err != nil {
+			s.log.Errorf("Failed to reserve stock: %v", err)
+			return err
+		}
+		reserved = true
+
+		// Create outbox entry for order created event
+		outboxEntry, err := repository.NewOrderCreatedOutboxEntry(createdOrder)
+		if err != nil {
+			s.log.Errorf("Failed to create outbox entry: %v", err)
+			return err
+		}
+
+		// Save outbox entry within transaction
+		if err := s.outboxRepo.SaveOutboxEntryWithTx(ctx, tx, outboxEntry); err != nil {
+			s.log.Errorf("Failed to save outbox entry: %v", err)
+			return err
+		}
+		return nil
+	})
 	if err != nil {
-		tx.Rollback()
-		s.log.Errorf("Failed to create order: %v", err)
+		// Return the stock even if the caller has gone away
+		if reserved {
+			if releaseErr := s.stock.Release(context.WithoutCancel(ctx), order.Items); releaseErr != nil {
+				s.log.Errorf("Failed to release stock of failed order: %v", releaseErr)
+			}
+		}
+
+		// Lost the race for the key, so answer with the winner's order
+		if errors.Is(err, errIdempotencyKeyTaken) {
+			orderID, findErr := s.idempotencyRepo.FindOrderID(ctx, customerID, key, expiredBefore)
+			if findErr != nil {
+				s.log.Errorf("Failed to look up idempotency key: %v", findErr)
+				return nil, findErr
+			}
+			return s.repo.GetOrder(ctx, orderID, s.items.embedLimit())
+		}
 		return nil, err
 	}
 
-	// Create outbox entry for order created event
-	outboxEntry, err := repository.NewOrderCreatedOutboxEntry(createdOrder)
+	// Cache the order only now that its key is committed, so the cache never answers
+	// for a key the database does not hold
+	if key != "" {
+		if err := s.results.Set(ctx, customerID, key, createdOrder); err != nil {
+			s.log.Warnf("Failed to cache order for idempotency key: %v", err)
+		}
+	}
+
+	s.metrics.OrdersCreatedCounter.WithLabelValues(tenant.MetricLabel(ctx)).Inc()
+	return createdOrder, nil
+}
+
+// prepareOrder validates a new order, checks its customer, prices and merges its items
+// and computes its total. It is Order.NormalizeAndValidate with the customer and catalog
+// checks in between: items are priced from the given source before they are merged, so
+// repeats of a product are compared at the prices they are stored with.
+func (s *DBOrderService) prepareOrder(ctx context.Context, order *domain.Order, source domain.PriceSource) error {
+	if err := order.Validate(); err != nil {
+		return err
+	}
+	if err := s.checkCustomer(ctx, order.CustomerID); err != nil {
+		return err
+	}
+	if err := s.priceItems(ctx, order, source); err != nil {
+		return err
+	}
+	if err := order.MergeItems(); err != nil {
+		return err
+	}
+	return order.ComputeTotal(s.amounts.MaxTotalAmount)
+}
+
+// checkCustomer rejects an order for a customer the customer validator does not know
+func (s *DBOrderService) checkCustomer(ctx context.Context, customerID string) error {
+	exists, err := s.customers.Exists(ctx, customerID)
 	if err != nil {
-		tx.Rollback()
-		s.log.Errorf("Failed to create outbox entry: %v", err)
+		s.log.Errorf("Failed to check customer customerID=%s: %v", customerID, err)
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("%w: customer %q does not exist", domain.ErrInvalidArgument, customerID)
+	}
+	return nil
+}
+
+// priceItems checks the order's items against the product catalog and stores the
+// items priced by it, so the total is computed from the authoritative prices. Snapshot
+// items are checked under the price policy; live items take the current prices.
+func (s *DBOrderService) priceItems(ctx context.Context, order *domain.Order, source domain.PriceSource) error {
+	pricer := s.catalog.PriceItems
+	if source == domain.PriceSourceLive {
+		pricer = s.catalog.CurrentPrices
+	}
+	items, err := pricer(ctx, order.Items)
+	if err != nil {
+		s.log.Errorf("Failed to check items against the product catalog: %v", err)
+		return err
+	}
+	order.Items = items
+	return nil
+}
+
+// PreviewOrder validates an order and computes its total the same way CreateOrder
+// would, without writing the order or its outbox entry. Like CreateOrder it checks
+// the customer and the items against the product catalog. A snapshot preview uses the
+// prices sent, as CreateOrder does; a live one the catalog's current prices.
+func (s *DBOrderService) PreviewOrder(ctx context.Context, customerID string, items []domain.OrderItem, source domain.PriceSource) (*domain.Order, error) {
+	s.log.Infof("DBOrderService_PreviewOrder customerID=%s priceSource=%s", customerID, source)
+
+	if !source.IsValid() {
+		return nil, fmt.Errorf("%w: unknown price source %q", domain.ErrInvalidArgument, source)
+	}
+	if err := s.productIDs.Check(items); err != nil {
 		return nil, err
 	}
 
-	// Save outbox entry within transaction
-	if err := s.outboxRepo.SaveOutboxEntryWithTx(ctx, tx, outboxEntry); err != nil {
-		tx.Rollback()
-		s.log.Errorf("Failed to save outbox entry: %v", err)
+	// Create the would-be order domain object
+	order := &domain.Order{
+		CustomerID: customerID,
+		Items:      items,
+		Status:     domain.OrderStatusPending,
+	}
+
+	// Validate the order, check its customer and items and compute its total
+	if err := s.prepareOrder(ctx, order, source); err != nil {
 		return nil, err
 	}
 
-	// Commit transaction
-	if err := tx.Commit().Error; err != nil {
-		s.log.Errorf("Failed to commit transaction: %v", err)
+	return order, nil
+}
+
+// Reorder creates a new order for the customer of an existing order with the same items.
+// The existing order is left as it is. A snapshot reorder keeps the prices the items were
+// bought at, a live one takes the catalog's current prices; either way the new order is
+// created like any other, so the price policy still applies to it.
+func (s *DBOrderService) Reorder(ctx context.Context, orderID string, source domain.PriceSource) (*domain.Order, error) {
+	s.log.Infof("DBOrderService_Reorder orderID=%s priceSource=%s", orderID, source)
+
+	if !source.IsValid() {
+		return nil, fmt.Errorf("%w: unknown price source %q", domain.ErrInvalidArgument, source)
+	}
+
+	// Repeat every item, not just those embedded in a returned order
+	original, err := s.repo.GetOrder(ctx, orderID, 0)
+	if err != nil {
 		return nil, err
 	}
 
-	return createdOrder, nil
+	items := append([]domain.OrderItem(nil), original.Items...)
+	if source == domain.PriceSourceLive {
+		items, err = s.catalog.CurrentPrices(ctx, items)
+		if err != nil {
+			s.log.Errorf("Failed to read current prices from the product catalog: %v", err)
+			return nil, err
+		}
+	}
+
+	return s.CreateOrder(ctx, original.CustomerID, items)
 }
 
 // GetOrder retrieves an order by ID using the repository
@@ -78,7 +423,15 @@ func (s *DBOrderService) GetOrder(ctx context.Context, orderID string) (*domain.
 	s.log.Infof("DBOrderService_GetOrder orderID=%s", orderID)
 
 	// Use the repository to retrieve the order
-	return s.repo.GetOrder(ctx, orderID)
+	return s.repo.GetOrder(ctx, orderID, s.items.embedLimit())
+}
+
+// ListOrderItems retrieves a page of an order's items using the repository
+func (s *DBOrderService) ListOrderItems(ctx context.Context, orderID string, pageSize int32, pageToken string) ([]domain.OrderItem, string, error) {
+	s.log.Infof("DBOrderService_ListOrderItems orderID=%s pageSize=%d pageToken=%s",
+		orderID, pageSize, pageToken)
+
+	return s.repo.ListOrderItems(ctx, orderID, pageSize, pageToken)
 }
 
 // ListOrders retrieves a list of orders using the repository
@@ -87,49 +440,282 @@ func (s *DBOrderService) ListOrders(ctx context.Context, customerID string, page
 		customerID, pageSize, pageToken)
 
 	// Use the repository to list orders
-	return s.repo.ListOrders(ctx, customerID, pageSize, pageToken)
+	return s.repo.ListOrders(ctx, customerID, pageSize, pageToken, s.items.embedLimit())
 }
 
-// UpdateOrderStatus updates the status of an order using the repository
+// CountOrders counts a customer's orders using the repository
+func (s *DBOrderService) CountOrders(ctx context.Context, customerID string) (int64, error) {
+	s.log.Infof("DBOrderService_CountOrders customerID=%s", customerID)
+
+	return s.repo.CountOrders(ctx, customerID)
+}
+
+// UpdateOrderStatus updates the status of an order using the repository. Only the moves
+// allowed by OrderStatus.CanTransitionTo are accepted; cancelling goes through CancelOrder
+// so the order's stock is returned, and shipping through ShipOrder without tracking information.
 func (s *DBOrderService) UpdateOrderStatus(ctx context.Context, orderID string, status domain.OrderStatus) (*domain.Order, error) {
 	s.log.Infof("DBOrderService_UpdateOrderStatus orderID=%s status=%d",
 		orderID, int(status))
 
-	// Begin transaction
-	tx, err := s.repo.BeginTransaction(ctx)
+	if !status.IsValid() {
+		return nil, fmt.Errorf("%w: unknown status %d", domain.ErrInvalidArgument, status)
+	}
+
+	switch status {
+	case domain.OrderStatusCancelled:
+		return s.CancelOrder(ctx, orderID)
+	case domain.OrderStatusShipped:
+		return s.ShipOrder(ctx, orderID, domain.Shipment{})
+	}
+
+	// Update the order and write its outbox entry in one transaction
+	var updatedOrder *domain.Order
+	err := s.withTx(ctx, "update_order_status", func(tx *gorm.DB) error {
+		// Lock the order so the transition is checked against the status it is applied to
+		current, err := s.repo.GetOrderForUpdateWithTx(ctx, tx, orderID, s.items.embedLimit())
+		if err != nil {
+			return err
+		}
+
+		if err := current.Status.CheckTransition(status); err != nil {
+			return err
+		}
+
+		updatedOrder, err = s.repo.UpdateOrderStatusWithTx(ctx, tx, orderID, status, s.items.embedLimit())
+		if err != nil {
+			s.log.Errorf("Failed to update order status: %v", err)
+			return err
+		}
+
+		// Create outbox entry for order status updated event
+		outboxEntry, err := repository.NewOrderStatusUpdatedOutboxEntry(updatedOrder)
+		if err != nil {
+			s.log.Errorf("Failed to create outbox entry: %v", err)
+			return err
+		}
+
+		// Save outbox entry within transaction
+		if err := s.outboxRepo.SaveOutboxEntryWithTx(ctx, tx, outboxEntry); err != nil {
+			s.log.Errorf("Failed to save outbox entry: %v", err)
+			return err
+		}
+		return nil
+	})
 	if err != nil {
-		s.log.Errorf("Failed to begin transaction: %v", err)
 		return nil, err
 	}
 
-	// Update order status within transaction
-	updatedOrder, err := s.repo.UpdateOrderStatusWithTx(ctx, tx, orderID, status)
+	s.forgetCachedOrder(ctx, orderID)
+	return updatedOrder, nil
+}
+
+// CancelOrder cancels an order that has not shipped yet and returns its items to stock.
+// The status change and the order_cancelled outbox entry commit together. The stock lives
+// in the product database, so it is returned just before the commit: a failed restock rolls
+// the cancellation back, and a failed commit takes the returned stock again.
+func (s *DBOrderService) CancelOrder(ctx context.Context, orderID string) (*domain.Order, error) {
+	s.log.Infof("DBOrderService_CancelOrder orderID=%s", orderID)
+
+	var cancelledOrder *domain.Order
+	var restocked bool
+	err := s.withTx(ctx, "cancel_order", func(tx *gorm.DB) error {
+		// Lock the order so a concurrent status change cannot slip in between the check and the update
+		order, err := s.repo.GetOrderForUpdateWithTx(ctx, tx, orderID, s.items.embedLimit())
+		if err != nil {
+			return err
+		}
+
+		if err := order.Status.CheckTransition(domain.OrderStatusCancelled); err != nil {
+			return err
+		}
+
+		// Every item is read back: all of them are restocked and listed in the cancelled event
+		cancelledOrder, err = s.repo.UpdateOrderStatusWithTx(ctx, tx, orderID, domain.OrderStatusCancelled, 0)
+		if err != nil {
+			s.log.Errorf("Failed to cancel order: %v", err)
+			return err
+		}
+
+		// Create outbox entry for order cancelled event
+		outboxEntry, err := repository.NewOrderCancelledOutboxEntry(cancelledOrder)
+		if err != nil {
+			s.log.Errorf("Failed to create outbox entry: %v", err)
+			return err
+		}
+
+		// Save outbox entry within transaction
+		if err := s.outboxRepo.SaveOutboxEntryWithTx(ctx, tx, outboxEntry); err != nil {
+			s.log.Errorf("Failed to save outbox entry: %v", err)
+			return err
+		}
+
+		// Restock last, so nothing that can still fail inside the transaction follows it
+		if err := s.stock.Release(ctx, cancelledOrder.Items); err != nil {
+			s.log.Errorf("Failed to restock cancelled order: %v", err)
+			return err
+		}
+		restocked = true
+		return nil
+	})
 	if err != nil {
-		tx.Rollback()
-		s.log.Errorf("Failed to update order status: %v", err)
+		// The order stays as it was, so take back the stock if it was already returned
+		if restocked {
+			if reserveErr := s.stock.Reserve(context.WithoutCancel(ctx), cancelledOrder.Items); reserveErr != nil {
+				s.log.Errorf("Failed to take back stock of order that was not cancelled: %v", reserveErr)
+			}
+		}
 		return nil, err
 	}
 
-	// Create outbox entry for order status updated event
-	outboxEntry, err := repository.NewOrderStatusUpdatedOutboxEntry(updatedOrder)
-	if err != nil {
-		tx.Rollback()
-		s.log.Errorf("Failed to create outbox entry: %v", err)
+	s.forgetCachedOrder(ctx, orderID)
+	return cancelledOrder, nil
+}
+
+// ShipOrder marks an order as shipped with its tracking information and writes an
+// order_shipped outbox entry in place of the generic status updated event
+func (s *DBOrderService) ShipOrder(ctx context.Context, orderID string, shipment domain.Shipment) (*domain.Order, error) {
+	s.log.Infof("DBOrderService_ShipOrder orderID=%s carrier=%s", orderID, shipment.Carrier)
+
+	if err := s.shipments.Check(shipment); err != nil {
 		return nil, err
 	}
 
-	// Save outbox entry within transaction
-	if err := s.outboxRepo.SaveOutboxEntryWithTx(ctx, tx, outboxEntry); err != nil {
-		tx.Rollback()
-		s.log.Errorf("Failed to save outbox entry: %v", err)
+	var shippedOrder *domain.Order
+	err := s.withTx(ctx, "ship_order", func(tx *gorm.DB) error {
+		// Lock the order so the transition is checked against the status it is applied to
+		current, err := s.repo.GetOrderForUpdateWithTx(ctx, tx, orderID, s.items.embedLimit())
+		if err != nil {
+			return err
+		}
+
+		if err := current.Status.CheckTransition(domain.OrderStatusShipped); err != nil {
+			return err
+		}
+
+		shippedOrder, err = s.repo.ShipOrderWithTx(ctx, tx, orderID, shipment, s.items.embedLimit())
+		if err != nil {
+			s.log.Errorf("Failed to ship order: %v", err)
+			return err
+		}
+
+		// Create outbox entry for order shipped event
+		outboxEntry, err := repository.NewOrderShippedOutboxEntry(shippedOrder)
+		if err != nil {
+			s.log.Errorf("Failed to create outbox entry: %v", err)
+			return err
+		}
+
+		// Save outbox entry within transaction
+		if err := s.outboxRepo.SaveOutboxEntryWithTx(ctx, tx, outboxEntry); err != nil {
+			s.log.Errorf("Failed to save outbox entry: %v", err)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	// Commit transaction
-	if err := tx.Commit().Error; err != nil {
-		s.log.Errorf("Failed to commit transaction: %v", err)
+	s.forgetCachedOrder(ctx, orderID)
+	return shippedOrder, nil
+}
+
+// forgetCachedOrder drops the cached idempotency replay of an order whose status changed,
+// so a later retry is answered with the current order from the database
+func (s *DBOrderService) forgetCachedOrder(ctx context.Context, orderID string) {
+	if err := s.results.Forget(context.WithoutCancel(ctx), orderID); err != nil {
+		s.log.Errorf("Failed to drop cached order orderID=%s: %v", orderID, err)
+	}
+}
+
+// CountOrdersByPeriod counts orders created in [from, to) grouped by time bucket using the repository
+func (s *DBOrderService) CountOrdersByPeriod(ctx context.Context, customerID string, bucket domain.TimeBucket, from, to time.Time) ([]domain.PeriodCount, error) {
+	s.log.Infof("DBOrderService_CountOrdersByPeriod customerID=%s bucket=%s from=%s to=%s",
+		customerID, bucket, from.Format(time.RFC3339), to.Format(time.RFC3339))
+
+	// Validate the query
+	if !bucket.IsValid() {
+		return nil, fmt.Errorf("%w: unknown bucket %q", domain.ErrInvalidArgument, bucket)
+	}
+
+	if !from.Before(to) {
+		return nil, fmt.Errorf("%w: from must be before to", domain.ErrInvalidArgument)
+	}
+
+	if to.Sub(from) > MaxTimeSeriesDays*24*time.Hour {
+		return nil, fmt.Errorf("%w: range must not exceed %d days", domain.ErrInvalidArgument, MaxTimeSeriesDays)
+	}
+
+	// Use the repository to count orders
+	return s.repo.CountOrdersByPeriod(ctx, customerID, bucket, from, to)
+}
+
+// GetOrderEvents retrieves the outbox entries written for an order using the outbox repository
+func (s *DBOrderService) GetOrderEvents(ctx context.Context, orderID string) ([]OrderEvent, error) {
+	s.log.Infof("DBOrderService_GetOrderEvents orderID=%s", orderID)
+
+	// Use the outbox repository to list the entries
+	entries, err := s.outboxRepo.GetOutboxEntries(ctx, orderID)
+	if err != nil {
 		return nil, err
 	}
 
-	return updatedOrder, nil
+	orderEvents := make([]OrderEvent, len(entries))
+	for i, entry := range entries {
+		orderEvents[i] = orderEventFromOutbox(entry)
+	}
+	return orderEvents, nil
+}
+
+// ReplayEvents re-publishes the order events created in [from, to), optionally for a single order,
+// by writing copies of their outbox entries. The original entries are left untouched.
+// Copies go to the configured replay topic when set, otherwise to the live topic.
+func (s *DBOrderService) ReplayEvents(ctx context.Context, aggregateID string, from, to time.Time) (int, error) {
+	s.log.Infof("DBOrderService_ReplayEvents aggregateID=%s from=%s to=%s topic=%s",
+		aggregateID, from.Format(time.RFC3339), to.Format(time.RFC3339), s.replay.Topic)
+
+	// Validate the range
+	if !from.Before(to) {
+		return 0, fmt.Errorf("%w: from must be before to", domain.ErrInvalidArgument)
+	}
+
+	if to.Sub(from) > MaxReplayDays*24*time.Hour {
+		return 0, fmt.Errorf("%w: range must not exceed %d days", domain.ErrInvalidArgument, MaxReplayDays)
+	}
+
+	// Select the live entries; earlier replay copies on a replay topic are not picked up again
+	entries, err := s.outboxRepo.FindOutboxEntries(ctx, string(repository.AggregateTypeOrder), aggregateID, from, to, MaxReplayEvents+1)
+	if err != nil {
+		s.log.Errorf("Failed to find outbox entries: %v", err)
+		return 0, err
+	}
+
+	if len(entries) > MaxReplayEvents {
+		return 0, fmt.Errorf("%w: more than %d events match, narrow the range", domain.ErrInvalidArgument, MaxReplayEvents)
+	}
+
+	if len(entries) == 0 {
+		return 0, nil
+	}
+
+	aggregateType := string(repository.AggregateTypeOrder)
+	if s.replay.Topic != "" {
+		aggregateType = s.replay.Topic
+	}
+
+	// Save the copies in one transaction
+	err = s.withTx(ctx, "replay_events", func(tx *gorm.DB) error {
+		for _, entry := range entries {
+			if err := s.outboxRepo.SaveOutboxEntryWithTx(ctx, tx, repository.NewReplayOutboxEntry(entry, aggregateType)); err != nil {
+				s.log.Errorf("Failed to save replay outbox entry: %v", err)
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(entries), nil
 }
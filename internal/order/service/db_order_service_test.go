@@ -0,0 +1,701 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	productv1 "go-bootiful-ordering/gen/product/v1"
+	"go-bootiful-ordering/internal/order/domain"
+	"go-bootiful-ordering/internal/order/events"
+	"go-bootiful-ordering/internal/order/repository"
+	"go-bootiful-ordering/internal/pkg/idgen"
+	"go-bootiful-ordering/internal/pkg/metrics/metricstest"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"gorm.io/gorm"
+)
+
+// recordingOrderRepository counts the transactions and orders the service asks for and
+// serves GetOrder from orders; the other methods are not expected to be called. Without
+// a db, transactions fail to begin.
+type recordingOrderRepository struct {
+	repository.OrderRepository
+	db           *gorm.DB
+	orders       map[string]*domain.Order
+	transactions int
+	created      int
+}
+
+func (r *recordingOrderRepository) BeginTransaction(ctx context.Context) (*gorm.DB, error) {
+	r.transactions++
+	if r.db == nil {
+		return nil, errors.New("no database in tests")
+	}
+	tx := r.db.WithContext(ctx).Begin()
+	return tx, tx.Error
+}
+
+func (r *recordingOrderRepository) CreateOrderWithTx(ctx context.Context, tx *gorm.DB, order *domain.Order) (*domain.Order, error) {
+	r.created++
+	order.ID = fmt.Sprintf("order-%d", r.created)
+	return order, nil
+}
+
+func (r *recordingOrderRepository) GetOrder(ctx context.Context, orderID string, maxItems int) (*domain.Order, error) {
+	if order, ok := r.orders[orderID]; ok {
+		return order, nil
+	}
+	return nil, domain.ErrOrderNotFound
+}
+
+// recordingStockReserver counts the reservations and releases the service asks for,
+// failing reservations with err
+type recordingStockReserver struct {
+	reserved int
+	released int
+	err      error
+}
+
+func (r *recordingStockReserver) Reserve(ctx context.Context, items []domain.OrderItem) error {
+	r.reserved++
+	return r.err
+}
+
+func (r *recordingStockReserver) Release(ctx context.Context, items []domain.OrderItem) error {
+	r.released++
+	return nil
+}
+
+// racingIdempotencyRepository plays a concurrent request holding the key: the key is not
+// committed when it is first looked up, saving it then finds the winner's entry, and
+// looking it up again returns the winner's order
+type racingIdempotencyRepository struct {
+	winner string
+	finds  int
+	saves  int
+}
+
+func (r *racingIdempotencyRepository) FindOrderID(ctx context.Context, customerID, key string, since time.Time) (string, error) {
+	r.finds++
+	if r.finds == 1 {
+		return "", nil
+	}
+	return r.winner, nil
+}
+
+func (r *racingIdempotencyRepository) SaveKeyWithTx(ctx context.Context, tx *gorm.DB, customerID, key, orderID string, expiredBefore time.Time) (bool, error) {
+	r.saves++
+	return false, nil
+}
+
+// fakeProductClient serves BatchGetProducts from a map; the other methods are not expected
+// to be called
+type fakeProductClient struct {
+	productv1.ProductServiceClient
+	products map[string]*productv1.Product
+}
+
+func (c *fakeProductClient) BatchGetProducts(ctx context.Context, in *productv1.BatchGetProductsRequest, opts ...grpc.CallOption) (*productv1.BatchGetProductsResponse, error) {
+	resp := &productv1.BatchGetProductsResponse{}
+	for _, id := range in.ProductIds {
+		if product, ok := c.products[id]; ok {
+			resp.Products = append(resp.Products, product)
+		}
+	}
+	return resp, nil
+}
+
+// newTestService creates a DBOrderService with no database, checking items against catalog
+// and recording metrics on a private registry
+func newTestService(repo repository.OrderRepository, stock StockReserver, catalog ProductCatalog) *DBOrderService {
+	return NewDBOrderService(zap.NewNop().Sugar(), repo, nil, nil, ReplayConfig{}, stock, catalog, NoopCustomerValidator{},
+		IdempotencyConfig{}, NoopIdempotencyCache{}, AmountConfig{}, ProductIDConfig{}, ShipmentConfig{}, ItemConfig{}, metricstest.NewRecorder().Metrics)
+}
+
+func TestCreateOrderRejectsOnlyZeroQuantityItems(t *testing.T) {
+	repo := &recordingOrderRepository{}
+	stock := &recordingStockReserver{}
+	svc := newTestService(repo, stock, NoopProductCatalog{})
+
+	order, err := svc.CreateOrder(context.Background(), "customer-1", []domain.OrderItem{
+		{ProductID: "product-1", Quantity: 0, Price: 100},
+		{ProductID: "product-2", Quantity: 0, Price: 200},
+	})
+
+	if !errors.Is(err, domain.ErrInvalidArgument) || !strings.Contains(err.Error(), "items[0]") || !strings.Contains(err.Error(), "items[1]") {
+		t.Fatalf("CreateOrder() error = %v, want ErrInvalidArgument naming items[0] and items[1]", err)
+	}
+	if order != nil {
+		t.Errorf("CreateOrder() order = %+v, want nil", order)
+	}
+	if repo.transactions != 0 || repo.created != 0 {
+		t.Errorf("CreateOrder() opened %d transactions and created %d orders, want none", repo.transactions, repo.created)
+	}
+	if stock.reserved != 0 {
+		t.Errorf("CreateOrder() reserved stock %d times, want none", stock.reserved)
+	}
+}
+
+func TestPreviewOrderMergesRepeatsAfterPricing(t *testing.T) {
+	client := &fakeProductClient{products: map[string]*productv1.Product{
+		"product-1": {Id: "product-1", Price: 1000},
+		"product-2": {Id: "product-2", Price: 1500},
+	}}
+	items := []domain.OrderItem{
+		{ProductID: "product-1", Quantity: 2, Price: 1000},
+		{ProductID: "product-2", Quantity: 1, Price: 1500},
+		{ProductID: "product-1", Quantity: 3, Price: 900},
+	}
+
+	tests := []struct {
+		name    string
+		policy  string
+		catalog bool
+		want    []domain.OrderItem
+		total   int64
+		wantErr string
+	}{
+		{
+			name:    "override prices the repeats alike and merges them",
+			policy:  PricePolicyOverride,
+			catalog: true,
+			want:    []domain.OrderItem{{ProductID: "product-1", Quantity: 5, Price: 1000}, {ProductID: "product-2", Quantity: 1, Price: 1500}},
+			total:   6500,
+		},
+		{name: "enforce rejects the repeat with a stale price", policy: PricePolicyEnforce, catalog: true, wantErr: "items[2]: price 900"},
+		{name: "without a catalog repeats must agree", wantErr: "items[2]: product_id \"product-1\" is repeated with a different price"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var catalog ProductCatalog = NoopProductCatalog{}
+			if tt.catalog {
+				grpcCatalog, err := NewGRPCProductCatalog(client, tt.policy)
+				if err != nil {
+					t.Fatalf("NewGRPCProductCatalog() error = %v", err)
+				}
+				catalog = grpcCatalog
+			}
+			svc := newTestService(&recordingOrderRepository{}, &recordingStockReserver{}, catalog)
+
+			order, err := svc.PreviewOrder(context.Background(), "customer-1", append([]domain.OrderItem(nil), items...), domain.PriceSourceSnapshot)
+			if tt.wantErr != "" {
+				if !errors.Is(err, domain.ErrInvalidArgument) || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("PreviewOrder() error = %v, want ErrInvalidArgument containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("PreviewOrder() error = %v", err)
+			}
+			if !reflect.DeepEqual(order.Items, tt.want) || order.TotalAmount != tt.total {
+				t.Errorf("PreviewOrder() = %+v totalling %d, want %+v totalling %d", order.Items, order.TotalAmount, tt.want, tt.total)
+			}
+		})
+	}
+}
+
+func TestCreateOrderLosingIdempotencyRaceReservesNoStock(t *testing.T) {
+	db, fake := newFakeDB(t)
+	winner := &domain.Order{ID: "order-winner", CustomerID: "customer-1", Status: domain.OrderStatusPending}
+	repo := &recordingOrderRepository{db: db, orders: map[string]*domain.Order{winner.ID: winner}}
+	stock := &recordingStockReserver{}
+	keys := &racingIdempotencyRepository{winner: winner.ID}
+	svc := newTestService(repo, stock, NoopProductCatalog{})
+	svc.idempotencyRepo = keys
+
+	ctx := WithIdempotencyKey(context.Background(), "key-1")
+	order, err := svc.CreateOrder(ctx, "customer-1", []domain.OrderItem{{ProductID: "product-1", Quantity: 1, Price: 100}})
+	if err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+	if order != winner {
+		t.Errorf("CreateOrder() = %+v, want the winner's order", order)
+	}
+	if stock.reserved != 0 || stock.released != 0 {
+		t.Errorf("CreateOrder() reserved stock %d times and released it %d times, want neither", stock.reserved, stock.released)
+	}
+	if keys.saves != 1 {
+		t.Errorf("CreateOrder() saved the key %d times, want 1", keys.saves)
+	}
+	if begins, commits, rollbacks, _ := fake.counts(); begins != 1 || commits != 0 || rollbacks != 1 {
+		t.Errorf("transactions begun, committed, rolled back = %d, %d, %d, want 1, 0, 1", begins, commits, rollbacks)
+	}
+}
+
+func TestWithTxCountsCommitsAndRollbacks(t *testing.T) {
+	failure := errors.New("operation failed")
+
+	tests := []struct {
+		name      string
+		fn        func(tx *gorm.DB) error
+		commits   float64
+		rollbacks float64
+	}{
+		{name: "success commits", fn: func(tx *gorm.DB) error { return nil }, commits: 1},
+		{name: "failure rolls back", fn: func(tx *gorm.DB) error { return failure }, rollbacks: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, fake := newFakeDB(t)
+			rec := metricstest.NewRecorder()
+			svc := newTestService(&recordingOrderRepository{db: db}, &recordingStockReserver{}, NoopProductCatalog{})
+			svc.metrics = rec.Metrics
+
+			err := svc.withTx(context.Background(), "test_operation", tt.fn)
+			if tt.rollbacks > 0 && !errors.Is(err, failure) {
+				t.Fatalf("withTx() error = %v, want %v", err, failure)
+			}
+			if tt.rollbacks == 0 && err != nil {
+				t.Fatalf("withTx() error = %v", err)
+			}
+
+			labels := prometheus.Labels{"operation": "test_operation"}
+			if got := rec.Counter("db_transactions_total", labels); got != tt.commits {
+				t.Errorf("db_transactions_total = %v, want %v", got, tt.commits)
+			}
+			if got := rec.Counter("db_transaction_rollbacks_total", labels); got != tt.rollbacks {
+				t.Errorf("db_transaction_rollbacks_total = %v, want %v", got, tt.rollbacks)
+			}
+			if _, commits, rollbacks, _ := fake.counts(); float64(commits) != tt.commits || float64(rollbacks) != tt.rollbacks {
+				t.Errorf("database saw %d commits and %d rollbacks, want %v and %v", commits, rollbacks, tt.commits, tt.rollbacks)
+			}
+		})
+	}
+}
+
+func TestFailedCreateOrderCountsOneRollback(t *testing.T) {
+	db, _ := newFakeDB(t)
+	rec := metricstest.NewRecorder()
+	stock := &recordingStockReserver{err: fmt.Errorf("%w: product-1", domain.ErrInsufficientStock)}
+	svc := newTestService(&recordingOrderRepository{db: db}, stock, NoopProductCatalog{})
+	svc.metrics = rec.Metrics
+
+	_, err := svc.CreateOrder(context.Background(), "customer-1", []domain.OrderItem{{ProductID: "product-1", Quantity: 1, Price: 100}})
+	if !errors.Is(err, domain.ErrInsufficientStock) {
+		t.Fatalf("CreateOrder() error = %v, want ErrInsufficientStock", err)
+	}
+
+	labels := prometheus.Labels{"operation": "create_order"}
+	if got := rec.Counter("db_transaction_rollbacks_total", labels); got != 1 {
+		t.Errorf("db_transaction_rollbacks_total = %v, want 1", got)
+	}
+	if got := rec.Counter("db_transactions_total", labels); got != 0 {
+		t.Errorf("db_transactions_total = %v, want 0", got)
+	}
+	if got := rec.Counter("orders_created_total", nil); got != 0 {
+		t.Errorf("orders_created_total = %v, want 0", got)
+	}
+	if stock.released != 0 {
+		t.Errorf("CreateOrder() released stock %d times after a failed reservation, want 0", stock.released)
+	}
+}
+
+func TestOrderWritesBeginOneTransaction(t *testing.T) {
+	items := []domain.OrderItem{{ProductID: "product-1", Quantity: 2, Price: 100}, {ProductID: "product-2", Quantity: 1, Price: 50}}
+
+	tests := []struct {
+		name      string
+		storage   repository.ItemStorage
+		write     func(ctx context.Context, svc *DBOrderService) error
+		commits   int
+		rollbacks int
+	}{
+		{
+			name:    "create with table items",
+			storage: repository.ItemStorageTable,
+			write: func(ctx context.Context, svc *DBOrderService) error {
+				_, err := svc.CreateOrder(WithIdempotencyKey(ctx, "key-1"), "customer-1", items)
+				return err
+			},
+			commits: 1,
+		},
+		{
+			name:    "create with jsonb items",
+			storage: repository.ItemStorageJSONB,
+			write: func(ctx context.Context, svc *DBOrderService) error {
+				_, err := svc.CreateOrder(WithIdempotencyKey(ctx, "key-1"), "customer-1", items)
+				return err
+			},
+			commits: 1,
+		},
+		{
+			name:    "status update of a missing order",
+			storage: repository.ItemStorageTable,
+			write: func(ctx context.Context, svc *DBOrderService) error {
+				_, err := svc.UpdateOrderStatus(ctx, "order-1", domain.OrderStatusProcessing)
+				return err
+			},
+			rollbacks: 1,
+		},
+		{
+			name:    "cancellation of a missing order",
+			storage: repository.ItemStorageTable,
+			write: func(ctx context.Context, svc *DBOrderService) error {
+				_, err := svc.CancelOrder(ctx, "order-1")
+				return err
+			},
+			rollbacks: 1,
+		},
+		{
+			name:    "shipment of a missing order",
+			storage: repository.ItemStorageTable,
+			write: func(ctx context.Context, svc *DBOrderService) error {
+				_, err := svc.ShipOrder(ctx, "order-1", domain.Shipment{TrackingNumber: "1Z999", Carrier: "UPS"})
+				return err
+			},
+			rollbacks: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, fake := newFakeDB(t)
+			repo := repository.NewGormOrderRepository(db, idgen.NewSequenceGenerator("order-"), tt.storage)
+			svc := newTestService(repo, &recordingStockReserver{}, NoopProductCatalog{})
+			svc.outboxRepo = repository.NewGormOutboxRepository(db, idgen.NewSequenceGenerator("event-"))
+			svc.idempotencyRepo = repository.NewGormIdempotencyRepository(db)
+
+			err := tt.write(context.Background(), svc)
+			if tt.rollbacks == 0 && err != nil {
+				t.Fatalf("write error = %v", err)
+			}
+
+			begins, commits, rollbacks, nested := fake.counts()
+			if begins != 1 || nested != 0 {
+				t.Errorf("write began %d transactions, %d of them nested, want exactly one", begins, nested)
+			}
+			if commits != tt.commits || rollbacks != tt.rollbacks {
+				t.Errorf("write committed %d and rolled back %d transactions, want %d and %d", commits, rollbacks, tt.commits, tt.rollbacks)
+			}
+		})
+	}
+}
+
+// entriesOutboxRepository serves GetOutboxEntries from entries, keyed by aggregate ID
+type entriesOutboxRepository struct {
+	repository.OutboxRepository
+	entries map[string][]*repository.OutboxModel
+}
+
+func (r *entriesOutboxRepository) GetOutboxEntries(ctx context.Context, aggregateID string) ([]*repository.OutboxModel, error) {
+	return r.entries[aggregateID], nil
+}
+
+func TestGetOrderEventsListsTheOrdersOutboxEntries(t *testing.T) {
+	order := &domain.Order{ID: "order-1", CustomerID: "customer-1", Status: domain.OrderStatusPending}
+	created, err := repository.NewOrderCreatedOutboxEntry(order)
+	if err != nil {
+		t.Fatalf("NewOrderCreatedOutboxEntry() error = %v", err)
+	}
+	order.Status = domain.OrderStatusProcessing
+	updated, err := repository.NewOrderStatusUpdatedOutboxEntry(order)
+	if err != nil {
+		t.Fatalf("NewOrderStatusUpdatedOutboxEntry() error = %v", err)
+	}
+	created.ID, updated.ID = "event-1", "event-2"
+	created.TraceID = "trace-1"
+
+	outbox := &entriesOutboxRepository{entries: map[string][]*repository.OutboxModel{order.ID: {created, updated}}}
+	svc := newTestService(&recordingOrderRepository{}, &recordingStockReserver{}, NoopProductCatalog{})
+	svc.outboxRepo = outbox
+
+	got, err := svc.GetOrderEvents(context.Background(), order.ID)
+	if err != nil {
+		t.Fatalf("GetOrderEvents() error = %v", err)
+	}
+	want := []OrderEvent{orderEventFromOutbox(created), orderEventFromOutbox(updated)}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("GetOrderEvents() = %+v, want %+v", got, want)
+	}
+	if got[0].EventType != events.EventTypeOrderCreated || got[0].TraceID != "trace-1" || got[1].EventType != events.EventTypeOrderStatusUpdated || got[1].PayloadType != events.PayloadTypeOrderStatusUpdatedV1 {
+		t.Errorf("GetOrderEvents() = %+v, want the created event then the status updated event", got)
+	}
+
+	if got, err := svc.GetOrderEvents(context.Background(), "order-2"); err != nil || len(got) != 0 {
+		t.Errorf("GetOrderEvents(order-2) = %v, %v, want no events", got, err)
+	}
+}
+
+// replayOutboxRepository serves FindOutboxEntries from entries, recording the aggregate
+// and limit asked for, and records the entries saved
+type replayOutboxRepository struct {
+	repository.OutboxRepository
+	entries     []*repository.OutboxModel
+	aggregateID string
+	limit       int
+	saved       []*repository.OutboxModel
+}
+
+func (r *replayOutboxRepository) FindOutboxEntries(ctx context.Context, aggregateType, aggregateID string, from, to time.Time, limit int) ([]*repository.OutboxModel, error) {
+	r.aggregateID, r.limit = aggregateID, limit
+	if aggregateType != string(repository.AggregateTypeOrder) {
+		return nil, nil
+	}
+	return r.entries, nil
+}
+
+func (r *replayOutboxRepository) SaveOutboxEntryWithTx(ctx context.Context, tx *gorm.DB, entry *repository.OutboxModel) error {
+	r.saved = append(r.saved, entry)
+	return nil
+}
+
+func TestReplayEventsRepublishesTheSelectedEntries(t *testing.T) {
+	order := &domain.Order{ID: "order-1", CustomerID: "customer-1", Status: domain.OrderStatusPending}
+	created, err := repository.NewOrderCreatedOutboxEntry(order)
+	if err != nil {
+		t.Fatalf("NewOrderCreatedOutboxEntry() error = %v", err)
+	}
+	order.Status = domain.OrderStatusCancelled
+	cancelled, err := repository.NewOrderCancelledOutboxEntry(order)
+	if err != nil {
+		t.Fatalf("NewOrderCancelledOutboxEntry() error = %v", err)
+	}
+	created.ID, cancelled.ID = "event-1", "event-2"
+	from, to := time.Now().Add(-time.Hour), time.Now()
+
+	tests := []struct {
+		name      string
+		topic     string
+		wantTopic string
+	}{
+		{name: "replay topic", topic: "order-replay", wantTopic: "order-replay"},
+		{name: "live topic", topic: "", wantTopic: string(repository.AggregateTypeOrder)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, fake := newFakeDB(t)
+			outbox := &replayOutboxRepository{entries: []*repository.OutboxModel{created, cancelled}}
+			svc := newTestService(&recordingOrderRepository{db: db}, &recordingStockReserver{}, NoopProductCatalog{})
+			svc.outboxRepo, svc.replay = outbox, ReplayConfig{Topic: tt.topic}
+
+			replayed, err := svc.ReplayEvents(context.Background(), order.ID, from, to)
+			if err != nil {
+				t.Fatalf("ReplayEvents() error = %v", err)
+			}
+			if replayed != 2 || len(outbox.saved) != 2 {
+				t.Fatalf("ReplayEvents() = %d with %d entries saved, want 2 and 2", replayed, len(outbox.saved))
+			}
+			if outbox.aggregateID != order.ID || outbox.limit != MaxReplayEvents+1 {
+				t.Errorf("FindOutboxEntries() asked for %q with limit %d, want %q with %d", outbox.aggregateID, outbox.limit, order.ID, MaxReplayEvents+1)
+			}
+			for i, original := range []*repository.OutboxModel{created, cancelled} {
+				saved := outbox.saved[i]
+				if saved == original || saved.AggregateType != tt.wantTopic || saved.EventType != original.EventType || string(saved.Payload) != string(original.Payload) {
+					t.Errorf("saved[%d] = %+v, want a copy of %s on %s", i, saved, original.ID, tt.wantTopic)
+				}
+				// The live entries are neither changed nor written again
+				if original.AggregateType != string(repository.AggregateTypeOrder) {
+					t.Errorf("original %s moved to %s", original.ID, original.AggregateType)
+				}
+			}
+			if _, commits, rollbacks, _ := fake.counts(); commits != 1 || rollbacks != 0 {
+				t.Errorf("database saw %d commits and %d rollbacks, want 1 and 0", commits, rollbacks)
+			}
+		})
+	}
+}
+
+func TestReplayEventsRejectsUnboundedReplays(t *testing.T) {
+	now := time.Now()
+	many := make([]*repository.OutboxModel, MaxReplayEvents+1)
+	for i := range many {
+		many[i] = &repository.OutboxModel{AggregateType: string(repository.AggregateTypeOrder), AggregateID: "order-1"}
+	}
+
+	tests := []struct {
+		name     string
+		from, to time.Time
+		entries  []*repository.OutboxModel
+	}{
+		{name: "empty range", from: now, to: now},
+		{name: "inverted range", from: now, to: now.Add(-time.Hour)},
+		{name: "range too long", from: now.Add(-(MaxReplayDays*24 + 1) * time.Hour), to: now},
+		{name: "too many events", from: now.Add(-time.Hour), to: now, entries: many},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, fake := newFakeDB(t)
+			outbox := &replayOutboxRepository{entries: tt.entries}
+			svc := newTestService(&recordingOrderRepository{db: db}, &recordingStockReserver{}, NoopProductCatalog{})
+			svc.outboxRepo, svc.replay = outbox, ReplayConfig{Topic: "order-replay"}
+
+			if _, err := svc.ReplayEvents(context.Background(), "", tt.from, tt.to); !errors.Is(err, domain.ErrInvalidArgument) {
+				t.Errorf("ReplayEvents() error = %v, want ErrInvalidArgument", err)
+			}
+			if begins, _, _, _ := fake.counts(); len(outbox.saved) != 0 || begins != 0 {
+				t.Errorf("ReplayEvents() saved %d entries in %d transactions, want none", len(outbox.saved), begins)
+			}
+		})
+	}
+}
+
+func TestPreviewOrderPriceSource(t *testing.T) {
+	client := &fakeProductClient{products: map[string]*productv1.Product{"product-1": {Id: "product-1", Price: 1500}}}
+	items := []domain.OrderItem{{ProductID: "product-1", Quantity: 2, Price: 1000}}
+
+	tests := []struct {
+		name    string
+		source  domain.PriceSource
+		policy  string
+		catalog bool
+		want    int64
+		wantErr error
+	}{
+		{name: "snapshot keeps the sent price", source: domain.PriceSourceSnapshot, want: 1000},
+		{name: "snapshot is checked under the price policy", source: domain.PriceSourceSnapshot, policy: PricePolicyEnforce, catalog: true, wantErr: domain.ErrInvalidArgument},
+		{name: "live takes the current price", source: domain.PriceSourceLive, policy: PricePolicyEnforce, catalog: true, want: 1500},
+		{name: "live without a catalog", source: domain.PriceSourceLive, wantErr: ErrUnsupported},
+		{name: "unknown source", source: "cached", catalog: true, policy: PricePolicyOverride, wantErr: domain.ErrInvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var catalog ProductCatalog = NoopProductCatalog{}
+			if tt.catalog {
+				grpcCatalog, err := NewGRPCProductCatalog(client, tt.policy)
+				if err != nil {
+					t.Fatalf("NewGRPCProductCatalog() error = %v", err)
+				}
+				catalog = grpcCatalog
+			}
+			svc := newTestService(&recordingOrderRepository{}, &recordingStockReserver{}, catalog)
+
+			order, err := svc.PreviewOrder(context.Background(), "customer-1", append([]domain.OrderItem(nil), items...), tt.source)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("PreviewOrder() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("PreviewOrder() error = %v", err)
+			}
+			if order.Items[0].Price != tt.want || order.TotalAmount != 2*tt.want {
+				t.Errorf("PreviewOrder() = %+v totalling %d, want price %d", order.Items, order.TotalAmount, tt.want)
+			}
+		})
+	}
+}
+
+func TestReorderPriceSource(t *testing.T) {
+	// The product cost 1000 when the order was placed and costs 1500 now
+	client := &fakeProductClient{products: map[string]*productv1.Product{"product-1": {Id: "product-1", Price: 1500}}}
+
+	tests := []struct {
+		name    string
+		source  domain.PriceSource
+		policy  string
+		catalog bool
+		want    int64
+		wantErr error
+	}{
+		{name: "live takes the current price", source: domain.PriceSourceLive, policy: PricePolicyEnforce, catalog: true, want: 1500},
+		{name: "snapshot keeps the price paid", source: domain.PriceSourceSnapshot, want: 1000},
+		// The new order is created like any other, so the price policy applies to it
+		{name: "snapshot of a changed price is enforced", source: domain.PriceSourceSnapshot, policy: PricePolicyEnforce, catalog: true, wantErr: domain.ErrInvalidArgument},
+		{name: "snapshot of a changed price is overridden", source: domain.PriceSourceSnapshot, policy: PricePolicyOverride, catalog: true, want: 1500},
+		{name: "live without a catalog", source: domain.PriceSourceLive, wantErr: ErrUnsupported},
+		{name: "unknown source", source: "cached", wantErr: domain.ErrInvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, _ := newFakeDB(t)
+			placed := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+			historical := &domain.Order{
+				ID: "order-old", CustomerID: "customer-1", Status: domain.OrderStatusDelivered, TotalAmount: 2000,
+				Items: []domain.OrderItem{{ProductID: "product-1", Quantity: 2, Price: 1000}}, CreatedAt: placed, UpdatedAt: placed,
+			}
+			repo := &recordingOrderRepository{db: db, orders: map[string]*domain.Order{historical.ID: historical}}
+			var catalog ProductCatalog = NoopProductCatalog{}
+			if tt.catalog {
+				grpcCatalog, err := NewGRPCProductCatalog(client, tt.policy)
+				if err != nil {
+					t.Fatalf("NewGRPCProductCatalog() error = %v", err)
+				}
+				catalog = grpcCatalog
+			}
+			stock := &recordingStockReserver{}
+			svc := newTestService(repo, stock, catalog)
+			svc.outboxRepo = repository.NewGormOutboxRepository(db, idgen.NewSequenceGenerator("event-"))
+
+			order, err := svc.Reorder(context.Background(), historical.ID, tt.source)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Reorder() error = %v, want %v", err, tt.wantErr)
+				}
+				if repo.created != 0 || stock.reserved != 0 {
+					t.Errorf("failed reorder created %d orders and reserved stock %d times", repo.created, stock.reserved)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("Reorder() error = %v", err)
+				}
+				want := []domain.OrderItem{{ProductID: "product-1", Quantity: 2, Price: tt.want}}
+				if order.ID == historical.ID || order.CustomerID != "customer-1" || order.Status != domain.OrderStatusPending ||
+					!reflect.DeepEqual(order.Items, want) || order.TotalAmount != 2*tt.want {
+					t.Errorf("Reorder() = %+v, want a new pending order for customer-1 with %+v", order, want)
+				}
+			}
+
+			// The historical order keeps the price that was paid
+			stored, err := svc.GetOrder(context.Background(), historical.ID)
+			if err != nil {
+				t.Fatalf("GetOrder() error = %v", err)
+			}
+			if stored.Items[0].Price != 1000 || stored.TotalAmount != 2000 {
+				t.Errorf("historical order = %+v, want it at the price paid", stored)
+			}
+		})
+	}
+
+	t.Run("unknown order", func(t *testing.T) {
+		svc := newTestService(&recordingOrderRepository{}, &recordingStockReserver{}, NoopProductCatalog{})
+		if _, err := svc.Reorder(context.Background(), "order-missing", domain.PriceSourceSnapshot); !errors.Is(err, domain.ErrOrderNotFound) {
+			t.Errorf("Reorder() error = %v, want ErrOrderNotFound", err)
+		}
+	})
+}
+
+func TestProductCatalogCurrentPrices(t *testing.T) {
+	client := &fakeProductClient{products: map[string]*productv1.Product{
+		"product-1": {Id: "product-1", Price: 1500},
+		"product-2": {Id: "product-2", Price: 700, Status: productv1.ProductStatus_PRODUCT_STATUS_INACTIVE},
+	}}
+	sent := []domain.OrderItem{{ProductID: "product-1", Quantity: 2, Price: 1000}}
+
+	// Every policy reads the current price, leaving the caller's items as they were
+	for _, policy := range []string{PricePolicyEnforce, PricePolicyOverride} {
+		catalog, err := NewGRPCProductCatalog(client, policy)
+		if err != nil {
+			t.Fatalf("NewGRPCProductCatalog() error = %v", err)
+		}
+		items, err := catalog.CurrentPrices(context.Background(), sent)
+		if err != nil || len(items) != 1 || items[0].Price != 1500 || items[0].Quantity != 2 {
+			t.Errorf("CurrentPrices() under %s = %+v, %v, want the item at 1500", policy, items, err)
+		}
+		if sent[0].Price != 1000 {
+			t.Errorf("CurrentPrices() changed the caller's item to %+v", sent[0])
+		}
+
+		for _, id := range []string{"product-2", "product-3"} {
+			if _, err := catalog.CurrentPrices(context.Background(), []domain.OrderItem{{ProductID: id, Quantity: 1}}); !errors.Is(err, domain.ErrInvalidArgument) {
+				t.Errorf("CurrentPrices(%s) error = %v, want ErrInvalidArgument", id, err)
+			}
+		}
+	}
+
+	if _, err := (NoopProductCatalog{}).CurrentPrices(context.Background(), sent); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("NoopProductCatalog.CurrentPrices() error = %v, want ErrUnsupported", err)
+	}
+}
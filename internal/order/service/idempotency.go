@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	// IdempotencyHeader is the HTTP header carrying a CreateOrder idempotency key
+	IdempotencyHeader = "Idempotency-Key"
+	// IdempotencyMetadataKey is the gRPC metadata key carrying a CreateOrder idempotency key
+	IdempotencyMetadataKey = "idempotency-key"
+	// MaxIdempotencyKeyLength matches the size of the order_idempotency.idempotency_key column
+	MaxIdempotencyKeyLength = 255
+	// DefaultIdempotencyTTL is how long a key is remembered when no TTL is configured
+	DefaultIdempotencyTTL = 24 * time.Hour
+)
+
+// IdempotencyConfig controls how long CreateOrder idempotency keys are remembered
+type IdempotencyConfig struct {
+	// TTL is how long a repeated key returns the original order; a non-positive value uses DefaultIdempotencyTTL
+	TTL time.Duration
+	// CacheTTL is how long a created order is also cached for fast retries; zero disables the cache
+	CacheTTL time.Duration
+}
+
+// Validate checks that cached orders expire before their idempotency keys do
+func (c IdempotencyConfig) Validate() error {
+	if c.CacheTTL < 0 || c.CacheTTL >= c.ttl() {
+		return fmt.Errorf("idempotency cache TTL %s must be between 0 and the idempotency TTL %s", c.CacheTTL, c.ttl())
+	}
+	return nil
+}
+
+// ttl returns the configured TTL, falling back to the default when unset
+func (c IdempotencyConfig) ttl() time.Duration {
+	if c.TTL <= 0 {
+		return DefaultIdempotencyTTL
+	}
+	return c.TTL
+}
+
+type idempotencyKeyContextKey struct{}
+
+// WithIdempotencyKey returns a copy of ctx carrying the idempotency key of a CreateOrder request
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	if key == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, idempotencyKeyContextKey{}, key)
+}
+
+// IdempotencyKeyFromContext returns the idempotency key of the request, or an empty string when there is none
+func IdempotencyKeyFromContext(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKeyContextKey{}).(string)
+	return key
+}
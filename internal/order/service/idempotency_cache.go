@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"go-bootiful-ordering/internal/order/domain"
+	"go-bootiful-ordering/internal/pkg/cache"
+	"time"
+)
+
+const (
+	// idempotencyKeyPrefix prefixes the cached order of a customer's idempotency key
+	idempotencyKeyPrefix = "order:idempotency:"
+	// idempotencyOrderPrefix prefixes the reverse entry pointing from an order to its cached key
+	idempotencyOrderPrefix = "order:idempotency-order:"
+)
+
+// IdempotencyCache answers CreateOrder retries from a cache before the database is asked.
+// It only ever holds orders whose idempotency key is already committed to the database.
+type IdempotencyCache interface {
+	// Get returns the order cached for a customer's key, or nil when there is none
+	Get(ctx context.Context, customerID, key string) (*domain.Order, error)
+	// Set caches the order created for a customer's key
+	Set(ctx context.Context, customerID, key string, order *domain.Order) error
+	// Forget drops the cached entry of an order, so the next retry reads its new status from the database
+	Forget(ctx context.Context, orderID string) error
+}
+
+// NoopIdempotencyCache provides an implementation of IdempotencyCache that caches nothing,
+// so every retry is answered from the database
+type NoopIdempotencyCache struct{}
+
+// Get never finds an order
+func (NoopIdempotencyCache) Get(ctx context.Context, customerID, key string) (*domain.Order, error) {
+	return nil, nil
+}
+
+// Set does nothing
+func (NoopIdempotencyCache) Set(ctx context.Context, customerID, key string, order *domain.Order) error {
+	return nil
+}
+
+// Forget does nothing
+func (NoopIdempotencyCache) Forget(ctx context.Context, orderID string) error {
+	return nil
+}
+
+// RedisIdempotencyCache provides an implementation of IdempotencyCache backed by Redis.
+// Entries expire after the cache TTL, which is shorter than the idempotency TTL, so a
+// cached order is never returned for a key the database has already forgotten.
+type RedisIdempotencyCache struct {
+	cache cache.Cache
+	ttl   time.Duration
+}
+
+// NewRedisIdempotencyCache creates a new RedisIdempotencyCache
+func NewRedisIdempotencyCache(c cache.Cache, ttl time.Duration) *RedisIdempotencyCache {
+	return &RedisIdempotencyCache{
+		cache: c,
+		ttl:   ttl,
+	}
+}
+
+// idempotencyCacheKey returns the cache key of a customer's idempotency key. Both parts are
+// hashed, as either may contain the separator and keys may be up to 255 characters long.
+func idempotencyCacheKey(customerID, key string) string {
+	sum := sha256.Sum256([]byte(customerID + "\x00" + key))
+	return idempotencyKeyPrefix + hex.EncodeToString(sum[:])
+}
+
+// idempotencyOrderKey returns the cache key of the reverse entry of an order
+func idempotencyOrderKey(orderID string) string {
+	return idempotencyOrderPrefix + orderID
+}
+
+// Get returns the order cached for a customer's key
+func (c *RedisIdempotencyCache) Get(ctx context.Context, customerID, key string) (*domain.Order, error) {
+	data, err := c.cache.Get(ctx, idempotencyCacheKey(customerID, key))
+	if errors.Is(err, cache.ErrMiss) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var order domain.Order
+	if err := json.Unmarshal(data, &order); err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// Set caches the order under the customer's key and records which key holds it
+func (c *RedisIdempotencyCache) Set(ctx context.Context, customerID, key string, order *domain.Order) error {
+	data, err := json.Marshal(order)
+	if err != nil {
+		return err
+	}
+
+	// Write the reverse entry first, so an order that can be found can also be forgotten
+	cacheKey := idempotencyCacheKey(customerID, key)
+	if err := c.cache.Set(ctx, idempotencyOrderKey(order.ID), []byte(cacheKey), c.ttl); err != nil {
+		return err
+	}
+	return c.cache.Set(ctx, cacheKey, data, c.ttl)
+}
+
+// Forget drops the cached order and its reverse entry
+func (c *RedisIdempotencyCache) Forget(ctx context.Context, orderID string) error {
+	orderKey := idempotencyOrderKey(orderID)
+	cacheKey, err := c.cache.Get(ctx, orderKey)
+	if errors.Is(err, cache.ErrMiss) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return c.cache.Del(ctx, string(cacheKey), orderKey)
+}
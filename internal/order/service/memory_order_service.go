@@ -0,0 +1,448 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go-bootiful-ordering/internal/order/domain"
+	"go-bootiful-ordering/internal/order/events"
+	"go-bootiful-ordering/internal/pkg/idgen"
+	"go-bootiful-ordering/internal/pkg/paging"
+	"go.uber.org/zap"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// MemoryOrderService provides an implementation of OrderService that keeps orders in memory.
+// It is meant for local development: nothing survives a restart and no events are published,
+// although the events an order would have produced are still recorded for GetOrderEvents.
+type MemoryOrderService struct {
+	log        *zap.SugaredLogger
+	ids        idgen.IDGenerator
+	amounts    AmountConfig
+	productIDs ProductIDConfig
+	shipments  ShipmentConfig
+	items      ItemConfig
+
+	mu     sync.RWMutex
+	orders map[string]*domain.Order
+	events map[string][]OrderEvent
+}
+
+// NewMemoryOrderService creates a new MemoryOrderService
+func NewMemoryOrderService(log *zap.SugaredLogger, ids idgen.IDGenerator, amounts AmountConfig, productIDs ProductIDConfig, shipments ShipmentConfig, items ItemConfig) *MemoryOrderService {
+	return &MemoryOrderService{
+		log:        log,
+		ids:        ids,
+		amounts:    amounts,
+		productIDs: productIDs,
+		shipments:  shipments,
+		items:      items,
+		orders:     make(map[string]*domain.Order),
+		events:     make(map[string][]OrderEvent),
+	}
+}
+
+// copyOrder returns a deep copy so callers cannot modify the stored order
+func copyOrder(order *domain.Order) *domain.Order {
+	c := *order
+	c.Items = append([]domain.OrderItem(nil), order.Items...)
+	return &c
+}
+
+// embedOrder returns a copy of an order embedding only its first items, as the database
+// implementation does
+func (s *MemoryOrderService) embedOrder(order *domain.Order) *domain.Order {
+	c := copyOrder(order)
+	if limit := s.items.embedLimit(); len(c.Items) > limit {
+		c.Items = c.Items[:limit]
+		c.ItemsTruncated = true
+	}
+	return c
+}
+
+// CreateOrder creates a new order and records its created event. Idempotency keys are
+// ignored, so every call creates a new order.
+func (s *MemoryOrderService) CreateOrder(ctx context.Context, customerID string, items []domain.OrderItem) (*domain.Order, error) {
+	s.log.Infof("MemoryOrderService_CreateOrder customerID=%s", customerID)
+
+	if err := s.productIDs.Check(items); err != nil {
+		return nil, err
+	}
+
+	// Create a new order domain object
+	now := time.Now()
+	order := &domain.Order{
+		ID:         s.ids.NewID(),
+		CustomerID: customerID,
+		Items:      append([]domain.OrderItem(nil), items...),
+		Status:     domain.OrderStatusPending,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	// Validate the order and merge repeated products
+	if err := order.NormalizeAndValidate(); err != nil {
+		return nil, err
+	}
+	if err := order.ComputeTotal(s.amounts.MaxTotalAmount); err != nil {
+		return nil, err
+	}
+
+	// Record the event the order would have published
+	event, err := newOrderEvent(ctx, s.ids, order, events.EventTypeOrderCreated, events.PayloadTypeOrderCreatedV1, events.NewOrderCreatedEventV1(order))
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.orders[order.ID] = order
+	s.events[order.ID] = append(s.events[order.ID], event)
+	s.mu.Unlock()
+
+	return copyOrder(order), nil
+}
+
+// PreviewOrder validates an order and computes its total without storing it. There is no
+// product catalog in memory mode, so only snapshot prices are available.
+func (s *MemoryOrderService) PreviewOrder(ctx context.Context, customerID string, items []domain.OrderItem, source domain.PriceSource) (*domain.Order, error) {
+	s.log.Infof("MemoryOrderService_PreviewOrder customerID=%s priceSource=%s", customerID, source)
+
+	if err := checkSnapshotSource(source, ModeMemory); err != nil {
+		return nil, err
+	}
+	if err := s.productIDs.Check(items); err != nil {
+		return nil, err
+	}
+
+	// Create the would-be order domain object
+	order := &domain.Order{
+		CustomerID: customerID,
+		Items:      items,
+		Status:     domain.OrderStatusPending,
+	}
+
+	// Validate the order and merge repeated products
+	if err := order.NormalizeAndValidate(); err != nil {
+		return nil, err
+	}
+
+	// Compute the total
+	if err := order.ComputeTotal(s.amounts.MaxTotalAmount); err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// Reorder creates a new order for the customer of an existing order with the same items
+// at the prices they were bought at; live prices are not available in memory mode
+func (s *MemoryOrderService) Reorder(ctx context.Context, orderID string, source domain.PriceSource) (*domain.Order, error) {
+	s.log.Infof("MemoryOrderService_Reorder orderID=%s priceSource=%s", orderID, source)
+
+	if err := checkSnapshotSource(source, ModeMemory); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	original, ok := s.orders[orderID]
+	if !ok {
+		s.mu.RUnlock()
+		return nil, domain.ErrOrderNotFound
+	}
+	customerID, items := original.CustomerID, append([]domain.OrderItem(nil), original.Items...)
+	s.mu.RUnlock()
+
+	return s.CreateOrder(ctx, customerID, items)
+}
+
+// GetOrder retrieves an order by ID
+func (s *MemoryOrderService) GetOrder(ctx context.Context, orderID string) (*domain.Order, error) {
+	s.log.Infof("MemoryOrderService_GetOrder orderID=%s", orderID)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	order, ok := s.orders[orderID]
+	if !ok {
+		return nil, domain.ErrOrderNotFound
+	}
+
+	return s.embedOrder(order), nil
+}
+
+// ListOrderItems retrieves a page of an order's items; the page token is the number of items already returned
+func (s *MemoryOrderService) ListOrderItems(ctx context.Context, orderID string, pageSize int32, pageToken string) ([]domain.OrderItem, string, error) {
+	s.log.Infof("MemoryOrderService_ListOrderItems orderID=%s pageSize=%d pageToken=%s",
+		orderID, pageSize, pageToken)
+
+	offset := 0
+	if pageToken != "" {
+		n, err := strconv.Atoi(pageToken)
+		if err != nil || n < 0 {
+			return nil, "", fmt.Errorf("%w: invalid page_token %q", domain.ErrInvalidArgument, pageToken)
+		}
+		offset = n
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	order, ok := s.orders[orderID]
+	if !ok {
+		return nil, "", domain.ErrOrderNotFound
+	}
+
+	items := order.Items[min(offset, len(order.Items)):]
+	var nextPageToken string
+	if pageSize > 0 && len(items) > int(pageSize) {
+		items = items[:pageSize]
+		nextPageToken = strconv.Itoa(offset + int(pageSize))
+	}
+	return append([]domain.OrderItem(nil), items...), nextPageToken, nil
+}
+
+// ListOrders retrieves a customer's orders ordered by ID, paginated like the database implementation
+func (s *MemoryOrderService) ListOrders(ctx context.Context, customerID string, pageSize int32, pageToken string) ([]*domain.Order, string, error) {
+	s.log.Infof("MemoryOrderService_ListOrders customerID=%s pageSize=%d pageToken=%s",
+		customerID, pageSize, pageToken)
+
+	var afterID string
+	if pageToken != "" {
+		cursor, err := paging.DecodeCursor(pageToken, "id", "ASC")
+		if err != nil {
+			return nil, "", fmt.Errorf("%w: %v", domain.ErrInvalidArgument, err)
+		}
+		afterID = cursor.ID
+	}
+
+	s.mu.RLock()
+	var orders []*domain.Order
+	for _, order := range s.orders {
+		if order.CustomerID == customerID && order.ID > afterID {
+			orders = append(orders, s.embedOrder(order))
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(orders, func(i, j int) bool {
+		return orders[i].ID < orders[j].ID
+	})
+
+	// Cut the page; the token is a cursor on the ID of the last order returned
+	var nextPageToken string
+	if pageSize > 0 && len(orders) > int(pageSize) {
+		orders = orders[:pageSize]
+		nextPageToken = paging.EncodeCursor(paging.Cursor{Column: "id", Direction: "ASC", ID: orders[len(orders)-1].ID})
+	}
+
+	return orders, nextPageToken, nil
+}
+
+// CountOrders counts a customer's orders
+func (s *MemoryOrderService) CountOrders(ctx context.Context, customerID string) (int64, error) {
+	s.log.Infof("MemoryOrderService_CountOrders customerID=%s", customerID)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var count int64
+	for _, order := range s.orders {
+		if order.CustomerID == customerID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// UpdateOrderStatus updates the status of an order and records its status updated event,
+// accepting the same transitions as the database implementation
+func (s *MemoryOrderService) UpdateOrderStatus(ctx context.Context, orderID string, status domain.OrderStatus) (*domain.Order, error) {
+	s.log.Infof("MemoryOrderService_UpdateOrderStatus orderID=%s status=%d",
+		orderID, int(status))
+
+	if !status.IsValid() {
+		return nil, fmt.Errorf("%w: unknown status %d", domain.ErrInvalidArgument, status)
+	}
+
+	switch status {
+	case domain.OrderStatusCancelled:
+		return s.CancelOrder(ctx, orderID)
+	case domain.OrderStatusShipped:
+		return s.ShipOrder(ctx, orderID, domain.Shipment{})
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, ok := s.orders[orderID]
+	if !ok {
+		return nil, domain.ErrOrderNotFound
+	}
+
+	if err := order.Status.CheckTransition(status); err != nil {
+		return nil, err
+	}
+
+	updated := copyOrder(order)
+	updated.Status = status
+	updated.UpdatedAt = time.Now()
+
+	// Record the event the update would have published
+	event, err := newOrderEvent(ctx, s.ids, updated, events.EventTypeOrderStatusUpdated, events.PayloadTypeOrderStatusUpdatedV1, events.NewOrderStatusUpdatedEventV1(updated))
+	if err != nil {
+		return nil, err
+	}
+
+	s.orders[orderID] = updated
+	s.events[orderID] = append(s.events[orderID], event)
+
+	return s.embedOrder(updated), nil
+}
+
+// CancelOrder cancels an order that has not shipped yet and records its cancelled event.
+// No stock is reserved in this mode, so none is returned.
+func (s *MemoryOrderService) CancelOrder(ctx context.Context, orderID string) (*domain.Order, error) {
+	s.log.Infof("MemoryOrderService_CancelOrder orderID=%s", orderID)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, ok := s.orders[orderID]
+	if !ok {
+		return nil, domain.ErrOrderNotFound
+	}
+
+	if err := order.Status.CheckTransition(domain.OrderStatusCancelled); err != nil {
+		return nil, err
+	}
+
+	cancelled := copyOrder(order)
+	cancelled.Status = domain.OrderStatusCancelled
+	cancelled.UpdatedAt = time.Now()
+
+	// Record the event the cancellation would have published
+	event, err := newOrderEvent(ctx, s.ids, cancelled, events.EventTypeOrderCancelled, events.PayloadTypeOrderCancelledV1, events.NewOrderCancelledEventV1(cancelled))
+	if err != nil {
+		return nil, err
+	}
+
+	s.orders[orderID] = cancelled
+	s.events[orderID] = append(s.events[orderID], event)
+
+	return copyOrder(cancelled), nil
+}
+
+// ShipOrder marks an order as shipped with its tracking information and records its shipped event
+func (s *MemoryOrderService) ShipOrder(ctx context.Context, orderID string, shipment domain.Shipment) (*domain.Order, error) {
+	s.log.Infof("MemoryOrderService_ShipOrder orderID=%s carrier=%s", orderID, shipment.Carrier)
+
+	if err := s.shipments.Check(shipment); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, ok := s.orders[orderID]
+	if !ok {
+		return nil, domain.ErrOrderNotFound
+	}
+
+	if err := order.Status.CheckTransition(domain.OrderStatusShipped); err != nil {
+		return nil, err
+	}
+
+	shipped := copyOrder(order)
+	shipped.Status = domain.OrderStatusShipped
+	shipped.TrackingNumber = shipment.TrackingNumber
+	shipped.Carrier = shipment.Carrier
+	shipped.UpdatedAt = time.Now()
+
+	// Record the event the shipment would have published
+	event, err := newOrderEvent(ctx, s.ids, shipped, events.EventTypeOrderShipped, events.PayloadTypeOrderShippedV1, events.NewOrderShippedEventV1(shipped))
+	if err != nil {
+		return nil, err
+	}
+
+	s.orders[orderID] = shipped
+	s.events[orderID] = append(s.events[orderID], event)
+
+	return s.embedOrder(shipped), nil
+}
+
+// CountOrdersByPeriod counts orders created in [from, to) grouped by time bucket
+func (s *MemoryOrderService) CountOrdersByPeriod(ctx context.Context, customerID string, bucket domain.TimeBucket, from, to time.Time) ([]domain.PeriodCount, error) {
+	s.log.Infof("MemoryOrderService_CountOrdersByPeriod customerID=%s bucket=%s from=%s to=%s",
+		customerID, bucket, from.Format(time.RFC3339), to.Format(time.RFC3339))
+
+	// Validate the query
+	if !bucket.IsValid() {
+		return nil, fmt.Errorf("%w: unknown bucket %q", domain.ErrInvalidArgument, bucket)
+	}
+
+	if !from.Before(to) {
+		return nil, fmt.Errorf("%w: from must be before to", domain.ErrInvalidArgument)
+	}
+
+	if to.Sub(from) > MaxTimeSeriesDays*24*time.Hour {
+		return nil, fmt.Errorf("%w: range must not exceed %d days", domain.ErrInvalidArgument, MaxTimeSeriesDays)
+	}
+
+	// Count the matching orders per bucket
+	byBucket := make(map[time.Time]int64)
+	s.mu.RLock()
+	for _, order := range s.orders {
+		if customerID != "" && order.CustomerID != customerID {
+			continue
+		}
+		if order.CreatedAt.Before(from) || !order.CreatedAt.Before(to) {
+			continue
+		}
+		byBucket[truncateToBucket(order.CreatedAt, bucket)]++
+	}
+	s.mu.RUnlock()
+
+	counts := make([]domain.PeriodCount, 0, len(byBucket))
+	for date, count := range byBucket {
+		counts = append(counts, domain.PeriodCount{Date: date, Count: count})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		return counts[i].Date.Before(counts[j].Date)
+	})
+
+	return counts, nil
+}
+
+// truncateToBucket returns the start of the bucket containing t, matching Postgres date_trunc in UTC
+func truncateToBucket(t time.Time, bucket domain.TimeBucket) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+
+	switch bucket {
+	case domain.TimeBucketWeek:
+		// Weeks start on Monday
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset)
+	case domain.TimeBucketMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return day
+	}
+}
+
+// GetOrderEvents retrieves the events recorded for an order
+func (s *MemoryOrderService) GetOrderEvents(ctx context.Context, orderID string) ([]OrderEvent, error) {
+	s.log.Infof("MemoryOrderService_GetOrderEvents orderID=%s", orderID)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]OrderEvent(nil), s.events[orderID]...), nil
+}
+
+// ReplayEvents is not supported because the in-memory service publishes no events
+func (s *MemoryOrderService) ReplayEvents(ctx context.Context, aggregateID string, from, to time.Time) (int, error) {
+	return 0, fmt.Errorf("%w: events are not published in %s mode", ErrUnsupported, ModeMemory)
+}
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"go-bootiful-ordering/internal/order/domain"
+	"go-bootiful-ordering/internal/pkg/idgen"
+	"go.uber.org/zap"
+)
+
+func TestMemoryOrderEventsUseInjectedIDs(t *testing.T) {
+	svc := NewMemoryOrderService(zap.NewNop().Sugar(), idgen.NewSequenceGenerator("id-"), AmountConfig{}, ProductIDConfig{}, ShipmentConfig{}, ItemConfig{})
+	ctx := context.Background()
+
+	order, err := svc.CreateOrder(ctx, "customer-1", []domain.OrderItem{{ProductID: "product-1", Quantity: 1, Price: 100}})
+	if err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+	if _, err := svc.CancelOrder(ctx, order.ID); err != nil {
+		t.Fatalf("CancelOrder() error = %v", err)
+	}
+
+	events, err := svc.GetOrderEvents(ctx, order.ID)
+	if err != nil {
+		t.Fatalf("GetOrderEvents() error = %v", err)
+	}
+	if order.ID != "id-1" {
+		t.Errorf("order ID = %q, want id-1", order.ID)
+	}
+	want := []string{"id-2", "id-3"}
+	if len(events) != len(want) {
+		t.Fatalf("GetOrderEvents() returned %d events, want %d", len(events), len(want))
+	}
+	for i, event := range events {
+		if event.ID != want[i] {
+			t.Errorf("events[%d].ID = %q, want %q", i, event.ID, want[i])
+		}
+	}
+}
+
+func TestTruncateToBucket(t *testing.T) {
+	utc := func(year int, month time.Month, day, hour, min int) time.Time {
+		return time.Date(year, month, day, hour, min, 0, 0, time.UTC)
+	}
+	hanoi := time.FixedZone("UTC+7", 7*60*60)
+	newYork := time.FixedZone("UTC-5", -5*60*60)
+
+	tests := []struct {
+		name   string
+		t      time.Time
+		bucket domain.TimeBucket
+		want   time.Time
+	}{
+		{name: "day start", t: utc(2024, 3, 5, 0, 0), bucket: domain.TimeBucketDay, want: utc(2024, 3, 5, 0, 0)},
+		{name: "day end", t: time.Date(2024, 3, 5, 23, 59, 59, 999999999, time.UTC), bucket: domain.TimeBucketDay, want: utc(2024, 3, 5, 0, 0)},
+		{name: "leap day", t: utc(2024, 2, 29, 12, 0), bucket: domain.TimeBucketDay, want: utc(2024, 2, 29, 0, 0)},
+		{name: "monday starts its week", t: utc(2024, 3, 4, 0, 0), bucket: domain.TimeBucketWeek, want: utc(2024, 3, 4, 0, 0)},
+		{name: "sunday ends the week", t: utc(2024, 3, 10, 23, 59), bucket: domain.TimeBucketWeek, want: utc(2024, 3, 4, 0, 0)},
+		{name: "week across a month", t: utc(2024, 3, 1, 9, 0), bucket: domain.TimeBucketWeek, want: utc(2024, 2, 26, 0, 0)},
+		{name: "week across a year", t: utc(2025, 1, 1, 9, 0), bucket: domain.TimeBucketWeek, want: utc(2024, 12, 30, 0, 0)},
+		{name: "month start", t: utc(2024, 3, 1, 0, 0), bucket: domain.TimeBucketMonth, want: utc(2024, 3, 1, 0, 0)},
+		{name: "month end", t: time.Date(2024, 1, 31, 23, 59, 59, 999999999, time.UTC), bucket: domain.TimeBucketMonth, want: utc(2024, 1, 1, 0, 0)},
+		{name: "december", t: utc(2024, 12, 31, 23, 0), bucket: domain.TimeBucketMonth, want: utc(2024, 12, 1, 0, 0)},
+		// Buckets are UTC: a time in another zone falls in the bucket of its UTC instant
+		{name: "ahead of UTC on the previous UTC day", t: time.Date(2024, 3, 1, 1, 30, 0, 0, hanoi), bucket: domain.TimeBucketDay, want: utc(2024, 2, 29, 0, 0)},
+		{name: "ahead of UTC in the previous UTC month", t: time.Date(2024, 3, 1, 1, 30, 0, 0, hanoi), bucket: domain.TimeBucketMonth, want: utc(2024, 2, 1, 0, 0)},
+		{name: "behind UTC on the next UTC day", t: time.Date(2024, 3, 10, 22, 0, 0, 0, newYork), bucket: domain.TimeBucketDay, want: utc(2024, 3, 11, 0, 0)},
+		{name: "behind UTC in the next UTC week", t: time.Date(2024, 3, 10, 22, 0, 0, 0, newYork), bucket: domain.TimeBucketWeek, want: utc(2024, 3, 11, 0, 0)},
+		{name: "behind UTC in the next UTC month", t: time.Date(2024, 3, 31, 23, 30, 0, 0, newYork), bucket: domain.TimeBucketMonth, want: utc(2024, 4, 1, 0, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncateToBucket(tt.t, tt.bucket)
+			if !got.Equal(tt.want) || got.Location() != time.UTC {
+				t.Errorf("truncateToBucket(%s, %s) = %s, want %s", tt.t, tt.bucket, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryCountOrdersByPeriod(t *testing.T) {
+	svc := NewMemoryOrderService(zap.NewNop().Sugar(), idgen.NewSequenceGenerator("order-"), AmountConfig{}, ProductIDConfig{}, ShipmentConfig{}, ItemConfig{})
+	hanoi := time.FixedZone("UTC+7", 7*60*60)
+	created := []struct {
+		customerID string
+		at         time.Time
+	}{
+		{"customer-1", time.Date(2024, 2, 29, 23, 59, 59, 0, time.UTC)},
+		{"customer-1", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"customer-2", time.Date(2024, 3, 1, 6, 59, 0, 0, hanoi)}, // 2024-02-29 23:59 UTC
+		{"customer-1", time.Date(2024, 3, 3, 12, 0, 0, 0, time.UTC)},
+		{"customer-1", time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC)},
+		{"customer-2", time.Date(2024, 3, 31, 23, 0, 0, 0, time.UTC)},
+		{"customer-1", time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for i, c := range created {
+		id := fmt.Sprintf("order-%d", i+1)
+		svc.orders[id] = &domain.Order{ID: id, CustomerID: c.customerID, Status: domain.OrderStatusPending, CreatedAt: c.at}
+	}
+
+	day := func(year int, month time.Month, d int) time.Time {
+		return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+	}
+	from, to := day(2024, 2, 1), day(2024, 4, 1)
+
+	tests := []struct {
+		name       string
+		customerID string
+		bucket     domain.TimeBucket
+		from, to   time.Time
+		want       []domain.PeriodCount
+	}{
+		{
+			name:   "by day",
+			bucket: domain.TimeBucketDay,
+			from:   from, to: to,
+			want: []domain.PeriodCount{{Date: day(2024, 2, 29), Count: 2}, {Date: day(2024, 3, 1), Count: 1}, {Date: day(2024, 3, 3), Count: 1}, {Date: day(2024, 3, 4), Count: 1}, {Date: day(2024, 3, 31), Count: 1}},
+		},
+		{
+			name:   "by week",
+			bucket: domain.TimeBucketWeek,
+			from:   from, to: to,
+			want: []domain.PeriodCount{{Date: day(2024, 2, 26), Count: 4}, {Date: day(2024, 3, 4), Count: 1}, {Date: day(2024, 3, 25), Count: 1}},
+		},
+		{
+			name:   "by month",
+			bucket: domain.TimeBucketMonth,
+			from:   from, to: day(2024, 5, 1),
+			want: []domain.PeriodCount{{Date: day(2024, 2, 1), Count: 2}, {Date: day(2024, 3, 1), Count: 4}, {Date: day(2024, 4, 1), Count: 1}},
+		},
+		{
+			name:       "one customer",
+			customerID: "customer-2",
+			bucket:     domain.TimeBucketMonth,
+			from:       from, to: to,
+			want: []domain.PeriodCount{{Date: day(2024, 2, 1), Count: 1}, {Date: day(2024, 3, 1), Count: 1}},
+		},
+		{
+			// 00:00 to 08:00 UTC: the order at from is counted and the one at to is not
+			name:   "bounds with an offset",
+			bucket: domain.TimeBucketDay,
+			from:   time.Date(2024, 3, 1, 7, 0, 0, 0, hanoi), to: time.Date(2024, 3, 4, 15, 0, 0, 0, hanoi),
+			want: []domain.PeriodCount{{Date: day(2024, 3, 1), Count: 1}, {Date: day(2024, 3, 3), Count: 1}},
+		},
+		{
+			name:   "empty range",
+			bucket: domain.TimeBucketDay,
+			from:   day(2024, 1, 1), to: day(2024, 1, 31),
+			want: []domain.PeriodCount{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := svc.CountOrdersByPeriod(context.Background(), tt.customerID, tt.bucket, tt.from, tt.to)
+			if err != nil {
+				t.Fatalf("CountOrdersByPeriod() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("CountOrdersByPeriod() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if !got[i].Date.Equal(tt.want[i].Date) || got[i].Count != tt.want[i].Count {
+					t.Errorf("CountOrdersByPeriod()[%d] = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryCountOrdersByPeriodRejectsInvalidQueries(t *testing.T) {
+	svc := NewMemoryOrderService(zap.NewNop().Sugar(), idgen.NewSequenceGenerator("order-"), AmountConfig{}, ProductIDConfig{}, ShipmentConfig{}, ItemConfig{})
+	now := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		bucket   domain.TimeBucket
+		from, to time.Time
+	}{
+		{name: "unknown bucket", bucket: "hour", from: now, to: now.Add(time.Hour)},
+		{name: "empty range", bucket: domain.TimeBucketDay, from: now, to: now},
+		{name: "inverted range", bucket: domain.TimeBucketDay, from: now, to: now.Add(-time.Hour)},
+		{name: "range too long", bucket: domain.TimeBucketMonth, from: now, to: now.Add((MaxTimeSeriesDays + 1) * 24 * time.Hour)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := svc.CountOrdersByPeriod(context.Background(), "", tt.bucket, tt.from, tt.to); !errors.Is(err, domain.ErrInvalidArgument) {
+				t.Errorf("CountOrdersByPeriod() error = %v, want ErrInvalidArgument", err)
+			}
+		})
+	}
+}
+
+func TestMemoryReorderKeepsSnapshotPrices(t *testing.T) {
+	svc := NewMemoryOrderService(zap.NewNop().Sugar(), idgen.NewSequenceGenerator("id-"), AmountConfig{}, ProductIDConfig{}, ShipmentConfig{}, ItemConfig{})
+	ctx := context.Background()
+
+	original, err := svc.CreateOrder(ctx, "customer-1", []domain.OrderItem{{ProductID: "product-1", Quantity: 2, Price: 1000}})
+	if err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+
+	reordered, err := svc.Reorder(ctx, original.ID, domain.PriceSourceSnapshot)
+	if err != nil {
+		t.Fatalf("Reorder() error = %v", err)
+	}
+	if reordered.ID == original.ID || reordered.CustomerID != "customer-1" || reordered.TotalAmount != 2000 || reordered.Items[0].Price != 1000 {
+		t.Errorf("Reorder() = %+v, want a new order for customer-1 at the price paid", reordered)
+	}
+
+	// There is no catalog to read live prices from
+	if _, err := svc.Reorder(ctx, original.ID, domain.PriceSourceLive); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("live Reorder() error = %v, want ErrUnsupported", err)
+	}
+	if _, err := svc.PreviewOrder(ctx, "customer-1", original.Items, domain.PriceSourceLive); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("live PreviewOrder() error = %v, want ErrUnsupported", err)
+	}
+	if _, err := svc.Reorder(ctx, original.ID, "cached"); !errors.Is(err, domain.ErrInvalidArgument) {
+		t.Errorf("Reorder() with an unknown source error = %v, want ErrInvalidArgument", err)
+	}
+	if _, err := svc.Reorder(ctx, "id-missing", domain.PriceSourceSnapshot); !errors.Is(err, domain.ErrOrderNotFound) {
+		t.Errorf("Reorder() of an unknown order error = %v, want ErrOrderNotFound", err)
+	}
+}
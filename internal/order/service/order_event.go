@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"go-bootiful-ordering/internal/order/domain"
+	"go-bootiful-ordering/internal/order/events"
+	"go-bootiful-ordering/internal/order/repository"
+	"go-bootiful-ordering/internal/pkg/idgen"
+	"go-bootiful-ordering/internal/pkg/tracing"
+	"time"
+)
+
+// OrderEvent is an event written for an order, as listed by GetOrderEvents
+type OrderEvent struct {
+	ID string
+	// AggregateType routes the event to its topic; replayed copies carry the replay topic
+	AggregateType string
+	AggregateID   string
+	EventType     events.EventType
+	// PayloadType names the schema of Payload; it is empty on events written before
+	// payloads were versioned
+	PayloadType events.PayloadType
+	Payload     json.RawMessage
+	CreatedAt   time.Time
+	// TraceID is the trace of the request that wrote the event, or empty when it was not traced
+	TraceID string
+}
+
+// orderEventFromOutbox converts an outbox entry to the event it publishes
+func orderEventFromOutbox(entry *repository.OutboxModel) OrderEvent {
+	return OrderEvent{
+		ID:            entry.ID,
+		AggregateType: entry.AggregateType,
+		AggregateID:   entry.AggregateID,
+		EventType:     events.EventType(entry.EventType),
+		PayloadType:   events.PayloadType(entry.PayloadType),
+		Payload:       json.RawMessage(entry.Payload),
+		CreatedAt:     entry.CreatedAt,
+		TraceID:       entry.TraceID,
+	}
+}
+
+// newOrderEvent creates the event an order change publishes with its typed payload and an
+// ID from ids, for services recording events without an outbox
+func newOrderEvent(ctx context.Context, ids idgen.IDGenerator, order *domain.Order, eventType events.EventType, payloadType events.PayloadType, payload interface{}) (OrderEvent, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return OrderEvent{}, err
+	}
+
+	return OrderEvent{
+		ID:            ids.NewID(),
+		AggregateType: string(repository.AggregateTypeOrder),
+		AggregateID:   order.ID,
+		EventType:     eventType,
+		PayloadType:   payloadType,
+		Payload:       data,
+		CreatedAt:     time.Now(),
+		TraceID:       tracing.TraceID(ctx),
+	}, nil
+}
@@ -2,13 +2,61 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"go-bootiful-ordering/internal/order/domain"
+	"time"
 )
 
+const (
+	// ModeDB serves orders from the database and publishes events through the outbox
+	ModeDB = "db"
+	// ModeMemory keeps orders in memory, for local development
+	ModeMemory = "memory"
+	// ModeRemote proxies order calls to another order service over gRPC
+	ModeRemote = "remote"
+)
+
+// ErrUnsupported is returned by operations the selected service mode cannot perform
+var ErrUnsupported = errors.New("operation not supported")
+
+// ParseMode checks that mode selects a known OrderService implementation; an empty mode selects ModeDB
+func ParseMode(mode string) (string, error) {
+	switch mode {
+	case "":
+		return ModeDB, nil
+	case ModeDB, ModeMemory, ModeRemote:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown service mode %q, expected %q, %q or %q", mode, ModeDB, ModeMemory, ModeRemote)
+	}
+}
+
+// checkSnapshotSource rejects price sources other than snapshot prices, for the modes
+// without a product catalog to read live prices from
+func checkSnapshotSource(source domain.PriceSource, mode string) error {
+	if !source.IsValid() {
+		return fmt.Errorf("%w: unknown price source %q", domain.ErrInvalidArgument, source)
+	}
+	if source != domain.PriceSourceSnapshot {
+		return fmt.Errorf("%w: live prices are not available in %s mode", ErrUnsupported, mode)
+	}
+	return nil
+}
+
 // OrderService defines the interface for order operations
 type OrderService interface {
 	CreateOrder(ctx context.Context, customerID string, items []domain.OrderItem) (*domain.Order, error)
+	PreviewOrder(ctx context.Context, customerID string, items []domain.OrderItem, source domain.PriceSource) (*domain.Order, error)
+	Reorder(ctx context.Context, orderID string, source domain.PriceSource) (*domain.Order, error)
 	GetOrder(ctx context.Context, orderID string) (*domain.Order, error)
+	ListOrderItems(ctx context.Context, orderID string, pageSize int32, pageToken string) ([]domain.OrderItem, string, error)
 	ListOrders(ctx context.Context, customerID string, pageSize int32, pageToken string) ([]*domain.Order, string, error)
+	CountOrders(ctx context.Context, customerID string) (int64, error)
 	UpdateOrderStatus(ctx context.Context, orderID string, status domain.OrderStatus) (*domain.Order, error)
+	CancelOrder(ctx context.Context, orderID string) (*domain.Order, error)
+	ShipOrder(ctx context.Context, orderID string, shipment domain.Shipment) (*domain.Order, error)
+	CountOrdersByPeriod(ctx context.Context, customerID string, bucket domain.TimeBucket, from, to time.Time) ([]domain.PeriodCount, error)
+	GetOrderEvents(ctx context.Context, orderID string) ([]OrderEvent, error)
+	ReplayEvents(ctx context.Context, aggregateID string, from, to time.Time) (int, error)
 }
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go-bootiful-ordering/gen/product/v1"
+	"go-bootiful-ordering/internal/order/domain"
+)
+
+const (
+	// PricePolicyEnforce rejects items whose price differs from the catalog price
+	PricePolicyEnforce = "enforce"
+	// PricePolicyOverride replaces the price of every item with the catalog price
+	PricePolicyOverride = "override"
+)
+
+// ValidatePricePolicy checks that policy is one of the known price policies
+func ValidatePricePolicy(policy string) error {
+	switch policy {
+	case PricePolicyEnforce, PricePolicyOverride:
+		return nil
+	default:
+		return fmt.Errorf("unknown price policy %q, expected %q or %q", policy, PricePolicyEnforce, PricePolicyOverride)
+	}
+}
+
+// ProductCatalog checks order items against the product catalog before an order is priced
+type ProductCatalog interface {
+	// PriceItems returns the items priced from the catalog, or an ErrInvalidArgument error
+	// naming the first item whose product is missing, inactive or, depending on the
+	// policy, sent with a price other than the current one
+	PriceItems(ctx context.Context, items []domain.OrderItem) ([]domain.OrderItem, error)
+
+	// CurrentPrices returns the items at the catalog's current prices whatever price they
+	// were sent with, or an ErrInvalidArgument error naming the first item whose product is
+	// missing or inactive
+	CurrentPrices(ctx context.Context, items []domain.OrderItem) ([]domain.OrderItem, error)
+}
+
+// NoopProductCatalog provides an implementation of ProductCatalog that trusts the items
+// as sent, for deployments where orders are accepted regardless of the product catalog
+type NoopProductCatalog struct{}
+
+// PriceItems returns the items unchanged
+func (NoopProductCatalog) PriceItems(ctx context.Context, items []domain.OrderItem) ([]domain.OrderItem, error) {
+	return items, nil
+}
+
+// CurrentPrices fails with ErrUnsupported, as there is no catalog to read the prices from
+func (NoopProductCatalog) CurrentPrices(ctx context.Context, items []domain.OrderItem) ([]domain.OrderItem, error) {
+	return nil, fmt.Errorf("%w: live prices need the product catalog checks enabled", ErrUnsupported)
+}
+
+// GRPCProductCatalog provides an implementation of ProductCatalog backed by the product
+// service. All products of an order are fetched in a single BatchGetProducts call.
+type GRPCProductCatalog struct {
+	client productv1.ProductServiceClient
+	policy string
+}
+
+// NewGRPCProductCatalog creates a new GRPCProductCatalog applying the given price policy
+func NewGRPCProductCatalog(client productv1.ProductServiceClient, policy string) (*GRPCProductCatalog, error) {
+	if err := ValidatePricePolicy(policy); err != nil {
+		return nil, err
+	}
+	return &GRPCProductCatalog{client: client, policy: policy}, nil
+}
+
+// PriceItems looks up every ordered product and checks the items against it. The
+// returned items are a copy, so the caller's items keep the prices that were sent.
+func (c *GRPCProductCatalog) PriceItems(ctx context.Context, items []domain.OrderItem) ([]domain.OrderItem, error) {
+	return c.priceItems(ctx, items, c.policy == PricePolicyEnforce)
+}
+
+// CurrentPrices looks up every ordered product and returns a copy of the items at their
+// current prices, regardless of the price policy
+func (c *GRPCProductCatalog) CurrentPrices(ctx context.Context, items []domain.OrderItem) ([]domain.OrderItem, error) {
+	return c.priceItems(ctx, items, false)
+}
+
+// priceItems prices a copy of the items at the current prices of their products, rejecting
+// items sent with another price when enforce is set
+func (c *GRPCProductCatalog) priceItems(ctx context.Context, items []domain.OrderItem, enforce bool) ([]domain.OrderItem, error) {
+	productIDs := make([]string, 0, len(items))
+	seen := make(map[string]bool, len(items))
+	for _, item := range items {
+		if !seen[item.ProductID] {
+			seen[item.ProductID] = true
+			productIDs = append(productIDs, item.ProductID)
+		}
+	}
+
+	resp, err := c.client.BatchGetProducts(ctx, &productv1.BatchGetProductsRequest{ProductIds: productIDs})
+	if err != nil {
+		return nil, productServiceError(err)
+	}
+
+	products := make(map[string]*productv1.Product, len(resp.Products))
+	for _, product := range resp.Products {
+		products[product.Id] = product
+	}
+
+	priced := make([]domain.OrderItem, len(items))
+	for idx, item := range items {
+		product, ok := products[item.ProductID]
+		if !ok {
+			return nil, fmt.Errorf("%w: items[%d]: product %q does not exist", domain.ErrInvalidArgument, idx, item.ProductID)
+		}
+		if product.Status == productv1.ProductStatus_PRODUCT_STATUS_INACTIVE {
+			return nil, fmt.Errorf("%w: items[%d]: product %q is not available", domain.ErrInvalidArgument, idx, item.ProductID)
+		}
+		if enforce && item.Price != product.Price {
+			return nil, fmt.Errorf("%w: items[%d]: price %d of product %q does not match the current price %d",
+				domain.ErrInvalidArgument, idx, item.Price, item.ProductID, product.Price)
+		}
+
+		item.Price = product.Price
+		priced[idx] = item
+	}
+	return priced, nil
+}
@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go-bootiful-ordering/gen/order/v1"
+	"go-bootiful-ordering/internal/order/domain"
+	"go-bootiful-ordering/internal/order/protoconv"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"time"
+)
+
+// RemoteOrderService provides an implementation of OrderService that proxies calls
+// to another order service over gRPC. Operations without an RPC return ErrUnsupported,
+// except PreviewOrder, which needs no storage and is computed locally.
+type RemoteOrderService struct {
+	log        *zap.SugaredLogger
+	client     orderv1.OrderServiceClient
+	amounts    AmountConfig
+	productIDs ProductIDConfig
+}
+
+// NewRemoteOrderService creates a new RemoteOrderService
+func NewRemoteOrderService(log *zap.SugaredLogger, client orderv1.OrderServiceClient, amounts AmountConfig, productIDs ProductIDConfig) *RemoteOrderService {
+	return &RemoteOrderService{
+		log:        log,
+		client:     client,
+		amounts:    amounts,
+		productIDs: productIDs,
+	}
+}
+
+// remoteError converts a gRPC error from the remote service into the errors the handlers expect
+func remoteError(err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	switch st.Code() {
+	case codes.InvalidArgument:
+		return fmt.Errorf("%w: %s", domain.ErrInvalidArgument, st.Message())
+	case codes.NotFound:
+		return domain.ErrOrderNotFound
+	case codes.FailedPrecondition:
+		return fmt.Errorf("%w: %s", domain.ErrInsufficientStock, st.Message())
+	default:
+		return err
+	}
+}
+
+// remoteTransitionError converts a gRPC error from a status change; a failed precondition
+// there is the order's status rather than its stock
+func remoteTransitionError(err error) error {
+	if status.Code(err) == codes.FailedPrecondition {
+		return fmt.Errorf("%w: %s", domain.ErrInvalidStatusTransition, status.Convert(err).Message())
+	}
+	return remoteError(err)
+}
+
+// CreateOrder creates an order on the remote service
+func (s *RemoteOrderService) CreateOrder(ctx context.Context, customerID string, items []domain.OrderItem) (*domain.Order, error) {
+	s.log.Infof("RemoteOrderService_CreateOrder customerID=%s", customerID)
+
+	// Reject malformed product IDs without a round trip to the remote service
+	if err := s.productIDs.Check(items); err != nil {
+		return nil, err
+	}
+
+	// Forward the idempotency key so the remote service deduplicates the request
+	if key := IdempotencyKeyFromContext(ctx); key != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, IdempotencyMetadataKey, key)
+	}
+
+	resp, err := s.client.CreateOrder(ctx, &orderv1.CreateOrderRequest{
+		CustomerId: customerID,
+		Items:      protoconv.ItemsToProto(items),
+	})
+	if err != nil {
+		return nil, remoteError(err)
+	}
+
+	return protoconv.OrderFromProto(resp.Order), nil
+}
+
+// PreviewOrder validates an order and computes its total locally, as the other
+// implementations do. Without a product catalog only snapshot prices are available.
+func (s *RemoteOrderService) PreviewOrder(ctx context.Context, customerID string, items []domain.OrderItem, source domain.PriceSource) (*domain.Order, error) {
+	s.log.Infof("RemoteOrderService_PreviewOrder customerID=%s priceSource=%s", customerID, source)
+
+	if err := checkSnapshotSource(source, ModeRemote); err != nil {
+		return nil, err
+	}
+	if err := s.productIDs.Check(items); err != nil {
+		return nil, err
+	}
+
+	// Create the would-be order domain object
+	order := &domain.Order{
+		CustomerID: customerID,
+		Items:      items,
+		Status:     domain.OrderStatusPending,
+	}
+
+	// Validate the order and merge repeated products
+	if err := order.NormalizeAndValidate(); err != nil {
+		return nil, err
+	}
+
+	// Compute the total
+	if err := order.ComputeTotal(s.amounts.MaxTotalAmount); err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// GetOrder retrieves an order from the remote service
+func (s *RemoteOrderService) GetOrder(ctx context.Context, orderID string) (*domain.Order, error) {
+	s.log.Infof("RemoteOrderService_GetOrder orderID=%s", orderID)
+
+	resp, err := s.client.GetOrder(ctx, &orderv1.GetOrderRequest{OrderId: orderID})
+	if err != nil {
+		return nil, remoteError(err)
+	}
+
+	return protoconv.OrderFromProto(resp.Order), nil
+}
+
+// ListOrderItems lists a page of an order's items on the remote service
+func (s *RemoteOrderService) ListOrderItems(ctx context.Context, orderID string, pageSize int32, pageToken string) ([]domain.OrderItem, string, error) {
+	s.log.Infof("RemoteOrderService_ListOrderItems orderID=%s pageSize=%d pageToken=%s",
+		orderID, pageSize, pageToken)
+
+	resp, err := s.client.ListOrderItems(ctx, &orderv1.ListOrderItemsRequest{
+		OrderId:   orderID,
+		PageSize:  pageSize,
+		PageToken: pageToken,
+	})
+	if err != nil {
+		return nil, "", remoteError(err)
+	}
+
+	return protoconv.ItemsFromProto(resp.Items), resp.NextPageToken, nil
+}
+
+// ListOrders lists a customer's orders on the remote service
+func (s *RemoteOrderService) ListOrders(ctx context.Context, customerID string, pageSize int32, pageToken string) ([]*domain.Order, string, error) {
+	s.log.Infof("RemoteOrderService_ListOrders customerID=%s pageSize=%d pageToken=%s",
+		customerID, pageSize, pageToken)
+
+	resp, err := s.client.ListOrders(ctx, &orderv1.ListOrdersRequest{
+		CustomerId: customerID,
+		PageSize:   pageSize,
+		PageToken:  pageToken,
+	})
+	if err != nil {
+		return nil, "", remoteError(err)
+	}
+
+	// Convert protobuf orders to domain orders
+	orders := make([]*domain.Order, len(resp.Orders))
+	for i, order := range resp.Orders {
+		orders[i] = protoconv.OrderFromProto(order)
+	}
+
+	return orders, resp.NextPageToken, nil
+}
+
+// CountOrders counts a customer's orders on the remote service. The API has no count RPC,
+// so it asks ListOrders for the total along with the smallest page.
+func (s *RemoteOrderService) CountOrders(ctx context.Context, customerID string) (int64, error) {
+	s.log.Infof("RemoteOrderService_CountOrders customerID=%s", customerID)
+
+	resp, err := s.client.ListOrders(ctx, &orderv1.ListOrdersRequest{
+		CustomerId:   customerID,
+		PageSize:     1,
+		IncludeTotal: true,
+	})
+	if err != nil {
+		return 0, remoteError(err)
+	}
+
+	return resp.GetTotalSize(), nil
+}
+
+// UpdateOrderStatus updates the status of an order on the remote service
+func (s *RemoteOrderService) UpdateOrderStatus(ctx context.Context, orderID string, status domain.OrderStatus) (*domain.Order, error) {
+	s.log.Infof("RemoteOrderService_UpdateOrderStatus orderID=%s status=%d",
+		orderID, int(status))
+
+	resp, err := s.client.UpdateOrderStatus(ctx, &orderv1.UpdateOrderStatusRequest{
+		OrderId: orderID,
+		Status:  protoconv.StatusToProto(status),
+	})
+	if err != nil {
+		return nil, remoteTransitionError(err)
+	}
+
+	return protoconv.OrderFromProto(resp.Order), nil
+}
+
+// CancelOrder cancels an order on the remote service, which returns its stock
+func (s *RemoteOrderService) CancelOrder(ctx context.Context, orderID string) (*domain.Order, error) {
+	s.log.Infof("RemoteOrderService_CancelOrder orderID=%s", orderID)
+
+	resp, err := s.client.CancelOrder(ctx, &orderv1.CancelOrderRequest{OrderId: orderID})
+	if err != nil {
+		return nil, remoteTransitionError(err)
+	}
+
+	return protoconv.OrderFromProto(resp.Order), nil
+}
+
+// ShipOrder ships an order on the remote service, which checks the tracking information
+func (s *RemoteOrderService) ShipOrder(ctx context.Context, orderID string, shipment domain.Shipment) (*domain.Order, error) {
+	s.log.Infof("RemoteOrderService_ShipOrder orderID=%s carrier=%s", orderID, shipment.Carrier)
+
+	resp, err := s.client.UpdateOrderStatus(ctx, &orderv1.UpdateOrderStatusRequest{
+		OrderId:        orderID,
+		Status:         orderv1.OrderStatus_ORDER_STATUS_SHIPPED,
+		TrackingNumber: shipment.TrackingNumber,
+		Carrier:        shipment.Carrier,
+	})
+	if err != nil {
+		return nil, remoteTransitionError(err)
+	}
+
+	return protoconv.OrderFromProto(resp.Order), nil
+}
+
+// Reorder is not supported because the order API has no reorder RPC
+func (s *RemoteOrderService) Reorder(ctx context.Context, orderID string, source domain.PriceSource) (*domain.Order, error) {
+	return nil, fmt.Errorf("%w: reorders are not available in %s mode", ErrUnsupported, ModeRemote)
+}
+
+// CountOrdersByPeriod is not supported because the order API has no time series RPC
+func (s *RemoteOrderService) CountOrdersByPeriod(ctx context.Context, customerID string, bucket domain.TimeBucket, from, to time.Time) ([]domain.PeriodCount, error) {
+	return nil, fmt.Errorf("%w: order time series are not available in %s mode", ErrUnsupported, ModeRemote)
+}
+
+// GetOrderEvents is not supported because the order API does not expose outbox entries
+func (s *RemoteOrderService) GetOrderEvents(ctx context.Context, orderID string) ([]OrderEvent, error) {
+	return nil, fmt.Errorf("%w: order events are not available in %s mode", ErrUnsupported, ModeRemote)
+}
+
+// ReplayEvents is not supported because replays must run against the service owning the outbox
+func (s *RemoteOrderService) ReplayEvents(ctx context.Context, aggregateID string, from, to time.Time) (int, error) {
+	return 0, fmt.Errorf("%w: events cannot be replayed in %s mode", ErrUnsupported, ModeRemote)
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-bootiful-ordering/internal/order/domain"
+	"strings"
+	"time"
+)
+
+// ShippingEstimator estimates the cost and delivery date of shipping items to an address.
+// The default implementation uses flat rates; a carrier integration can replace it.
+type ShippingEstimator interface {
+	EstimateShipping(ctx context.Context, address domain.Address, items []domain.OrderItem) (domain.ShippingEstimate, error)
+}
+
+// ShippingRate is the flat shipping rate of one destination country
+type ShippingRate struct {
+	BaseCost    int64
+	PerItemCost int64
+	TransitDays int
+}
+
+// FlatRateShippingEstimator provides an implementation of ShippingEstimator charging a base
+// cost per order plus a cost per unit, with a fixed transit time per destination country.
+// Products carry no weight, so every unit costs the same to ship.
+type FlatRateShippingEstimator struct {
+	rates map[string]ShippingRate
+	now   func() time.Time
+}
+
+// NewFlatRateShippingEstimator creates a new FlatRateShippingEstimator from rates keyed by country code
+func NewFlatRateShippingEstimator(rates map[string]ShippingRate) *FlatRateShippingEstimator {
+	normalized := make(map[string]ShippingRate, len(rates))
+	for country, rate := range rates {
+		normalized[strings.ToUpper(country)] = rate
+	}
+	return &FlatRateShippingEstimator{
+		rates: normalized,
+		now:   time.Now,
+	}
+}
+
+// EstimateShipping returns the cost and delivery date of shipping the items to the address
+func (e *FlatRateShippingEstimator) EstimateShipping(ctx context.Context, address domain.Address, items []domain.OrderItem) (domain.ShippingEstimate, error) {
+	// Validate the address and items
+	var problems []string
+	var validationErr *domain.ValidationError
+	if err := address.Validate(); errors.As(err, &validationErr) {
+		problems = append(problems, validationErr.Problems...)
+	}
+
+	if len(items) == 0 {
+		problems = append(problems, "at least one item is required")
+	}
+
+	for idx, item := range items {
+		if err := item.Validate(); errors.As(err, &validationErr) {
+			for _, problem := range validationErr.Problems {
+				problems = append(problems, fmt.Sprintf("items[%d]: %s", idx, problem))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return domain.ShippingEstimate{}, &domain.ValidationError{Problems: problems}
+	}
+
+	// Look up the destination rate
+	country := strings.ToUpper(strings.TrimSpace(address.Country))
+	rate, ok := e.rates[country]
+	if !ok {
+		return domain.ShippingEstimate{}, fmt.Errorf("%w: no shipping rate for country %q", domain.ErrUnknownDestination, country)
+	}
+
+	// Charge the base cost plus the per-unit cost
+	var units int64
+	for _, item := range items {
+		units += int64(item.Quantity)
+	}
+
+	return domain.ShippingEstimate{
+		Cost:              rate.BaseCost + rate.PerItemCost*units,
+		TransitDays:       rate.TransitDays,
+		EstimatedDelivery: e.now().UTC().AddDate(0, 0, rate.TransitDays).Truncate(24 * time.Hour),
+	}, nil
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go-bootiful-ordering/gen/product/v1"
+	"go-bootiful-ordering/internal/order/domain"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// StockReserver takes the stock of ordered items from the product catalog and returns it
+// when the order cannot be completed
+type StockReserver interface {
+	Reserve(ctx context.Context, items []domain.OrderItem) error
+	Release(ctx context.Context, items []domain.OrderItem) error
+}
+
+// NoopStockReserver provides an implementation of StockReserver that does not track stock,
+// for deployments where orders are accepted regardless of the product catalog
+type NoopStockReserver struct{}
+
+// Reserve accepts every order
+func (NoopStockReserver) Reserve(ctx context.Context, items []domain.OrderItem) error {
+	return nil
+}
+
+// Release does nothing
+func (NoopStockReserver) Release(ctx context.Context, items []domain.OrderItem) error {
+	return nil
+}
+
+// GRPCStockReserver provides an implementation of StockReserver backed by the product service.
+// The product service reserves all items of an order in one transaction, or none of them.
+type GRPCStockReserver struct {
+	client productv1.ProductServiceClient
+}
+
+// NewGRPCStockReserver creates a new GRPCStockReserver
+func NewGRPCStockReserver(client productv1.ProductServiceClient) *GRPCStockReserver {
+	return &GRPCStockReserver{client: client}
+}
+
+// Reserve decrements the stock of the ordered products
+func (r *GRPCStockReserver) Reserve(ctx context.Context, items []domain.OrderItem) error {
+	_, err := r.client.ReserveStock(ctx, &productv1.ReserveStockRequest{Items: toStockItems(items)})
+	return productServiceError(err)
+}
+
+// Release increments the stock of the ordered products
+func (r *GRPCStockReserver) Release(ctx context.Context, items []domain.OrderItem) error {
+	_, err := r.client.ReleaseStock(ctx, &productv1.ReleaseStockRequest{Items: toStockItems(items)})
+	return productServiceError(err)
+}
+
+// toStockItems converts order items to protobuf stock items
+func toStockItems(items []domain.OrderItem) []*productv1.StockItem {
+	stockItems := make([]*productv1.StockItem, len(items))
+	for i, item := range items {
+		stockItems[i] = &productv1.StockItem{ProductId: item.ProductID, Quantity: item.Quantity}
+	}
+	return stockItems
+}
+
+// productServiceError converts a product service error into the order domain errors
+func productServiceError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	switch st.Code() {
+	case codes.FailedPrecondition:
+		return fmt.Errorf("%w: %s", domain.ErrInsufficientStock, st.Message())
+	case codes.InvalidArgument, codes.NotFound:
+		return fmt.Errorf("%w: %s", domain.ErrInvalidArgument, st.Message())
+	default:
+		return fmt.Errorf("product service: %w", err)
+	}
+}
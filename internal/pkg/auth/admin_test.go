@@ -0,0 +1,184 @@
+package auth
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const guardedMethod = "/product.v1.ProductService/UpdateStock"
+
+func TestTrusted(t *testing.T) {
+	guard, err := NewAdminGuard("secret", []string{"10.0.0.0/8", "192.168.1.0/24", "fd00::/8"}, nil)
+	if err != nil {
+		t.Fatalf("NewAdminGuard() error = %v", err)
+	}
+
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{addr: "10.1.2.3:5000", want: true},
+		{addr: "10.1.2.3", want: true},
+		{addr: "192.168.1.255:80", want: true},
+		{addr: "192.168.2.1:80", want: false},
+		{addr: "11.0.0.1:5000", want: false},
+		{addr: "[fd00::1]:5000", want: true},
+		{addr: "[fe80::1]:5000", want: false},
+		{addr: "localhost:5000", want: false},
+		{addr: "", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := guard.trusted(tt.addr); got != tt.want {
+			t.Errorf("trusted(%q) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+
+	untrusting, _ := NewAdminGuard("secret", nil, nil)
+	if untrusting.trusted("10.1.2.3:5000") {
+		t.Errorf("trusted() = true without trusted networks")
+	}
+}
+
+func TestNewAdminGuardRejectsInvalidNetworks(t *testing.T) {
+	if _, err := NewAdminGuard("secret", []string{"10.0.0.0/33"}, nil); err == nil {
+		t.Errorf("NewAdminGuard() error = nil for an invalid CIDR")
+	}
+}
+
+// guardCases are the requests both transports must decide alike: a guard with or without
+// an API key, a peer inside or outside 10.0.0.0/8 and the key presented, if any
+var guardCases = []struct {
+	name     string
+	apiKey   string
+	peerAddr string
+	key      string
+	wantHTTP int
+	wantGRPC codes.Code
+}{
+	{name: "trusted peer without a key", apiKey: "secret", peerAddr: "10.0.0.7:41000", wantHTTP: http.StatusOK, wantGRPC: codes.OK},
+	{name: "trusted peer with the admin API disabled", peerAddr: "10.0.0.7:41000", wantHTTP: http.StatusOK, wantGRPC: codes.OK},
+	{name: "outside peer with the key", apiKey: "secret", peerAddr: "203.0.113.5:41000", key: "secret", wantHTTP: http.StatusOK, wantGRPC: codes.OK},
+	{name: "outside peer without a key", apiKey: "secret", peerAddr: "203.0.113.5:41000", wantHTTP: http.StatusUnauthorized, wantGRPC: codes.Unauthenticated},
+	{name: "outside peer with a wrong key", apiKey: "secret", peerAddr: "203.0.113.5:41000", key: "guess", wantHTTP: http.StatusUnauthorized, wantGRPC: codes.Unauthenticated},
+	{name: "outside peer with the admin API disabled", peerAddr: "203.0.113.5:41000", key: "secret", wantHTTP: http.StatusForbidden, wantGRPC: codes.PermissionDenied},
+}
+
+func TestGinMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for _, tt := range guardCases {
+		t.Run(tt.name, func(t *testing.T) {
+			guard, err := NewAdminGuard(tt.apiKey, []string{"10.0.0.0/8"}, nil)
+			if err != nil {
+				t.Fatalf("NewAdminGuard() error = %v", err)
+			}
+			engine := gin.New()
+			engine.GET("/admin", guard.GinMiddleware(), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+			req.RemoteAddr = tt.peerAddr
+			// Forwarding headers do not make a request trusted
+			req.Header.Set("X-Forwarded-For", "10.0.0.1")
+			if tt.key != "" {
+				req.Header.Set(APIKeyHeader, tt.key)
+			}
+			rec := httptest.NewRecorder()
+			engine.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantHTTP {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantHTTP)
+			}
+		})
+	}
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+
+	for _, tt := range guardCases {
+		t.Run(tt.name, func(t *testing.T) {
+			guard, err := NewAdminGuard(tt.apiKey, []string{"10.0.0.0/8"}, []string{guardedMethod})
+			if err != nil {
+				t.Fatalf("NewAdminGuard() error = %v", err)
+			}
+			addr, err := net.ResolveTCPAddr("tcp", tt.peerAddr)
+			if err != nil {
+				t.Fatalf("ResolveTCPAddr() error = %v", err)
+			}
+			ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: addr})
+			md := metadata.Pairs("x-forwarded-for", "10.0.0.1")
+			if tt.key != "" {
+				md.Append(strings.ToLower(APIKeyHeader), tt.key)
+			}
+			ctx = metadata.NewIncomingContext(ctx, md)
+
+			_, err = guard.UnaryServerInterceptor()(ctx, nil, &grpc.UnaryServerInfo{FullMethod: guardedMethod}, handler)
+			if got := status.Code(err); got != tt.wantGRPC {
+				t.Errorf("code = %v, want %v", got, tt.wantGRPC)
+			}
+		})
+	}
+}
+
+func TestUnaryServerInterceptorPassesUnguardedMethods(t *testing.T) {
+	guard, err := NewAdminGuard("secret", nil, []string{guardedMethod})
+	if err != nil {
+		t.Fatalf("NewAdminGuard() error = %v", err)
+	}
+
+	called := false
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		called = true
+		return "ok", nil
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: "/product.v1.ProductService/GetProduct"}
+	if _, err := guard.UnaryServerInterceptor()(context.Background(), nil, info, handler); err != nil || !called {
+		t.Errorf("interceptor() error = %v, called = %v, want the unguarded method served", err, called)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	server := grpc.NewServer()
+	healthpb.RegisterHealthServer(server, health.NewServer())
+
+	tests := []struct {
+		name    string
+		methods []string
+		wantErr string
+	}{
+		{name: "none", methods: nil},
+		{name: "registered", methods: []string{"/grpc.health.v1.Health/Check"}},
+		{name: "misspelt", methods: []string{"/grpc.health.v1.Health/Check", "/grpc.health.v1.Health/Chek"}, wantErr: "/grpc.health.v1.Health/Chek"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			guard, err := NewAdminGuard("secret", nil, tt.methods)
+			if err != nil {
+				t.Fatalf("NewAdminGuard() error = %v", err)
+			}
+			err = guard.Validate(server)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want one naming %s", err, tt.wantErr)
+			}
+		})
+	}
+}
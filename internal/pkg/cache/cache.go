@@ -0,0 +1,28 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrMiss is returned by Get when the key is not cached
+var ErrMiss = errors.New("cache miss")
+
+// Cache defines the key/value operations the caching repositories depend on
+type Cache interface {
+	// Get returns the value stored under key, or ErrMiss when there is none
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// MGet returns the values stored under keys in one round trip, with nil for keys that are not cached
+	MGet(ctx context.Context, keys ...string) ([][]byte, error)
+
+	// Set stores value under key for the given time to live
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+
+	// Del removes the given keys; missing keys are ignored
+	Del(ctx context.Context, keys ...string) error
+
+	// Scan returns the keys matching a glob pattern such as "category:*"
+	Scan(ctx context.Context, pattern string) ([]string, error)
+}
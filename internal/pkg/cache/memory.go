@@ -0,0 +1,208 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// memoryEntry is a value held by MemoryCache
+type memoryEntry struct {
+	value     []byte
+	expiresAt time.Time // zero means the entry never expires
+}
+
+// MemoryCache implements Cache in process memory, for tests and single-instance setups
+type MemoryCache struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+	now     func() time.Time
+}
+
+// NewMemoryCache creates a new MemoryCache
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{
+		entries: make(map[string]memoryEntry),
+		now:     time.Now,
+	}
+}
+
+// live returns the entry under key if it exists and has not expired; callers hold the lock
+func (c *MemoryCache) live(key string) (memoryEntry, bool) {
+	entry, ok := c.entries[key]
+	if !ok {
+		return memoryEntry{}, false
+	}
+	if !entry.expiresAt.IsZero() && !c.now().Before(entry.expiresAt) {
+		delete(c.entries, key)
+		return memoryEntry{}, false
+	}
+	return entry, true
+}
+
+// Get returns the value stored under key, or ErrMiss when there is none
+func (c *MemoryCache) Get(ctx context.Context, key string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.live(key)
+	if !ok {
+		return nil, ErrMiss
+	}
+	return append([]byte(nil), entry.value...), nil
+}
+
+// MGet returns the values stored under keys, with nil for keys that are not cached
+func (c *MemoryCache) MGet(ctx context.Context, keys ...string) ([][]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	values := make([][]byte, len(keys))
+	for i, key := range keys {
+		if entry, ok := c.live(key); ok {
+			values[i] = append([]byte(nil), entry.value...)
+		}
+	}
+	return values, nil
+}
+
+// Set stores value under key for the given time to live; a ttl of zero or less never expires
+func (c *MemoryCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	entry := memoryEntry{value: append([]byte(nil), value...)}
+	if ttl > 0 {
+		entry.expiresAt = c.now().Add(ttl)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = entry
+	return nil
+}
+
+// Del removes the given keys; missing keys are ignored
+func (c *MemoryCache) Del(ctx context.Context, keys ...string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		delete(c.entries, key)
+	}
+	return nil
+}
+
+// Scan returns the keys matching a glob pattern, with the semantics of Redis SCAN MATCH
+func (c *MemoryCache) Scan(ctx context.Context, pattern string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var keys []string
+	for key := range c.entries {
+		if _, ok := c.live(key); !ok {
+			continue
+		}
+		if globMatch(pattern, key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// globMatch reports whether key matches a glob pattern the way Redis SCAN MATCH does:
+// * matches any run of bytes, / included, ? matches one byte, [abc], [a-z] and [^a] match
+// a set of bytes and a backslash escapes the byte after it. As in Redis, a malformed pattern
+// such as an unterminated [ is matched as far as it goes rather than rejected.
+func globMatch(pattern, key string) bool {
+	p, k := 0, 0
+	for p < len(pattern) && k < len(key) {
+		switch pattern[p] {
+		case '*':
+			for p+1 < len(pattern) && pattern[p+1] == '*' {
+				p++
+			}
+			if p+1 == len(pattern) {
+				return true
+			}
+			for i := k; i < len(key); i++ {
+				if globMatch(pattern[p+1:], key[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			k++
+		case '[':
+			p++
+			negate := p < len(pattern) && pattern[p] == '^'
+			if negate {
+				p++
+			}
+			matched := false
+			for ; p < len(pattern) && pattern[p] != ']'; p++ {
+				switch {
+				case pattern[p] == '\\' && p+1 < len(pattern):
+					p++
+					if pattern[p] == key[k] {
+						matched = true
+					}
+				case p+2 < len(pattern) && pattern[p+1] == '-':
+					start, end := pattern[p], pattern[p+2]
+					if start > end {
+						start, end = end, start
+					}
+					if key[k] >= start && key[k] <= end {
+						matched = true
+					}
+					p += 2
+				case pattern[p] == key[k]:
+					matched = true
+				}
+			}
+			if p == len(pattern) {
+				// An unterminated set ends the pattern
+				p--
+			}
+			if matched == negate {
+				return false
+			}
+			k++
+		case '\\':
+			if p+1 < len(pattern) {
+				p++
+			}
+			fallthrough
+		default:
+			if pattern[p] != key[k] {
+				return false
+			}
+			k++
+		}
+		p++
+	}
+
+	// The key is consumed; only trailing stars can still match the empty rest
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern) && k == len(key)
+}
@@ -0,0 +1,94 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestGlobMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		key     string
+		want    bool
+	}{
+		{"category:*", "category:books:10::", true},
+		{"category:*", "category:books:10::a/b+c==", true},
+		{"category:books:*", "category:books:10:..-:tok/en", true},
+		{"category:books:*", "category:games:10::", false},
+		{"*", "", true},
+		{"*", "a/b", true},
+		{"a*b*c", "a/x/b/y/c", true},
+		{"a*b*c", "a/x/b/y/d", false},
+		{"**", "anything", true},
+		{"product:?", "product:1", true},
+		{"product:?", "product:12", false},
+		{"product:?", "product:/", true},
+		{"product:[12]", "product:2", true},
+		{"product:[12]", "product:3", false},
+		{"product:[^12]", "product:3", true},
+		{"product:[^12]", "product:1", false},
+		{"product:[a-c]", "product:b", true},
+		{"product:[c-a]", "product:b", true},
+		{"product:[a-c]", "product:d", false},
+		{`product:[\]]`, "product:]", true},
+		{`category:\*:*`, "category:*:10", true},
+		{`category:\*:*`, "category:x:10", false},
+		{`category:\?`, "category:?", true},
+		{`category:\[books\]:*`, "category:[books]:10", true},
+		{"category:[ab", "category:a", true},
+		{"category:[ab", "category:c", false},
+		{"category:", "category:x", false},
+		{"category:x*", "category:", false},
+	}
+
+	for _, tt := range tests {
+		if got := globMatch(tt.pattern, tt.key); got != tt.want {
+			t.Errorf("globMatch(%q, %q) = %v, want %v", tt.pattern, tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestMemoryCacheScanMatchesAcrossSlashes(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache()
+	for _, key := range []string{"category:books:10::", "category:books:10::page/2", "category:games:10::", "product:1"} {
+		if err := c.Set(ctx, key, []byte("v"), 0); err != nil {
+			t.Fatalf("Set(%q) error = %v", key, err)
+		}
+	}
+
+	keys, err := c.Scan(ctx, "category:books:*")
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	sort.Strings(keys)
+	want := []string{"category:books:10::", "category:books:10::page/2"}
+	if len(keys) != len(want) || keys[0] != want[0] || keys[1] != want[1] {
+		t.Errorf("Scan() = %q, want %q", keys, want)
+	}
+}
+
+func TestMemoryCacheExpiry(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1000, 0)
+	c := NewMemoryCache()
+	c.now = func() time.Time { return now }
+
+	if err := c.Set(ctx, "key", []byte("value"), time.Minute); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if value, err := c.Get(ctx, "key"); err != nil || string(value) != "value" {
+		t.Fatalf("Get() = %q, %v, want the value before it expires", value, err)
+	}
+
+	now = now.Add(time.Minute)
+	if _, err := c.Get(ctx, "key"); !errors.Is(err, ErrMiss) {
+		t.Errorf("Get() error = %v after the TTL, want ErrMiss", err)
+	}
+	if keys, _ := c.Scan(ctx, "*"); len(keys) != 0 {
+		t.Errorf("Scan() = %q after the TTL, want no keys", keys)
+	}
+}
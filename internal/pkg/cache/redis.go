@@ -0,0 +1,75 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// scanCount is the number of keys Redis is asked to examine per SCAN call
+const scanCount = 100
+
+// RedisCache implements Cache using go-redis
+type RedisCache struct {
+	client *redis.Client
+}
+
+// NewRedisCache creates a new RedisCache
+func NewRedisCache(client *redis.Client) *RedisCache {
+	return &RedisCache{
+		client: client,
+	}
+}
+
+// Get returns the value stored under key, or ErrMiss when there is none
+func (c *RedisCache) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := c.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrMiss
+	}
+	return value, err
+}
+
+// MGet returns the values stored under keys with a single MGET, with nil for keys that are not cached
+func (c *RedisCache) MGet(ctx context.Context, keys ...string) ([][]byte, error) {
+	values := make([][]byte, len(keys))
+	if len(keys) == 0 {
+		return values, nil
+	}
+
+	results, err := c.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	for i, result := range results {
+		if value, ok := result.(string); ok {
+			values[i] = []byte(value)
+		}
+	}
+	return values, nil
+}
+
+// Set stores value under key for the given time to live
+func (c *RedisCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.client.Set(ctx, key, value, ttl).Err()
+}
+
+// Del removes the given keys; missing keys are ignored
+func (c *RedisCache) Del(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	return c.client.Del(ctx, keys...).Err()
+}
+
+// Scan returns the keys matching a glob pattern, iterating with SCAN rather than blocking Redis with KEYS
+func (c *RedisCache) Scan(ctx context.Context, pattern string) ([]string, error) {
+	var keys []string
+	iter := c.client.Scan(ctx, 0, pattern, scanCount).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	return keys, iter.Err()
+}
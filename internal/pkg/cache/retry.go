@@ -0,0 +1,119 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+const (
+	// maxRetryAttempts bounds the configured attempts, so a misconfiguration cannot turn a
+	// Redis outage into requests that hang on their retries
+	maxRetryAttempts = 10
+	// maxRetryDelay caps the wait between two attempts
+	maxRetryDelay = time.Second
+)
+
+// RetryConfig controls how RetryingCache retries failed operations
+type RetryConfig struct {
+	// Attempts is the number of times an operation is tried, the first one included;
+	// 1 or less disables retries
+	Attempts int
+	// BaseDelay is the wait before the first retry, doubled for every retry after it
+	BaseDelay time.Duration
+}
+
+// Validate checks that the attempts and base delay are within bounds
+func (c RetryConfig) Validate() error {
+	if c.Attempts < 0 || c.Attempts > maxRetryAttempts {
+		return fmt.Errorf("cache retry attempts must be between 0 and %d, got %d", maxRetryAttempts, c.Attempts)
+	}
+	if c.BaseDelay < 0 {
+		return fmt.Errorf("cache retry base delay cannot be negative, got %s", c.BaseDelay)
+	}
+	return nil
+}
+
+// RetryingCache wraps a Cache and retries Get, Set and Del when they fail, waiting with
+// exponential backoff and jitter between attempts so the instances hit by the same blip do
+// not retry in lockstep. Misses are not retried, and neither is an operation whose caller
+// has gone away. MGet and Scan are passed through as they are.
+type RetryingCache struct {
+	Cache
+	config RetryConfig
+	jitter func() float64 // Returns a random factor in [0, 1)
+}
+
+// NewRetryingCache creates a new RetryingCache around cache
+func NewRetryingCache(cache Cache, config RetryConfig) *RetryingCache {
+	return &RetryingCache{
+		Cache:  cache,
+		config: config,
+		jitter: rand.Float64,
+	}
+}
+
+// Get returns the value stored under key, or ErrMiss when there is none
+func (c *RetryingCache) Get(ctx context.Context, key string) ([]byte, error) {
+	var value []byte
+	err := c.retry(ctx, func() error {
+		var err error
+		value, err = c.Cache.Get(ctx, key)
+		return err
+	})
+	return value, err
+}
+
+// Set stores value under key for the given time to live
+func (c *RetryingCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.retry(ctx, func() error {
+		return c.Cache.Set(ctx, key, value, ttl)
+	})
+}
+
+// Del removes the given keys; missing keys are ignored
+func (c *RetryingCache) Del(ctx context.Context, keys ...string) error {
+	return c.retry(ctx, func() error {
+		return c.Cache.Del(ctx, keys...)
+	})
+}
+
+// retry runs op until it succeeds, fails with an error not worth retrying or has been
+// tried the configured number of times, returning its last error
+func (c *RetryingCache) retry(ctx context.Context, op func() error) error {
+	err := op()
+	for attempt := 1; attempt < c.config.Attempts && retryable(ctx, err); attempt++ {
+		timer := time.NewTimer(c.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		err = op()
+	}
+	return err
+}
+
+// backoff returns the wait before the given retry: the base delay doubled for every earlier
+// retry and capped, of which a random half is waited
+func (c *RetryingCache) backoff(retry int) time.Duration {
+	delay := c.config.BaseDelay
+	for i := 1; i < retry && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay/2 + time.Duration(c.jitter()*float64(delay/2))
+}
+
+// retryable reports whether a failed operation may succeed when tried again
+func retryable(ctx context.Context, err error) bool {
+	if err == nil || errors.Is(err, ErrMiss) || ctx.Err() != nil {
+		return false
+	}
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
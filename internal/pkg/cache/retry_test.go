@@ -0,0 +1,185 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+var errUnavailable = errors.New("connection refused")
+
+// flakyCache fails the first failures operations with err and then passes them to a
+// MemoryCache, counting every call
+type flakyCache struct {
+	*MemoryCache
+
+	mu       sync.Mutex
+	failures int
+	err      error
+	calls    int
+}
+
+func (c *flakyCache) fail() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls++
+	if c.failures > 0 {
+		c.failures--
+		return c.err
+	}
+	return nil
+}
+
+func (c *flakyCache) callCount() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls
+}
+
+func (c *flakyCache) Get(ctx context.Context, key string) ([]byte, error) {
+	if err := c.fail(); err != nil {
+		return nil, err
+	}
+	return c.MemoryCache.Get(ctx, key)
+}
+
+func (c *flakyCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := c.fail(); err != nil {
+		return err
+	}
+	return c.MemoryCache.Set(ctx, key, value, ttl)
+}
+
+func (c *flakyCache) Del(ctx context.Context, keys ...string) error {
+	if err := c.fail(); err != nil {
+		return err
+	}
+	return c.MemoryCache.Del(ctx, keys...)
+}
+
+func newFlakyCache(failures int, err error) *flakyCache {
+	return &flakyCache{MemoryCache: NewMemoryCache(), failures: failures, err: err}
+}
+
+func TestRetryConfigValidate(t *testing.T) {
+	tests := []struct {
+		config  RetryConfig
+		wantErr bool
+	}{
+		{config: RetryConfig{}},
+		{config: RetryConfig{Attempts: 3, BaseDelay: 10 * time.Millisecond}},
+		{config: RetryConfig{Attempts: maxRetryAttempts}},
+		{config: RetryConfig{Attempts: maxRetryAttempts + 1}, wantErr: true},
+		{config: RetryConfig{Attempts: -1}, wantErr: true},
+		{config: RetryConfig{Attempts: 3, BaseDelay: -time.Millisecond}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		if err := tt.config.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("Validate(%+v) error = %v, want error: %v", tt.config, err, tt.wantErr)
+		}
+	}
+}
+
+func TestRetryingCacheRecoversAfterFailures(t *testing.T) {
+	ctx := context.Background()
+	flaky := newFlakyCache(2, errUnavailable)
+	c := NewRetryingCache(flaky, RetryConfig{Attempts: 3, BaseDelay: time.Millisecond})
+
+	if err := c.Set(ctx, "key", []byte("value"), time.Minute); err != nil {
+		t.Fatalf("Set() error = %v, want success on the third attempt", err)
+	}
+	if calls := flaky.callCount(); calls != 3 {
+		t.Errorf("Set() tried %d times, want 3", calls)
+	}
+	if value, err := flaky.MemoryCache.Get(ctx, "key"); err != nil || string(value) != "value" {
+		t.Errorf("cached value = %q, %v, want the value set by the retry", value, err)
+	}
+}
+
+func TestRetryingCacheStopsAtAttempts(t *testing.T) {
+	for _, attempts := range []int{0, 1, 3, maxRetryAttempts} {
+		flaky := newFlakyCache(maxRetryAttempts+5, errUnavailable)
+		c := NewRetryingCache(flaky, RetryConfig{Attempts: attempts})
+
+		if _, err := c.Get(context.Background(), "key"); !errors.Is(err, errUnavailable) {
+			t.Errorf("Get() with %d attempts error = %v, want the last failure", attempts, err)
+		}
+		want := attempts
+		if want < 1 {
+			want = 1
+		}
+		if calls := flaky.callCount(); calls != want {
+			t.Errorf("Get() with %d attempts tried %d times, want %d", attempts, calls, want)
+		}
+	}
+}
+
+func TestRetryingCacheDoesNotRetryMisses(t *testing.T) {
+	flaky := newFlakyCache(0, nil)
+	c := NewRetryingCache(flaky, RetryConfig{Attempts: 3})
+
+	if _, err := c.Get(context.Background(), "missing"); !errors.Is(err, ErrMiss) {
+		t.Fatalf("Get() error = %v, want ErrMiss", err)
+	}
+	if calls := flaky.callCount(); calls != 1 {
+		t.Errorf("Get() of a miss tried %d times, want 1", calls)
+	}
+}
+
+func TestRetryingCacheStopsWhenContextEnds(t *testing.T) {
+	flaky := newFlakyCache(maxRetryAttempts, errUnavailable)
+	c := NewRetryingCache(flaky, RetryConfig{Attempts: maxRetryAttempts, BaseDelay: time.Hour})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := c.Del(ctx, "key")
+
+	if !errors.Is(err, errUnavailable) {
+		t.Errorf("Del() error = %v, want the failure before the context ended", err)
+	}
+	if calls := flaky.callCount(); calls != 1 {
+		t.Errorf("Del() tried %d times, want 1 before the context ended", calls)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Del() returned after %s, want right after the context ended", elapsed)
+	}
+}
+
+func TestRetryingCacheDoesNotRetryContextErrors(t *testing.T) {
+	flaky := newFlakyCache(maxRetryAttempts, context.DeadlineExceeded)
+	c := NewRetryingCache(flaky, RetryConfig{Attempts: 3})
+
+	if _, err := c.Get(context.Background(), "key"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Get() error = %v, want DeadlineExceeded", err)
+	}
+	if calls := flaky.callCount(); calls != 1 {
+		t.Errorf("Get() failing with a context error tried %d times, want 1", calls)
+	}
+}
+
+func TestRetryingCacheBackoff(t *testing.T) {
+	tests := []struct {
+		retry  int
+		jitter float64
+		want   time.Duration
+	}{
+		{retry: 1, jitter: 0, want: 5 * time.Millisecond},
+		{retry: 1, jitter: 0.5, want: 7500 * time.Microsecond},
+		{retry: 2, jitter: 0, want: 10 * time.Millisecond},
+		{retry: 3, jitter: 0, want: 20 * time.Millisecond},
+		{retry: 9, jitter: 0, want: maxRetryDelay / 2},
+		{retry: 9, jitter: 0.9999, want: maxRetryDelay - 50*time.Microsecond},
+	}
+
+	for _, tt := range tests {
+		c := NewRetryingCache(NewMemoryCache(), RetryConfig{Attempts: maxRetryAttempts, BaseDelay: 10 * time.Millisecond})
+		c.jitter = func() float64 { return tt.jitter }
+		if got := c.backoff(tt.retry); got != tt.want {
+			t.Errorf("backoff(%d) with jitter %v = %s, want %s", tt.retry, tt.jitter, got, tt.want)
+		}
+	}
+}
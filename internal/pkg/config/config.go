@@ -2,28 +2,49 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/spf13/viper"
+	"go-bootiful-ordering/internal/pkg/metrics"
 )
 
 // Config represents the application configuration
 type Config struct {
-	Service   ServiceConfig   `yaml:"service" mapstructure:"service"`
-	Jaeger    TempoConfig     `yaml:"jaeger" mapstructure:"jaeger"` // Still using "jaeger" in YAML for backward compatibility
-	Tempo     TempoConfig     `yaml:"tempo" mapstructure:"tempo"`   // New field for explicit Tempo config
-	Pyroscope PyroscopeConfig `yaml:"pyroscope" mapstructure:"pyroscope"`
-	Redis     RedisConfig     `yaml:"redis" mapstructure:"redis"`
-	DB        DBConfig        `yaml:"db" mapstructure:"db"`
-	Server    ServerConfig    `yaml:"server" mapstructure:"server"`
+	Service     ServiceConfig     `yaml:"service" mapstructure:"service"`
+	Jaeger      TempoConfig       `yaml:"jaeger" mapstructure:"jaeger"` // Still using "jaeger" in YAML for backward compatibility
+	Tempo       TempoConfig       `yaml:"tempo" mapstructure:"tempo"`   // New field for explicit Tempo config
+	Tracing     TracingConfig     `yaml:"tracing" mapstructure:"tracing"`
+	Pyroscope   PyroscopeConfig   `yaml:"pyroscope" mapstructure:"pyroscope"`
+	Redis       RedisConfig       `yaml:"redis" mapstructure:"redis"`
+	DB          DBConfig          `yaml:"db" mapstructure:"db"`
+	Server      ServerConfig      `yaml:"server" mapstructure:"server"`
+	Product     ProductConfig     `yaml:"product" mapstructure:"product"`
+	Routes      RoutesConfig      `yaml:"routes" mapstructure:"routes"`
+	Security    SecurityConfig    `yaml:"security" mapstructure:"security"`
+	Outbox      OutboxConfig      `yaml:"outbox" mapstructure:"outbox"`
+	ID          IDConfig          `yaml:"id" mapstructure:"id"`
+	Tenant      TenantConfig      `yaml:"tenant" mapstructure:"tenant"`
+	Paging      PagingConfig      `yaml:"paging" mapstructure:"paging"`
+	Shipping    ShippingConfig    `yaml:"shipping" mapstructure:"shipping"`
+	Stock       StockConfig       `yaml:"stock" mapstructure:"stock"`
+	Customer    CustomerConfig    `yaml:"customer" mapstructure:"customer"`
+	Idempotency IdempotencyConfig `yaml:"idempotency" mapstructure:"idempotency"`
+	Order       OrderConfig       `yaml:"order" mapstructure:"order"`
+	Metrics     MetricsConfig     `yaml:"metrics" mapstructure:"metrics"`
+	Debug       DebugConfig       `yaml:"debug" mapstructure:"debug"`
 }
 
 // ServiceConfig holds service-specific configuration
 type ServiceConfig struct {
 	Name string `yaml:"name" mapstructure:"name"`
+	// Mode selects the order service implementation: "db" (default), "memory" or "remote"
+	Mode string `yaml:"mode" mapstructure:"mode"`
+	// RemoteAddr is the host:port of the order service gRPC server that remote mode proxies to
+	RemoteAddr string `yaml:"remoteAddr" mapstructure:"remoteAddr"`
 }
 
 // TempoConfig holds tracing configuration for Tempo
@@ -34,11 +55,41 @@ type TempoConfig struct {
 	LogSpans bool   `yaml:"logSpans" mapstructure:"logSpans"`
 }
 
+// IsSet reports whether the block configures a tracing backend, i.e. has a host
+func (c *TempoConfig) IsSet() bool {
+	return c.Host != ""
+}
+
 // HostPort returns the host:port string for the tracing backend
 func (c *TempoConfig) HostPort() string {
 	return fmt.Sprintf("%s:%s", c.Host, c.Port)
 }
 
+// TracingBackend returns the block spans are reported to: the tempo block, or the legacy
+// jaeger block when only that one is set, in which case legacy is true
+func (c *Config) TracingBackend() (backend TempoConfig, legacy bool) {
+	if !c.Tempo.IsSet() && c.Jaeger.IsSet() {
+		return c.Jaeger, true
+	}
+	return c.Tempo, false
+}
+
+// TracingConfig holds the destinations spans are exported to
+type TracingConfig struct {
+	// Exporters receive every span; when empty, spans go to the tempo block's agent only
+	Exporters []ExporterConfig `yaml:"exporters" mapstructure:"exporters"`
+	// Propagation is the header format of span contexts between services: "b3" (the
+	// default), "w3c" or "both"
+	Propagation string `yaml:"propagation" mapstructure:"propagation"`
+}
+
+// ExporterConfig configures one span exporter
+type ExporterConfig struct {
+	// Type is "jaeger-agent" (UDP host:port), "jaeger-http" (collector URL) or "stdout"
+	Type     string `yaml:"type" mapstructure:"type"`
+	Endpoint string `yaml:"endpoint" mapstructure:"endpoint"`
+}
+
 // PyroscopeConfig holds profiling configuration for Pyroscope
 type PyroscopeConfig struct {
 	Host string `yaml:"host" mapstructure:"host"`
@@ -56,6 +107,25 @@ type RedisConfig struct {
 	Port     string `yaml:"port" mapstructure:"port"`
 	Password string `yaml:"password" mapstructure:"password"`
 	DB       int    `yaml:"db" mapstructure:"db"`
+
+	// Timeouts bounding cache operations; zero uses the client defaults
+	DialTimeout  time.Duration `yaml:"dialTimeout" mapstructure:"dialTimeout"`
+	ReadTimeout  time.Duration `yaml:"readTimeout" mapstructure:"readTimeout"`
+	WriteTimeout time.Duration `yaml:"writeTimeout" mapstructure:"writeTimeout"`
+
+	// CategoryTTLOverrides sets the product cache TTL per category; other categories use ProductTTL and ListTTL
+	CategoryTTLOverrides map[string]time.Duration `yaml:"categoryTTLOverrides" mapstructure:"categoryTTLOverrides"`
+
+	// Retries of failed product cache reads, writes and deletes; RetryAttempts counts the
+	// first try, so 1 or less disables them
+	RetryAttempts  int           `yaml:"retryAttempts" mapstructure:"retryAttempts"`
+	RetryBaseDelay time.Duration `yaml:"retryBaseDelay" mapstructure:"retryBaseDelay"`
+
+	// Product cache TTLs of products and list pages, zero meaning 30 minutes, and the
+	// percentage every cached entry's TTL is randomly moved by
+	ProductTTL       time.Duration `yaml:"productTTL" mapstructure:"productTTL"`
+	ListTTL          time.Duration `yaml:"listTTL" mapstructure:"listTTL"`
+	TTLJitterPercent int           `yaml:"ttlJitterPercent" mapstructure:"ttlJitterPercent"`
 }
 
 // Addr returns the address for the Redis connection
@@ -63,6 +133,178 @@ func (c *RedisConfig) Addr() string {
 	return fmt.Sprintf("%s:%s", c.Host, c.Port)
 }
 
+// SecurityConfig holds authentication configuration
+type SecurityConfig struct {
+	// AdminAPIKey authorizes admin endpoints; admin endpoints are disabled when empty
+	AdminAPIKey string `yaml:"adminApiKey" mapstructure:"adminApiKey"`
+	// TrustedNetworks lists CIDR ranges whose requests bypass the API key check
+	TrustedNetworks []string `yaml:"trustedNetworks" mapstructure:"trustedNetworks"`
+	// AdminMethods lists gRPC methods ("/product.v1.ProductService/UpdateStock") that
+	// require the API key like the admin HTTP endpoints
+	AdminMethods []string `yaml:"adminMethods" mapstructure:"adminMethods"`
+}
+
+// IDConfig holds entity ID generation configuration
+type IDConfig struct {
+	// Generator selects how new entity IDs are generated: "uuid" (default) or "ulid"
+	Generator string `yaml:"generator" mapstructure:"generator"`
+}
+
+// StockConfig holds order stock reservation configuration
+type StockConfig struct {
+	// Reserve takes ordered quantities from product stock when an order is created; disabled by default
+	Reserve bool `yaml:"reserve" mapstructure:"reserve"`
+	// ProductServiceAddr is the host:port of the product service gRPC server holding the stock
+	// and the catalog orders are checked against
+	ProductServiceAddr string `yaml:"productServiceAddr" mapstructure:"productServiceAddr"`
+}
+
+// CustomerConfig holds the customer check run when an order is created
+type CustomerConfig struct {
+	// ServiceURL is the base URL of the customer service orders are checked against; empty accepts every customer
+	ServiceURL string `yaml:"serviceUrl" mapstructure:"serviceUrl"`
+	// Timeout bounds a customer lookup; zero uses 2s
+	Timeout time.Duration `yaml:"timeout" mapstructure:"timeout"`
+}
+
+// IdempotencyConfig holds order creation idempotency configuration
+type IdempotencyConfig struct {
+	// TTL is how long an Idempotency-Key is remembered per customer; zero uses 24h
+	TTL time.Duration `yaml:"ttl" mapstructure:"ttl"`
+	// CacheTTL is how long created orders are cached in Redis for retries; zero disables the cache
+	CacheTTL time.Duration `yaml:"cacheTTL" mapstructure:"cacheTTL"`
+}
+
+// OrderConfig holds order amount and item configuration
+type OrderConfig struct {
+	// MaxTotalAmount caps the total of a single order; zero means no cap
+	MaxTotalAmount int64 `yaml:"maxTotalAmount" mapstructure:"maxTotalAmount"`
+	// ProductIDFormat is the format ("uuid" or "ulid") order item product IDs must have; empty accepts any ID
+	ProductIDFormat string `yaml:"productIdFormat" mapstructure:"productIdFormat"`
+	// ValidateProducts checks every item against the product catalog; disabled by default
+	ValidateProducts bool `yaml:"validateProducts" mapstructure:"validateProducts"`
+	// PricePolicy is "enforce" to reject prices that differ from the catalog or "override" to
+	// replace them with the catalog price; empty uses enforce
+	PricePolicy string `yaml:"pricePolicy" mapstructure:"pricePolicy"`
+	// RequireTracking rejects shipping an order without a tracking number and carrier; disabled by default
+	RequireTracking bool `yaml:"requireTracking" mapstructure:"requireTracking"`
+	// MaxEmbeddedItems caps the items embedded in an order fetched, listed or updated; zero uses 100
+	MaxEmbeddedItems int `yaml:"maxEmbeddedItems" mapstructure:"maxEmbeddedItems"`
+	// ItemStorage is "table" to store items as order_items rows or "jsonb" to store them in
+	// the orders.items column; empty uses table
+	ItemStorage string `yaml:"itemStorage" mapstructure:"itemStorage"`
+}
+
+// MetricsConfig holds Prometheus metrics configuration
+type MetricsConfig struct {
+	Buckets MetricsBucketsConfig `yaml:"buckets" mapstructure:"buckets"`
+}
+
+// MetricsBucketsConfig holds the histogram buckets in seconds; an empty list uses the Prometheus defaults
+type MetricsBucketsConfig struct {
+	// HTTP is used for http_request_duration_seconds
+	HTTP []float64 `yaml:"http" mapstructure:"http"`
+	// GRPC is used for grpc_request_duration_seconds
+	GRPC []float64 `yaml:"grpc" mapstructure:"grpc"`
+	// GRPCClient is used for grpc_client_request_duration_seconds
+	GRPCClient []float64 `yaml:"grpcClient" mapstructure:"grpcClient"`
+	// DB is used for database_query_duration_seconds
+	DB []float64 `yaml:"db" mapstructure:"db"`
+}
+
+// MetricsOptions returns the metrics settings: the configured histogram buckets, and the
+// number of slow requests to keep, which is zero unless the debug endpoint can show them
+func (c *Config) MetricsOptions() metrics.Config {
+	var slowRequests int
+	if c.Debug.Enabled {
+		slowRequests = c.Debug.SlowRequests
+		if slowRequests <= 0 {
+			slowRequests = metrics.DefaultSlowRequests
+		}
+	}
+
+	return metrics.Config{
+		HTTPBuckets:       c.Metrics.Buckets.HTTP,
+		GRPCBuckets:       c.Metrics.Buckets.GRPC,
+		GRPCClientBuckets: c.Metrics.Buckets.GRPCClient,
+		DBBuckets:         c.Metrics.Buckets.DB,
+		SlowRequests:      slowRequests,
+	}
+}
+
+// DebugConfig holds the triage endpoints meant for development and staging
+type DebugConfig struct {
+	// Enabled serves GET /debug/slow; disabled by default
+	Enabled bool `yaml:"enabled" mapstructure:"enabled"`
+	// SlowRequests is how many recent slow requests /debug/slow lists; zero uses 100, at most 1000 are kept
+	SlowRequests int `yaml:"slowRequests" mapstructure:"slowRequests"`
+}
+
+// ShippingConfig holds shipping estimate configuration
+type ShippingConfig struct {
+	// Rates maps ISO country codes to flat shipping rates; destinations without a rate cannot be estimated
+	Rates map[string]ShippingRateConfig `yaml:"rates" mapstructure:"rates"`
+}
+
+// ShippingRateConfig holds the flat shipping rate of one destination
+type ShippingRateConfig struct {
+	BaseCost    int64 `yaml:"baseCost" mapstructure:"baseCost"`
+	PerItemCost int64 `yaml:"perItemCost" mapstructure:"perItemCost"`
+	TransitDays int   `yaml:"transitDays" mapstructure:"transitDays"`
+}
+
+// PagingConfig holds list pagination configuration for the HTTP and gRPC APIs
+type PagingConfig struct {
+	// DefaultPageSize applies when a request leaves page_size unset; zero uses 10
+	DefaultPageSize int32 `yaml:"defaultPageSize" mapstructure:"defaultPageSize"`
+	// MaxPageSize clamps larger page sizes; zero uses 100
+	MaxPageSize int32 `yaml:"maxPageSize" mapstructure:"maxPageSize"`
+}
+
+// TenantConfig holds tenant tagging configuration
+type TenantConfig struct {
+	// Header is the HTTP header and gRPC metadata key carrying the tenant ID; empty uses "X-Tenant-ID"
+	Header string `yaml:"header" mapstructure:"header"`
+	// MetricLabels adds the tenant as a label on business metrics; disabled by default to bound cardinality
+	MetricLabels bool `yaml:"metricLabels" mapstructure:"metricLabels"`
+	// MaxMetricTenants caps distinct tenant label values; later tenants are labelled "other". Zero uses 100
+	MaxMetricTenants int `yaml:"maxMetricTenants" mapstructure:"maxMetricTenants"`
+}
+
+// OutboxConfig holds outbox event configuration
+type OutboxConfig struct {
+	// ReplayTopic receives replayed events so live consumers see them only once; empty replays onto the live topic
+	ReplayTopic string `yaml:"replayTopic" mapstructure:"replayTopic"`
+}
+
+// RoutesConfig holds route registration configuration
+type RoutesConfig struct {
+	// Disabled lists HTTP routes ("POST /products") and gRPC methods
+	// ("/product.v1.ProductService/CreateProduct") to reject
+	Disabled []string `yaml:"disabled" mapstructure:"disabled"`
+}
+
+// ProductConfig holds product service configuration
+type ProductConfig struct {
+	// Maximum field lengths in characters; zero uses the built-in defaults
+	MaxNameLength        int `yaml:"maxNameLength" mapstructure:"maxNameLength"`
+	MaxDescriptionLength int `yaml:"maxDescriptionLength" mapstructure:"maxDescriptionLength"`
+	MaxCategoryLength    int `yaml:"maxCategoryLength" mapstructure:"maxCategoryLength"`
+
+	// DefaultSort orders product listings: "created_at_desc", "name_asc" or "price_asc"; empty lists by ID
+	DefaultSort string `yaml:"defaultSort" mapstructure:"defaultSort"`
+
+	// GoneForDeleted makes GET /products/{id} answer 410 Gone instead of 404 for deleted products
+	GoneForDeleted bool `yaml:"goneForDeleted" mapstructure:"goneForDeleted"`
+
+	// NegativeCacheTTL caches product IDs that were not found for this long; zero disables it
+	NegativeCacheTTL time.Duration `yaml:"negativeCacheTTL" mapstructure:"negativeCacheTTL"`
+
+	// CacheStaleWindow serves cached products and list pages this long past their TTL while
+	// they are refreshed in the background; zero disables it
+	CacheStaleWindow time.Duration `yaml:"cacheStaleWindow" mapstructure:"cacheStaleWindow"`
+}
+
 // DBConfig holds database configuration
 type DBConfig struct {
 	Host     string `yaml:"host" mapstructure:"host"`
@@ -76,26 +318,92 @@ type DBConfig struct {
 	MaxIdleConns    int           `yaml:"maxIdleConns" mapstructure:"maxIdleConns"`
 	MaxOpenConns    int           `yaml:"maxOpenConns" mapstructure:"maxOpenConns"`
 	ConnMaxLifetime time.Duration `yaml:"connMaxLifetime" mapstructure:"connMaxLifetime"`
+	// ConnMaxLifetimeJitter randomizes ConnMaxLifetime by up to ±this percentage (0-99); 0 disables it
+	ConnMaxLifetimeJitter int `yaml:"connMaxLifetimeJitter" mapstructure:"connMaxLifetimeJitter"`
 
 	// Additional PostgreSQL parameters
 	ApplicationName string `yaml:"applicationName" mapstructure:"applicationName"`
 	ConnectTimeout  int    `yaml:"connectTimeout" mapstructure:"connectTimeout"` // in seconds
+
+	// StatementTimeout makes the server abort statements running longer than this (unset means no timeout)
+	StatementTimeout time.Duration `yaml:"statementTimeout" mapstructure:"statementTimeout"`
+
+	// UseAutoMigrate creates the schema with GORM AutoMigrate instead of the migration files.
+	// It is for local development only and refused for databases not on localhost.
+	UseAutoMigrate bool `yaml:"useAutoMigrate" mapstructure:"useAutoMigrate"`
 }
 
 // ServerConfig holds HTTP and gRPC server configuration
 type ServerConfig struct {
 	HTTP HTTPConfig `yaml:"http" mapstructure:"http"`
 	GRPC GRPCConfig `yaml:"grpc" mapstructure:"grpc"`
+
+	// MaxConcurrentRequests caps in-flight HTTP and gRPC requests combined; zero means unlimited
+	MaxConcurrentRequests int `yaml:"maxConcurrentRequests" mapstructure:"maxConcurrentRequests"`
+	// ConcurrencyQueueTimeout is how long a request waits for a free slot before being rejected; zero rejects immediately
+	ConcurrencyQueueTimeout time.Duration `yaml:"concurrencyQueueTimeout" mapstructure:"concurrencyQueueTimeout"`
+	// TimeFormat is the Go time layout of timestamps in HTTP and gRPC responses; empty uses RFC3339
+	TimeFormat string `yaml:"timeFormat" mapstructure:"timeFormat"`
 }
 
+// DefaultSlowRequestThreshold is used when no slow request threshold is configured
+const DefaultSlowRequestThreshold = time.Second
+
 // HTTPConfig holds HTTP server configuration
 type HTTPConfig struct {
+	// Host restricts the listener to one interface; empty listens on all interfaces
+	Host string `yaml:"host" mapstructure:"host"`
 	Port string `yaml:"port" mapstructure:"port"`
+	// Requests slower than this are logged as warnings; a negative value disables the log
+	SlowRequestThreshold time.Duration `yaml:"slowRequestThreshold" mapstructure:"slowRequestThreshold"`
+}
+
+// Addr returns the host:port address the HTTP server listens on
+func (c *HTTPConfig) Addr() string {
+	return net.JoinHostPort(c.Host, c.Port)
+}
+
+// SlowThreshold returns the slow request threshold, falling back to the default when unset
+func (c *HTTPConfig) SlowThreshold() time.Duration {
+	if c.SlowRequestThreshold == 0 {
+		return DefaultSlowRequestThreshold
+	}
+	return c.SlowRequestThreshold
 }
 
 // GRPCConfig holds gRPC server configuration
 type GRPCConfig struct {
+	// Host restricts the listener to one interface; empty listens on all interfaces
+	Host string `yaml:"host" mapstructure:"host"`
 	Port string `yaml:"port" mapstructure:"port"`
+	// Calls slower than this are logged as warnings; a negative value disables the log
+	SlowRequestThreshold time.Duration `yaml:"slowRequestThreshold" mapstructure:"slowRequestThreshold"`
+	// ShutdownTimeout bounds how long a graceful stop may wait for in-flight calls before the server is stopped forcibly
+	ShutdownTimeout time.Duration `yaml:"shutdownTimeout" mapstructure:"shutdownTimeout"`
+}
+
+// DefaultGRPCShutdownTimeout is used when no gRPC shutdown timeout is configured
+const DefaultGRPCShutdownTimeout = 10 * time.Second
+
+// Addr returns the host:port address the gRPC server listens on
+func (c *GRPCConfig) Addr() string {
+	return net.JoinHostPort(c.Host, c.Port)
+}
+
+// SlowThreshold returns the slow request threshold, falling back to the default when unset
+func (c *GRPCConfig) SlowThreshold() time.Duration {
+	if c.SlowRequestThreshold == 0 {
+		return DefaultSlowRequestThreshold
+	}
+	return c.SlowRequestThreshold
+}
+
+// StopTimeout returns the shutdown timeout, falling back to the default when unset
+func (c *GRPCConfig) StopTimeout() time.Duration {
+	if c.ShutdownTimeout <= 0 {
+		return DefaultGRPCShutdownTimeout
+	}
+	return c.ShutdownTimeout
 }
 
 // DSN returns the data source name for the database connection in key=value format
@@ -114,9 +422,19 @@ func (c *DBConfig) DSN() string {
 		dsn += fmt.Sprintf(" connect_timeout=%d", c.ConnectTimeout)
 	}
 
+	if c.StatementTimeout > 0 {
+		dsn += fmt.Sprintf(" options='-c statement_timeout=%d'", c.statementTimeoutMillis())
+	}
+
 	return dsn
 }
 
+// statementTimeoutMillis returns StatementTimeout in whole milliseconds, rounded up so
+// that a timeout under a millisecond does not become 0, which Postgres reads as none
+func (c *DBConfig) statementTimeoutMillis() int64 {
+	return int64((c.StatementTimeout + time.Millisecond - 1) / time.Millisecond)
+}
+
 // DSNURL returns the data source name for the database connection in URL format
 func (c *DBConfig) DSNURL() string {
 	// Base URL with credentials and host
@@ -135,6 +453,10 @@ func (c *DBConfig) DSNURL() string {
 		params = append(params, fmt.Sprintf("connect_timeout=%d", c.ConnectTimeout))
 	}
 
+	if c.StatementTimeout > 0 {
+		params = append(params, fmt.Sprintf("options=-c%%20statement_timeout%%3D%d", c.statementTimeoutMillis()))
+	}
+
 	// Join all parameters with &
 	if len(params) > 0 {
 		url += "?" + params[0]
@@ -164,6 +486,10 @@ func (c *DBConfig) Validate() error {
 		return fmt.Errorf("database name is required")
 	}
 
+	if c.ConnMaxLifetimeJitter < 0 || c.ConnMaxLifetimeJitter >= 100 {
+		return fmt.Errorf("database connection lifetime jitter must be between 0 and 99 percent")
+	}
+
 	return nil
 }
 
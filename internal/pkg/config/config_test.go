@@ -0,0 +1,95 @@
+package config
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDSNStatementTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		want    string
+	}{
+		{name: "unset", timeout: 0, want: ""},
+		{name: "seconds", timeout: 30 * time.Second, want: "-c statement_timeout=30000"},
+		{name: "milliseconds", timeout: 1500 * time.Millisecond, want: "-c statement_timeout=1500"},
+		{name: "under a millisecond", timeout: 250 * time.Microsecond, want: "-c statement_timeout=1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := NewDefaultDBConfig("orders")
+			cfg.StatementTimeout = tt.timeout
+
+			dsn := cfg.DSN()
+			if tt.want == "" {
+				if strings.Contains(dsn, "statement_timeout") {
+					t.Errorf("DSN() = %q, want no statement_timeout", dsn)
+				}
+			} else if !strings.Contains(dsn, " options='"+tt.want+"'") {
+				t.Errorf("DSN() = %q, want options '%s'", dsn, tt.want)
+			}
+
+			parsed, err := url.Parse(cfg.DSNURL())
+			if err != nil {
+				t.Fatalf("url.Parse(DSNURL()) error = %v", err)
+			}
+			if got := parsed.Query().Get("options"); got != tt.want {
+				t.Errorf("DSNURL() options = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTracingBackend(t *testing.T) {
+	tempo := TempoConfig{Host: "tempo", Port: "6831"}
+	jaeger := TempoConfig{Host: "jaeger", Port: "6831"}
+
+	tests := []struct {
+		name       string
+		tempo      TempoConfig
+		jaeger     TempoConfig
+		want       string
+		wantLegacy bool
+	}{
+		{name: "neither block", want: ":"},
+		{name: "tempo only", tempo: tempo, want: "tempo:6831"},
+		{name: "jaeger only", jaeger: jaeger, want: "jaeger:6831", wantLegacy: true},
+		{name: "both blocks", tempo: tempo, jaeger: jaeger, want: "tempo:6831"},
+		{name: "tempo with a port only", tempo: TempoConfig{Port: "6831"}, jaeger: jaeger, want: "jaeger:6831", wantLegacy: true},
+		{name: "jaeger with a port only", jaeger: TempoConfig{Port: "6831"}, want: ":"},
+		{name: "tempo host of a colon", tempo: TempoConfig{Host: ":"}, jaeger: jaeger, want: "::"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Tempo: tt.tempo, Jaeger: tt.jaeger}
+			backend, legacy := cfg.TracingBackend()
+			if got := backend.HostPort(); got != tt.want || legacy != tt.wantLegacy {
+				t.Errorf("TracingBackend() = %s, legacy %v, want %s, legacy %v", got, legacy, tt.want, tt.wantLegacy)
+			}
+		})
+	}
+}
+
+func TestTempoConfigIsSet(t *testing.T) {
+	tests := []struct {
+		config TempoConfig
+		want   bool
+	}{
+		{config: TempoConfig{}, want: false},
+		{config: TempoConfig{Port: "6831"}, want: false},
+		{config: TempoConfig{Host: "tempo"}, want: true},
+		{config: TempoConfig{Host: "tempo", Port: "6831"}, want: true},
+		{config: TempoConfig{Host: ":"}, want: true},
+	}
+
+	for _, tt := range tests {
+		if got := tt.config.IsSet(); got != tt.want {
+			t.Errorf("%+v.IsSet() = %v, want %v", tt.config, got, tt.want)
+		}
+	}
+}
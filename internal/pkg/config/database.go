@@ -2,10 +2,12 @@ package config
 
 import (
 	"fmt"
+	"go-bootiful-ordering/internal/pkg/metrics"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 	"log"
+	"math/rand/v2"
 	"os"
 	"strconv"
 	"time"
@@ -37,6 +39,13 @@ func NewDefaultDBConfig(dbName string) *DBConfig {
 		}
 	}
 
+	var connMaxLifetimeJitter int
+	if envJitter := getEnv("DB_CONN_MAX_LIFETIME_JITTER", ""); envJitter != "" {
+		if val, err := strconv.Atoi(envJitter); err == nil && val > 0 {
+			connMaxLifetimeJitter = val
+		}
+	}
+
 	// Parse connect timeout from environment variable
 	connectTimeout := 10 // Default 10 seconds
 	if envTimeout := getEnv("DB_CONNECT_TIMEOUT", ""); envTimeout != "" {
@@ -45,6 +54,14 @@ func NewDefaultDBConfig(dbName string) *DBConfig {
 		}
 	}
 
+	// Parse statement timeout from environment variable (unset means no timeout)
+	var statementTimeout time.Duration
+	if envTimeout := getEnv("DB_STATEMENT_TIMEOUT", ""); envTimeout != "" {
+		if val, err := time.ParseDuration(envTimeout); err == nil && val > 0 {
+			statementTimeout = val
+		}
+	}
+
 	return &DBConfig{
 		// Basic connection parameters
 		Host:     getEnv("DB_HOST", "localhost"),
@@ -55,18 +72,22 @@ func NewDefaultDBConfig(dbName string) *DBConfig {
 		SSLMode:  getEnv("DB_SSL_MODE", "disable"),
 
 		// Connection pool settings
-		MaxIdleConns:    maxIdleConns,
-		MaxOpenConns:    maxOpenConns,
-		ConnMaxLifetime: connMaxLifetime,
+		MaxIdleConns:          maxIdleConns,
+		MaxOpenConns:          maxOpenConns,
+		ConnMaxLifetime:       connMaxLifetime,
+		ConnMaxLifetimeJitter: connMaxLifetimeJitter,
 
 		// Additional PostgreSQL parameters
 		ApplicationName: getEnv("DB_APPLICATION_NAME", "go-bootiful-ordering"),
 		ConnectTimeout:  connectTimeout,
+
+		// Server-side statement timeout
+		StatementTimeout: statementTimeout,
 	}
 }
 
-// NewGormDB creates a new GORM DB instance from a DBConfig
-func NewGormDB(config *DBConfig) (*gorm.DB, error) {
+// NewGormDB creates a new GORM DB instance from a DBConfig, recording its queries in m
+func NewGormDB(config *DBConfig, m *metrics.Metrics) (*gorm.DB, error) {
 	// Validate the configuration
 	if err := config.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid database configuration: %w", err)
@@ -115,11 +136,38 @@ func NewGormDB(config *DBConfig) (*gorm.DB, error) {
 
 	sqlDB.SetMaxIdleConns(maxIdleConns)
 	sqlDB.SetMaxOpenConns(maxOpenConns)
-	sqlDB.SetConnMaxLifetime(connMaxLifetime)
+	sqlDB.SetConnMaxLifetime(jitteredLifetime(connMaxLifetime, config.ConnMaxLifetimeJitter, rand.Float64()))
+
+	// database/sql applies a single lifetime to the whole pool, so connections opened together
+	// would still expire together. Closing connections that sit idle longer than the jitter
+	// window retires them on their own schedule and spreads the reconnects out.
+	if config.ConnMaxLifetimeJitter > 0 {
+		sqlDB.SetConnMaxIdleTime(jitterWindow(connMaxLifetime, config.ConnMaxLifetimeJitter))
+	}
+
+	// Record query metrics
+	if err := m.RegisterGormCallbacks(db); err != nil {
+		return nil, fmt.Errorf("failed to register metrics callbacks: %w", err)
+	}
 
 	return db, nil
 }
 
+// jitterWindow returns jitterPercent percent of lifetime
+func jitterWindow(lifetime time.Duration, jitterPercent int) time.Duration {
+	return lifetime * time.Duration(jitterPercent) / 100
+}
+
+// jitteredLifetime moves lifetime by up to ±jitterPercent percent, picked uniformly by rnd in [0, 1).
+// A jitterPercent of 0 returns lifetime unchanged.
+func jitteredLifetime(lifetime time.Duration, jitterPercent int, rnd float64) time.Duration {
+	if jitterPercent <= 0 {
+		return lifetime
+	}
+	window := jitterWindow(lifetime, jitterPercent)
+	return lifetime - window + time.Duration(rnd*float64(2*window))
+}
+
 // Helper function to get environment variable with a default value
 func getEnv(key, defaultValue string) string {
 	if value, exists := os.LookupEnv(key); exists {
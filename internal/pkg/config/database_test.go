@@ -0,0 +1,107 @@
+package config
+
+import (
+	"math/rand/v2"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestJitteredLifetimeBounds(t *testing.T) {
+	tests := []struct {
+		lifetime      time.Duration
+		jitterPercent int
+		min, max      time.Duration
+	}{
+		{lifetime: time.Hour, jitterPercent: 0, min: time.Hour, max: time.Hour},
+		{lifetime: time.Hour, jitterPercent: 10, min: 54 * time.Minute, max: 66 * time.Minute},
+		{lifetime: 30 * time.Minute, jitterPercent: 50, min: 15 * time.Minute, max: 45 * time.Minute},
+		{lifetime: time.Hour, jitterPercent: 99, min: 36 * time.Second, max: 119*time.Minute + 24*time.Second},
+	}
+
+	random := rand.New(rand.NewPCG(1, 2))
+	for _, tt := range tests {
+		rnds := []float64{0, 0.5, 0.9999999}
+		for i := 0; i < 1000; i++ {
+			rnds = append(rnds, random.Float64())
+		}
+
+		for _, rnd := range rnds {
+			got := jitteredLifetime(tt.lifetime, tt.jitterPercent, rnd)
+			if got < tt.min || got > tt.max {
+				t.Fatalf("jitteredLifetime(%s, %d, %v) = %s, want within [%s, %s]", tt.lifetime, tt.jitterPercent, rnd, got, tt.min, tt.max)
+			}
+		}
+	}
+}
+
+func TestJitteredLifetimeSpread(t *testing.T) {
+	tests := []struct {
+		rnd  float64
+		want time.Duration
+	}{
+		{rnd: 0, want: 54 * time.Minute},
+		{rnd: 0.25, want: 57 * time.Minute},
+		{rnd: 0.5, want: time.Hour},
+		{rnd: 0.75, want: 63 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := jitteredLifetime(time.Hour, 10, tt.rnd); got != tt.want {
+			t.Errorf("jitteredLifetime(1h, 10, %v) = %s, want %s", tt.rnd, got, tt.want)
+		}
+	}
+}
+
+func TestJitterWindow(t *testing.T) {
+	if got := jitterWindow(time.Hour, 10); got != 6*time.Minute {
+		t.Errorf("jitterWindow(1h, 10) = %s, want 6m", got)
+	}
+	if got := jitterWindow(time.Hour, 0); got != 0 {
+		t.Errorf("jitterWindow(1h, 0) = %s, want 0", got)
+	}
+}
+
+// TestStatementTimeoutAbortsSlowQuery needs a Postgres server; it runs when DB_HOST is
+// set, connecting with the DB_* variables NewDefaultDBConfig reads
+func TestStatementTimeoutAbortsSlowQuery(t *testing.T) {
+	if os.Getenv("DB_HOST") == "" {
+		t.Skip("DB_HOST is not set")
+	}
+
+	cfg := NewDefaultDBConfig("postgres")
+	cfg.StatementTimeout = 100 * time.Millisecond
+	for name, dsn := range map[string]string{"DSN": cfg.DSN(), "DSNURL": cfg.DSNURL()} {
+		t.Run(name, func(t *testing.T) {
+			db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+			if err != nil {
+				t.Fatalf("gorm.Open() error = %v", err)
+			}
+			sqlDB, err := db.DB()
+			if err != nil {
+				t.Fatalf("db.DB() error = %v", err)
+			}
+			defer sqlDB.Close()
+
+			var timeout string
+			if err := db.Raw("SHOW statement_timeout").Scan(&timeout).Error; err != nil || timeout != "100ms" {
+				t.Errorf("statement_timeout = %q, %v, want 100ms", timeout, err)
+			}
+
+			start := time.Now()
+			err = db.Exec("SELECT pg_sleep(5)").Error
+			// 57014 is query_canceled, raised by the server when the timeout fires
+			if err == nil || !strings.Contains(err.Error(), "57014") {
+				t.Errorf("slow query error = %v, want the server to cancel it", err)
+			}
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Errorf("slow query ran for %s, want it aborted after about 100ms", elapsed)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package grpcclient
+
+import (
+	"github.com/opentracing/opentracing-go"
+	"go-bootiful-ordering/gen/order/v1"
+	"go-bootiful-ordering/gen/product/v1"
+	"go-bootiful-ordering/internal/pkg/metrics"
+	"go-bootiful-ordering/internal/pkg/requestid"
+	"go-bootiful-ordering/internal/pkg/tracing"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+	"time"
+)
+
+const (
+	// DialTimeout bounds each attempt to establish a connection to the target
+	DialTimeout = 5 * time.Second
+	// KeepaliveTime is how long a connection with active calls may be idle before it is pinged.
+	// It matches the servers' default ping enforcement, which closes connections pinging more often.
+	KeepaliveTime = 5 * time.Minute
+	// KeepaliveTimeout is how long a ping may go unanswered before the connection is closed
+	KeepaliveTimeout = 20 * time.Second
+)
+
+// serviceConfig retries calls that failed with UNAVAILABLE, which gRPC only does before any
+// response header has been received. The services send the request ID header on every
+// response, so the calls retried are those that could not reach a handler.
+const serviceConfig = `{
+	"methodConfig": [{
+		"name": [{}],
+		"retryPolicy": {
+			"maxAttempts": 3,
+			"initialBackoff": "0.1s",
+			"maxBackoff": "1s",
+			"backoffMultiplier": 2,
+			"retryableStatusCodes": ["UNAVAILABLE"]
+		}
+	}]
+}`
+
+// NewClientConn creates a connection to a service in the cluster. Calls carry the request ID,
+// are traced and counted, unavailable targets are retried and idle connections with calls in flight are
+// kept alive. Like grpc.NewClient, it connects lazily, on the first call.
+func NewClientConn(target string, tracer opentracing.Tracer, m *metrics.Metrics) (*grpc.ClientConn, error) {
+	return grpc.NewClient(
+		target,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithChainUnaryInterceptor(
+			requestid.UnaryClientInterceptor(),
+			tracing.UnaryClientInterceptor(tracer),
+			m.UnaryClientInterceptor(),
+		),
+		grpc.WithChainStreamInterceptor(
+			requestid.StreamClientInterceptor(),
+			m.StreamClientInterceptor(),
+		),
+		grpc.WithDefaultServiceConfig(serviceConfig),
+		grpc.WithConnectParams(grpc.ConnectParams{
+			Backoff:           backoff.DefaultConfig,
+			MinConnectTimeout: DialTimeout,
+		}),
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:    KeepaliveTime,
+			Timeout: KeepaliveTimeout,
+		}),
+	)
+}
+
+// NewOrderServiceClient creates a client of the order service at target.
+// The caller closes the returned connection.
+func NewOrderServiceClient(target string, tracer opentracing.Tracer, m *metrics.Metrics) (orderv1.OrderServiceClient, *grpc.ClientConn, error) {
+	conn, err := NewClientConn(target, tracer, m)
+	if err != nil {
+		return nil, nil, err
+	}
+	return orderv1.NewOrderServiceClient(conn), conn, nil
+}
+
+// NewProductServiceClient creates a client of the product service at target.
+// The caller closes the returned connection.
+func NewProductServiceClient(target string, tracer opentracing.Tracer, m *metrics.Metrics) (productv1.ProductServiceClient, *grpc.ClientConn, error) {
+	conn, err := NewClientConn(target, tracer, m)
+	if err != nil {
+		return nil, nil, err
+	}
+	return productv1.NewProductServiceClient(conn), conn, nil
+}
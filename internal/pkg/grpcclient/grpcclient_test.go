@@ -0,0 +1,210 @@
+package grpcclient
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/uber/jaeger-client-go"
+	"go-bootiful-ordering/internal/pkg/metrics/metricstest"
+	"go-bootiful-ordering/internal/pkg/requestid"
+	"go-bootiful-ordering/internal/pkg/tracing"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+const checkMethod = "/grpc.health.v1.Health/Check"
+
+// healthServer answers Check calls, failing the first ones with UNAVAILABLE, and records
+// the request ID and trace of the calls reaching it
+type healthServer struct {
+	healthpb.UnimplementedHealthServer
+
+	mu          sync.Mutex
+	unavailable int
+	calls       int
+	requestIDs  []string
+	traced      []bool
+}
+
+func (s *healthServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	s.requestIDs = append(s.requestIDs, requestid.FromContext(ctx))
+	s.traced = append(s.traced, opentracing.SpanFromContext(ctx) != nil)
+	if s.calls <= s.unavailable {
+		return nil, status.Error(codes.Unavailable, "not ready yet")
+	}
+	return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, nil
+}
+
+// seen returns the number of calls received and the request ID and trace of each
+func (s *healthServer) seen() (int, []string, []bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls, s.requestIDs, s.traced
+}
+
+// serve starts an in-process gRPC server with the health service and the given
+// interceptors on a local port and returns its address
+func serve(t *testing.T, health *healthServer, interceptors ...grpc.UnaryServerInterceptor) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(interceptors...))
+	healthpb.RegisterHealthServer(server, health)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+	return lis.Addr().String()
+}
+
+func newTracer(t *testing.T) (opentracing.Tracer, *jaeger.InMemoryReporter) {
+	t.Helper()
+	reporter := jaeger.NewInMemoryReporter()
+	tracer, closer := jaeger.NewTracer("test", jaeger.NewConstSampler(true), reporter)
+	t.Cleanup(func() { closer.Close() })
+	return tracer, reporter
+}
+
+func TestClientConnRunsInterceptors(t *testing.T) {
+	tracer, reporter := newTracer(t)
+	health := &healthServer{}
+	addr := serve(t, health, requestid.UnaryServerInterceptor(), tracing.UnaryServerInterceptor(tracer))
+	rec := metricstest.NewRecorder()
+
+	conn, err := NewClientConn(addr, tracer, rec.Metrics)
+	if err != nil {
+		t.Fatalf("NewClientConn() error = %v", err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(requestid.WithID(context.Background(), "request-1"), 5*time.Second)
+	defer cancel()
+	if _, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+
+	_, requestIDs, traced := health.seen()
+	if len(requestIDs) != 1 || requestIDs[0] != "request-1" {
+		t.Errorf("server saw request IDs %v, want [request-1]", requestIDs)
+	}
+	if len(traced) != 1 || !traced[0] {
+		t.Errorf("server spans = %v, want the call to continue the client's trace", traced)
+	}
+	var clientSpans int
+	for _, span := range reporter.GetSpans() {
+		if span.(*jaeger.Span).OperationName() == checkMethod && span.(*jaeger.Span).Tags()[string(ext.SpanKind)] == ext.SpanKindRPCClientEnum {
+			clientSpans++
+		}
+	}
+	if clientSpans != 1 {
+		t.Errorf("reported %d client spans for %s, want 1", clientSpans, checkMethod)
+	}
+	if got := rec.Counter("grpc_client_requests_total", prometheus.Labels{"method": checkMethod, "status": "OK"}); got != 1 {
+		t.Errorf("grpc_client_requests_total = %v, want 1", got)
+	}
+	if got := rec.HistogramCount("grpc_client_request_duration_seconds", prometheus.Labels{"method": checkMethod}); got != 1 {
+		t.Errorf("grpc_client_request_duration_seconds count = %d, want 1", got)
+	}
+}
+
+func TestClientConnRetriesUnavailable(t *testing.T) {
+	tests := []struct {
+		name        string
+		unavailable int
+		// withRequestID serves the call like the services do, sending the request ID header
+		withRequestID bool
+		wantCalls     int
+		wantCode      codes.Code
+	}{
+		{name: "recovers within the attempts", unavailable: 2, wantCalls: 3, wantCode: codes.OK},
+		{name: "stays unavailable", unavailable: 5, wantCalls: 3, wantCode: codes.Unavailable},
+		// Response headers commit the call, so a handler's own UNAVAILABLE is not retried
+		{name: "answered with headers", unavailable: 1, withRequestID: true, wantCalls: 1, wantCode: codes.Unavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracer, _ := newTracer(t)
+			health := &healthServer{unavailable: tt.unavailable}
+			var interceptors []grpc.UnaryServerInterceptor
+			if tt.withRequestID {
+				interceptors = append(interceptors, requestid.UnaryServerInterceptor())
+			}
+			addr := serve(t, health, interceptors...)
+			rec := metricstest.NewRecorder()
+
+			_, conn, err := NewOrderServiceClient(addr, tracer, rec.Metrics)
+			if err != nil {
+				t.Fatalf("NewOrderServiceClient() error = %v", err)
+			}
+			defer conn.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			_, err = healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+			if status.Code(err) != tt.wantCode {
+				t.Fatalf("Check() error = %v, want %s", err, tt.wantCode)
+			}
+			if calls, _, _ := health.seen(); calls != tt.wantCalls {
+				t.Errorf("server received %d attempts, want %d", calls, tt.wantCalls)
+			}
+			// The interceptors run once per call, around its attempts
+			if got := rec.Counter("grpc_client_requests_total", prometheus.Labels{"method": checkMethod}); got != 1 {
+				t.Errorf("grpc_client_requests_total = %v, want 1", got)
+			}
+		})
+	}
+}
+
+func TestClientConnGivesUpAfterDialTimeout(t *testing.T) {
+	if testing.Short() {
+		t.Skip("waits for the dial timeout")
+	}
+
+	// The listener accepts connections but never completes the HTTP/2 handshake
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	defer lis.Close()
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	tracer, _ := newTracer(t)
+	_, conn, err := NewProductServiceClient(lis.Addr().String(), tracer, metricstest.NewRecorder().Metrics)
+	if err != nil {
+		t.Fatalf("NewProductServiceClient() error = %v", err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*DialTimeout)
+	defer cancel()
+	start := time.Now()
+	_, err = healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	elapsed := time.Since(start)
+
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("Check() error = %v, want Unavailable once the connection attempt times out", err)
+	}
+	if elapsed < DialTimeout || elapsed >= 2*DialTimeout {
+		t.Errorf("Check() failed after %v, want about the %v dial timeout", elapsed, DialTimeout)
+	}
+}
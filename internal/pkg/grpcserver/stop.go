@@ -0,0 +1,32 @@
+package grpcserver
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"time"
+)
+
+// Stop stops the server gracefully, waiting for in-flight calls to finish. A stream that
+// never ends would block GracefulStop forever, so the server is stopped forcibly once
+// timeout passes or ctx ends. It reports whether the stop had to be forced.
+func Stop(ctx context.Context, log *zap.Logger, server *grpc.Server, timeout time.Duration) bool {
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-stopped:
+		return false
+	case <-timer.C:
+		log.Warn("gRPC graceful stop timed out, forcing stop", zap.Duration("timeout", timeout))
+	case <-ctx.Done():
+		log.Warn("gRPC graceful stop interrupted, forcing stop", zap.Error(ctx.Err()))
+	}
+	server.Stop()
+	return true
+}
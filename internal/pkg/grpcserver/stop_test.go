@@ -0,0 +1,110 @@
+package grpcserver
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// streamDesc describes a server-streaming method whose handler blocks until the stream
+// is torn down, like a subscription the client never cancels
+var streamDesc = grpc.StreamDesc{StreamName: "Watch", ServerStreams: true}
+
+// startServer serves a test service on a local port, signalling on opened every time its
+// stream handler starts
+func startServer(t *testing.T, opened chan<- struct{}) (*grpc.Server, string) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	server := grpc.NewServer()
+	desc := streamDesc
+	desc.Handler = func(srv interface{}, stream grpc.ServerStream) error {
+		opened <- struct{}{}
+		<-stream.Context().Done()
+		return stream.Context().Err()
+	}
+	server.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "test.Watcher",
+		HandlerType: (*interface{})(nil),
+		Streams:     []grpc.StreamDesc{desc},
+	}, struct{}{})
+
+	go server.Serve(listener)
+	return server, listener.Addr().String()
+}
+
+func TestStopForcesNeverEndingStream(t *testing.T) {
+	opened := make(chan struct{}, 1)
+	server, addr := startServer(t, opened)
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer conn.Close()
+
+	streamCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if _, err := conn.NewStream(streamCtx, &streamDesc, "/test.Watcher/Watch"); err != nil {
+		t.Fatalf("failed to open stream: %v", err)
+	}
+	select {
+	case <-opened:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream handler never started")
+	}
+
+	const timeout = 100 * time.Millisecond
+	start := time.Now()
+	forced := Stop(context.Background(), zap.NewNop(), server, timeout)
+	elapsed := time.Since(start)
+
+	if !forced {
+		t.Error("Stop() = false, want a forced stop for a stream that never ends")
+	}
+	if elapsed < timeout {
+		t.Errorf("Stop() returned after %s, before the %s timeout", elapsed, timeout)
+	}
+	if elapsed > timeout+2*time.Second {
+		t.Errorf("Stop() returned after %s, long after the %s timeout", elapsed, timeout)
+	}
+}
+
+func TestStopForcesWhenContextEnds(t *testing.T) {
+	opened := make(chan struct{}, 1)
+	server, addr := startServer(t, opened)
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.NewStream(context.Background(), &streamDesc, "/test.Watcher/Watch"); err != nil {
+		t.Fatalf("failed to open stream: %v", err)
+	}
+	<-opened
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if forced := Stop(ctx, zap.NewNop(), server, time.Hour); !forced {
+		t.Error("Stop() = false, want a forced stop once the context ends")
+	}
+}
+
+func TestStopIsGracefulWithoutCalls(t *testing.T) {
+	server, _ := startServer(t, make(chan struct{}, 1))
+
+	if forced := Stop(context.Background(), zap.NewNop(), server, 5*time.Second); forced {
+		t.Error("Stop() = true, want a graceful stop when no call is in flight")
+	}
+}
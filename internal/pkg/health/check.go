@@ -0,0 +1,97 @@
+package health
+
+import (
+	"context"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultCheckTimeout bounds each dependency check when no timeout is given
+	DefaultCheckTimeout = 2 * time.Second
+	// StatusUp reports a live process or a reachable dependency
+	StatusUp = "UP"
+	// StatusDown reports an unreachable dependency
+	StatusDown = "DOWN"
+)
+
+// Check probes one dependency the service needs to serve requests
+type Check struct {
+	Name  string
+	Probe func(ctx context.Context) error
+}
+
+// NewDatabaseCheck creates a check pinging the database behind db
+func NewDatabaseCheck(db *gorm.DB) Check {
+	return Check{
+		Name: "database",
+		Probe: func(ctx context.Context) error {
+			sqlDB, err := db.DB()
+			if err != nil {
+				return err
+			}
+			return sqlDB.PingContext(ctx)
+		},
+	}
+}
+
+// NewRedisCheck creates a check pinging the Redis server behind client
+func NewRedisCheck(client *redis.Client) Check {
+	return Check{
+		Name: "redis",
+		Probe: func(ctx context.Context) error {
+			return client.Ping(ctx).Err()
+		},
+	}
+}
+
+// Checker runs the readiness checks of a service. A service without checks is always ready.
+type Checker struct {
+	timeout time.Duration
+	checks  []Check
+}
+
+// NewChecker creates a new Checker; a non-positive timeout uses DefaultCheckTimeout
+func NewChecker(timeout time.Duration, checks ...Check) *Checker {
+	if timeout <= 0 {
+		timeout = DefaultCheckTimeout
+	}
+	return &Checker{
+		timeout: timeout,
+		checks:  checks,
+	}
+}
+
+// Ready runs every check concurrently, each bounded by the checker's timeout, and
+// reports the status of each dependency by name and whether all of them are up
+func (c *Checker) Ready(ctx context.Context) (map[string]string, bool) {
+	statuses := make(map[string]string, len(c.checks))
+	ready := true
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range c.checks {
+		wg.Add(1)
+		go func(check Check) {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, c.timeout)
+			defer cancel()
+			err := check.Probe(checkCtx)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				statuses[check.Name] = StatusDown + ": " + err.Error()
+				ready = false
+				return
+			}
+			statuses[check.Name] = StatusUp
+		}(check)
+	}
+	wg.Wait()
+
+	return statuses, ready
+}
@@ -1,15 +1,37 @@
 package health
 
 import (
+	"context"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/health"
 	"google.golang.org/grpc/health/grpc_health_v1"
 )
 
-// RegisterHealthServer registers the official gRPC health server with the gRPC server
-func RegisterHealthServer(server *grpc.Server) {
+// checkedHealthServer answers Check for the overall service (the empty service name)
+// by running the readiness checks; Watch and named services use the static statuses
+// of the embedded server
+type checkedHealthServer struct {
+	*health.Server
+	checker *Checker
+}
+
+// Check reports NOT_SERVING when any dependency check fails
+func (s *checkedHealthServer) Check(ctx context.Context, req *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	if req.GetService() != "" {
+		return s.Server.Check(ctx, req)
+	}
+
+	if _, ready := s.checker.Ready(ctx); !ready {
+		return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_NOT_SERVING}, nil
+	}
+	return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}, nil
+}
+
+// RegisterHealthServer registers the gRPC health server with the gRPC server.
+// Checks of the overall health consult the same dependency checks as /health/ready.
+func RegisterHealthServer(server *grpc.Server, checker *Checker) {
 	healthServer := health.NewServer()
 	// Set the health status for the empty service name (overall health)
 	healthServer.SetServingStatus("", grpc_health_v1.HealthCheckResponse_SERVING)
-	grpc_health_v1.RegisterHealthServer(server, healthServer)
+	grpc_health_v1.RegisterHealthServer(server, &checkedHealthServer{Server: healthServer, checker: checker})
 }
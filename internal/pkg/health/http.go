@@ -5,14 +5,51 @@ import (
 	"net/http"
 )
 
+// HealthzPath is the plain liveness endpoint for load balancers that do not parse JSON
+const HealthzPath = "/healthz"
+
 // HealthStatus represents the health status of the service
 type HealthStatus struct {
-	Status string `json:"status"`
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// Healthz returns a handler answering GET and HEAD /healthz with a plain 200 "ok" and passing
+// every other request to next. It runs in front of the gin engine, so health checkers hitting
+// it every second add no request logs, traces, metrics or concurrency slots, and it never
+// consults the dependency checks.
+func Healthz(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != HealthzPath || (r.Method != http.MethodGet && r.Method != http.MethodHead) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodGet {
+			w.Write([]byte("ok"))
+		}
+	})
 }
 
-// RegisterHealthEndpoint registers the /health endpoint with the gin engine
-func RegisterHealthEndpoint(r *gin.Engine) {
-	r.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, HealthStatus{Status: "UP"})
+// RegisterHealthEndpoint registers the health endpoints with the gin engine.
+// /health/live reports that the process is up; /health/ready also runs the
+// checker's dependency checks and responds 503 when any of them fails.
+// /health is kept as an alias of /health/live for existing probes.
+func RegisterHealthEndpoint(r *gin.Engine, checker *Checker) {
+	live := func(c *gin.Context) {
+		c.JSON(http.StatusOK, HealthStatus{Status: StatusUp})
+	}
+	r.GET("/health", live)
+	r.GET("/health/live", live)
+
+	r.GET("/health/ready", func(c *gin.Context) {
+		checks, ready := checker.Ready(c.Request.Context())
+		if !ready {
+			c.JSON(http.StatusServiceUnavailable, HealthStatus{Status: StatusDown, Checks: checks})
+			return
+		}
+		c.JSON(http.StatusOK, HealthStatus{Status: StatusUp, Checks: checks})
 	})
 }
@@ -0,0 +1,99 @@
+package idgen
+
+import (
+	"crypto/rand"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/oklog/ulid/v2"
+)
+
+const (
+	// KindUUID generates random UUIDv4 identifiers
+	KindUUID = "uuid"
+	// KindULID generates ULIDs, which sort lexicographically by creation time
+	KindULID = "ulid"
+)
+
+// IDGenerator generates identifiers for new entities
+type IDGenerator interface {
+	NewID() string
+}
+
+// New creates the generator of the given kind; an empty kind selects UUIDs
+func New(kind string) (IDGenerator, error) {
+	switch kind {
+	case "", KindUUID:
+		return UUIDGenerator{}, nil
+	case KindULID:
+		return NewULIDGenerator(), nil
+	default:
+		return nil, fmt.Errorf("unknown ID generator %q, expected %q or %q", kind, KindUUID, KindULID)
+	}
+}
+
+// Valid reports whether id is a well-formed identifier of the given kind: a UUID in its
+// canonical 36 character form, or a 26 character ULID. Unknown kinds accept nothing.
+func Valid(kind, id string) bool {
+	switch kind {
+	case KindUUID:
+		if len(id) != 36 {
+			return false
+		}
+		_, err := uuid.Parse(id)
+		return err == nil
+	case KindULID:
+		_, err := ulid.ParseStrict(id)
+		return err == nil
+	default:
+		return false
+	}
+}
+
+// UUIDGenerator generates random UUIDv4 identifiers
+type UUIDGenerator struct{}
+
+// NewID returns a new UUIDv4
+func (UUIDGenerator) NewID() string {
+	return uuid.New().String()
+}
+
+// ULIDGenerator generates ULIDs. IDs generated within the same millisecond
+// increase monotonically, so they sort in generation order.
+type ULIDGenerator struct {
+	mu      sync.Mutex
+	entropy *ulid.MonotonicEntropy
+}
+
+// NewULIDGenerator creates a new ULIDGenerator
+func NewULIDGenerator() *ULIDGenerator {
+	return &ULIDGenerator{
+		entropy: ulid.Monotonic(rand.Reader, 0),
+	}
+}
+
+// NewID returns a new ULID
+func (g *ULIDGenerator) NewID() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return ulid.MustNew(ulid.Timestamp(time.Now()), g.entropy).String()
+}
+
+// SequenceGenerator generates predictable IDs ("<prefix>1", "<prefix>2", ...) for tests
+type SequenceGenerator struct {
+	prefix string
+	next   atomic.Int64
+}
+
+// NewSequenceGenerator creates a new SequenceGenerator
+func NewSequenceGenerator(prefix string) *SequenceGenerator {
+	return &SequenceGenerator{prefix: prefix}
+}
+
+// NewID returns the next ID in the sequence
+func (g *SequenceGenerator) NewID() string {
+	return fmt.Sprintf("%s%d", g.prefix, g.next.Add(1))
+}
@@ -0,0 +1,101 @@
+package limit
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go-bootiful-ordering/internal/pkg/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Limiter caps the number of requests handled concurrently across the HTTP and gRPC servers.
+// When all slots are taken a request waits up to the queue timeout for one to free up,
+// and is rejected once it expires. A zero queue timeout rejects immediately.
+type Limiter struct {
+	slots        chan struct{}
+	queueTimeout time.Duration
+	metrics      *metrics.Metrics
+}
+
+// NewLimiter creates a new Limiter allowing maxConcurrent in-flight requests.
+// A maxConcurrent of zero or less disables the limit.
+func NewLimiter(maxConcurrent int, queueTimeout time.Duration, m *metrics.Metrics) *Limiter {
+	l := &Limiter{queueTimeout: queueTimeout, metrics: m}
+	if maxConcurrent > 0 {
+		l.slots = make(chan struct{}, maxConcurrent)
+	}
+	return l
+}
+
+// acquire takes a slot, waiting up to the queue timeout, and reports whether it succeeded
+func (l *Limiter) acquire(ctx context.Context) bool {
+	select {
+	case l.slots <- struct{}{}:
+		l.metrics.InFlightRequests.Inc()
+		return true
+	default:
+	}
+
+	if l.queueTimeout <= 0 {
+		return false
+	}
+
+	timer := time.NewTimer(l.queueTimeout)
+	defer timer.Stop()
+
+	select {
+	case l.slots <- struct{}{}:
+		l.metrics.InFlightRequests.Inc()
+		return true
+	case <-timer.C:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// release frees a slot taken by acquire
+func (l *Limiter) release() {
+	<-l.slots
+	l.metrics.InFlightRequests.Dec()
+}
+
+// GinMiddleware returns a gin middleware that responds 503 when the limit is reached
+func (l *Limiter) GinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if l.slots == nil {
+			c.Next()
+			return
+		}
+
+		if !l.acquire(c.Request.Context()) {
+			l.metrics.RejectedRequests.WithLabelValues("http").Inc()
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "Server is busy, try again later"})
+			return
+		}
+		defer l.release()
+
+		c.Next()
+	}
+}
+
+// UnaryServerInterceptor returns a gRPC interceptor that fails with ResourceExhausted when the limit is reached
+func (l *Limiter) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if l.slots == nil {
+			return handler(ctx, req)
+		}
+
+		if !l.acquire(ctx) {
+			l.metrics.RejectedRequests.WithLabelValues("grpc").Inc()
+			return nil, status.Error(codes.ResourceExhausted, "server is busy, try again later")
+		}
+		defer l.release()
+
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,22 @@
+package metrics
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"go-bootiful-ordering/internal/pkg/tracing"
+)
+
+// traceIDLabel is the exemplar label holding the trace ID, as Grafana expects it
+const traceIDLabel = "trace_id"
+
+// observeWithTrace records a value and, when the context belongs to a sampled trace,
+// attaches the trace ID as an exemplar so the sample links to the trace in Tempo
+func observeWithTrace(ctx context.Context, observer prometheus.Observer, value float64) {
+	if traceID := tracing.TraceID(ctx); traceID != "" {
+		if exemplarObserver, ok := observer.(prometheus.ExemplarObserver); ok {
+			exemplarObserver.ObserveWithExemplar(value, prometheus.Labels{traceIDLabel: traceID})
+			return
+		}
+	}
+	observer.Observe(value)
+}
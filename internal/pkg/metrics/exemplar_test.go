@@ -0,0 +1,76 @@
+package metrics_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/opentracing/opentracing-go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/uber/jaeger-client-go"
+	"go-bootiful-ordering/internal/pkg/metrics/metricstest"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+// spanContext returns a context carrying a span of a tracer with the given sampling, and
+// the ID of its trace
+func spanContext(t *testing.T, sampled bool) (context.Context, string) {
+	t.Helper()
+	tracer, closer := jaeger.NewTracer("test", jaeger.NewConstSampler(sampled), jaeger.NewNullReporter())
+	t.Cleanup(func() { closer.Close() })
+
+	span := tracer.StartSpan("request")
+	t.Cleanup(span.Finish)
+	return opentracing.ContextWithSpan(context.Background(), span), span.Context().(jaeger.SpanContext).TraceID().String()
+}
+
+func TestRequestDurationExemplars(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name string
+		// span returns the request context and the ID of its trace, if any
+		span        func(t *testing.T) (context.Context, string)
+		wantTraceID bool
+	}{
+		{name: "sampled span", span: func(t *testing.T) (context.Context, string) { return spanContext(t, true) }, wantTraceID: true},
+		{name: "unsampled span", span: func(t *testing.T) (context.Context, string) { return spanContext(t, false) }},
+		{name: "no span", span: func(t *testing.T) (context.Context, string) { return context.Background(), "" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, traceID := tt.span(t)
+			rec := metricstest.NewRecorder()
+
+			router := gin.New()
+			router.Use(rec.Metrics.GinMiddleware(zap.NewNop(), 0))
+			router.GET("/orders", func(c *gin.Context) { c.Status(http.StatusOK) })
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders", nil).WithContext(ctx))
+
+			interceptor := rec.Metrics.UnaryServerInterceptor(zap.NewNop(), 0)
+			interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/order.v1.OrderService/ListOrders"}, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return "ok", nil
+			})
+
+			for _, name := range []string{"http_request_duration_seconds", "grpc_request_duration_seconds"} {
+				if got := rec.HistogramCount(name, prometheus.Labels{}); got != 1 {
+					t.Errorf("%s count = %d, want 1", name, got)
+				}
+				exemplars := rec.HistogramExemplars(name, prometheus.Labels{})
+				if !tt.wantTraceID {
+					if len(exemplars) != 0 {
+						t.Errorf("%s exemplars = %v, want none", name, exemplars)
+					}
+					continue
+				}
+				if len(exemplars) != 1 || exemplars[0]["trace_id"] != traceID {
+					t.Errorf("%s exemplars = %v, want one with trace_id %s", name, exemplars, traceID)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// gormStartTimeKey is the statement instance key holding the query start time
+const gormStartTimeKey = "metrics:start_time"
+
+// RegisterGormCallbacks registers GORM callbacks that record the count and duration of
+// create, query, update, delete, row and raw operations, labeled by operation and table.
+// Raw SQL has no model, so its table label is empty.
+func (m *Metrics) RegisterGormCallbacks(db *gorm.DB) error {
+	cb := db.Callback()
+
+	// Create
+	if err := cb.Create().Before("gorm:create").Register("metrics:before_create", gormBefore); err != nil {
+		return err
+	}
+	if err := cb.Create().After("gorm:create").Register("metrics:after_create", m.gormAfter("create")); err != nil {
+		return err
+	}
+
+	// Query
+	if err := cb.Query().Before("gorm:query").Register("metrics:before_query", gormBefore); err != nil {
+		return err
+	}
+	if err := cb.Query().After("gorm:query").Register("metrics:after_query", m.gormAfter("query")); err != nil {
+		return err
+	}
+
+	// Update
+	if err := cb.Update().Before("gorm:update").Register("metrics:before_update", gormBefore); err != nil {
+		return err
+	}
+	if err := cb.Update().After("gorm:update").Register("metrics:after_update", m.gormAfter("update")); err != nil {
+		return err
+	}
+
+	// Delete
+	if err := cb.Delete().Before("gorm:delete").Register("metrics:before_delete", gormBefore); err != nil {
+		return err
+	}
+	if err := cb.Delete().After("gorm:delete").Register("metrics:after_delete", m.gormAfter("delete")); err != nil {
+		return err
+	}
+
+	// Row (Row and Rows, also used by Scan)
+	if err := cb.Row().Before("gorm:row").Register("metrics:before_row", gormBefore); err != nil {
+		return err
+	}
+	if err := cb.Row().After("gorm:row").Register("metrics:after_row", m.gormAfter("row")); err != nil {
+		return err
+	}
+
+	// Raw (Exec)
+	if err := cb.Raw().Before("gorm:raw").Register("metrics:before_raw", gormBefore); err != nil {
+		return err
+	}
+	if err := cb.Raw().After("gorm:raw").Register("metrics:after_raw", m.gormAfter("raw")); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// gormBefore stores the start time of the statement
+func gormBefore(db *gorm.DB) {
+	db.InstanceSet(gormStartTimeKey, time.Now())
+}
+
+// gormAfter returns a callback that records metrics for the given operation
+func (m *Metrics) gormAfter(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(gormStartTimeKey)
+		if !ok {
+			return
+		}
+
+		start, ok := value.(time.Time)
+		if !ok {
+			return
+		}
+
+		table := db.Statement.Table
+		m.DatabaseQueryCounter.WithLabelValues(operation, table).Inc()
+		m.DatabaseQueryDuration.WithLabelValues(operation, table).Observe(time.Since(start).Seconds())
+	}
+}
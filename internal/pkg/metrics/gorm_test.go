@@ -0,0 +1,60 @@
+package metrics_test
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go-bootiful-ordering/internal/pkg/metrics/metricstest"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type widget struct {
+	ID   string `gorm:"primaryKey"`
+	Name string
+}
+
+func TestGormCallbacksRecordEachOperation(t *testing.T) {
+	rec := metricstest.NewRecorder()
+	// A dry run builds the statements without a database, and runs the callbacks around them
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost dbname=metrics"}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+		Logger:                 logger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+	if err := rec.Metrics.RegisterGormCallbacks(db); err != nil {
+		t.Fatalf("RegisterGormCallbacks() error = %v", err)
+	}
+
+	db.Create(&widget{ID: "widget-1", Name: "first"})
+	db.Create(&widget{ID: "widget-2", Name: "second"})
+	db.Find(&[]widget{})
+	db.Model(&widget{ID: "widget-1"}).Update("name", "renamed")
+	db.Delete(&widget{ID: "widget-2"})
+	db.Exec("SELECT 1")
+
+	tests := []struct {
+		labels prometheus.Labels
+		want   float64
+	}{
+		{prometheus.Labels{"operation": "create", "table": "widgets"}, 2},
+		{prometheus.Labels{"operation": "query", "table": "widgets"}, 1},
+		{prometheus.Labels{"operation": "update", "table": "widgets"}, 1},
+		{prometheus.Labels{"operation": "delete", "table": "widgets"}, 1},
+		{prometheus.Labels{"operation": "raw", "table": ""}, 1},
+		{prometheus.Labels{}, 6},
+	}
+	for _, tt := range tests {
+		if got := rec.Counter("database_queries_total", tt.labels); got != tt.want {
+			t.Errorf("database_queries_total%v = %v, want %v", tt.labels, got, tt.want)
+		}
+	}
+	if got := rec.HistogramCount("database_query_duration_seconds", prometheus.Labels{"operation": "create", "table": "widgets"}); got != 2 {
+		t.Errorf("database_query_duration_seconds count of creates = %d, want 2", got)
+	}
+}
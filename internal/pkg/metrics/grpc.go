@@ -2,14 +2,22 @@ package metrics
 
 import (
 	"context"
+	"errors"
+	"io"
+	"sync"
 	"time"
 
+	"go-bootiful-ordering/internal/pkg/requestid"
+	"go-bootiful-ordering/internal/pkg/tracing"
+	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/status"
 )
 
-// UnaryServerInterceptor returns a gRPC interceptor that collects metrics for unary RPC calls
-func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+// UnaryServerInterceptor returns a gRPC interceptor that collects metrics for unary RPC calls.
+// Calls taking longer than slowThreshold are logged as warnings and kept in SlowRequests;
+// a non-positive threshold disables both.
+func (m *Metrics) UnaryServerInterceptor(log *zap.Logger, slowThreshold time.Duration) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
 		// Start timer
 		start := time.Now()
@@ -18,22 +26,28 @@ func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
 		resp, err := handler(ctx, req)
 
 		// Stop timer
-		duration := time.Since(start).Seconds()
+		elapsed := time.Since(start)
+		duration := elapsed.Seconds()
 
 		// Get status code
 		st := status.Code(err)
 		statusStr := st.String()
 
 		// Record metrics
-		GRPCRequestCounter.WithLabelValues(info.FullMethod, statusStr).Inc()
-		GRPCRequestDuration.WithLabelValues(info.FullMethod).Observe(duration)
+		m.GRPCRequestCounter.WithLabelValues(info.FullMethod, statusStr).Inc()
+		observeWithTrace(ctx, m.GRPCRequestDuration.WithLabelValues(info.FullMethod), duration)
+
+		// Log slow calls
+		m.logSlowCall(ctx, log, slowThreshold, info.FullMethod, statusStr, start, elapsed)
 
 		return resp, err
 	}
 }
 
-// StreamServerInterceptor returns a gRPC interceptor that collects metrics for streaming RPC calls
-func StreamServerInterceptor() grpc.StreamServerInterceptor {
+// StreamServerInterceptor returns a gRPC interceptor that collects metrics for streaming RPC calls.
+// Streams taking longer than slowThreshold are logged as warnings and kept in SlowRequests;
+// a non-positive threshold disables both.
+func (m *Metrics) StreamServerInterceptor(log *zap.Logger, slowThreshold time.Duration) grpc.StreamServerInterceptor {
 	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
 		// Start timer
 		start := time.Now()
@@ -42,16 +56,113 @@ func StreamServerInterceptor() grpc.StreamServerInterceptor {
 		err := handler(srv, ss)
 
 		// Stop timer
-		duration := time.Since(start).Seconds()
+		elapsed := time.Since(start)
+		duration := elapsed.Seconds()
 
 		// Get status code
 		st := status.Code(err)
 		statusStr := st.String()
 
 		// Record metrics
-		GRPCRequestCounter.WithLabelValues(info.FullMethod, statusStr).Inc()
-		GRPCRequestDuration.WithLabelValues(info.FullMethod).Observe(duration)
+		m.GRPCRequestCounter.WithLabelValues(info.FullMethod, statusStr).Inc()
+		observeWithTrace(ss.Context(), m.GRPCRequestDuration.WithLabelValues(info.FullMethod), duration)
+
+		// Log slow calls
+		m.logSlowCall(ss.Context(), log, slowThreshold, info.FullMethod, statusStr, start, elapsed)
 
 		return err
 	}
 }
+
+// UnaryClientInterceptor returns a gRPC interceptor that collects metrics for outgoing unary calls.
+// The method label is the full "/package.Service/Method" name, as on the server side.
+func (m *Metrics) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		// Start timer
+		start := time.Now()
+
+		// Make the call
+		err := invoker(ctx, method, req, reply, cc, opts...)
+
+		// Record metrics
+		m.recordClientCall(ctx, method, err, start)
+
+		return err
+	}
+}
+
+// StreamClientInterceptor returns a gRPC interceptor that collects metrics for outgoing streams.
+// A stream is recorded once, when it ends: when receiving reports io.EOF or an error, or when
+// it could not be opened.
+func (m *Metrics) StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		// Start timer
+		start := time.Now()
+
+		// Open the stream
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			m.recordClientCall(ctx, method, err, start)
+			return nil, err
+		}
+
+		return &monitoredClientStream{ClientStream: stream, metrics: m, ctx: ctx, method: method, start: start}, nil
+	}
+}
+
+// monitoredClientStream records the metrics of a client stream when it ends
+type monitoredClientStream struct {
+	grpc.ClientStream
+	metrics *Metrics
+	ctx     context.Context
+	method  string
+	start   time.Time
+	once    sync.Once
+}
+
+// RecvMsg receives a message and records the stream once receiving reports its end
+func (s *monitoredClientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil {
+		s.once.Do(func() {
+			// io.EOF is how a stream reports that it finished successfully
+			if errors.Is(err, io.EOF) {
+				s.metrics.recordClientCall(s.ctx, s.method, nil, s.start)
+				return
+			}
+			s.metrics.recordClientCall(s.ctx, s.method, err, s.start)
+		})
+	}
+	return err
+}
+
+// recordClientCall records the outcome and duration of an outgoing call
+func (m *Metrics) recordClientCall(ctx context.Context, method string, err error, start time.Time) {
+	m.GRPCClientRequestCounter.WithLabelValues(method, status.Code(err).String()).Inc()
+	observeWithTrace(ctx, m.GRPCClientRequestDuration.WithLabelValues(method), time.Since(start).Seconds())
+}
+
+// logSlowCall logs a warning and keeps the call in SlowRequests when a gRPC call
+// exceeded the slow threshold
+func (m *Metrics) logSlowCall(ctx context.Context, log *zap.Logger, slowThreshold time.Duration, method, statusStr string, start time.Time, elapsed time.Duration) {
+	if slowThreshold <= 0 || elapsed <= slowThreshold {
+		return
+	}
+
+	requestID := requestid.FromContext(ctx)
+	log.Warn("Slow gRPC request",
+		zap.String("method", method),
+		zap.String("status", statusStr),
+		zap.Duration("duration", elapsed),
+		zap.String(requestid.LogField, requestID),
+	)
+	m.SlowRequests.Add(SlowRequest{
+		Protocol:   "grpc",
+		Method:     method,
+		Status:     statusStr,
+		DurationMS: float64(elapsed) / float64(time.Millisecond),
+		Time:       start,
+		TraceID:    tracing.TraceID(ctx),
+		RequestID:  requestID,
+	})
+}
@@ -0,0 +1,132 @@
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go-bootiful-ordering/internal/pkg/metrics/metricstest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// echoServer answers every method on the in-process connection: unary calls and streams
+// echo the request back, and methods ending in "Fail" return NotFound
+func echoServer(srv interface{}, stream grpc.ServerStream) error {
+	method, _ := grpc.MethodFromServerStream(stream)
+	var req healthpb.HealthCheckRequest
+	if err := stream.RecvMsg(&req); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	if strings.HasSuffix(method, "Fail") {
+		return status.Error(codes.NotFound, "no such thing")
+	}
+	time.Sleep(time.Millisecond)
+	return stream.SendMsg(&healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING})
+}
+
+// dialEcho connects to an in-process echo server through the client interceptors of rec
+func dialEcho(t *testing.T, rec *metricstest.Recorder) *grpc.ClientConn {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(grpc.UnknownServiceHandler(echoServer))
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(rec.Metrics.UnaryClientInterceptor()),
+		grpc.WithStreamInterceptor(rec.Metrics.StreamClientInterceptor()),
+	)
+	if err != nil {
+		t.Fatalf("grpc.NewClient() error = %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestUnaryClientInterceptorRecordsCalls(t *testing.T) {
+	rec := metricstest.NewRecorder()
+	conn := dialEcho(t, rec)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if err := conn.Invoke(ctx, "/test.v1.Echo/Get", &healthpb.HealthCheckRequest{}, &healthpb.HealthCheckResponse{}); err != nil {
+			t.Fatalf("Invoke(Get) error = %v", err)
+		}
+	}
+	if err := conn.Invoke(ctx, "/test.v1.Echo/GetFail", &healthpb.HealthCheckRequest{}, &healthpb.HealthCheckResponse{}); status.Code(err) != codes.NotFound {
+		t.Fatalf("Invoke(GetFail) error = %v, want NotFound", err)
+	}
+
+	tests := []struct {
+		labels prometheus.Labels
+		want   float64
+	}{
+		{prometheus.Labels{"method": "/test.v1.Echo/Get", "status": "OK"}, 2},
+		{prometheus.Labels{"method": "/test.v1.Echo/GetFail", "status": "NotFound"}, 1},
+	}
+	for _, tt := range tests {
+		if got := rec.Counter("grpc_client_requests_total", tt.labels); got != tt.want {
+			t.Errorf("grpc_client_requests_total%v = %v, want %v", tt.labels, got, tt.want)
+		}
+	}
+	get := prometheus.Labels{"method": "/test.v1.Echo/Get"}
+	if count, sum := rec.HistogramCount("grpc_client_request_duration_seconds", get), rec.HistogramSum("grpc_client_request_duration_seconds", get); count != 2 || sum <= 0 {
+		t.Errorf("grpc_client_request_duration_seconds of Get = %d observations summing %v, want 2 with a positive duration", count, sum)
+	}
+}
+
+func TestStreamClientInterceptorRecordsStreamsOnce(t *testing.T) {
+	tests := []struct {
+		method string
+		want   string
+	}{
+		{method: "/test.v1.Echo/Watch", want: "OK"},
+		{method: "/test.v1.Echo/WatchFail", want: "NotFound"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			rec := metricstest.NewRecorder()
+			conn := dialEcho(t, rec)
+
+			stream, err := conn.NewStream(context.Background(), &grpc.StreamDesc{ServerStreams: true, ClientStreams: true}, tt.method)
+			if err != nil {
+				t.Fatalf("NewStream() error = %v", err)
+			}
+			if err := stream.SendMsg(&healthpb.HealthCheckRequest{}); err != nil {
+				t.Fatalf("SendMsg() error = %v", err)
+			}
+			if err := stream.CloseSend(); err != nil {
+				t.Fatalf("CloseSend() error = %v", err)
+			}
+			// Receive the reply, the end of the stream, and the end once more: the stream is
+			// still recorded once
+			for i := 0; i < 3; i++ {
+				_ = stream.RecvMsg(&healthpb.HealthCheckResponse{})
+			}
+
+			if got := rec.Counter("grpc_client_requests_total", prometheus.Labels{"method": tt.method}); got != 1 {
+				t.Errorf("grpc_client_requests_total = %v, want the stream recorded once", got)
+			}
+			if got := rec.Counter("grpc_client_requests_total", prometheus.Labels{"method": tt.method, "status": tt.want}); got != 1 {
+				t.Errorf("grpc_client_requests_total with status %s = %v, want 1", tt.want, got)
+			}
+			labels := prometheus.Labels{"method": tt.method}
+			if sum := rec.HistogramSum("grpc_client_request_duration_seconds", labels); sum <= 0 {
+				t.Errorf("grpc_client_request_duration_seconds sum = %v, want a positive duration", sum)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package metrics_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go-bootiful-ordering/internal/pkg/metrics/metricstest"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryServerInterceptorCountsCalls(t *testing.T) {
+	rec := metricstest.NewRecorder()
+	interceptor := rec.Metrics.UnaryServerInterceptor(zap.NewNop(), 0)
+	info := &grpc.UnaryServerInfo{FullMethod: "/order.v1.OrderService/GetOrder"}
+
+	handlers := []grpc.UnaryHandler{
+		func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil },
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, status.Error(codes.NotFound, "order not found")
+		},
+	}
+	for _, handler := range handlers {
+		interceptor(context.Background(), nil, info, handler)
+	}
+
+	tests := []struct {
+		status string
+		want   float64
+	}{
+		{codes.OK.String(), 1},
+		{codes.NotFound.String(), 1},
+		{codes.Internal.String(), 0},
+	}
+	for _, tt := range tests {
+		labels := prometheus.Labels{"method": info.FullMethod, "status": tt.status}
+		if got := rec.Counter("grpc_requests_total", labels); got != tt.want {
+			t.Errorf("grpc_requests_total%v = %v, want %v", labels, got, tt.want)
+		}
+	}
+	if got := rec.HistogramCount("grpc_request_duration_seconds", prometheus.Labels{"method": info.FullMethod}); got != 2 {
+		t.Errorf("grpc_request_duration_seconds count = %d, want 2", got)
+	}
+}
@@ -2,13 +2,19 @@ package metrics
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go-bootiful-ordering/internal/pkg/requestid"
+	"go-bootiful-ordering/internal/pkg/tracing"
+	"go.uber.org/zap"
 	"strconv"
 	"time"
 )
 
-// GinMiddleware returns a gin middleware that collects metrics for HTTP requests
-func GinMiddleware() gin.HandlerFunc {
+// GinMiddleware returns a gin middleware that collects metrics for HTTP requests.
+// Requests taking longer than slowThreshold are logged as warnings and kept in
+// SlowRequests; a non-positive threshold disables both.
+func (m *Metrics) GinMiddleware(log *zap.Logger, slowThreshold time.Duration) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Skip metrics endpoint to avoid circular measurements
 		if c.Request.URL.Path == "/metrics" {
@@ -23,16 +29,44 @@ func GinMiddleware() gin.HandlerFunc {
 		c.Next()
 
 		// Stop timer
-		duration := time.Since(start).Seconds()
+		elapsed := time.Since(start)
+		duration := elapsed.Seconds()
 
 		// Record metrics
 		status := strconv.Itoa(c.Writer.Status())
-		RequestCounter.WithLabelValues(c.Request.Method, c.Request.URL.Path, status).Inc()
-		RequestDuration.WithLabelValues(c.Request.Method, c.Request.URL.Path).Observe(duration)
+		m.RequestCounter.WithLabelValues(c.Request.Method, c.Request.URL.Path, status).Inc()
+		observeWithTrace(c.Request.Context(), m.RequestDuration.WithLabelValues(c.Request.Method, c.Request.URL.Path), duration)
+
+		// Log and keep slow requests
+		if slowThreshold > 0 && elapsed > slowThreshold {
+			requestID := requestid.FromContext(c.Request.Context())
+			log.Warn("Slow HTTP request",
+				zap.String("method", c.Request.Method),
+				zap.String("path", c.Request.URL.Path),
+				zap.Duration("duration", elapsed),
+				zap.String(requestid.LogField, requestID),
+			)
+			m.SlowRequests.Add(SlowRequest{
+				Protocol:   "http",
+				Method:     c.Request.Method,
+				Path:       c.Request.URL.Path,
+				Status:     status,
+				DurationMS: float64(elapsed) / float64(time.Millisecond),
+				Time:       start,
+				TraceID:    tracing.TraceID(c.Request.Context()),
+				RequestID:  requestID,
+			})
+		}
 	}
 }
 
-// RegisterMetricsEndpoint registers the /metrics endpoint with the gin engine
+// RegisterMetricsEndpoint registers the /metrics endpoint with the gin engine.
+// Scrapers asking for OpenMetrics in their Accept header get it, including
+// exemplars and _created samples; everyone else gets the Prometheus text format.
 func RegisterMetricsEndpoint(r *gin.Engine) {
-	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	handler := promhttp.InstrumentMetricHandler(
+		prometheus.DefaultRegisterer,
+		promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{EnableOpenMetrics: true}),
+	)
+	r.GET("/metrics", gin.WrapH(handler))
 }
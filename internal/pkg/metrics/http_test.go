@@ -0,0 +1,62 @@
+package metrics_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"go-bootiful-ordering/internal/pkg/metrics/metricstest"
+	"go.uber.org/zap"
+)
+
+func TestGinMiddlewareCountsRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rec := metricstest.NewRecorder()
+
+	router := gin.New()
+	router.Use(rec.Metrics.GinMiddleware(zap.NewNop(), 0))
+	router.GET("/orders", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/missing", func(c *gin.Context) { c.Status(http.StatusNotFound) })
+	router.GET("/metrics", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for _, path := range []string{"/orders", "/orders", "/missing", "/metrics"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	tests := []struct {
+		labels prometheus.Labels
+		want   float64
+	}{
+		{prometheus.Labels{"method": "GET", "path": "/orders", "status": "200"}, 2},
+		{prometheus.Labels{"method": "GET", "path": "/missing", "status": "404"}, 1},
+		{prometheus.Labels{"path": "/metrics"}, 0},
+		{prometheus.Labels{}, 3},
+	}
+	for _, tt := range tests {
+		if got := rec.Counter("http_requests_total", tt.labels); got != tt.want {
+			t.Errorf("http_requests_total%v = %v, want %v", tt.labels, got, tt.want)
+		}
+	}
+	if got := rec.HistogramCount("http_request_duration_seconds", prometheus.Labels{"path": "/orders"}); got != 2 {
+		t.Errorf("http_request_duration_seconds count = %d, want 2", got)
+	}
+}
+
+func TestRecordersDoNotShareCounts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	first, second := metricstest.NewRecorder(), metricstest.NewRecorder()
+
+	router := gin.New()
+	router.Use(first.Metrics.GinMiddleware(zap.NewNop(), 0))
+	router.GET("/orders", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders", nil))
+
+	if got := first.Counter("http_requests_total", nil); got != 1 {
+		t.Errorf("first recorder counted %v requests, want 1", got)
+	}
+	if got := second.Counter("http_requests_total", nil); got != 0 {
+		t.Errorf("second recorder counted %v requests, want 0", got)
+	}
+}
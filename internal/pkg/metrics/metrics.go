@@ -1,77 +1,253 @@
 package metrics
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promauto"
 )
 
-var (
-	// RequestCounter counts the number of HTTP requests
-	RequestCounter = promauto.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: "http_requests_total",
-			Help: "The total number of HTTP requests",
-		},
-		[]string{"method", "path", "status"},
-	)
+// Config holds the histogram buckets, in seconds. Empty buckets use prometheus.DefBuckets.
+type Config struct {
+	HTTPBuckets       []float64
+	GRPCBuckets       []float64
+	GRPCClientBuckets []float64
+	DBBuckets         []float64
 
-	// RequestDuration measures the duration of HTTP requests
-	RequestDuration = promauto.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Name:    "http_request_duration_seconds",
-			Help:    "The HTTP request duration in seconds",
-			Buckets: prometheus.DefBuckets,
-		},
-		[]string{"method", "path"},
-	)
+	// SlowRequests is the number of recent slow requests kept in memory; zero keeps none
+	SlowRequests int
+}
 
-	// GRPCRequestCounter counts the number of gRPC requests
-	GRPCRequestCounter = promauto.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: "grpc_requests_total",
-			Help: "The total number of gRPC requests",
-		},
-		[]string{"method", "status"},
-	)
+// Validate checks that every configured bucket list is strictly increasing
+func (c Config) Validate() error {
+	for _, histogram := range []struct {
+		name    string
+		buckets []float64
+	}{
+		{"http", c.HTTPBuckets},
+		{"grpc", c.GRPCBuckets},
+		{"grpcClient", c.GRPCClientBuckets},
+		{"db", c.DBBuckets},
+	} {
+		for i := 1; i < len(histogram.buckets); i++ {
+			if histogram.buckets[i] <= histogram.buckets[i-1] {
+				return fmt.Errorf("%s buckets must be in strictly increasing order", histogram.name)
+			}
+		}
+	}
+	return nil
+}
 
-	// GRPCRequestDuration measures the duration of gRPC requests
-	GRPCRequestDuration = promauto.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Name:    "grpc_request_duration_seconds",
-			Help:    "The gRPC request duration in seconds",
-			Buckets: prometheus.DefBuckets,
-		},
-		[]string{"method"},
-	)
+// bucketsOrDefault returns buckets, or prometheus.DefBuckets when none are configured
+func bucketsOrDefault(buckets []float64) []float64 {
+	if len(buckets) == 0 {
+		return prometheus.DefBuckets
+	}
+	return buckets
+}
 
+// Metrics holds the collectors shared by the middleware, interceptors and services
+type Metrics struct {
+	// RequestCounter counts the number of HTTP requests
+	RequestCounter *prometheus.CounterVec
+	// RequestDuration measures the duration of HTTP requests
+	RequestDuration *prometheus.HistogramVec
+	// GRPCRequestCounter counts the number of gRPC requests
+	GRPCRequestCounter *prometheus.CounterVec
+	// GRPCRequestDuration measures the duration of gRPC requests
+	GRPCRequestDuration *prometheus.HistogramVec
+	// GRPCClientRequestCounter counts the gRPC calls made to other services
+	GRPCClientRequestCounter *prometheus.CounterVec
+	// GRPCClientRequestDuration measures the duration of gRPC calls made to other services, retries included
+	GRPCClientRequestDuration *prometheus.HistogramVec
+	// InFlightRequests tracks the number of requests currently holding a concurrency slot
+	InFlightRequests prometheus.Gauge
+	// RejectedRequests counts requests rejected because the concurrency limit was reached
+	RejectedRequests *prometheus.CounterVec
+	// DatabaseTransactionCounter counts committed database transactions
+	DatabaseTransactionCounter *prometheus.CounterVec
+	// DatabaseRollbackCounter counts rolled back database transactions
+	DatabaseRollbackCounter *prometheus.CounterVec
+	// OrdersCreatedCounter counts created orders; the tenant label is empty unless tenant labels are enabled
+	OrdersCreatedCounter *prometheus.CounterVec
+	// ProductsCreatedCounter counts created products; the tenant label is empty unless tenant labels are enabled
+	ProductsCreatedCounter *prometheus.CounterVec
 	// DatabaseQueryCounter counts the number of database queries
-	DatabaseQueryCounter = promauto.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: "database_queries_total",
-			Help: "The total number of database queries",
-		},
-		[]string{"operation"},
-	)
-
+	DatabaseQueryCounter *prometheus.CounterVec
 	// DatabaseQueryDuration measures the duration of database queries
-	DatabaseQueryDuration = promauto.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Name:    "database_query_duration_seconds",
-			Help:    "The database query duration in seconds",
-			Buckets: prometheus.DefBuckets,
-		},
-		[]string{"operation"},
-	)
-)
+	DatabaseQueryDuration *prometheus.HistogramVec
+	// OrderEventsConsumedCounter counts the order outbox events passed to the metrics event handler, by event type
+	OrderEventsConsumedCounter *prometheus.CounterVec
+
+	// SlowRequests holds the latest requests logged as slow; it is nil when none are kept
+	SlowRequests *SlowRequests
+}
+
+// registrar registers collectors, keeping the first error
+type registrar struct {
+	reg prometheus.Registerer
+	err error
+}
+
+// register registers c and returns it. A collector registered before under the same
+// name is returned instead, so the metrics can be created more than once per registry.
+func register[C prometheus.Collector](r *registrar, c C) C {
+	if r.err != nil {
+		return c
+	}
+
+	if err := r.reg.Register(c); err != nil {
+		var alreadyRegistered prometheus.AlreadyRegisteredError
+		if errors.As(err, &alreadyRegistered) {
+			if existing, ok := alreadyRegistered.ExistingCollector.(C); ok {
+				return existing
+			}
+		}
+		r.err = err
+	}
+	return c
+}
+
+// InitMetrics creates the service's metrics on the default registry, which /metrics serves
+func InitMetrics(serviceName string, cfg Config) (*Metrics, error) {
+	return New(prometheus.DefaultRegisterer, serviceName, cfg)
+}
+
+// New creates the service's metrics and registers them with reg. Collectors already
+// registered by an earlier call are reused, keeping the buckets they were created with.
+func New(reg prometheus.Registerer, serviceName string, cfg Config) (*Metrics, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid metrics configuration: %w", err)
+	}
+
+	r := &registrar{reg: reg}
+	m := &Metrics{
+		RequestCounter: register(r, prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "http_requests_total",
+				Help: "The total number of HTTP requests",
+			},
+			[]string{"method", "path", "status"},
+		)),
+		RequestDuration: register(r, prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "http_request_duration_seconds",
+				Help:    "The HTTP request duration in seconds",
+				Buckets: bucketsOrDefault(cfg.HTTPBuckets),
+			},
+			[]string{"method", "path"},
+		)),
+		GRPCRequestCounter: register(r, prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "grpc_requests_total",
+				Help: "The total number of gRPC requests",
+			},
+			[]string{"method", "status"},
+		)),
+		GRPCRequestDuration: register(r, prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "grpc_request_duration_seconds",
+				Help:    "The gRPC request duration in seconds",
+				Buckets: bucketsOrDefault(cfg.GRPCBuckets),
+			},
+			[]string{"method"},
+		)),
+		GRPCClientRequestCounter: register(r, prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "grpc_client_requests_total",
+				Help: "The total number of outgoing gRPC requests",
+			},
+			[]string{"method", "status"},
+		)),
+		GRPCClientRequestDuration: register(r, prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "grpc_client_request_duration_seconds",
+				Help:    "The outgoing gRPC request duration in seconds",
+				Buckets: bucketsOrDefault(cfg.GRPCClientBuckets),
+			},
+			[]string{"method"},
+		)),
+		InFlightRequests: register(r, prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "in_flight_requests",
+				Help: "The number of requests currently being handled under the concurrency limit",
+			},
+		)),
+		RejectedRequests: register(r, prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "rejected_requests_total",
+				Help: "The total number of requests rejected by the concurrency limit",
+			},
+			[]string{"protocol"},
+		)),
+		DatabaseTransactionCounter: register(r, prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "db_transactions_total",
+				Help: "The total number of committed database transactions",
+			},
+			[]string{"operation"},
+		)),
+		DatabaseRollbackCounter: register(r, prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "db_transaction_rollbacks_total",
+				Help: "The total number of rolled back database transactions",
+			},
+			[]string{"operation"},
+		)),
+		OrdersCreatedCounter: register(r, prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "orders_created_total",
+				Help: "The total number of created orders",
+			},
+			[]string{"tenant"},
+		)),
+		ProductsCreatedCounter: register(r, prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "products_created_total",
+				Help: "The total number of created products",
+			},
+			[]string{"tenant"},
+		)),
+		DatabaseQueryCounter: register(r, prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "database_queries_total",
+				Help: "The total number of database queries",
+			},
+			[]string{"operation", "table"},
+		)),
+		DatabaseQueryDuration: register(r, prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "database_query_duration_seconds",
+				Help:    "The database query duration in seconds",
+				Buckets: bucketsOrDefault(cfg.DBBuckets),
+			},
+			[]string{"operation", "table"},
+		)),
+		OrderEventsConsumedCounter: register(r, prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "order_events_consumed_total",
+				Help: "The total number of consumed order events",
+			},
+			[]string{"event_type"},
+		)),
+	}
 
-// InitMetrics initializes the metrics registry
-func InitMetrics(serviceName string) {
 	// Register service info metric
-	promauto.NewGaugeVec(
+	serviceInfo := register(r, prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "service_info",
 			Help: "Information about the service",
 		},
 		[]string{"name", "version"},
-	).WithLabelValues(serviceName, "1.0.0").Set(1)
+	))
+	if r.err != nil {
+		return nil, r.err
+	}
+	serviceInfo.WithLabelValues(serviceName, "1.0.0").Set(1)
+
+	if cfg.SlowRequests > 0 {
+		m.SlowRequests = NewSlowRequests(cfg.SlowRequests)
+	}
+
+	return m, nil
 }
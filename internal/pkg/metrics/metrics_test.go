@@ -0,0 +1,93 @@
+package metrics_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go-bootiful-ordering/internal/pkg/config"
+	"go-bootiful-ordering/internal/pkg/metrics"
+	"go-bootiful-ordering/internal/pkg/metrics/metricstest"
+)
+
+func TestHistogramBucketsFromConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "service.yaml")
+	yaml := `
+metrics:
+  buckets:
+    http: [0.001, 0.005, 0.01]
+    grpc: [0.0005, 0.002]
+    db: [0.0001, 0.001, 0.1, 1]
+`
+	if err := os.WriteFile(path, []byte(yaml), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	rec := metricstest.NewRecorderWithConfig(cfg.MetricsOptions())
+	rec.Metrics.RequestDuration.WithLabelValues("GET", "/orders").Observe(0.002)
+	rec.Metrics.GRPCRequestDuration.WithLabelValues("/order.v1.OrderService/GetOrder").Observe(0.002)
+	rec.Metrics.GRPCClientRequestDuration.WithLabelValues("/product.v1.ProductService/GetProduct").Observe(0.002)
+	rec.Metrics.DatabaseQueryDuration.WithLabelValues("query", "orders").Observe(0.002)
+
+	tests := []struct {
+		name string
+		want []float64
+	}{
+		{name: "http_request_duration_seconds", want: []float64{0.001, 0.005, 0.01}},
+		{name: "grpc_request_duration_seconds", want: []float64{0.0005, 0.002}},
+		{name: "grpc_client_request_duration_seconds", want: prometheus.DefBuckets},
+		{name: "database_query_duration_seconds", want: []float64{0.0001, 0.001, 0.1, 1}},
+	}
+	for _, tt := range tests {
+		if got := rec.HistogramBuckets(tt.name, nil); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s buckets = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNewRejectsUnorderedBuckets(t *testing.T) {
+	_, err := metrics.New(prometheus.NewRegistry(), "test", metrics.Config{DBBuckets: []float64{0.1, 0.01}})
+	if err == nil {
+		t.Fatalf("New() error = nil for decreasing buckets")
+	}
+}
+
+func TestNewTwiceReusesRegisteredMetrics(t *testing.T) {
+	rec := metricstest.NewRecorderWithConfig(metrics.Config{HTTPBuckets: []float64{0.01, 0.1}})
+
+	second, err := metrics.New(rec.Registry, "test", metrics.Config{HTTPBuckets: []float64{1, 2, 3}})
+	if err != nil {
+		t.Fatalf("New() error = %v on a registry that already has the metrics", err)
+	}
+
+	// Both share the collectors registered first, buckets included
+	rec.Metrics.OrdersCreatedCounter.WithLabelValues("").Inc()
+	second.OrdersCreatedCounter.WithLabelValues("").Inc()
+	if got := rec.Counter("orders_created_total", nil); got != 2 {
+		t.Errorf("orders_created_total = %v, want 2 from both instances", got)
+	}
+	second.RequestDuration.WithLabelValues("GET", "/orders").Observe(0.05)
+	if got := rec.HistogramBuckets("http_request_duration_seconds", nil); !reflect.DeepEqual(got, []float64{0.01, 0.1}) {
+		t.Errorf("http_request_duration_seconds buckets = %v, want the ones registered first", got)
+	}
+}
+
+func TestInitMetricsTwice(t *testing.T) {
+	first, err := metrics.InitMetrics("test", metrics.Config{})
+	if err != nil {
+		t.Fatalf("InitMetrics() error = %v", err)
+	}
+	second, err := metrics.InitMetrics("test", metrics.Config{})
+	if err != nil {
+		t.Fatalf("InitMetrics() error = %v the second time", err)
+	}
+	if first.RequestCounter != second.RequestCounter {
+		t.Errorf("InitMetrics() created a second http_requests_total collector instead of reusing the first")
+	}
+}
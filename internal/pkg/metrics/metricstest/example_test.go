@@ -0,0 +1,28 @@
+package metricstest_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"go-bootiful-ordering/internal/pkg/metrics/metricstest"
+	"go.uber.org/zap"
+)
+
+func ExampleRecorder() {
+	gin.SetMode(gin.TestMode)
+	rec := metricstest.NewRecorder()
+
+	router := gin.New()
+	router.Use(rec.Metrics.GinMiddleware(zap.NewNop(), 0))
+	router.GET("/orders", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders", nil))
+
+	fmt.Println(rec.Counter("http_requests_total", prometheus.Labels{"method": "GET", "path": "/orders", "status": "200"}))
+	fmt.Println(rec.HistogramCount("http_request_duration_seconds", prometheus.Labels{"path": "/orders"}))
+	// Output:
+	// 1
+	// 1
+}
@@ -0,0 +1,139 @@
+// Package metricstest creates the service metrics on a private registry, so tests can
+// assert on metric values without sharing the default registry with the rest of the suite.
+//
+//	rec := metricstest.NewRecorder()
+//	router.Use(rec.Metrics.GinMiddleware(zap.NewNop(), 0))
+//	// ... serve a request ...
+//	rec.Counter("http_requests_total", prometheus.Labels{"method": "GET", "status": "200"})
+package metricstest
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"go-bootiful-ordering/internal/pkg/metrics"
+)
+
+// Recorder holds metrics registered on a registry of their own
+type Recorder struct {
+	Registry *prometheus.Registry
+	Metrics  *metrics.Metrics
+}
+
+// NewRecorder creates the metrics with the default buckets on a new registry
+func NewRecorder() *Recorder {
+	return NewRecorderWithConfig(metrics.Config{})
+}
+
+// NewRecorderWithConfig creates the metrics with the given configuration on a new registry
+func NewRecorderWithConfig(cfg metrics.Config) *Recorder {
+	registry := prometheus.NewRegistry()
+	m, err := metrics.New(registry, "test", cfg)
+	if err != nil {
+		panic(fmt.Sprintf("metricstest: failed to create metrics: %v", err))
+	}
+	return &Recorder{Registry: registry, Metrics: m}
+}
+
+// Counter returns the sum of the counter series of name whose labels include labels; a
+// series that was never incremented counts as zero
+func (r *Recorder) Counter(name string, labels prometheus.Labels) float64 {
+	var total float64
+	for _, metric := range r.series(name, labels) {
+		total += metric.GetCounter().GetValue()
+	}
+	return total
+}
+
+// HistogramCount returns the number of observations in the histogram series of name whose
+// labels include labels
+func (r *Recorder) HistogramCount(name string, labels prometheus.Labels) uint64 {
+	var count uint64
+	for _, metric := range r.series(name, labels) {
+		count += metric.GetHistogram().GetSampleCount()
+	}
+	return count
+}
+
+// HistogramSum returns the sum of the observations in the histogram series of name whose
+// labels include labels
+func (r *Recorder) HistogramSum(name string, labels prometheus.Labels) float64 {
+	var sum float64
+	for _, metric := range r.series(name, labels) {
+		sum += metric.GetHistogram().GetSampleSum()
+	}
+	return sum
+}
+
+// HistogramBuckets returns the upper bounds of the buckets of the first histogram series
+// of name whose labels include labels, or nil when there is none
+func (r *Recorder) HistogramBuckets(name string, labels prometheus.Labels) []float64 {
+	series := r.series(name, labels)
+	if len(series) == 0 {
+		return nil
+	}
+
+	var bounds []float64
+	for _, bucket := range series[0].GetHistogram().GetBucket() {
+		bounds = append(bounds, bucket.GetUpperBound())
+	}
+	return bounds
+}
+
+// HistogramExemplars returns the labels of the exemplars attached to the buckets of the
+// histogram series of name whose labels include labels
+func (r *Recorder) HistogramExemplars(name string, labels prometheus.Labels) []prometheus.Labels {
+	var exemplars []prometheus.Labels
+	for _, metric := range r.series(name, labels) {
+		for _, bucket := range metric.GetHistogram().GetBucket() {
+			if bucket.GetExemplar() == nil {
+				continue
+			}
+			exemplar := prometheus.Labels{}
+			for _, pair := range bucket.GetExemplar().GetLabel() {
+				exemplar[pair.GetName()] = pair.GetValue()
+			}
+			exemplars = append(exemplars, exemplar)
+		}
+	}
+	return exemplars
+}
+
+// series gathers the registry and returns the series of name matching labels
+func (r *Recorder) series(name string, labels prometheus.Labels) []*dto.Metric {
+	families, err := r.Registry.Gather()
+	if err != nil {
+		panic(fmt.Sprintf("metricstest: failed to gather metrics: %v", err))
+	}
+
+	var matched []*dto.Metric
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			if hasLabels(metric, labels) {
+				matched = append(matched, metric)
+			}
+		}
+	}
+	return matched
+}
+
+// hasLabels reports whether metric carries every label in labels with the same value
+func hasLabels(metric *dto.Metric, labels prometheus.Labels) bool {
+	for name, value := range labels {
+		found := false
+		for _, pair := range metric.GetLabel() {
+			if pair.GetName() == name {
+				found = pair.GetValue() == value
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,64 @@
+package metricstest_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"go-bootiful-ordering/internal/pkg/metrics/metricstest"
+	"go.uber.org/zap"
+)
+
+func TestRecordersDoNotShareState(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Each parallel test serves its own number of concurrent requests and must only see those
+	for _, requests := range []int{1, 5, 20} {
+		requests := requests
+		t.Run(fmt.Sprintf("%d requests", requests), func(t *testing.T) {
+			t.Parallel()
+			rec := metricstest.NewRecorder()
+			router := gin.New()
+			router.Use(rec.Metrics.GinMiddleware(zap.NewNop(), 0))
+			router.GET("/orders", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			var wg sync.WaitGroup
+			for i := 0; i < requests; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders", nil))
+				}()
+			}
+			wg.Wait()
+
+			if got := rec.Counter("http_requests_total", prometheus.Labels{"path": "/orders"}); got != float64(requests) {
+				t.Errorf("http_requests_total = %v, want %d", got, requests)
+			}
+			if got := rec.HistogramCount("http_request_duration_seconds", prometheus.Labels{"path": "/orders"}); got != uint64(requests) {
+				t.Errorf("http_request_duration_seconds count = %d, want %d", got, requests)
+			}
+		})
+	}
+}
+
+func TestRecorderReadsUnrecordedSeries(t *testing.T) {
+	rec := metricstest.NewRecorder()
+
+	if got := rec.Counter("http_requests_total", prometheus.Labels{"path": "/orders"}); got != 0 {
+		t.Errorf("Counter() of a series never incremented = %v, want 0", got)
+	}
+	if got := rec.HistogramCount("http_request_duration_seconds", prometheus.Labels{}); got != 0 {
+		t.Errorf("HistogramCount() of a histogram never observed = %d, want 0", got)
+	}
+	if got := rec.HistogramSum("no_such_histogram", prometheus.Labels{}); got != 0 {
+		t.Errorf("HistogramSum() of an unknown histogram = %v, want 0", got)
+	}
+	if got := rec.HistogramBuckets("no_such_histogram", prometheus.Labels{}); got != nil {
+		t.Errorf("HistogramBuckets() of an unknown histogram = %v, want nil", got)
+	}
+}
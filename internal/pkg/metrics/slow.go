@@ -0,0 +1,97 @@
+package metrics
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// DefaultSlowRequests is the number of slow requests kept when no size is configured
+	DefaultSlowRequests = 100
+	// MaxSlowRequests bounds the slow request buffer, whatever the configuration asks for
+	MaxSlowRequests = 1000
+)
+
+// SlowRequest describes a request that exceeded its slow threshold
+type SlowRequest struct {
+	// Protocol is "http" or "grpc"
+	Protocol string `json:"protocol"`
+	// Method is the HTTP method, or the full gRPC method name
+	Method string `json:"method"`
+	// Path is the HTTP request path; it is empty for gRPC calls
+	Path       string    `json:"path,omitempty"`
+	Status     string    `json:"status"`
+	DurationMS float64   `json:"duration_ms"`
+	Time       time.Time `json:"time"`
+	TraceID    string    `json:"trace_id,omitempty"`
+	RequestID  string    `json:"request_id,omitempty"`
+}
+
+// SlowRequests is a fixed-size ring buffer of the most recent slow requests.
+// A nil SlowRequests records nothing.
+type SlowRequests struct {
+	mu      sync.Mutex
+	entries []SlowRequest
+	next    int
+	full    bool
+}
+
+// NewSlowRequests creates a SlowRequests keeping the last size requests. A non-positive
+// size uses DefaultSlowRequests and sizes above MaxSlowRequests are capped.
+func NewSlowRequests(size int) *SlowRequests {
+	if size <= 0 {
+		size = DefaultSlowRequests
+	}
+	if size > MaxSlowRequests {
+		size = MaxSlowRequests
+	}
+	return &SlowRequests{entries: make([]SlowRequest, size)}
+}
+
+// Add records a slow request, overwriting the oldest one when the buffer is full
+func (s *SlowRequests) Add(request SlowRequest) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[s.next] = request
+	s.next = (s.next + 1) % len(s.entries)
+	if s.next == 0 {
+		s.full = true
+	}
+}
+
+// List returns the recorded slow requests, most recent first
+func (s *SlowRequests) List() []SlowRequest {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := s.next
+	if s.full {
+		count = len(s.entries)
+	}
+
+	requests := make([]SlowRequest, 0, count)
+	for i := 1; i <= count; i++ {
+		requests = append(requests, s.entries[(s.next-i+len(s.entries))%len(s.entries)])
+	}
+	return requests
+}
+
+// RegisterSlowRequestsEndpoint registers GET /debug/slow, listing the recorded slow requests
+// most recent first
+func RegisterSlowRequestsEndpoint(r *gin.Engine, slow *SlowRequests) {
+	r.GET("/debug/slow", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"requests": slow.List()})
+	})
+}
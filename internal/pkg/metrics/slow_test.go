@@ -0,0 +1,133 @@
+package metrics_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go-bootiful-ordering/internal/pkg/metrics"
+	"go-bootiful-ordering/internal/pkg/metrics/metricstest"
+	"go-bootiful-ordering/internal/pkg/requestid"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc"
+)
+
+const slowThreshold = 20 * time.Millisecond
+
+// slowRecorder returns a recorder keeping slow requests, and a logger recording its warnings
+func slowRecorder() (*metricstest.Recorder, *zap.Logger, *observer.ObservedLogs) {
+	core, logs := observer.New(zapcore.WarnLevel)
+	return metricstest.NewRecorderWithConfig(metrics.Config{SlowRequests: 10}), zap.New(core), logs
+}
+
+func TestGinMiddlewareLogsSlowRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rec, log, logs := slowRecorder()
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Request = c.Request.WithContext(requestid.WithID(c.Request.Context(), "request-1"))
+		c.Next()
+	})
+	router.Use(rec.Metrics.GinMiddleware(log, slowThreshold))
+	router.GET("/slow", func(c *gin.Context) {
+		time.Sleep(2 * slowThreshold)
+		c.Status(http.StatusOK)
+	})
+	router.GET("/fast", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for _, path := range []string{"/slow", "/fast"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	entries := logs.FilterMessage("Slow HTTP request").All()
+	if len(entries) != 1 {
+		t.Fatalf("logged %d slow HTTP requests, want 1", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if fields["method"] != "GET" || fields["path"] != "/slow" || fields[requestid.LogField] != "request-1" {
+		t.Errorf("slow request log fields = %v, want GET /slow of request-1", fields)
+	}
+	if duration, _ := fields["duration"].(time.Duration); duration < 2*slowThreshold {
+		t.Errorf("slow request log duration = %v, want at least %v", fields["duration"], 2*slowThreshold)
+	}
+
+	slow := rec.Metrics.SlowRequests.List()
+	if len(slow) != 1 || slow[0].Protocol != "http" || slow[0].Path != "/slow" || slow[0].Status != "200" || slow[0].RequestID != "request-1" {
+		t.Errorf("SlowRequests.List() = %+v, want the /slow request only", slow)
+	}
+}
+
+func TestUnaryServerInterceptorLogsSlowCalls(t *testing.T) {
+	rec, log, logs := slowRecorder()
+	interceptor := rec.Metrics.UnaryServerInterceptor(log, slowThreshold)
+	ctx := requestid.WithID(context.Background(), "request-1")
+
+	slow := func(ctx context.Context, req interface{}) (interface{}, error) {
+		time.Sleep(2 * slowThreshold)
+		return "ok", nil
+	}
+	fast := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+	interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/order.v1.OrderService/ListOrders"}, slow)
+	interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/order.v1.OrderService/GetOrder"}, fast)
+
+	entries := logs.FilterMessage("Slow gRPC request").All()
+	if len(entries) != 1 {
+		t.Fatalf("logged %d slow gRPC calls, want 1", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if fields["method"] != "/order.v1.OrderService/ListOrders" || fields["status"] != "OK" || fields[requestid.LogField] != "request-1" {
+		t.Errorf("slow call log fields = %v, want an OK ListOrders call of request-1", fields)
+	}
+
+	calls := rec.Metrics.SlowRequests.List()
+	if len(calls) != 1 || calls[0].Protocol != "grpc" || calls[0].Method != "/order.v1.OrderService/ListOrders" {
+		t.Errorf("SlowRequests.List() = %+v, want the ListOrders call only", calls)
+	}
+}
+
+func TestSlowThresholdDisabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rec, log, logs := slowRecorder()
+
+	router := gin.New()
+	router.Use(rec.Metrics.GinMiddleware(log, 0))
+	router.GET("/slow", func(c *gin.Context) {
+		time.Sleep(slowThreshold)
+		c.Status(http.StatusOK)
+	})
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/slow", nil))
+
+	interceptor := rec.Metrics.UnaryServerInterceptor(log, 0)
+	interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/order.v1.OrderService/ListOrders"}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		time.Sleep(slowThreshold)
+		return "ok", nil
+	})
+
+	if logs.Len() != 0 || len(rec.Metrics.SlowRequests.List()) != 0 {
+		t.Errorf("a zero threshold logged %d requests and kept %d, want none", logs.Len(), len(rec.Metrics.SlowRequests.List()))
+	}
+}
+
+func TestSlowRequestsKeepsTheLatest(t *testing.T) {
+	slow := metrics.NewSlowRequests(2)
+	for _, path := range []string{"/first", "/second", "/third"} {
+		slow.Add(metrics.SlowRequest{Path: path})
+	}
+
+	got := slow.List()
+	if len(got) != 2 || got[0].Path != "/third" || got[1].Path != "/second" {
+		t.Errorf("List() = %+v, want /third then /second", got)
+	}
+
+	var none *metrics.SlowRequests
+	none.Add(metrics.SlowRequest{Path: "/ignored"})
+	if got := none.List(); got != nil {
+		t.Errorf("nil SlowRequests List() = %+v, want nil", got)
+	}
+}
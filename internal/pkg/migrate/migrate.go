@@ -3,6 +3,7 @@ package migrate
 import (
 	"fmt"
 	"log"
+	"net"
 	"path/filepath"
 	"strings"
 	"time"
@@ -60,6 +61,20 @@ func Run(cfg *Config) error {
 	return RunWithTimeout(migrationDir, cfg.DSN, cfg.Timeout)
 }
 
+// CheckAutoMigrate refuses GORM AutoMigrate for a database that is not on the local
+// machine. AutoMigrate is a shortcut for local iteration: it only ever adds tables and
+// columns, skips the indexes and data changes kept in the migration files, and leaves
+// the migration version untouched, so it must never replace migrations on a shared database.
+func CheckAutoMigrate(host string) error {
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+	return fmt.Errorf("db.useAutoMigrate is for local development only and cannot be used with database host %q", host)
+}
+
 // RunWithTimeout runs migrations with a timeout
 func RunWithTimeout(dir, dsn string, timeout time.Duration) error {
 	done := make(chan error, 1)
@@ -0,0 +1,26 @@
+package migrate
+
+import "testing"
+
+func TestCheckAutoMigrate(t *testing.T) {
+	tests := []struct {
+		host    string
+		allowed bool
+	}{
+		{host: "localhost", allowed: true},
+		{host: "127.0.0.1", allowed: true},
+		{host: "127.0.0.2", allowed: true},
+		{host: "::1", allowed: true},
+		{host: "postgres", allowed: false},
+		{host: "db.internal.example.com", allowed: false},
+		{host: "10.0.0.5", allowed: false},
+		{host: "localhost.example.com", allowed: false},
+		{host: "", allowed: false},
+	}
+
+	for _, tt := range tests {
+		if err := CheckAutoMigrate(tt.host); (err == nil) != tt.allowed {
+			t.Errorf("CheckAutoMigrate(%q) error = %v, want allowed %v", tt.host, err, tt.allowed)
+		}
+	}
+}
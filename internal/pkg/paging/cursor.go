@@ -0,0 +1,66 @@
+package paging
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+var (
+	// ErrMalformedCursor is returned for a page token that was not issued by EncodeCursor
+	ErrMalformedCursor = errors.New("malformed page token")
+	// ErrCursorSortMismatch is returned for a page token issued for a different sort column
+	// or direction, which would skip or repeat rows
+	ErrCursorSortMismatch = errors.New("page token was issued for a different sort order")
+)
+
+// Cursor is the position of the last row of a page. It records the value of the column
+// the list is sorted by as well as the row's ID, which breaks ties between equal values,
+// so the next page continues from the same place even if that row has since been changed
+// or deleted. Value is empty when the list is sorted by ID alone. Direction is the
+// direction the list is sorted in ("ASC" or "DESC").
+type Cursor struct {
+	Column    string `json:"c"`
+	Direction string `json:"d"`
+	Value     string `json:"v,omitempty"`
+	ID        string `json:"id"`
+}
+
+// EncodeCursor returns the opaque page token of a cursor
+func EncodeCursor(cursor Cursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a page token issued by EncodeCursor for a list sorted by column in
+// direction
+func DecodeCursor(token, column, direction string) (Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return Cursor{}, ErrMalformedCursor
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == "" {
+		return Cursor{}, ErrMalformedCursor
+	}
+	if cursor.Column != column || cursor.Direction != direction {
+		return Cursor{}, ErrCursorSortMismatch
+	}
+	return cursor, nil
+}
+
+// KeysetPredicate returns the WHERE clause selecting the rows after a cursor in a list
+// ordered by column and then id, both in direction ("ASC" or "DESC"). A list ordered by
+// id alone compares the ID only; otherwise the row value (column, id) is compared, which
+// the caller binds to the cursor's typed value and ID.
+func KeysetPredicate(column, direction string) string {
+	operator := ">"
+	if direction == "DESC" {
+		operator = "<"
+	}
+	if column == "id" {
+		return "id " + operator + " ?"
+	}
+	return "(" + column + ", id) " + operator + " (?, ?)"
+}
@@ -0,0 +1,73 @@
+package paging
+
+import (
+	"fmt"
+	"strconv"
+)
+
+const (
+	// DefaultPageSize is used when a list request does not set a page size
+	DefaultPageSize = 10
+	// DefaultMaxPageSize caps page sizes when no maximum is configured
+	DefaultMaxPageSize = 100
+)
+
+// Limits holds the default and maximum page size of list requests
+type Limits struct {
+	Default int32
+	Max     int32
+}
+
+// NewLimits creates Limits, falling back to the package defaults for non-positive values
+func NewLimits(defaultSize, maxSize int32) Limits {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxPageSize
+	}
+	if defaultSize <= 0 {
+		defaultSize = DefaultPageSize
+	}
+	if defaultSize > maxSize {
+		defaultSize = maxSize
+	}
+	return Limits{Default: defaultSize, Max: maxSize}
+}
+
+// PageSize returns the page size to use for a requested size: zero selects the default
+// and sizes above the maximum are clamped to it. Negative sizes are rejected.
+func (l Limits) PageSize(requested int32) (int32, error) {
+	switch {
+	case requested < 0:
+		return 0, fmt.Errorf("page_size must not be negative, got %d", requested)
+	case requested == 0:
+		return l.Default, nil
+	case requested > l.Max:
+		return l.Max, nil
+	default:
+		return requested, nil
+	}
+}
+
+// ParsePageSize parses the page_size query parameter of an HTTP list request and applies
+// the limits to it; empty selects the default and non-integers are rejected
+func (l Limits) ParsePageSize(raw string) (int32, error) {
+	if raw == "" {
+		return l.Default, nil
+	}
+	requested, err := strconv.ParseInt(raw, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("page_size must be an integer, got %q", raw)
+	}
+	return l.PageSize(int32(requested))
+}
+
+// ParseIncludeTotal parses the include_total query parameter of a list request; empty means false
+func ParseIncludeTotal(raw string) (bool, error) {
+	if raw == "" {
+		return false, nil
+	}
+	includeTotal, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, fmt.Errorf("include_total must be true or false, got %q", raw)
+	}
+	return includeTotal, nil
+}
@@ -0,0 +1,125 @@
+package paging
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor Cursor
+	}{
+		{name: "by id", cursor: Cursor{Column: "id", Direction: "ASC", ID: "order-1"}},
+		{name: "by value descending", cursor: Cursor{Column: "price", Direction: "DESC", Value: "1999", ID: "product-1"}},
+		{name: "value with separators", cursor: Cursor{Column: "name", Direction: "ASC", Value: `a "b" / c:d`, ID: "product-2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := EncodeCursor(tt.cursor)
+			got, err := DecodeCursor(token, tt.cursor.Column, tt.cursor.Direction)
+			if err != nil {
+				t.Fatalf("DecodeCursor(%q) error = %v", token, err)
+			}
+			if got != tt.cursor {
+				t.Errorf("DecodeCursor(EncodeCursor(%+v)) = %+v", tt.cursor, got)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorErrors(t *testing.T) {
+	valid := EncodeCursor(Cursor{Column: "price", Direction: "ASC", Value: "100", ID: "product-1"})
+	encode := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+
+	tests := []struct {
+		name      string
+		token     string
+		column    string
+		direction string
+		want      error
+	}{
+		{name: "not base64", token: "not a token!", column: "price", direction: "ASC", want: ErrMalformedCursor},
+		{name: "truncated", token: valid[:len(valid)/2], column: "price", direction: "ASC", want: ErrMalformedCursor},
+		{name: "not json", token: encode("price:100:product-1"), column: "price", direction: "ASC", want: ErrMalformedCursor},
+		{name: "tampered fields", token: encode(`{"c":"price","d":"ASC","v":100,"id":"product-1"}`), column: "price", direction: "ASC", want: ErrMalformedCursor},
+		{name: "missing id", token: encode(`{"c":"price","d":"ASC","v":"100"}`), column: "price", direction: "ASC", want: ErrMalformedCursor},
+		{name: "other column", token: valid, column: "name", direction: "ASC", want: ErrCursorSortMismatch},
+		{name: "other direction", token: valid, column: "price", direction: "DESC", want: ErrCursorSortMismatch},
+		{name: "no direction", token: encode(`{"c":"price","v":"100","id":"product-1"}`), column: "price", direction: "ASC", want: ErrCursorSortMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeCursor(tt.token, tt.column, tt.direction); !errors.Is(err, tt.want) {
+				t.Errorf("DecodeCursor(%q, %q, %q) error = %v, want %v", tt.token, tt.column, tt.direction, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeysetPredicate(t *testing.T) {
+	tests := []struct {
+		column    string
+		direction string
+		want      string
+	}{
+		{column: "id", direction: "ASC", want: "id > ?"},
+		{column: "id", direction: "DESC", want: "id < ?"},
+		{column: "price", direction: "ASC", want: "(price, id) > (?, ?)"},
+		{column: "created_at", direction: "DESC", want: "(created_at, id) < (?, ?)"},
+	}
+
+	for _, tt := range tests {
+		if got := KeysetPredicate(tt.column, tt.direction); got != tt.want {
+			t.Errorf("KeysetPredicate(%q, %q) = %q, want %q", tt.column, tt.direction, got, tt.want)
+		}
+	}
+}
+
+func TestNewLimits(t *testing.T) {
+	tests := []struct {
+		name                 string
+		defaultSize, maxSize int32
+		wantDefault, wantMax int32
+	}{
+		{name: "configured", defaultSize: 20, maxSize: 50, wantDefault: 20, wantMax: 50},
+		{name: "unset", wantDefault: DefaultPageSize, wantMax: DefaultMaxPageSize},
+		{name: "negative", defaultSize: -1, maxSize: -1, wantDefault: DefaultPageSize, wantMax: DefaultMaxPageSize},
+		{name: "default above the maximum", defaultSize: 30, maxSize: 5, wantDefault: 5, wantMax: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewLimits(tt.defaultSize, tt.maxSize)
+			if got.Default != tt.wantDefault || got.Max != tt.wantMax {
+				t.Errorf("NewLimits(%d, %d) = %+v, want default %d and max %d", tt.defaultSize, tt.maxSize, got, tt.wantDefault, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestLimitsPageSize(t *testing.T) {
+	limits := NewLimits(10, 50)
+
+	tests := []struct {
+		requested int32
+		want      int32
+		wantErr   bool
+	}{
+		{requested: 0, want: 10},
+		{requested: 1, want: 1},
+		{requested: 50, want: 50},
+		{requested: 51, want: 50},
+		{requested: -1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := limits.PageSize(tt.requested)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("PageSize(%d) = %d, %v, want %d, error %v", tt.requested, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package requestid
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// Header is the HTTP header carrying the request ID
+	Header = "X-Request-ID"
+	// MetadataKey is the gRPC metadata key carrying the request ID
+	MetadataKey = "x-request-id"
+	// LogField is the log field holding the request ID
+	LogField = "request_id"
+	// MaxLength bounds accepted request IDs; longer ones are replaced with a generated ID
+	MaxLength = 128
+)
+
+type contextKey struct{}
+
+// WithID returns a copy of ctx carrying the request ID
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the request ID of the request, or an empty string when there is none
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// Logger returns log with the request ID of ctx as a field, or log itself when there is none
+func Logger(ctx context.Context, log *zap.Logger) *zap.Logger {
+	if id := FromContext(ctx); id != "" {
+		return log.With(zap.String(LogField, id))
+	}
+	return log
+}
+
+// SugaredLogger returns log with the request ID of ctx as a field, or log itself when there is none
+func SugaredLogger(ctx context.Context, log *zap.SugaredLogger) *zap.SugaredLogger {
+	if id := FromContext(ctx); id != "" {
+		return log.With(LogField, id)
+	}
+	return log
+}
+
+// resolve returns the caller's request ID, or a new UUID when it sent none or an oversized one
+func resolve(id string) string {
+	if id == "" || len(id) > MaxLength {
+		return uuid.New().String()
+	}
+	return id
+}
+
+// GinMiddleware returns a gin middleware storing the request ID in the request context
+// and echoing it in the response header
+func GinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := resolve(c.GetHeader(Header))
+		c.Request = c.Request.WithContext(WithID(c.Request.Context(), id))
+		c.Header(Header, id)
+		c.Next()
+	}
+}
+
+// UnaryServerInterceptor returns a gRPC interceptor storing the request ID from the request
+// metadata in the call context and sending it back in the response header
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		var id string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(MetadataKey); len(values) > 0 {
+				id = values[0]
+			}
+		}
+		id = resolve(id)
+
+		// The header is only sent with the response, so a failure here cannot affect the call
+		_ = grpc.SetHeader(ctx, metadata.Pairs(MetadataKey, id))
+		return handler(WithID(ctx, id), req)
+	}
+}
+
+// outgoing returns ctx with the request ID added to the outgoing metadata, if it has one
+func outgoing(ctx context.Context) context.Context {
+	if id := FromContext(ctx); id != "" {
+		return metadata.AppendToOutgoingContext(ctx, MetadataKey, id)
+	}
+	return ctx
+}
+
+// UnaryClientInterceptor returns a gRPC interceptor forwarding the request ID to the called service
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(outgoing(ctx), method, req, reply, cc, opts...)
+	}
+}
+
+// StreamClientInterceptor returns a gRPC interceptor forwarding the request ID to the called service
+func StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(outgoing(ctx), desc, cc, method, opts...)
+	}
+}
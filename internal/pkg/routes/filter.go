@@ -0,0 +1,96 @@
+package routes
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Filter rejects HTTP routes and gRPC methods disabled by configuration.
+// HTTP routes are named "METHOD /path" using the registered gin pattern
+// (e.g. "POST /products", "DELETE /products/:id"), and gRPC methods by their
+// full name (e.g. "/product.v1.ProductService/CreateProduct").
+type Filter struct {
+	disabled map[string]struct{}
+}
+
+// NewFilter creates a new Filter for the given disabled route names
+func NewFilter(disabled []string) *Filter {
+	f := &Filter{disabled: make(map[string]struct{}, len(disabled))}
+	for _, name := range disabled {
+		f.disabled[normalize(name)] = struct{}{}
+	}
+	return f
+}
+
+// normalize canonicalizes a route name so that "post  /products" matches "POST /products"
+func normalize(name string) string {
+	fields := strings.Fields(name)
+	if len(fields) == 2 {
+		return strings.ToUpper(fields[0]) + " " + fields[1]
+	}
+	return strings.TrimSpace(name)
+}
+
+// isDisabled reports whether the named route is disabled
+func (f *Filter) isDisabled(name string) bool {
+	_, ok := f.disabled[name]
+	return ok
+}
+
+// GinMiddleware returns a gin middleware that responds 404 to disabled routes.
+// It must be installed before the routes are registered.
+func (f *Filter) GinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if len(f.disabled) > 0 && f.isDisabled(c.Request.Method+" "+c.FullPath()) {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Not found"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// UnaryServerInterceptor returns a gRPC interceptor that rejects disabled methods with Unimplemented
+func (f *Filter) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if f.isDisabled(info.FullMethod) {
+			return nil, status.Errorf(codes.Unimplemented, "method %s is disabled", info.FullMethod)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// Validate checks that every disabled name matches a route registered on the
+// engine or a method registered on the gRPC server
+func (f *Filter) Validate(engine *gin.Engine, server *grpc.Server) error {
+	known := make(map[string]struct{})
+	for _, route := range engine.Routes() {
+		known[route.Method+" "+route.Path] = struct{}{}
+	}
+	for serviceName, info := range server.GetServiceInfo() {
+		for _, method := range info.Methods {
+			known["/"+serviceName+"/"+method.Name] = struct{}{}
+		}
+	}
+
+	var unknown []string
+	for name := range f.disabled {
+		if _, ok := known[name]; !ok {
+			unknown = append(unknown, name)
+		}
+	}
+
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown disabled routes: %s", strings.Join(unknown, ", "))
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package routes_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go-bootiful-ordering/internal/pkg/routes"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+func newProductEngine(filter *routes.Filter) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(filter.GinMiddleware())
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	engine.GET("/products", ok)
+	engine.POST("/products", ok)
+	engine.GET("/products/:id", ok)
+	engine.DELETE("/products/:id", ok)
+	return engine
+}
+
+func TestFilterDisablesHTTPRoutes(t *testing.T) {
+	engine := newProductEngine(routes.NewFilter([]string{"POST /products", "delete  /products/:id"}))
+
+	tests := []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodPost, "/products", http.StatusNotFound},
+		{http.MethodDelete, "/products/product-1", http.StatusNotFound},
+		{http.MethodGet, "/products", http.StatusOK},
+		{http.MethodGet, "/products/product-1", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+			if w.Code != tt.want {
+				t.Errorf("%s %s status = %d, want %d", tt.method, tt.path, w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterDisablesGRPCMethods(t *testing.T) {
+	interceptor := routes.NewFilter([]string{"/grpc.health.v1.Health/Watch"}).UnaryServerInterceptor()
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+
+	_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Watch"}, handler)
+	if status.Code(err) != codes.Unimplemented {
+		t.Errorf("disabled method error = %v, want Unimplemented", err)
+	}
+	if resp, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"}, handler); err != nil || resp != "ok" {
+		t.Errorf("enabled method = %v, %v, want the handler's response", resp, err)
+	}
+}
+
+func TestFilterValidate(t *testing.T) {
+	server := grpc.NewServer()
+	healthpb.RegisterHealthServer(server, health.NewServer())
+
+	tests := []struct {
+		name     string
+		disabled []string
+		unknown  string
+	}{
+		{name: "nothing disabled"},
+		{name: "known route and method", disabled: []string{"POST /products", "/grpc.health.v1.Health/Check"}},
+		{name: "unknown route", disabled: []string{"PUT /products"}, unknown: "PUT /products"},
+		{name: "route that is a path only", disabled: []string{"/products"}, unknown: "/products"},
+		{name: "unknown method", disabled: []string{"/grpc.health.v1.Health/List"}, unknown: "/grpc.health.v1.Health/List"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := routes.NewFilter(tt.disabled)
+			err := filter.Validate(newProductEngine(filter), server)
+			if tt.unknown == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.unknown) {
+				t.Errorf("Validate() error = %v, want one naming %q", err, tt.unknown)
+			}
+		})
+	}
+}
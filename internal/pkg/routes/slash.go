@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"net/http"
+	"strings"
+)
+
+// StripTrailingSlash returns a handler that removes trailing slashes from the request
+// path before routing, so "/products/" is served by the "/products" route directly.
+// Gin's own trailing slash redirects are meant to be disabled alongside it: a redirect
+// costs a round trip and clients that do not follow 307s drop the request body.
+func StripTrailingSlash(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if path := r.URL.Path; len(path) > 1 && strings.HasSuffix(path, "/") {
+			r.URL.Path = strings.TrimRight(path, "/")
+			if r.URL.Path == "" {
+				r.URL.Path = "/"
+			}
+			// RawPath must be cleared or match Path, otherwise routing uses the stale value
+			r.URL.RawPath = ""
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,134 @@
+package tenant
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/opentracing/opentracing-go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// DefaultHeader is the header (or gRPC metadata key) carrying the tenant ID when none is configured
+	DefaultHeader = "X-Tenant-ID"
+	// DefaultMaxMetricTenants caps distinct tenant label values when no limit is configured
+	DefaultMaxMetricTenants = 100
+	// SpanTag is the span tag holding the tenant ID
+	SpanTag = "tenant.id"
+	// OtherLabel is the metric label used for tenants past the cardinality limit
+	OtherLabel = "other"
+)
+
+type contextKey struct{}
+
+// tenantInfo is the tenant information stored in a request context
+type tenantInfo struct {
+	id          string
+	metricLabel string
+}
+
+// WithID returns a copy of ctx carrying the tenant ID and its metric label
+func WithID(ctx context.Context, id, metricLabel string) context.Context {
+	return context.WithValue(ctx, contextKey{}, tenantInfo{id: id, metricLabel: metricLabel})
+}
+
+// FromContext returns the tenant ID of the request, or an empty string when there is none
+func FromContext(ctx context.Context) string {
+	t, _ := ctx.Value(contextKey{}).(tenantInfo)
+	return t.id
+}
+
+// MetricLabel returns the tenant label value for business metrics. It is empty when
+// tenant labels are disabled or the request has no tenant, so metrics stay unlabelled.
+func MetricLabel(ctx context.Context) string {
+	t, _ := ctx.Value(contextKey{}).(tenantInfo)
+	return t.metricLabel
+}
+
+// Resolver extracts the tenant ID from incoming requests, stores it in the request
+// context and tags the active span with it. When metric labels are enabled, the first
+// maxMetricTenants tenants seen get their own label value and later ones share OtherLabel.
+type Resolver struct {
+	header           string
+	metricLabels     bool
+	maxMetricTenants int
+
+	mu            sync.Mutex
+	metricTenants map[string]struct{}
+}
+
+// NewResolver creates a new Resolver reading the tenant ID from header.
+// An empty header uses DefaultHeader and a non-positive limit uses DefaultMaxMetricTenants.
+func NewResolver(header string, metricLabels bool, maxMetricTenants int) *Resolver {
+	if header == "" {
+		header = DefaultHeader
+	}
+	if maxMetricTenants <= 0 {
+		maxMetricTenants = DefaultMaxMetricTenants
+	}
+	return &Resolver{
+		header:           header,
+		metricLabels:     metricLabels,
+		maxMetricTenants: maxMetricTenants,
+		metricTenants:    make(map[string]struct{}),
+	}
+}
+
+// metricLabel returns the bounded metric label value for a tenant ID
+func (r *Resolver) metricLabel(id string) string {
+	if !r.metricLabels {
+		return ""
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.metricTenants[id]; ok {
+		return id
+	}
+	if len(r.metricTenants) >= r.maxMetricTenants {
+		return OtherLabel
+	}
+	r.metricTenants[id] = struct{}{}
+	return id
+}
+
+// resolve stores the tenant in the context and tags the span already started for the request
+func (r *Resolver) resolve(ctx context.Context, id string) context.Context {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return ctx
+	}
+
+	if span := opentracing.SpanFromContext(ctx); span != nil {
+		span.SetTag(SpanTag, id)
+	}
+	return WithID(ctx, id, r.metricLabel(id))
+}
+
+// GinMiddleware returns a gin middleware resolving the tenant from the request header.
+// It must run after the tracing middleware so the request span can be tagged.
+func (r *Resolver) GinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if id := c.GetHeader(r.header); id != "" {
+			c.Request = c.Request.WithContext(r.resolve(c.Request.Context(), id))
+		}
+		c.Next()
+	}
+}
+
+// UnaryServerInterceptor returns a gRPC interceptor resolving the tenant from the request metadata.
+// It must run after the tracing interceptor so the call span can be tagged.
+func (r *Resolver) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(r.header); len(values) > 0 {
+				ctx = r.resolve(ctx, values[0])
+			}
+		}
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,39 @@
+package timefmt
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// DefaultLayout is the timestamp layout used when none is configured
+const DefaultLayout = time.RFC3339
+
+var layout atomic.Value
+
+func init() {
+	layout.Store(DefaultLayout)
+}
+
+// SetLayout sets the layout used for timestamps in API responses; an empty layout restores DefaultLayout.
+// It is meant to be called once at startup, before any request is served.
+func SetLayout(l string) {
+	if l == "" {
+		l = DefaultLayout
+	}
+	layout.Store(l)
+}
+
+// Layout returns the layout used for timestamps in API responses
+func Layout() string {
+	return layout.Load().(string)
+}
+
+// Format formats t with the configured layout, so HTTP and gRPC responses render timestamps identically
+func Format(t time.Time) string {
+	return t.Format(Layout())
+}
+
+// Parse parses a timestamp formatted with the configured layout
+func Parse(value string) (time.Time, error) {
+	return time.Parse(Layout(), value)
+}
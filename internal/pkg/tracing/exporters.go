@@ -0,0 +1,152 @@
+package tracing
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/uber/jaeger-client-go"
+	jaegercfg "github.com/uber/jaeger-client-go/config"
+	jaegerlog "github.com/uber/jaeger-client-go/log"
+	"github.com/uber/jaeger-client-go/transport"
+)
+
+const (
+	// ExporterJaegerAgent sends spans over UDP to a Jaeger agent endpoint (host:port), as Tempo receives them by default
+	ExporterJaegerAgent = "jaeger-agent"
+	// ExporterJaegerHTTP posts spans to a Jaeger collector URL such as http://tempo:14268/api/traces
+	ExporterJaegerHTTP = "jaeger-http"
+	// ExporterStdout writes every finished span to standard output, for local debugging
+	ExporterStdout = "stdout"
+)
+
+// reportFlushInterval is how often buffered spans are sent to the remote exporters
+const reportFlushInterval = time.Second
+
+// stdout is where the stdout exporter writes spans; tests replace it
+var stdout io.Writer = os.Stdout
+
+// Exporter is one destination finished spans are reported to
+type Exporter struct {
+	Type     string
+	Endpoint string
+}
+
+// Validate checks that the exporter has a known type and a well-formed endpoint for it
+func (e Exporter) Validate() error {
+	switch e.Type {
+	case ExporterJaegerAgent:
+		host, port, err := net.SplitHostPort(e.Endpoint)
+		if err != nil {
+			return fmt.Errorf("%s exporter endpoint %q must be host:port: %w", e.Type, e.Endpoint, err)
+		}
+		if n, err := strconv.Atoi(port); host == "" || err != nil || n <= 0 || n > 65535 {
+			return fmt.Errorf("%s exporter endpoint %q must be host:port", e.Type, e.Endpoint)
+		}
+		return nil
+	case ExporterJaegerHTTP:
+		u, err := url.Parse(e.Endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%s exporter endpoint %q must be an http(s) URL", e.Type, e.Endpoint)
+		}
+		return nil
+	case ExporterStdout:
+		if e.Endpoint != "" {
+			return fmt.Errorf("%s exporter takes no endpoint, got %q", e.Type, e.Endpoint)
+		}
+		return nil
+	case "otlp-grpc", "otlp-http":
+		return fmt.Errorf("%s exporters need the OpenTelemetry SDK; spans are reported with the Jaeger client, use %q or %q", e.Type, ExporterJaegerAgent, ExporterJaegerHTTP)
+	default:
+		return fmt.Errorf("unknown exporter type %q, expected %q, %q or %q", e.Type, ExporterJaegerAgent, ExporterJaegerHTTP, ExporterStdout)
+	}
+}
+
+// reporter creates the Jaeger reporter sending spans to the exporter
+func (e Exporter) reporter(logger jaeger.Logger) (jaeger.Reporter, error) {
+	switch e.Type {
+	case ExporterJaegerAgent:
+		sender, err := jaeger.NewUDPTransport(e.Endpoint, 0)
+		if err != nil {
+			return nil, err
+		}
+		return jaeger.NewRemoteReporter(sender, jaeger.ReporterOptions.BufferFlushInterval(reportFlushInterval), jaeger.ReporterOptions.Logger(logger)), nil
+	case ExporterJaegerHTTP:
+		sender := transport.NewHTTPTransport(e.Endpoint)
+		return jaeger.NewRemoteReporter(sender, jaeger.ReporterOptions.BufferFlushInterval(reportFlushInterval), jaeger.ReporterOptions.Logger(logger)), nil
+	default:
+		return jaeger.NewLoggingReporter(stdoutLogger{out: stdout}), nil
+	}
+}
+
+// stdoutLogger is a jaeger.Logger writing to standard output
+type stdoutLogger struct {
+	out io.Writer
+}
+
+// Error writes an error message
+func (l stdoutLogger) Error(msg string) {
+	fmt.Fprintf(l.out, "ERROR: %s\n", msg)
+}
+
+// Infof writes an informational message
+func (l stdoutLogger) Infof(msg string, args ...interface{}) {
+	fmt.Fprintf(l.out, msg+"\n", args...)
+}
+
+// InitTracerWithExporters initializes a new OpenTracing tracer reporting every span to all
+// the given exporters. Every exporter is validated before any of them is created.
+func InitTracerWithExporters(serviceName string, exporters []Exporter, propagation Propagation) (opentracing.Tracer, io.Closer, error) {
+	if err := propagation.Validate(); err != nil {
+		return nil, nil, err
+	}
+	if len(exporters) == 0 {
+		return nil, nil, fmt.Errorf("at least one tracing exporter is required")
+	}
+	for i, exporter := range exporters {
+		if err := exporter.Validate(); err != nil {
+			return nil, nil, fmt.Errorf("tracing exporter %d: %w", i, err)
+		}
+	}
+
+	jLogger := jaegerlog.StdLogger
+	reporters := make([]jaeger.Reporter, 0, len(exporters))
+	for i, exporter := range exporters {
+		reporter, err := exporter.reporter(jLogger)
+		if err != nil {
+			for _, created := range reporters {
+				created.Close()
+			}
+			return nil, nil, fmt.Errorf("tracing exporter %d: %w", i, err)
+		}
+		reporters = append(reporters, reporter)
+	}
+
+	cfg := jaegercfg.Configuration{
+		ServiceName: serviceName,
+		Sampler: &jaegercfg.SamplerConfig{
+			Type:  jaeger.SamplerTypeConst,
+			Param: 1,
+		},
+	}
+
+	// Use the same propagation options as InitTracer
+	options := append([]jaegercfg.Option{
+		jaegercfg.Logger(jLogger),
+		jaegercfg.Reporter(jaeger.NewCompositeReporter(reporters...)),
+		jaegercfg.ZipkinSharedRPCSpan(true),
+	}, propagation.options()...)
+
+	tracer, closer, err := cfg.NewTracer(options...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot initialize OpenTracing Tracer: %w", err)
+	}
+
+	opentracing.SetGlobalTracer(tracer)
+	return tracer, closer, nil
+}
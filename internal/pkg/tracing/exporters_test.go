@@ -0,0 +1,113 @@
+package tracing
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/uber/jaeger-client-go"
+)
+
+func TestExporterValidate(t *testing.T) {
+	tests := []struct {
+		exporter Exporter
+		valid    bool
+	}{
+		{exporter: Exporter{Type: ExporterJaegerAgent, Endpoint: "tempo:6831"}, valid: true},
+		{exporter: Exporter{Type: ExporterJaegerAgent, Endpoint: "tempo"}},
+		{exporter: Exporter{Type: ExporterJaegerAgent, Endpoint: ":6831"}},
+		{exporter: Exporter{Type: ExporterJaegerAgent, Endpoint: "tempo:70000"}},
+		{exporter: Exporter{Type: ExporterJaegerHTTP, Endpoint: "http://tempo:14268/api/traces"}, valid: true},
+		{exporter: Exporter{Type: ExporterJaegerHTTP, Endpoint: "tempo:14268"}},
+		{exporter: Exporter{Type: ExporterJaegerHTTP, Endpoint: "ftp://tempo/api/traces"}},
+		{exporter: Exporter{Type: ExporterStdout}, valid: true},
+		{exporter: Exporter{Type: ExporterStdout, Endpoint: "/dev/stdout"}},
+		{exporter: Exporter{Type: "otlp-grpc", Endpoint: "tempo:4317"}},
+		{exporter: Exporter{Type: "zipkin", Endpoint: "http://zipkin:9411"}},
+	}
+
+	for _, tt := range tests {
+		if err := tt.exporter.Validate(); (err == nil) != tt.valid {
+			t.Errorf("%+v.Validate() error = %v, want valid %v", tt.exporter, err, tt.valid)
+		}
+	}
+}
+
+// collector is a mock Jaeger collector recording the batches posted to it
+type collector struct {
+	mu      sync.Mutex
+	batches [][]byte
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	c.mu.Lock()
+	c.batches = append(c.batches, body)
+	c.mu.Unlock()
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (c *collector) received() []byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return bytes.Join(c.batches, nil)
+}
+
+func TestInitTracerWithExportersReportsToEveryExporter(t *testing.T) {
+	var out bytes.Buffer
+	stdout = &out
+	t.Cleanup(func() { stdout = os.Stdout })
+	t.Cleanup(func() { opentracing.SetGlobalTracer(opentracing.NoopTracer{}) })
+
+	mock := &collector{}
+	server := httptest.NewServer(mock)
+	defer server.Close()
+
+	tracer, closer, err := InitTracerWithExporters("test", []Exporter{
+		{Type: ExporterStdout},
+		{Type: ExporterJaegerHTTP, Endpoint: server.URL + "/api/traces"},
+	}, PropagationB3)
+	if err != nil {
+		t.Fatalf("InitTracerWithExporters() error = %v", err)
+	}
+
+	span := tracer.StartSpan("checkout-span")
+	traceID := span.Context().(jaeger.SpanContext).TraceID().String()
+	span.Finish()
+	// Closing flushes the spans buffered for the collector
+	if err := closer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if !strings.Contains(out.String(), traceID) {
+		t.Errorf("stdout exporter wrote %q, want the span of trace %s", out.String(), traceID)
+	}
+	if !bytes.Contains(mock.received(), []byte("checkout-span")) {
+		t.Errorf("collector received %q, want the checkout-span span", mock.received())
+	}
+}
+
+func TestInitTracerWithExportersRejectsInvalidExporters(t *testing.T) {
+	tests := []struct {
+		name      string
+		exporters []Exporter
+	}{
+		{name: "none"},
+		{name: "one invalid among valid ones", exporters: []Exporter{{Type: ExporterStdout}, {Type: ExporterJaegerAgent, Endpoint: "tempo"}}},
+		{name: "otlp", exporters: []Exporter{{Type: "otlp-http", Endpoint: "http://tempo:4318"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := InitTracerWithExporters("test", tt.exporters, PropagationB3); err == nil {
+				t.Errorf("InitTracerWithExporters(%+v) error = nil, want the exporters rejected", tt.exporters)
+			}
+		})
+	}
+}
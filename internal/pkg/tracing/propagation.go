@@ -0,0 +1,176 @@
+package tracing
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/uber/jaeger-client-go"
+	jaegercfg "github.com/uber/jaeger-client-go/config"
+	"github.com/uber/jaeger-client-go/zipkin"
+)
+
+// Propagation is the header format span contexts are passed between services in
+type Propagation string
+
+const (
+	// PropagationB3 uses the Zipkin B3 headers (x-b3-traceid, x-b3-spanid, ...), the default
+	PropagationB3 Propagation = "b3"
+	// PropagationW3C uses the W3C Trace Context traceparent header
+	PropagationW3C Propagation = "w3c"
+	// PropagationBoth extracts either format and injects both
+	PropagationBoth Propagation = "both"
+)
+
+// Validate checks that the propagation is a known format; empty means B3
+func (p Propagation) Validate() error {
+	switch p {
+	case "", PropagationB3, PropagationW3C, PropagationBoth:
+		return nil
+	default:
+		return fmt.Errorf("unknown tracing propagation %q, expected %q, %q or %q", p, PropagationB3, PropagationW3C, PropagationBoth)
+	}
+}
+
+// options returns the tracer options registering the propagators of the format. W3C trace
+// IDs are 128 bits, so traces started by a tracer injecting traceparent get 128-bit IDs.
+func (p Propagation) options() []jaegercfg.Option {
+	b3 := zipkin.NewZipkinB3HTTPHeaderPropagator()
+
+	var propagator propagator
+	switch p {
+	case PropagationW3C:
+		propagator = w3cPropagator{}
+	case PropagationBoth:
+		propagator = compositePropagator{b3, w3cPropagator{}}
+	default:
+		return []jaegercfg.Option{
+			jaegercfg.Injector(opentracing.HTTPHeaders, b3),
+			jaegercfg.Extractor(opentracing.HTTPHeaders, b3),
+		}
+	}
+	return []jaegercfg.Option{
+		jaegercfg.Injector(opentracing.HTTPHeaders, propagator),
+		jaegercfg.Extractor(opentracing.HTTPHeaders, propagator),
+		jaegercfg.Gen128Bit(true),
+	}
+}
+
+// propagator injects span contexts into and extracts them from HTTP headers
+type propagator interface {
+	jaeger.Injector
+	jaeger.Extractor
+}
+
+// traceparentHeader is the W3C Trace Context header, traceparentVersion the only version defined
+const (
+	traceparentHeader  = "traceparent"
+	traceparentVersion = "00"
+)
+
+// w3cPropagator injects and extracts the W3C traceparent header
+// (version-traceid-parentid-flags, e.g. 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01).
+// Baggage and tracestate are not carried.
+type w3cPropagator struct{}
+
+// Inject writes the span context as a traceparent header
+func (w3cPropagator) Inject(sc jaeger.SpanContext, abstractCarrier interface{}) error {
+	textMapWriter, ok := abstractCarrier.(opentracing.TextMapWriter)
+	if !ok {
+		return opentracing.ErrInvalidCarrier
+	}
+
+	flags := "00"
+	if sc.IsSampled() {
+		flags = "01"
+	}
+	traceID := sc.TraceID()
+	textMapWriter.Set(traceparentHeader, fmt.Sprintf("%s-%016x%016x-%s-%s", traceparentVersion, traceID.High, traceID.Low, sc.SpanID(), flags))
+	return nil
+}
+
+// Extract reads the span context from a traceparent header. A malformed header is treated
+// as missing, as the specification asks, so the request starts a new trace.
+func (w3cPropagator) Extract(abstractCarrier interface{}) (jaeger.SpanContext, error) {
+	textMapReader, ok := abstractCarrier.(opentracing.TextMapReader)
+	if !ok {
+		return jaeger.SpanContext{}, opentracing.ErrInvalidCarrier
+	}
+
+	var traceparent string
+	if err := textMapReader.ForeachKey(func(key, value string) error {
+		if strings.EqualFold(key, traceparentHeader) {
+			traceparent = value
+		}
+		return nil
+	}); err != nil {
+		return jaeger.SpanContext{}, err
+	}
+
+	sc, ok := parseTraceparent(traceparent)
+	if !ok {
+		return jaeger.SpanContext{}, opentracing.ErrSpanContextNotFound
+	}
+	return sc, nil
+}
+
+// parseTraceparent parses a version 00 traceparent value. Later versions may append fields,
+// so only the first four are read from them.
+func parseTraceparent(value string) (jaeger.SpanContext, bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == traceparentVersion && len(parts) != 4) {
+		return jaeger.SpanContext{}, false
+	}
+	if len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return jaeger.SpanContext{}, false
+	}
+
+	high, errHigh := strconv.ParseUint(parts[1][:16], 16, 64)
+	low, errLow := strconv.ParseUint(parts[1][16:], 16, 64)
+	spanID, errSpan := strconv.ParseUint(parts[2], 16, 64)
+	flags, errFlags := strconv.ParseUint(parts[3], 16, 8)
+	if errHigh != nil || errLow != nil || errSpan != nil || errFlags != nil {
+		return jaeger.SpanContext{}, false
+	}
+
+	traceID := jaeger.TraceID{High: high, Low: low}
+	if !traceID.IsValid() || spanID == 0 {
+		return jaeger.SpanContext{}, false
+	}
+	return jaeger.NewSpanContext(traceID, jaeger.SpanID(spanID), 0, flags&1 == 1, nil), true
+}
+
+// compositePropagator injects every format and extracts the first one present
+type compositePropagator []propagator
+
+// Inject writes the span context in every format
+func (c compositePropagator) Inject(sc jaeger.SpanContext, abstractCarrier interface{}) error {
+	for _, propagator := range c {
+		if err := propagator.Inject(sc, abstractCarrier); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Extract returns the span context of the first format found in the carrier. A format
+// that fails to parse does not hide a later one; its error is only returned when no
+// format yields a span context.
+func (c compositePropagator) Extract(abstractCarrier interface{}) (jaeger.SpanContext, error) {
+	var firstErr error
+	for _, propagator := range c {
+		sc, err := propagator.Extract(abstractCarrier)
+		if err == nil {
+			return sc, nil
+		}
+		if firstErr == nil && !errors.Is(err, opentracing.ErrSpanContextNotFound) {
+			firstErr = err
+		}
+	}
+	if firstErr != nil {
+		return jaeger.SpanContext{}, firstErr
+	}
+	return jaeger.SpanContext{}, opentracing.ErrSpanContextNotFound
+}
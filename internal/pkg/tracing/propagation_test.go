@@ -0,0 +1,209 @@
+package tracing
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/uber/jaeger-client-go"
+	jaegercfg "github.com/uber/jaeger-client-go/config"
+	"google.golang.org/grpc/metadata"
+)
+
+// newTestTracer creates a sampling tracer with the propagators of the format that reports
+// nowhere
+func newTestTracer(t *testing.T, propagation Propagation) opentracing.Tracer {
+	t.Helper()
+	cfg := jaegercfg.Configuration{
+		ServiceName: "test",
+		Sampler:     &jaegercfg.SamplerConfig{Type: jaeger.SamplerTypeConst, Param: 1},
+	}
+	options := append([]jaegercfg.Option{jaegercfg.Reporter(jaeger.NewNullReporter())}, propagation.options()...)
+	tracer, closer, err := cfg.NewTracer(options...)
+	if err != nil {
+		t.Fatalf("NewTracer() error = %v", err)
+	}
+	t.Cleanup(func() { closer.Close() })
+	return tracer
+}
+
+// carriers are the header maps span contexts travel in: HTTP headers and gRPC metadata
+var carriers = []struct {
+	name string
+	new  func() interface{}
+	keys func(carrier interface{}) []string
+}{
+	{
+		name: "http",
+		new:  func() interface{} { return opentracing.HTTPHeadersCarrier(http.Header{}) },
+		keys: func(carrier interface{}) []string {
+			var keys []string
+			for key := range carrier.(opentracing.HTTPHeadersCarrier) {
+				keys = append(keys, strings.ToLower(key))
+			}
+			return keys
+		},
+	},
+	{
+		name: "grpc",
+		new:  func() interface{} { return MetadataTextMap(metadata.MD{}) },
+		keys: func(carrier interface{}) []string {
+			var keys []string
+			for key := range carrier.(MetadataTextMap) {
+				keys = append(keys, key)
+			}
+			return keys
+		},
+	},
+}
+
+func hasKey(keys []string, want string) bool {
+	for _, key := range keys {
+		if key == want {
+			return true
+		}
+	}
+	return false
+}
+
+func TestPropagationRoundTrip(t *testing.T) {
+	tests := []struct {
+		inject      Propagation
+		extract     Propagation
+		wantB3      bool
+		wantW3C     bool
+		wantSurvive bool
+	}{
+		{inject: PropagationB3, extract: PropagationB3, wantB3: true, wantSurvive: true},
+		{inject: "", extract: PropagationB3, wantB3: true, wantSurvive: true},
+		{inject: PropagationW3C, extract: PropagationW3C, wantW3C: true, wantSurvive: true},
+		{inject: PropagationBoth, extract: PropagationBoth, wantB3: true, wantW3C: true, wantSurvive: true},
+		{inject: PropagationBoth, extract: PropagationB3, wantB3: true, wantW3C: true, wantSurvive: true},
+		{inject: PropagationBoth, extract: PropagationW3C, wantB3: true, wantW3C: true, wantSurvive: true},
+		{inject: PropagationB3, extract: PropagationBoth, wantB3: true, wantSurvive: true},
+		{inject: PropagationW3C, extract: PropagationBoth, wantW3C: true, wantSurvive: true},
+		{inject: PropagationB3, extract: PropagationW3C, wantB3: true},
+		{inject: PropagationW3C, extract: PropagationB3, wantW3C: true},
+	}
+
+	for _, carrier := range carriers {
+		for _, tt := range tests {
+			t.Run(carrier.name+"/"+string(tt.inject)+" to "+string(tt.extract), func(t *testing.T) {
+				span := newTestTracer(t, tt.inject).StartSpan("caller")
+				defer span.Finish()
+				sent := span.Context().(jaeger.SpanContext)
+
+				headers := carrier.new()
+				if err := span.Tracer().Inject(span.Context(), opentracing.HTTPHeaders, headers); err != nil {
+					t.Fatalf("Inject() error = %v", err)
+				}
+				keys := carrier.keys(headers)
+				if got := hasKey(keys, "x-b3-traceid"); got != tt.wantB3 {
+					t.Errorf("B3 headers injected = %v, want %v (headers %v)", got, tt.wantB3, keys)
+				}
+				if got := hasKey(keys, traceparentHeader); got != tt.wantW3C {
+					t.Errorf("traceparent injected = %v, want %v (headers %v)", got, tt.wantW3C, keys)
+				}
+
+				extracted, err := newTestTracer(t, tt.extract).Extract(opentracing.HTTPHeaders, headers)
+				if !tt.wantSurvive {
+					if err == nil {
+						t.Errorf("Extract() = %v, want no span context in the other format", extracted)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("Extract() error = %v", err)
+				}
+				got := extracted.(jaeger.SpanContext)
+				if got.TraceID() != sent.TraceID() || got.SpanID() != sent.SpanID() || !got.IsSampled() {
+					t.Errorf("Extract() = %v, want %v", got, sent)
+				}
+			})
+		}
+	}
+}
+
+func TestBothExtractsEitherHeaderSet(t *testing.T) {
+	const (
+		w3cTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+		w3cSpanID  = "00f067aa0ba902b7"
+		b3TraceID  = "463ac35c9f6413ad48485a3953bb6124"
+		b3SpanID   = "a2fb4a1d1a96d312"
+	)
+	traceparent := "00-" + w3cTraceID + "-" + w3cSpanID + "-01"
+
+	tests := []struct {
+		name        string
+		headers     map[string]string
+		wantTraceID string
+		wantSpanID  string
+	}{
+		{
+			name:        "b3 only",
+			headers:     map[string]string{"X-B3-TraceId": b3TraceID, "X-B3-SpanId": b3SpanID, "X-B3-Sampled": "1"},
+			wantTraceID: b3TraceID,
+			wantSpanID:  b3SpanID,
+		},
+		{
+			name:        "traceparent only",
+			headers:     map[string]string{"Traceparent": traceparent},
+			wantTraceID: w3cTraceID,
+			wantSpanID:  w3cSpanID,
+		},
+		{
+			name:        "malformed b3 next to a valid traceparent",
+			headers:     map[string]string{"X-B3-TraceId": "not-hex", "X-B3-SpanId": b3SpanID, "Traceparent": traceparent},
+			wantTraceID: w3cTraceID,
+			wantSpanID:  w3cSpanID,
+		},
+	}
+
+	tracer := newTestTracer(t, PropagationBoth)
+	for _, carrier := range carriers {
+		for _, tt := range tests {
+			t.Run(carrier.name+"/"+tt.name, func(t *testing.T) {
+				headers := carrier.new()
+				for key, value := range tt.headers {
+					headers.(opentracing.TextMapWriter).Set(key, value)
+				}
+
+				extracted, err := tracer.Extract(opentracing.HTTPHeaders, headers)
+				if err != nil {
+					t.Fatalf("Extract() error = %v", err)
+				}
+				got := extracted.(jaeger.SpanContext)
+				if got.TraceID().String() != tt.wantTraceID || got.SpanID().String() != tt.wantSpanID {
+					t.Errorf("Extract() = %s:%s, want %s:%s", got.TraceID(), got.SpanID(), tt.wantTraceID, tt.wantSpanID)
+				}
+			})
+		}
+	}
+
+	if _, err := tracer.Extract(opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(http.Header{})); err != opentracing.ErrSpanContextNotFound {
+		t.Errorf("Extract() error = %v without headers, want ErrSpanContextNotFound", err)
+	}
+}
+
+func TestParseTraceparentRejectsMalformedValues(t *testing.T) {
+	values := []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e47zz-00f067aa0ba902b7-01",
+	}
+	for _, value := range values {
+		if sc, ok := parseTraceparent(value); ok {
+			t.Errorf("parseTraceparent(%q) = %v, want it rejected", value, sc)
+		}
+	}
+
+	if _, ok := parseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-future"); !ok {
+		t.Errorf("parseTraceparent() rejected the extra field of a later version")
+	}
+}
@@ -1,6 +1,7 @@
 package tracing
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"time"
@@ -9,12 +10,16 @@ import (
 	"github.com/uber/jaeger-client-go"
 	jaegercfg "github.com/uber/jaeger-client-go/config"
 	jaegerlog "github.com/uber/jaeger-client-go/log"
-	"github.com/uber/jaeger-client-go/zipkin"
 )
 
-// InitTracer initializes a new OpenTracing tracer with Tempo as the backend
+// InitTracer initializes a new OpenTracing tracer with Tempo as the backend, passing span
+// contexts in the given propagation format
 // We're still using the Jaeger client as Tempo supports the Jaeger protocol
-func InitTracer(serviceName string, tempoHostPort string) (opentracing.Tracer, io.Closer, error) {
+func InitTracer(serviceName string, tempoHostPort string, propagation Propagation) (opentracing.Tracer, io.Closer, error) {
+	if err := propagation.Validate(); err != nil {
+		return nil, nil, err
+	}
+
 	cfg := jaegercfg.Configuration{
 		ServiceName: serviceName,
 		Sampler: &jaegercfg.SamplerConfig{
@@ -28,16 +33,14 @@ func InitTracer(serviceName string, tempoHostPort string) (opentracing.Tracer, i
 		},
 	}
 
-	// Initialize tracer with zipkin propagation format
+	// Initialize tracer with the configured propagation format
 	jLogger := jaegerlog.StdLogger
-	zipkinPropagator := zipkin.NewZipkinB3HTTPHeaderPropagator()
-
-	tracer, closer, err := cfg.NewTracer(
+	options := append([]jaegercfg.Option{
 		jaegercfg.Logger(jLogger),
-		jaegercfg.Injector(opentracing.HTTPHeaders, zipkinPropagator),
-		jaegercfg.Extractor(opentracing.HTTPHeaders, zipkinPropagator),
 		jaegercfg.ZipkinSharedRPCSpan(true),
-	)
+	}, propagation.options()...)
+
+	tracer, closer, err := cfg.NewTracer(options...)
 
 	if err != nil {
 		return nil, nil, fmt.Errorf("cannot initialize OpenTracing Tracer: %w", err)
@@ -46,3 +49,18 @@ func InitTracer(serviceName string, tempoHostPort string) (opentracing.Tracer, i
 	opentracing.SetGlobalTracer(tracer)
 	return tracer, closer, nil
 }
+
+// TraceID returns the ID of the sampled trace the context's span belongs to, or an
+// empty string when the context has no span or its trace is not recorded
+func TraceID(ctx context.Context) string {
+	span := opentracing.SpanFromContext(ctx)
+	if span == nil {
+		return ""
+	}
+
+	sc, ok := span.Context().(jaeger.SpanContext)
+	if !ok || !sc.IsSampled() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
@@ -0,0 +1,36 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/uber/jaeger-client-go"
+)
+
+func TestTraceID(t *testing.T) {
+	sampled := newTestTracer(t, PropagationB3)
+	unsampled, closer := jaeger.NewTracer("test", jaeger.NewConstSampler(false), jaeger.NewNullReporter())
+	t.Cleanup(func() { closer.Close() })
+
+	span := sampled.StartSpan("request")
+	defer span.Finish()
+	want := span.Context().(jaeger.SpanContext).TraceID().String()
+	if got := TraceID(opentracing.ContextWithSpan(context.Background(), span)); got != want {
+		t.Errorf("TraceID() of a sampled span = %q, want %q", got, want)
+	}
+
+	unrecorded := unsampled.StartSpan("request")
+	defer unrecorded.Finish()
+	if got := TraceID(opentracing.ContextWithSpan(context.Background(), unrecorded)); got != "" {
+		t.Errorf("TraceID() of an unsampled span = %q, want empty", got)
+	}
+
+	noop := opentracing.NoopTracer{}.StartSpan("request")
+	if got := TraceID(opentracing.ContextWithSpan(context.Background(), noop)); got != "" {
+		t.Errorf("TraceID() of a span of another tracer = %q, want empty", got)
+	}
+	if got := TraceID(context.Background()); got != "" {
+		t.Errorf("TraceID() without a span = %q, want empty", got)
+	}
+}
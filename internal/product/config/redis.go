@@ -7,21 +7,38 @@ import (
 	"time"
 )
 
+const (
+	// DefaultDialTimeout bounds establishing a new Redis connection
+	DefaultDialTimeout = time.Second
+	// DefaultReadTimeout bounds reading a Redis reply; a slower cache falls back to the database
+	DefaultReadTimeout = 200 * time.Millisecond
+	// DefaultWriteTimeout bounds writing a Redis command
+	DefaultWriteTimeout = 200 * time.Millisecond
+)
+
 // RedisConfig holds the configuration for the Redis connection
 type RedisConfig struct {
 	Host     string
 	Port     string
 	Password string
 	DB       int
+
+	// Timeouts; zero uses the defaults above
+	DialTimeout  time.Duration
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
 }
 
 // NewDefaultRedisConfig creates a new RedisConfig with default values
 func NewDefaultRedisConfig() *RedisConfig {
 	return &RedisConfig{
-		Host:     getEnv("REDIS_HOST", "localhost"),
-		Port:     getEnv("REDIS_PORT", "6379"),
-		Password: getEnv("REDIS_PASSWORD", ""),
-		DB:       0,
+		Host:         getEnv("REDIS_HOST", "localhost"),
+		Port:         getEnv("REDIS_PORT", "6379"),
+		Password:     getEnv("REDIS_PASSWORD", ""),
+		DB:           0,
+		DialTimeout:  getEnvDuration("REDIS_DIAL_TIMEOUT", DefaultDialTimeout),
+		ReadTimeout:  getEnvDuration("REDIS_READ_TIMEOUT", DefaultReadTimeout),
+		WriteTimeout: getEnvDuration("REDIS_WRITE_TIMEOUT", DefaultWriteTimeout),
 	}
 }
 
@@ -30,13 +47,21 @@ func (c *RedisConfig) Addr() string {
 	return c.Host + ":" + c.Port
 }
 
+// Options builds the go-redis client options, applying the default timeouts where unset
+func (c *RedisConfig) Options() *redis.Options {
+	return &redis.Options{
+		Addr:         c.Addr(),
+		Password:     c.Password,
+		DB:           c.DB,
+		DialTimeout:  durationOrDefault(c.DialTimeout, DefaultDialTimeout),
+		ReadTimeout:  durationOrDefault(c.ReadTimeout, DefaultReadTimeout),
+		WriteTimeout: durationOrDefault(c.WriteTimeout, DefaultWriteTimeout),
+	}
+}
+
 // NewRedisClient creates a new Redis client
 func NewRedisClient(config *RedisConfig) (*redis.Client, error) {
-	client := redis.NewClient(&redis.Options{
-		Addr:     config.Addr(),
-		Password: config.Password,
-		DB:       config.DB,
-	})
+	client := redis.NewClient(config.Options())
 
 	// Test the connection
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -57,3 +82,21 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// Helper function to get a duration environment variable with a default value
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value, exists := os.LookupEnv(key); exists {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			return d
+		}
+	}
+	return defaultValue
+}
+
+// durationOrDefault returns d, or defaultValue when d is not positive
+func durationOrDefault(d, defaultValue time.Duration) time.Duration {
+	if d <= 0 {
+		return defaultValue
+	}
+	return d
+}
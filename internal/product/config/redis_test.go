@@ -0,0 +1,113 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	pkgconfig "go-bootiful-ordering/internal/pkg/config"
+)
+
+func TestRedisOptionsTimeouts(t *testing.T) {
+	tests := []struct {
+		name                     string
+		config                   RedisConfig
+		wantDial, wantRead, want time.Duration
+	}{
+		{name: "defaults", wantDial: DefaultDialTimeout, wantRead: DefaultReadTimeout, want: DefaultWriteTimeout},
+		{
+			name:     "configured",
+			config:   RedisConfig{DialTimeout: 2 * time.Second, ReadTimeout: 50 * time.Millisecond, WriteTimeout: 75 * time.Millisecond},
+			wantDial: 2 * time.Second, wantRead: 50 * time.Millisecond, want: 75 * time.Millisecond,
+		},
+		{
+			name:     "negative values use the defaults",
+			config:   RedisConfig{DialTimeout: -1, ReadTimeout: -1, WriteTimeout: -1},
+			wantDial: DefaultDialTimeout, wantRead: DefaultReadTimeout, want: DefaultWriteTimeout,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.config.Options()
+			if opts.DialTimeout != tt.wantDial || opts.ReadTimeout != tt.wantRead || opts.WriteTimeout != tt.want {
+				t.Errorf("Options() timeouts = %s, %s, %s, want %s, %s, %s", opts.DialTimeout, opts.ReadTimeout, opts.WriteTimeout, tt.wantDial, tt.wantRead, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedisTimeoutsFromEnvironment(t *testing.T) {
+	t.Setenv("REDIS_DIAL_TIMEOUT", "3s")
+	t.Setenv("REDIS_READ_TIMEOUT", "120ms")
+	t.Setenv("REDIS_WRITE_TIMEOUT", "not a duration")
+
+	cfg := NewDefaultRedisConfig()
+	if cfg.DialTimeout != 3*time.Second || cfg.ReadTimeout != 120*time.Millisecond || cfg.WriteTimeout != DefaultWriteTimeout {
+		t.Errorf("NewDefaultRedisConfig() timeouts = %s, %s, %s, want 3s, 120ms and the default write timeout", cfg.DialTimeout, cfg.ReadTimeout, cfg.WriteTimeout)
+	}
+}
+
+func TestRedisTimeoutsFromConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "product.yaml")
+	yaml := `
+redis:
+  host: cache
+  dialTimeout: 500ms
+  readTimeout: 150ms
+  writeTimeout: 250ms
+`
+	if err := os.WriteFile(path, []byte(yaml), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	cfg, err := pkgconfig.LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	redisConfig := RedisConfig{DialTimeout: cfg.Redis.DialTimeout, ReadTimeout: cfg.Redis.ReadTimeout, WriteTimeout: cfg.Redis.WriteTimeout}
+	opts := redisConfig.Options()
+	if opts.DialTimeout != 500*time.Millisecond || opts.ReadTimeout != 150*time.Millisecond || opts.WriteTimeout != 250*time.Millisecond {
+		t.Errorf("Options() timeouts = %s, %s, %s, want 500ms, 150ms, 250ms", opts.DialTimeout, opts.ReadTimeout, opts.WriteTimeout)
+	}
+}
+
+func TestRedisReadTimeoutBoundsASlowServer(t *testing.T) {
+	// The server accepts connections and never answers
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	cfg := RedisConfig{Host: host, Port: port, ReadTimeout: 50 * time.Millisecond}
+	opts := cfg.Options()
+	opts.MaxRetries = -1
+	client := redis.NewClient(opts)
+	defer client.Close()
+
+	start := time.Now()
+	err = client.Get(context.Background(), "product:1").Err()
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Fatalf("Get() error = %v, want a timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Get() took %s, want about the 50ms read timeout", elapsed)
+	}
+}
@@ -0,0 +1,125 @@
+package domain
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// ProductPatch is a partial update of a product's editable fields. Nil fields are left
+// unchanged, so a patch can set the price alone without resending the rest of the product.
+type ProductPatch struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	Price       *int64  `json:"price"`
+	Stock       *int32  `json:"stock"`
+	Category    *string `json:"category"`
+	WeightGrams *int32  `json:"weight_grams"`
+	LengthMM    *int32  `json:"length_mm"`
+	WidthMM     *int32  `json:"width_mm"`
+	HeightMM    *int32  `json:"height_mm"`
+}
+
+// IsEmpty reports whether the patch sets no field
+func (p *ProductPatch) IsEmpty() bool {
+	return p.Name == nil && p.Description == nil && p.Price == nil && p.Stock == nil && p.Category == nil &&
+		p.WeightGrams == nil && p.LengthMM == nil && p.WidthMM == nil && p.HeightMM == nil
+}
+
+// Sanitize strips control characters and surrounding whitespace from the free-text fields the patch sets
+func (p *ProductPatch) Sanitize() {
+	if p.Name != nil {
+		name := stripControl(*p.Name, false)
+		p.Name = &name
+	}
+	if p.Description != nil {
+		description := stripControl(*p.Description, true)
+		p.Description = &description
+	}
+	if p.Category != nil {
+		category := stripControl(*p.Category, false)
+		p.Category = &category
+	}
+}
+
+// Validate checks the fields the patch sets against the rules Product.Validate applies,
+// reporting every violation found; fields it leaves out are not checked
+func (p *ProductPatch) Validate(limits FieldLimits) error {
+	var problems []string
+
+	if p.Name != nil {
+		if *p.Name == "" {
+			problems = append(problems, "name is required")
+		} else if n := utf8.RuneCountInString(*p.Name); n > limits.MaxNameLength {
+			problems = append(problems, fmt.Sprintf("name must be at most %d characters, got %d", limits.MaxNameLength, n))
+		}
+	}
+
+	if p.Description != nil {
+		if n := utf8.RuneCountInString(*p.Description); n > limits.MaxDescriptionLength {
+			problems = append(problems, fmt.Sprintf("description must be at most %d characters, got %d", limits.MaxDescriptionLength, n))
+		}
+	}
+
+	if p.Price != nil && *p.Price <= 0 {
+		problems = append(problems, "price must be greater than 0")
+	}
+
+	if p.Stock != nil && *p.Stock < 0 {
+		problems = append(problems, "stock cannot be negative")
+	}
+
+	if p.Category != nil {
+		if n := utf8.RuneCountInString(*p.Category); n > limits.MaxCategoryLength {
+			problems = append(problems, fmt.Sprintf("category must be at most %d characters, got %d", limits.MaxCategoryLength, n))
+		}
+	}
+
+	if p.WeightGrams != nil && *p.WeightGrams < 0 {
+		problems = append(problems, "weight_grams cannot be negative")
+	}
+
+	for _, dimension := range []*int32{p.LengthMM, p.WidthMM, p.HeightMM} {
+		if dimension != nil && *dimension < 0 {
+			problems = append(problems, "dimensions cannot be negative")
+			break
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// Apply sets the patched fields on a product. A stock change moves the product in or out
+// of stock, as described by Product.RecalculateStatus.
+func (p *ProductPatch) Apply(product *Product) {
+	if p.Name != nil {
+		product.Name = *p.Name
+	}
+	if p.Description != nil {
+		product.Description = *p.Description
+	}
+	if p.Price != nil {
+		product.Price = *p.Price
+	}
+	if p.Stock != nil {
+		product.Stock = *p.Stock
+		product.RecalculateStatus()
+	}
+	if p.Category != nil {
+		product.Category = *p.Category
+	}
+	if p.WeightGrams != nil {
+		product.WeightGrams = *p.WeightGrams
+	}
+	if p.LengthMM != nil {
+		product.LengthMM = *p.LengthMM
+	}
+	if p.WidthMM != nil {
+		product.WidthMM = *p.WidthMM
+	}
+	if p.HeightMM != nil {
+		product.HeightMM = *p.HeightMM
+	}
+}
@@ -0,0 +1,106 @@
+package domain
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestProductPatchApply(t *testing.T) {
+	price := func(v int64) *int64 { return &v }
+	stock := func(v int32) *int32 { return &v }
+	name := func(v string) *string { return &v }
+	original := Product{
+		ID: "product-1", Name: "Lamp", Description: "A desk lamp", Price: 1000, Stock: 5, Category: "home",
+		Status: ProductStatusActive, PhysicalAttributes: PhysicalAttributes{WeightGrams: 800, LengthMM: 300},
+	}
+
+	tests := []struct {
+		name  string
+		patch ProductPatch
+		want  func(p *Product)
+	}{
+		{name: "price alone", patch: ProductPatch{Price: price(1500)}, want: func(p *Product) { p.Price = 1500 }},
+		{name: "name and price", patch: ProductPatch{Name: name("Floor lamp"), Price: price(2500)}, want: func(p *Product) { p.Name, p.Price = "Floor lamp", 2500 }},
+		{name: "stock sold out", patch: ProductPatch{Stock: stock(0)}, want: func(p *Product) { p.Stock, p.Status = 0, ProductStatusOutOfStock }},
+		{name: "stock changed", patch: ProductPatch{Stock: stock(9)}, want: func(p *Product) { p.Stock = 9 }},
+		{name: "nothing set", patch: ProductPatch{}, want: func(p *Product) {}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, want := original, original
+			tt.patch.Apply(&got)
+			tt.want(&want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Apply() = %+v, want %+v", got, want)
+			}
+		})
+	}
+
+	t.Run("restocked", func(t *testing.T) {
+		product := Product{Stock: 0, Status: ProductStatusOutOfStock}
+		(&ProductPatch{Stock: stock(3)}).Apply(&product)
+		if product.Stock != 3 || product.Status != ProductStatusActive {
+			t.Errorf("Apply() = %+v, want 3 in stock and active", product)
+		}
+	})
+}
+
+func TestProductPatchValidate(t *testing.T) {
+	limits := NewFieldLimits(10, 20, 10)
+	price := func(v int64) *int64 { return &v }
+	stock := func(v int32) *int32 { return &v }
+	text := func(v string) *string { return &v }
+
+	tests := []struct {
+		name  string
+		patch ProductPatch
+		want  []string
+	}{
+		// The name and other required fields of a product are not required of a patch
+		{name: "price alone", patch: ProductPatch{Price: price(1)}},
+		{name: "stock to zero", patch: ProductPatch{Stock: stock(0)}},
+		{name: "empty description", patch: ProductPatch{Description: text("")}},
+		{name: "empty name", patch: ProductPatch{Name: text("")}, want: []string{"name is required"}},
+		{name: "long name", patch: ProductPatch{Name: text("A very long lamp")}, want: []string{"name must be at most 10 characters, got 16"}},
+		{
+			name:  "every violation",
+			patch: ProductPatch{Price: price(0), Stock: stock(-1), HeightMM: stock(-1), WidthMM: stock(-1)},
+			want:  []string{"price must be greater than 0", "stock cannot be negative", "dimensions cannot be negative"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.patch.Validate(limits)
+			if tt.want == nil {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) || !reflect.DeepEqual(validationErr.Problems, tt.want) {
+				t.Errorf("Validate() error = %v, want problems %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestProductPatchIsEmptyAndSanitize(t *testing.T) {
+	if !(&ProductPatch{}).IsEmpty() {
+		t.Error("IsEmpty() of a patch setting nothing = false, want true")
+	}
+	zero := int32(0)
+	if (&ProductPatch{LengthMM: &zero}).IsEmpty() {
+		t.Error("IsEmpty() of a patch setting a zero field = true, want false")
+	}
+
+	name, category := "  Lamp\x00 ", "home\t"
+	patch := ProductPatch{Name: &name, Category: &category}
+	patch.Sanitize()
+	if *patch.Name != "Lamp" || *patch.Category != "home" || patch.Description != nil {
+		t.Errorf("Sanitize() = name %q, category %q, description %v, want the set fields trimmed and the rest unset", *patch.Name, *patch.Category, patch.Description)
+	}
+}
@@ -1,7 +1,12 @@
 package domain
 
 import (
+	"errors"
+	"fmt"
+	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 // ProductStatus represents the possible states of a product
@@ -14,6 +19,27 @@ const (
 	ProductStatusOutOfStock
 )
 
+const (
+	// DefaultMaxNameLength matches the size of the products.name column
+	DefaultMaxNameLength = 255
+	// DefaultMaxDescriptionLength bounds descriptions so they stay cheap to cache and list
+	DefaultMaxDescriptionLength = 4096
+	// DefaultMaxCategoryLength matches the size of the products.category column
+	DefaultMaxCategoryLength = 100
+)
+
+var (
+	// ErrInvalidArgument is returned when product input fails validation
+	ErrInvalidArgument = errors.New("invalid argument")
+	// ErrProductNotFound is returned when no product has the requested ID
+	ErrProductNotFound = errors.New("product not found")
+	// ErrInsufficientStock is returned when a reservation exceeds a product's stock
+	ErrInsufficientStock = errors.New("insufficient stock")
+	// ErrVersionConflict is returned when an update was based on a version of the product
+	// that has since been changed
+	ErrVersionConflict = errors.New("product version conflict")
+)
+
 // Product represents a product in the system
 type Product struct {
 	ID          string        `json:"id"`
@@ -23,6 +49,342 @@ type Product struct {
 	Stock       int32         `json:"stock"`
 	Category    string        `json:"category"`
 	Status      ProductStatus `json:"status"`
-	CreatedAt   time.Time     `json:"created_at"`
-	UpdatedAt   time.Time     `json:"updated_at"`
+	PhysicalAttributes
+	// AverageRating and ReviewCount come from the reviews system and cannot be set through the product API
+	AverageRating float32   `json:"average_rating"`
+	ReviewCount   int32     `json:"review_count"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	// DeletedAt is set on deleted products, which are only returned when explicitly requested
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// Version starts at 1 and is incremented by every write to the product's fields; an
+	// update sent with the version it read fails instead of overwriting a newer write
+	Version int `json:"version"`
+}
+
+// PhysicalAttributes holds the weight and dimensions of a product, used to estimate shipping.
+// A zero value means the attribute is unknown.
+type PhysicalAttributes struct {
+	WeightGrams int32 `json:"weight_grams"`
+	LengthMM    int32 `json:"length_mm"`
+	WidthMM     int32 `json:"width_mm"`
+	HeightMM    int32 `json:"height_mm"`
+}
+
+// StockChange is a quantity of one product to take from or return to its stock
+type StockChange struct {
+	ProductID string
+	Quantity  int32
+}
+
+// Availability reports whether a product can supply a requested quantity
+type Availability struct {
+	ProductID string `json:"product_id"`
+	Available bool   `json:"available"`
+	Stock     int32  `json:"stock"`
+	// NotFound is set when no product has the ID; Available is then false
+	NotFound bool `json:"not_found,omitempty"`
+}
+
+// MaxRating is the highest average rating the reviews system gives
+const MaxRating = 5
+
+// RatingAggregate is a product's review summary as computed by the reviews system at AsOf
+type RatingAggregate struct {
+	AverageRating float32
+	ReviewCount   int32
+	AsOf          time.Time
+}
+
+// Validate checks that the aggregate is a plausible review summary
+func (a RatingAggregate) Validate() error {
+	var problems []string
+
+	if a.AverageRating < 0 || a.AverageRating > MaxRating {
+		problems = append(problems, fmt.Sprintf("average_rating must be between 0 and %d", MaxRating))
+	}
+	if a.ReviewCount < 0 {
+		problems = append(problems, "review_count cannot be negative")
+	}
+	if a.ReviewCount == 0 && a.AverageRating != 0 {
+		problems = append(problems, "average_rating must be 0 without reviews")
+	}
+	if a.AsOf.IsZero() {
+		problems = append(problems, "as_of is required")
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidArgument, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// ProductSort is the order in which products are listed
+type ProductSort string
+
+const (
+	// ProductSortID lists products by ID, the order used when no sort is configured
+	ProductSortID ProductSort = ""
+	// ProductSortCreatedAtDesc lists the newest products first
+	ProductSortCreatedAtDesc ProductSort = "created_at_desc"
+	// ProductSortNameAsc lists products alphabetically by name
+	ProductSortNameAsc ProductSort = "name_asc"
+	// ProductSortPriceAsc lists the cheapest products first
+	ProductSortPriceAsc ProductSort = "price_asc"
+)
+
+// IsValid reports whether the sort is a known product ordering
+func (s ProductSort) IsValid() bool {
+	switch s {
+	case ProductSortID, ProductSortCreatedAtDesc, ProductSortNameAsc, ProductSortPriceAsc:
+		return true
+	default:
+		return false
+	}
+}
+
+// ProductSortField is a column a product listing can be ordered by on request
+type ProductSortField string
+
+const (
+	// ProductSortFieldDefault keeps the configured default ordering
+	ProductSortFieldDefault ProductSortField = ""
+	// ProductSortFieldPrice orders products by price
+	ProductSortFieldPrice ProductSortField = "price"
+	// ProductSortFieldCreatedAt orders products by creation time
+	ProductSortFieldCreatedAt ProductSortField = "created_at"
+	// ProductSortFieldName orders products by name
+	ProductSortFieldName ProductSortField = "name"
+)
+
+// SortDirection is the direction of a listing order
+type SortDirection string
+
+const (
+	// SortDirectionDefault uses the natural direction of the sort field: newest first for
+	// created_at, ascending otherwise
+	SortDirectionDefault SortDirection = ""
+	// SortDirectionAsc orders from the smallest value up
+	SortDirectionAsc SortDirection = "asc"
+	// SortDirectionDesc orders from the largest value down
+	SortDirectionDesc SortDirection = "desc"
+)
+
+// ProductListOptions holds the optional ordering and price range of a product listing
+type ProductListOptions struct {
+	SortBy  ProductSortField
+	SortDir SortDirection
+	// MinPrice and MaxPrice bound the price inclusively; nil leaves that side open
+	MinPrice *int64
+	MaxPrice *int64
+}
+
+// Validate checks that the sort is known and the price range is well-formed
+func (o ProductListOptions) Validate() error {
+	var problems []string
+
+	switch o.SortBy {
+	case ProductSortFieldDefault, ProductSortFieldPrice, ProductSortFieldCreatedAt, ProductSortFieldName:
+	default:
+		problems = append(problems, fmt.Sprintf("unknown sort_by %q, expected price, created_at or name", o.SortBy))
+	}
+
+	switch o.SortDir {
+	case SortDirectionDefault, SortDirectionAsc, SortDirectionDesc:
+	default:
+		problems = append(problems, fmt.Sprintf("unknown sort_dir %q, expected asc or desc", o.SortDir))
+	}
+
+	if o.MinPrice != nil && *o.MinPrice < 0 {
+		problems = append(problems, "min_price must not be negative")
+	}
+	if o.MaxPrice != nil && *o.MaxPrice < 0 {
+		problems = append(problems, "max_price must not be negative")
+	}
+	if o.MinPrice != nil && o.MaxPrice != nil && *o.MinPrice > *o.MaxPrice {
+		problems = append(problems, "min_price must not exceed max_price")
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidArgument, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// FieldLimits holds the maximum lengths (in characters) of a product's free-text fields
+type FieldLimits struct {
+	MaxNameLength        int
+	MaxDescriptionLength int
+	MaxCategoryLength    int
+}
+
+// NewFieldLimits creates a FieldLimits, using the defaults for any value that is not positive
+func NewFieldLimits(maxName, maxDescription, maxCategory int) FieldLimits {
+	limits := FieldLimits{
+		MaxNameLength:        DefaultMaxNameLength,
+		MaxDescriptionLength: DefaultMaxDescriptionLength,
+		MaxCategoryLength:    DefaultMaxCategoryLength,
+	}
+
+	if maxName > 0 {
+		limits.MaxNameLength = maxName
+	}
+	if maxDescription > 0 {
+		limits.MaxDescriptionLength = maxDescription
+	}
+	if maxCategory > 0 {
+		limits.MaxCategoryLength = maxCategory
+	}
+
+	return limits
+}
+
+// String returns the lower-case name of the status
+func (s ProductStatus) String() string {
+	switch s {
+	case ProductStatusActive:
+		return "active"
+	case ProductStatusInactive:
+		return "inactive"
+	case ProductStatusOutOfStock:
+		return "out_of_stock"
+	default:
+		return "unspecified"
+	}
+}
+
+// IsValid reports whether the status is one of the known product states
+func (s ProductStatus) IsValid() bool {
+	return s >= ProductStatusActive && s <= ProductStatusOutOfStock
+}
+
+// ForStock returns the status a product moves to when its stock is set: an active product
+// without stock goes out of stock and an out of stock product with stock is active again.
+// Inactive products keep their status.
+func (s ProductStatus) ForStock(stock int32) ProductStatus {
+	switch {
+	case s == ProductStatusActive && stock == 0:
+		return ProductStatusOutOfStock
+	case s == ProductStatusOutOfStock && stock > 0:
+		return ProductStatusActive
+	default:
+		return s
+	}
+}
+
+// RecalculateStatus moves the product in or out of stock to match its stock, as described
+// by ProductStatus.ForStock. Repositories call it whenever they save a product's stock.
+func (p *Product) RecalculateStatus() {
+	p.Status = p.Status.ForStock(p.Stock)
+}
+
+// ValidationError aggregates the rule violations found while validating a product
+type ValidationError struct {
+	Problems []string
+}
+
+// Error implements the error interface
+func (e *ValidationError) Error() string {
+	return "invalid product: " + strings.Join(e.Problems, "; ")
+}
+
+// Unwrap makes errors.Is(err, ErrInvalidArgument) match validation errors
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidArgument
+}
+
+// BatchItemError reports the item that made a whole batch fail
+type BatchItemError struct {
+	// Index is the position of the item in the batch, starting at 0
+	Index int
+	Err   error
+}
+
+// Error implements the error interface
+func (e *BatchItemError) Error() string {
+	return fmt.Sprintf("item %d: %v", e.Index, e.Err)
+}
+
+// Unwrap returns the item's own error, so errors.Is(err, ErrInvalidArgument) still matches
+func (e *BatchItemError) Unwrap() error {
+	return e.Err
+}
+
+// SameDetails reports whether two products have the same editable fields
+func (p *Product) SameDetails(other *Product) bool {
+	return p.Name == other.Name &&
+		p.Description == other.Description &&
+		p.Price == other.Price &&
+		p.Stock == other.Stock &&
+		p.Category == other.Category &&
+		p.PhysicalAttributes == other.PhysicalAttributes
+}
+
+// Sanitize strips control characters and surrounding whitespace from the product's free-text fields
+func (p *Product) Sanitize() {
+	p.Name = stripControl(p.Name, false)
+	p.Description = stripControl(p.Description, true)
+	p.Category = stripControl(p.Category, false)
+}
+
+// Validate checks the product against the business rules and field limits,
+// reporting every violation found. An unspecified status is allowed and
+// defaults to active on creation.
+func (p *Product) Validate(limits FieldLimits) error {
+	var problems []string
+
+	if p.Name == "" {
+		problems = append(problems, "name is required")
+	} else if n := utf8.RuneCountInString(p.Name); n > limits.MaxNameLength {
+		problems = append(problems, fmt.Sprintf("name must be at most %d characters, got %d", limits.MaxNameLength, n))
+	}
+
+	if n := utf8.RuneCountInString(p.Description); n > limits.MaxDescriptionLength {
+		problems = append(problems, fmt.Sprintf("description must be at most %d characters, got %d", limits.MaxDescriptionLength, n))
+	}
+
+	if p.Price <= 0 {
+		problems = append(problems, "price must be greater than 0")
+	}
+
+	if p.Stock < 0 {
+		problems = append(problems, "stock cannot be negative")
+	}
+
+	if n := utf8.RuneCountInString(p.Category); n > limits.MaxCategoryLength {
+		problems = append(problems, fmt.Sprintf("category must be at most %d characters, got %d", limits.MaxCategoryLength, n))
+	}
+
+	if p.WeightGrams < 0 {
+		problems = append(problems, "weight_grams cannot be negative")
+	}
+
+	if p.LengthMM < 0 || p.WidthMM < 0 || p.HeightMM < 0 {
+		problems = append(problems, "dimensions cannot be negative")
+	}
+
+	if p.Status != ProductStatusUnspecified && !p.Status.IsValid() {
+		problems = append(problems, fmt.Sprintf("unknown status %d", p.Status))
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// stripControl removes control characters and surrounding whitespace from s.
+// Newlines and tabs are kept when multiline is set.
+func stripControl(s string, multiline bool) string {
+	s = strings.Map(func(r rune) rune {
+		if multiline && (r == '\n' || r == '\t') {
+			return r
+		}
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, s)
+
+	return strings.TrimSpace(s)
 }
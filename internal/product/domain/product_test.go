@@ -0,0 +1,113 @@
+package domain
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestProductListOptionsValidate(t *testing.T) {
+	price := func(v int64) *int64 { return &v }
+
+	tests := []struct {
+		name    string
+		opts    ProductListOptions
+		wantErr string
+	}{
+		{name: "defaults", opts: ProductListOptions{}},
+		{name: "each sort field", opts: ProductListOptions{SortBy: ProductSortFieldName, SortDir: SortDirectionDesc}},
+		{name: "price range", opts: ProductListOptions{SortBy: ProductSortFieldPrice, MinPrice: price(0), MaxPrice: price(100)}},
+		{name: "single price", opts: ProductListOptions{MinPrice: price(100), MaxPrice: price(100)}},
+		{name: "unknown sort key", opts: ProductListOptions{SortBy: "popularity"}, wantErr: `unknown sort_by "popularity"`},
+		{name: "sort key in another case", opts: ProductListOptions{SortBy: "Price"}, wantErr: `unknown sort_by "Price"`},
+		{name: "unknown direction", opts: ProductListOptions{SortDir: "up"}, wantErr: `unknown sort_dir "up"`},
+		{name: "negative min price", opts: ProductListOptions{MinPrice: price(-1)}, wantErr: "min_price must not be negative"},
+		{name: "inverted range", opts: ProductListOptions{MinPrice: price(200), MaxPrice: price(100)}, wantErr: "min_price must not exceed max_price"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidArgument) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want ErrInvalidArgument with %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProductSortIsValid(t *testing.T) {
+	for _, sort := range []ProductSort{ProductSortID, ProductSortCreatedAtDesc, ProductSortNameAsc, ProductSortPriceAsc} {
+		if !sort.IsValid() {
+			t.Errorf("ProductSort(%q).IsValid() = false", sort)
+		}
+	}
+	for _, sort := range []ProductSort{"price_desc", "name", "newest"} {
+		if sort.IsValid() {
+			t.Errorf("ProductSort(%q).IsValid() = true, want unknown default sorts rejected", sort)
+		}
+	}
+}
+
+func TestProductValidate(t *testing.T) {
+	limits := NewFieldLimits(5, 8, 4)
+	valid := func() Product {
+		return Product{Name: "Mug", Description: "Blue mug", Price: 1, Stock: 0, Category: "home", Status: ProductStatusActive}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(p *Product)
+		want   []string
+	}{
+		{name: "valid", modify: func(p *Product) {}},
+		{name: "unspecified status", modify: func(p *Product) { p.Status = ProductStatusUnspecified }},
+		{name: "fields at their limits", modify: func(p *Product) { p.Name, p.Description, p.Category = "Cafés", "12345678", "café" }},
+		{name: "known physical attributes", modify: func(p *Product) {
+			p.PhysicalAttributes = PhysicalAttributes{WeightGrams: 250, LengthMM: 120, WidthMM: 80, HeightMM: 30}
+		}},
+		{name: "missing name", modify: func(p *Product) { p.Name = "" }, want: []string{"name is required"}},
+		{name: "name too long", modify: func(p *Product) { p.Name = "Teapot" }, want: []string{"name must be at most 5 characters, got 6"}},
+		{name: "description too long", modify: func(p *Product) { p.Description = "Large mug" }, want: []string{"description must be at most 8 characters, got 9"}},
+		{name: "zero price", modify: func(p *Product) { p.Price = 0 }, want: []string{"price must be greater than 0"}},
+		{name: "negative price", modify: func(p *Product) { p.Price = -100 }, want: []string{"price must be greater than 0"}},
+		{name: "negative stock", modify: func(p *Product) { p.Stock = -1 }, want: []string{"stock cannot be negative"}},
+		{name: "category too long", modify: func(p *Product) { p.Category = "kitchen" }, want: []string{"category must be at most 4 characters, got 7"}},
+		{name: "negative weight", modify: func(p *Product) { p.WeightGrams = -1 }, want: []string{"weight_grams cannot be negative"}},
+		{name: "negative dimension", modify: func(p *Product) { p.HeightMM = -1 }, want: []string{"dimensions cannot be negative"}},
+		{name: "unknown status", modify: func(p *Product) { p.Status = ProductStatus(42) }, want: []string{"unknown status 42"}},
+		{
+			name:   "every violation",
+			modify: func(p *Product) { p.Name, p.Price, p.Stock, p.Category = "", 0, -5, "kitchen" },
+			want:   []string{"name is required", "price must be greater than 0", "stock cannot be negative", "category must be at most 4 characters, got 7"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			product := valid()
+			tt.modify(&product)
+			err := product.Validate(limits)
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+
+			var validation *ValidationError
+			if !errors.As(err, &validation) || !errors.Is(err, ErrInvalidArgument) {
+				t.Fatalf("Validate() error = %v, want a ValidationError matching ErrInvalidArgument", err)
+			}
+			if !reflect.DeepEqual(validation.Problems, tt.want) {
+				t.Errorf("Validate() problems = %q, want %q", validation.Problems, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package handler
+
+import (
+	"encoding/csv"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"go-bootiful-ordering/internal/pkg/auth"
+	"go-bootiful-ordering/internal/pkg/requestid"
+	"go-bootiful-ordering/internal/product/domain"
+	"go-bootiful-ordering/internal/product/service"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// inventoryPageSize is the number of products fetched per page while exporting the inventory
+const inventoryPageSize = 500
+
+// InventoryExportHandler handles admin requests for a full product stock snapshot
+type InventoryExportHandler struct {
+	log     *zap.Logger
+	service service.ProductService
+	guard   *auth.AdminGuard
+}
+
+// NewInventoryExportHandler creates a new InventoryExportHandler
+func NewInventoryExportHandler(log *zap.Logger, service service.ProductService, guard *auth.AdminGuard) *InventoryExportHandler {
+	return &InventoryExportHandler{
+		log:     log,
+		service: service,
+		guard:   guard,
+	}
+}
+
+// Pattern returns the URL pattern for this handler
+func (h *InventoryExportHandler) Pattern() string {
+	return "/admin/products/inventory.csv"
+}
+
+// Register registers the handler with the router group
+func (h *InventoryExportHandler) Register(rg *gin.RouterGroup) {
+	rg.GET("/admin/products/inventory.csv", h.guard.GinMiddleware(), h.ExportInventory)
+}
+
+// ExportInventory streams the stock of every product, optionally filtered by category, as CSV.
+// Products are read page by page so the full catalog is never held in memory.
+func (h *InventoryExportHandler) ExportInventory(c *gin.Context) {
+	ctx := c.Request.Context()
+	log := requestid.Logger(ctx, h.log)
+	category := c.Query("category")
+
+	// Fetch the first page before writing anything so a failure can still report an error status
+	products, pageToken, err := h.service.ListProducts(ctx, category, domain.ProductListOptions{}, inventoryPageSize, "")
+	if err != nil {
+		log.Error("Failed to export inventory", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export inventory"})
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="inventory.csv"`)
+	c.Header("X-Generated-At", time.Now().UTC().Format(time.RFC3339))
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	// Products have no SKU yet, so the sku column is left empty
+	if err := w.Write([]string{"id", "sku", "name", "stock", "status"}); err != nil {
+		log.Error("Failed to write inventory header", zap.Error(err))
+		return
+	}
+
+	for {
+		for _, product := range products {
+			record := []string{product.ID, "", product.Name, strconv.Itoa(int(product.Stock)), product.Status.String()}
+			if err := w.Write(record); err != nil {
+				log.Error("Failed to write inventory row", zap.Error(err), zap.String("productID", product.ID))
+				return
+			}
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			log.Error("Failed to flush inventory rows", zap.Error(err))
+			return
+		}
+		c.Writer.Flush()
+
+		if pageToken == "" {
+			return
+		}
+
+		// The status line is already sent, so a failed page can only truncate the export
+		next, nextPageToken, err := h.service.ListProducts(ctx, category, domain.ProductListOptions{}, inventoryPageSize, pageToken)
+		if err != nil {
+			log.Error("Inventory export truncated", zap.Error(err), zap.String("pageToken", pageToken))
+			return
+		}
+		products, pageToken = next, nextPageToken
+	}
+}
+
+// BulkDeleteProductsHandler handles admin requests to delete many products at once
+type BulkDeleteProductsHandler struct {
+	log     *zap.Logger
+	service service.ProductService
+	guard   *auth.AdminGuard
+}
+
+// NewBulkDeleteProductsHandler creates a new BulkDeleteProductsHandler
+func NewBulkDeleteProductsHandler(log *zap.Logger, service service.ProductService, guard *auth.AdminGuard) *BulkDeleteProductsHandler {
+	return &BulkDeleteProductsHandler{
+		log:     log,
+		service: service,
+		guard:   guard,
+	}
+}
+
+// Pattern returns the URL pattern for this handler
+func (h *BulkDeleteProductsHandler) Pattern() string {
+	return "/products"
+}
+
+// Register registers the handler with the router group
+func (h *BulkDeleteProductsHandler) Register(rg *gin.RouterGroup) {
+	rg.DELETE("/products", h.guard.GinMiddleware(), h.DeleteProducts)
+}
+
+// DeleteProducts handles HTTP requests to delete the products listed in the
+// JSON body ({"ids": [...]}) or in repeated id query parameters
+func (h *BulkDeleteProductsHandler) DeleteProducts(c *gin.Context) {
+	log := requestid.Logger(c.Request.Context(), h.log)
+
+	productIDs := c.QueryArray("id")
+	if len(productIDs) == 0 {
+		var req struct {
+			IDs []string `json:"ids"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+		productIDs = req.IDs
+	}
+
+	deleted, failed, err := h.service.DeleteProducts(c.Request.Context(), productIDs)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidArgument) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		log.Error("Failed to delete products", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete products"})
+		return
+	}
+
+	failures := make(map[string]string, len(failed))
+	for id, err := range failed {
+		if !errors.Is(err, domain.ErrProductNotFound) {
+			log.Error("Failed to delete product", zap.Error(err), zap.String("productID", id))
+		}
+		failures[id] = err.Error()
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deleted": deleted, "failed": failures})
+}
+
+// CategoryStatusHandler handles admin requests to change the status of a whole category
+type CategoryStatusHandler struct {
+	log     *zap.Logger
+	service service.ProductService
+	guard   *auth.AdminGuard
+}
+
+// NewCategoryStatusHandler creates a new CategoryStatusHandler
+func NewCategoryStatusHandler(log *zap.Logger, service service.ProductService, guard *auth.AdminGuard) *CategoryStatusHandler {
+	return &CategoryStatusHandler{
+		log:     log,
+		service: service,
+		guard:   guard,
+	}
+}
+
+// Pattern returns the URL pattern for this handler
+func (h *CategoryStatusHandler) Pattern() string {
+	return "/admin/products/category/:category/status"
+}
+
+// Register registers the handler with the router group
+func (h *CategoryStatusHandler) Register(rg *gin.RouterGroup) {
+	rg.POST("/admin/products/category/:category/status", h.guard.GinMiddleware(), h.SetCategoryStatus)
+}
+
+// SetCategoryStatus handles HTTP requests to set the status ({"status": 1} or {"status": 2})
+// of every product in a category, e.g. to take a seasonal catalog offline
+func (h *CategoryStatusHandler) SetCategoryStatus(c *gin.Context) {
+	log := requestid.Logger(c.Request.Context(), h.log)
+	category := c.Param("category")
+
+	var req struct {
+		Status domain.ProductStatus `json:"status"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	affected, err := h.service.SetStatusByCategory(c.Request.Context(), category, req.Status)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidArgument) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		log.Error("Failed to set category status", zap.Error(err), zap.String("category", category))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set category status"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"category": category, "status": req.Status, "affected": affected})
+}
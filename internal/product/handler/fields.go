@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"fmt"
+	"go-bootiful-ordering/internal/pkg/timefmt"
+	"go-bootiful-ordering/internal/product/domain"
+	"strings"
+)
+
+// productFields maps each JSON field name of a product to its value accessor
+var productFields = map[string]func(p *domain.Product) interface{}{
+	"id":             func(p *domain.Product) interface{} { return p.ID },
+	"name":           func(p *domain.Product) interface{} { return p.Name },
+	"description":    func(p *domain.Product) interface{} { return p.Description },
+	"price":          func(p *domain.Product) interface{} { return p.Price },
+	"stock":          func(p *domain.Product) interface{} { return p.Stock },
+	"category":       func(p *domain.Product) interface{} { return p.Category },
+	"status":         func(p *domain.Product) interface{} { return p.Status },
+	"weight_grams":   func(p *domain.Product) interface{} { return p.WeightGrams },
+	"length_mm":      func(p *domain.Product) interface{} { return p.LengthMM },
+	"width_mm":       func(p *domain.Product) interface{} { return p.WidthMM },
+	"height_mm":      func(p *domain.Product) interface{} { return p.HeightMM },
+	"average_rating": func(p *domain.Product) interface{} { return p.AverageRating },
+	"review_count":   func(p *domain.Product) interface{} { return p.ReviewCount },
+	"created_at":     func(p *domain.Product) interface{} { return timefmt.Format(p.CreatedAt) },
+	"updated_at":     func(p *domain.Product) interface{} { return timefmt.Format(p.UpdatedAt) },
+	"version":        func(p *domain.Product) interface{} { return p.Version },
+}
+
+// parseFields parses a comma-separated fields query parameter.
+// It returns nil when the parameter is empty, meaning the full product should be returned.
+func parseFields(raw string) ([]string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+
+	var fields []string
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		if _, ok := productFields[name]; !ok {
+			return nil, fmt.Errorf("unknown field %q", name)
+		}
+		seen[name] = true
+		fields = append(fields, name)
+	}
+	return fields, nil
+}
+
+// projectProduct returns the full product response when no fields are requested,
+// otherwise a map holding only the requested fields
+func projectProduct(p *domain.Product, fields []string) interface{} {
+	if fields == nil {
+		return newProductResponse(p)
+	}
+
+	projected := make(map[string]interface{}, len(fields))
+	for _, name := range fields {
+		projected[name] = productFields[name](p)
+	}
+	return projected
+}
@@ -2,13 +2,16 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"go-bootiful-ordering/gen/product/v1"
+	"go-bootiful-ordering/internal/pkg/paging"
+	"go-bootiful-ordering/internal/pkg/requestid"
 	"go-bootiful-ordering/internal/product/domain"
+	"go-bootiful-ordering/internal/product/protoconv"
 	"go-bootiful-ordering/internal/product/service"
 	"go.uber.org/zap"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
-	"time"
 )
 
 // GRPCProductServer implements the ProductService gRPC server
@@ -16,50 +19,113 @@ type GRPCProductServer struct {
 	productv1.UnimplementedProductServiceServer
 	log     *zap.SugaredLogger
 	service service.ProductService
+	limits  domain.FieldLimits
+	paging  paging.Limits
 }
 
 // NewGRPCProductServer creates a new GRPCProductServer
-func NewGRPCProductServer(log *zap.SugaredLogger, service service.ProductService) *GRPCProductServer {
+func NewGRPCProductServer(log *zap.SugaredLogger, service service.ProductService, limits domain.FieldLimits, paging paging.Limits) *GRPCProductServer {
 	return &GRPCProductServer{
 		log:     log,
 		service: service,
+		limits:  limits,
+		paging:  paging,
 	}
 }
 
 // CreateProduct implements the CreateProduct RPC method
 func (s *GRPCProductServer) CreateProduct(ctx context.Context, req *productv1.CreateProductRequest) (*productv1.CreateProductResponse, error) {
-	s.log.Infof("GRPCProductServer_CreateProduct name=%s category=%s",
+	log := requestid.SugaredLogger(ctx, s.log)
+
+	log.Infof("GRPCProductServer_CreateProduct name=%s category=%s",
 		req.Name, req.Category)
 
 	// Validate request
-	if req.Name == "" {
-		return nil, status.Error(codes.InvalidArgument, "name is required")
-	}
-
-	if req.Price <= 0 {
-		return nil, status.Error(codes.InvalidArgument, "price must be greater than 0")
-	}
-
-	if req.Stock < 0 {
-		return nil, status.Error(codes.InvalidArgument, "stock cannot be negative")
+	physical := domain.PhysicalAttributes{WeightGrams: req.WeightGrams, LengthMM: req.LengthMm, WidthMM: req.WidthMm, HeightMM: req.HeightMm}
+	candidate := &domain.Product{Name: req.Name, Description: req.Description, Price: req.Price, Stock: req.Stock, Category: req.Category, PhysicalAttributes: physical}
+	candidate.Sanitize()
+	if err := candidate.Validate(s.limits); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
 
 	// Create product using the service
-	product, err := s.service.CreateProduct(ctx, req.Name, req.Description, req.Price, req.Stock, req.Category)
+	product, err := s.service.CreateProduct(ctx, req.Name, req.Description, req.Price, req.Stock, req.Category, physical)
 	if err != nil {
-		s.log.Errorf("Failed to create product: %v", err)
+		log.Errorf("Failed to create product: %v", err)
+		if errors.Is(err, domain.ErrInvalidArgument) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
 		return nil, status.Error(codes.Internal, "failed to create product")
 	}
 
 	// Convert domain product to protobuf product
 	return &productv1.CreateProductResponse{
-		Product: domainToProtoProduct(product),
+		Product: protoconv.ProductToProto(product),
+	}, nil
+}
+
+// BatchGetProducts implements the BatchGetProducts RPC method
+func (s *GRPCProductServer) BatchGetProducts(ctx context.Context, req *productv1.BatchGetProductsRequest) (*productv1.BatchGetProductsResponse, error) {
+	log := requestid.SugaredLogger(ctx, s.log)
+
+	log.Infof("GRPCProductServer_BatchGetProducts count=%d", len(req.ProductIds))
+
+	// Get the products using the service
+	products, err := s.service.GetProductsByIDs(ctx, req.ProductIds)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidArgument) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		log.Errorf("Failed to get products: %v", err)
+		return nil, status.Error(codes.Internal, "failed to get products")
+	}
+
+	// Answer in request order, each product once; unknown IDs are left out
+	protoProducts := make([]*productv1.Product, 0, len(products))
+	for _, productID := range req.ProductIds {
+		if product, ok := products[productID]; ok {
+			protoProducts = append(protoProducts, protoconv.ProductToProto(product))
+			delete(products, productID)
+		}
+	}
+
+	return &productv1.BatchGetProductsResponse{
+		Products: protoProducts,
+	}, nil
+}
+
+// BatchCreateProducts implements the BatchCreateProducts RPC method
+func (s *GRPCProductServer) BatchCreateProducts(ctx context.Context, req *productv1.BatchCreateProductsRequest) (*productv1.BatchCreateProductsResponse, error) {
+	log := requestid.SugaredLogger(ctx, s.log)
+
+	log.Infof("GRPCProductServer_BatchCreateProducts count=%d", len(req.Products))
+
+	// Create the products using the service, which validates every item first
+	products, err := s.service.CreateProducts(ctx, protoconv.ProductsFromCreateRequests(req.Products))
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidArgument) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		log.Errorf("Failed to create products: %v", err)
+		return nil, status.Error(codes.Internal, "failed to create products")
+	}
+
+	// Convert domain products to protobuf products
+	protoProducts := make([]*productv1.Product, len(products))
+	for i, product := range products {
+		protoProducts[i] = protoconv.ProductToProto(product)
+	}
+
+	return &productv1.BatchCreateProductsResponse{
+		Products: protoProducts,
 	}, nil
 }
 
 // GetProduct implements the GetProduct RPC method
 func (s *GRPCProductServer) GetProduct(ctx context.Context, req *productv1.GetProductRequest) (*productv1.GetProductResponse, error) {
-	s.log.Infof("GRPCProductServer_GetProduct productID=%s", req.ProductId)
+	log := requestid.SugaredLogger(ctx, s.log)
+
+	log.Infof("GRPCProductServer_GetProduct productID=%s", req.ProductId)
 
 	if req.ProductId == "" {
 		return nil, status.Error(codes.InvalidArgument, "product_id is required")
@@ -68,77 +134,155 @@ func (s *GRPCProductServer) GetProduct(ctx context.Context, req *productv1.GetPr
 	// Get product using the service
 	product, err := s.service.GetProduct(ctx, req.ProductId)
 	if err != nil {
-		s.log.Errorf("Failed to get product: %v, productID=%s", err, req.ProductId)
-		return nil, status.Error(codes.NotFound, "product not found")
+		log.Errorf("Failed to get product: %v, productID=%s", err, req.ProductId)
+		if errors.Is(err, domain.ErrProductNotFound) {
+			return nil, status.Error(codes.NotFound, "product not found")
+		}
+		return nil, status.Error(codes.Internal, "failed to get product")
 	}
 
 	// Convert domain product to protobuf product
 	return &productv1.GetProductResponse{
-		Product: domainToProtoProduct(product),
+		Product: protoconv.ProductToProto(product),
 	}, nil
 }
 
 // ListProducts implements the ListProducts RPC method
 func (s *GRPCProductServer) ListProducts(ctx context.Context, req *productv1.ListProductsRequest) (*productv1.ListProductsResponse, error) {
-	s.log.Infof("GRPCProductServer_ListProducts category=%s pageSize=%d pageToken=%s",
+	log := requestid.SugaredLogger(ctx, s.log)
+
+	log.Infof("GRPCProductServer_ListProducts category=%s pageSize=%d pageToken=%s",
 		req.Category, req.PageSize, req.PageToken)
 
+	// Apply the default and maximum page size
+	pageSize, err := s.paging.PageSize(req.PageSize)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	// Sorting and price filter
+	opts := domain.ProductListOptions{
+		SortBy:   domain.ProductSortField(req.SortBy),
+		SortDir:  domain.SortDirection(req.SortDir),
+		MinPrice: req.MinPrice,
+		MaxPrice: req.MaxPrice,
+	}
+
 	// List products using the service
-	products, nextPageToken, err := s.service.ListProducts(ctx, req.Category, req.PageSize, req.PageToken)
+	products, nextPageToken, err := s.service.ListProducts(ctx, req.Category, opts, pageSize, req.PageToken)
 	if err != nil {
-		s.log.Errorf("Failed to list products: %v", err)
+		if errors.Is(err, domain.ErrInvalidArgument) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		log.Errorf("Failed to list products: %v", err)
 		return nil, status.Error(codes.Internal, "failed to list products")
 	}
 
+	// Count the matching products only when asked, as it costs a second query
+	var totalSize *int64
+	if req.IncludeTotal {
+		total, err := s.service.CountProducts(ctx, req.Category, opts)
+		if err != nil {
+			log.Errorf("Failed to count products: %v", err)
+			return nil, status.Error(codes.Internal, "failed to list products")
+		}
+		totalSize = &total
+	}
+
 	// Convert domain products to protobuf products
 	protoProducts := make([]*productv1.Product, len(products))
 	for i, product := range products {
-		protoProducts[i] = domainToProtoProduct(product)
+		protoProducts[i] = protoconv.ProductToProto(product)
 	}
 
 	return &productv1.ListProductsResponse{
 		Products:      protoProducts,
 		NextPageToken: nextPageToken,
+		TotalSize:     totalSize,
+	}, nil
+}
+
+// SearchProducts implements the SearchProducts RPC method
+func (s *GRPCProductServer) SearchProducts(ctx context.Context, req *productv1.SearchProductsRequest) (*productv1.SearchProductsResponse, error) {
+	log := requestid.SugaredLogger(ctx, s.log)
+
+	log.Infof("GRPCProductServer_SearchProducts query=%q pageSize=%d pageToken=%s",
+		req.Query, req.PageSize, req.PageToken)
+
+	// Apply the default and maximum page size
+	pageSize, err := s.paging.PageSize(req.PageSize)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	// Search products using the service
+	products, nextPageToken, err := s.service.SearchProducts(ctx, req.Query, pageSize, req.PageToken)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidArgument) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		log.Errorf("Failed to search products: %v", err)
+		return nil, status.Error(codes.Internal, "failed to search products")
+	}
+
+	// Convert domain products to protobuf products
+	protoProducts := make([]*productv1.Product, len(products))
+	for i, product := range products {
+		protoProducts[i] = protoconv.ProductToProto(product)
+	}
+
+	return &productv1.SearchProductsResponse{
+		Products:      protoProducts,
+		NextPageToken: nextPageToken,
 	}, nil
 }
 
 // UpdateProduct implements the UpdateProduct RPC method
 func (s *GRPCProductServer) UpdateProduct(ctx context.Context, req *productv1.UpdateProductRequest) (*productv1.UpdateProductResponse, error) {
-	s.log.Infof("GRPCProductServer_UpdateProduct productID=%s name=%s category=%s",
+	log := requestid.SugaredLogger(ctx, s.log)
+
+	log.Infof("GRPCProductServer_UpdateProduct productID=%s name=%s category=%s",
 		req.ProductId, req.Name, req.Category)
 
 	if req.ProductId == "" {
 		return nil, status.Error(codes.InvalidArgument, "product_id is required")
 	}
 
-	if req.Name == "" {
-		return nil, status.Error(codes.InvalidArgument, "name is required")
-	}
-
-	if req.Price <= 0 {
-		return nil, status.Error(codes.InvalidArgument, "price must be greater than 0")
-	}
-
-	if req.Stock < 0 {
-		return nil, status.Error(codes.InvalidArgument, "stock cannot be negative")
+	// Validate request
+	physical := domain.PhysicalAttributes{WeightGrams: req.WeightGrams, LengthMM: req.LengthMm, WidthMM: req.WidthMm, HeightMM: req.HeightMm}
+	candidate := &domain.Product{Name: req.Name, Description: req.Description, Price: req.Price, Stock: req.Stock, Category: req.Category, PhysicalAttributes: physical}
+	candidate.Sanitize()
+	if err := candidate.Validate(s.limits); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
 
 	// Update product using the service
-	product, err := s.service.UpdateProduct(ctx, req.ProductId, req.Name, req.Description, req.Price, req.Stock, req.Category)
+	product, err := s.service.UpdateProduct(ctx, req.ProductId, req.Name, req.Description, req.Price, req.Stock, req.Category, physical, int(req.Version))
 	if err != nil {
-		s.log.Errorf("Failed to update product: %v, productID=%s", err, req.ProductId)
+		log.Errorf("Failed to update product: %v, productID=%s", err, req.ProductId)
+		if errors.Is(err, domain.ErrInvalidArgument) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if errors.Is(err, domain.ErrProductNotFound) {
+			return nil, status.Error(codes.NotFound, "product not found")
+		}
+		if errors.Is(err, domain.ErrVersionConflict) {
+			return nil, status.Error(codes.Aborted, err.Error())
+		}
 		return nil, status.Error(codes.Internal, "failed to update product")
 	}
 
 	// Convert domain product to protobuf product
 	return &productv1.UpdateProductResponse{
-		Product: domainToProtoProduct(product),
+		Product: protoconv.ProductToProto(product),
 	}, nil
 }
 
 // DeleteProduct implements the DeleteProduct RPC method
 func (s *GRPCProductServer) DeleteProduct(ctx context.Context, req *productv1.DeleteProductRequest) (*productv1.DeleteProductResponse, error) {
-	s.log.Info("GRPCProductServer_DeleteProduct", zap.String("productID", req.ProductId))
+	log := requestid.SugaredLogger(ctx, s.log)
+
+	log.Info("GRPCProductServer_DeleteProduct", zap.String("productID", req.ProductId))
 
 	if req.ProductId == "" {
 		return nil, status.Error(codes.InvalidArgument, "product_id is required")
@@ -147,7 +291,10 @@ func (s *GRPCProductServer) DeleteProduct(ctx context.Context, req *productv1.De
 	// Delete product using the service
 	err := s.service.DeleteProduct(ctx, req.ProductId)
 	if err != nil {
-		s.log.Error("Failed to delete product", zap.Error(err), zap.String("productID", req.ProductId))
+		log.Error("Failed to delete product", zap.Error(err), zap.String("productID", req.ProductId))
+		if errors.Is(err, domain.ErrProductNotFound) {
+			return nil, status.Error(codes.NotFound, "product not found")
+		}
 		return nil, status.Error(codes.Internal, "failed to delete product")
 	}
 
@@ -156,16 +303,64 @@ func (s *GRPCProductServer) DeleteProduct(ctx context.Context, req *productv1.De
 	}, nil
 }
 
-// domainToProtoProduct converts a domain product to a protobuf product
-func domainToProtoProduct(product *domain.Product) *productv1.Product {
-	return &productv1.Product{
-		Id:          product.ID,
-		Name:        product.Name,
-		Description: product.Description,
-		Price:       product.Price,
-		Stock:       product.Stock,
-		Category:    product.Category,
-		CreatedAt:   product.CreatedAt.Format(time.RFC3339),
-		UpdatedAt:   product.UpdatedAt.Format(time.RFC3339),
+// ReserveStock implements the ReserveStock RPC method
+func (s *GRPCProductServer) ReserveStock(ctx context.Context, req *productv1.ReserveStockRequest) (*productv1.ReserveStockResponse, error) {
+	log := requestid.SugaredLogger(ctx, s.log)
+
+	log.Infof("GRPCProductServer_ReserveStock count=%d", len(req.Items))
+
+	// Reserve stock using the service
+	if err := s.service.ReserveStock(ctx, protoconv.StockChangesFromProto(req.Items)); err != nil {
+		log.Errorf("Failed to reserve stock: %v", err)
+		return nil, stockStatusError(err, "failed to reserve stock")
+	}
+
+	return &productv1.ReserveStockResponse{}, nil
+}
+
+// ReleaseStock implements the ReleaseStock RPC method
+func (s *GRPCProductServer) ReleaseStock(ctx context.Context, req *productv1.ReleaseStockRequest) (*productv1.ReleaseStockResponse, error) {
+	log := requestid.SugaredLogger(ctx, s.log)
+
+	log.Infof("GRPCProductServer_ReleaseStock count=%d", len(req.Items))
+
+	// Release stock using the service
+	if err := s.service.ReleaseStock(ctx, protoconv.StockChangesFromProto(req.Items)); err != nil {
+		log.Errorf("Failed to release stock: %v", err)
+		return nil, stockStatusError(err, "failed to release stock")
+	}
+
+	return &productv1.ReleaseStockResponse{}, nil
+}
+
+// UpdateStock implements the UpdateStock RPC method
+func (s *GRPCProductServer) UpdateStock(ctx context.Context, req *productv1.UpdateStockRequest) (*productv1.UpdateStockResponse, error) {
+	log := requestid.SugaredLogger(ctx, s.log)
+
+	log.Infof("GRPCProductServer_UpdateStock productID=%s stock=%d", req.ProductId, req.Stock)
+
+	// Set the stock using the service
+	product, err := s.service.UpdateStock(ctx, req.ProductId, req.Stock)
+	if err != nil {
+		log.Errorf("Failed to update stock: %v, productID=%s", err, req.ProductId)
+		return nil, stockStatusError(err, "failed to update stock")
+	}
+
+	return &productv1.UpdateStockResponse{
+		Product: protoconv.ProductToProto(product),
+	}, nil
+}
+
+// stockStatusError maps stock errors to gRPC status errors
+func stockStatusError(err error, internalMessage string) error {
+	switch {
+	case errors.Is(err, domain.ErrInvalidArgument):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, domain.ErrProductNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, domain.ErrInsufficientStock):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		return status.Error(codes.Internal, internalMessage)
 	}
 }
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"context"
+	"testing"
+
+	"go-bootiful-ordering/gen/product/v1"
+	"go-bootiful-ordering/internal/pkg/paging"
+	"go-bootiful-ordering/internal/product/domain"
+	"go-bootiful-ordering/internal/product/service"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGRPCListProductsPageSize(t *testing.T) {
+	tests := []struct {
+		name      string
+		requested int32
+		want      int32
+		wantCode  codes.Code
+	}{
+		{name: "zero selects the default", requested: 0, want: 10},
+		{name: "within the limit", requested: 25, want: 25},
+		{name: "at the limit", requested: 50, want: 50},
+		{name: "oversized is clamped", requested: 1000, want: 50},
+		{name: "negative is rejected", requested: -1, wantCode: codes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &listRecordingRepository{}
+			svc := service.NewDBProductService(zap.NewNop().Sugar(), repo, domain.FieldLimits{}, nil)
+			server := NewGRPCProductServer(zap.NewNop().Sugar(), svc, domain.FieldLimits{}, paging.NewLimits(10, 50))
+
+			_, err := server.ListProducts(context.Background(), &productv1.ListProductsRequest{PageSize: tt.requested})
+			if tt.wantCode != codes.OK {
+				if status.Code(err) != tt.wantCode {
+					t.Fatalf("ListProducts() error = %v, want %s", err, tt.wantCode)
+				}
+				if len(repo.pageSizes) != 0 {
+					t.Errorf("rejected listing reached the repository with page sizes %v", repo.pageSizes)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ListProducts() error = %v", err)
+			}
+			if len(repo.pageSizes) != 1 || repo.pageSizes[0] != tt.want {
+				t.Errorf("repository listed with page sizes %v, want [%d]", repo.pageSizes, tt.want)
+			}
+		})
+	}
+}
+
+func TestGRPCUpdateProductVersionConflict(t *testing.T) {
+	tests := []struct {
+		name            string
+		version         int64
+		concurrentWrite bool
+		wantCode        codes.Code
+	}{
+		{name: "current version", version: 3, wantCode: codes.OK},
+		{name: "stale version", version: 2, wantCode: codes.Aborted},
+		{name: "written since it was read", version: 3, concurrentWrite: true, wantCode: codes.Aborted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newVersionedRepository(tt.concurrentWrite)
+			limits := domain.NewFieldLimits(0, 0, 0)
+			svc := service.NewDBProductService(zap.NewNop().Sugar(), repo, limits, nil)
+			server := NewGRPCProductServer(zap.NewNop().Sugar(), svc, limits, paging.NewLimits(0, 0))
+
+			resp, err := server.UpdateProduct(context.Background(), &productv1.UpdateProductRequest{
+				ProductId: "product-1", Name: "Lamp", Price: 1500, Stock: 5, Category: "home", Version: tt.version,
+			})
+			if status.Code(err) != tt.wantCode {
+				t.Fatalf("UpdateProduct() error = %v, want %s", err, tt.wantCode)
+			}
+			if tt.wantCode == codes.OK && resp.Product.Version != 4 {
+				t.Errorf("UpdateProduct() version = %d, want 4", resp.Product.Version)
+			}
+		})
+	}
+}
@@ -1,24 +1,33 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
+	"go-bootiful-ordering/internal/pkg/paging"
+	"go-bootiful-ordering/internal/pkg/requestid"
+	"go-bootiful-ordering/internal/pkg/timefmt"
+	"go-bootiful-ordering/internal/product/domain"
 	"go-bootiful-ordering/internal/product/service"
 	"go.uber.org/zap"
 	"net/http"
 	"strconv"
+	"strings"
 )
 
 // CreateProductHandler handles requests to create products
 type CreateProductHandler struct {
 	log     *zap.Logger
 	service service.ProductService
+	limits  domain.FieldLimits
 }
 
 // NewCreateProductHandler creates a new CreateProductHandler
-func NewCreateProductHandler(log *zap.Logger, service service.ProductService) *CreateProductHandler {
+func NewCreateProductHandler(log *zap.Logger, service service.ProductService, limits domain.FieldLimits) *CreateProductHandler {
 	return &CreateProductHandler{
 		log:     log,
 		service: service,
+		limits:  limits,
 	}
 }
 
@@ -39,61 +48,132 @@ type CreateProductRequest struct {
 	Price       int64  `json:"price"`
 	Stock       int32  `json:"stock"`
 	Category    string `json:"category"`
+	domain.PhysicalAttributes
 }
 
 // CreateProduct handles HTTP requests to create products
 func (h *CreateProductHandler) CreateProduct(c *gin.Context) {
+	log := requestid.Logger(c.Request.Context(), h.log)
+
 	var req CreateProductRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.log.Error("Failed to decode request", zap.Error(err))
+		log.Error("Failed to decode request", zap.Error(err))
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
 		return
 	}
 
 	// Validate request
-	if req.Name == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Name is required"})
+	candidate := &domain.Product{Name: req.Name, Description: req.Description, Price: req.Price, Stock: req.Stock, Category: req.Category, PhysicalAttributes: req.PhysicalAttributes}
+	candidate.Sanitize()
+	if err := candidate.Validate(h.limits); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if req.Price <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Price must be greater than 0"})
+	// Create product
+	product, err := h.service.CreateProduct(c.Request.Context(), req.Name, req.Description, req.Price, req.Stock, req.Category, req.PhysicalAttributes)
+	if err != nil {
+		log.Error("Failed to create product", zap.Error(err))
+		if errors.Is(err, domain.ErrInvalidArgument) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create product"})
 		return
 	}
 
-	if req.Stock < 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Stock cannot be negative"})
+	c.JSON(http.StatusCreated, newProductResponse(product))
+}
+
+// BatchCreateProductsHandler handles requests to create many products at once
+type BatchCreateProductsHandler struct {
+	log     *zap.Logger
+	service service.ProductService
+}
+
+// NewBatchCreateProductsHandler creates a new BatchCreateProductsHandler
+func NewBatchCreateProductsHandler(log *zap.Logger, service service.ProductService) *BatchCreateProductsHandler {
+	return &BatchCreateProductsHandler{
+		log:     log,
+		service: service,
+	}
+}
+
+// Pattern returns the URL pattern for this handler
+func (h *BatchCreateProductsHandler) Pattern() string {
+	return "/products/batch"
+}
+
+// Register registers the handler with the router group
+func (h *BatchCreateProductsHandler) Register(rg *gin.RouterGroup) {
+	rg.POST("/products/batch", h.CreateProducts)
+}
+
+// CreateProducts handles HTTP requests creating the products of a JSON array in one
+// transaction. An invalid item rejects the whole batch with its index in the response.
+func (h *BatchCreateProductsHandler) CreateProducts(c *gin.Context) {
+	log := requestid.Logger(c.Request.Context(), h.log)
+
+	var req []CreateProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
 		return
 	}
 
-	// Create product
-	product, err := h.service.CreateProduct(c.Request.Context(), req.Name, req.Description, req.Price, req.Stock, req.Category)
+	products := make([]*domain.Product, len(req))
+	for i, item := range req {
+		products[i] = &domain.Product{Name: item.Name, Description: item.Description, Price: item.Price, Stock: item.Stock, Category: item.Category, PhysicalAttributes: item.PhysicalAttributes}
+	}
+
+	// Create the products
+	created, err := h.service.CreateProducts(c.Request.Context(), products)
 	if err != nil {
-		h.log.Error("Failed to create product", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create product"})
+		var itemErr *domain.BatchItemError
+		if errors.As(err, &itemErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "index": itemErr.Index})
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidArgument) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		log.Error("Failed to create products", zap.Error(err), zap.Int("count", len(products)))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create products"})
 		return
 	}
 
-	c.JSON(http.StatusCreated, product)
+	responses := make([]productResponse, len(created))
+	for i, product := range created {
+		responses[i] = newProductResponse(product)
+	}
+	c.JSON(http.StatusCreated, responses)
+}
+
+// GetProductConfig controls how GetProductHandler answers for deleted products
+type GetProductConfig struct {
+	// GoneForDeleted responds 410 Gone with a tombstone instead of 404 for soft-deleted products
+	GoneForDeleted bool
 }
 
 // GetProductHandler handles requests to get products
 type GetProductHandler struct {
 	log     *zap.Logger
 	service service.ProductService
+	cfg     GetProductConfig
 }
 
 // NewGetProductHandler creates a new GetProductHandler
-func NewGetProductHandler(log *zap.Logger, service service.ProductService) *GetProductHandler {
+func NewGetProductHandler(log *zap.Logger, service service.ProductService, cfg GetProductConfig) *GetProductHandler {
 	return &GetProductHandler{
 		log:     log,
 		service: service,
+		cfg:     cfg,
 	}
 }
 
 // Pattern returns the URL pattern for this handler
 func (h *GetProductHandler) Pattern() string {
-	return "/products/"
+	return "/products/:id"
 }
 
 // Register registers the handler with the router group
@@ -103,33 +183,63 @@ func (h *GetProductHandler) Register(rg *gin.RouterGroup) {
 
 // GetProduct handles HTTP requests to get products
 func (h *GetProductHandler) GetProduct(c *gin.Context) {
+	log := requestid.Logger(c.Request.Context(), h.log)
+
 	productID := c.Param("id")
 	if productID == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Product ID is required"})
 		return
 	}
 
+	// Parse the optional sparse fieldset
+	fields, err := parseFields(c.Query("fields"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	product, err := h.service.GetProduct(c.Request.Context(), productID)
 	if err != nil {
-		h.log.Error("Failed to get product", zap.Error(err), zap.String("productID", productID))
+		log.Error("Failed to get product", zap.Error(err), zap.String("productID", productID))
+		if errors.Is(err, domain.ErrProductNotFound) && h.cfg.GoneForDeleted && h.respondGone(c, productID) {
+			return
+		}
 		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
 		return
 	}
 
-	c.JSON(http.StatusOK, product)
+	c.JSON(http.StatusOK, projectProduct(product, fields))
+}
+
+// respondGone answers 410 Gone with a minimal tombstone if the product was deleted,
+// reporting whether it did; products that never existed are left to the 404
+func (h *GetProductHandler) respondGone(c *gin.Context, productID string) bool {
+	product, err := h.service.GetProductIncludingDeleted(c.Request.Context(), productID)
+	if err != nil || product.DeletedAt == nil {
+		return false
+	}
+
+	c.JSON(http.StatusGone, gin.H{
+		"error":      "Product deleted",
+		"id":         product.ID,
+		"deleted_at": timefmt.Format(*product.DeletedAt),
+	})
+	return true
 }
 
 // ListProductsHandler handles requests to list products
 type ListProductsHandler struct {
 	log     *zap.Logger
 	service service.ProductService
+	paging  paging.Limits
 }
 
 // NewListProductsHandler creates a new ListProductsHandler
-func NewListProductsHandler(log *zap.Logger, service service.ProductService) *ListProductsHandler {
+func NewListProductsHandler(log *zap.Logger, service service.ProductService, paging paging.Limits) *ListProductsHandler {
 	return &ListProductsHandler{
 		log:     log,
 		service: service,
+		paging:  paging,
 	}
 }
 
@@ -145,59 +255,205 @@ func (h *ListProductsHandler) Register(rg *gin.RouterGroup) {
 
 // ListProducts handles HTTP requests to list products
 func (h *ListProductsHandler) ListProducts(c *gin.Context) {
+	log := requestid.Logger(c.Request.Context(), h.log)
+
 	category := c.Query("category")
 
-	pageSizeStr := c.Query("page_size")
-	pageSize := int32(10) // Default page size
-	if pageSizeStr != "" {
-		if size, err := strconv.ParseInt(pageSizeStr, 10, 32); err == nil {
-			pageSize = int32(size)
-		}
+	// Apply the default and maximum page size
+	pageSize, err := h.paging.ParsePageSize(c.Query("page_size"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
 	pageToken := c.Query("page_token")
 
-	products, nextPageToken, err := h.service.ListProducts(c.Request.Context(), category, pageSize, pageToken)
+	// Parse the optional sparse fieldset
+	fields, err := parseFields(c.Query("fields"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Parse the optional sorting and price filter
+	opts, err := parseListOptions(c)
 	if err != nil {
-		h.log.Error("Failed to list products", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	includeTotal, err := paging.ParseIncludeTotal(c.Query("include_total"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	products, nextPageToken, err := h.service.ListProducts(c.Request.Context(), category, opts, pageSize, pageToken)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidArgument) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		log.Error("Failed to list products", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list products"})
 		return
 	}
 
+	// Count the matching products only when asked, as it costs a second query
+	var totalSize *int64
+	if includeTotal {
+		total, err := h.service.CountProducts(c.Request.Context(), category, opts)
+		if err != nil {
+			log.Error("Failed to count products", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list products"})
+			return
+		}
+		totalSize = &total
+	}
+
+	projected := make([]interface{}, len(products))
+	for i, product := range products {
+		projected[i] = projectProduct(product, fields)
+	}
+
 	response := struct {
-		Products      interface{} `json:"products"`
-		NextPageToken string      `json:"next_page_token,omitempty"`
+		Products      []interface{} `json:"products"`
+		NextPageToken string        `json:"next_page_token,omitempty"`
+		TotalSize     *int64        `json:"total_size,omitempty"`
 	}{
-		Products:      products,
+		Products:      projected,
 		NextPageToken: nextPageToken,
+		TotalSize:     totalSize,
 	}
 
 	c.JSON(http.StatusOK, response)
 }
 
+// SearchProductsHandler handles full-text product search requests
+type SearchProductsHandler struct {
+	log     *zap.Logger
+	service service.ProductService
+	paging  paging.Limits
+}
+
+// NewSearchProductsHandler creates a new SearchProductsHandler
+func NewSearchProductsHandler(log *zap.Logger, service service.ProductService, paging paging.Limits) *SearchProductsHandler {
+	return &SearchProductsHandler{
+		log:     log,
+		service: service,
+		paging:  paging,
+	}
+}
+
+// Pattern returns the URL pattern for this handler
+func (h *SearchProductsHandler) Pattern() string {
+	return "/products/search"
+}
+
+// Register registers the handler with the router group
+func (h *SearchProductsHandler) Register(rg *gin.RouterGroup) {
+	rg.GET("/products/search", h.SearchProducts)
+}
+
+// SearchProducts handles HTTP requests to search products by name and description
+func (h *SearchProductsHandler) SearchProducts(c *gin.Context) {
+	log := requestid.Logger(c.Request.Context(), h.log)
+
+	query := c.Query("q")
+	if strings.TrimSpace(query) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Query parameter q is required"})
+		return
+	}
+
+	// Apply the default and maximum page size
+	pageSize, err := h.paging.ParsePageSize(c.Query("page_size"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	products, nextPageToken, err := h.service.SearchProducts(c.Request.Context(), query, pageSize, c.Query("page_token"))
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidArgument) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		log.Error("Failed to search products", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search products"})
+		return
+	}
+
+	results := make([]productResponse, len(products))
+	for i, product := range products {
+		results[i] = newProductResponse(product)
+	}
+
+	response := struct {
+		Products      []productResponse `json:"products"`
+		NextPageToken string            `json:"next_page_token,omitempty"`
+	}{
+		Products:      results,
+		NextPageToken: nextPageToken,
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// parseListOptions reads the sort_by, sort_dir, min_price and max_price query parameters.
+// The values themselves are validated by the service.
+func parseListOptions(c *gin.Context) (domain.ProductListOptions, error) {
+	opts := domain.ProductListOptions{
+		SortBy:  domain.ProductSortField(c.Query("sort_by")),
+		SortDir: domain.SortDirection(c.Query("sort_dir")),
+	}
+
+	parsePrice := func(name string) (*int64, error) {
+		raw := c.Query(name)
+		if raw == "" {
+			return nil, nil
+		}
+		price, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be an integer", name)
+		}
+		return &price, nil
+	}
+
+	var err error
+	if opts.MinPrice, err = parsePrice("min_price"); err != nil {
+		return opts, err
+	}
+	if opts.MaxPrice, err = parsePrice("max_price"); err != nil {
+		return opts, err
+	}
+	return opts, nil
+}
+
 // UpdateProductHandler handles requests to update products
 type UpdateProductHandler struct {
 	log     *zap.Logger
 	service service.ProductService
+	limits  domain.FieldLimits
 }
 
 // NewUpdateProductHandler creates a new UpdateProductHandler
-func NewUpdateProductHandler(log *zap.Logger, service service.ProductService) *UpdateProductHandler {
+func NewUpdateProductHandler(log *zap.Logger, service service.ProductService, limits domain.FieldLimits) *UpdateProductHandler {
 	return &UpdateProductHandler{
 		log:     log,
 		service: service,
+		limits:  limits,
 	}
 }
 
 // Pattern returns the URL pattern for this handler
 func (h *UpdateProductHandler) Pattern() string {
-	return "/products/"
+	return "/products/:id"
 }
 
 // Register registers the handler with the router group
 func (h *UpdateProductHandler) Register(rg *gin.RouterGroup) {
 	rg.PUT("/products/:id", h.UpdateProduct)
-	rg.PATCH("/products/:id", h.UpdateProduct)
+	rg.PATCH("/products/:id", h.PatchProduct)
 }
 
 // UpdateProductRequest represents the request body for updating a product
@@ -207,10 +463,15 @@ type UpdateProductRequest struct {
 	Price       int64  `json:"price"`
 	Stock       int32  `json:"stock"`
 	Category    string `json:"category"`
+	domain.PhysicalAttributes
+	// Version is the version of the product the update is based on; zero skips the check
+	Version int `json:"version"`
 }
 
-// UpdateProduct handles HTTP requests to update products
+// UpdateProduct handles HTTP requests to replace all of a product's editable fields
 func (h *UpdateProductHandler) UpdateProduct(c *gin.Context) {
+	log := requestid.Logger(c.Request.Context(), h.log)
+
 	productID := c.Param("id")
 	if productID == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Product ID is required"})
@@ -219,36 +480,138 @@ func (h *UpdateProductHandler) UpdateProduct(c *gin.Context) {
 
 	var req UpdateProductRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.log.Error("Failed to decode request", zap.Error(err))
+		log.Error("Failed to decode request", zap.Error(err))
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
 		return
 	}
 
 	// Validate request
-	if req.Name == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Name is required"})
+	candidate := &domain.Product{Name: req.Name, Description: req.Description, Price: req.Price, Stock: req.Stock, Category: req.Category, PhysicalAttributes: req.PhysicalAttributes}
+	candidate.Sanitize()
+	if err := candidate.Validate(h.limits); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if req.Price <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Price must be greater than 0"})
+	// Update product
+	product, err := h.service.UpdateProduct(c.Request.Context(), productID, req.Name, req.Description, req.Price, req.Stock, req.Category, req.PhysicalAttributes, req.Version)
+	if err != nil {
+		log.Error("Failed to update product", zap.Error(err), zap.String("productID", productID))
+		if errors.Is(err, domain.ErrInvalidArgument) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, domain.ErrVersionConflict) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update product"})
 		return
 	}
 
-	if req.Stock < 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Stock cannot be negative"})
+	c.JSON(http.StatusOK, newProductResponse(product))
+}
+
+// PatchProductRequest represents the request body for a partial product update;
+// omitted fields are left unchanged
+type PatchProductRequest struct {
+	domain.ProductPatch
+}
+
+// PatchProduct handles HTTP requests to update some of a product's fields
+func (h *UpdateProductHandler) PatchProduct(c *gin.Context) {
+	log := requestid.Logger(c.Request.Context(), h.log)
+
+	productID := c.Param("id")
+	if productID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Product ID is required"})
 		return
 	}
 
-	// Update product
-	product, err := h.service.UpdateProduct(c.Request.Context(), productID, req.Name, req.Description, req.Price, req.Stock, req.Category)
+	var req PatchProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Error("Failed to decode request", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	product, err := h.service.PatchProduct(c.Request.Context(), productID, req.ProductPatch)
 	if err != nil {
-		h.log.Error("Failed to update product", zap.Error(err), zap.String("productID", productID))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update product"})
+		switch {
+		case errors.Is(err, domain.ErrInvalidArgument):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, domain.ErrProductNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		default:
+			log.Error("Failed to patch product", zap.Error(err), zap.String("productID", productID))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update product"})
+		}
 		return
 	}
 
-	c.JSON(http.StatusOK, product)
+	c.JSON(http.StatusOK, newProductResponse(product))
+}
+
+// UpdateStockHandler handles requests to set a product's stock alone
+type UpdateStockHandler struct {
+	log     *zap.Logger
+	service service.ProductService
+}
+
+// NewUpdateStockHandler creates a new UpdateStockHandler
+func NewUpdateStockHandler(log *zap.Logger, service service.ProductService) *UpdateStockHandler {
+	return &UpdateStockHandler{
+		log:     log,
+		service: service,
+	}
+}
+
+// Pattern returns the URL pattern for this handler
+func (h *UpdateStockHandler) Pattern() string {
+	return "/products/:id/stock"
+}
+
+// Register registers the handler with the router group
+func (h *UpdateStockHandler) Register(rg *gin.RouterGroup) {
+	rg.PATCH("/products/:id/stock", h.UpdateStock)
+}
+
+// UpdateStockRequest represents the request body for setting a product's stock
+type UpdateStockRequest struct {
+	Stock *int32 `json:"stock" binding:"required"`
+}
+
+// UpdateStock handles HTTP requests to set a product's stock, leaving its other fields as they are
+func (h *UpdateStockHandler) UpdateStock(c *gin.Context) {
+	log := requestid.Logger(c.Request.Context(), h.log)
+
+	productID := c.Param("id")
+	if productID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Product ID is required"})
+		return
+	}
+
+	var req UpdateStockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: stock is required"})
+		return
+	}
+
+	product, err := h.service.UpdateStock(c.Request.Context(), productID, *req.Stock)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidArgument):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, domain.ErrProductNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		default:
+			log.Error("Failed to update stock", zap.Error(err), zap.String("productID", productID))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update stock"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, newProductResponse(product))
 }
 
 // DeleteProductHandler handles requests to delete products
@@ -267,7 +630,7 @@ func NewDeleteProductHandler(log *zap.Logger, service service.ProductService) *D
 
 // Pattern returns the URL pattern for this handler
 func (h *DeleteProductHandler) Pattern() string {
-	return "/products/"
+	return "/products/:id"
 }
 
 // Register registers the handler with the router group
@@ -277,6 +640,8 @@ func (h *DeleteProductHandler) Register(rg *gin.RouterGroup) {
 
 // DeleteProduct handles HTTP requests to delete products
 func (h *DeleteProductHandler) DeleteProduct(c *gin.Context) {
+	log := requestid.Logger(c.Request.Context(), h.log)
+
 	productID := c.Param("id")
 	if productID == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Product ID is required"})
@@ -285,10 +650,67 @@ func (h *DeleteProductHandler) DeleteProduct(c *gin.Context) {
 
 	err := h.service.DeleteProduct(c.Request.Context(), productID)
 	if err != nil {
-		h.log.Error("Failed to delete product", zap.Error(err), zap.String("productID", productID))
+		log.Error("Failed to delete product", zap.Error(err), zap.String("productID", productID))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete product"})
 		return
 	}
 
 	c.Status(http.StatusNoContent)
 }
+
+// CheckAvailabilityHandler handles requests to check the availability of several products at once
+type CheckAvailabilityHandler struct {
+	log     *zap.Logger
+	service service.ProductService
+}
+
+// NewCheckAvailabilityHandler creates a new CheckAvailabilityHandler
+func NewCheckAvailabilityHandler(log *zap.Logger, service service.ProductService) *CheckAvailabilityHandler {
+	return &CheckAvailabilityHandler{
+		log:     log,
+		service: service,
+	}
+}
+
+// Pattern returns the URL pattern for this handler
+func (h *CheckAvailabilityHandler) Pattern() string {
+	return "/products/availability"
+}
+
+// Register registers the handler with the router group
+func (h *CheckAvailabilityHandler) Register(rg *gin.RouterGroup) {
+	rg.POST("/products/availability", h.CheckAvailability)
+}
+
+// CheckAvailability handles HTTP requests checking a batch of [{product_id, qty}] items,
+// responding with one {product_id, available, stock} entry per item in request order
+func (h *CheckAvailabilityHandler) CheckAvailability(c *gin.Context) {
+	log := requestid.Logger(c.Request.Context(), h.log)
+
+	var req []struct {
+		ProductID string `json:"product_id"`
+		Qty       int32  `json:"qty"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	items := make([]domain.StockChange, len(req))
+	for i, item := range req {
+		items[i] = domain.StockChange{ProductID: item.ProductID, Quantity: item.Qty}
+	}
+
+	availability, err := h.service.CheckAvailability(c.Request.Context(), items)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidArgument) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		log.Error("Failed to check availability", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check availability"})
+		return
+	}
+
+	c.JSON(http.StatusOK, availability)
+}
@@ -0,0 +1,275 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go-bootiful-ordering/internal/pkg/paging"
+	"go-bootiful-ordering/internal/product/domain"
+	"go-bootiful-ordering/internal/product/repository"
+	"go-bootiful-ordering/internal/product/service"
+	"go.uber.org/zap"
+)
+
+// listRecordingRepository records the options and page sizes of the listings reaching it;
+// the methods it does not implement are not expected to be called
+type listRecordingRepository struct {
+	repository.ProductRepository
+
+	lists     []domain.ProductListOptions
+	pageSizes []int32
+}
+
+func (r *listRecordingRepository) ListProducts(ctx context.Context, category string, opts domain.ProductListOptions, pageSize int32, pageToken string) ([]*domain.Product, string, error) {
+	r.lists = append(r.lists, opts)
+	r.pageSizes = append(r.pageSizes, pageSize)
+	return nil, "", nil
+}
+
+func TestListProductsSortAndFilterParameters(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	price := func(v int64) *int64 { return &v }
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		want       *domain.ProductListOptions
+	}{
+		{name: "default sort", query: "", wantStatus: http.StatusOK, want: &domain.ProductListOptions{}},
+		{name: "sort by price", query: "sort_by=price", wantStatus: http.StatusOK, want: &domain.ProductListOptions{SortBy: domain.ProductSortFieldPrice}},
+		{name: "newest first", query: "sort_by=created_at&sort_dir=desc", wantStatus: http.StatusOK, want: &domain.ProductListOptions{SortBy: domain.ProductSortFieldCreatedAt, SortDir: domain.SortDirectionDesc}},
+		{name: "default sort reversed", query: "sort_dir=desc", wantStatus: http.StatusOK, want: &domain.ProductListOptions{SortDir: domain.SortDirectionDesc}},
+		{
+			name:       "name within a price range",
+			query:      "category=books&sort_by=name&sort_dir=asc&min_price=100&max_price=500",
+			wantStatus: http.StatusOK,
+			want:       &domain.ProductListOptions{SortBy: domain.ProductSortFieldName, SortDir: domain.SortDirectionAsc, MinPrice: price(100), MaxPrice: price(500)},
+		},
+		{name: "unknown sort key", query: "sort_by=popularity", wantStatus: http.StatusBadRequest},
+		{name: "unknown direction", query: "sort_by=price&sort_dir=up", wantStatus: http.StatusBadRequest},
+		{name: "price that is not a number", query: "min_price=cheap", wantStatus: http.StatusBadRequest},
+		{name: "inverted price range", query: "min_price=500&max_price=100", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &listRecordingRepository{}
+			svc := service.NewDBProductService(zap.NewNop().Sugar(), repo, domain.FieldLimits{}, nil)
+			router := gin.New()
+			NewListProductsHandler(zap.NewNop(), svc, paging.NewLimits(0, 0)).Register(&router.RouterGroup)
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/products?"+tt.query, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.want == nil {
+				if len(repo.lists) != 0 {
+					t.Errorf("rejected listing reached the repository with %+v", repo.lists)
+				}
+				return
+			}
+			if len(repo.lists) != 1 || !reflect.DeepEqual(repo.lists[0], *tt.want) {
+				t.Errorf("repository listed with %+v, want %+v", repo.lists, *tt.want)
+			}
+		})
+	}
+}
+
+// patchRepository holds one product and applies the patches reaching it the way the
+// database repository does
+type patchRepository struct {
+	repository.ProductRepository
+
+	product *domain.Product
+	patches []domain.ProductPatch
+}
+
+func (r *patchRepository) PatchProduct(ctx context.Context, productID string, patch domain.ProductPatch) (*domain.Product, error) {
+	r.patches = append(r.patches, patch)
+	if productID != r.product.ID {
+		return nil, domain.ErrProductNotFound
+	}
+	patch.Apply(r.product)
+	r.product.Version++
+	updated := *r.product
+	return &updated, nil
+}
+
+func TestPatchProductUpdatesOnlyTheSetFields(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	type product struct {
+		Name        string               `json:"name"`
+		Description string               `json:"description"`
+		Price       int64                `json:"price"`
+		Stock       int32                `json:"stock"`
+		Category    string               `json:"category"`
+		Status      domain.ProductStatus `json:"status"`
+	}
+	original := product{Name: "Lamp", Description: "A desk lamp", Price: 1000, Stock: 5, Category: "home", Status: domain.ProductStatusActive}
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		want       *product
+	}{
+		{name: "price alone", method: http.MethodPatch, path: "/products/product-1", body: `{"price":1500}`, wantStatus: http.StatusOK,
+			want: &product{Name: "Lamp", Description: "A desk lamp", Price: 1500, Stock: 5, Category: "home", Status: domain.ProductStatusActive}},
+		{name: "stock sold out", method: http.MethodPatch, path: "/products/product-1", body: `{"stock":0}`, wantStatus: http.StatusOK,
+			want: &product{Name: "Lamp", Description: "A desk lamp", Price: 1000, Stock: 0, Category: "home", Status: domain.ProductStatusOutOfStock}},
+		{name: "name trimmed", method: http.MethodPatch, path: "/products/product-1", body: `{"name":"  Floor lamp "}`, wantStatus: http.StatusOK,
+			want: &product{Name: "Floor lamp", Description: "A desk lamp", Price: 1000, Stock: 5, Category: "home", Status: domain.ProductStatusActive}},
+		{name: "empty patch", method: http.MethodPatch, path: "/products/product-1", body: `{}`, wantStatus: http.StatusBadRequest},
+		{name: "empty name", method: http.MethodPatch, path: "/products/product-1", body: `{"name":""}`, wantStatus: http.StatusBadRequest},
+		{name: "negative stock", method: http.MethodPatch, path: "/products/product-1", body: `{"stock":-1}`, wantStatus: http.StatusBadRequest},
+		{name: "unknown product", method: http.MethodPatch, path: "/products/product-2", body: `{"price":1500}`, wantStatus: http.StatusNotFound},
+		// A full update still needs the whole product
+		{name: "put with the price alone", method: http.MethodPut, path: "/products/product-1", body: `{"price":1500}`, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &patchRepository{product: &domain.Product{
+				ID: "product-1", Name: original.Name, Description: original.Description, Price: original.Price,
+				Stock: original.Stock, Category: original.Category, Status: original.Status, Version: 1,
+			}}
+			limits := domain.NewFieldLimits(0, 0, 0)
+			svc := service.NewDBProductService(zap.NewNop().Sugar(), repo, limits, nil)
+			router := gin.New()
+			NewUpdateProductHandler(zap.NewNop(), svc, limits).Register(&router.RouterGroup)
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.want == nil {
+				if tt.wantStatus == http.StatusBadRequest && len(repo.patches) != 0 {
+					t.Errorf("rejected patch reached the repository with %+v", repo.patches)
+				}
+				return
+			}
+			var got product
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("decoding the response: %v", err)
+			}
+			if got != *tt.want {
+				t.Errorf("patched product = %+v, want %+v", got, *tt.want)
+			}
+			if len(repo.patches) != 1 {
+				t.Fatalf("repository received %d patches, want 1", len(repo.patches))
+			}
+		})
+	}
+}
+
+// versionedRepository holds one product and writes an update only at the version it has,
+// as the database repository does. A concurrent write, when set, lands between the read
+// and the update, moving the product to the next version.
+type versionedRepository struct {
+	repository.ProductRepository
+
+	product         *domain.Product
+	concurrentWrite bool
+	updates         int
+}
+
+func (r *versionedRepository) GetProduct(ctx context.Context, productID string) (*domain.Product, error) {
+	if productID != r.product.ID {
+		return nil, domain.ErrProductNotFound
+	}
+	product := *r.product
+	if r.concurrentWrite {
+		r.product.Version++
+	}
+	return &product, nil
+}
+
+func (r *versionedRepository) UpdateProduct(ctx context.Context, product *domain.Product) (*domain.Product, error) {
+	if product.Version != 0 && product.Version != r.product.Version {
+		return nil, fmt.Errorf("%w: product %s is no longer at version %d", domain.ErrVersionConflict, product.ID, product.Version)
+	}
+	r.updates++
+	updated := *product
+	updated.Version = r.product.Version + 1
+	r.product = &updated
+	result := updated
+	return &result, nil
+}
+
+func newVersionedRepository(concurrentWrite bool) *versionedRepository {
+	return &versionedRepository{
+		product:         &domain.Product{ID: "product-1", Name: "Lamp", Price: 1000, Stock: 5, Category: "home", Status: domain.ProductStatusActive, Version: 3},
+		concurrentWrite: concurrentWrite,
+	}
+}
+
+func TestUpdateProductVersionConflict(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name            string
+		version         int
+		concurrentWrite bool
+		wantStatus      int
+		wantVersion     int
+	}{
+		{name: "current version", version: 3, wantStatus: http.StatusOK, wantVersion: 4},
+		{name: "no version", version: 0, wantStatus: http.StatusOK, wantVersion: 4},
+		{name: "stale version", version: 2, wantStatus: http.StatusConflict},
+		// The version was current when read, but another write landed before the update
+		{name: "written since it was read", version: 3, concurrentWrite: true, wantStatus: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newVersionedRepository(tt.concurrentWrite)
+			limits := domain.NewFieldLimits(0, 0, 0)
+			svc := service.NewDBProductService(zap.NewNop().Sugar(), repo, limits, nil)
+			router := gin.New()
+			NewUpdateProductHandler(zap.NewNop(), svc, limits).Register(&router.RouterGroup)
+
+			body := fmt.Sprintf(`{"name":"Lamp","price":1500,"stock":5,"category":"home","version":%d}`, tt.version)
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPut, "/products/product-1", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus == http.StatusConflict {
+				if repo.updates != 0 || repo.product.Price != 1000 {
+					t.Errorf("stale update was written: %+v", repo.product)
+				}
+				return
+			}
+			var got struct {
+				Price   int64 `json:"price"`
+				Version int   `json:"version"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("decoding the response: %v", err)
+			}
+			if got.Price != 1500 || got.Version != tt.wantVersion {
+				t.Errorf("updated product = %+v, want price 1500 at version %d", got, tt.wantVersion)
+			}
+		})
+	}
+}
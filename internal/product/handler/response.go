@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"go-bootiful-ordering/internal/pkg/timefmt"
+	"go-bootiful-ordering/internal/product/domain"
+)
+
+// productResponse is the JSON representation of a product. Timestamps are formatted with
+// the configured layout so they match the gRPC API, rather than time.Time's RFC3339Nano.
+type productResponse struct {
+	*domain.Product
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+	DeletedAt string `json:"deleted_at,omitempty"`
+}
+
+// newProductResponse converts a domain product into its JSON representation
+func newProductResponse(product *domain.Product) productResponse {
+	response := productResponse{
+		Product:   product,
+		CreatedAt: timefmt.Format(product.CreatedAt),
+		UpdatedAt: timefmt.Format(product.UpdatedAt),
+	}
+	if product.DeletedAt != nil {
+		response.DeletedAt = timefmt.Format(*product.DeletedAt)
+	}
+	return response
+}
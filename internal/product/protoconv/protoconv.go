@@ -0,0 +1,153 @@
+package protoconv
+
+import (
+	"go-bootiful-ordering/gen/product/v1"
+	"go-bootiful-ordering/internal/pkg/timefmt"
+	"go-bootiful-ordering/internal/product/domain"
+)
+
+// StatusToProto converts a domain product status to a protobuf product status
+func StatusToProto(status domain.ProductStatus) productv1.ProductStatus {
+	switch status {
+	case domain.ProductStatusActive:
+		return productv1.ProductStatus_PRODUCT_STATUS_ACTIVE
+	case domain.ProductStatusInactive:
+		return productv1.ProductStatus_PRODUCT_STATUS_INACTIVE
+	case domain.ProductStatusOutOfStock:
+		return productv1.ProductStatus_PRODUCT_STATUS_OUT_OF_STOCK
+	default:
+		return productv1.ProductStatus_PRODUCT_STATUS_UNSPECIFIED
+	}
+}
+
+// StatusFromProto converts a protobuf product status to a domain product status.
+// Unknown statuses convert to domain.ProductStatusUnspecified.
+func StatusFromProto(status productv1.ProductStatus) domain.ProductStatus {
+	switch status {
+	case productv1.ProductStatus_PRODUCT_STATUS_ACTIVE:
+		return domain.ProductStatusActive
+	case productv1.ProductStatus_PRODUCT_STATUS_INACTIVE:
+		return domain.ProductStatusInactive
+	case productv1.ProductStatus_PRODUCT_STATUS_OUT_OF_STOCK:
+		return domain.ProductStatusOutOfStock
+	default:
+		return domain.ProductStatusUnspecified
+	}
+}
+
+// ProductToProto converts a domain product to a protobuf product
+func ProductToProto(product *domain.Product) *productv1.Product {
+	return &productv1.Product{
+		Id:            product.ID,
+		Name:          product.Name,
+		Description:   product.Description,
+		Price:         product.Price,
+		Stock:         product.Stock,
+		Category:      product.Category,
+		Status:        StatusToProto(product.Status),
+		WeightGrams:   product.WeightGrams,
+		LengthMm:      product.LengthMM,
+		WidthMm:       product.WidthMM,
+		HeightMm:      product.HeightMM,
+		AverageRating: product.AverageRating,
+		ReviewCount:   product.ReviewCount,
+		CreatedAt:     timefmt.Format(product.CreatedAt),
+		UpdatedAt:     timefmt.Format(product.UpdatedAt),
+		Version:       int64(product.Version),
+	}
+}
+
+// ProductFromProto converts a protobuf product to a domain product
+func ProductFromProto(product *productv1.Product) *domain.Product {
+	// Timestamps are sent in the configured layout; unparsable values are left zero
+	createdAt, _ := timefmt.Parse(product.CreatedAt)
+	updatedAt, _ := timefmt.Parse(product.UpdatedAt)
+
+	return &domain.Product{
+		ID:          product.Id,
+		Name:        product.Name,
+		Description: product.Description,
+		Price:       product.Price,
+		Stock:       product.Stock,
+		Category:    product.Category,
+		Status:      StatusFromProto(product.Status),
+		PhysicalAttributes: domain.PhysicalAttributes{
+			WeightGrams: product.WeightGrams,
+			LengthMM:    product.LengthMm,
+			WidthMM:     product.WidthMm,
+			HeightMM:    product.HeightMm,
+		},
+		AverageRating: product.AverageRating,
+		ReviewCount:   product.ReviewCount,
+		CreatedAt:     createdAt,
+		UpdatedAt:     updatedAt,
+		Version:       int(product.Version),
+	}
+}
+
+// ProductsFromProto converts protobuf products to domain products
+func ProductsFromProto(products []*productv1.Product) []*domain.Product {
+	converted := make([]*domain.Product, len(products))
+	for i, product := range products {
+		converted[i] = ProductFromProto(product)
+	}
+	return converted
+}
+
+// ProductsToCreateRequests converts new domain products to protobuf create requests
+func ProductsToCreateRequests(products []*domain.Product) []*productv1.CreateProductRequest {
+	requests := make([]*productv1.CreateProductRequest, len(products))
+	for i, product := range products {
+		requests[i] = &productv1.CreateProductRequest{
+			Name:        product.Name,
+			Description: product.Description,
+			Price:       product.Price,
+			Stock:       product.Stock,
+			Category:    product.Category,
+			WeightGrams: product.WeightGrams,
+			LengthMm:    product.LengthMM,
+			WidthMm:     product.WidthMM,
+			HeightMm:    product.HeightMM,
+		}
+	}
+	return requests
+}
+
+// ProductsFromCreateRequests converts protobuf create requests to new domain products
+func ProductsFromCreateRequests(requests []*productv1.CreateProductRequest) []*domain.Product {
+	products := make([]*domain.Product, len(requests))
+	for i, req := range requests {
+		products[i] = &domain.Product{
+			Name:        req.Name,
+			Description: req.Description,
+			Price:       req.Price,
+			Stock:       req.Stock,
+			Category:    req.Category,
+			PhysicalAttributes: domain.PhysicalAttributes{
+				WeightGrams: req.WeightGrams,
+				LengthMM:    req.LengthMm,
+				WidthMM:     req.WidthMm,
+				HeightMM:    req.HeightMm,
+			},
+		}
+	}
+	return products
+}
+
+// StockChangesToProto converts domain stock changes to protobuf stock items
+func StockChangesToProto(changes []domain.StockChange) []*productv1.StockItem {
+	items := make([]*productv1.StockItem, len(changes))
+	for i, change := range changes {
+		items[i] = &productv1.StockItem{ProductId: change.ProductID, Quantity: change.Quantity}
+	}
+	return items
+}
+
+// StockChangesFromProto converts protobuf stock items to domain stock changes
+func StockChangesFromProto(items []*productv1.StockItem) []domain.StockChange {
+	changes := make([]domain.StockChange, len(items))
+	for i, item := range items {
+		changes[i] = domain.StockChange{ProductID: item.ProductId, Quantity: item.Quantity}
+	}
+	return changes
+}
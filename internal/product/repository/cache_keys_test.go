@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+
+	"go-bootiful-ordering/internal/product/domain"
+)
+
+func TestCategoryKeyUsesDecimalPageSize(t *testing.T) {
+	ten := categoryKey("books", domain.ProductListOptions{}, 10, "tok")
+	twenty := categoryKey("books", domain.ProductListOptions{}, 20, "tok")
+
+	if ten == twenty {
+		t.Fatalf("categoryKey() = %q for page sizes 10 and 20, want distinct keys", ten)
+	}
+	for _, key := range []string{ten, twenty} {
+		for _, r := range key {
+			if r > unicode.MaxASCII || !unicode.IsPrint(r) {
+				t.Errorf("categoryKey() = %q contains the non-printable rune %U", key, r)
+			}
+		}
+	}
+	if !strings.HasPrefix(ten, "category:books:10:") || !strings.HasSuffix(ten, ":tok") {
+		t.Errorf("categoryKey(10) = %q, want category:books:10:...:tok", ten)
+	}
+	if !strings.HasPrefix(twenty, "category:books:20:") || !strings.HasSuffix(twenty, ":tok") {
+		t.Errorf("categoryKey(20) = %q, want category:books:20:...:tok", twenty)
+	}
+}
+
+func TestListCacheSeparatesPageSizes(t *testing.T) {
+	repo := newFakeProductRepository(
+		&domain.Product{ID: "p1", Category: "books"},
+		&domain.Product{ID: "p2", Category: "books"},
+		&domain.Product{ID: "p3", Category: "books"},
+	)
+	r, _ := newTestRepository(repo, CacheConfig{})
+
+	for _, pageSize := range []int32{1, 2, 3, 1, 2, 3} {
+		products, _, err := r.ListProducts(t.Context(), "books", domain.ProductListOptions{}, pageSize, "")
+		if err != nil {
+			t.Fatalf("ListProducts(%d) error = %v", pageSize, err)
+		}
+		if int32(len(products)) != pageSize {
+			t.Errorf("ListProducts(%d) returned %d products, want a page of its own size", pageSize, len(products))
+		}
+	}
+	if _, lists := repo.counts(); lists != 3 {
+		t.Errorf("repository ListProducts called %d times, want one per page size", lists)
+	}
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"fmt"
+	"go-bootiful-ordering/internal/pkg/paging"
+	"go-bootiful-ordering/internal/product/domain"
+	"strconv"
+	"time"
+)
+
+// encodeCursor builds the page token continuing after the given product
+func encodeCursor(column, direction string, model *ProductModel) string {
+	cursor := paging.Cursor{Column: column, Direction: direction, ID: model.ID}
+	switch column {
+	case "price":
+		cursor.Value = strconv.FormatInt(model.Price, 10)
+	case "created_at":
+		cursor.Value = model.CreatedAt.Format(time.RFC3339Nano)
+	case "name":
+		cursor.Value = model.Name
+	}
+	return paging.EncodeCursor(cursor)
+}
+
+// decodeCursor parses a page token and returns the sort column value it continues after,
+// typed for the column so Postgres compares it as the column does
+func decodeCursor(token, column, direction string) (interface{}, string, error) {
+	cursor, err := paging.DecodeCursor(token, column, direction)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", domain.ErrInvalidArgument, err)
+	}
+
+	switch column {
+	case "price":
+		price, err := strconv.ParseInt(cursor.Value, 10, 64)
+		if err != nil {
+			return nil, "", fmt.Errorf("%w: %v", domain.ErrInvalidArgument, paging.ErrMalformedCursor)
+		}
+		return price, cursor.ID, nil
+	case "created_at":
+		createdAt, err := time.Parse(time.RFC3339Nano, cursor.Value)
+		if err != nil {
+			return nil, "", fmt.Errorf("%w: %v", domain.ErrInvalidArgument, paging.ErrMalformedCursor)
+		}
+		return createdAt, cursor.ID, nil
+	case "name":
+		return cursor.Value, cursor.ID, nil
+	default:
+		return nil, cursor.ID, nil
+	}
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go-bootiful-ordering/internal/pkg/paging"
+	"go-bootiful-ordering/internal/product/domain"
+)
+
+func TestProductCursorRoundTrip(t *testing.T) {
+	createdAt := time.Date(2024, 3, 1, 9, 30, 0, 123456789, time.UTC)
+	model := &ProductModel{ID: "product-1", Name: "Go Programming", Price: 1999, CreatedAt: createdAt}
+
+	tests := []struct {
+		column    string
+		direction string
+		want      interface{}
+	}{
+		{column: "price", direction: "ASC", want: int64(1999)},
+		{column: "created_at", direction: "DESC", want: createdAt},
+		{column: "name", direction: "ASC", want: "Go Programming"},
+		{column: "id", direction: "DESC", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.column, func(t *testing.T) {
+			value, id, err := decodeCursor(encodeCursor(tt.column, tt.direction, model), tt.column, tt.direction)
+			if err != nil {
+				t.Fatalf("decodeCursor() error = %v", err)
+			}
+			if id != model.ID {
+				t.Errorf("decodeCursor() id = %q, want %q", id, model.ID)
+			}
+			if createdAt, ok := value.(time.Time); ok {
+				if !createdAt.Equal(tt.want.(time.Time)) {
+					t.Errorf("decodeCursor() value = %v, want %v", createdAt, tt.want)
+				}
+			} else if value != tt.want {
+				t.Errorf("decodeCursor() value = %#v, want %#v", value, tt.want)
+			}
+		})
+	}
+}
+
+func TestProductCursorErrors(t *testing.T) {
+	priceAsc := encodeCursor("price", "ASC", &ProductModel{ID: "product-1", Price: 1999})
+
+	tests := []struct {
+		name      string
+		token     string
+		column    string
+		direction string
+	}{
+		{name: "garbled", token: "%%%", column: "price", direction: "ASC"},
+		{name: "other direction", token: priceAsc, column: "price", direction: "DESC"},
+		{name: "other column", token: priceAsc, column: "name", direction: "ASC"},
+		{name: "value of the wrong type", token: paging.EncodeCursor(paging.Cursor{Column: "price", Direction: "ASC", Value: "cheap", ID: "product-1"}), column: "price", direction: "ASC"},
+		{name: "unparsable time", token: paging.EncodeCursor(paging.Cursor{Column: "created_at", Direction: "DESC", Value: "yesterday", ID: "product-1"}), column: "created_at", direction: "DESC"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := decodeCursor(tt.token, tt.column, tt.direction); !errors.Is(err, domain.ErrInvalidArgument) {
+				t.Errorf("decodeCursor() error = %v, want ErrInvalidArgument", err)
+			}
+		})
+	}
+}
@@ -3,29 +3,62 @@ package repository
 import (
 	"context"
 	"errors"
-	"github.com/google/uuid"
+	"fmt"
+	"go-bootiful-ordering/internal/pkg/idgen"
+	"go-bootiful-ordering/internal/pkg/paging"
 	"go-bootiful-ordering/internal/product/domain"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strconv"
 	"time"
 )
 
-// GormProductRepository implements ProductRepository using GORM
+// createBatchSize is the number of products inserted per statement by CreateProducts,
+// keeping each INSERT well below the Postgres bind parameter limit
+const createBatchSize = 100
+
+// GormProductRepository implements ProductRepository using GORM.
+// Deletes are soft, so deleted products keep their row and stay resolvable from old orders.
 type GormProductRepository struct {
-	db *gorm.DB
+	db   *gorm.DB
+	ids  idgen.IDGenerator
+	sort domain.ProductSort
+
+	// includeDeleted makes reads return soft-deleted products too
+	includeDeleted bool
 }
 
-// NewGormProductRepository creates a new GormProductRepository
-func NewGormProductRepository(db *gorm.DB) *GormProductRepository {
+// NewGormProductRepository creates a new GormProductRepository listing products in the given order
+func NewGormProductRepository(db *gorm.DB, ids idgen.IDGenerator, sort domain.ProductSort) *GormProductRepository {
 	return &GormProductRepository{
-		db: db,
+		db:   db,
+		ids:  ids,
+		sort: sort,
+	}
+}
+
+// WithDeleted returns a copy of the repository whose reads include soft-deleted products.
+// Writes are unaffected, so deleting through the copy still only soft-deletes.
+func (r *GormProductRepository) WithDeleted() *GormProductRepository {
+	c := *r
+	c.includeDeleted = true
+	return &c
+}
+
+// read returns the session used for queries, including soft-deleted rows when requested
+func (r *GormProductRepository) read(ctx context.Context) *gorm.DB {
+	db := r.db.WithContext(ctx)
+	if r.includeDeleted {
+		db = db.Unscoped()
 	}
+	return db
 }
 
 // CreateProduct persists a new product and returns the created product
 func (r *GormProductRepository) CreateProduct(ctx context.Context, product *domain.Product) (*domain.Product, error) {
-	// Generate a new UUID if not provided
+	// Generate a new ID if not provided
 	if product.ID == "" {
-		product.ID = uuid.New().String()
+		product.ID = r.ids.NewID()
 	}
 
 	// Set timestamps
@@ -33,10 +66,12 @@ func (r *GormProductRepository) CreateProduct(ctx context.Context, product *doma
 	product.CreatedAt = now
 	product.UpdatedAt = now
 
-	// Set default status if not set
+	// Set default status if not set, out of stock without stock
 	if product.Status == domain.ProductStatusUnspecified {
 		product.Status = domain.ProductStatusActive
 	}
+	product.RecalculateStatus()
+	product.Version = 1
 
 	// Convert domain model to database model
 	productModel := FromProductDomain(product)
@@ -62,14 +97,57 @@ func (r *GormProductRepository) CreateProduct(ctx context.Context, product *doma
 	return productModel.ToProductDomain(), nil
 }
 
+// CreateProducts persists new products with batched inserts in a single transaction
+func (r *GormProductRepository) CreateProducts(ctx context.Context, products []*domain.Product) ([]*domain.Product, error) {
+	// Prepare the products the same way CreateProduct does
+	now := time.Now()
+	productModels := make([]*ProductModel, len(products))
+	for i, product := range products {
+		if product.ID == "" {
+			product.ID = r.ids.NewID()
+		}
+		product.CreatedAt = now
+		product.UpdatedAt = now
+		if product.Status == domain.ProductStatusUnspecified {
+			product.Status = domain.ProductStatusActive
+		}
+		product.RecalculateStatus()
+		product.Version = 1
+		productModels[i] = FromProductDomain(product)
+	}
+
+	// Begin transaction
+	tx := r.db.WithContext(ctx).Begin()
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	// Insert the products, createBatchSize rows per statement
+	if err := tx.CreateInBatches(productModels, createBatchSize).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
+	}
+
+	created := make([]*domain.Product, len(productModels))
+	for i, productModel := range productModels {
+		created[i] = productModel.ToProductDomain()
+	}
+	return created, nil
+}
+
 // GetProduct retrieves a product by ID
 func (r *GormProductRepository) GetProduct(ctx context.Context, productID string) (*domain.Product, error) {
 	var productModel ProductModel
 
 	// Query product
-	if err := r.db.WithContext(ctx).First(&productModel, "id = ?", productID).Error; err != nil {
+	if err := r.read(ctx).First(&productModel, "id = ?", productID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("product not found")
+			return nil, domain.ErrProductNotFound
 		}
 		return nil, err
 	}
@@ -78,38 +156,147 @@ func (r *GormProductRepository) GetProduct(ctx context.Context, productID string
 	return productModel.ToProductDomain(), nil
 }
 
-// ListProducts retrieves a list of products with pagination
-func (r *GormProductRepository) ListProducts(ctx context.Context, category string, pageSize int32, pageToken string) ([]*domain.Product, string, error) {
+// GetProductIncludingDeleted retrieves a product by ID even if it has been soft-deleted
+func (r *GormProductRepository) GetProductIncludingDeleted(ctx context.Context, productID string) (*domain.Product, error) {
+	return r.WithDeleted().GetProduct(ctx, productID)
+}
+
+// GetProducts retrieves the products with the given IDs with a single IN query
+func (r *GormProductRepository) GetProducts(ctx context.Context, productIDs []string) (map[string]*domain.Product, error) {
+	products := make(map[string]*domain.Product, len(productIDs))
+	if len(productIDs) == 0 {
+		return products, nil
+	}
+
+	var productModels []ProductModel
+	if err := r.read(ctx).Where("id IN ?", productIDs).Find(&productModels).Error; err != nil {
+		return nil, err
+	}
+
+	for i := range productModels {
+		products[productModels[i].ID] = productModels[i].ToProductDomain()
+	}
+	return products, nil
+}
+
+// ListProducts retrieves a list of products with keyset pagination, ordered and
+// filtered by the options; products without a requested sort use the configured one
+func (r *GormProductRepository) ListProducts(ctx context.Context, category string, opts domain.ProductListOptions, pageSize int32, pageToken string) ([]*domain.Product, string, error) {
 	var productModels []ProductModel
 
 	// Build query
-	query := r.db.WithContext(ctx)
+	query := filterProducts(r.read(ctx), category, opts)
+
+	// Apply pagination; the token holds the sort value and ID of the last product
+	// of the previous page, and the next page continues after that position
+	column, direction := r.sortOrder(opts)
+	if pageToken != "" {
+		value, id, err := decodeCursor(pageToken, column, direction)
+		if err != nil {
+			return nil, "", err
+		}
+		if column == "id" {
+			query = query.Where(paging.KeysetPredicate(column, direction), id)
+		} else {
+			query = query.Where(paging.KeysetPredicate(column, direction), value, id)
+		}
+	}
+
+	// Apply limit
+	if pageSize > 0 {
+		query = query.Limit(int(pageSize + 1)) // Fetch one extra to determine if there are more results
+	}
 
-	// Filter by category if provided
+	// Execute query
+	order := column + " " + direction
+	if column != "id" {
+		order += ", id " + direction
+	}
+	if err := query.Order(order).Find(&productModels).Error; err != nil {
+		return nil, "", err
+	}
+
+	// Determine if there are more results
+	var nextPageToken string
+	if pageSize > 0 && len(productModels) > int(pageSize) {
+		productModels = productModels[:pageSize]
+		nextPageToken = encodeCursor(column, direction, &productModels[len(productModels)-1]) // Next page starts after the last returned product
+	}
+
+	// Convert to domain models
+	products := make([]*domain.Product, len(productModels))
+	for i, model := range productModels {
+		products[i] = model.ToProductDomain()
+	}
+
+	return products, nextPageToken, nil
+}
+
+// CountProducts counts the products ListProducts pages through for the same filters
+func (r *GormProductRepository) CountProducts(ctx context.Context, category string, opts domain.ProductListOptions) (int64, error) {
+	var count int64
+	if err := filterProducts(r.read(ctx).Model(&ProductModel{}), category, opts).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// filterProducts restricts a product query to a category, when one is given, and to the options' price range
+func filterProducts(query *gorm.DB, category string, opts domain.ProductListOptions) *gorm.DB {
 	if category != "" {
 		query = query.Where("category = ?", category)
 	}
+	if opts.MinPrice != nil {
+		query = query.Where("price >= ?", *opts.MinPrice)
+	}
+	if opts.MaxPrice != nil {
+		query = query.Where("price <= ?", *opts.MaxPrice)
+	}
+	return query
+}
+
+// searchVector is the text search document of a product. Name matches weigh more than
+// description matches; the expression must match the GIN index created by the migrations.
+const searchVector = "setweight(to_tsvector('english', coalesce(name, '')), 'A') || setweight(to_tsvector('english', coalesce(description, '')), 'B')"
+
+// SearchProducts runs a Postgres full-text search over product names and descriptions,
+// ranked by ts_rank. Relevance is not a stable keyset, so the page token is an offset.
+func (r *GormProductRepository) SearchProducts(ctx context.Context, query string, pageSize int32, pageToken string) ([]*domain.Product, string, error) {
+	var productModels []ProductModel
 
-	// Apply pagination
+	// Parse the page token
+	offset := 0
 	if pageToken != "" {
-		query = query.Where("id > ?", pageToken)
+		parsed, err := strconv.Atoi(pageToken)
+		if err != nil || parsed < 0 {
+			return nil, "", fmt.Errorf("%w: malformed page token", domain.ErrInvalidArgument)
+		}
+		offset = parsed
 	}
 
+	// Match and rank the products
+	tsQuery := r.read(ctx).
+		Where("("+searchVector+") @@ plainto_tsquery('english', ?)", query).
+		Clauses(clause.OrderBy{Expression: clause.Expr{
+			SQL:  "ts_rank(" + searchVector + ", plainto_tsquery('english', ?)) DESC, id",
+			Vars: []interface{}{query},
+		}}).
+		Offset(offset)
+
 	// Apply limit
 	if pageSize > 0 {
-		query = query.Limit(int(pageSize + 1)) // Fetch one extra to determine if there are more results
+		tsQuery = tsQuery.Limit(int(pageSize + 1)) // Fetch one extra to determine if there are more results
 	}
 
-	// Execute query
-	if err := query.Order("id").Find(&productModels).Error; err != nil {
+	if err := tsQuery.Find(&productModels).Error; err != nil {
 		return nil, "", err
 	}
 
 	// Determine if there are more results
 	var nextPageToken string
-	if len(productModels) > int(pageSize) {
-		nextPageToken = productModels[len(productModels)-1].ID
-		productModels = productModels[:len(productModels)-1]
+	if pageSize > 0 && len(productModels) > int(pageSize) {
+		productModels = productModels[:pageSize]
+		nextPageToken = strconv.Itoa(offset + int(pageSize))
 	}
 
 	// Convert to domain models
@@ -121,36 +308,92 @@ func (r *GormProductRepository) ListProducts(ctx context.Context, category strin
 	return products, nextPageToken, nil
 }
 
-// UpdateProduct updates a product
+// sortOrder returns the column and direction of a listing: the requested sort field if
+// any, otherwise the configured default, with the requested direction applied to either
+func (r *GormProductRepository) sortOrder(opts domain.ProductListOptions) (string, string) {
+	column, direction := sortColumn(r.sort)
+	if opts.SortBy != domain.ProductSortFieldDefault {
+		column, direction = string(opts.SortBy), "ASC"
+		if opts.SortBy == domain.ProductSortFieldCreatedAt {
+			direction = "DESC"
+		}
+	}
+
+	switch opts.SortDir {
+	case domain.SortDirectionAsc:
+		direction = "ASC"
+	case domain.SortDirectionDesc:
+		direction = "DESC"
+	}
+	return column, direction
+}
+
+// sortColumn returns the column and direction products are ordered by for a sort
+func sortColumn(sort domain.ProductSort) (string, string) {
+	switch sort {
+	case domain.ProductSortCreatedAtDesc:
+		return "created_at", "DESC"
+	case domain.ProductSortNameAsc:
+		return "name", "ASC"
+	case domain.ProductSortPriceAsc:
+		return "price", "ASC"
+	default:
+		return "id", "ASC"
+	}
+}
+
+// UpdateProduct updates a product if it is still at the version the product was read at,
+// returning ErrVersionConflict otherwise, and increments its version. A product without a
+// version, such as one cached before versions were stored, is updated at its current version.
 func (r *GormProductRepository) UpdateProduct(ctx context.Context, product *domain.Product) (*domain.Product, error) {
 	// Set updated timestamp
 	product.UpdatedAt = time.Now()
 
-	// Convert domain model to database model
-	productModel := FromProductDomain(product)
-
 	// Begin transaction
 	tx := r.db.WithContext(ctx).Begin()
 	if tx.Error != nil {
 		return nil, tx.Error
 	}
 
-	// Check if product exists
-	var count int64
-	if err := tx.Model(&ProductModel{}).Where("id = ?", product.ID).Count(&count).Error; err != nil {
-		tx.Rollback()
-		return nil, err
+	// Without a version, lock the row and update the version it has now
+	expectedVersion := product.Version
+	if expectedVersion == 0 {
+		var current ProductModel
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("version").First(&current, "id = ?", product.ID).Error; err != nil {
+			tx.Rollback()
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, domain.ErrProductNotFound
+			}
+			return nil, err
+		}
+		expectedVersion = current.Version
 	}
 
-	if count == 0 {
+	// Convert domain model to database model, with the status following the new stock
+	product.RecalculateStatus()
+	product.Version = expectedVersion + 1
+	productModel := FromProductDomain(product)
+
+	// Update product at the expected version, keeping the rating aggregate recorded from the reviews system
+	result := tx.Model(&ProductModel{}).Where("id = ? AND version = ?", product.ID, expectedVersion).
+		Select("*").Omit(append([]string{"id"}, ratingColumns...)...).Updates(productModel)
+	if result.Error != nil {
 		tx.Rollback()
-		return nil, errors.New("product not found")
+		return nil, result.Error
 	}
 
-	// Update product
-	if err := tx.Save(productModel).Error; err != nil {
+	// No row matched: the product is gone or was written since it was read
+	if result.RowsAffected == 0 {
+		var count int64
+		if err := tx.Model(&ProductModel{}).Where("id = ?", product.ID).Count(&count).Error; err != nil {
+			tx.Rollback()
+			return nil, err
+		}
 		tx.Rollback()
-		return nil, err
+		if count == 0 {
+			return nil, domain.ErrProductNotFound
+		}
+		return nil, fmt.Errorf("%w: product %s is no longer at version %d", domain.ErrVersionConflict, product.ID, expectedVersion)
 	}
 
 	// Commit transaction
@@ -162,7 +405,75 @@ func (r *GormProductRepository) UpdateProduct(ctx context.Context, product *doma
 	return productModel.ToProductDomain(), nil
 }
 
-// DeleteProduct deletes a product by ID
+// PatchProduct applies a partial update to a locked product row, writing only the patched
+// columns, so it cannot overwrite a concurrent change of the fields it leaves out
+func (r *GormProductRepository) PatchProduct(ctx context.Context, productID string, patch domain.ProductPatch) (*domain.Product, error) {
+	var product *domain.Product
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Lock the product row
+		var productModel ProductModel
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&productModel, "id = ?", productID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return domain.ErrProductNotFound
+			}
+			return err
+		}
+
+		existing := productModel.ToProductDomain()
+		product = productModel.ToProductDomain()
+		patch.Apply(product)
+
+		// Skip the write, and with it the updated_at bump, when nothing changed
+		if product.SameDetails(existing) && product.Status == existing.Status {
+			product = existing
+			return nil
+		}
+
+		// Write only the patched columns, the status, the timestamp and the next version
+		product.UpdatedAt = time.Now()
+		product.Version++
+		updates := map[string]interface{}{
+			"status":     int(product.Status),
+			"updated_at": product.UpdatedAt,
+			"version":    product.Version,
+		}
+		if patch.Name != nil {
+			updates["name"] = product.Name
+		}
+		if patch.Description != nil {
+			updates["description"] = product.Description
+		}
+		if patch.Price != nil {
+			updates["price"] = product.Price
+		}
+		if patch.Stock != nil {
+			updates["stock"] = product.Stock
+		}
+		if patch.Category != nil {
+			updates["category"] = product.Category
+		}
+		if patch.WeightGrams != nil {
+			updates["weight_grams"] = product.WeightGrams
+		}
+		if patch.LengthMM != nil {
+			updates["length_mm"] = product.LengthMM
+		}
+		if patch.WidthMM != nil {
+			updates["width_mm"] = product.WidthMM
+		}
+		if patch.HeightMM != nil {
+			updates["height_mm"] = product.HeightMM
+		}
+		return tx.Model(&productModel).Updates(updates).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return product, nil
+}
+
+// DeleteProduct soft-deletes a product by ID
 func (r *GormProductRepository) DeleteProduct(ctx context.Context, productID string) error {
 	// Begin transaction
 	tx := r.db.WithContext(ctx).Begin()
@@ -179,7 +490,7 @@ func (r *GormProductRepository) DeleteProduct(ctx context.Context, productID str
 
 	if count == 0 {
 		tx.Rollback()
-		return errors.New("product not found")
+		return domain.ErrProductNotFound
 	}
 
 	// Delete product
@@ -195,3 +506,239 @@ func (r *GormProductRepository) DeleteProduct(ctx context.Context, productID str
 
 	return nil
 }
+
+// DeleteProducts soft-deletes the products with the given IDs in one transaction.
+// IDs that do not exist are reported in failed with ErrProductNotFound; if the
+// transaction fails, every ID is reported with that error.
+func (r *GormProductRepository) DeleteProducts(ctx context.Context, productIDs []string) (int64, map[string]error) {
+	failed := make(map[string]error)
+	failAll := func(err error) (int64, map[string]error) {
+		for _, id := range productIDs {
+			failed[id] = err
+		}
+		return 0, failed
+	}
+
+	// Begin transaction
+	tx := r.db.WithContext(ctx).Begin()
+	if tx.Error != nil {
+		return failAll(tx.Error)
+	}
+
+	// Find which of the products exist, locking them until the delete
+	var existingIDs []string
+	if err := tx.Model(&ProductModel{}).Where("id IN ?", productIDs).Clauses(clause.Locking{Strength: "UPDATE"}).Pluck("id", &existingIDs).Error; err != nil {
+		tx.Rollback()
+		return failAll(err)
+	}
+
+	existing := make(map[string]bool, len(existingIDs))
+	for _, id := range existingIDs {
+		existing[id] = true
+	}
+	for _, id := range productIDs {
+		if !existing[id] {
+			failed[id] = domain.ErrProductNotFound
+		}
+	}
+
+	if len(existingIDs) == 0 {
+		tx.Rollback()
+		return 0, failed
+	}
+
+	// Delete products
+	result := tx.Delete(&ProductModel{}, "id IN ?", existingIDs)
+	if result.Error != nil {
+		tx.Rollback()
+		return failAll(result.Error)
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		return failAll(err)
+	}
+
+	return result.RowsAffected, failed
+}
+
+// SetStatusByCategory sets the status of a category's products with a single UPDATE,
+// skipping those already at the status they would get, so their updated_at and version stay
+func (r *GormProductRepository) SetStatusByCategory(ctx context.Context, category string, status domain.ProductStatus) ([]string, error) {
+	newStatus := gorm.Expr("?", int(status))
+	if status == domain.ProductStatusActive {
+		// Follow the stock, as ProductStatus.ForStock does for a single product. The statuses
+		// are inlined, as a CASE of untyped parameters would be typed as text.
+		newStatus = gorm.Expr(fmt.Sprintf("CASE WHEN stock = 0 THEN %d ELSE %d END", domain.ProductStatusOutOfStock, domain.ProductStatusActive))
+	}
+
+	var updated []ProductModel
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return tx.Model(&updated).
+			Clauses(clause.Returning{Columns: []clause.Column{{Name: "id"}}}).
+			Where("category = ? AND status <> (?)", category, newStatus).
+			Updates(map[string]interface{}{
+				"status":     newStatus,
+				"updated_at": time.Now(),
+				"version":    gorm.Expr("version + 1"),
+			}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	productIDs := make([]string, len(updated))
+	for i, productModel := range updated {
+		productIDs[i] = productModel.ID
+	}
+	return productIDs, nil
+}
+
+// ReserveStock decrements the stock of each product in one transaction. Each decrement
+// is a conditional UPDATE, so concurrent reservations can never take stock below zero,
+// and a product whose stock runs out moves out of stock.
+func (r *GormProductRepository) ReserveStock(ctx context.Context, changes []domain.StockChange) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, change := range changes {
+			result := tx.Model(&ProductModel{}).
+				Where("id = ? AND stock >= ?", change.ProductID, change.Quantity).
+				Updates(map[string]interface{}{
+					"stock":      gorm.Expr("stock - ?", change.Quantity),
+					"status":     statusForStock("stock - ?", change.Quantity),
+					"updated_at": time.Now(),
+					"version":    gorm.Expr("version + 1"),
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+
+			if result.RowsAffected == 0 {
+				return r.stockError(tx, change)
+			}
+		}
+		return nil
+	})
+}
+
+// ReleaseStock increments the stock of each product in one transaction, making out of stock
+// products active again. Deleted products are included so that stock reserved before a
+// product was deleted can still be returned.
+func (r *GormProductRepository) ReleaseStock(ctx context.Context, changes []domain.StockChange) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, change := range changes {
+			result := tx.Unscoped().Model(&ProductModel{}).
+				Where("id = ?", change.ProductID).
+				Updates(map[string]interface{}{
+					"stock":      gorm.Expr("stock + ?", change.Quantity),
+					"status":     statusForStock("stock + ?", change.Quantity),
+					"updated_at": time.Now(),
+					"version":    gorm.Expr("version + 1"),
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+
+			if result.RowsAffected == 0 {
+				return fmt.Errorf("%w: %s", domain.ErrProductNotFound, change.ProductID)
+			}
+		}
+		return nil
+	})
+}
+
+// statusForStock returns the SQL for the status a product moves to when its stock is set to
+// newStock, an expression of the current row with quantity bound to its placeholder. It
+// mirrors ProductStatus.ForStock; the statuses are inlined, as a CASE of untyped parameters
+// would be typed as text.
+func statusForStock(newStock string, quantity int32) clause.Expr {
+	return gorm.Expr(fmt.Sprintf("CASE WHEN status = %d AND %s = 0 THEN %d WHEN status = %d AND %s > 0 THEN %d ELSE status END",
+		domain.ProductStatusActive, newStock, domain.ProductStatusOutOfStock,
+		domain.ProductStatusOutOfStock, newStock, domain.ProductStatusActive), quantity, quantity)
+}
+
+// SetStock sets the stock of a product and the status that follows from it. The row is
+// locked while the status is derived, so concurrent writes cannot interleave. Setting the
+// stock a product already has writes nothing and keeps its updated_at.
+func (r *GormProductRepository) SetStock(ctx context.Context, productID string, stock int32) (*domain.Product, error) {
+	var productModel ProductModel
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Lock the product row
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&productModel, "id = ?", productID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return domain.ErrProductNotFound
+			}
+			return err
+		}
+
+		if productModel.Stock == stock {
+			return nil
+		}
+
+		// Write only the stock, the status, the timestamp and the next version
+		productModel.Stock = stock
+		productModel.Status = int(domain.ProductStatus(productModel.Status).ForStock(stock))
+		productModel.UpdatedAt = time.Now()
+		productModel.Version++
+		return tx.Model(&productModel).Updates(map[string]interface{}{
+			"stock":      productModel.Stock,
+			"status":     productModel.Status,
+			"updated_at": productModel.UpdatedAt,
+			"version":    productModel.Version,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return productModel.ToProductDomain(), nil
+}
+
+// RecordProductRating stores a product's rating aggregate. Aggregates are snapshots, so
+// applying one twice changes nothing, and one older than the stored aggregate is ignored,
+// which keeps redelivered or reordered events from rolling the rating back. Ratings are
+// not product edits, so updated_at is left as is.
+func (r *GormProductRepository) RecordProductRating(ctx context.Context, productID string, rating domain.RatingAggregate) (*domain.Product, error) {
+	var productModel ProductModel
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Lock the product row
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&productModel, "id = ?", productID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return domain.ErrProductNotFound
+			}
+			return err
+		}
+
+		if productModel.RatedAt != nil && !rating.AsOf.After(*productModel.RatedAt) {
+			return nil
+		}
+
+		// Write only the rating columns
+		asOf := rating.AsOf.UTC()
+		productModel.AverageRating = rating.AverageRating
+		productModel.ReviewCount = rating.ReviewCount
+		productModel.RatedAt = &asOf
+		return tx.Model(&productModel).UpdateColumns(map[string]interface{}{
+			"average_rating": productModel.AverageRating,
+			"review_count":   productModel.ReviewCount,
+			"rated_at":       productModel.RatedAt,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return productModel.ToProductDomain(), nil
+}
+
+// stockError explains why a stock decrement matched no row
+func (r *GormProductRepository) stockError(tx *gorm.DB, change domain.StockChange) error {
+	var count int64
+	if err := tx.Model(&ProductModel{}).Where("id = ?", change.ProductID).Count(&count).Error; err != nil {
+		return err
+	}
+
+	if count == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrProductNotFound, change.ProductID)
+	}
+	return fmt.Errorf("%w: product %s has fewer than %d units", domain.ErrInsufficientStock, change.ProductID, change.Quantity)
+}
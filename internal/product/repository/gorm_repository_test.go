@@ -0,0 +1,217 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go-bootiful-ordering/internal/pkg/idgen"
+	"go-bootiful-ordering/internal/product/domain"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// recordedQuery is a SELECT statement built by GORM with its bind values
+type recordedQuery struct {
+	sql  string
+	vars []interface{}
+}
+
+// newRecordingDB opens a GORM connection that builds statements without sending them, and
+// so without connecting, and records the queries it builds
+func newRecordingDB(t *testing.T) (*gorm.DB, *[]recordedQuery) {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost dbname=products"}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+		Logger:                 logger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+
+	queries := &[]recordedQuery{}
+	if err := db.Callback().Query().After("gorm:query").Register("test:record", func(db *gorm.DB) {
+		*queries = append(*queries, recordedQuery{sql: db.Statement.SQL.String(), vars: db.Statement.Vars})
+	}); err != nil {
+		t.Fatalf("registering the query recorder: %v", err)
+	}
+	return db, queries
+}
+
+// listQuery runs ListProducts on a recording database and returns the query it built
+func listQuery(t *testing.T, sort domain.ProductSort, category string, opts domain.ProductListOptions, pageToken string) recordedQuery {
+	t.Helper()
+	db, queries := newRecordingDB(t)
+	repo := NewGormProductRepository(db, idgen.NewSequenceGenerator("product-"), sort)
+	if _, _, err := repo.ListProducts(context.Background(), category, opts, 10, pageToken); err != nil {
+		t.Fatalf("ListProducts() error = %v", err)
+	}
+	if len(*queries) != 1 {
+		t.Fatalf("ListProducts() ran %d queries, want 1", len(*queries))
+	}
+	return (*queries)[0]
+}
+
+// orderBy returns the ORDER BY clause of a query
+func orderBy(sql string) string {
+	_, order, _ := strings.Cut(sql, " ORDER BY ")
+	order, _, _ = strings.Cut(order, " LIMIT ")
+	return order
+}
+
+func TestListProductsOrder(t *testing.T) {
+	tests := []struct {
+		name string
+		sort domain.ProductSort
+		opts domain.ProductListOptions
+		want string
+	}{
+		{name: "no default sort", want: "id ASC"},
+		{name: "default newest first", sort: domain.ProductSortCreatedAtDesc, want: "created_at DESC, id DESC"},
+		{name: "default by name", sort: domain.ProductSortNameAsc, want: "name ASC, id ASC"},
+		{name: "default cheapest first", sort: domain.ProductSortPriceAsc, want: "price ASC, id ASC"},
+		{name: "default direction reversed", sort: domain.ProductSortNameAsc, opts: domain.ProductListOptions{SortDir: domain.SortDirectionDesc}, want: "name DESC, id DESC"},
+		{name: "id direction reversed", opts: domain.ProductListOptions{SortDir: domain.SortDirectionDesc}, want: "id DESC"},
+		{name: "sort by price overrides the default", sort: domain.ProductSortCreatedAtDesc, opts: domain.ProductListOptions{SortBy: domain.ProductSortFieldPrice}, want: "price ASC, id ASC"},
+		{name: "sort by name overrides the default", sort: domain.ProductSortPriceAsc, opts: domain.ProductListOptions{SortBy: domain.ProductSortFieldName}, want: "name ASC, id ASC"},
+		{name: "sort by created_at is newest first", sort: domain.ProductSortNameAsc, opts: domain.ProductListOptions{SortBy: domain.ProductSortFieldCreatedAt}, want: "created_at DESC, id DESC"},
+		{name: "sort by created_at oldest first", opts: domain.ProductListOptions{SortBy: domain.ProductSortFieldCreatedAt, SortDir: domain.SortDirectionAsc}, want: "created_at ASC, id ASC"},
+		{name: "sort by price descending", sort: domain.ProductSortNameAsc, opts: domain.ProductListOptions{SortBy: domain.ProductSortFieldPrice, SortDir: domain.SortDirectionDesc}, want: "price DESC, id DESC"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := listQuery(t, tt.sort, "", tt.opts, "")
+			if got := orderBy(query.sql); got != tt.want {
+				t.Errorf("ORDER BY %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListProductsFiltersWithEachSort(t *testing.T) {
+	minPrice, maxPrice := int64(1000), int64(5000)
+	filters := []struct {
+		name      string
+		category  string
+		opts      domain.ProductListOptions
+		wantWhere []string
+		wantVars  []interface{}
+	}{
+		{name: "no filter"},
+		{name: "category", category: "books", wantWhere: []string{"category = $1"}, wantVars: []interface{}{"books"}},
+		{name: "min price", opts: domain.ProductListOptions{MinPrice: &minPrice}, wantWhere: []string{"price >= $1"}, wantVars: []interface{}{minPrice}},
+		{name: "max price", opts: domain.ProductListOptions{MaxPrice: &maxPrice}, wantWhere: []string{"price <= $1"}, wantVars: []interface{}{maxPrice}},
+		{
+			name:      "category and price range",
+			category:  "books",
+			opts:      domain.ProductListOptions{MinPrice: &minPrice, MaxPrice: &maxPrice},
+			wantWhere: []string{"category = $1", "price >= $2", "price <= $3"},
+			wantVars:  []interface{}{"books", minPrice, maxPrice},
+		},
+	}
+	sorts := []struct {
+		sortBy    domain.ProductSortField
+		sortDir   domain.SortDirection
+		wantOrder string
+	}{
+		{wantOrder: "id ASC"},
+		{sortBy: domain.ProductSortFieldPrice, wantOrder: "price ASC, id ASC"},
+		{sortBy: domain.ProductSortFieldPrice, sortDir: domain.SortDirectionDesc, wantOrder: "price DESC, id DESC"},
+		{sortBy: domain.ProductSortFieldCreatedAt, wantOrder: "created_at DESC, id DESC"},
+		{sortBy: domain.ProductSortFieldCreatedAt, sortDir: domain.SortDirectionAsc, wantOrder: "created_at ASC, id ASC"},
+		{sortBy: domain.ProductSortFieldName, wantOrder: "name ASC, id ASC"},
+		{sortBy: domain.ProductSortFieldName, sortDir: domain.SortDirectionDesc, wantOrder: "name DESC, id DESC"},
+	}
+
+	for _, filter := range filters {
+		for _, sort := range sorts {
+			t.Run(filter.name+"/"+string(sort.sortBy)+" "+string(sort.sortDir), func(t *testing.T) {
+				opts := filter.opts
+				opts.SortBy, opts.SortDir = sort.sortBy, sort.sortDir
+				query := listQuery(t, domain.ProductSortID, filter.category, opts, "")
+
+				for _, condition := range filter.wantWhere {
+					if !strings.Contains(query.sql, condition) {
+						t.Errorf("query %q does not filter on %s", query.sql, condition)
+					}
+				}
+				unset := map[string]bool{"category =": filter.category == "", "price >=": opts.MinPrice == nil, "price <=": opts.MaxPrice == nil}
+				for condition, isUnset := range unset {
+					if isUnset && strings.Contains(query.sql, condition) {
+						t.Errorf("query %q filters on %s without being asked to", query.sql, condition)
+					}
+				}
+				if got := orderBy(query.sql); got != sort.wantOrder {
+					t.Errorf("ORDER BY %s, want %s", got, sort.wantOrder)
+				}
+				// The filter values come first; the page size is the last bind value
+				if got := query.vars[:len(filter.wantVars)]; len(filter.wantVars) > 0 && !reflect.DeepEqual(got, filter.wantVars) {
+					t.Errorf("bind values %v, want %v first", query.vars, filter.wantVars)
+				}
+			})
+		}
+	}
+}
+
+func TestListProductsContinuesAfterCursor(t *testing.T) {
+	createdAt := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+	last := &ProductModel{ID: "product-7", Name: "Go", Price: 1999, CreatedAt: createdAt}
+	minPrice := int64(1000)
+
+	tests := []struct {
+		name      string
+		sort      domain.ProductSort
+		opts      domain.ProductListOptions
+		column    string
+		direction string
+		wantWhere string
+		wantVars  []interface{}
+	}{
+		{name: "by id", column: "id", direction: "ASC", wantWhere: "id > $1", wantVars: []interface{}{"product-7"}},
+		{name: "default newest first", sort: domain.ProductSortCreatedAtDesc, column: "created_at", direction: "DESC", wantWhere: "(created_at, id) < ($1, $2)", wantVars: []interface{}{createdAt, "product-7"}},
+		{name: "by price", opts: domain.ProductListOptions{SortBy: domain.ProductSortFieldPrice}, column: "price", direction: "ASC", wantWhere: "(price, id) > ($1, $2)", wantVars: []interface{}{int64(1999), "product-7"}},
+		{
+			name:      "by name descending within a price range",
+			opts:      domain.ProductListOptions{SortBy: domain.ProductSortFieldName, SortDir: domain.SortDirectionDesc, MinPrice: &minPrice},
+			column:    "name",
+			direction: "DESC",
+			wantWhere: "price >= $1 AND (name, id) < ($2, $3)",
+			wantVars:  []interface{}{minPrice, "Go", "product-7"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := listQuery(t, tt.sort, "", tt.opts, encodeCursor(tt.column, tt.direction, last))
+			if !strings.Contains(query.sql, tt.wantWhere) {
+				t.Errorf("query %q does not continue with %s", query.sql, tt.wantWhere)
+			}
+			if got := query.vars[:len(tt.wantVars)]; !reflect.DeepEqual(got, tt.wantVars) {
+				t.Errorf("bind values %v, want %v first", query.vars, tt.wantVars)
+			}
+		})
+	}
+}
+
+func TestListProductsRejectsTokenOfAnotherSort(t *testing.T) {
+	db, queries := newRecordingDB(t)
+	repo := NewGormProductRepository(db, idgen.NewSequenceGenerator("product-"), domain.ProductSortCreatedAtDesc)
+	token := encodeCursor("created_at", "DESC", &ProductModel{ID: "product-7", CreatedAt: time.Now()})
+
+	// The default order's token is valid until the listing asks for another order
+	if _, _, err := repo.ListProducts(context.Background(), "", domain.ProductListOptions{SortBy: domain.ProductSortFieldPrice}, 10, token); err == nil {
+		t.Errorf("ListProducts() error = nil for a created_at token listing by price")
+	}
+	if _, _, err := repo.ListProducts(context.Background(), "", domain.ProductListOptions{SortDir: domain.SortDirectionAsc}, 10, token); err == nil {
+		t.Errorf("ListProducts() error = nil for a newest-first token listing oldest first")
+	}
+	if len(*queries) != 0 {
+		t.Errorf("ListProducts() ran %d queries for rejected tokens", len(*queries))
+	}
+}
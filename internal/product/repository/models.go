@@ -15,10 +15,26 @@ type ProductModel struct {
 	Stock       int32
 	Category    string
 	Status      int
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	WeightGrams int32
+	LengthMM    int32
+	WidthMM     int32
+	HeightMM    int32
+	// AverageRating, ReviewCount and RatedAt are only written by RecordProductRating;
+	// RatedAt is the AsOf of the last aggregate applied and is nil until the first one
+	AverageRating float32
+	ReviewCount   int32
+	RatedAt       *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	// DeletedAt makes deletes soft: GORM sets it instead of removing the row and skips such rows in queries
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+	// Version is the optimistic concurrency version described on domain.Product
+	Version int `gorm:"not null;default:1"`
 }
 
+// ratingColumns are the columns owned by the reviews system, which product writes leave alone
+var ratingColumns = []string{"average_rating", "review_count", "rated_at"}
+
 // TableName specifies the table name for ProductModel
 func (ProductModel) TableName() string {
 	return "products"
@@ -26,7 +42,7 @@ func (ProductModel) TableName() string {
 
 // ToProductDomain converts a ProductModel to a domain.Product
 func (m *ProductModel) ToProductDomain() *domain.Product {
-	return &domain.Product{
+	product := &domain.Product{
 		ID:          m.ID,
 		Name:        m.Name,
 		Description: m.Description,
@@ -34,24 +50,49 @@ func (m *ProductModel) ToProductDomain() *domain.Product {
 		Stock:       m.Stock,
 		Category:    m.Category,
 		Status:      domain.ProductStatus(m.Status),
-		CreatedAt:   m.CreatedAt,
-		UpdatedAt:   m.UpdatedAt,
+		PhysicalAttributes: domain.PhysicalAttributes{
+			WeightGrams: m.WeightGrams,
+			LengthMM:    m.LengthMM,
+			WidthMM:     m.WidthMM,
+			HeightMM:    m.HeightMM,
+		},
+		AverageRating: m.AverageRating,
+		ReviewCount:   m.ReviewCount,
+		CreatedAt:     m.CreatedAt,
+		UpdatedAt:     m.UpdatedAt,
+		Version:       m.Version,
+	}
+	if m.DeletedAt.Valid {
+		deletedAt := m.DeletedAt.Time
+		product.DeletedAt = &deletedAt
 	}
+	return product
 }
 
 // FromProductDomain creates a ProductModel from a domain.Product
 func FromProductDomain(product *domain.Product) *ProductModel {
-	return &ProductModel{
-		ID:          product.ID,
-		Name:        product.Name,
-		Description: product.Description,
-		Price:       product.Price,
-		Stock:       product.Stock,
-		Category:    product.Category,
-		Status:      int(product.Status),
-		CreatedAt:   product.CreatedAt,
-		UpdatedAt:   product.UpdatedAt,
+	model := &ProductModel{
+		ID:            product.ID,
+		Name:          product.Name,
+		Description:   product.Description,
+		Price:         product.Price,
+		Stock:         product.Stock,
+		Category:      product.Category,
+		Status:        int(product.Status),
+		WeightGrams:   product.WeightGrams,
+		LengthMM:      product.LengthMM,
+		WidthMM:       product.WidthMM,
+		HeightMM:      product.HeightMM,
+		AverageRating: product.AverageRating,
+		ReviewCount:   product.ReviewCount,
+		CreatedAt:     product.CreatedAt,
+		UpdatedAt:     product.UpdatedAt,
+		Version:       product.Version,
+	}
+	if product.DeletedAt != nil {
+		model.DeletedAt = gorm.DeletedAt{Time: *product.DeletedAt, Valid: true}
 	}
+	return model
 }
 
 // AutoMigrate creates or updates the database schema for product models
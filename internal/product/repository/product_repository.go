@@ -10,15 +10,63 @@ type ProductRepository interface {
 	// CreateProduct persists a new product and returns the created product
 	CreateProduct(ctx context.Context, product *domain.Product) (*domain.Product, error)
 
-	// GetProduct retrieves a product by ID
+	// CreateProducts persists several new products in one transaction, all or none,
+	// and returns the created products in the given order
+	CreateProducts(ctx context.Context, products []*domain.Product) ([]*domain.Product, error)
+
+	// GetProduct retrieves a product by ID; deleted products are not found
 	GetProduct(ctx context.Context, productID string) (*domain.Product, error)
 
-	// ListProducts retrieves a list of products with pagination
-	ListProducts(ctx context.Context, category string, pageSize int32, pageToken string) ([]*domain.Product, string, error)
+	// GetProductIncludingDeleted retrieves a product by ID even if it has been deleted
+	GetProductIncludingDeleted(ctx context.Context, productID string) (*domain.Product, error)
+
+	// GetProducts retrieves the products with the given IDs in one query, keyed by ID.
+	// IDs that do not exist (or are deleted) are absent from the map.
+	GetProducts(ctx context.Context, productIDs []string) (map[string]*domain.Product, error)
+
+	// ListProducts retrieves a list of products with pagination, ordered and filtered by the options.
+	// Page tokens are only valid for the ordering they were issued for.
+	ListProducts(ctx context.Context, category string, opts domain.ProductListOptions, pageSize int32, pageToken string) ([]*domain.Product, string, error)
+
+	// CountProducts counts the products matching the category and the options' price range,
+	// i.e. every product ListProducts would return across all pages
+	CountProducts(ctx context.Context, category string, opts domain.ProductListOptions) (int64, error)
+
+	// SearchProducts retrieves the products whose name or description match a text query,
+	// most relevant first, with pagination
+	SearchProducts(ctx context.Context, query string, pageSize int32, pageToken string) ([]*domain.Product, string, error)
 
 	// UpdateProduct updates a product
 	UpdateProduct(ctx context.Context, product *domain.Product) (*domain.Product, error)
 
-	// DeleteProduct deletes a product by ID
+	// PatchProduct sets the fields of a product the patch holds and returns the updated
+	// product; no other field is written. Fields are not validated here.
+	PatchProduct(ctx context.Context, productID string, patch domain.ProductPatch) (*domain.Product, error)
+
+	// DeleteProduct soft-deletes a product by ID
 	DeleteProduct(ctx context.Context, productID string) error
+
+	// DeleteProducts soft-deletes the products with the given IDs, returning how many
+	// were deleted and the error for each ID that could not be
+	DeleteProducts(ctx context.Context, productIDs []string) (int64, map[string]error)
+
+	// SetStatusByCategory sets the status of every product in a category in one statement
+	// and returns the IDs of the products whose status changed. Activated products without
+	// stock go out of stock instead.
+	SetStatusByCategory(ctx context.Context, category string, status domain.ProductStatus) ([]string, error)
+
+	// ReserveStock decrements the stock of each product in one transaction, failing
+	// without changes if any product is missing or has too little stock
+	ReserveStock(ctx context.Context, changes []domain.StockChange) error
+
+	// ReleaseStock increments the stock of each product in one transaction
+	ReleaseStock(ctx context.Context, changes []domain.StockChange) error
+
+	// SetStock sets the stock of a product, moving its status in and out of stock,
+	// and returns the updated product; no other field is written
+	SetStock(ctx context.Context, productID string, stock int32) (*domain.Product, error)
+
+	// RecordProductRating stores a product's rating aggregate unless a newer one is already
+	// stored, and returns the product; no other field is written
+	RecordProductRating(ctx context.Context, productID string, rating domain.RatingAggregate) (*domain.Product, error)
 }
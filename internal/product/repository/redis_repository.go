@@ -1,35 +1,250 @@
 package repository
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
-	"github.com/redis/go-redis/v9"
+	"errors"
+	"fmt"
+	"go-bootiful-ordering/internal/pkg/cache"
 	"go-bootiful-ordering/internal/product/domain"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+	"math/rand"
+	"strconv"
+	"strings"
 	"time"
 )
 
 const (
-	// Default cache expiration time
+	// Default cache expiration time of products and list pages
 	defaultCacheTTL = 30 * time.Minute
 
+	// maxTTLJitterPercent bounds the TTL jitter, so entries never expire in less than half their TTL
+	maxTTLJitterPercent = 50
+
+	// staleRefreshTimeout bounds the background refresh of a stale cache entry
+	staleRefreshTimeout = 5 * time.Second
+
 	// Key prefixes for Redis
 	productKeyPrefix  = "product:"
 	categoryKeyPrefix = "category:"
 )
 
-// RedisProductRepository implements ProductRepository using Redis for caching
+// notFoundTombstone is cached under a product key to record that the product does not exist.
+// It is not valid JSON, so it can never be mistaken for a cached product.
+var notFoundTombstone = []byte("!not-found")
+
+// CacheConfig controls the product cache
+type CacheConfig struct {
+	// NegativeTTL is how long a product ID that was not found is remembered as missing;
+	// zero disables negative caching
+	NegativeTTL time.Duration
+	// CategoryTTLs overrides the cache TTL of the products and list pages of a category.
+	// Categories are matched case-insensitively; others use ProductTTL and ListTTL.
+	CategoryTTLs map[string]time.Duration
+	// StaleWindow enables stale-while-revalidate: an entry past its TTL is still served for
+	// this long while one background refresh reloads it. Zero disables it, so expired
+	// entries are misses.
+	StaleWindow time.Duration
+	// ProductTTL and ListTTL are the cache TTLs of products and of list pages; zero uses
+	// the 30 minute default
+	ProductTTL time.Duration
+	ListTTL    time.Duration
+	// TTLJitterPercent moves the TTL of every cache write by up to ± this percent, so
+	// entries cached together do not all expire together; zero disables it
+	TTLJitterPercent int
+}
+
+// Validate checks that not-found entries expire before cached products do, that every
+// TTL is positive and that the jitter is within bounds
+func (c CacheConfig) Validate() error {
+	if c.ProductTTL < 0 || c.ListTTL < 0 {
+		return fmt.Errorf("product cache TTL %s and list cache TTL %s cannot be negative", c.ProductTTL, c.ListTTL)
+	}
+	if c.NegativeTTL < 0 || c.NegativeTTL >= c.productTTL() {
+		return fmt.Errorf("negative cache TTL %s must be between 0 and the product cache TTL %s", c.NegativeTTL, c.productTTL())
+	}
+	if c.TTLJitterPercent < 0 || c.TTLJitterPercent > maxTTLJitterPercent {
+		return fmt.Errorf("cache TTL jitter must be between 0 and %d percent, got %d", maxTTLJitterPercent, c.TTLJitterPercent)
+	}
+	for category, ttl := range c.CategoryTTLs {
+		if ttl <= 0 {
+			return fmt.Errorf("cache TTL of category %q must be positive, got %s", category, ttl)
+		}
+	}
+	if c.StaleWindow < 0 {
+		return fmt.Errorf("cache stale window cannot be negative, got %s", c.StaleWindow)
+	}
+	return nil
+}
+
+// productTTL returns the cache TTL of products outside the category overrides
+func (c CacheConfig) productTTL() time.Duration {
+	if c.ProductTTL > 0 {
+		return c.ProductTTL
+	}
+	return defaultCacheTTL
+}
+
+// listTTL returns the cache TTL of list pages outside the category overrides
+func (c CacheConfig) listTTL() time.Duration {
+	if c.ListTTL > 0 {
+		return c.ListTTL
+	}
+	return defaultCacheTTL
+}
+
+// ttlFor returns the cache TTL of the entries of a category, or fallback when the category
+// has no override
+func (c CacheConfig) ttlFor(category string, fallback time.Duration) time.Duration {
+	if ttl, ok := c.CategoryTTLs[category]; ok {
+		return ttl
+	}
+	// Configuration keys are usually lower-cased by the loader, so fall back to a case-insensitive match
+	for name, ttl := range c.CategoryTTLs {
+		if strings.EqualFold(name, category) {
+			return ttl
+		}
+	}
+	return fallback
+}
+
+// jittered moves ttl by up to ±TTLJitterPercent percent, picked uniformly by rnd in [0, 1)
+func (c CacheConfig) jittered(ttl time.Duration, rnd float64) time.Duration {
+	if c.TTLJitterPercent <= 0 {
+		return ttl
+	}
+	window := ttl * time.Duration(c.TTLJitterPercent) / 100
+	return ttl - window + time.Duration(rnd*float64(2*window))
+}
+
+// expiry returns until when an entry cached with a TTL is fresh and how long the cache
+// keeps it. Entries are only marked with a freshness deadline in stale-while-revalidate
+// mode, where they are kept for the stale window past it.
+func (c CacheConfig) expiry(ttl time.Duration) (*time.Time, time.Duration) {
+	if c.StaleWindow <= 0 {
+		return nil, ttl
+	}
+	freshUntil := time.Now().Add(ttl)
+	return &freshUntil, ttl + c.StaleWindow
+}
+
+// isStale reports whether a cached entry is past its freshness deadline
+func isStale(freshUntil *time.Time) bool {
+	return freshUntil != nil && !time.Now().Before(*freshUntil)
+}
+
+// RedisProductRepository implements ProductRepository using a cache (Redis in production)
 // and delegates to another ProductRepository for persistence
 type RedisProductRepository struct {
-	redis      *redis.Client
+	log        *zap.Logger
+	cache      cache.Cache
 	repository ProductRepository // The underlying repository for persistence
+	config     CacheConfig
+	refreshes  singleflight.Group // Background refreshes of stale entries, by cache key
+	loads      singleflight.Group // Loads of missed entries from the repository, by cache key
+	rnd        func() float64     // Picks the TTL jitter of a write, in [0, 1)
 }
 
 // NewRedisProductRepository creates a new RedisProductRepository
-func NewRedisProductRepository(redis *redis.Client, repository ProductRepository) *RedisProductRepository {
+func NewRedisProductRepository(log *zap.Logger, cache cache.Cache, repository ProductRepository, config CacheConfig) *RedisProductRepository {
 	return &RedisProductRepository{
-		redis:      redis,
+		log:        log,
+		cache:      cache,
 		repository: repository,
+		config:     config,
+		rnd:        rand.Float64,
+	}
+}
+
+// cacheNotFound records that a product does not exist, when negative caching is enabled.
+// Creating or updating the product overwrites the tombstone with the product itself.
+func (r *RedisProductRepository) cacheNotFound(ctx context.Context, productID string) {
+	if r.config.NegativeTTL <= 0 || ctx.Err() != nil {
+		return
 	}
+	if err := r.cache.Set(ctx, productKey(productID), notFoundTombstone, r.config.NegativeTTL); err != nil {
+		r.log.Warn("Failed to cache missing product", zap.Error(err), zap.String("productID", productID))
+	}
+}
+
+// cachedProduct is a product as stored in the cache. FreshUntil is only set in
+// stale-while-revalidate mode.
+type cachedProduct struct {
+	*domain.Product
+	FreshUntil *time.Time `json:"fresh_until,omitempty"`
+}
+
+// cacheProduct stores a product under its key with the TTL of its category
+func (r *RedisProductRepository) cacheProduct(ctx context.Context, product *domain.Product) error {
+	freshUntil, ttl := r.config.expiry(r.config.jittered(r.config.ttlFor(product.Category, r.config.productTTL()), r.rnd()))
+	productJSON, err := json.Marshal(cachedProduct{Product: product, FreshUntil: freshUntil})
+	if err != nil {
+		return err
+	}
+	return r.cache.Set(ctx, productKey(product.ID), productJSON, ttl)
+}
+
+// revalidate runs refresh in the background to replace a stale cache entry. Only one
+// refresh runs per key at a time, however many requests are served the stale entry
+// meanwhile; it outlives the request that triggered it.
+func (r *RedisProductRepository) revalidate(ctx context.Context, key string, refresh func(ctx context.Context) error) {
+	ctx = context.WithoutCancel(ctx)
+	r.refreshes.DoChan(key, func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(ctx, staleRefreshTimeout)
+		defer cancel()
+		if err := refresh(ctx); err != nil {
+			r.log.Warn("Failed to refresh stale cache entry", zap.Error(err), zap.String("key", key))
+		}
+		return nil, nil
+	})
+}
+
+// loadShared runs load once for the concurrent callers missing the same key, so a cold
+// key sends a single query to the repository and the others share its result. The load is
+// not cancelled when the caller that started it goes away, only when its deadline passes;
+// every caller stops waiting when its own context ends, and one whose context has already
+// ended starts no load.
+func (r *RedisProductRepository) loadShared(ctx context.Context, key string, load func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	results := r.loads.DoChan(key, func() (interface{}, error) {
+		loadCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		if deadline, ok := ctx.Deadline(); ok {
+			loadCtx, cancel = context.WithDeadline(loadCtx, deadline)
+		}
+		defer cancel()
+		return load(loadCtx)
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result := <-results:
+		return result.Val, result.Err
+	}
+}
+
+// revalidateProduct reloads a stale cached product from the repository in the background.
+// A product deleted meanwhile is dropped from the cache, or remembered as missing.
+func (r *RedisProductRepository) revalidateProduct(ctx context.Context, productID string) {
+	r.revalidate(ctx, productKey(productID), func(ctx context.Context) error {
+		product, err := r.repository.GetProduct(ctx, productID)
+		if errors.Is(err, domain.ErrProductNotFound) {
+			if r.config.NegativeTTL > 0 {
+				r.cacheNotFound(ctx, productID)
+				return nil
+			}
+			return r.cache.Del(ctx, productKey(productID))
+		}
+		if err != nil {
+			return err
+		}
+		return r.cacheProduct(ctx, product)
+	})
 }
 
 // productKey generates a Redis key for a product
@@ -37,9 +252,112 @@ func productKey(productID string) string {
 	return productKeyPrefix + productID
 }
 
-// categoryKey generates a Redis key for a category
-func categoryKey(category string, pageSize int32, pageToken string) string {
-	return categoryKeyPrefix + category + ":" + string(pageSize) + ":" + pageToken
+// categoryKey generates a Redis key for a page of a category, e.g. "category:books:10:price.asc.100-:<token>".
+// The category comes first so that invalidating a category also drops its sorted and filtered pages.
+func categoryKey(category string, opts domain.ProductListOptions, pageSize int32, pageToken string) string {
+	return categoryKeyPrefix + category + ":" + strconv.Itoa(int(pageSize)) + ":" + listOptionsKey(opts) + ":" + pageToken
+}
+
+// listOptionsKey encodes the ordering and price range of a listing for its cache key
+func listOptionsKey(opts domain.ProductListOptions) string {
+	priceBound := func(price *int64) string {
+		if price == nil {
+			return ""
+		}
+		return strconv.FormatInt(*price, 10)
+	}
+	return string(opts.SortBy) + "." + string(opts.SortDir) + "." + priceBound(opts.MinPrice) + "-" + priceBound(opts.MaxPrice)
+}
+
+// globEscaper escapes the characters that are special in cache key patterns
+var globEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`, "]", `\]`)
+
+// invalidateLists deletes the cached list pages matching a key pattern. Invalidation is
+// best-effort: a failure is logged and the pages expire with their TTL.
+func (r *RedisProductRepository) invalidateLists(ctx context.Context, pattern string) {
+	keys, err := r.cache.Scan(ctx, pattern)
+	if err != nil {
+		r.log.Warn("Failed to scan cached product lists", zap.Error(err), zap.String("pattern", pattern))
+		return
+	}
+
+	if len(keys) == 0 {
+		return
+	}
+
+	if err := r.cache.Del(ctx, keys...); err != nil {
+		r.log.Warn("Failed to invalidate cached product lists", zap.Error(err), zap.String("pattern", pattern))
+	}
+}
+
+// invalidateCategoryLists deletes the cached pages of the given categories and, once, of the
+// unfiltered listing, which shows the products of every category
+func (r *RedisProductRepository) invalidateCategoryLists(ctx context.Context, categories ...string) {
+	invalidated := map[string]bool{"": true}
+	for _, category := range categories {
+		if !invalidated[category] {
+			invalidated[category] = true
+			r.invalidateLists(ctx, categoryKeyPrefix+globEscaper.Replace(category)+":*")
+		}
+	}
+	r.invalidateLists(ctx, categoryKeyPrefix+":*")
+}
+
+// invalidateAllLists deletes every cached list page, for changes whose categories could not be read
+func (r *RedisProductRepository) invalidateAllLists(ctx context.Context) {
+	r.invalidateLists(ctx, categoryKeyPrefix+"*")
+}
+
+// productCategories returns the categories of the given products, read from the cached
+// products and, for the misses, from the repository, so that a write only invalidates the
+// list pages that can show them. It reports false when they could not be read, and the
+// caller then invalidates every list page. Products that exist nowhere have no category.
+func (r *RedisProductRepository) productCategories(ctx context.Context, productIDs []string) ([]string, bool) {
+	keys := make([]string, len(productIDs))
+	for i, productID := range productIDs {
+		keys[i] = productKey(productID)
+	}
+	values, err := r.cache.MGet(ctx, keys...)
+	if err != nil {
+		values = make([][]byte, len(keys))
+	}
+
+	var categories, missing []string
+	for i, value := range values {
+		if bytes.Equal(value, notFoundTombstone) {
+			continue
+		}
+		// A product's key is dropped whenever its category changes, so a cached product,
+		// even a stale one, is in the category it is cached with
+		var cached cachedProduct
+		if value != nil && json.Unmarshal(value, &cached) == nil && cached.Product != nil {
+			categories = append(categories, cached.Category)
+			continue
+		}
+		missing = append(missing, productIDs[i])
+	}
+
+	if len(missing) > 0 {
+		loaded, err := r.repository.GetProducts(ctx, missing)
+		if err != nil {
+			r.log.Warn("Failed to read the categories of changed products", zap.Error(err))
+			return nil, false
+		}
+		for _, product := range loaded {
+			categories = append(categories, product.Category)
+		}
+	}
+	return categories, true
+}
+
+// invalidateProductLists deletes the cached list pages of the given categories, or every
+// list page when they are not known
+func (r *RedisProductRepository) invalidateProductLists(ctx context.Context, categories []string, known bool) {
+	if !known {
+		r.invalidateAllLists(ctx)
+		return
+	}
+	r.invalidateCategoryLists(ctx, categories...)
 }
 
 // CreateProduct persists a new product and invalidates cache
@@ -50,143 +368,372 @@ func (r *RedisProductRepository) CreateProduct(ctx context.Context, product *dom
 		return nil, err
 	}
 
-	// Cache the created product
-	productJSON, err := json.Marshal(createdProduct)
+	// The new product belongs on the pages of its category and of the unfiltered listing
+	r.invalidateCategoryLists(ctx, createdProduct.Category)
+
+	// Cache the created product; return the product even if caching fails
+	_ = r.cacheProduct(ctx, createdProduct)
+
+	return createdProduct, nil
+}
+
+// CreateProducts persists new products and invalidates the lists of their categories.
+// The products themselves are cached on their first read; any tombstone left by reading
+// one of their IDs before is dropped.
+func (r *RedisProductRepository) CreateProducts(ctx context.Context, products []*domain.Product) ([]*domain.Product, error) {
+	// Delegate to the underlying repository
+	created, err := r.repository.CreateProducts(ctx, products)
 	if err != nil {
-		return createdProduct, nil // Return the product even if caching fails
+		return nil, err
 	}
 
-	// Store in Redis with expiration
-	err = r.redis.Set(ctx, productKey(createdProduct.ID), productJSON, defaultCacheTTL).Err()
-	if err != nil {
-		return createdProduct, nil // Return the product even if caching fails
+	// Each category's pages are invalidated once, however many products it gained
+	categories := make([]string, len(created))
+	for i, product := range created {
+		categories[i] = product.Category
 	}
+	r.invalidateCategoryLists(ctx, categories...)
 
-	return createdProduct, nil
+	keys := make([]string, len(created))
+	for i, product := range created {
+		keys[i] = productKey(product.ID)
+	}
+	if err := r.cache.Del(ctx, keys...); err != nil {
+		r.log.Warn("Failed to drop cached products", zap.Error(err), zap.Int("count", len(keys)))
+	}
+
+	return created, nil
 }
 
 // GetProduct retrieves a product by ID, using cache if available
 func (r *RedisProductRepository) GetProduct(ctx context.Context, productID string) (*domain.Product, error) {
 	// Try to get from cache first
-	productJSON, err := r.redis.Get(ctx, productKey(productID)).Bytes()
+	productJSON, err := r.cache.Get(ctx, productKey(productID))
 	if err == nil {
-		// Cache hit
-		var product domain.Product
-		if err := json.Unmarshal(productJSON, &product); err == nil {
-			return &product, nil
+		// The product is known not to exist
+		if bytes.Equal(productJSON, notFoundTombstone) {
+			return nil, domain.ErrProductNotFound
+		}
+
+		// Cache hit; a stale product is served while it is refreshed, or treated as a miss
+		// when stale-while-revalidate is off
+		var cached cachedProduct
+		if err := json.Unmarshal(productJSON, &cached); err == nil && cached.Product != nil {
+			if !isStale(cached.FreshUntil) {
+				return cached.Product, nil
+			}
+			if r.config.StaleWindow > 0 {
+				r.revalidateProduct(ctx, productID)
+				return cached.Product, nil
+			}
 		}
 		// If unmarshaling fails, fall through to get from repository
+	} else if !errors.Is(err, cache.ErrMiss) {
+		// A read failing because the caller has gone away is not a miss, so the database
+		// is not queried for it; other cache errors fall back to the repository
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
 	}
 
-	// Cache miss or error, get from repository
-	product, err := r.repository.GetProduct(ctx, productID)
+	// Cache miss or error, get from repository; concurrent misses share one load
+	loaded, err := r.loadShared(ctx, productKey(productID), func(ctx context.Context) (interface{}, error) {
+		product, err := r.repository.GetProduct(ctx, productID)
+		if err != nil {
+			if errors.Is(err, domain.ErrProductNotFound) {
+				r.cacheNotFound(ctx, productID)
+			}
+			return nil, err
+		}
+
+		// Cache the product for future requests unless the load ran out of time;
+		// return the product even if caching fails
+		if ctx.Err() == nil {
+			_ = r.cacheProduct(ctx, product)
+		}
+		return product, nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// Cache the product for future requests
-	productJSON, err = json.Marshal(product)
+	// Every caller gets its own copy of the shared product, as callers may modify it
+	product := *loaded.(*domain.Product)
+	return &product, nil
+}
+
+// GetProductIncludingDeleted retrieves a product by ID even if it has been deleted.
+// Deleted products are rarely read, so this bypasses the cache.
+func (r *RedisProductRepository) GetProductIncludingDeleted(ctx context.Context, productID string) (*domain.Product, error) {
+	return r.repository.GetProductIncludingDeleted(ctx, productID)
+}
+
+// GetProducts retrieves the products with the given IDs, reading cached products with
+// one MGET and loading only the misses from the repository
+func (r *RedisProductRepository) GetProducts(ctx context.Context, productIDs []string) (map[string]*domain.Product, error) {
+	products := make(map[string]*domain.Product, len(productIDs))
+	if len(productIDs) == 0 {
+		return products, nil
+	}
+
+	// Try the cache first
+	keys := make([]string, len(productIDs))
+	for i, productID := range productIDs {
+		keys[i] = productKey(productID)
+	}
+	values, err := r.cache.MGet(ctx, keys...)
 	if err != nil {
-		return product, nil // Return the product even if caching fails
+		// A read failing because the caller has gone away is not a miss, so the database
+		// is not queried for it; other cache errors fall back to the repository
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		values = make([][]byte, len(keys))
+	}
+
+	var missing []string
+	for i, value := range values {
+		// Known missing products are left out, as the repository would
+		if bytes.Equal(value, notFoundTombstone) {
+			continue
+		}
+
+		var cached cachedProduct
+		if value != nil && json.Unmarshal(value, &cached) == nil && cached.Product != nil {
+			stale := isStale(cached.FreshUntil)
+			if stale && r.config.StaleWindow > 0 {
+				r.revalidateProduct(ctx, productIDs[i])
+			}
+			if !stale || r.config.StaleWindow > 0 {
+				products[cached.ID] = cached.Product
+				continue
+			}
+		}
+		missing = append(missing, productIDs[i])
 	}
 
-	// Store in Redis with expiration
-	err = r.redis.Set(ctx, productKey(product.ID), productJSON, defaultCacheTTL).Err()
+	if len(missing) == 0 {
+		return products, nil
+	}
+
+	// Load the misses from the repository, unless the caller has gone away
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	loaded, err := r.repository.GetProducts(ctx, missing)
 	if err != nil {
-		return product, nil // Return the product even if caching fails
+		return nil, err
 	}
 
-	return product, nil
+	for productID, product := range loaded {
+		products[productID] = product
+
+		// Cache the product for future requests; failures only cost a later miss
+		if ctx.Err() != nil {
+			continue
+		}
+		_ = r.cacheProduct(ctx, product)
+	}
+
+	// Remember the products the repository did not find either
+	for _, productID := range missing {
+		if _, ok := loaded[productID]; !ok {
+			r.cacheNotFound(ctx, productID)
+		}
+	}
+
+	return products, nil
+}
+
+// cachedProductList is a page of products as stored in the cache
+type cachedProductList struct {
+	Products      []*domain.Product
+	NextPageToken string
+	// FreshUntil is only set in stale-while-revalidate mode
+	FreshUntil *time.Time `json:",omitempty"`
+}
+
+// validate rejects a cached page that parsed but cannot be what was stored, such as a
+// blob written by an older version with different field names
+func (l cachedProductList) validate() error {
+	// Pages are stored with a products array, even an empty one
+	if l.Products == nil {
+		return errors.New("cached product list has no products array")
+	}
+	for i, product := range l.Products {
+		if product == nil || product.ID == "" {
+			return fmt.Errorf("cached product %d has no ID", i)
+		}
+	}
+	return nil
+}
+
+// dropCorruptList deletes a cached list page that could not be served, so the next
+// request caches a fresh page instead of reading the bad one again
+func (r *RedisProductRepository) dropCorruptList(ctx context.Context, cacheKey string, reason error) {
+	r.log.Warn("Discarding corrupt cached product list", zap.Error(reason), zap.String("key", cacheKey))
+	if err := r.cache.Del(ctx, cacheKey); err != nil {
+		r.log.Warn("Failed to delete corrupt cached product list", zap.Error(err), zap.String("key", cacheKey))
+	}
+}
+
+// cacheList stores a page of products under its key with the TTL of its category
+func (r *RedisProductRepository) cacheList(ctx context.Context, cacheKey, category string, products []*domain.Product, nextPageToken string) error {
+	freshUntil, ttl := r.config.expiry(r.config.jittered(r.config.ttlFor(category, r.config.listTTL()), r.rnd()))
+	cacheData, err := json.Marshal(cachedProductList{
+		Products:      products,
+		NextPageToken: nextPageToken,
+		FreshUntil:    freshUntil,
+	})
+	if err != nil {
+		return err
+	}
+	return r.cache.Set(ctx, cacheKey, cacheData, ttl)
 }
 
 // ListProducts retrieves a list of products with pagination, using cache if available
-func (r *RedisProductRepository) ListProducts(ctx context.Context, category string, pageSize int32, pageToken string) ([]*domain.Product, string, error) {
+func (r *RedisProductRepository) ListProducts(ctx context.Context, category string, opts domain.ProductListOptions, pageSize int32, pageToken string) ([]*domain.Product, string, error) {
 	// Generate cache key for this query
-	cacheKey := categoryKey(category, pageSize, pageToken)
+	cacheKey := categoryKey(category, opts, pageSize, pageToken)
 
 	// Try to get from cache first
-	cacheData, err := r.redis.Get(ctx, cacheKey).Bytes()
+	cacheData, err := r.cache.Get(ctx, cacheKey)
 	if err == nil {
 		// Cache hit
-		var cacheResult struct {
-			Products      []*domain.Product
-			NextPageToken string
+		var cacheResult cachedProductList
+		err := json.Unmarshal(cacheData, &cacheResult)
+		if err == nil {
+			err = cacheResult.validate()
 		}
-		if err := json.Unmarshal(cacheData, &cacheResult); err == nil {
+		switch {
+		case err != nil:
+			// Drop a page that cannot be served and fall through to the repository
+			r.dropCorruptList(ctx, cacheKey, err)
+		case !isStale(cacheResult.FreshUntil):
+			return cacheResult.Products, cacheResult.NextPageToken, nil
+		case r.config.StaleWindow > 0:
+			// Serve the stale page while it is reloaded in the background
+			r.revalidate(ctx, cacheKey, func(ctx context.Context) error {
+				products, nextPageToken, err := r.repository.ListProducts(ctx, category, opts, pageSize, pageToken)
+				if err != nil {
+					return err
+				}
+				return r.cacheList(ctx, cacheKey, category, products, nextPageToken)
+			})
 			return cacheResult.Products, cacheResult.NextPageToken, nil
 		}
-		// If unmarshaling fails, fall through to get from repository
+	} else if !errors.Is(err, cache.ErrMiss) {
+		// A read failing because the caller has gone away is not a miss, so the database
+		// is not queried for it; other cache errors fall back to the repository
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, "", ctxErr
+		}
 	}
 
-	// Cache miss or error, get from repository
-	products, nextPageToken, err := r.repository.ListProducts(ctx, category, pageSize, pageToken)
+	// Cache miss or error, get from repository; concurrent misses share one load
+	loaded, err := r.loadShared(ctx, cacheKey, func(ctx context.Context) (interface{}, error) {
+		products, nextPageToken, err := r.repository.ListProducts(ctx, category, opts, pageSize, pageToken)
+		if err != nil {
+			return nil, err
+		}
+
+		// Cache the results for future requests unless the load ran out of time;
+		// return the products even if caching fails
+		if ctx.Err() == nil {
+			_ = r.cacheList(ctx, cacheKey, category, products, nextPageToken)
+		}
+		return cachedProductList{Products: products, NextPageToken: nextPageToken}, nil
+	})
 	if err != nil {
 		return nil, "", err
 	}
 
-	// Cache the results for future requests
-	cacheResult := struct {
-		Products      []*domain.Product
-		NextPageToken string
-	}{
-		Products:      products,
-		NextPageToken: nextPageToken,
-	}
-
-	cacheData, err = json.Marshal(cacheResult)
-	if err != nil {
-		return products, nextPageToken, nil // Return the products even if caching fails
+	// Every caller gets its own copy of the shared page, as callers may modify it
+	page := loaded.(cachedProductList)
+	products := make([]*domain.Product, len(page.Products))
+	for i, product := range page.Products {
+		product := *product
+		products[i] = &product
 	}
+	return products, page.NextPageToken, nil
+}
 
-	// Store in Redis with expiration
-	err = r.redis.Set(ctx, cacheKey, cacheData, defaultCacheTTL).Err()
-	if err != nil {
-		return products, nextPageToken, nil // Return the products even if caching fails
-	}
+// CountProducts counts products on the underlying repository. Counts are not cached:
+// every write would have to invalidate them, and they are only run when asked for.
+func (r *RedisProductRepository) CountProducts(ctx context.Context, category string, opts domain.ProductListOptions) (int64, error) {
+	return r.repository.CountProducts(ctx, category, opts)
+}
 
-	return products, nextPageToken, nil
+// SearchProducts runs a text search on the underlying repository. Results are not
+// cached, since free-text queries are too varied to hit often.
+func (r *RedisProductRepository) SearchProducts(ctx context.Context, query string, pageSize int32, pageToken string) ([]*domain.Product, string, error) {
+	return r.repository.SearchProducts(ctx, query, pageSize, pageToken)
 }
 
 // UpdateProduct updates a product and invalidates cache
 func (r *RedisProductRepository) UpdateProduct(ctx context.Context, product *domain.Product) (*domain.Product, error) {
+	// The product may move to another category, so note the one it leaves
+	categories, known := r.productCategories(ctx, []string{product.ID})
+
 	// Delegate to the underlying repository
 	updatedProduct, err := r.repository.UpdateProduct(ctx, product)
 	if err != nil {
+		// The cached product may be the stale one the update was based on; drop it so the
+		// client's next read returns the current version
+		if errors.Is(err, domain.ErrVersionConflict) {
+			_ = r.cache.Del(ctx, productKey(product.ID))
+		}
 		return nil, err
 	}
 
+	// Invalidate the pages of the category the product left and of the one it is in
+	r.invalidateProductLists(ctx, append(categories, updatedProduct.Category), known)
+
 	// Invalidate the cache for this product
-	err = r.redis.Del(ctx, productKey(updatedProduct.ID)).Err()
+	err = r.cache.Del(ctx, productKey(updatedProduct.ID))
 	if err != nil {
 		// Log the error but continue
 		// In a real implementation, you might want to log this error
 	}
 
-	// Cache the updated product
-	productJSON, err := json.Marshal(updatedProduct)
-	if err != nil {
-		return updatedProduct, nil // Return the product even if caching fails
-	}
+	// Cache the updated product; return the product even if caching fails
+	_ = r.cacheProduct(ctx, updatedProduct)
 
-	// Store in Redis with expiration
-	err = r.redis.Set(ctx, productKey(updatedProduct.ID), productJSON, defaultCacheTTL).Err()
+	return updatedProduct, nil
+}
+
+// PatchProduct applies a partial update and invalidates the product and the list pages of
+// the category it was in and the one it is in, as the patch may move it between them
+func (r *RedisProductRepository) PatchProduct(ctx context.Context, productID string, patch domain.ProductPatch) (*domain.Product, error) {
+	categories, known := r.productCategories(ctx, []string{productID})
+
+	product, err := r.repository.PatchProduct(ctx, productID, patch)
 	if err != nil {
-		return updatedProduct, nil // Return the product even if caching fails
+		return nil, err
 	}
 
-	return updatedProduct, nil
+	if err := r.cache.Del(ctx, productKey(productID)); err != nil {
+		r.log.Warn("Failed to invalidate cached product", zap.Error(err), zap.String("productID", productID))
+	}
+	r.invalidateProductLists(ctx, append(categories, product.Category), known)
+	return product, nil
 }
 
 // DeleteProduct deletes a product and invalidates cache
 func (r *RedisProductRepository) DeleteProduct(ctx context.Context, productID string) error {
+	// Read the product's category while it can still be read
+	categories, known := r.productCategories(ctx, []string{productID})
+
 	// Delegate to the underlying repository
 	err := r.repository.DeleteProduct(ctx, productID)
 	if err != nil {
 		return err
 	}
 
+	r.invalidateProductLists(ctx, categories, known)
+
 	// Invalidate the cache for this product
-	err = r.redis.Del(ctx, productKey(productID)).Err()
+	err = r.cache.Del(ctx, productKey(productID))
 	if err != nil {
 		// Log the error but continue
 		// In a real implementation, you might want to log this error
@@ -194,3 +741,117 @@ func (r *RedisProductRepository) DeleteProduct(ctx context.Context, productID st
 
 	return nil
 }
+
+// DeleteProducts deletes products and invalidates their cache entries with a single call
+func (r *RedisProductRepository) DeleteProducts(ctx context.Context, productIDs []string) (int64, map[string]error) {
+	// Read the products' categories while they can still be read
+	categories, known := r.productCategories(ctx, productIDs)
+
+	// Delegate to the underlying repository
+	deleted, failed := r.repository.DeleteProducts(ctx, productIDs)
+	if deleted == 0 {
+		return deleted, failed
+	}
+
+	// Invalidate the cache for the deleted products
+	keys := make([]string, 0, len(productIDs))
+	for _, id := range productIDs {
+		if _, ok := failed[id]; !ok {
+			keys = append(keys, productKey(id))
+		}
+	}
+	_ = r.cache.Del(ctx, keys...) // The products are gone even if invalidation fails; entries expire with their TTL
+	r.invalidateProductLists(ctx, categories, known)
+
+	return deleted, failed
+}
+
+// SetStatusByCategory sets the status of a category's products and invalidates the changed
+// products and the category's list pages, which may filter on their status
+func (r *RedisProductRepository) SetStatusByCategory(ctx context.Context, category string, status domain.ProductStatus) ([]string, error) {
+	productIDs, err := r.repository.SetStatusByCategory(ctx, category, status)
+	if err != nil || len(productIDs) == 0 {
+		return productIDs, err
+	}
+
+	keys := make([]string, len(productIDs))
+	for i, id := range productIDs {
+		keys[i] = productKey(id)
+	}
+	if err := r.cache.Del(ctx, keys...); err != nil {
+		r.log.Warn("Failed to invalidate cached products", zap.Error(err), zap.String("category", category))
+	}
+	r.invalidateCategoryLists(ctx, category)
+
+	return productIDs, nil
+}
+
+// ReserveStock decrements stock and invalidates the cached products and the list pages of
+// their categories
+func (r *RedisProductRepository) ReserveStock(ctx context.Context, changes []domain.StockChange) error {
+	if err := r.repository.ReserveStock(ctx, changes); err != nil {
+		return err
+	}
+
+	r.invalidateStock(ctx, changes)
+	return nil
+}
+
+// ReleaseStock increments stock and invalidates the cached products and the list pages of
+// their categories
+func (r *RedisProductRepository) ReleaseStock(ctx context.Context, changes []domain.StockChange) error {
+	if err := r.repository.ReleaseStock(ctx, changes); err != nil {
+		return err
+	}
+
+	r.invalidateStock(ctx, changes)
+	return nil
+}
+
+// SetStock sets a product's stock and invalidates the product and the list pages of its
+// category, which may show its stock or filter on its status
+func (r *RedisProductRepository) SetStock(ctx context.Context, productID string, stock int32) (*domain.Product, error) {
+	product, err := r.repository.SetStock(ctx, productID, stock)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.cache.Del(ctx, productKey(productID)); err != nil {
+		r.log.Warn("Failed to invalidate cached product", zap.Error(err), zap.String("productID", productID))
+	}
+	r.invalidateCategoryLists(ctx, product.Category)
+	return product, nil
+}
+
+// RecordProductRating stores a product's rating aggregate and invalidates the product and
+// the list pages of its category, which show its rating
+func (r *RedisProductRepository) RecordProductRating(ctx context.Context, productID string, rating domain.RatingAggregate) (*domain.Product, error) {
+	product, err := r.repository.RecordProductRating(ctx, productID, rating)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.cache.Del(ctx, productKey(productID)); err != nil {
+		r.log.Warn("Failed to invalidate cached product", zap.Error(err), zap.String("productID", productID))
+	}
+	r.invalidateCategoryLists(ctx, product.Category)
+	return product, nil
+}
+
+// invalidateStock evicts the cache entries showing the stock of the changed products: the
+// products and the list pages of their categories. Stock changes leave categories alone,
+// so the categories are read before the products are evicted.
+func (r *RedisProductRepository) invalidateStock(ctx context.Context, changes []domain.StockChange) {
+	productIDs := make([]string, len(changes))
+	keys := make([]string, len(changes))
+	for i, change := range changes {
+		productIDs[i] = change.ProductID
+		keys[i] = productKey(change.ProductID)
+	}
+	categories, known := r.productCategories(ctx, productIDs)
+
+	if err := r.cache.Del(ctx, keys...); err != nil {
+		r.log.Warn("Failed to invalidate cached products", zap.Error(err))
+	}
+	r.invalidateProductLists(ctx, categories, known)
+}
@@ -0,0 +1,956 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"testing"
+	"time"
+
+	"go-bootiful-ordering/internal/pkg/cache"
+	"go-bootiful-ordering/internal/product/domain"
+	"go.uber.org/zap"
+)
+
+// fakeProductRepository serves products from a map and counts the reads reaching it; the
+// methods it does not implement are not expected to be called
+type fakeProductRepository struct {
+	ProductRepository
+
+	mu       sync.Mutex
+	products map[string]*domain.Product
+	gets     int
+	lists    int
+	// load, when set, runs inside every read, e.g. to hold reads until a test releases them
+	load func()
+}
+
+func newFakeProductRepository(products ...*domain.Product) *fakeProductRepository {
+	repo := &fakeProductRepository{products: make(map[string]*domain.Product)}
+	for _, product := range products {
+		repo.products[product.ID] = product
+	}
+	return repo
+}
+
+func (r *fakeProductRepository) read(count *int) {
+	r.mu.Lock()
+	*count++
+	load := r.load
+	r.mu.Unlock()
+	if load != nil {
+		load()
+	}
+}
+
+func (r *fakeProductRepository) counts() (gets, lists int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.gets, r.lists
+}
+
+func (r *fakeProductRepository) GetProduct(ctx context.Context, productID string) (*domain.Product, error) {
+	r.read(&r.gets)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	product, ok := r.products[productID]
+	if !ok {
+		return nil, domain.ErrProductNotFound
+	}
+	copied := *product
+	return &copied, nil
+}
+
+func (r *fakeProductRepository) ListProducts(ctx context.Context, category string, opts domain.ProductListOptions, pageSize int32, pageToken string) ([]*domain.Product, string, error) {
+	r.read(&r.lists)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var products []*domain.Product
+	for _, product := range r.products {
+		if (category == "" || product.Category == category) && int32(len(products)) < pageSize {
+			copied := *product
+			products = append(products, &copied)
+		}
+	}
+	return products, "", nil
+}
+
+func (r *fakeProductRepository) CreateProduct(ctx context.Context, product *domain.Product) (*domain.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.products[product.ID] = product
+	return product, nil
+}
+
+func (r *fakeProductRepository) CreateProducts(ctx context.Context, products []*domain.Product) ([]*domain.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, product := range products {
+		r.products[product.ID] = product
+	}
+	return products, nil
+}
+
+func (r *fakeProductRepository) UpdateProduct(ctx context.Context, product *domain.Product) (*domain.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if existing, ok := r.products[product.ID]; ok && product.Version != 0 && product.Version != existing.Version {
+		return nil, domain.ErrVersionConflict
+	}
+	r.products[product.ID] = product
+	return product, nil
+}
+
+func (r *fakeProductRepository) DeleteProduct(ctx context.Context, productID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.products, productID)
+	return nil
+}
+
+func (r *fakeProductRepository) DeleteProducts(ctx context.Context, productIDs []string) (int64, map[string]error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var deleted int64
+	failed := make(map[string]error)
+	for _, productID := range productIDs {
+		if _, ok := r.products[productID]; !ok {
+			failed[productID] = domain.ErrProductNotFound
+			continue
+		}
+		delete(r.products, productID)
+		deleted++
+	}
+	return deleted, failed
+}
+
+func (r *fakeProductRepository) GetProducts(ctx context.Context, productIDs []string) (map[string]*domain.Product, error) {
+	r.read(&r.gets)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	products := make(map[string]*domain.Product, len(productIDs))
+	for _, productID := range productIDs {
+		if product, ok := r.products[productID]; ok {
+			copied := *product
+			products[productID] = &copied
+		}
+	}
+	return products, nil
+}
+
+func (r *fakeProductRepository) changeStock(changes []domain.StockChange, sign int32) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, change := range changes {
+		product, ok := r.products[change.ProductID]
+		if !ok {
+			return domain.ErrProductNotFound
+		}
+		product.Stock += sign * change.Quantity
+	}
+	return nil
+}
+
+func (r *fakeProductRepository) ReserveStock(ctx context.Context, changes []domain.StockChange) error {
+	return r.changeStock(changes, -1)
+}
+
+func (r *fakeProductRepository) ReleaseStock(ctx context.Context, changes []domain.StockChange) error {
+	return r.changeStock(changes, 1)
+}
+
+func (r *fakeProductRepository) SetStock(ctx context.Context, productID string, stock int32) (*domain.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	product, ok := r.products[productID]
+	if !ok {
+		return nil, domain.ErrProductNotFound
+	}
+	product.Stock = stock
+	copied := *product
+	return &copied, nil
+}
+
+func newTestRepository(repo ProductRepository, config CacheConfig) (*RedisProductRepository, *cache.MemoryCache) {
+	memoryCache := cache.NewMemoryCache()
+	return NewRedisProductRepository(zap.NewNop(), memoryCache, repo, config), memoryCache
+}
+
+func TestGetProductCachesMisses(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeProductRepository(&domain.Product{ID: "p1", Name: "Book", Category: "books"})
+	r, memoryCache := newTestRepository(repo, CacheConfig{})
+
+	for i := 0; i < 3; i++ {
+		product, err := r.GetProduct(ctx, "p1")
+		if err != nil || product.Name != "Book" {
+			t.Fatalf("GetProduct() = %+v, %v", product, err)
+		}
+	}
+
+	if gets, _ := repo.counts(); gets != 1 {
+		t.Errorf("repository GetProduct called %d times, want 1 for a miss then hits", gets)
+	}
+	if _, err := memoryCache.Get(ctx, productKey("p1")); err != nil {
+		t.Errorf("product not cached after a miss: %v", err)
+	}
+}
+
+func TestGetProductCachesNotFound(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeProductRepository()
+	r, _ := newTestRepository(repo, CacheConfig{NegativeTTL: defaultCacheTTL / 2})
+
+	for i := 0; i < 2; i++ {
+		if _, err := r.GetProduct(ctx, "missing"); !errors.Is(err, domain.ErrProductNotFound) {
+			t.Fatalf("GetProduct() error = %v, want ErrProductNotFound", err)
+		}
+	}
+	if gets, _ := repo.counts(); gets != 1 {
+		t.Errorf("repository GetProduct called %d times, want 1 with negative caching", gets)
+	}
+}
+
+func TestWritesClearNotFoundTombstone(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(ctx context.Context, r *RedisProductRepository, product *domain.Product) error
+	}{
+		{
+			name: "create",
+			write: func(ctx context.Context, r *RedisProductRepository, product *domain.Product) error {
+				_, err := r.CreateProduct(ctx, product)
+				return err
+			},
+		},
+		{
+			name: "batch create",
+			write: func(ctx context.Context, r *RedisProductRepository, product *domain.Product) error {
+				_, err := r.CreateProducts(ctx, []*domain.Product{product})
+				return err
+			},
+		},
+		{
+			name: "update",
+			write: func(ctx context.Context, r *RedisProductRepository, product *domain.Product) error {
+				_, err := r.UpdateProduct(ctx, product)
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newFakeProductRepository()
+			r, memoryCache := newTestRepository(repo, CacheConfig{NegativeTTL: defaultCacheTTL / 2})
+
+			if _, err := r.GetProduct(ctx, "p1"); !errors.Is(err, domain.ErrProductNotFound) {
+				t.Fatalf("GetProduct() error = %v, want ErrProductNotFound", err)
+			}
+			if cached, err := memoryCache.Get(ctx, productKey("p1")); err != nil || string(cached) != string(notFoundTombstone) {
+				t.Fatalf("cached %q, %v for the missing product, want its tombstone", cached, err)
+			}
+
+			if err := tt.write(ctx, r, &domain.Product{ID: "p1", Name: "Book", Category: "books"}); err != nil {
+				t.Fatalf("write error = %v", err)
+			}
+
+			product, err := r.GetProduct(ctx, "p1")
+			if err != nil || product.Name != "Book" {
+				t.Errorf("GetProduct() = %+v, %v after the %s, want the product instead of the tombstone", product, err, tt.name)
+			}
+		})
+	}
+}
+
+func TestUpdateProductReplacesCachedProduct(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeProductRepository(&domain.Product{ID: "p1", Name: "Book", Category: "books"})
+	r, _ := newTestRepository(repo, CacheConfig{})
+
+	if _, err := r.GetProduct(ctx, "p1"); err != nil {
+		t.Fatalf("GetProduct() error = %v", err)
+	}
+	if _, err := r.UpdateProduct(ctx, &domain.Product{ID: "p1", Name: "Renamed", Category: "books"}); err != nil {
+		t.Fatalf("UpdateProduct() error = %v", err)
+	}
+
+	product, err := r.GetProduct(ctx, "p1")
+	if err != nil || product.Name != "Renamed" {
+		t.Errorf("GetProduct() = %+v, %v after the update, want the renamed product", product, err)
+	}
+}
+
+func TestUpdateProductConflictDropsCachedProduct(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeProductRepository(&domain.Product{ID: "p1", Name: "Book", Category: "books", Version: 1})
+	r, _ := newTestRepository(repo, CacheConfig{})
+
+	if _, err := r.GetProduct(ctx, "p1"); err != nil {
+		t.Fatalf("GetProduct() error = %v", err)
+	}
+	// Another instance writes the product, leaving this one's cache at the old version
+	repo.mu.Lock()
+	repo.products["p1"] = &domain.Product{ID: "p1", Name: "Novel", Category: "books", Version: 2}
+	repo.mu.Unlock()
+
+	if _, err := r.UpdateProduct(ctx, &domain.Product{ID: "p1", Name: "Renamed", Category: "books", Version: 1}); !errors.Is(err, domain.ErrVersionConflict) {
+		t.Fatalf("UpdateProduct() error = %v, want ErrVersionConflict", err)
+	}
+
+	product, err := r.GetProduct(ctx, "p1")
+	if err != nil || product.Name != "Novel" || product.Version != 2 {
+		t.Errorf("GetProduct() = %+v, %v after the conflict, want the current version", product, err)
+	}
+}
+
+func TestDeleteProductInvalidatesCache(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeProductRepository(&domain.Product{ID: "p1", Name: "Book", Category: "books"})
+	r, memoryCache := newTestRepository(repo, CacheConfig{})
+
+	if _, err := r.GetProduct(ctx, "p1"); err != nil {
+		t.Fatalf("GetProduct() error = %v", err)
+	}
+	if _, _, err := r.ListProducts(ctx, "books", domain.ProductListOptions{}, 10, "a/b"); err != nil {
+		t.Fatalf("ListProducts() error = %v", err)
+	}
+	if err := r.DeleteProduct(ctx, "p1"); err != nil {
+		t.Fatalf("DeleteProduct() error = %v", err)
+	}
+
+	if _, err := r.GetProduct(ctx, "p1"); !errors.Is(err, domain.ErrProductNotFound) {
+		t.Errorf("GetProduct() error = %v after the delete, want ErrProductNotFound", err)
+	}
+	if keys, _ := memoryCache.Scan(ctx, categoryKeyPrefix+"*"); len(keys) != 0 {
+		t.Errorf("list pages %q still cached after the delete", keys)
+	}
+}
+
+func TestDeleteProductsInvalidatesOnlyDeletedProducts(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeProductRepository(
+		&domain.Product{ID: "p1", Name: "Book", Category: "books"},
+		&domain.Product{ID: "p2", Name: "Ball", Category: "toys"},
+		&domain.Product{ID: "p3", Name: "Chess", Category: "games"},
+	)
+	r, memoryCache := newTestRepository(repo, CacheConfig{})
+
+	categories := []string{"", "books", "toys", "games"}
+	for _, category := range categories {
+		if _, _, err := r.ListProducts(ctx, category, domain.ProductListOptions{}, 10, ""); err != nil {
+			t.Fatalf("ListProducts(%q) error = %v", category, err)
+		}
+	}
+	for _, id := range []string{"p1", "p3"} {
+		if _, err := r.GetProduct(ctx, id); err != nil {
+			t.Fatalf("GetProduct(%q) error = %v", id, err)
+		}
+	}
+
+	// p1 is read from the cache, p2 from the repository, and missing does not exist
+	deleted, failed := r.DeleteProducts(ctx, []string{"p1", "missing", "p2"})
+	if deleted != 2 {
+		t.Errorf("DeleteProducts() deleted = %d, want 2", deleted)
+	}
+	if len(failed) != 1 || !errors.Is(failed["missing"], domain.ErrProductNotFound) {
+		t.Errorf("DeleteProducts() failed = %v, want missing as not found", failed)
+	}
+
+	for _, id := range []string{"p1", "p2"} {
+		if _, err := r.GetProduct(ctx, id); !errors.Is(err, domain.ErrProductNotFound) {
+			t.Errorf("GetProduct(%q) error = %v after the delete, want ErrProductNotFound", id, err)
+		}
+	}
+	if _, err := memoryCache.Get(ctx, productKey("p3")); err != nil {
+		t.Errorf("product p3 not in the batch dropped from the cache: %v", err)
+	}
+	evicted := map[string]bool{"": true, "books": true, "toys": true}
+	for _, category := range categories {
+		_, err := memoryCache.Get(ctx, categoryKey(category, domain.ProductListOptions{}, 10, ""))
+		if cached := err == nil; cached == evicted[category] {
+			t.Errorf("page of category %q cached = %v, want %v", category, cached, !evicted[category])
+		}
+	}
+}
+
+func TestDeleteProductsOfOnlyMissingIDsKeepsTheCache(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeProductRepository(&domain.Product{ID: "p1", Name: "Book", Category: "books"})
+	r, memoryCache := newTestRepository(repo, CacheConfig{})
+
+	if _, _, err := r.ListProducts(ctx, "books", domain.ProductListOptions{}, 10, ""); err != nil {
+		t.Fatalf("ListProducts() error = %v", err)
+	}
+	deleted, failed := r.DeleteProducts(ctx, []string{"missing-1", "missing-2"})
+	if deleted != 0 || len(failed) != 2 {
+		t.Errorf("DeleteProducts() = %d, %v, want nothing deleted and both IDs failed", deleted, failed)
+	}
+	if _, err := memoryCache.Get(ctx, categoryKey("books", domain.ProductListOptions{}, 10, "")); err != nil {
+		t.Errorf("list page dropped although nothing was deleted: %v", err)
+	}
+}
+
+func TestCreateProductInvalidatesCategoryLists(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeProductRepository(&domain.Product{ID: "p1", Name: "Book", Category: "books"})
+	r, _ := newTestRepository(repo, CacheConfig{})
+
+	// A page token may contain a slash, which the invalidation pattern must still match
+	if products, _, err := r.ListProducts(ctx, "books", domain.ProductListOptions{}, 10, "page/1"); err != nil || len(products) != 1 {
+		t.Fatalf("ListProducts() = %d products, %v, want 1", len(products), err)
+	}
+	if _, err := r.CreateProduct(ctx, &domain.Product{ID: "p2", Name: "Another book", Category: "books"}); err != nil {
+		t.Fatalf("CreateProduct() error = %v", err)
+	}
+
+	products, _, err := r.ListProducts(ctx, "books", domain.ProductListOptions{}, 10, "page/1")
+	if err != nil || len(products) != 2 {
+		t.Errorf("ListProducts() = %d products, %v after the create, want 2", len(products), err)
+	}
+	if _, lists := repo.counts(); lists != 2 {
+		t.Errorf("repository ListProducts called %d times, want 2 as the create invalidated the page", lists)
+	}
+}
+
+func TestWritesInvalidateOnlyAffectedCategoryLists(t *testing.T) {
+	tests := []struct {
+		name string
+		// cached reads the product first, so its category comes from the cache rather than the repository
+		cached bool
+		write  func(ctx context.Context, r *RedisProductRepository) error
+		// evicted lists the categories whose pages are dropped besides the unfiltered listing
+		evicted []string
+		// recached is set for writes that cache the product they wrote
+		recached bool
+	}{
+		{
+			name:   "update in place",
+			cached: true,
+			write: func(ctx context.Context, r *RedisProductRepository) error {
+				_, err := r.UpdateProduct(ctx, &domain.Product{ID: "p1", Name: "Renamed", Category: "books"})
+				return err
+			},
+			evicted:  []string{"books"},
+			recached: true,
+		},
+		{
+			name: "update moving the product",
+			write: func(ctx context.Context, r *RedisProductRepository) error {
+				_, err := r.UpdateProduct(ctx, &domain.Product{ID: "p1", Name: "Book", Category: "toys"})
+				return err
+			},
+			evicted:  []string{"books", "toys"},
+			recached: true,
+		},
+		{
+			name:   "delete",
+			cached: true,
+			write: func(ctx context.Context, r *RedisProductRepository) error {
+				return r.DeleteProduct(ctx, "p1")
+			},
+			evicted: []string{"books"},
+		},
+		{
+			name: "delete of an uncached product",
+			write: func(ctx context.Context, r *RedisProductRepository) error {
+				return r.DeleteProduct(ctx, "p1")
+			},
+			evicted: []string{"books"},
+		},
+		{
+			name:   "stock reservation",
+			cached: true,
+			write: func(ctx context.Context, r *RedisProductRepository) error {
+				return r.ReserveStock(ctx, []domain.StockChange{{ProductID: "p1", Quantity: 1}})
+			},
+			evicted: []string{"books"},
+		},
+		{
+			name: "stock release of an uncached product",
+			write: func(ctx context.Context, r *RedisProductRepository) error {
+				return r.ReleaseStock(ctx, []domain.StockChange{{ProductID: "p1", Quantity: 1}})
+			},
+			evicted: []string{"books"},
+		},
+		{
+			name: "stock set",
+			write: func(ctx context.Context, r *RedisProductRepository) error {
+				_, err := r.SetStock(ctx, "p1", 3)
+				return err
+			},
+			evicted: []string{"books"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newFakeProductRepository(
+				&domain.Product{ID: "p1", Name: "Book", Category: "books", Stock: 5},
+				&domain.Product{ID: "p2", Name: "Ball", Category: "toys", Stock: 5},
+				&domain.Product{ID: "p3", Name: "Chess", Category: "games", Stock: 5},
+			)
+			r, memoryCache := newTestRepository(repo, CacheConfig{})
+
+			categories := []string{"", "books", "toys", "games"}
+			for _, category := range categories {
+				if _, _, err := r.ListProducts(ctx, category, domain.ProductListOptions{}, 10, ""); err != nil {
+					t.Fatalf("ListProducts(%q) error = %v", category, err)
+				}
+			}
+			if tt.cached {
+				if _, err := r.GetProduct(ctx, "p1"); err != nil {
+					t.Fatalf("GetProduct() error = %v", err)
+				}
+			}
+
+			if err := tt.write(ctx, r); err != nil {
+				t.Fatalf("write error = %v", err)
+			}
+
+			evicted := map[string]bool{"": true}
+			for _, category := range tt.evicted {
+				evicted[category] = true
+			}
+			for _, category := range categories {
+				_, err := memoryCache.Get(ctx, categoryKey(category, domain.ProductListOptions{}, 10, ""))
+				if cached := err == nil; cached == evicted[category] {
+					t.Errorf("page of category %q cached = %v, want %v", category, cached, !evicted[category])
+				}
+			}
+			if _, err := memoryCache.Get(ctx, productKey("p1")); (err == nil) != tt.recached {
+				t.Errorf("product p1 cached = %v after the write, want %v", err == nil, tt.recached)
+			}
+		})
+	}
+}
+
+// ttlRecordingCache records the TTL of the latest write of every key
+type ttlRecordingCache struct {
+	*cache.MemoryCache
+
+	mu   sync.Mutex
+	ttls map[string]time.Duration
+}
+
+func (c *ttlRecordingCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	c.ttls[key] = ttl
+	c.mu.Unlock()
+	return c.MemoryCache.Set(ctx, key, value, ttl)
+}
+
+func TestJitteredStaysWithinBounds(t *testing.T) {
+	tests := []struct {
+		ttl           time.Duration
+		jitterPercent int
+		min, max      time.Duration
+	}{
+		{ttl: 30 * time.Minute, jitterPercent: 0, min: 30 * time.Minute, max: 30 * time.Minute},
+		{ttl: 30 * time.Minute, jitterPercent: 10, min: 27 * time.Minute, max: 33 * time.Minute},
+		{ttl: 2 * time.Minute, jitterPercent: 25, min: 90 * time.Second, max: 150 * time.Second},
+		{ttl: time.Minute, jitterPercent: maxTTLJitterPercent, min: 30 * time.Second, max: 90 * time.Second},
+	}
+
+	random := rand.New(rand.NewPCG(1, 2))
+	for _, tt := range tests {
+		config := CacheConfig{TTLJitterPercent: tt.jitterPercent}
+		rnds := []float64{0, 0.5, 0.9999999}
+		for i := 0; i < 1000; i++ {
+			rnds = append(rnds, random.Float64())
+		}
+
+		for _, rnd := range rnds {
+			if got := config.jittered(tt.ttl, rnd); got < tt.min || got > tt.max {
+				t.Fatalf("jittered(%s, %v) with %d%% jitter = %s, want within [%s, %s]", tt.ttl, rnd, tt.jitterPercent, got, tt.min, tt.max)
+			}
+		}
+	}
+}
+
+func TestCacheWritesUseProductAndListTTLs(t *testing.T) {
+	config := CacheConfig{
+		ProductTTL:       10 * time.Minute,
+		ListTTL:          2 * time.Minute,
+		CategoryTTLs:     map[string]time.Duration{"games": time.Hour},
+		TTLJitterPercent: 20,
+	}
+	tests := []struct {
+		name        string
+		rnd         float64
+		productTTL  time.Duration
+		listTTL     time.Duration
+		categoryTTL time.Duration
+	}{
+		{name: "shortest", rnd: 0, productTTL: 8 * time.Minute, listTTL: 96 * time.Second, categoryTTL: 48 * time.Minute},
+		{name: "unjittered", rnd: 0.5, productTTL: 10 * time.Minute, listTTL: 2 * time.Minute, categoryTTL: time.Hour},
+		{name: "longest", rnd: 0.9999999, productTTL: 12 * time.Minute, listTTL: 144 * time.Second, categoryTTL: 72 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newFakeProductRepository(
+				&domain.Product{ID: "p1", Name: "Book", Category: "books"},
+				&domain.Product{ID: "p2", Name: "Game", Category: "games"},
+			)
+			recorder := &ttlRecordingCache{MemoryCache: cache.NewMemoryCache(), ttls: make(map[string]time.Duration)}
+			r := NewRedisProductRepository(zap.NewNop(), recorder, repo, config)
+			r.rnd = func() float64 { return tt.rnd }
+
+			for _, id := range []string{"p1", "p2"} {
+				if _, err := r.GetProduct(ctx, id); err != nil {
+					t.Fatalf("GetProduct(%q) error = %v", id, err)
+				}
+			}
+			if _, _, err := r.ListProducts(ctx, "books", domain.ProductListOptions{}, 10, ""); err != nil {
+				t.Fatalf("ListProducts() error = %v", err)
+			}
+
+			checks := []struct {
+				key  string
+				want time.Duration
+			}{
+				{productKey("p1"), tt.productTTL},
+				{productKey("p2"), tt.categoryTTL},
+				{categoryKey("books", domain.ProductListOptions{}, 10, ""), tt.listTTL},
+			}
+			for _, check := range checks {
+				got := recorder.ttls[check.key]
+				if diff := got - check.want; diff < -time.Millisecond || diff > time.Millisecond {
+					t.Errorf("TTL of %q = %s, want %s", check.key, got, check.want)
+				}
+			}
+		})
+	}
+}
+
+// getCountingCache counts the Gets reaching the cache, so a test can tell when every
+// concurrent caller has looked its key up
+type getCountingCache struct {
+	*cache.MemoryCache
+
+	mu   sync.Mutex
+	gets int
+}
+
+func (c *getCountingCache) Get(ctx context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	c.gets++
+	c.mu.Unlock()
+	return c.MemoryCache.Get(ctx, key)
+}
+
+func (c *getCountingCache) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.gets
+}
+
+// holdLoads makes the repository's reads wait until that many cache lookups have reached the
+// cache, so all of them miss while the first load is still running
+func holdLoads(repo *fakeProductRepository, counting *getCountingCache, callers int) {
+	repo.load = func() {
+		deadline := time.Now().Add(5 * time.Second)
+		for counting.count() < callers && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		// Let the last callers get from their lookup to the shared load
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestConcurrentMissesLoadOnce(t *testing.T) {
+	const callers = 100
+	ctx := context.Background()
+	repo := newFakeProductRepository(&domain.Product{ID: "p1", Name: "Book", Category: "books"})
+	counting := &getCountingCache{MemoryCache: cache.NewMemoryCache()}
+	r := NewRedisProductRepository(zap.NewNop(), counting, repo, CacheConfig{})
+	holdLoads(repo, counting, 2*callers)
+
+	var wg sync.WaitGroup
+	products := make([]*domain.Product, callers)
+	pages := make([][]*domain.Product, callers)
+	errs := make(chan error, 2*callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			product, err := r.GetProduct(ctx, "p1")
+			products[i] = product
+			errs <- err
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			page, _, err := r.ListProducts(ctx, "books", domain.ProductListOptions{}, 10, "")
+			pages[i] = page
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("concurrent read error = %v", err)
+		}
+	}
+	if gets, lists := repo.counts(); gets != 1 || lists != 1 {
+		t.Errorf("repository called %d times for the product and %d times for the page, want 1 each for %d callers", gets, lists, callers)
+	}
+
+	// Every caller gets its own copy of the shared result
+	products[0].Name = "Changed"
+	pages[0][0].Name = "Changed"
+	for i := 1; i < callers; i++ {
+		if products[i].Name != "Book" || pages[i][0].Name != "Book" {
+			t.Fatalf("caller %d shares its result with caller 0: %+v, %+v", i, products[i], pages[i][0])
+		}
+	}
+}
+
+func TestSharedLoadOutlivesCancelledCaller(t *testing.T) {
+	repo := newFakeProductRepository(&domain.Product{ID: "p1", Name: "Book", Category: "books"})
+	started, release := make(chan struct{}), make(chan struct{})
+	repo.load = func() {
+		close(started)
+		<-release
+	}
+	r, memoryCache := newTestRepository(repo, CacheConfig{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := r.GetProduct(ctx, "p1")
+		done <- err
+	}()
+	<-started
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("GetProduct() error = %v, want Canceled for the caller that went away", err)
+	}
+
+	// The load keeps going for the callers still waiting and fills the cache
+	waiting := make(chan *domain.Product, 1)
+	go func() {
+		product, _ := r.GetProduct(context.Background(), "p1")
+		waiting <- product
+	}()
+	close(release)
+	if product := <-waiting; product == nil || product.Name != "Book" {
+		t.Fatalf("GetProduct() = %+v for a caller waiting on the load", product)
+	}
+	if gets, _ := repo.counts(); gets != 1 {
+		t.Errorf("repository GetProduct called %d times, want the one load the cancelled caller started", gets)
+	}
+	if _, err := memoryCache.Get(context.Background(), productKey("p1")); err != nil {
+		t.Errorf("product not cached after the shared load: %v", err)
+	}
+}
+
+// cacheStaleProduct caches a product that went stale a second ago and that the cache keeps
+// for ttl more
+func cacheStaleProduct(t *testing.T, memoryCache *cache.MemoryCache, product *domain.Product, ttl time.Duration) {
+	t.Helper()
+	freshUntil := time.Now().Add(-time.Second)
+	data, err := json.Marshal(cachedProduct{Product: product, FreshUntil: &freshUntil})
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if err := memoryCache.Set(context.Background(), productKey(product.ID), data, ttl); err != nil {
+		t.Fatalf("cache.Set() error = %v", err)
+	}
+}
+
+func TestStaleProductIsServedWhileOneRefreshRuns(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeProductRepository(&domain.Product{ID: "p1", Name: "New", Category: "books"})
+	started, release := make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	repo.load = func() {
+		once.Do(func() { close(started) })
+		<-release
+	}
+	r, memoryCache := newTestRepository(repo, CacheConfig{StaleWindow: time.Minute})
+	cacheStaleProduct(t, memoryCache, &domain.Product{ID: "p1", Name: "Old", Category: "books"}, time.Minute)
+
+	// Every read inside the stale window is answered from the cache straight away, while
+	// a single refresh is held in the repository
+	for i := 0; i < 5; i++ {
+		product, err := r.GetProduct(ctx, "p1")
+		if err != nil {
+			t.Fatalf("GetProduct() error = %v", err)
+		}
+		if product.Name != "Old" {
+			t.Fatalf("GetProduct() = %q, want the stale %q served while it is refreshed", product.Name, "Old")
+		}
+	}
+	<-started
+	if gets, _ := repo.counts(); gets != 1 {
+		t.Errorf("repository GetProduct called %d times, want one refresh for every stale read", gets)
+	}
+
+	close(release)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		product, err := r.GetProduct(ctx, "p1")
+		if err != nil {
+			t.Fatalf("GetProduct() error = %v", err)
+		}
+		if product.Name == "New" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("GetProduct() = %q after the refresh, want %q", product.Name, "New")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if gets, _ := repo.counts(); gets != 1 {
+		t.Errorf("repository GetProduct called %d times, want only the refresh", gets)
+	}
+}
+
+func TestProductPastStaleWindowLoadsSynchronously(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeProductRepository(&domain.Product{ID: "p1", Name: "New", Category: "books"})
+	r, memoryCache := newTestRepository(repo, CacheConfig{StaleWindow: time.Minute})
+	// The cache drops the entry at the end of its stale window
+	cacheStaleProduct(t, memoryCache, &domain.Product{ID: "p1", Name: "Old", Category: "books"}, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	product, err := r.GetProduct(ctx, "p1")
+	if err != nil {
+		t.Fatalf("GetProduct() error = %v", err)
+	}
+	if product.Name != "New" {
+		t.Errorf("GetProduct() = %q, want %q loaded from the repository", product.Name, "New")
+	}
+	if gets, _ := repo.counts(); gets != 1 {
+		t.Errorf("repository GetProduct called %d times, want 1", gets)
+	}
+
+	// The loaded product is cached fresh again
+	if product, err := r.GetProduct(ctx, "p1"); err != nil || product.Name != "New" {
+		t.Fatalf("GetProduct() = %+v, %v", product, err)
+	}
+	if gets, _ := repo.counts(); gets != 1 {
+		t.Errorf("repository GetProduct called %d times after the reload, want it served from the cache", gets)
+	}
+}
+
+// contextCache fails its reads with the context's error once it has ended, as the Redis
+// client does
+type contextCache struct {
+	*cache.MemoryCache
+}
+
+func (c contextCache) Get(ctx context.Context, key string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.MemoryCache.Get(ctx, key)
+}
+
+func (c contextCache) MGet(ctx context.Context, keys ...string) ([][]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.MemoryCache.MGet(ctx, keys...)
+}
+
+// failingCache fails every read with err
+type failingCache struct {
+	*cache.MemoryCache
+	err error
+}
+
+func (c failingCache) Get(ctx context.Context, key string) ([]byte, error) {
+	return nil, c.err
+}
+
+func (c failingCache) MGet(ctx context.Context, keys ...string) ([][]byte, error) {
+	return nil, c.err
+}
+
+func TestEndedContextSkipsTheRepository(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancelExpired := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancelExpired()
+
+	contexts := []struct {
+		name string
+		ctx  context.Context
+		want error
+	}{
+		{name: "cancelled", ctx: cancelled, want: context.Canceled},
+		{name: "past its deadline", ctx: expired, want: context.DeadlineExceeded},
+	}
+	caches := []struct {
+		name string
+		wrap func(*cache.MemoryCache) cache.Cache
+	}{
+		{name: "cache failing the read", wrap: func(c *cache.MemoryCache) cache.Cache { return contextCache{c} }},
+		{name: "cache missing", wrap: func(c *cache.MemoryCache) cache.Cache { return c }},
+	}
+
+	for _, tc := range contexts {
+		for _, cc := range caches {
+			t.Run(tc.name+" with "+cc.name, func(t *testing.T) {
+				repo := newFakeProductRepository(&domain.Product{ID: "p1", Name: "Book", Category: "books"})
+				memoryCache := cache.NewMemoryCache()
+				r := NewRedisProductRepository(zap.NewNop(), cc.wrap(memoryCache), repo, CacheConfig{NegativeTTL: time.Minute})
+
+				if _, err := r.GetProduct(tc.ctx, "p1"); !errors.Is(err, tc.want) {
+					t.Errorf("GetProduct() error = %v, want %v", err, tc.want)
+				}
+				if _, _, err := r.ListProducts(tc.ctx, "books", domain.ProductListOptions{}, 10, ""); !errors.Is(err, tc.want) {
+					t.Errorf("ListProducts() error = %v, want %v", err, tc.want)
+				}
+				if _, err := r.GetProducts(tc.ctx, []string{"p1", "p2"}); !errors.Is(err, tc.want) {
+					t.Errorf("GetProducts() error = %v, want %v", err, tc.want)
+				}
+
+				if gets, lists := repo.counts(); gets != 0 || lists != 0 {
+					t.Errorf("repository read %d products and %d pages for an ended context, want none", gets, lists)
+				}
+				// Nothing is written back, not even a tombstone
+				if keys, _ := memoryCache.Scan(context.Background(), "*"); len(keys) != 0 {
+					t.Errorf("cache holds %v after reads with an ended context, want nothing", keys)
+				}
+			})
+		}
+	}
+}
+
+func TestCacheErrorsFallBackToTheRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeProductRepository(&domain.Product{ID: "p1", Name: "Book", Category: "books"})
+	r := NewRedisProductRepository(zap.NewNop(), failingCache{cache.NewMemoryCache(), errors.New("connection refused")}, repo, CacheConfig{})
+
+	if product, err := r.GetProduct(ctx, "p1"); err != nil || product.Name != "Book" {
+		t.Errorf("GetProduct() = %+v, %v, want the product from the repository", product, err)
+	}
+	if products, _, err := r.ListProducts(ctx, "books", domain.ProductListOptions{}, 10, ""); err != nil || len(products) != 1 {
+		t.Errorf("ListProducts() = %+v, %v, want the page from the repository", products, err)
+	}
+	if products, err := r.GetProducts(ctx, []string{"p1"}); err != nil || products["p1"] == nil {
+		t.Errorf("GetProducts() = %+v, %v, want the product from the repository", products, err)
+	}
+	if gets, lists := repo.counts(); gets != 2 || lists != 1 {
+		t.Errorf("repository read %d products and %d pages, want 2 and 1", gets, lists)
+	}
+
+	// A cache read timing out on its own, while the caller still waits, falls back too
+	timedOut := NewRedisProductRepository(zap.NewNop(), failingCache{cache.NewMemoryCache(), context.DeadlineExceeded}, repo, CacheConfig{})
+	if product, err := timedOut.GetProduct(ctx, "p1"); err != nil || product.Name != "Book" {
+		t.Errorf("GetProduct() = %+v, %v after a cache timeout, want the product from the repository", product, err)
+	}
+}
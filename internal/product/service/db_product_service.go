@@ -2,42 +2,131 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"go-bootiful-ordering/internal/pkg/metrics"
+	"go-bootiful-ordering/internal/pkg/tenant"
 	"go-bootiful-ordering/internal/product/domain"
 	"go-bootiful-ordering/internal/product/repository"
 	"go.uber.org/zap"
+	"sort"
+	"strings"
+	"unicode/utf8"
 )
 
+// MaxBulkDeleteProducts bounds the number of products deleted by one DeleteProducts call
+const MaxBulkDeleteProducts = 500
+
+// MaxBatchCreateProducts bounds the number of products created by one CreateProducts call
+const MaxBatchCreateProducts = 500
+
+// MaxSearchQueryLength bounds the length (in characters) of a SearchProducts query, keeping
+// the text search cheap to parse and rank
+const MaxSearchQueryLength = 200
+
+// MaxAvailabilityItems bounds the number of items checked by one CheckAvailability call
+const MaxAvailabilityItems = 100
+
+// MaxBatchGetProducts bounds the number of distinct products read by one GetProductsByIDs call
+const MaxBatchGetProducts = 500
+
 // DBProductService provides an implementation of ProductService that uses a database repository
 type DBProductService struct {
-	log  *zap.SugaredLogger
-	repo repository.ProductRepository
+	log     *zap.SugaredLogger
+	repo    repository.ProductRepository
+	limits  domain.FieldLimits
+	metrics *metrics.Metrics
 }
 
 // NewDBProductService creates a new DBProductService
-func NewDBProductService(log *zap.SugaredLogger, repo repository.ProductRepository) *DBProductService {
+func NewDBProductService(log *zap.SugaredLogger, repo repository.ProductRepository, limits domain.FieldLimits, m *metrics.Metrics) *DBProductService {
 	return &DBProductService{
-		log:  log,
-		repo: repo,
+		log:     log,
+		repo:    repo,
+		limits:  limits,
+		metrics: m,
 	}
 }
 
 // CreateProduct creates a new product using the repository
-func (s *DBProductService) CreateProduct(ctx context.Context, name, description string, price int64, stock int32, category string) (*domain.Product, error) {
+func (s *DBProductService) CreateProduct(ctx context.Context, name, description string, price int64, stock int32, category string, physical domain.PhysicalAttributes) (*domain.Product, error) {
 	s.log.Infof("DBProductService_CreateProduct name=%s category=%s",
 		name, category)
 
 	// Create a new product domain object
 	product := &domain.Product{
-		Name:        name,
-		Description: description,
-		Price:       price,
-		Stock:       stock,
-		Category:    category,
-		Status:      domain.ProductStatusActive,
+		Name:               name,
+		Description:        description,
+		Price:              price,
+		Stock:              stock,
+		Category:           category,
+		Status:             domain.ProductStatusActive,
+		PhysicalAttributes: physical,
+	}
+
+	// Sanitize and validate the product
+	product.Sanitize()
+	if err := product.Validate(s.limits); err != nil {
+		return nil, err
 	}
 
 	// Use the repository to persist the product
-	return s.repo.CreateProduct(ctx, product)
+	createdProduct, err := s.repo.CreateProduct(ctx, product)
+	if err != nil {
+		return nil, err
+	}
+
+	s.metrics.ProductsCreatedCounter.WithLabelValues(tenant.MetricLabel(ctx)).Inc()
+	return createdProduct, nil
+}
+
+// CreateProducts creates several products in one transaction using the repository.
+// Every product is validated before anything is written; the first invalid one fails
+// the whole batch with a domain.BatchItemError holding its index.
+func (s *DBProductService) CreateProducts(ctx context.Context, products []*domain.Product) ([]*domain.Product, error) {
+	s.log.Infof("DBProductService_CreateProducts count=%d", len(products))
+
+	// Validate the batch
+	if len(products) == 0 {
+		return nil, fmt.Errorf("%w: at least one product is required", domain.ErrInvalidArgument)
+	}
+
+	if len(products) > MaxBatchCreateProducts {
+		return nil, fmt.Errorf("%w: at most %d products can be created at once, got %d", domain.ErrInvalidArgument, MaxBatchCreateProducts, len(products))
+	}
+
+	// Build new product domain objects, so no caller-set ID, status or rating is kept
+	batch := make([]*domain.Product, len(products))
+	for i, product := range products {
+		if product == nil {
+			return nil, &domain.BatchItemError{Index: i, Err: fmt.Errorf("%w: product is required", domain.ErrInvalidArgument)}
+		}
+
+		candidate := &domain.Product{
+			Name:               product.Name,
+			Description:        product.Description,
+			Price:              product.Price,
+			Stock:              product.Stock,
+			Category:           product.Category,
+			Status:             domain.ProductStatusActive,
+			PhysicalAttributes: product.PhysicalAttributes,
+		}
+
+		// Sanitize and validate the product
+		candidate.Sanitize()
+		if err := candidate.Validate(s.limits); err != nil {
+			return nil, &domain.BatchItemError{Index: i, Err: err}
+		}
+		batch[i] = candidate
+	}
+
+	// Use the repository to persist the products
+	createdProducts, err := s.repo.CreateProducts(ctx, batch)
+	if err != nil {
+		return nil, err
+	}
+
+	s.metrics.ProductsCreatedCounter.WithLabelValues(tenant.MetricLabel(ctx)).Add(float64(len(createdProducts)))
+	return createdProducts, nil
 }
 
 // GetProduct retrieves a product by ID using the repository
@@ -48,19 +137,96 @@ func (s *DBProductService) GetProduct(ctx context.Context, productID string) (*d
 	return s.repo.GetProduct(ctx, productID)
 }
 
+// GetProductsByIDs retrieves several products at once using the repository, keyed by ID.
+// Duplicate and empty IDs are ignored, and products that do not exist are absent from the map.
+func (s *DBProductService) GetProductsByIDs(ctx context.Context, productIDs []string) (map[string]*domain.Product, error) {
+	s.log.Infof("DBProductService_GetProductsByIDs count=%d", len(productIDs))
+
+	ids := uniqueProductIDs(productIDs)
+	if len(ids) > MaxBatchGetProducts {
+		return nil, fmt.Errorf("%w: at most %d products can be read at once, got %d", domain.ErrInvalidArgument, MaxBatchGetProducts, len(ids))
+	}
+
+	// Use the repository to read the products with a single query for the uncached ones
+	return s.repo.GetProducts(ctx, ids)
+}
+
+// uniqueProductIDs returns the non-empty IDs in the order they first appear
+func uniqueProductIDs(productIDs []string) []string {
+	ids := make([]string, 0, len(productIDs))
+	seen := make(map[string]bool, len(productIDs))
+	for _, id := range productIDs {
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// GetProductIncludingDeleted retrieves a product by ID even if it has been deleted
+func (s *DBProductService) GetProductIncludingDeleted(ctx context.Context, productID string) (*domain.Product, error) {
+	s.log.Infof("DBProductService_GetProductIncludingDeleted productID=%s", productID)
+
+	// Use the repository to retrieve the product
+	return s.repo.GetProductIncludingDeleted(ctx, productID)
+}
+
 // ListProducts retrieves a list of products using the repository
-func (s *DBProductService) ListProducts(ctx context.Context, category string, pageSize int32, pageToken string) ([]*domain.Product, string, error) {
-	s.log.Infof("DBProductService_ListProducts category=%s pageSize=%d pageToken=%s",
-		category, pageSize, pageToken)
+func (s *DBProductService) ListProducts(ctx context.Context, category string, opts domain.ProductListOptions, pageSize int32, pageToken string) ([]*domain.Product, string, error) {
+	s.log.Infof("DBProductService_ListProducts category=%s sortBy=%s sortDir=%s pageSize=%d pageToken=%s",
+		category, opts.SortBy, opts.SortDir, pageSize, pageToken)
+
+	// Validate the ordering and price range
+	if err := opts.Validate(); err != nil {
+		return nil, "", err
+	}
 
 	// Use the repository to list products
-	return s.repo.ListProducts(ctx, category, pageSize, pageToken)
+	return s.repo.ListProducts(ctx, category, opts, pageSize, pageToken)
 }
 
-// UpdateProduct updates a product using the repository
-func (s *DBProductService) UpdateProduct(ctx context.Context, productID, name, description string, price int64, stock int32, category string) (*domain.Product, error) {
-	s.log.Infof("DBProductService_UpdateProduct productID=%s name=%s category=%s",
-		productID, name, category)
+// CountProducts counts the products ListProducts returns for the same filters using the repository
+func (s *DBProductService) CountProducts(ctx context.Context, category string, opts domain.ProductListOptions) (int64, error) {
+	s.log.Infof("DBProductService_CountProducts category=%s", category)
+
+	// Validate the price range
+	if err := opts.Validate(); err != nil {
+		return 0, err
+	}
+
+	return s.repo.CountProducts(ctx, category, opts)
+}
+
+// SearchProducts finds products matching a free-text query using the repository
+func (s *DBProductService) SearchProducts(ctx context.Context, query string, pageSize int32, pageToken string) ([]*domain.Product, string, error) {
+	s.log.Infof("DBProductService_SearchProducts query=%q pageSize=%d pageToken=%s",
+		query, pageSize, pageToken)
+
+	// Validate the query
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, "", fmt.Errorf("%w: query is required", domain.ErrInvalidArgument)
+	}
+	if utf8.RuneCountInString(query) > MaxSearchQueryLength {
+		return nil, "", fmt.Errorf("%w: query must be at most %d characters", domain.ErrInvalidArgument, MaxSearchQueryLength)
+	}
+
+	// Use the repository to search products
+	return s.repo.SearchProducts(ctx, query, pageSize, pageToken)
+}
+
+// UpdateProduct updates a product using the repository. With a version, the update is
+// only written if the product is still at that version; without one, it is written over
+// whatever version the product has.
+func (s *DBProductService) UpdateProduct(ctx context.Context, productID, name, description string, price int64, stock int32, category string, physical domain.PhysicalAttributes, version int) (*domain.Product, error) {
+	s.log.Infof("DBProductService_UpdateProduct productID=%s name=%s category=%s version=%d",
+		productID, name, category, version)
+
+	if version < 0 {
+		return nil, fmt.Errorf("%w: version cannot be negative", domain.ErrInvalidArgument)
+	}
 
 	// First, get the existing product
 	existingProduct, err := s.repo.GetProduct(ctx, productID)
@@ -68,15 +234,56 @@ func (s *DBProductService) UpdateProduct(ctx context.Context, productID, name, d
 		return nil, err
 	}
 
-	// Update the product fields
-	existingProduct.Name = name
-	existingProduct.Description = description
-	existingProduct.Price = price
-	existingProduct.Stock = stock
-	existingProduct.Category = category
+	// Fail early on a version that is already known to be stale, even if nothing would change
+	if version != 0 && existingProduct.Version != 0 && version != existingProduct.Version {
+		return nil, fmt.Errorf("%w: product %s is at version %d, not %d", domain.ErrVersionConflict, productID, existingProduct.Version, version)
+	}
+
+	// Apply the changes to a copy so the loaded product stays available for comparison
+	updatedProduct := *existingProduct
+	updatedProduct.Name = name
+	updatedProduct.Description = description
+	updatedProduct.Price = price
+	updatedProduct.Stock = stock
+	updatedProduct.Category = category
+	updatedProduct.PhysicalAttributes = physical
+	updatedProduct.Version = version
+
+	// Sanitize and validate the product
+	updatedProduct.Sanitize()
+	if err := updatedProduct.Validate(s.limits); err != nil {
+		return nil, err
+	}
+
+	// Skip the write, and with it the cache refresh and updated_at bump, when nothing changed
+	if updatedProduct.SameDetails(existingProduct) {
+		return existingProduct, nil
+	}
 
 	// Use the repository to update the product
-	return s.repo.UpdateProduct(ctx, existingProduct)
+	return s.repo.UpdateProduct(ctx, &updatedProduct)
+}
+
+// PatchProduct updates only the fields the patch sets, validating just those, so a client
+// can change the price alone without resending the rest of the product
+func (s *DBProductService) PatchProduct(ctx context.Context, productID string, patch domain.ProductPatch) (*domain.Product, error) {
+	s.log.Infof("DBProductService_PatchProduct productID=%s", productID)
+
+	if productID == "" {
+		return nil, fmt.Errorf("%w: product_id is required", domain.ErrInvalidArgument)
+	}
+	if patch.IsEmpty() {
+		return nil, fmt.Errorf("%w: a patch must set at least one field", domain.ErrInvalidArgument)
+	}
+
+	// Sanitize and validate the patched fields
+	patch.Sanitize()
+	if err := patch.Validate(s.limits); err != nil {
+		return nil, err
+	}
+
+	// Use the repository to apply the patch
+	return s.repo.PatchProduct(ctx, productID, patch)
 }
 
 // DeleteProduct deletes a product using the repository
@@ -86,3 +293,191 @@ func (s *DBProductService) DeleteProduct(ctx context.Context, productID string)
 	// Use the repository to delete the product
 	return s.repo.DeleteProduct(ctx, productID)
 }
+
+// DeleteProducts deletes several products at once using the repository.
+// Duplicate and empty IDs are ignored. The returned map holds the error for each
+// ID that could not be deleted; the error return is for requests rejected as a whole.
+func (s *DBProductService) DeleteProducts(ctx context.Context, productIDs []string) (int64, map[string]error, error) {
+	s.log.Infof("DBProductService_DeleteProducts count=%d", len(productIDs))
+
+	// Drop duplicate and empty IDs
+	ids := uniqueProductIDs(productIDs)
+
+	// Validate the batch
+	if len(ids) == 0 {
+		return 0, nil, fmt.Errorf("%w: at least one product ID is required", domain.ErrInvalidArgument)
+	}
+
+	if len(ids) > MaxBulkDeleteProducts {
+		return 0, nil, fmt.Errorf("%w: at most %d products can be deleted at once, got %d", domain.ErrInvalidArgument, MaxBulkDeleteProducts, len(ids))
+	}
+
+	// Use the repository to delete the products
+	deleted, failed := s.repo.DeleteProducts(ctx, ids)
+	return deleted, failed, nil
+}
+
+// SetStatusByCategory activates or deactivates every product in a category, returning how
+// many products changed. Out of stock is not a target: it follows from the stock, so
+// activated products without stock go out of stock.
+func (s *DBProductService) SetStatusByCategory(ctx context.Context, category string, status domain.ProductStatus) (int64, error) {
+	s.log.Infof("DBProductService_SetStatusByCategory category=%s status=%s", category, status)
+
+	category = strings.TrimSpace(category)
+	if category == "" {
+		return 0, fmt.Errorf("%w: category is required", domain.ErrInvalidArgument)
+	}
+	if status != domain.ProductStatusActive && status != domain.ProductStatusInactive {
+		return 0, fmt.Errorf("%w: status must be %d (active) or %d (inactive)", domain.ErrInvalidArgument, domain.ProductStatusActive, domain.ProductStatusInactive)
+	}
+
+	// Use the repository to update the category
+	productIDs, err := s.repo.SetStatusByCategory(ctx, category, status)
+	if err != nil {
+		return 0, err
+	}
+
+	s.log.Infof("DBProductService_SetStatusByCategory category=%s status=%s affected=%d", category, status, len(productIDs))
+	return int64(len(productIDs)), nil
+}
+
+// ReserveStock takes the requested quantities from the products' stock, all or none
+func (s *DBProductService) ReserveStock(ctx context.Context, changes []domain.StockChange) error {
+	s.log.Infof("DBProductService_ReserveStock count=%d", len(changes))
+
+	merged, err := mergeStockChanges(changes)
+	if err != nil {
+		return err
+	}
+
+	// Use the repository to reserve the stock
+	return s.repo.ReserveStock(ctx, merged)
+}
+
+// ReleaseStock returns previously reserved quantities to the products' stock
+func (s *DBProductService) ReleaseStock(ctx context.Context, changes []domain.StockChange) error {
+	s.log.Infof("DBProductService_ReleaseStock count=%d", len(changes))
+
+	merged, err := mergeStockChanges(changes)
+	if err != nil {
+		return err
+	}
+
+	// Use the repository to release the stock
+	return s.repo.ReleaseStock(ctx, merged)
+}
+
+// UpdateStock sets the stock of a product without reading and rewriting its other fields,
+// so it cannot race with a concurrent update of them. Products without stock move out of
+// stock and back, as described by ProductStatus.ForStock.
+func (s *DBProductService) UpdateStock(ctx context.Context, productID string, stock int32) (*domain.Product, error) {
+	s.log.Infof("DBProductService_UpdateStock productID=%s stock=%d", productID, stock)
+
+	if productID == "" {
+		return nil, fmt.Errorf("%w: product_id is required", domain.ErrInvalidArgument)
+	}
+	if stock < 0 {
+		return nil, fmt.Errorf("%w: stock cannot be negative", domain.ErrInvalidArgument)
+	}
+
+	// Use the repository to set the stock
+	return s.repo.SetStock(ctx, productID, stock)
+}
+
+// RecordProductRating stores the rating aggregate computed by the reviews system. It is
+// meant for the consumer of the reviews events, so no API exposes it.
+func (s *DBProductService) RecordProductRating(ctx context.Context, productID string, rating domain.RatingAggregate) (*domain.Product, error) {
+	s.log.Infof("DBProductService_RecordProductRating productID=%s averageRating=%.2f reviewCount=%d",
+		productID, rating.AverageRating, rating.ReviewCount)
+
+	if productID == "" {
+		return nil, fmt.Errorf("%w: product_id is required", domain.ErrInvalidArgument)
+	}
+	if err := rating.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Use the repository to store the aggregate
+	return s.repo.RecordProductRating(ctx, productID, rating)
+}
+
+// CheckAvailability reports, for each item in request order, whether the product's stock covers
+// the quantity. It applies the same rule as ReserveStock, so an available item can be reserved
+// unless stock changes in between. All products are loaded with a single repository call.
+func (s *DBProductService) CheckAvailability(ctx context.Context, items []domain.StockChange) ([]domain.Availability, error) {
+	s.log.Infof("DBProductService_CheckAvailability items=%d", len(items))
+
+	// Validate the batch
+	if len(items) == 0 {
+		return nil, fmt.Errorf("%w: at least one item is required", domain.ErrInvalidArgument)
+	}
+	if len(items) > MaxAvailabilityItems {
+		return nil, fmt.Errorf("%w: at most %d items can be checked at once, got %d", domain.ErrInvalidArgument, MaxAvailabilityItems, len(items))
+	}
+
+	ids := make([]string, 0, len(items))
+	seen := make(map[string]bool, len(items))
+	for idx, item := range items {
+		if item.ProductID == "" {
+			return nil, fmt.Errorf("%w: items[%d]: product_id is required", domain.ErrInvalidArgument, idx)
+		}
+		if item.Quantity <= 0 {
+			return nil, fmt.Errorf("%w: items[%d]: qty must be greater than 0", domain.ErrInvalidArgument, idx)
+		}
+		if !seen[item.ProductID] {
+			seen[item.ProductID] = true
+			ids = append(ids, item.ProductID)
+		}
+	}
+
+	// Load every product at once
+	products, err := s.repo.GetProducts(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	availability := make([]domain.Availability, len(items))
+	for i, item := range items {
+		product, ok := products[item.ProductID]
+		if !ok {
+			availability[i] = domain.Availability{ProductID: item.ProductID, NotFound: true}
+			continue
+		}
+		availability[i] = domain.Availability{
+			ProductID: item.ProductID,
+			Available: product.Stock >= item.Quantity,
+			Stock:     product.Stock,
+		}
+	}
+
+	return availability, nil
+}
+
+// mergeStockChanges validates stock changes and sums the quantities of repeated products.
+// The result is sorted by product ID so concurrent reservations lock rows in the same order.
+func mergeStockChanges(changes []domain.StockChange) ([]domain.StockChange, error) {
+	if len(changes) == 0 {
+		return nil, fmt.Errorf("%w: at least one item is required", domain.ErrInvalidArgument)
+	}
+
+	quantities := make(map[string]int32, len(changes))
+	for idx, change := range changes {
+		if change.ProductID == "" {
+			return nil, fmt.Errorf("%w: items[%d]: product_id is required", domain.ErrInvalidArgument, idx)
+		}
+		if change.Quantity <= 0 {
+			return nil, fmt.Errorf("%w: items[%d]: quantity must be greater than 0", domain.ErrInvalidArgument, idx)
+		}
+		quantities[change.ProductID] += change.Quantity
+	}
+
+	merged := make([]domain.StockChange, 0, len(quantities))
+	for productID, quantity := range quantities {
+		merged = append(merged, domain.StockChange{ProductID: productID, Quantity: quantity})
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		return merged[i].ProductID < merged[j].ProductID
+	})
+
+	return merged, nil
+}
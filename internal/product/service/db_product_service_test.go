@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"go-bootiful-ordering/internal/pkg/cache"
+	"go-bootiful-ordering/internal/product/domain"
+	"go-bootiful-ordering/internal/product/repository"
+	"go.uber.org/zap"
+)
+
+// updateRecordingRepository serves products from a map and saves updates to it like the
+// database does, bumping the version and updated_at; the methods it does not implement are
+// not expected to be called
+type updateRecordingRepository struct {
+	repository.ProductRepository
+
+	products map[string]*domain.Product
+	updates  int
+}
+
+func (r *updateRecordingRepository) GetProduct(ctx context.Context, productID string) (*domain.Product, error) {
+	product, ok := r.products[productID]
+	if !ok {
+		return nil, domain.ErrProductNotFound
+	}
+	copied := *product
+	return &copied, nil
+}
+
+func (r *updateRecordingRepository) GetProducts(ctx context.Context, productIDs []string) (map[string]*domain.Product, error) {
+	products := make(map[string]*domain.Product, len(productIDs))
+	for _, productID := range productIDs {
+		if product, err := r.GetProduct(ctx, productID); err == nil {
+			products[productID] = product
+		}
+	}
+	return products, nil
+}
+
+func (r *updateRecordingRepository) UpdateProduct(ctx context.Context, product *domain.Product) (*domain.Product, error) {
+	r.updates++
+	existing := r.products[product.ID]
+	if product.Version != 0 && product.Version != existing.Version {
+		return nil, domain.ErrVersionConflict
+	}
+	updated := *product
+	updated.Version = existing.Version + 1
+	updated.UpdatedAt = time.Now()
+	r.products[product.ID] = &updated
+	copied := updated
+	return &copied, nil
+}
+
+// delCountingCache counts the keys deleted from the cache
+type delCountingCache struct {
+	*cache.MemoryCache
+
+	mu      sync.Mutex
+	deleted []string
+}
+
+func (c *delCountingCache) Del(ctx context.Context, keys ...string) error {
+	c.mu.Lock()
+	c.deleted = append(c.deleted, keys...)
+	c.mu.Unlock()
+	return c.MemoryCache.Del(ctx, keys...)
+}
+
+func TestUpdateProductSkipsUnchangedProducts(t *testing.T) {
+	updatedAt := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	stored := domain.Product{
+		ID: "p1", Name: "Mug", Description: "Blue mug", Price: 1200, Stock: 5, Category: "home",
+		Status: domain.ProductStatusActive, PhysicalAttributes: domain.PhysicalAttributes{WeightGrams: 300},
+		UpdatedAt: updatedAt, Version: 3,
+	}
+
+	tests := []struct {
+		name        string
+		productName string
+		price       int64
+		version     int
+		wantUpdates int
+		wantVersion int
+		wantErr     error
+	}{
+		{name: "unchanged without a version", productName: "Mug", price: 1200, wantVersion: 3},
+		{name: "unchanged at the current version", productName: "Mug", price: 1200, version: 3, wantVersion: 3},
+		{name: "unchanged once sanitized", productName: "  Mug\t", price: 1200, version: 3, wantVersion: 3},
+		{name: "unchanged at a stale version", productName: "Mug", price: 1200, version: 2, wantErr: domain.ErrVersionConflict},
+		{name: "price changed", productName: "Mug", price: 1500, version: 3, wantUpdates: 1, wantVersion: 4},
+		{name: "name changed", productName: "Cup", price: 1200, wantUpdates: 1, wantVersion: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			product := stored
+			repo := &updateRecordingRepository{products: map[string]*domain.Product{"p1": &product}}
+			memoryCache := &delCountingCache{MemoryCache: cache.NewMemoryCache()}
+			cached := repository.NewRedisProductRepository(zap.NewNop(), memoryCache, repo, repository.CacheConfig{})
+			svc := NewDBProductService(zap.NewNop().Sugar(), cached, domain.NewFieldLimits(0, 0, 0), nil)
+
+			// Cache the product, as an earlier read would have
+			if _, err := cached.GetProduct(ctx, "p1"); err != nil {
+				t.Fatalf("GetProduct() error = %v", err)
+			}
+			got, err := svc.UpdateProduct(ctx, "p1", tt.productName, stored.Description, tt.price, stored.Stock, stored.Category, stored.PhysicalAttributes, tt.version)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("UpdateProduct() error = %v, want %v", err, tt.wantErr)
+				}
+				if repo.updates != 0 {
+					t.Errorf("UpdateProduct() wrote %d times, want none", repo.updates)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UpdateProduct() error = %v", err)
+			}
+
+			if repo.updates != tt.wantUpdates {
+				t.Errorf("UpdateProduct() wrote %d times, want %d", repo.updates, tt.wantUpdates)
+			}
+			if got.Version != tt.wantVersion || repo.products["p1"].Version != tt.wantVersion {
+				t.Errorf("UpdateProduct() version = %d, stored %d, want %d", got.Version, repo.products["p1"].Version, tt.wantVersion)
+			}
+			if tt.wantUpdates == 0 {
+				if !got.UpdatedAt.Equal(updatedAt) {
+					t.Errorf("UpdateProduct() updated_at = %s, want it left at %s", got.UpdatedAt, updatedAt)
+				}
+				if len(memoryCache.deleted) != 0 {
+					t.Errorf("UpdateProduct() invalidated %v, want nothing invalidated", memoryCache.deleted)
+				}
+			} else if len(memoryCache.deleted) == 0 {
+				t.Errorf("UpdateProduct() invalidated nothing after a write")
+			}
+		})
+	}
+}
+
+// deleteRecordingRepository records the IDs passed to DeleteProducts, reporting the unknown
+// ones as not found
+type deleteRecordingRepository struct {
+	repository.ProductRepository
+
+	known map[string]bool
+	calls [][]string
+}
+
+func (r *deleteRecordingRepository) DeleteProducts(ctx context.Context, productIDs []string) (int64, map[string]error) {
+	r.calls = append(r.calls, productIDs)
+	var deleted int64
+	failed := make(map[string]error)
+	for _, id := range productIDs {
+		if !r.known[id] {
+			failed[id] = domain.ErrProductNotFound
+			continue
+		}
+		deleted++
+	}
+	return deleted, failed
+}
+
+func TestDeleteProductsValidatesTheBatch(t *testing.T) {
+	oversized := make([]string, MaxBulkDeleteProducts+1)
+	for i := range oversized {
+		oversized[i] = fmt.Sprintf("p%d", i)
+	}
+
+	tests := []struct {
+		name        string
+		ids         []string
+		wantIDs     []string
+		wantDeleted int64
+		wantFailed  []string
+		wantErr     error
+	}{
+		{name: "mixed batch", ids: []string{"p1", "missing", "p2"}, wantIDs: []string{"p1", "missing", "p2"}, wantDeleted: 2, wantFailed: []string{"missing"}},
+		{name: "duplicates and empty IDs", ids: []string{"p1", "", "p1", "p2", "p2"}, wantIDs: []string{"p1", "p2"}, wantDeleted: 2},
+		{name: "duplicates of the limit", ids: append(append([]string{}, oversized[:MaxBulkDeleteProducts]...), "p0"), wantIDs: oversized[:MaxBulkDeleteProducts], wantFailed: oversized[3:MaxBulkDeleteProducts], wantDeleted: 3},
+		{name: "no IDs", ids: nil, wantErr: domain.ErrInvalidArgument},
+		{name: "only empty IDs", ids: []string{"", ""}, wantErr: domain.ErrInvalidArgument},
+		{name: "above the limit", ids: oversized, wantErr: domain.ErrInvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &deleteRecordingRepository{known: map[string]bool{"p0": true, "p1": true, "p2": true}}
+			svc := NewDBProductService(zap.NewNop().Sugar(), repo, domain.NewFieldLimits(0, 0, 0), nil)
+
+			deleted, failed, err := svc.DeleteProducts(context.Background(), tt.ids)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("DeleteProducts() error = %v, want %v", err, tt.wantErr)
+				}
+				if len(repo.calls) != 0 {
+					t.Errorf("DeleteProducts() reached the repository with %d IDs, want a rejected batch", len(repo.calls[0]))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DeleteProducts() error = %v", err)
+			}
+
+			if len(repo.calls) != 1 || !reflect.DeepEqual(repo.calls[0], tt.wantIDs) {
+				t.Errorf("repository DeleteProducts calls = %v, want one with %v", repo.calls, tt.wantIDs)
+			}
+			if deleted != tt.wantDeleted {
+				t.Errorf("DeleteProducts() deleted = %d, want %d", deleted, tt.wantDeleted)
+			}
+			if len(failed) != len(tt.wantFailed) {
+				t.Errorf("DeleteProducts() failed %d IDs, want %d", len(failed), len(tt.wantFailed))
+			}
+			for _, id := range tt.wantFailed {
+				if !errors.Is(failed[id], domain.ErrProductNotFound) {
+					t.Errorf("DeleteProducts() failed[%q] = %v, want ErrProductNotFound", id, failed[id])
+				}
+			}
+		})
+	}
+}
@@ -2,14 +2,31 @@ package service
 
 import (
 	"context"
+	"errors"
 	"go-bootiful-ordering/internal/product/domain"
 )
 
+// ErrUnsupported is returned by operations a ProductService implementation cannot perform
+var ErrUnsupported = errors.New("operation not supported")
+
 // ProductService defines the interface for product operations
 type ProductService interface {
-	CreateProduct(ctx context.Context, name, description string, price int64, stock int32, category string) (*domain.Product, error)
+	CreateProduct(ctx context.Context, name, description string, price int64, stock int32, category string, physical domain.PhysicalAttributes) (*domain.Product, error)
+	CreateProducts(ctx context.Context, products []*domain.Product) ([]*domain.Product, error)
 	GetProduct(ctx context.Context, productID string) (*domain.Product, error)
-	ListProducts(ctx context.Context, category string, pageSize int32, pageToken string) ([]*domain.Product, string, error)
-	UpdateProduct(ctx context.Context, productID, name, description string, price int64, stock int32, category string) (*domain.Product, error)
+	GetProductsByIDs(ctx context.Context, productIDs []string) (map[string]*domain.Product, error)
+	GetProductIncludingDeleted(ctx context.Context, productID string) (*domain.Product, error)
+	ListProducts(ctx context.Context, category string, opts domain.ProductListOptions, pageSize int32, pageToken string) ([]*domain.Product, string, error)
+	CountProducts(ctx context.Context, category string, opts domain.ProductListOptions) (int64, error)
+	SearchProducts(ctx context.Context, query string, pageSize int32, pageToken string) ([]*domain.Product, string, error)
+	UpdateProduct(ctx context.Context, productID, name, description string, price int64, stock int32, category string, physical domain.PhysicalAttributes, version int) (*domain.Product, error)
+	PatchProduct(ctx context.Context, productID string, patch domain.ProductPatch) (*domain.Product, error)
 	DeleteProduct(ctx context.Context, productID string) error
+	DeleteProducts(ctx context.Context, productIDs []string) (int64, map[string]error, error)
+	SetStatusByCategory(ctx context.Context, category string, status domain.ProductStatus) (int64, error)
+	ReserveStock(ctx context.Context, changes []domain.StockChange) error
+	ReleaseStock(ctx context.Context, changes []domain.StockChange) error
+	CheckAvailability(ctx context.Context, items []domain.StockChange) ([]domain.Availability, error)
+	UpdateStock(ctx context.Context, productID string, stock int32) (*domain.Product, error)
+	RecordProductRating(ctx context.Context, productID string, rating domain.RatingAggregate) (*domain.Product, error)
 }
@@ -0,0 +1,269 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go-bootiful-ordering/gen/product/v1"
+	"go-bootiful-ordering/internal/product/domain"
+	"go-bootiful-ordering/internal/product/protoconv"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RemoteProductService provides an implementation of ProductService that calls a product
+// service over gRPC, so other services can use products through the same interface.
+// Operations without an RPC return ErrUnsupported.
+type RemoteProductService struct {
+	log    *zap.SugaredLogger
+	client productv1.ProductServiceClient
+}
+
+// NewRemoteProductService creates a new RemoteProductService
+func NewRemoteProductService(log *zap.SugaredLogger, client productv1.ProductServiceClient) *RemoteProductService {
+	return &RemoteProductService{
+		log:    log,
+		client: client,
+	}
+}
+
+// remoteError converts a gRPC error from the product service into the domain errors the callers expect
+func remoteError(err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	switch st.Code() {
+	case codes.InvalidArgument:
+		return fmt.Errorf("%w: %s", domain.ErrInvalidArgument, st.Message())
+	case codes.NotFound:
+		return fmt.Errorf("%w: %s", domain.ErrProductNotFound, st.Message())
+	case codes.FailedPrecondition:
+		return fmt.Errorf("%w: %s", domain.ErrInsufficientStock, st.Message())
+	case codes.Aborted:
+		return fmt.Errorf("%w: %s", domain.ErrVersionConflict, st.Message())
+	default:
+		return err
+	}
+}
+
+// CreateProduct creates a product on the remote service
+func (s *RemoteProductService) CreateProduct(ctx context.Context, name, description string, price int64, stock int32, category string, physical domain.PhysicalAttributes) (*domain.Product, error) {
+	s.log.Infof("RemoteProductService_CreateProduct name=%s category=%s", name, category)
+
+	resp, err := s.client.CreateProduct(ctx, &productv1.CreateProductRequest{
+		Name:        name,
+		Description: description,
+		Price:       price,
+		Stock:       stock,
+		Category:    category,
+		WeightGrams: physical.WeightGrams,
+		LengthMm:    physical.LengthMM,
+		WidthMm:     physical.WidthMM,
+		HeightMm:    physical.HeightMM,
+	})
+	if err != nil {
+		return nil, remoteError(err)
+	}
+
+	return protoconv.ProductFromProto(resp.Product), nil
+}
+
+// CreateProducts creates several products on the remote service in one call, all or none
+func (s *RemoteProductService) CreateProducts(ctx context.Context, products []*domain.Product) ([]*domain.Product, error) {
+	s.log.Infof("RemoteProductService_CreateProducts count=%d", len(products))
+
+	resp, err := s.client.BatchCreateProducts(ctx, &productv1.BatchCreateProductsRequest{
+		Products: protoconv.ProductsToCreateRequests(products),
+	})
+	if err != nil {
+		return nil, remoteError(err)
+	}
+
+	return protoconv.ProductsFromProto(resp.Products), nil
+}
+
+// GetProduct retrieves a product from the remote service
+func (s *RemoteProductService) GetProduct(ctx context.Context, productID string) (*domain.Product, error) {
+	s.log.Infof("RemoteProductService_GetProduct productID=%s", productID)
+
+	resp, err := s.client.GetProduct(ctx, &productv1.GetProductRequest{ProductId: productID})
+	if err != nil {
+		return nil, remoteError(err)
+	}
+
+	return protoconv.ProductFromProto(resp.Product), nil
+}
+
+// GetProductsByIDs retrieves several products from the remote service in one call
+func (s *RemoteProductService) GetProductsByIDs(ctx context.Context, productIDs []string) (map[string]*domain.Product, error) {
+	s.log.Infof("RemoteProductService_GetProductsByIDs count=%d", len(productIDs))
+
+	resp, err := s.client.BatchGetProducts(ctx, &productv1.BatchGetProductsRequest{ProductIds: productIDs})
+	if err != nil {
+		return nil, remoteError(err)
+	}
+
+	products := make(map[string]*domain.Product, len(resp.Products))
+	for _, product := range protoconv.ProductsFromProto(resp.Products) {
+		products[product.ID] = product
+	}
+	return products, nil
+}
+
+// GetProductIncludingDeleted is not supported because the product API does not return deleted products
+func (s *RemoteProductService) GetProductIncludingDeleted(ctx context.Context, productID string) (*domain.Product, error) {
+	return nil, fmt.Errorf("%w: deleted products are not available remotely", ErrUnsupported)
+}
+
+// ListProducts lists products on the remote service; the page token is passed through unchanged
+func (s *RemoteProductService) ListProducts(ctx context.Context, category string, opts domain.ProductListOptions, pageSize int32, pageToken string) ([]*domain.Product, string, error) {
+	s.log.Infof("RemoteProductService_ListProducts category=%s pageSize=%d pageToken=%s",
+		category, pageSize, pageToken)
+
+	resp, err := s.client.ListProducts(ctx, &productv1.ListProductsRequest{
+		Category:  category,
+		PageSize:  pageSize,
+		PageToken: pageToken,
+		SortBy:    string(opts.SortBy),
+		SortDir:   string(opts.SortDir),
+		MinPrice:  opts.MinPrice,
+		MaxPrice:  opts.MaxPrice,
+	})
+	if err != nil {
+		return nil, "", remoteError(err)
+	}
+
+	return protoconv.ProductsFromProto(resp.Products), resp.NextPageToken, nil
+}
+
+// CountProducts counts products on the remote service. The API has no count RPC, so it asks
+// ListProducts for the total along with the smallest page.
+func (s *RemoteProductService) CountProducts(ctx context.Context, category string, opts domain.ProductListOptions) (int64, error) {
+	s.log.Infof("RemoteProductService_CountProducts category=%s", category)
+
+	resp, err := s.client.ListProducts(ctx, &productv1.ListProductsRequest{
+		Category:     category,
+		PageSize:     1,
+		MinPrice:     opts.MinPrice,
+		MaxPrice:     opts.MaxPrice,
+		IncludeTotal: true,
+	})
+	if err != nil {
+		return 0, remoteError(err)
+	}
+
+	return resp.GetTotalSize(), nil
+}
+
+// SearchProducts searches products on the remote service
+func (s *RemoteProductService) SearchProducts(ctx context.Context, query string, pageSize int32, pageToken string) ([]*domain.Product, string, error) {
+	s.log.Infof("RemoteProductService_SearchProducts query=%q pageSize=%d pageToken=%s",
+		query, pageSize, pageToken)
+
+	resp, err := s.client.SearchProducts(ctx, &productv1.SearchProductsRequest{
+		Query:     query,
+		PageSize:  pageSize,
+		PageToken: pageToken,
+	})
+	if err != nil {
+		return nil, "", remoteError(err)
+	}
+
+	return protoconv.ProductsFromProto(resp.Products), resp.NextPageToken, nil
+}
+
+// UpdateProduct updates a product on the remote service
+func (s *RemoteProductService) UpdateProduct(ctx context.Context, productID, name, description string, price int64, stock int32, category string, physical domain.PhysicalAttributes, version int) (*domain.Product, error) {
+	s.log.Infof("RemoteProductService_UpdateProduct productID=%s name=%s category=%s",
+		productID, name, category)
+
+	resp, err := s.client.UpdateProduct(ctx, &productv1.UpdateProductRequest{
+		ProductId:   productID,
+		Name:        name,
+		Description: description,
+		Price:       price,
+		Stock:       stock,
+		Category:    category,
+		WeightGrams: physical.WeightGrams,
+		LengthMm:    physical.LengthMM,
+		WidthMm:     physical.WidthMM,
+		HeightMm:    physical.HeightMM,
+		Version:     int64(version),
+	})
+	if err != nil {
+		return nil, remoteError(err)
+	}
+
+	return protoconv.ProductFromProto(resp.Product), nil
+}
+
+// PatchProduct is not supported because the product API has no partial update RPC, and
+// reading and rewriting the product could overwrite a concurrent change
+func (s *RemoteProductService) PatchProduct(ctx context.Context, productID string, patch domain.ProductPatch) (*domain.Product, error) {
+	return nil, fmt.Errorf("%w: partial updates are not available remotely", ErrUnsupported)
+}
+
+// DeleteProduct deletes a product on the remote service
+func (s *RemoteProductService) DeleteProduct(ctx context.Context, productID string) error {
+	s.log.Infof("RemoteProductService_DeleteProduct productID=%s", productID)
+
+	if _, err := s.client.DeleteProduct(ctx, &productv1.DeleteProductRequest{ProductId: productID}); err != nil {
+		return remoteError(err)
+	}
+	return nil
+}
+
+// DeleteProducts is not supported because the product API has no bulk delete RPC, and
+// deleting one by one would lose its single transaction
+func (s *RemoteProductService) DeleteProducts(ctx context.Context, productIDs []string) (int64, map[string]error, error) {
+	return 0, nil, fmt.Errorf("%w: bulk deletes are not available remotely", ErrUnsupported)
+}
+
+// SetStatusByCategory is not supported because the product API has no bulk status RPC
+func (s *RemoteProductService) SetStatusByCategory(ctx context.Context, category string, status domain.ProductStatus) (int64, error) {
+	return 0, fmt.Errorf("%w: bulk status changes are not available remotely", ErrUnsupported)
+}
+
+// ReserveStock reserves stock on the remote service, all or none
+func (s *RemoteProductService) ReserveStock(ctx context.Context, changes []domain.StockChange) error {
+	s.log.Infof("RemoteProductService_ReserveStock count=%d", len(changes))
+
+	if _, err := s.client.ReserveStock(ctx, &productv1.ReserveStockRequest{Items: protoconv.StockChangesToProto(changes)}); err != nil {
+		return remoteError(err)
+	}
+	return nil
+}
+
+// ReleaseStock returns reserved stock on the remote service
+func (s *RemoteProductService) ReleaseStock(ctx context.Context, changes []domain.StockChange) error {
+	s.log.Infof("RemoteProductService_ReleaseStock count=%d", len(changes))
+
+	if _, err := s.client.ReleaseStock(ctx, &productv1.ReleaseStockRequest{Items: protoconv.StockChangesToProto(changes)}); err != nil {
+		return remoteError(err)
+	}
+	return nil
+}
+
+// CheckAvailability is not supported because the product API has no availability RPC
+func (s *RemoteProductService) CheckAvailability(ctx context.Context, items []domain.StockChange) ([]domain.Availability, error) {
+	return nil, fmt.Errorf("%w: availability checks are not available remotely", ErrUnsupported)
+}
+
+// UpdateStock sets the stock of a product on the remote service
+func (s *RemoteProductService) UpdateStock(ctx context.Context, productID string, stock int32) (*domain.Product, error) {
+	s.log.Infof("RemoteProductService_UpdateStock productID=%s stock=%d", productID, stock)
+
+	resp, err := s.client.UpdateStock(ctx, &productv1.UpdateStockRequest{ProductId: productID, Stock: stock})
+	if err != nil {
+		return nil, remoteError(err)
+	}
+
+	return protoconv.ProductFromProto(resp.Product), nil
+}
+
+// RecordProductRating is not supported because ratings are recorded by the service owning the products
+func (s *RemoteProductService) RecordProductRating(ctx context.Context, productID string, rating domain.RatingAggregate) (*domain.Product, error) {
+	return nil, fmt.Errorf("%w: ratings cannot be recorded remotely", ErrUnsupported)
+}
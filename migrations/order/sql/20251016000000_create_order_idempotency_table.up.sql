@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS order_idempotency (
+    customer_id VARCHAR(36) NOT NULL,
+    idempotency_key VARCHAR(255) NOT NULL,
+    order_id VARCHAR(36) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (customer_id, idempotency_key)
+);
@@ -0,0 +1 @@
+ALTER TABLE order_outbox ADD COLUMN IF NOT EXISTS trace_id VARCHAR(32) NOT NULL DEFAULT '';
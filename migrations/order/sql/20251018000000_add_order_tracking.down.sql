@@ -0,0 +1,2 @@
+ALTER TABLE orders DROP COLUMN IF EXISTS carrier;
+ALTER TABLE orders DROP COLUMN IF EXISTS tracking_number;
@@ -0,0 +1,2 @@
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS tracking_number VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS carrier VARCHAR(50) NOT NULL DEFAULT '';
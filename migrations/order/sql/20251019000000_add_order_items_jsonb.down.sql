@@ -0,0 +1,2 @@
+-- Items of orders created with order.itemStorage=jsonb exist only in this column
+ALTER TABLE orders DROP COLUMN IF EXISTS items;
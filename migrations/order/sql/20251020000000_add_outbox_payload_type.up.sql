@@ -0,0 +1 @@
+ALTER TABLE order_outbox ADD COLUMN IF NOT EXISTS payload_type VARCHAR(64) NOT NULL DEFAULT '';
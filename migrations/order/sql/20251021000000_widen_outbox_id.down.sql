@@ -0,0 +1 @@
+ALTER TABLE order_outbox ALTER COLUMN id TYPE UUID USING id::uuid;
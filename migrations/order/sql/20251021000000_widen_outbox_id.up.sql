@@ -0,0 +1 @@
+ALTER TABLE order_outbox ALTER COLUMN id TYPE VARCHAR(36);
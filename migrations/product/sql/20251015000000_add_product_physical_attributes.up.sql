@@ -0,0 +1,5 @@
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS weight_grams INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS length_mm INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS width_mm INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS height_mm INTEGER NOT NULL DEFAULT 0;
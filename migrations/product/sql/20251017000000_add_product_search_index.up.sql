@@ -0,0 +1,3 @@
+CREATE INDEX IF NOT EXISTS idx_products_search ON products USING GIN (
+    (setweight(to_tsvector('english', coalesce(name, '')), 'A') || setweight(to_tsvector('english', coalesce(description, '')), 'B'))
+);
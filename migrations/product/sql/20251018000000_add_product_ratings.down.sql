@@ -0,0 +1,4 @@
+ALTER TABLE products
+    DROP COLUMN IF EXISTS rated_at,
+    DROP COLUMN IF EXISTS review_count,
+    DROP COLUMN IF EXISTS average_rating;
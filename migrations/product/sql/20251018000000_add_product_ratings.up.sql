@@ -0,0 +1,4 @@
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS average_rating REAL NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS review_count INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS rated_at TIMESTAMP;
@@ -0,0 +1,63 @@
+#!/bin/bash
+
+# Read benchmark for the order item storage
+# Run it once against an order service started with ORDER_ITEMSTORAGE=table and once with
+# ORDER_ITEMSTORAGE=jsonb, then compare the reported timings
+
+# Set the base URL, the number of items per order and the number of timed reads
+BASE_URL="${BASE_URL:-http://localhost:8080}"
+ITEMS="${ITEMS:-20}"
+ORDERS="${ORDERS:-20}"
+READS="${READS:-200}"
+
+# Colors for output
+GREEN='\033[0;32m'
+RED='\033[0;31m'
+NC='\033[0m' # No Color
+
+# Function to print success message
+success() {
+  echo -e "${GREEN}SUCCESS: $1${NC}"
+}
+
+# Function to print error message
+error() {
+  echo -e "${RED}ERROR: $1${NC}"
+}
+
+# Create the orders of one customer, each with ITEMS items
+CUSTOMER_ID="customer-bench-$(date +%s)"
+ORDER_ITEMS=""
+for i in $(seq 1 "$ITEMS"); do
+  ORDER_ITEMS+=$(printf '{"product_id": "00000000-0000-4000-8000-%012d", "quantity": 1, "price": 100},' "$i")
+done
+echo "Creating $ORDERS orders with $ITEMS items..."
+for i in $(seq 1 "$ORDERS"); do
+  CREATE_RESPONSE=$(curl -s -X POST "${BASE_URL}/orders" \
+    -H "Content-Type: application/json" \
+    -d "{\"customer_id\": \"${CUSTOMER_ID}\", \"items\": [${ORDER_ITEMS%,}]}")
+  ORDER_ID=$(echo $CREATE_RESPONSE | grep -o '"id":"[^"]*' | cut -d'"' -f4)
+  if [[ -z "$ORDER_ID" ]]; then
+    error "Failed to create order: $CREATE_RESPONSE"
+    exit 1
+  fi
+done
+
+# Time READS sequential requests to a URL and print the mean latency
+bench() {
+  local name=$1 url=$2
+  local start end
+  start=$(date +%s%N)
+  for _ in $(seq 1 "$READS"); do
+    if [[ $(curl -s -o /dev/null -w "%{http_code}" "$url") != "200" ]]; then
+      error "$name request failed: $url"
+      exit 1
+    fi
+  done
+  end=$(date +%s%N)
+  success "$name: $READS reads, mean $(( (end - start) / READS / 1000 ))us"
+}
+
+bench "GetOrder" "${BASE_URL}/orders/${ORDER_ID}"
+bench "ListOrders" "${BASE_URL}/orders?customer_id=${CUSTOMER_ID}&page_size=${ORDERS}"
+bench "ListOrderItems" "${BASE_URL}/orders/${ORDER_ID}/items?page_size=${ITEMS}"
@@ -0,0 +1,49 @@
+#!/bin/bash
+
+# Load test for the concurrency limit
+# Start the product service with a low limit, e.g. SERVER_MAXCONCURRENTREQUESTS=2,
+# then run this script to check that requests past the limit are rejected with 503
+
+# Set the base URL and the number of parallel requests
+BASE_URL="${BASE_URL:-http://localhost:8083}"
+REQUESTS="${REQUESTS:-50}"
+
+# Colors for output
+GREEN='\033[0;32m'
+RED='\033[0;31m'
+NC='\033[0m' # No Color
+
+# Function to print success message
+success() {
+  echo -e "${GREEN}SUCCESS: $1${NC}"
+}
+
+# Function to print error message
+error() {
+  echo -e "${RED}ERROR: $1${NC}"
+}
+
+# Fire the requests in parallel and collect the status codes
+echo "Sending $REQUESTS parallel requests..."
+STATUS_CODES=$(seq "$REQUESTS" | xargs -P "$REQUESTS" -I{} \
+  curl -s -o /dev/null -w "%{http_code}\n" "$BASE_URL/products?page_size=100")
+
+OK_COUNT=$(echo "$STATUS_CODES" | grep -c '^200$')
+REJECTED_COUNT=$(echo "$STATUS_CODES" | grep -c '^503$')
+echo "200: $OK_COUNT, 503: $REJECTED_COUNT"
+
+# Check that some requests were served and some were rejected
+if [ "$OK_COUNT" -gt 0 ] && [ "$REJECTED_COUNT" -gt 0 ]; then
+  success "Requests past the limit were rejected"
+else
+  error "Expected both served and rejected requests"
+  exit 1
+fi
+
+# Check that rejections were recorded in the metrics
+if curl -s "$BASE_URL/metrics" | grep -q 'rejected_requests_total{protocol="http"}'; then
+  success "Rejections recorded in metrics"
+else
+  error "Rejections missing from metrics"
+  exit 1
+fi
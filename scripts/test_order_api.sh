@@ -5,6 +5,38 @@
 
 # Set the base URL
 BASE_URL="http://localhost:8080"
+GRPC_ADDR="localhost:9090"
+
+# HTTP address of a second order service running with SERVICE_MODE=remote and
+# SERVICE_REMOTEADDR pointing at GRPC_ADDR; the remote mode checks are skipped when empty
+REMOTE_BASE_URL="${REMOTE_BASE_URL:-}"
+
+# HTTP address of the product service the order service checks items against when it runs
+# with ORDER_VALIDATEPRODUCTS=true; the product catalog checks are skipped when empty.
+# PRODUCT_DATABASE_URL (a psql connection string) additionally enables the inactive product check.
+PRODUCT_BASE_URL="${PRODUCT_BASE_URL:-}"
+PRODUCT_DATABASE_URL="${PRODUCT_DATABASE_URL:-}"
+
+# Set to true when the order service runs with ORDER_REQUIRETRACKING=true
+REQUIRE_TRACKING="${REQUIRE_TRACKING:-false}"
+
+# Admin API key of the order service; the order event checks are skipped when empty
+ADMIN_API_KEY="${ADMIN_API_KEY:-}"
+
+# The order service's ORDER_MAXEMBEDDEDITEMS
+MAX_EMBEDDED_ITEMS="${MAX_EMBEDDED_ITEMS:-100}"
+
+# A customer ID the customer service does not know, when the order service runs with
+# CUSTOMER_SERVICEURL; the unknown customer checks are skipped when empty
+UNKNOWN_CUSTOMER_ID="${UNKNOWN_CUSTOMER_ID:-}"
+
+# The order service's ORDER_ITEMSTORAGE, and a psql connection string to its database;
+# the item storage checks are skipped when ORDER_DATABASE_URL is empty
+ITEM_STORAGE="${ITEM_STORAGE:-table}"
+ORDER_DATABASE_URL="${ORDER_DATABASE_URL:-}"
+
+# Set STOCK_RESERVE=true when the order service reserves product stock
+STOCK_RESERVE="${STOCK_RESERVE:-false}"
 
 # Colors for output
 GREEN='\033[0;32m'
@@ -21,25 +53,350 @@ error() {
   echo -e "${RED}ERROR: $1${NC}"
 }
 
-# Test creating an order
-echo "Testing order creation..."
-CREATE_RESPONSE=$(curl -s -X POST "${BASE_URL}/orders" \
-  -H "Content-Type: application/json" \
-  -d '{
+# Test previewing an order
+echo "Testing order preview..."
+ORDER_REQUEST='{
     "customer_id": "customer123",
     "items": [
       {
-        "product_id": "product456",
+        "product_id": "3f1c2a9e-5b7d-4c11-9e2f-8a6b4d0c7e15",
         "quantity": 2,
         "price": 1000
       },
       {
-        "product_id": "product789",
+        "product_id": "9b8e7d6c-1a2b-4c3d-8e9f-0a1b2c3d4e5f",
         "quantity": 1,
         "price": 1500
       }
     ]
+  }'
+PREVIEW_RESPONSE=$(curl -s -X POST "${BASE_URL}/orders/preview" \
+  -H "Content-Type: application/json" \
+  -d "$ORDER_REQUEST")
+PREVIEW_TOTAL=$(echo $PREVIEW_RESPONSE | grep -o '"total_amount":[0-9]*' | cut -d':' -f2)
+
+if [[ -n "$PREVIEW_TOTAL" ]]; then
+  success "Order previewed with total: $PREVIEW_TOTAL"
+else
+  error "Failed to preview order: $PREVIEW_RESPONSE"
+  exit 1
+fi
+
+# Check that a malformed product ID among valid ones is rejected before any database work
+# (needs order.productIdFormat, uuid by default)
+MALFORMED_RESPONSE=$(curl -s -w "\n%{http_code}" -X POST "${BASE_URL}/orders" \
+  -H "Content-Type: application/json" \
+  -d '{
+    "customer_id": "customer123",
+    "items": [
+      {"product_id": "3f1c2a9e-5b7d-4c11-9e2f-8a6b4d0c7e15", "quantity": 1, "price": 1000},
+      {"product_id": "not-a-product-id", "quantity": 1, "price": 1000}
+    ]
   }')
+MALFORMED_STATUS=$(echo "$MALFORMED_RESPONSE" | tail -n1)
+
+if [[ $MALFORMED_STATUS == "400" && $MALFORMED_RESPONSE == *"items[1]"* ]]; then
+  success "Malformed product ID rejected with its item index"
+else
+  error "Expected 400 for a malformed product ID, got: $MALFORMED_RESPONSE"
+  exit 1
+fi
+
+# Check that orders for a customer unknown to the customer service are rejected
+if [[ -n "$UNKNOWN_CUSTOMER_ID" ]]; then
+  echo "Testing unknown customer checks..."
+  UNKNOWN_CUSTOMER_REQUEST="{\"customer_id\": \"${UNKNOWN_CUSTOMER_ID}\", \"items\": [{\"product_id\": \"3f1c2a9e-5b7d-4c11-9e2f-8a6b4d0c7e15\", \"quantity\": 1, \"price\": 1000}]}"
+  UNKNOWN_CUSTOMER_RESPONSE=$(curl -s -w "\n%{http_code}" -X POST "${BASE_URL}/orders" \
+    -H "Content-Type: application/json" \
+    -d "$UNKNOWN_CUSTOMER_REQUEST")
+  UNKNOWN_CUSTOMER_PREVIEW_STATUS=$(curl -s -o /dev/null -w "%{http_code}" -X POST "${BASE_URL}/orders/preview" \
+    -H "Content-Type: application/json" \
+    -d "$UNKNOWN_CUSTOMER_REQUEST")
+  if [[ $(echo "$UNKNOWN_CUSTOMER_RESPONSE" | tail -n1) == "400" && $UNKNOWN_CUSTOMER_RESPONSE == *"does not exist"* && $UNKNOWN_CUSTOMER_PREVIEW_STATUS == "400" ]]; then
+    success "Orders for an unknown customer rejected"
+  else
+    error "Expected 400 for an unknown customer, got: $UNKNOWN_CUSTOMER_RESPONSE (preview $UNKNOWN_CUSTOMER_PREVIEW_STATUS)"
+    exit 1
+  fi
+fi
+
+# Check that items are checked against the product catalog (order.validateProducts with the
+# default enforce price policy)
+if [[ -n "$PRODUCT_BASE_URL" ]]; then
+  echo "Testing product catalog checks..."
+  CATALOG_PRODUCT=$(curl -s -X POST "${PRODUCT_BASE_URL}/products" \
+    -H "Content-Type: application/json" \
+    -d '{"name": "Catalog Check Product", "description": "Priced by the catalog", "price": 1250, "stock": 10, "category": "Test"}')
+  CATALOG_PRODUCT_ID=$(echo $CATALOG_PRODUCT | grep -o '"id":"[^"]*' | cut -d'"' -f4)
+  if [[ -z "$CATALOG_PRODUCT_ID" ]]; then
+    error "Failed to create a product for the catalog checks: $CATALOG_PRODUCT"
+    exit 1
+  fi
+
+  MISSING_PRODUCT='00000000-0000-4000-8000-000000000000'
+  MISSING_RESPONSE=$(curl -s -w "\n%{http_code}" -X POST "${BASE_URL}/orders" \
+    -H "Content-Type: application/json" \
+    -d "{\"customer_id\": \"customer123\", \"items\": [
+      {\"product_id\": \"${CATALOG_PRODUCT_ID}\", \"quantity\": 1, \"price\": 1250},
+      {\"product_id\": \"${MISSING_PRODUCT}\", \"quantity\": 1, \"price\": 1000}]}")
+  if [[ $(echo "$MISSING_RESPONSE" | tail -n1) == "400" && $MISSING_RESPONSE == *"$MISSING_PRODUCT"* ]]; then
+    success "Order with a missing product rejected with its product ID"
+  else
+    error "Expected 400 for a missing product, got: $MISSING_RESPONSE"
+    exit 1
+  fi
+
+  MISMATCH_RESPONSE=$(curl -s -w "\n%{http_code}" -X POST "${BASE_URL}/orders" \
+    -H "Content-Type: application/json" \
+    -d "{\"customer_id\": \"customer123\", \"items\": [{\"product_id\": \"${CATALOG_PRODUCT_ID}\", \"quantity\": 1, \"price\": 1}]}")
+  if [[ $(echo "$MISMATCH_RESPONSE" | tail -n1) == "400" && $MISMATCH_RESPONSE == *"$CATALOG_PRODUCT_ID"* ]]; then
+    success "Order with a spoofed price rejected with its product ID"
+  else
+    error "Expected 400 for a price mismatch, got: $MISMATCH_RESPONSE"
+    exit 1
+  fi
+
+  CATALOG_PREVIEW=$(curl -s -X POST "${BASE_URL}/orders/preview" \
+    -H "Content-Type: application/json" \
+    -d "{\"customer_id\": \"customer123\", \"items\": [{\"product_id\": \"${CATALOG_PRODUCT_ID}\", \"quantity\": 2, \"price\": 1250}]}")
+  if [[ $CATALOG_PREVIEW == *'"total_amount":2500'* ]]; then
+    success "Order with the catalog price accepted"
+  else
+    error "Order with the catalog price was not accepted: $CATALOG_PREVIEW"
+    exit 1
+  fi
+
+  # A historical order keeps the price paid, while live previews and reorders use the new price
+  echo "Testing price sources..."
+  PRICED_ORDER=$(curl -s -X POST "${BASE_URL}/orders" \
+    -H "Content-Type: application/json" \
+    -d "{\"customer_id\": \"customer123\", \"items\": [{\"product_id\": \"${CATALOG_PRODUCT_ID}\", \"quantity\": 2, \"price\": 1250}]}")
+  PRICED_ORDER_ID=$(echo $PRICED_ORDER | grep -o '"id":"[^"]*' | head -n 1 | cut -d'"' -f4)
+  curl -s -o /dev/null -X PATCH "${PRODUCT_BASE_URL}/products/${CATALOG_PRODUCT_ID}" \
+    -H "Content-Type: application/json" \
+    -d '{"price": 1500}'
+
+  HISTORICAL_ORDER=$(curl -s "${BASE_URL}/orders/${PRICED_ORDER_ID}")
+  LIVE_PREVIEW=$(curl -s -X POST "${BASE_URL}/orders/preview?price_source=live" \
+    -H "Content-Type: application/json" \
+    -d "{\"customer_id\": \"customer123\", \"items\": [{\"product_id\": \"${CATALOG_PRODUCT_ID}\", \"quantity\": 2, \"price\": 1250}]}")
+  LIVE_REORDER=$(curl -s -w "\n%{http_code}" -X POST "${BASE_URL}/orders/${PRICED_ORDER_ID}/reorder")
+  SNAPSHOT_REORDER_STATUS=$(curl -s -o /dev/null -w "%{http_code}" -X POST "${BASE_URL}/orders/${PRICED_ORDER_ID}/reorder?price_source=snapshot")
+  UNKNOWN_SOURCE_STATUS=$(curl -s -o /dev/null -w "%{http_code}" -X POST "${BASE_URL}/orders/${PRICED_ORDER_ID}/reorder?price_source=cached")
+  MISSING_REORDER_STATUS=$(curl -s -o /dev/null -w "%{http_code}" -X POST "${BASE_URL}/orders/missing-order/reorder")
+
+  if [[ -n "$PRICED_ORDER_ID" && $HISTORICAL_ORDER == *'"price":1250'* && $HISTORICAL_ORDER == *'"total_amount":2500'* &&
+    $LIVE_PREVIEW == *'"total_amount":3000'* &&
+    $(echo "$LIVE_REORDER" | tail -n1) == "201" && $LIVE_REORDER == *'"total_amount":3000'* && $LIVE_REORDER != *"\"id\":\"${PRICED_ORDER_ID}\""* &&
+    "$SNAPSHOT_REORDER_STATUS" == "400" && "$UNKNOWN_SOURCE_STATUS" == "400" && "$MISSING_REORDER_STATUS" == "404" ]]; then
+    success "Historical order kept the price paid while the live preview and reorder used the current price"
+  else
+    error "Price source mismatch: historical=$HISTORICAL_ORDER livePreview=$LIVE_PREVIEW liveReorder=$LIVE_REORDER snapshotReorder=$SNAPSHOT_REORDER_STATUS unknownSource=$UNKNOWN_SOURCE_STATUS missing=$MISSING_REORDER_STATUS"
+    exit 1
+  fi
+
+  # Products cannot be deactivated through the API yet, so flip the status in the database
+  if [[ -n "$PRODUCT_DATABASE_URL" ]] && command -v psql >/dev/null 2>&1; then
+    psql "$PRODUCT_DATABASE_URL" -q -c "UPDATE products SET status = 2 WHERE id = '${CATALOG_PRODUCT_ID}'"
+    if command -v redis-cli >/dev/null 2>&1; then
+      redis-cli del "product:${CATALOG_PRODUCT_ID}" >/dev/null
+    fi
+    INACTIVE_RESPONSE=$(curl -s -w "\n%{http_code}" -X POST "${BASE_URL}/orders" \
+      -H "Content-Type: application/json" \
+      -d "{\"customer_id\": \"customer123\", \"items\": [{\"product_id\": \"${CATALOG_PRODUCT_ID}\", \"quantity\": 1, \"price\": 1250}]}")
+    if [[ $(echo "$INACTIVE_RESPONSE" | tail -n1) == "400" && $INACTIVE_RESPONSE == *"not available"* ]]; then
+      success "Order with an inactive product rejected"
+    else
+      error "Expected 400 for an inactive product, got: $INACTIVE_RESPONSE"
+      exit 1
+    fi
+  fi
+fi
+
+# Check that an order taking a product's last units moves it out of stock and cancelling
+# the order makes it active again (needs STOCK_RESERVE=true on the order service)
+if [[ -n "$PRODUCT_BASE_URL" && "$STOCK_RESERVE" == "true" ]]; then
+  echo "Testing product status with stock reservation..."
+  LAST_UNITS_PRODUCT=$(curl -s -X POST "${PRODUCT_BASE_URL}/products" \
+    -H "Content-Type: application/json" \
+    -d '{"name": "Last Units Product", "description": "Two left", "price": 700, "stock": 2, "category": "Test"}')
+  LAST_UNITS_ID=$(echo $LAST_UNITS_PRODUCT | grep -o '"id":"[^"]*' | cut -d'"' -f4)
+  LAST_UNITS_ORDER=$(curl -s -X POST "${BASE_URL}/orders" \
+    -H "Content-Type: application/json" \
+    -d "{\"customer_id\": \"customer123\", \"items\": [{\"product_id\": \"${LAST_UNITS_ID}\", \"quantity\": 2, \"price\": 700}]}")
+  LAST_UNITS_ORDER_ID=$(echo $LAST_UNITS_ORDER | grep -o '"id":"[^"]*' | head -n 1 | cut -d'"' -f4)
+  SOLD_OUT_PRODUCT=$(curl -s "${PRODUCT_BASE_URL}/products/${LAST_UNITS_ID}")
+  curl -s -o /dev/null -X POST "${BASE_URL}/orders/${LAST_UNITS_ORDER_ID}/cancel"
+  RESTOCKED_PRODUCT=$(curl -s "${PRODUCT_BASE_URL}/products/${LAST_UNITS_ID}")
+  if [[ $SOLD_OUT_PRODUCT == *'"stock":0'* && $SOLD_OUT_PRODUCT == *'"status":3'* && $RESTOCKED_PRODUCT == *'"stock":2'* && $RESTOCKED_PRODUCT == *'"status":1'* ]]; then
+    success "Reserved product went out of stock and came back on cancellation"
+  else
+    error "Product status did not follow the reservation: $LAST_UNITS_ORDER $SOLD_OUT_PRODUCT $RESTOCKED_PRODUCT"
+    exit 1
+  fi
+
+  # Check that an order for more than the stock is rejected, and that concurrent orders for
+  # the last units sell exactly the stock without ever taking it below zero
+  echo "Testing stock reservation races..."
+  LIMITED_STOCK=5
+  LIMITED_ORDERS=20
+  LIMITED_PRODUCT=$(curl -s -X POST "${PRODUCT_BASE_URL}/products" \
+    -H "Content-Type: application/json" \
+    -d "{\"name\": \"Limited Product\", \"description\": \"Few left\", \"price\": 300, \"stock\": ${LIMITED_STOCK}, \"category\": \"Test\"}")
+  LIMITED_ID=$(echo $LIMITED_PRODUCT | grep -o '"id":"[^"]*' | cut -d'"' -f4)
+  LIMITED_ORDER_BODY="{\"customer_id\": \"customer123\", \"items\": [{\"product_id\": \"${LIMITED_ID}\", \"quantity\": 1, \"price\": 300}]}"
+
+  OVERSIZED_CODE=$(curl -s -o /dev/null -w "%{http_code}" -X POST "${BASE_URL}/orders" \
+    -H "Content-Type: application/json" \
+    -d "{\"customer_id\": \"customer123\", \"items\": [{\"product_id\": \"${LIMITED_ID}\", \"quantity\": $((LIMITED_STOCK + 1)), \"price\": 300}]}")
+  if [[ "$OVERSIZED_CODE" != "409" ]]; then
+    error "Expected 409 for an order past the stock, got $OVERSIZED_CODE"
+    exit 1
+  fi
+
+  RACE_CODES=$(seq "$LIMITED_ORDERS" | xargs -P "$LIMITED_ORDERS" -I{} \
+    curl -s -o /dev/null -w "%{http_code}\n" -X POST "${BASE_URL}/orders" \
+      -H "Content-Type: application/json" -d "$LIMITED_ORDER_BODY")
+  RACE_CREATED=$(echo "$RACE_CODES" | grep -c '^201$')
+  RACE_REJECTED=$(echo "$RACE_CODES" | grep -c '^409$')
+  LIMITED_AFTER=$(curl -s "${PRODUCT_BASE_URL}/products/${LIMITED_ID}")
+  if [[ $RACE_CREATED -eq $LIMITED_STOCK && $RACE_REJECTED -eq $((LIMITED_ORDERS - LIMITED_STOCK)) && $LIMITED_AFTER == *'"stock":0'* ]]; then
+    success "Concurrent orders sold exactly the stock and the rest were rejected"
+  else
+    error "Stock race: ${RACE_CREATED} created, ${RACE_REJECTED} rejected of ${LIMITED_ORDERS} orders for ${LIMITED_STOCK} units, product: $LIMITED_AFTER"
+    exit 1
+  fi
+fi
+
+# Check that negative quantities and negative prices are rejected with their item index
+echo "Testing order item validation..."
+for BAD_ITEM in '"quantity": -1, "price": 1000' '"quantity": 1, "price": -1'; do
+  BAD_RESPONSE=$(curl -s -w "\n%{http_code}" -X POST "${BASE_URL}/orders" \
+    -H "Content-Type: application/json" \
+    -d "{\"customer_id\": \"customer123\", \"items\": [
+      {\"product_id\": \"3f1c2a9e-5b7d-4c11-9e2f-8a6b4d0c7e15\", \"quantity\": 1, \"price\": 1000},
+      {\"product_id\": \"9b8e7d6c-1a2b-4c3d-8e9f-0a1b2c3d4e5f\", ${BAD_ITEM}}]}")
+  if [[ $(echo "$BAD_RESPONSE" | tail -n1) != "400" || $BAD_RESPONSE != *"items[1]"* ]]; then
+    error "Expected 400 naming items[1] for ${BAD_ITEM}, got: $BAD_RESPONSE"
+    exit 1
+  fi
+done
+success "Invalid quantities and prices rejected with their item index"
+
+# Check that zero-quantity lines are rejected at their position, and that no order is created for them
+ZERO_CUSTOMER_ID="customer-zero-$(date +%s)"
+for ZERO_ITEMS in \
+  '{"product_id": "3f1c2a9e-5b7d-4c11-9e2f-8a6b4d0c7e15", "quantity": 1, "price": 1000}, {"product_id": "9b8e7d6c-1a2b-4c3d-8e9f-0a1b2c3d4e5f", "quantity": 0, "price": 1500}' \
+  '{"product_id": "3f1c2a9e-5b7d-4c11-9e2f-8a6b4d0c7e15", "quantity": 0, "price": 1000}, {"product_id": "9b8e7d6c-1a2b-4c3d-8e9f-0a1b2c3d4e5f", "quantity": 0, "price": 1500}'; do
+  ZERO_RESPONSE=$(curl -s -w "\n%{http_code}" -X POST "${BASE_URL}/orders" \
+    -H "Content-Type: application/json" \
+    -d "{\"customer_id\": \"${ZERO_CUSTOMER_ID}\", \"items\": [${ZERO_ITEMS}]}")
+  if [[ $(echo "$ZERO_RESPONSE" | tail -n1) != "400" || $ZERO_RESPONSE != *"items[1]: quantity must be greater than 0"* ]]; then
+    error "Expected 400 naming items[1] for a zero-quantity line, got: $ZERO_RESPONSE"
+    exit 1
+  fi
+done
+ZERO_ORDERS=$(curl -s "${BASE_URL}/orders?customer_id=${ZERO_CUSTOMER_ID}")
+if [[ $ZERO_ORDERS == *'"id"'* ]]; then
+  error "Expected no order for rejected zero-quantity lines, got: $ZERO_ORDERS"
+  exit 1
+fi
+success "Zero-quantity lines rejected with their item index"
+
+# Check that items repeating a product are merged into one item with the summed quantity
+MERGED_RESPONSE=$(curl -s -X POST "${BASE_URL}/orders/preview" \
+  -H "Content-Type: application/json" \
+  -d '{
+    "customer_id": "customer123",
+    "items": [
+      {"product_id": "3f1c2a9e-5b7d-4c11-9e2f-8a6b4d0c7e15", "quantity": 2, "price": 1000},
+      {"product_id": "9b8e7d6c-1a2b-4c3d-8e9f-0a1b2c3d4e5f", "quantity": 1, "price": 1500},
+      {"product_id": "3f1c2a9e-5b7d-4c11-9e2f-8a6b4d0c7e15", "quantity": 3, "price": 1000}
+    ]
+  }')
+MERGED_ITEMS=$(echo "$MERGED_RESPONSE" | grep -o '"product_id"' | wc -l)
+
+if [[ $MERGED_ITEMS -eq 2 && $MERGED_RESPONSE == *'"quantity":5'* && $MERGED_RESPONSE == *'"total_amount":6500'* ]]; then
+  success "Repeated product merged into one item"
+else
+  error "Repeated product was not merged: $MERGED_RESPONSE"
+  exit 1
+fi
+
+CONFLICT_RESPONSE=$(curl -s -w "\n%{http_code}" -X POST "${BASE_URL}/orders" \
+  -H "Content-Type: application/json" \
+  -d '{
+    "customer_id": "customer123",
+    "items": [
+      {"product_id": "3f1c2a9e-5b7d-4c11-9e2f-8a6b4d0c7e15", "quantity": 1, "price": 1000},
+      {"product_id": "3f1c2a9e-5b7d-4c11-9e2f-8a6b4d0c7e15", "quantity": 1, "price": 900}
+    ]
+  }')
+
+if [[ $(echo "$CONFLICT_RESPONSE" | tail -n1) == "400" && $CONFLICT_RESPONSE == *"items[1]"* ]]; then
+  success "Repeated product with a different price rejected"
+else
+  error "Expected 400 for a repeated product with a different price, got: $CONFLICT_RESPONSE"
+  exit 1
+fi
+
+# Check that a total overflowing int64 is rejected instead of wrapping to a negative amount
+OVERFLOW_RESPONSE=$(curl -s -w "\n%{http_code}" -X POST "${BASE_URL}/orders" \
+  -H "Content-Type: application/json" \
+  -d '{
+    "customer_id": "customer123",
+    "items": [
+      {"product_id": "3f1c2a9e-5b7d-4c11-9e2f-8a6b4d0c7e15", "quantity": 1, "price": 9223372036854775807},
+      {"product_id": "9b8e7d6c-1a2b-4c3d-8e9f-0a1b2c3d4e5f", "quantity": 1, "price": 1}
+    ]
+  }')
+OVERFLOW_STATUS=$(echo "$OVERFLOW_RESPONSE" | tail -n1)
+
+if [[ $OVERFLOW_STATUS == "400" && $OVERFLOW_RESPONSE == *"overflows"* ]]; then
+  success "Overflowing order total rejected"
+else
+  error "Expected 400 for an overflowing order total, got: $OVERFLOW_RESPONSE"
+  exit 1
+fi
+
+# Test estimating shipping
+echo "Testing shipping estimate..."
+SHIPPING_RESPONSE=$(curl -s -X POST "${BASE_URL}/orders/shipping-estimate" \
+  -H "Content-Type: application/json" \
+  -d '{
+    "address": {"country": "US"},
+    "items": [{"product_id": "5d2f8c1a-7e3b-4a9d-b6c0-1f2e3d4c5b6a", "quantity": 2, "price": 1000}]
+  }')
+
+# Check if the shipping estimate was returned
+if [[ $SHIPPING_RESPONSE == *"estimated_delivery"* ]]; then
+  success "Shipping estimated successfully"
+else
+  error "Failed to estimate shipping: $SHIPPING_RESPONSE"
+  exit 1
+fi
+
+# Check that an unknown destination is rejected
+UNKNOWN_STATUS=$(curl -s -o /dev/null -w "%{http_code}" -X POST "${BASE_URL}/orders/shipping-estimate" \
+  -H "Content-Type: application/json" \
+  -d '{
+    "address": {"country": "ZZ"},
+    "items": [{"product_id": "5d2f8c1a-7e3b-4a9d-b6c0-1f2e3d4c5b6a", "quantity": 2, "price": 1000}]
+  }')
+
+if [[ $UNKNOWN_STATUS == "422" ]]; then
+  success "Unknown destination rejected"
+else
+  error "Expected 422 for an unknown destination, got $UNKNOWN_STATUS"
+  exit 1
+fi
+
+# Test creating an order
+echo "Testing order creation..."
+CREATE_RESPONSE=$(curl -s -X POST "${BASE_URL}/orders" \
+  -H "Content-Type: application/json" \
+  -H "X-Tenant-ID: tenant123" \
+  -d "$ORDER_REQUEST")
 
 # Check if order creation was successful
 if [[ $CREATE_RESPONSE == *"id"* ]]; then
@@ -52,6 +409,120 @@ else
   exit 1
 fi
 
+# Check that the preview total matches the created order's total
+CREATE_TOTAL=$(echo $CREATE_RESPONSE | grep -o '"total_amount":[0-9]*' | cut -d':' -f2)
+if [[ "$CREATE_TOTAL" == "$PREVIEW_TOTAL" ]]; then
+  success "Preview total matches created order"
+else
+  error "Preview total $PREVIEW_TOTAL does not match created total $CREATE_TOTAL"
+  exit 1
+fi
+
+# Test that a newer order ID sorts after an older one (ULID order IDs)
+echo "Testing order ID ordering..."
+SECOND_RESPONSE=$(curl -s -X POST "${BASE_URL}/orders" \
+  -H "Content-Type: application/json" \
+  -d "$ORDER_REQUEST")
+SECOND_ORDER_ID=$(echo $SECOND_RESPONSE | grep -o '"id":"[^"]*' | cut -d'"' -f4)
+
+if [[ "$SECOND_ORDER_ID" > "$ORDER_ID" ]]; then
+  success "Order IDs sort by creation time"
+else
+  error "Order ID $SECOND_ORDER_ID does not sort after $ORDER_ID"
+  exit 1
+fi
+
+# Test that a repeated idempotency key returns the original order
+echo "Testing idempotency keys..."
+IDEMPOTENCY_KEY="test-key-$(date +%s%N)"
+FIRST_KEYED=$(curl -s -X POST "${BASE_URL}/orders" \
+  -H "Content-Type: application/json" \
+  -H "Idempotency-Key: ${IDEMPOTENCY_KEY}" \
+  -d "$ORDER_REQUEST")
+REPEATED_KEYED=$(curl -s -X POST "${BASE_URL}/orders" \
+  -H "Content-Type: application/json" \
+  -H "Idempotency-Key: ${IDEMPOTENCY_KEY}" \
+  -d "$ORDER_REQUEST")
+FIRST_KEYED_ID=$(echo $FIRST_KEYED | grep -o '"id":"[^"]*' | cut -d'"' -f4)
+REPEATED_KEYED_ID=$(echo $REPEATED_KEYED | grep -o '"id":"[^"]*' | cut -d'"' -f4)
+
+if [[ -n "$FIRST_KEYED_ID" && "$FIRST_KEYED_ID" == "$REPEATED_KEYED_ID" ]]; then
+  success "Repeated idempotency key returned the same order"
+else
+  error "Idempotency key created orders $FIRST_KEYED_ID and $REPEATED_KEYED_ID"
+  exit 1
+fi
+
+# Test that a retry after a status change returns the current order, whether the
+# idempotency cache is enabled or not
+echo "Testing idempotency replay after a status change..."
+curl -s -o /dev/null -X PATCH "${BASE_URL}/orders/${FIRST_KEYED_ID}" \
+  -H "Content-Type: application/json" \
+  -d '{"status": 2}'
+RETRIED_KEYED=$(curl -s -X POST "${BASE_URL}/orders" \
+  -H "Content-Type: application/json" \
+  -H "Idempotency-Key: ${IDEMPOTENCY_KEY}" \
+  -d "$ORDER_REQUEST")
+
+if [[ $RETRIED_KEYED == *"\"id\":\"${FIRST_KEYED_ID}\""* && $RETRIED_KEYED == *'"status":2'* ]]; then
+  success "Retry after a status change returned the updated order"
+else
+  error "Retry after a status change returned: $RETRIED_KEYED"
+  exit 1
+fi
+
+# With the idempotency cache enabled, a retry whose cache entry is gone falls back to the database
+if command -v redis-cli >/dev/null 2>&1; then
+  echo "Testing idempotency cache miss fallback..."
+  redis-cli --scan --pattern 'order:idempotency*' | xargs -r redis-cli del >/dev/null
+  MISSED_KEYED=$(curl -s -X POST "${BASE_URL}/orders" \
+    -H "Content-Type: application/json" \
+    -H "Idempotency-Key: ${IDEMPOTENCY_KEY}" \
+    -d "$ORDER_REQUEST")
+  MISSED_KEYED_ID=$(echo $MISSED_KEYED | grep -o '"id":"[^"]*' | cut -d'"' -f4)
+
+  if [[ "$MISSED_KEYED_ID" == "$FIRST_KEYED_ID" ]]; then
+    success "Retry without a cache entry returned the same order"
+  else
+    error "Retry without a cache entry returned order $MISSED_KEYED_ID instead of $FIRST_KEYED_ID"
+    exit 1
+  fi
+fi
+
+# Test that identical requests sent at the same time with one idempotency key create a single order
+echo "Testing concurrent idempotent requests..."
+CONCURRENT_KEY="concurrent-key-$(date +%s%N)"
+CONCURRENT_CUSTOMER="concurrent-customer-$(date +%s%N)"
+CONCURRENT_REQUEST=$(echo "$ORDER_REQUEST" | sed "s/customer123/${CONCURRENT_CUSTOMER}/")
+CONCURRENT_IDS=$(seq 2 | xargs -P 2 -I{} \
+  curl -s -w "\n" -X POST "${BASE_URL}/orders" \
+    -H "Content-Type: application/json" \
+    -H "Idempotency-Key: ${CONCURRENT_KEY}" \
+    -d "$CONCURRENT_REQUEST" | grep -o '"id":"[^"]*' | cut -d'"' -f4)
+CONCURRENT_ORDERS=$(curl -s "${BASE_URL}/orders?customer_id=${CONCURRENT_CUSTOMER}")
+CONCURRENT_ORDER_COUNT=$(echo "$CONCURRENT_ORDERS" | grep -o '"customer_id"' | wc -l)
+
+if [[ $(echo "$CONCURRENT_IDS" | wc -l) -eq 2 && $(echo "$CONCURRENT_IDS" | sort -u | wc -l) -eq 1 && $CONCURRENT_ORDER_COUNT -eq 1 ]]; then
+  success "Concurrent requests with one idempotency key created a single order"
+else
+  error "Concurrent idempotent requests returned orders [$(echo $CONCURRENT_IDS)] and the customer has $CONCURRENT_ORDER_COUNT orders"
+  exit 1
+fi
+
+# Test that a trailing slash is served directly, without a redirect dropping the body
+echo "Testing trailing slash handling..."
+SLASH_STATUS=$(curl -s -o /dev/null -w "%{http_code}" -X POST "${BASE_URL}/orders/" \
+  -H "Content-Type: application/json" \
+  -d "$ORDER_REQUEST")
+SLASH_GET_STATUS=$(curl -s -o /dev/null -w "%{http_code}" -X GET "${BASE_URL}/orders/${ORDER_ID}/")
+
+if [[ "$SLASH_STATUS" == "201" && "$SLASH_GET_STATUS" == "200" ]]; then
+  success "Trailing slashes are handled without redirects"
+else
+  error "Trailing slash requests returned POST=$SLASH_STATUS GET=$SLASH_GET_STATUS"
+  exit 1
+fi
+
 # Test getting an order
 echo "Testing get order..."
 GET_RESPONSE=$(curl -s -X GET "${BASE_URL}/orders/${ORDER_ID}")
@@ -64,6 +535,169 @@ else
   exit 1
 fi
 
+# Test that an order with more items than the cap is returned truncated and paged in full.
+# The made-up products are not in the catalog, so this is skipped when items are checked against it.
+if [[ -z "$PRODUCT_BASE_URL" ]]; then
+  echo "Testing truncated order items..."
+  LARGE_ITEM_COUNT=$((MAX_EMBEDDED_ITEMS + 5))
+  LARGE_ITEMS=""
+  for i in $(seq 1 $LARGE_ITEM_COUNT); do
+    LARGE_ITEMS+=$(printf '{"product_id": "00000000-0000-4000-8000-%012d", "quantity": 1, "price": 100},' "$i")
+  done
+  LARGE_RESPONSE=$(curl -s -X POST "${BASE_URL}/orders" \
+    -H "Content-Type: application/json" \
+    -d "{\"customer_id\": \"customer-large-order\", \"items\": [${LARGE_ITEMS%,}]}")
+  LARGE_ORDER_ID=$(echo $LARGE_RESPONSE | grep -o '"id":"[^"]*' | cut -d'"' -f4)
+  if [[ -z "$LARGE_ORDER_ID" ]]; then
+    error "Failed to create an order with $LARGE_ITEM_COUNT items: $LARGE_RESPONSE"
+    exit 1
+  fi
+
+  LARGE_GET_RESPONSE=$(curl -s "${BASE_URL}/orders/${LARGE_ORDER_ID}")
+  EMBEDDED_COUNT=$(echo "$LARGE_GET_RESPONSE" | grep -o '"product_id"' | wc -l)
+  if [[ $EMBEDDED_COUNT -eq $MAX_EMBEDDED_ITEMS && $LARGE_GET_RESPONSE == *'"items_truncated":true'* && $LARGE_GET_RESPONSE == *"\"items_url\":\"/orders/${LARGE_ORDER_ID}/items\""* ]]; then
+    success "Order with $LARGE_ITEM_COUNT items embeds $EMBEDDED_COUNT and is flagged as truncated"
+  else
+    error "Expected $MAX_EMBEDDED_ITEMS items and items_truncated, got $EMBEDDED_COUNT items: $LARGE_GET_RESPONSE"
+    exit 1
+  fi
+
+  if [[ $GET_RESPONSE == *"items_truncated"* ]]; then
+    error "Order within the cap should not be flagged as truncated: $GET_RESPONSE"
+    exit 1
+  fi
+
+  # Listing and status changes embed the same number of items; cancelling returns them all
+  LARGE_LIST_RESPONSE=$(curl -s "${BASE_URL}/orders?customer_id=customer-large-order&page_size=1")
+  LARGE_LIST_COUNT=$(echo "$LARGE_LIST_RESPONSE" | grep -o '"product_id"' | wc -l)
+  LARGE_UPDATE_RESPONSE=$(curl -s -X PATCH "${BASE_URL}/orders/${LARGE_ORDER_ID}" \
+    -H "Content-Type: application/json" \
+    -d '{"status": 2}')
+  LARGE_UPDATE_COUNT=$(echo "$LARGE_UPDATE_RESPONSE" | grep -o '"product_id"' | wc -l)
+  LARGE_CANCEL_RESPONSE=$(curl -s -X POST "${BASE_URL}/orders/${LARGE_ORDER_ID}/cancel")
+  LARGE_CANCEL_COUNT=$(echo "$LARGE_CANCEL_RESPONSE" | grep -o '"product_id"' | wc -l)
+  if [[ $LARGE_LIST_COUNT -eq $MAX_EMBEDDED_ITEMS && $LARGE_LIST_RESPONSE == *'"items_truncated":true'* \
+    && $LARGE_UPDATE_COUNT -eq $MAX_EMBEDDED_ITEMS && $LARGE_UPDATE_RESPONSE == *'"items_truncated":true'* \
+    && $LARGE_CANCEL_COUNT -eq $LARGE_ITEM_COUNT ]]; then
+    success "Listed and updated orders embed $MAX_EMBEDDED_ITEMS items, cancelled ones all $LARGE_ITEM_COUNT"
+  else
+    error "Expected $MAX_EMBEDDED_ITEMS items listed and updated and $LARGE_ITEM_COUNT cancelled, got $LARGE_LIST_COUNT, $LARGE_UPDATE_COUNT and $LARGE_CANCEL_COUNT"
+    exit 1
+  fi
+
+  # Page through every item
+  PAGED_COUNT=0
+  ITEMS_PAGE_TOKEN=""
+  while :; do
+    ITEMS_RESPONSE=$(curl -s "${BASE_URL}/orders/${LARGE_ORDER_ID}/items?page_size=40&page_token=${ITEMS_PAGE_TOKEN}")
+    PAGED_COUNT=$((PAGED_COUNT + $(echo "$ITEMS_RESPONSE" | grep -o '"product_id"' | wc -l)))
+    ITEMS_PAGE_TOKEN=$(echo "$ITEMS_RESPONSE" | grep -o '"next_page_token":"[^"]*' | cut -d'"' -f4)
+    if [[ -z "$ITEMS_PAGE_TOKEN" ]]; then
+      break
+    fi
+  done
+  if [[ $PAGED_COUNT -eq $LARGE_ITEM_COUNT ]]; then
+    success "All $PAGED_COUNT order items paged through /orders/{id}/items"
+  else
+    error "Expected $LARGE_ITEM_COUNT paged items, got $PAGED_COUNT: $ITEMS_RESPONSE"
+    exit 1
+  fi
+
+  MISSING_ITEMS_STATUS=$(curl -s -o /dev/null -w "%{http_code}" "${BASE_URL}/orders/no-such-order/items")
+  if [[ $MISSING_ITEMS_STATUS == "404" ]]; then
+    success "Items of a missing order return 404"
+  else
+    error "Expected 404 for the items of a missing order, got: $MISSING_ITEMS_STATUS"
+    exit 1
+  fi
+fi
+
+# Test that items are stored where ORDER_ITEMSTORAGE says and read back unchanged
+if [[ -n "$ORDER_DATABASE_URL" ]] && command -v psql >/dev/null 2>&1; then
+  echo "Testing $ITEM_STORAGE item storage..."
+  STORED_JSON=$(psql "$ORDER_DATABASE_URL" -tA -c "SELECT items IS NOT NULL FROM orders WHERE id = '${ORDER_ID}'")
+  STORED_ROWS=$(psql "$ORDER_DATABASE_URL" -tA -c "SELECT COUNT(*) FROM order_items WHERE order_id = '${ORDER_ID}'")
+  if [[ $ITEM_STORAGE == "jsonb" && $STORED_JSON == "t" && $STORED_ROWS == "0" ]] ||
+    [[ $ITEM_STORAGE == "table" && $STORED_JSON == "f" && $STORED_ROWS == "2" ]]; then
+    success "Order items stored in $ITEM_STORAGE storage"
+  else
+    error "Unexpected $ITEM_STORAGE storage: items column set=$STORED_JSON, order_items rows=$STORED_ROWS"
+    exit 1
+  fi
+
+  CREATED_ITEMS=$(echo "$CREATE_RESPONSE" | grep -o '"items":\[[^]]*\]')
+  STORED_ITEMS=$(echo "$GET_RESPONSE" | grep -o '"items":\[[^]]*\]')
+  if [[ -n "$CREATED_ITEMS" && "$CREATED_ITEMS" == "$STORED_ITEMS" ]]; then
+    success "Order items read back unchanged from $ITEM_STORAGE storage"
+  else
+    error "Order items changed in $ITEM_STORAGE storage: created $CREATED_ITEMS, read $STORED_ITEMS"
+    exit 1
+  fi
+
+  # Orders always have items, so store an empty list directly on a separate order
+  if [[ $ITEM_STORAGE == "jsonb" ]]; then
+    EMPTY_RESPONSE=$(curl -s -X POST "${BASE_URL}/orders" \
+      -H "Content-Type: application/json" \
+      -d "$ORDER_REQUEST")
+    EMPTY_ORDER_ID=$(echo $EMPTY_RESPONSE | grep -o '"id":"[^"]*' | cut -d'"' -f4)
+    psql "$ORDER_DATABASE_URL" -q -c "UPDATE orders SET items = '[]' WHERE id = '${EMPTY_ORDER_ID}'"
+    EMPTY_GET_RESPONSE=$(curl -s "${BASE_URL}/orders/${EMPTY_ORDER_ID}")
+    if [[ $EMPTY_GET_RESPONSE == *'"items":[]'* ]]; then
+      success "Empty jsonb items read back as an empty list"
+    else
+      error "Expected an empty item list: $EMPTY_GET_RESPONSE"
+      exit 1
+    fi
+  fi
+fi
+
+# Test that HTTP and gRPC format timestamps identically
+echo "Testing timestamp format..."
+HTTP_CREATED_AT=$(echo $GET_RESPONSE | grep -o '"created_at":"[^"]*' | cut -d'"' -f4)
+if command -v grpcurl >/dev/null 2>&1; then
+  GRPC_RESPONSE=$(grpcurl -plaintext -import-path proto -proto order/v1/order.proto -d "{\"order_id\": \"${ORDER_ID}\"}" "${GRPC_ADDR}" order.v1.OrderService/GetOrder)
+  GRPC_CREATED_AT=$(echo $GRPC_RESPONSE | grep -o '"createdAt": "[^"]*' | cut -d'"' -f4)
+  if [[ -n "$HTTP_CREATED_AT" && "$HTTP_CREATED_AT" == "$GRPC_CREATED_AT" ]]; then
+    success "created_at is formatted identically over HTTP and gRPC"
+  else
+    error "created_at differs: HTTP=$HTTP_CREATED_AT gRPC=$GRPC_CREATED_AT"
+    exit 1
+  fi
+elif [[ $HTTP_CREATED_AT =~ ^[0-9]{4}-[0-9]{2}-[0-9]{2}T[0-9]{2}:[0-9]{2}:[0-9]{2}(Z|[+-][0-9]{2}:[0-9]{2})$ ]]; then
+  success "created_at is RFC3339 without fractional seconds (grpcurl not installed, gRPC not compared)"
+else
+  error "created_at is not RFC3339: $HTTP_CREATED_AT"
+  exit 1
+fi
+
+# Test that a supplied request ID is echoed back and a missing one is generated
+echo "Testing request IDs..."
+ECHOED_ID=$(curl -s -o /dev/null -D - -H "X-Request-ID: test-request-123" "${BASE_URL}/orders/${ORDER_ID}" | grep -i '^X-Request-ID:' | cut -d' ' -f2 | tr -d '\r')
+if [[ "$ECHOED_ID" == "test-request-123" ]]; then
+  success "Supplied request ID was echoed back"
+else
+  error "Expected X-Request-ID test-request-123, got: $ECHOED_ID"
+  exit 1
+fi
+
+GENERATED_ID=$(curl -s -o /dev/null -D - "${BASE_URL}/orders/${ORDER_ID}" | grep -i '^X-Request-ID:' | cut -d' ' -f2 | tr -d '\r')
+if [[ $GENERATED_ID =~ ^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$ ]]; then
+  success "Request ID was generated: $GENERATED_ID"
+else
+  error "Expected a generated UUID request ID, got: $GENERATED_ID"
+  exit 1
+fi
+
+if command -v grpcurl >/dev/null 2>&1; then
+  GRPC_ID_RESPONSE=$(grpcurl -v -plaintext -import-path proto -proto order/v1/order.proto -H "x-request-id: test-request-456" -d "{\"order_id\": \"${ORDER_ID}\"}" "${GRPC_ADDR}" order.v1.OrderService/GetOrder)
+  if [[ $GRPC_ID_RESPONSE == *"x-request-id: test-request-456"* ]]; then
+    success "Request ID metadata was echoed back over gRPC"
+  else
+    error "Request ID metadata missing from gRPC response headers: $GRPC_ID_RESPONSE"
+    exit 1
+  fi
+fi
+
 # Test listing orders
 echo "Testing list orders..."
 LIST_RESPONSE=$(curl -s -X GET "${BASE_URL}/orders?customer_id=customer123&page_size=10")
@@ -76,6 +710,64 @@ else
   exit 1
 fi
 
+# Test that the total counts all of a customer's orders and is only returned when asked
+TOTAL_CUSTOMER="total-customer-$(date +%s)"
+for i in 1 2 3; do
+  curl -s -o /dev/null -X POST "${BASE_URL}/orders" \
+    -H "Content-Type: application/json" \
+    -d "$(echo "$ORDER_REQUEST" | sed "s/customer123/${TOTAL_CUSTOMER}/")"
+done
+TOTAL_RESPONSE=$(curl -s "${BASE_URL}/orders?customer_id=${TOTAL_CUSTOMER}&page_size=2&include_total=true")
+NO_TOTAL_RESPONSE=$(curl -s "${BASE_URL}/orders?customer_id=${TOTAL_CUSTOMER}&page_size=2")
+
+if [[ $TOTAL_RESPONSE == *'"total_size":3'* && $NO_TOTAL_RESPONSE != *"total_size"* ]]; then
+  success "Order total returned only when requested"
+else
+  error "Unexpected order totals: with=$TOTAL_RESPONSE without=$NO_TOTAL_RESPONSE"
+  exit 1
+fi
+
+# Test that paging one order at a time returns each of the customer's orders exactly once
+echo "Testing order list cursors..."
+PAGED_ORDER_IDS=""
+ORDERS_PAGE_TOKEN=""
+while :; do
+  ORDERS_PAGE=$(curl -s "${BASE_URL}/orders?customer_id=${TOTAL_CUSTOMER}&page_size=1&page_token=${ORDERS_PAGE_TOKEN}")
+  PAGED_ORDER_IDS+=$(echo "$ORDERS_PAGE" | grep -o '"id":"[^"]*' | cut -d'"' -f4)$'\n'
+  ORDERS_PAGE_TOKEN=$(echo "$ORDERS_PAGE" | grep -o '"next_page_token":"[^"]*' | cut -d'"' -f4)
+  if [[ -z "$ORDERS_PAGE_TOKEN" ]]; then
+    break
+  fi
+done
+if [[ $(echo -n "$PAGED_ORDER_IDS" | grep -c .) -eq 3 && $(echo -n "$PAGED_ORDER_IDS" | sort -u | grep -c .) -eq 3 ]]; then
+  success "Order pages returned all 3 orders once"
+else
+  error "Expected 3 distinct orders across pages, got: $PAGED_ORDER_IDS"
+  exit 1
+fi
+
+MALFORMED_TOKEN_RESPONSE=$(curl -s -w "\n%{http_code}" "${BASE_URL}/orders?customer_id=${TOTAL_CUSTOMER}&page_token=not-a-cursor")
+if [[ $(echo "$MALFORMED_TOKEN_RESPONSE" | tail -n1) == "400" && $MALFORMED_TOKEN_RESPONSE == *"malformed page token"* ]]; then
+  success "Malformed order page token rejected"
+else
+  error "Expected 400 for a malformed page token, got: $MALFORMED_TOKEN_RESPONSE"
+  exit 1
+fi
+
+# Test that page sizes are defaulted, clamped to paging.maxPageSize and must be integers
+echo "Testing order page size limits..."
+DEFAULT_PAGE_COUNT=$(curl -s "${BASE_URL}/orders?customer_id=customer123" | grep -o '"customer_id"' | wc -l)
+HUGE_PAGE_STATUS=$(curl -s -o /dev/null -w "%{http_code}" "${BASE_URL}/orders?customer_id=customer123&page_size=1000000")
+HUGE_PAGE_COUNT=$(curl -s "${BASE_URL}/orders?customer_id=customer123&page_size=1000000" | grep -o '"customer_id"' | wc -l)
+BAD_PAGE_STATUS=$(curl -s -o /dev/null -w "%{http_code}" "${BASE_URL}/orders?customer_id=customer123&page_size=ten")
+NEGATIVE_PAGE_STATUS=$(curl -s -o /dev/null -w "%{http_code}" "${BASE_URL}/orders?customer_id=customer123&page_size=-1")
+if [[ $DEFAULT_PAGE_COUNT -le 10 && $HUGE_PAGE_STATUS == "200" && $HUGE_PAGE_COUNT -le 100 && $BAD_PAGE_STATUS == "400" && $NEGATIVE_PAGE_STATUS == "400" ]]; then
+  success "Order page sizes defaulted, clamped and validated"
+else
+  error "Unexpected page size handling: default=$DEFAULT_PAGE_COUNT huge=$HUGE_PAGE_STATUS/$HUGE_PAGE_COUNT bad=$BAD_PAGE_STATUS negative=$NEGATIVE_PAGE_STATUS"
+  exit 1
+fi
+
 # Test updating order status
 echo "Testing update order status..."
 UPDATE_RESPONSE=$(curl -s -X PATCH "${BASE_URL}/orders/${ORDER_ID}" \
@@ -92,4 +784,261 @@ else
   exit 1
 fi
 
+# Test cancelling a pending order, then cancelling it again
+echo "Testing order cancellation..."
+CANCEL_RESPONSE=$(curl -s -X POST "${BASE_URL}/orders/${SECOND_ORDER_ID}/cancel")
+CANCEL_AGAIN_STATUS=$(curl -s -o /dev/null -w "%{http_code}" -X POST "${BASE_URL}/orders/${SECOND_ORDER_ID}/cancel")
+
+if [[ $CANCEL_RESPONSE == *'"status":5'* && "$CANCEL_AGAIN_STATUS" == "409" ]]; then
+  success "Order cancelled once and the repeat rejected"
+else
+  error "Cancellation returned $CANCEL_RESPONSE, repeat returned $CANCEL_AGAIN_STATUS"
+  exit 1
+fi
+
+# Test that tracking information is only accepted when shipping
+echo "Testing shipping with tracking information..."
+EARLY_TRACKING_STATUS=$(curl -s -o /dev/null -w "%{http_code}" -X PATCH "${BASE_URL}/orders/${SECOND_ORDER_ID}" \
+  -H "Content-Type: application/json" \
+  -d '{"status": 2, "tracking_number": "1Z999AA10123456784", "carrier": "UPS"}')
+HALF_TRACKING_STATUS=$(curl -s -o /dev/null -w "%{http_code}" -X PATCH "${BASE_URL}/orders/${ORDER_ID}" \
+  -H "Content-Type: application/json" \
+  -d '{"status": 3, "tracking_number": "1Z999AA10123456784"}')
+
+if [[ "$EARLY_TRACKING_STATUS" == "400" && "$HALF_TRACKING_STATUS" == "400" ]]; then
+  success "Tracking information rejected outside shipping and without its carrier"
+else
+  error "Expected 400 for misplaced tracking information, got $EARLY_TRACKING_STATUS and $HALF_TRACKING_STATUS"
+  exit 1
+fi
+
+# Test shipping a processing order with its tracking number and carrier
+SHIP_RESPONSE=$(curl -s -X PATCH "${BASE_URL}/orders/${ORDER_ID}" \
+  -H "Content-Type: application/json" \
+  -d '{"status": 3, "tracking_number": "1Z999AA10123456784", "carrier": "UPS"}')
+
+if [[ $SHIP_RESPONSE == *'"status":3'* && $SHIP_RESPONSE == *'"tracking_number":"1Z999AA10123456784"'* && $SHIP_RESPONSE == *'"carrier":"UPS"'* ]]; then
+  success "Order shipped with its tracking information"
+else
+  error "Failed to ship order with tracking information: $SHIP_RESPONSE"
+  exit 1
+fi
+
+# The shipment writes an order_shipped event instead of a generic status update
+if [[ -n "$ADMIN_API_KEY" ]]; then
+  EVENTS_RESPONSE=$(curl -s -H "X-API-Key: $ADMIN_API_KEY" "${BASE_URL}/admin/orders/${ORDER_ID}/events")
+  if [[ $EVENTS_RESPONSE == *'"event_type":"order_shipped"'* && $EVENTS_RESPONSE == *"1Z999AA10123456784"* ]]; then
+    success "order_shipped event carries the tracking number"
+  else
+    error "order_shipped event missing: $EVENTS_RESPONSE"
+    exit 1
+  fi
+
+  # Payloads are versioned and carry only the fields of their event
+  if [[ $EVENTS_RESPONSE == *'"payload_type":"order_created.v1"'* && $EVENTS_RESPONSE == *'"payload_type":"order_shipped.v1"'* \
+    && $EVENTS_RESPONSE == *'"schema_version":1'* && $EVENTS_RESPONSE == *'"order_id":"'"${ORDER_ID}"'"'* \
+    && $EVENTS_RESPONSE == *'"status":"processing"'* && $EVENTS_RESPONSE != *'"items_truncated"'* ]]; then
+    success "Event payloads are versioned"
+  else
+    error "Event payloads are not versioned: $EVENTS_RESPONSE"
+    exit 1
+  fi
+fi
+
+# Test shipping without tracking information, which is only accepted when it is not required
+NO_TRACKING_ORDER_ID=$(curl -s -X POST "${BASE_URL}/orders" \
+  -H "Content-Type: application/json" \
+  -d "$ORDER_REQUEST" | grep -o '"id":"[^"]*' | cut -d'"' -f4)
+curl -s -o /dev/null -X PATCH "${BASE_URL}/orders/${NO_TRACKING_ORDER_ID}" \
+  -H "Content-Type: application/json" \
+  -d '{"status": 2}'
+NO_TRACKING_RESPONSE=$(curl -s -w "\n%{http_code}" -X PATCH "${BASE_URL}/orders/${NO_TRACKING_ORDER_ID}" \
+  -H "Content-Type: application/json" \
+  -d '{"status": 3}')
+NO_TRACKING_STATUS=$(echo "$NO_TRACKING_RESPONSE" | tail -n1)
+
+if [[ "$REQUIRE_TRACKING" == "true" && "$NO_TRACKING_STATUS" == "400" ]]; then
+  success "Shipping without tracking information rejected"
+elif [[ "$REQUIRE_TRACKING" != "true" && "$NO_TRACKING_STATUS" == "200" && $NO_TRACKING_RESPONSE != *"tracking_number"* ]]; then
+  success "Order shipped without tracking information"
+else
+  error "Shipping without tracking information (required=$REQUIRE_TRACKING) returned: $NO_TRACKING_RESPONSE"
+  exit 1
+fi
+
+# Test that a shipped order cannot be cancelled
+SHIPPED_CANCEL_STATUS=$(curl -s -o /dev/null -w "%{http_code}" -X POST "${BASE_URL}/orders/${ORDER_ID}/cancel")
+
+if [[ "$SHIPPED_CANCEL_STATUS" == "409" ]]; then
+  success "Shipped order cannot be cancelled"
+else
+  error "Cancelling a shipped order returned $SHIPPED_CANCEL_STATUS"
+  exit 1
+fi
+
+# Test that a shipped order cannot move back to pending
+BACKWARD_STATUS=$(curl -s -o /dev/null -w "%{http_code}" -X PATCH "${BASE_URL}/orders/${ORDER_ID}" \
+  -H "Content-Type: application/json" \
+  -d '{"status": 1}')
+
+if [[ "$BACKWARD_STATUS" == "409" ]]; then
+  success "Illegal status transition rejected"
+else
+  error "Moving a shipped order back to pending returned $BACKWARD_STATUS"
+  exit 1
+fi
+
+# Test shipping several orders at once, each with its own tracking number (requires the admin API key)
+if [[ -n "$ADMIN_API_KEY" ]]; then
+  echo "Testing bulk shipment..."
+  BULK_PROCESSING_ID=$(curl -s -X POST "${BASE_URL}/orders" \
+    -H "Content-Type: application/json" \
+    -d "$ORDER_REQUEST" | grep -o '"id":"[^"]*' | cut -d'"' -f4)
+  curl -s -o /dev/null -X PATCH "${BASE_URL}/orders/${BULK_PROCESSING_ID}" -H "Content-Type: application/json" -d '{"status": 2}'
+
+  BULK_DELIVERED_ID=$(curl -s -X POST "${BASE_URL}/orders" \
+    -H "Content-Type: application/json" \
+    -d "$ORDER_REQUEST" | grep -o '"id":"[^"]*' | cut -d'"' -f4)
+  for NEXT_STATUS in 2 3 4; do
+    curl -s -o /dev/null -X PATCH "${BASE_URL}/orders/${BULK_DELIVERED_ID}" -H "Content-Type: application/json" -d "{\"status\": ${NEXT_STATUS}}"
+  done
+
+  BULK_MISSING_ID='00000000-0000-4000-8000-000000000000'
+  BULK_SHIP_RESPONSE=$(curl -s -X POST "${BASE_URL}/admin/orders/ship" \
+    -H "X-API-Key: $ADMIN_API_KEY" \
+    -H "Content-Type: application/json" \
+    -d "[
+      {\"order_id\": \"${BULK_PROCESSING_ID}\", \"tracking_number\": \"1Z999AA10000000001\", \"carrier\": \"UPS\"},
+      {\"order_id\": \"${BULK_DELIVERED_ID}\", \"tracking_number\": \"1Z999AA10000000002\", \"carrier\": \"UPS\"},
+      {\"order_id\": \"${BULK_MISSING_ID}\", \"tracking_number\": \"1Z999AA10000000003\", \"carrier\": \"UPS\"}]")
+  BULK_SHIPPED_ORDER=$(curl -s "${BASE_URL}/orders/${BULK_PROCESSING_ID}")
+  BULK_DELIVERED_ORDER=$(curl -s "${BASE_URL}/orders/${BULK_DELIVERED_ID}")
+
+  if [[ $BULK_SHIP_RESPONSE == *'"shipped":1'* && $BULK_SHIP_RESPONSE == *'"failed":2'* \
+    && $BULK_SHIP_RESPONSE == *"\"order_id\":\"${BULK_PROCESSING_ID}\",\"status\":200"* \
+    && $BULK_SHIP_RESPONSE == *"\"order_id\":\"${BULK_DELIVERED_ID}\",\"status\":409"* \
+    && $BULK_SHIP_RESPONSE == *"\"order_id\":\"${BULK_MISSING_ID}\",\"status\":404"* \
+    && $BULK_SHIPPED_ORDER == *'"status":3'* && $BULK_SHIPPED_ORDER == *'"tracking_number":"1Z999AA10000000001"'* \
+    && $BULK_DELIVERED_ORDER == *'"status":4'* && $BULK_DELIVERED_ORDER != *"1Z999AA10000000002"* ]]; then
+    success "Bulk shipment shipped the processing order and reported the delivered and missing ones"
+  else
+    error "Bulk shipment returned: $BULK_SHIP_RESPONSE $BULK_SHIPPED_ORDER $BULK_DELIVERED_ORDER"
+    exit 1
+  fi
+
+  BULK_EVENTS_RESPONSE=$(curl -s -H "X-API-Key: $ADMIN_API_KEY" "${BASE_URL}/admin/orders/${BULK_PROCESSING_ID}/events")
+  if [[ $BULK_EVENTS_RESPONSE == *'"event_type":"order_shipped"'* && $BULK_EVENTS_RESPONSE == *"1Z999AA10000000001"* ]]; then
+    success "Bulk shipment wrote the order_shipped event"
+  else
+    error "order_shipped event missing after bulk shipment: $BULK_EVENTS_RESPONSE"
+    exit 1
+  fi
+
+  # A batch naming an order twice is rejected as a whole
+  BULK_DUPLICATE_STATUS=$(curl -s -o /dev/null -w "%{http_code}" -X POST "${BASE_URL}/admin/orders/ship" \
+    -H "X-API-Key: $ADMIN_API_KEY" \
+    -H "Content-Type: application/json" \
+    -d "[{\"order_id\": \"${BULK_PROCESSING_ID}\"}, {\"order_id\": \"${BULK_PROCESSING_ID}\"}]")
+  if [[ "$BULK_DUPLICATE_STATUS" == "400" ]]; then
+    success "Bulk shipment listing an order twice rejected"
+  else
+    error "Expected 400 for a duplicate order in a bulk shipment, got $BULK_DUPLICATE_STATUS"
+    exit 1
+  fi
+fi
+
+# Check that created orders are counted in the business metrics
+echo "Testing order metrics..."
+if curl -s "${BASE_URL}/metrics" | grep -q '^orders_created_total{tenant='; then
+  success "Created orders recorded in metrics"
+else
+  error "orders_created_total missing from metrics"
+  exit 1
+fi
+
+# Check that the queries behind the requests above were recorded by the GORM callbacks
+if curl -s "${BASE_URL}/metrics" | grep -q '^database_queries_total{operation="create",table="orders"}'; then
+  success "Database queries recorded in metrics"
+else
+  error "database_queries_total missing from metrics"
+  exit 1
+fi
+
+# Check that /metrics negotiates OpenMetrics and keeps the Prometheus text format by default
+echo "Testing metrics exposition formats..."
+OPENMETRICS_TYPE=$(curl -s -o /dev/null -w "%{content_type}" -H "Accept: application/openmetrics-text; version=1.0.0" "${BASE_URL}/metrics")
+TEXT_TYPE=$(curl -s -o /dev/null -w "%{content_type}" "${BASE_URL}/metrics")
+
+if [[ $OPENMETRICS_TYPE == application/openmetrics-text* && $TEXT_TYPE == text/plain* ]]; then
+  success "Metrics served as OpenMetrics on request"
+else
+  error "Metrics content types were $OPENMETRICS_TYPE and $TEXT_TYPE"
+  exit 1
+fi
+
+# Check the slow request buffer when the service runs with DEBUG_ENABLED=true and a
+# SERVER_HTTP_SLOWREQUESTTHRESHOLD low enough (e.g. 1ns) for every request to count as slow
+SLOW_CODE=$(curl -s -o /dev/null -w "%{http_code}" "${BASE_URL}/debug/slow")
+if [[ "$SLOW_CODE" == "200" ]]; then
+  echo "Testing slow request buffer..."
+  SLOW_RESPONSE=$(curl -s "${BASE_URL}/debug/slow")
+  if [[ $SLOW_RESPONSE == *"\"path\":\"/orders/${ORDER_ID}\""* ]]; then
+    success "Slow requests are listed at /debug/slow"
+  else
+    error "Slow request for /orders/${ORDER_ID} missing from /debug/slow: $SLOW_RESPONSE"
+    exit 1
+  fi
+
+  # The buffer holds at most DEBUG_SLOWREQUESTS entries (100 by default)
+  for i in $(seq 1 110); do
+    curl -s -o /dev/null "${BASE_URL}/health/live"
+  done
+  SLOW_COUNT=$(curl -s "${BASE_URL}/debug/slow" | grep -o '"protocol":' | wc -l)
+  if [[ $SLOW_COUNT -gt 0 && $SLOW_COUNT -le ${DEBUG_SLOWREQUESTS:-100} ]]; then
+    success "Slow request buffer is capped at $SLOW_COUNT entries"
+  else
+    error "Slow request buffer holds $SLOW_COUNT entries"
+    exit 1
+  fi
+fi
+
+# Test that a remote mode service round-trips every call through the gRPC API
+if [[ -n "$REMOTE_BASE_URL" ]]; then
+  echo "Testing remote mode..."
+  REMOTE_CREATE_RESPONSE=$(curl -s -X POST "${REMOTE_BASE_URL}/orders" \
+    -H "Content-Type: application/json" \
+    -d "$ORDER_REQUEST")
+  REMOTE_ORDER_ID=$(echo $REMOTE_CREATE_RESPONSE | grep -o '"id":"[^"]*' | cut -d'"' -f4)
+  if [[ -z "$REMOTE_ORDER_ID" || $REMOTE_CREATE_RESPONSE != *"\"total_amount\":${PREVIEW_TOTAL}"* ]]; then
+    error "Failed to create order through the remote service: $REMOTE_CREATE_RESPONSE"
+    exit 1
+  fi
+
+  # The order must be stored by the service behind the proxy
+  if [[ $(curl -s "${BASE_URL}/orders/${REMOTE_ORDER_ID}") != *"$REMOTE_ORDER_ID"* ]]; then
+    error "Order created through the remote service is missing from ${BASE_URL}"
+    exit 1
+  fi
+
+  REMOTE_GET_RESPONSE=$(curl -s "${REMOTE_BASE_URL}/orders/${REMOTE_ORDER_ID}")
+  REMOTE_LIST_RESPONSE=$(curl -s "${REMOTE_BASE_URL}/orders?customer_id=customer123&page_size=100")
+  REMOTE_UPDATE_RESPONSE=$(curl -s -X PATCH "${REMOTE_BASE_URL}/orders/${REMOTE_ORDER_ID}" \
+    -H "Content-Type: application/json" \
+    -d '{"status": 2}')
+  REMOTE_BACKWARDS_CODE=$(curl -s -o /dev/null -w "%{http_code}" -X PATCH "${REMOTE_BASE_URL}/orders/${REMOTE_ORDER_ID}" \
+    -H "Content-Type: application/json" \
+    -d '{"status": 1}')
+  REMOTE_CANCEL_RESPONSE=$(curl -s -X POST "${REMOTE_BASE_URL}/orders/${REMOTE_ORDER_ID}/cancel")
+  REMOTE_MISSING_CODE=$(curl -s -o /dev/null -w "%{http_code}" "${REMOTE_BASE_URL}/orders/missing-order")
+
+  if [[ $REMOTE_GET_RESPONSE == *"$REMOTE_ORDER_ID"* && $REMOTE_LIST_RESPONSE == *"$REMOTE_ORDER_ID"* &&
+    $REMOTE_UPDATE_RESPONSE == *'"status":2'* && "$REMOTE_BACKWARDS_CODE" == "409" &&
+    $REMOTE_CANCEL_RESPONSE == *'"status":5'* && "$REMOTE_MISSING_CODE" == "404" ]]; then
+    success "Remote mode round-trips create, get, list, status updates, cancellation and errors"
+  else
+    error "Remote mode mismatch: get=$REMOTE_GET_RESPONSE update=$REMOTE_UPDATE_RESPONSE backwards=$REMOTE_BACKWARDS_CODE cancel=$REMOTE_CANCEL_RESPONSE missing=$REMOTE_MISSING_CODE"
+    exit 1
+  fi
+fi
+
 echo "All tests completed successfully!"
\ No newline at end of file
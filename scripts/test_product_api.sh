@@ -5,6 +5,13 @@
 
 # Set the base URL
 BASE_URL="http://localhost:8081"
+GRPC_ADDR="localhost:9093"
+
+# Admin API key for the admin endpoints (security.adminApiKey)
+ADMIN_API_KEY="${ADMIN_API_KEY:-}"
+
+# Set to true when the service runs with product.goneForDeleted (PRODUCT_GONEFORDELETED)
+GONE_FOR_DELETED="${GONE_FOR_DELETED:-false}"
 
 # Colors for output
 GREEN='\033[0;32m'
@@ -24,6 +31,24 @@ error() {
 
 echo "Testing Product API..."
 
+# Check the plain liveness endpoint, which bypasses logging and metrics
+echo "Checking /healthz..."
+HEALTHZ_RESPONSE=$(curl -s -w "\n%{http_code}" "${BASE_URL}/healthz")
+if [[ "$HEALTHZ_RESPONSE" == $'ok\n200' ]] && ! curl -s "${BASE_URL}/metrics" | grep -q 'path="/healthz"'; then
+  success "/healthz answers ok without being counted"
+else
+  error "Unexpected /healthz response: $HEALTHZ_RESPONSE"
+fi
+
+# Check that the service reports its dependencies as ready
+echo "Checking readiness..."
+READY_RESPONSE=$(curl -s "${BASE_URL}/health/ready")
+if [[ $READY_RESPONSE == *'"database":"UP"'* && $READY_RESPONSE == *'"redis":"UP"'* ]]; then
+  success "Database and Redis are ready"
+else
+  error "Readiness check failed: $READY_RESPONSE"
+fi
+
 # Create a product
 echo "Creating a product..."
 CREATE_RESPONSE=$(curl -s -X POST -H "Content-Type: application/json" -d '{
@@ -31,7 +56,11 @@ CREATE_RESPONSE=$(curl -s -X POST -H "Content-Type: application/json" -d '{
   "description": "This is a test product",
   "price": 1999,
   "stock": 100,
-  "category": "test"
+  "category": "test",
+  "weight_grams": 250,
+  "length_mm": 120,
+  "width_mm": 80,
+  "height_mm": 30
 }' $BASE_URL/products)
 
 # Extract product ID from response
@@ -43,6 +72,73 @@ else
   success "Product created with ID: $PRODUCT_ID"
 fi
 
+# A product created without stock starts out of stock
+SOLD_OUT_RESPONSE=$(curl -s -X POST -H "Content-Type: application/json" -d '{"name": "Sold Out Product", "price": 999, "stock": 0, "category": "test"}' $BASE_URL/products)
+if [[ $SOLD_OUT_RESPONSE == *'"stock":0'* && $SOLD_OUT_RESPONSE == *'"status":3'* ]]; then
+  success "Product without stock created out of stock"
+else
+  error "Product without stock not created out of stock: $SOLD_OUT_RESPONSE"
+fi
+
+# Check availability of an available, an unavailable and a missing item at once
+echo "Checking availability..."
+AVAILABILITY_RESPONSE=$(curl -s -X POST -H "Content-Type: application/json" -d "[
+  {\"product_id\": \"$PRODUCT_ID\", \"qty\": 10},
+  {\"product_id\": \"$PRODUCT_ID\", \"qty\": 1000},
+  {\"product_id\": \"no-such-product\", \"qty\": 1}
+]" $BASE_URL/products/availability)
+
+if [[ $AVAILABILITY_RESPONSE == *"\"available\":true,\"stock\":100"* && \
+      $AVAILABILITY_RESPONSE == *"\"available\":false,\"stock\":100"* && \
+      $AVAILABILITY_RESPONSE == *"\"not_found\":true"* ]]; then
+  success "Availability checked for mixed items"
+else
+  error "Unexpected availability response: $AVAILABILITY_RESPONSE"
+fi
+
+# Create a batch of products in one call
+echo "Creating a batch of products..."
+BATCH_CATEGORY="batch-$(date +%s%N)"
+BATCH_RESPONSE=$(curl -s -w "\n%{http_code}" -X POST -H "Content-Type: application/json" -d "[
+  {\"name\": \"Batch Product 1\", \"price\": 100, \"stock\": 1, \"category\": \"$BATCH_CATEGORY\"},
+  {\"name\": \"Batch Product 2\", \"price\": 200, \"stock\": 2, \"category\": \"$BATCH_CATEGORY\"}
+]" $BASE_URL/products/batch)
+BATCH_STATUS=$(echo "$BATCH_RESPONSE" | tail -n1)
+BATCH_IDS=$(echo "$BATCH_RESPONSE" | grep -o '"id":"[^"]*' | wc -l)
+
+if [[ "$BATCH_STATUS" == "201" && "$BATCH_IDS" == "2" && $BATCH_RESPONSE == *"Batch Product 1"*"Batch Product 2"* ]]; then
+  success "Batch of products created in request order"
+else
+  error "Batch creation failed: $BATCH_RESPONSE"
+fi
+
+# One invalid item rejects the whole batch and reports its index
+echo "Creating a batch with an invalid product..."
+INVALID_CATEGORY="batch-invalid-$(date +%s%N)"
+INVALID_BATCH_RESPONSE=$(curl -s -w "\n%{http_code}" -X POST -H "Content-Type: application/json" -d "[
+  {\"name\": \"Valid Product\", \"price\": 100, \"stock\": 1, \"category\": \"$INVALID_CATEGORY\"},
+  {\"name\": \"\", \"price\": 100, \"stock\": 1, \"category\": \"$INVALID_CATEGORY\"}
+]" $BASE_URL/products/batch)
+INVALID_BATCH_STATUS=$(echo "$INVALID_BATCH_RESPONSE" | tail -n1)
+INVALID_CATEGORY_LIST=$(curl -s "$BASE_URL/products?category=$INVALID_CATEGORY")
+
+if [[ "$INVALID_BATCH_STATUS" == "400" && $INVALID_BATCH_RESPONSE == *'"index":1'* && $INVALID_CATEGORY_LIST != *"Valid Product"* ]]; then
+  success "Invalid batch rejected as a whole with the offending index"
+else
+  error "Invalid batch not rejected atomically: $INVALID_BATCH_RESPONSE / $INVALID_CATEGORY_LIST"
+fi
+
+# Batches above the size cap are rejected
+echo "Creating an oversized batch..."
+OVERSIZED_BATCH="[$(for i in $(seq 1 501); do printf '{"name": "Product %d", "price": 1, "stock": 1},' "$i"; done | sed 's/,$//')]"
+OVERSIZED_STATUS=$(curl -s -o /dev/null -w "%{http_code}" -X POST -H "Content-Type: application/json" -d "$OVERSIZED_BATCH" $BASE_URL/products/batch)
+
+if [[ "$OVERSIZED_STATUS" == "400" ]]; then
+  success "Batch above 500 products rejected"
+else
+  error "Oversized batch returned $OVERSIZED_STATUS"
+fi
+
 # Get the product
 echo "Getting the product..."
 GET_RESPONSE=$(curl -s -X GET $BASE_URL/products/$PRODUCT_ID)
@@ -53,6 +149,66 @@ else
   error "Failed to get product"
 fi
 
+if [[ $GET_RESPONSE == *'"weight_grams":250'* && $GET_RESPONSE == *'"height_mm":30'* ]]; then
+  success "Product weight and dimensions returned"
+else
+  error "Product weight and dimensions missing: $GET_RESPONSE"
+fi
+
+# A new product has no reviews yet
+if [[ $GET_RESPONSE == *'"average_rating":0'* && $GET_RESPONSE == *'"review_count":0'* ]]; then
+  success "Product rating aggregate returned"
+else
+  error "Product rating aggregate missing: $GET_RESPONSE"
+fi
+
+# Check that gRPC returns the same product, including its status, as RemoteProductService reads it
+if command -v grpcurl >/dev/null 2>&1; then
+  echo "Getting the product over gRPC..."
+  GRPC_GET_RESPONSE=$(grpcurl -plaintext -import-path proto -proto product/v1/product.proto -d "{\"product_id\": \"${PRODUCT_ID}\"}" "${GRPC_ADDR}" product.v1.ProductService/GetProduct)
+  if [[ $GRPC_GET_RESPONSE == *"\"id\": \"${PRODUCT_ID}\""* && $GRPC_GET_RESPONSE == *'"status": "PRODUCT_STATUS_ACTIVE"'* ]]; then
+    success "Product and status returned over gRPC"
+  else
+    error "Unexpected gRPC product: $GRPC_GET_RESPONSE"
+  fi
+
+  GRPC_MISSING_RESPONSE=$(grpcurl -plaintext -import-path proto -proto product/v1/product.proto -d '{"product_id": "missing-product"}' "${GRPC_ADDR}" product.v1.ProductService/DeleteProduct 2>&1)
+  if [[ $GRPC_MISSING_RESPONSE == *"NotFound"* ]]; then
+    success "Deleting a missing product returns NotFound over gRPC"
+  else
+    error "Expected NotFound for a missing product: $GRPC_MISSING_RESPONSE"
+  fi
+
+  # Repeated IDs are returned once and unknown IDs are left out
+  GRPC_BATCH_GET_RESPONSE=$(grpcurl -plaintext -import-path proto -proto product/v1/product.proto -d "{\"product_ids\": [\"${PRODUCT_ID}\", \"missing-product\", \"${PRODUCT_ID}\"]}" "${GRPC_ADDR}" product.v1.ProductService/BatchGetProducts)
+  GRPC_BATCH_GET_COUNT=$(echo "$GRPC_BATCH_GET_RESPONSE" | grep -c '"id":')
+  if [[ "$GRPC_BATCH_GET_COUNT" == "1" && $GRPC_BATCH_GET_RESPONSE == *"\"id\": \"${PRODUCT_ID}\""* ]]; then
+    success "Batch lookup returned each known product once over gRPC"
+  else
+    error "Unexpected batch lookup response: $GRPC_BATCH_GET_RESPONSE"
+  fi
+fi
+
+# Get a subset of the product's fields
+echo "Getting selected product fields..."
+FIELDS_RESPONSE=$(curl -s -X GET "$BASE_URL/products/$PRODUCT_ID?fields=id,name")
+
+if [[ $FIELDS_RESPONSE == *"Test Product"* && $FIELDS_RESPONSE != *"price"* ]]; then
+  success "Product fields selected successfully"
+else
+  error "Failed to select product fields"
+fi
+
+# Request an unknown field (should fail)
+echo "Requesting an unknown field..."
+UNKNOWN_FIELD_RESPONSE=$(curl -s -X GET -w "%{http_code}" "$BASE_URL/products/$PRODUCT_ID?fields=id,bogus" -o /dev/null)
+
+if [ "$UNKNOWN_FIELD_RESPONSE" -eq 400 ]; then
+  success "Unknown field rejected"
+else
+  error "Unknown field was not rejected"
+fi
+
 # Update the product
 echo "Updating the product..."
 UPDATE_RESPONSE=$(curl -s -X PUT -H "Content-Type: application/json" -d '{
@@ -60,7 +216,9 @@ UPDATE_RESPONSE=$(curl -s -X PUT -H "Content-Type: application/json" -d '{
   "description": "This is an updated test product",
   "price": 2999,
   "stock": 50,
-  "category": "test-updated"
+  "category": "test-updated",
+  "average_rating": 4.5,
+  "review_count": 10
 }' $BASE_URL/products/$PRODUCT_ID)
 
 if [[ $UPDATE_RESPONSE == *"Updated Test Product"* ]]; then
@@ -69,6 +227,129 @@ else
   error "Failed to update product"
 fi
 
+# The rating aggregate is owned by the reviews system, so the update must not change it
+if [[ $UPDATE_RESPONSE == *'"average_rating":0'* && $UPDATE_RESPONSE == *'"review_count":0'* ]]; then
+  success "Product rating aggregate is read-only"
+else
+  error "Product update changed the rating aggregate: $UPDATE_RESPONSE"
+fi
+
+# An update sent with the version it read is applied and bumps the version
+echo "Updating the product at its version..."
+READ_VERSION=$(echo $UPDATE_RESPONSE | grep -o '"version":[0-9]*' | cut -d':' -f2)
+VERSIONED_UPDATE='{
+  "name": "Updated Test Product",
+  "description": "This is an updated test product, written at a version",
+  "price": 2999,
+  "stock": 50,
+  "category": "test-updated",
+  "version": '"$READ_VERSION"'
+}'
+VERSIONED_RESPONSE=$(curl -s -X PUT -H "Content-Type: application/json" -d "$VERSIONED_UPDATE" $BASE_URL/products/$PRODUCT_ID)
+
+if [[ -n "$READ_VERSION" && $VERSIONED_RESPONSE == *"\"version\":$((READ_VERSION + 1))"* ]]; then
+  success "Update at the current version applied and bumped the version"
+else
+  error "Failed to update at version $READ_VERSION: $VERSIONED_RESPONSE"
+fi
+
+# Replaying the update with the version it was based on is now stale
+STALE_UPDATE=${VERSIONED_UPDATE/written at a version/written at a stale version}
+STALE_STATUS=$(curl -s -o /dev/null -w "%{http_code}" -X PUT -H "Content-Type: application/json" -d "$STALE_UPDATE" $BASE_URL/products/$PRODUCT_ID)
+AFTER_STALE_RESPONSE=$(curl -s -X GET $BASE_URL/products/$PRODUCT_ID)
+
+if [ "$STALE_STATUS" -eq 409 ] && [[ $AFTER_STALE_RESPONSE == *"written at a version"* && $AFTER_STALE_RESPONSE == *"\"version\":$((READ_VERSION + 1))"* ]]; then
+  success "Update at a stale version rejected with 409 and not written"
+else
+  error "Stale update returned $STALE_STATUS: $AFTER_STALE_RESPONSE"
+fi
+
+# Set only the stock; running out moves the product out of stock and keeps its other fields
+echo "Updating the product stock..."
+STOCK_RESPONSE=$(curl -s -X PATCH -H "Content-Type: application/json" -d '{"stock": 0}' $BASE_URL/products/$PRODUCT_ID/stock)
+STOCK_UPDATED_AT=$(echo $STOCK_RESPONSE | grep -o '"updated_at":"[^"]*"')
+
+if [[ $STOCK_RESPONSE == *'"stock":0'* && $STOCK_RESPONSE == *'"status":3'* && $STOCK_RESPONSE == *"Updated Test Product"* && $STOCK_RESPONSE == *'"price":2999'* ]]; then
+  success "Product stock updated without touching other fields"
+else
+  error "Failed to update product stock: $STOCK_RESPONSE"
+fi
+
+# Setting the same stock again writes nothing, so updated_at stays
+SAME_STOCK_RESPONSE=$(curl -s -X PATCH -H "Content-Type: application/json" -d '{"stock": 0}' $BASE_URL/products/$PRODUCT_ID/stock)
+if [[ -n "$STOCK_UPDATED_AT" && $SAME_STOCK_RESPONSE == *"$STOCK_UPDATED_AT"* ]]; then
+  success "Unchanged stock kept updated_at"
+else
+  error "Unchanged stock bumped updated_at: $SAME_STOCK_RESPONSE"
+fi
+
+# Restocking makes the product active again
+RESTOCK_RESPONSE=$(curl -s -X PATCH -H "Content-Type: application/json" -d '{"stock": 50}' $BASE_URL/products/$PRODUCT_ID/stock)
+if [[ $RESTOCK_RESPONSE == *'"stock":50'* && $RESTOCK_RESPONSE == *'"status":1'* ]]; then
+  success "Restocked product is active again"
+else
+  error "Failed to restock product: $RESTOCK_RESPONSE"
+fi
+
+# A full update follows the stock too
+PUT_OUT_RESPONSE=$(curl -s -X PUT -H "Content-Type: application/json" -d '{
+  "name": "Updated Test Product",
+  "description": "This is an updated test product",
+  "price": 2999,
+  "stock": 0,
+  "category": "test-updated"
+}' $BASE_URL/products/$PRODUCT_ID)
+PUT_IN_RESPONSE=$(curl -s -X PUT -H "Content-Type: application/json" -d '{
+  "name": "Updated Test Product",
+  "description": "This is an updated test product",
+  "price": 2999,
+  "stock": 50,
+  "category": "test-updated"
+}' $BASE_URL/products/$PRODUCT_ID)
+if [[ $PUT_OUT_RESPONSE == *'"status":3'* && $PUT_IN_RESPONSE == *'"stock":50'* && $PUT_IN_RESPONSE == *'"status":1'* ]]; then
+  success "Full update moved the product out of stock and back"
+else
+  error "Full update did not follow the stock: $PUT_OUT_RESPONSE $PUT_IN_RESPONSE"
+fi
+
+# Negative stock is rejected
+NEGATIVE_STOCK_STATUS=$(curl -s -o /dev/null -w "%{http_code}" -X PATCH -H "Content-Type: application/json" -d '{"stock": -1}' $BASE_URL/products/$PRODUCT_ID/stock)
+if [[ $NEGATIVE_STOCK_STATUS == "400" ]]; then
+  success "Negative stock rejected"
+else
+  error "Expected 400 for negative stock, got $NEGATIVE_STOCK_STATUS"
+fi
+
+# Patch only the price; every other field is preserved
+echo "Patching the product..."
+PATCH_PRICE_RESPONSE=$(curl -s -X PATCH -H "Content-Type: application/json" -d '{"price": 2500}' $BASE_URL/products/$PRODUCT_ID)
+if [[ $PATCH_PRICE_RESPONSE == *'"price":2500'* && $PATCH_PRICE_RESPONSE == *"Updated Test Product"* && $PATCH_PRICE_RESPONSE == *"This is an updated test product"* && $PATCH_PRICE_RESPONSE == *'"stock":50'* && $PATCH_PRICE_RESPONSE == *'"category":"test-updated"'* ]]; then
+  success "Product price patched without touching other fields"
+else
+  error "Failed to patch product price: $PATCH_PRICE_RESPONSE"
+fi
+
+# Patch only the stock; the patched price stays
+PATCH_STOCK_RESPONSE=$(curl -s -X PATCH -H "Content-Type: application/json" -d '{"stock": 40}' $BASE_URL/products/$PRODUCT_ID)
+if [[ $PATCH_STOCK_RESPONSE == *'"stock":40'* && $PATCH_STOCK_RESPONSE == *'"price":2500'* && $PATCH_STOCK_RESPONSE == *"Updated Test Product"* ]]; then
+  success "Product stock patched without touching other fields"
+else
+  error "Failed to patch product stock: $PATCH_STOCK_RESPONSE"
+fi
+
+# Only present fields are validated: an empty name is rejected, as are an empty patch and unknown products
+PATCH_EMPTY_NAME_STATUS=$(curl -s -o /dev/null -w "%{http_code}" -X PATCH -H "Content-Type: application/json" -d '{"name": ""}' $BASE_URL/products/$PRODUCT_ID)
+PATCH_NOTHING_STATUS=$(curl -s -o /dev/null -w "%{http_code}" -X PATCH -H "Content-Type: application/json" -d '{}' $BASE_URL/products/$PRODUCT_ID)
+PATCH_MISSING_STATUS=$(curl -s -o /dev/null -w "%{http_code}" -X PATCH -H "Content-Type: application/json" -d '{"price": 100}' $BASE_URL/products/00000000-0000-4000-8000-000000000000)
+if [[ $PATCH_EMPTY_NAME_STATUS == "400" && $PATCH_NOTHING_STATUS == "400" && $PATCH_MISSING_STATUS == "404" ]]; then
+  success "Invalid patches rejected"
+else
+  error "Unexpected patch statuses: empty name=$PATCH_EMPTY_NAME_STATUS empty patch=$PATCH_NOTHING_STATUS missing=$PATCH_MISSING_STATUS"
+fi
+
+# Restore the price and stock the rest of the script expects
+curl -s -o /dev/null -X PATCH -H "Content-Type: application/json" -d '{"price": 2999, "stock": 50}' $BASE_URL/products/$PRODUCT_ID
+
 # List products
 echo "Listing products..."
 LIST_RESPONSE=$(curl -s -X GET "$BASE_URL/products?category=test-updated")
@@ -79,6 +360,191 @@ else
   error "Failed to list products"
 fi
 
+# List products sorted by price within a price range
+echo "Listing products by price..."
+PRICE_LIST_RESPONSE=$(curl -s -X GET "$BASE_URL/products?category=test-updated&sort_by=price&sort_dir=desc&min_price=2000&max_price=3000")
+
+if [[ $PRICE_LIST_RESPONSE == *"$PRODUCT_ID"* ]]; then
+  success "Products sorted and filtered by price"
+else
+  error "Failed to list products by price: $PRICE_LIST_RESPONSE"
+fi
+
+OUT_OF_RANGE_RESPONSE=$(curl -s -X GET "$BASE_URL/products?category=test-updated&sort_by=created_at&max_price=1000")
+
+if [[ $OUT_OF_RANGE_RESPONSE != *"$PRODUCT_ID"* ]]; then
+  success "Products outside the price range are excluded"
+else
+  error "Product listed outside the price range: $OUT_OF_RANGE_RESPONSE"
+fi
+
+# Count the products matching the filters across all pages, only when asked
+echo "Listing products with their total..."
+TOTAL_CATEGORY="total-$(date +%s)"
+curl -s -o /dev/null -X POST -H "Content-Type: application/json" -d "[
+  {\"name\": \"Total A\", \"description\": \"Counted\", \"price\": 500, \"stock\": 1, \"category\": \"$TOTAL_CATEGORY\"},
+  {\"name\": \"Total B\", \"description\": \"Counted\", \"price\": 900, \"stock\": 1, \"category\": \"$TOTAL_CATEGORY\"},
+  {\"name\": \"Total C\", \"description\": \"Counted\", \"price\": 5000, \"stock\": 1, \"category\": \"$TOTAL_CATEGORY\"}
+]" $BASE_URL/products/batch
+TOTAL_RESPONSE=$(curl -s "$BASE_URL/products?category=$TOTAL_CATEGORY&page_size=1&include_total=true")
+FILTERED_TOTAL_RESPONSE=$(curl -s "$BASE_URL/products?category=$TOTAL_CATEGORY&page_size=1&max_price=1000&include_total=true")
+NO_TOTAL_RESPONSE=$(curl -s "$BASE_URL/products?category=$TOTAL_CATEGORY&page_size=1")
+
+if [[ $TOTAL_RESPONSE == *'"total_size":3'* && $FILTERED_TOTAL_RESPONSE == *'"total_size":2'* && $NO_TOTAL_RESPONSE != *"total_size"* ]]; then
+  success "Total matches the filtered products and is omitted unless requested"
+else
+  error "Unexpected totals: all=$TOTAL_RESPONSE filtered=$FILTERED_TOTAL_RESPONSE none=$NO_TOTAL_RESPONSE"
+fi
+
+BAD_TOTAL_STATUS=$(curl -s -o /dev/null -w "%{http_code}" "$BASE_URL/products?include_total=maybe")
+if [ "$BAD_TOTAL_STATUS" -eq 400 ]; then
+  success "Malformed include_total rejected"
+else
+  error "Malformed include_total returned $BAD_TOTAL_STATUS"
+fi
+
+# Page through the products by price one at a time; each appears once, cheapest first
+echo "Paging products by price..."
+PAGED_PRICES=""
+PAGE_TOKEN=""
+while :; do
+  PAGE_RESPONSE=$(curl -s "$BASE_URL/products?category=$TOTAL_CATEGORY&sort_by=price&page_size=1&page_token=$PAGE_TOKEN")
+  PAGED_PRICES+="$(echo "$PAGE_RESPONSE" | grep -o '"price":[0-9]*' | cut -d: -f2) "
+  PAGE_TOKEN=$(echo "$PAGE_RESPONSE" | grep -o '"next_page_token":"[^"]*' | cut -d'"' -f4)
+  if [[ -z "$PAGE_TOKEN" ]]; then
+    break
+  fi
+done
+if [[ "$PAGED_PRICES" == "500 900 5000 " ]]; then
+  success "Price cursor returned every product once, in order"
+else
+  error "Unexpected prices across pages: $PAGED_PRICES"
+fi
+
+# A cached list page that parses but holds products without IDs is discarded and re-read
+if command -v redis-cli >/dev/null 2>&1; then
+  echo "Listing products over a corrupt cache entry..."
+  CORRUPT_KEY="category:$TOTAL_CATEGORY:10:..-:"
+  redis-cli set "$CORRUPT_KEY" '{"Products":[{"name":"stale"}],"NextPageToken":""}' >/dev/null
+  CORRUPT_LIST_RESPONSE=$(curl -s "$BASE_URL/products?category=$TOTAL_CATEGORY")
+  if [[ $(echo "$CORRUPT_LIST_RESPONSE" | grep -o '"id":"[^"]\+"' | wc -l) -eq 3 && $(redis-cli get "$CORRUPT_KEY") != *'"stale"'* ]]; then
+    success "Corrupt cached product list replaced from the database"
+  else
+    error "Corrupt cached product list served: $CORRUPT_LIST_RESPONSE"
+  fi
+fi
+
+# A token for one sort order must not be reused with another, and garbage tokens are rejected
+PRICE_TOKEN=$(curl -s "$BASE_URL/products?category=$TOTAL_CATEGORY&sort_by=price&page_size=1" | grep -o '"next_page_token":"[^"]*' | cut -d'"' -f4)
+MISMATCH_TOKEN_STATUS=$(curl -s -o /dev/null -w "%{http_code}" "$BASE_URL/products?category=$TOTAL_CATEGORY&sort_by=name&page_token=$PRICE_TOKEN")
+REVERSED_TOKEN_STATUS=$(curl -s -o /dev/null -w "%{http_code}" "$BASE_URL/products?category=$TOTAL_CATEGORY&sort_by=price&sort_dir=desc&page_token=$PRICE_TOKEN")
+MALFORMED_TOKEN_STATUS=$(curl -s -o /dev/null -w "%{http_code}" "$BASE_URL/products?page_token=not-a-cursor")
+if [ "$MISMATCH_TOKEN_STATUS" -eq 400 ] && [ "$REVERSED_TOKEN_STATUS" -eq 400 ] && [ "$MALFORMED_TOKEN_STATUS" -eq 400 ]; then
+  success "Page tokens for another sort order or direction and malformed page tokens rejected"
+else
+  error "Expected 400 for mismatched, reversed and malformed page tokens, got $MISMATCH_TOKEN_STATUS, $REVERSED_TOKEN_STATUS and $MALFORMED_TOKEN_STATUS"
+fi
+
+# Page sizes are defaulted, clamped to paging.maxPageSize and must be integers
+DEFAULT_PAGE_COUNT=$(curl -s "$BASE_URL/products" | grep -o '"created_at"' | wc -l)
+HUGE_PAGE_STATUS=$(curl -s -o /dev/null -w "%{http_code}" "$BASE_URL/products?page_size=1000000")
+HUGE_PAGE_COUNT=$(curl -s "$BASE_URL/products?page_size=1000000" | grep -o '"created_at"' | wc -l)
+BAD_PAGE_STATUS=$(curl -s -o /dev/null -w "%{http_code}" "$BASE_URL/products?page_size=ten")
+BAD_SEARCH_PAGE_STATUS=$(curl -s -o /dev/null -w "%{http_code}" "$BASE_URL/products/search?q=product&page_size=ten")
+if [ "$DEFAULT_PAGE_COUNT" -le 10 ] && [ "$HUGE_PAGE_STATUS" -eq 200 ] && [ "$HUGE_PAGE_COUNT" -le 100 ] && [ "$BAD_PAGE_STATUS" -eq 400 ] && [ "$BAD_SEARCH_PAGE_STATUS" -eq 400 ]; then
+  success "Product page sizes defaulted, clamped and validated"
+else
+  error "Unexpected page size handling: default=$DEFAULT_PAGE_COUNT huge=$HUGE_PAGE_STATUS/$HUGE_PAGE_COUNT bad=$BAD_PAGE_STATUS search=$BAD_SEARCH_PAGE_STATUS"
+fi
+
+BAD_SORT_STATUS=$(curl -s -o /dev/null -w "%{http_code}" -X GET "$BASE_URL/products?sort_by=stock")
+
+if [ "$BAD_SORT_STATUS" -eq 400 ]; then
+  success "Unknown sort rejected"
+else
+  error "Unknown sort returned $BAD_SORT_STATUS"
+fi
+
+# Search products by name and description
+echo "Searching products..."
+SEARCH_RESPONSE=$(curl -s -X GET "$BASE_URL/products/search?q=updated+product")
+
+if [[ $SEARCH_RESPONSE == *"$PRODUCT_ID"* ]]; then
+  success "Product found by full-text search"
+else
+  error "Product not found by search: $SEARCH_RESPONSE"
+fi
+
+EMPTY_SEARCH_STATUS=$(curl -s -o /dev/null -w "%{http_code}" -X GET "$BASE_URL/products/search?q=")
+
+if [ "$EMPTY_SEARCH_STATUS" -eq 400 ]; then
+  success "Empty search query rejected"
+else
+  error "Empty search query returned $EMPTY_SEARCH_STATUS"
+fi
+
+# Export the inventory (requires the admin API key)
+echo "Exporting the inventory..."
+INVENTORY_RESPONSE=$(curl -s -X GET -H "X-API-Key: $ADMIN_API_KEY" "$BASE_URL/admin/products/inventory.csv?category=test-updated")
+
+if [[ $(echo "$INVENTORY_RESPONSE" | head -n 1) == "id,sku,name,stock,status" && $INVENTORY_RESPONSE == *"$PRODUCT_ID"* ]]; then
+  success "Inventory exported successfully"
+else
+  error "Failed to export inventory"
+fi
+
+# Deactivate and reactivate a whole category (requires the admin API key)
+echo "Setting the status of a category..."
+SEASONAL_CATEGORY="seasonal-$(date +%s)"
+SEASONAL_RESPONSE=$(curl -s -X POST -H "Content-Type: application/json" -d "[
+  {\"name\": \"Seasonal A\", \"description\": \"In stock\", \"price\": 500, \"stock\": 1, \"category\": \"$SEASONAL_CATEGORY\"},
+  {\"name\": \"Seasonal B\", \"description\": \"Sold out\", \"price\": 500, \"stock\": 0, \"category\": \"$SEASONAL_CATEGORY\"}
+]" $BASE_URL/products/batch)
+SEASONAL_ID=$(echo $SEASONAL_RESPONSE | grep -o '"id":"[^"]*' | head -n 1 | cut -d'"' -f4)
+
+# Read the product and the category first, so both are cached
+curl -s -o /dev/null "$BASE_URL/products/$SEASONAL_ID"
+curl -s -o /dev/null "$BASE_URL/products?category=$SEASONAL_CATEGORY"
+
+UNAUTHORIZED_STATUS=$(curl -s -o /dev/null -w "%{http_code}" -X POST -H "Content-Type: application/json" -d '{"status": 2}' "$BASE_URL/admin/products/category/$SEASONAL_CATEGORY/status")
+DEACTIVATE_RESPONSE=$(curl -s -X POST -H "X-API-Key: $ADMIN_API_KEY" -H "Content-Type: application/json" -d '{"status": 2}' "$BASE_URL/admin/products/category/$SEASONAL_CATEGORY/status")
+DEACTIVATED_PRODUCT=$(curl -s "$BASE_URL/products/$SEASONAL_ID")
+DEACTIVATED_LIST=$(curl -s "$BASE_URL/products?category=$SEASONAL_CATEGORY")
+
+if [[ $UNAUTHORIZED_STATUS != "200" && $DEACTIVATE_RESPONSE == *'"affected":2'* && $DEACTIVATED_PRODUCT == *'"status":2'* && $(echo "$DEACTIVATED_LIST" | grep -o '"status":2' | wc -l) -eq 2 ]]; then
+  success "Category deactivated and its cached product and list refreshed"
+else
+  error "Failed to deactivate the category (unauthenticated $UNAUTHORIZED_STATUS): $DEACTIVATE_RESPONSE $DEACTIVATED_PRODUCT $DEACTIVATED_LIST"
+fi
+
+# An inactive product stays inactive when it runs out and is restocked
+INACTIVE_EMPTY_RESPONSE=$(curl -s -X PATCH -H "Content-Type: application/json" -d '{"stock": 0}' $BASE_URL/products/$SEASONAL_ID/stock)
+INACTIVE_RESTOCK_RESPONSE=$(curl -s -X PATCH -H "Content-Type: application/json" -d '{"stock": 1}' $BASE_URL/products/$SEASONAL_ID/stock)
+if [[ $INACTIVE_EMPTY_RESPONSE == *'"status":2'* && $INACTIVE_RESTOCK_RESPONSE == *'"stock":1'* && $INACTIVE_RESTOCK_RESPONSE == *'"status":2'* ]]; then
+  success "Inactive product stayed inactive through a restock"
+else
+  error "Inactive product changed status with its stock: $INACTIVE_EMPTY_RESPONSE $INACTIVE_RESTOCK_RESPONSE"
+fi
+
+# Products already at the status are not counted; activating follows the stock
+REPEAT_RESPONSE=$(curl -s -X POST -H "X-API-Key: $ADMIN_API_KEY" -H "Content-Type: application/json" -d '{"status": 2}' "$BASE_URL/admin/products/category/$SEASONAL_CATEGORY/status")
+ACTIVATE_RESPONSE=$(curl -s -X POST -H "X-API-Key: $ADMIN_API_KEY" -H "Content-Type: application/json" -d '{"status": 1}' "$BASE_URL/admin/products/category/$SEASONAL_CATEGORY/status")
+ACTIVATED_LIST=$(curl -s "$BASE_URL/products?category=$SEASONAL_CATEGORY")
+
+if [[ $REPEAT_RESPONSE == *'"affected":0'* && $ACTIVATE_RESPONSE == *'"affected":2'* && $ACTIVATED_LIST == *'"status":1'* && $ACTIVATED_LIST == *'"status":3'* ]]; then
+  success "Category reactivated, with the sold out product out of stock"
+else
+  error "Failed to reactivate the category: $REPEAT_RESPONSE $ACTIVATE_RESPONSE $ACTIVATED_LIST"
+fi
+
+BAD_STATUS=$(curl -s -o /dev/null -w "%{http_code}" -X POST -H "X-API-Key: $ADMIN_API_KEY" -H "Content-Type: application/json" -d '{"status": 3}' "$BASE_URL/admin/products/category/$SEASONAL_CATEGORY/status")
+
+if [ "$BAD_STATUS" -eq 400 ]; then
+  success "Out of stock rejected as a category status"
+else
+  error "Out of stock category status returned $BAD_STATUS"
+fi
+
 # Delete the product
 echo "Deleting the product..."
 DELETE_RESPONSE=$(curl -s -X DELETE -w "%{http_code}" $BASE_URL/products/$PRODUCT_ID -o /dev/null)
@@ -93,10 +559,54 @@ fi
 echo "Verifying deletion..."
 GET_DELETED_RESPONSE=$(curl -s -X GET -w "%{http_code}" $BASE_URL/products/$PRODUCT_ID -o /dev/null)
 
-if [ "$GET_DELETED_RESPONSE" -eq 404 ]; then
-  success "Product deletion verified"
+# Deleted products answer 410 when product.goneForDeleted is enabled, 404 otherwise
+EXPECTED_DELETED_STATUS=404
+if [ "$GONE_FOR_DELETED" = "true" ]; then
+  EXPECTED_DELETED_STATUS=410
+fi
+
+if [ "$GET_DELETED_RESPONSE" -eq "$EXPECTED_DELETED_STATUS" ]; then
+  success "Product deletion verified ($GET_DELETED_RESPONSE)"
 else
-  error "Product still exists after deletion"
+  error "Deleted product returned $GET_DELETED_RESPONSE, expected $EXPECTED_DELETED_STATUS"
 fi
 
-echo "All tests passed!"
\ No newline at end of file
+MISSING_RESPONSE=$(curl -s -X GET -w "%{http_code}" $BASE_URL/products/no-such-product -o /dev/null)
+
+if [ "$MISSING_RESPONSE" -eq 404 ]; then
+  success "Unknown product returns 404"
+else
+  error "Unknown product returned $MISSING_RESPONSE"
+fi
+
+# Deleted products are soft-deleted and must not be listed
+LIST_DELETED_RESPONSE=$(curl -s -X GET "$BASE_URL/products?category=test-updated")
+
+if [[ $LIST_DELETED_RESPONSE != *"$PRODUCT_ID"* ]]; then
+  success "Deleted product is hidden from listings"
+else
+  error "Deleted product is still listed: $LIST_DELETED_RESPONSE"
+fi
+
+echo "All tests passed!"
+# Delete the batch products and an unknown ID at once (requires the admin API key); the
+# unknown ID is reported and the others are deleted
+echo "Deleting a mixed batch of products..."
+BATCH_ID_LIST=$(echo "$BATCH_RESPONSE" | grep -o '"id":"[^"]*' | cut -d'"' -f4 | sed 's/.*/"&"/' | paste -sd, -)
+BULK_DELETE_RESPONSE=$(curl -s -w "\n%{http_code}" -X DELETE -H "X-API-Key: $ADMIN_API_KEY" -H "Content-Type: application/json" -d "{\"ids\": [$BATCH_ID_LIST, \"no-such-product\"]}" "$BASE_URL/products")
+BULK_DELETE_STATUS=$(echo "$BULK_DELETE_RESPONSE" | tail -n1)
+BATCH_LIST_AFTER_DELETE=$(curl -s "$BASE_URL/products?category=$BATCH_CATEGORY")
+
+if [[ "$BULK_DELETE_STATUS" == "200" && $BULK_DELETE_RESPONSE == *'"deleted":2'* && $BULK_DELETE_RESPONSE == *'"no-such-product"'* && $BATCH_LIST_AFTER_DELETE != *"Batch Product"* ]]; then
+  success "Mixed batch deleted with the unknown ID reported"
+else
+  error "Bulk delete failed: $BULK_DELETE_RESPONSE / $BATCH_LIST_AFTER_DELETE"
+fi
+
+EMPTY_BULK_DELETE_STATUS=$(curl -s -o /dev/null -w "%{http_code}" -X DELETE -H "X-API-Key: $ADMIN_API_KEY" -H "Content-Type: application/json" -d '{"ids": []}' "$BASE_URL/products")
+
+if [ "$EMPTY_BULK_DELETE_STATUS" -eq 400 ]; then
+  success "Empty bulk delete rejected"
+else
+  error "Empty bulk delete returned $EMPTY_BULK_DELETE_STATUS"
+fi